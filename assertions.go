@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golem/ui"
+)
+
+// evaluateAssertions checks each assertion against response, in order.
+func evaluateAssertions(assertions []ui.Assertion, response *ResponseInfo) []ui.AssertionResult {
+	results := make([]ui.AssertionResult, len(assertions))
+	for i, assertion := range assertions {
+		results[i] = evaluateAssertion(assertion, response)
+	}
+	return results
+}
+
+func evaluateAssertion(assertion ui.Assertion, response *ResponseInfo) ui.AssertionResult {
+	result := ui.AssertionResult{Assertion: assertion}
+
+	// actual holds every value found; the assertion passes if any matches
+	var actual []string
+	switch assertion.Type {
+	case ui.AssertStatus:
+		actual = []string{strconv.Itoa(response.StatusCode)}
+	case ui.AssertHeader:
+		for _, header := range response.Headers {
+			if strings.EqualFold(header.Key, assertion.Target) {
+				actual = append(actual, header.Value)
+			}
+		}
+	case ui.AssertJSONPath:
+		values, err := evaluateJSONPath(response.Body, assertion.Target)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		for _, value := range values {
+			actual = append(actual, jsonValueText(value))
+		}
+	case ui.AssertBody:
+		actual = []string{response.Body}
+	case ui.AssertTime:
+		actual = []string{strconv.FormatInt(response.ResponseTime.Milliseconds(), 10)}
+	default:
+		result.Message = fmt.Sprintf("unknown assertion type %q", assertion.Type)
+		return result
+	}
+
+	if len(actual) > 0 && assertion.Type != ui.AssertBody {
+		result.Actual = strings.Join(actual, ", ")
+	}
+
+	if assertion.Operator == ui.OperatorExists {
+		result.Passed = len(actual) > 0
+		if !result.Passed {
+			result.Message = "not found"
+		}
+		return result
+	}
+	if len(actual) == 0 {
+		result.Message = "not found"
+		return result
+	}
+
+	var err error
+	for _, value := range actual {
+		var passed bool
+		if passed, err = compare(value, assertion.Operator, assertion.Expected); passed {
+			result.Passed = true
+			return result
+		}
+	}
+	if err != nil {
+		result.Message = err.Error()
+	} else if assertion.Type == ui.AssertBody {
+		result.Message = fmt.Sprintf("body does not satisfy %s %q", assertion.Operator, assertion.Expected)
+	} else {
+		result.Message = fmt.Sprintf("got %s", result.Actual)
+	}
+	return result
+}
+
+// compare applies operator to actual and expected. The ordering operators
+// compare numbers.
+func compare(actual, operator, expected string) (bool, error) {
+	switch operator {
+	case ui.OperatorEquals:
+		return actual == expected, nil
+	case ui.OperatorNotEquals:
+		return actual != expected, nil
+	case ui.OperatorContains:
+		return strings.Contains(actual, expected), nil
+	case ui.OperatorNotContains:
+		return !strings.Contains(actual, expected), nil
+	case ui.OperatorMatches:
+		pattern, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return pattern.MatchString(actual), nil
+	case ui.OperatorLess, ui.OperatorLessOrEqual, ui.OperatorGreater, ui.OperatorGreaterOrEqual:
+		a, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+		if err != nil {
+			return false, fmt.Errorf("got %q, not a number", actual)
+		}
+		e, err := strconv.ParseFloat(strings.TrimSpace(expected), 64)
+		if err != nil {
+			return false, fmt.Errorf("expected %q is not a number", expected)
+		}
+		switch operator {
+		case ui.OperatorLess:
+			return a < e, nil
+		case ui.OperatorLessOrEqual:
+			return a <= e, nil
+		case ui.OperatorGreater:
+			return a > e, nil
+		default:
+			return a >= e, nil
+		}
+	}
+	return false, fmt.Errorf("unknown operator %q", operator)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golem/ui"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Uploads smaller than this finish too quickly for a progress bar to be useful
+const uploadProgressThreshold = 4 << 20
+
+type requestBody struct {
+	reader        io.Reader
+	contentType   string
+	contentLength int64
+}
+
+// buildRequestBody prepares the body for the request's body type. File
+// contents are streamed rather than loaded into memory.
+func buildRequestBody(request *RequestInfo) (*requestBody, error) {
+	switch request.BodyType {
+	case ui.BodyTypeMultipart:
+		return buildMultipartBody(request.FormFields, request.OnUploadProgress)
+	case ui.BodyTypeBinary:
+		return buildFileBody(request.BodyFile, request.OnUploadProgress)
+	default:
+		if request.Body == "" {
+			return &requestBody{}, nil
+		}
+		length := int64(len(request.Body))
+		var reader io.Reader = strings.NewReader(request.Body)
+		if request.OnUploadProgress != nil && length > uploadProgressThreshold {
+			reader = &progressReader{reader: reader, total: length, onProgress: request.OnUploadProgress}
+		}
+		return &requestBody{
+			reader:        reader,
+			contentLength: length,
+		}, nil
+	}
+}
+
+func buildFileBody(path string, onProgress func(sent, total int64, rate float64)) (*requestBody, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no file selected for the request body")
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("body file %s no longer exists; choose it again", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read body file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot read body file: %w", err)
+	}
+
+	var reader io.Reader = file
+	if onProgress != nil && info.Size() > uploadProgressThreshold {
+		reader = &progressReader{reader: file, total: info.Size(), onProgress: onProgress}
+	}
+
+	return &requestBody{
+		reader:        reader,
+		contentLength: info.Size(),
+	}, nil
+}
+
+func buildMultipartBody(fields []ui.FormField, onProgress func(sent, total int64, rate float64)) (*requestBody, error) {
+	// Check every file up front so a missing file is reported before sending
+	fileSizes := make(map[string]int64)
+	for _, field := range fields {
+		if !field.IsFile {
+			continue
+		}
+		if field.Value == "" {
+			return nil, fmt.Errorf("no file selected for form field %q", field.Key)
+		}
+		info, err := os.Stat(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file for form field %q: %w", field.Key, err)
+		}
+		fileSizes[field.Value] = info.Size()
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+
+	length, err := multipartLength(fields, fileSizes, writer.Boundary())
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		pipeWriter.CloseWithError(writeMultipartFields(writer, fields))
+	}()
+
+	var reader io.Reader = pipeReader
+	if onProgress != nil && length > uploadProgressThreshold {
+		reader = &progressReader{reader: pipeReader, total: length, onProgress: onProgress}
+	}
+
+	return &requestBody{
+		reader:        reader,
+		contentType:   writer.FormDataContentType(),
+		contentLength: length,
+	}, nil
+}
+
+func writeMultipartFields(writer *multipart.Writer, fields []ui.FormField) error {
+	for _, field := range fields {
+		if !field.IsFile {
+			if err := writer.WriteField(field.Key, field.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		part, err := writer.CreateFormFile(field.Key, filepath.Base(field.Value))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(field.Value)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// multipartLength computes the encoded size of the form without reading any
+// file, so the request can be sent with an exact Content-Length.
+func multipartLength(fields []ui.FormField, fileSizes map[string]int64, boundary string) (int64, error) {
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, field := range fields {
+		if !field.IsFile {
+			if err := writer.WriteField(field.Key, field.Value); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if _, err := writer.CreateFormFile(field.Key, filepath.Base(field.Value)); err != nil {
+			return 0, err
+		}
+		total += fileSizes[field.Value]
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	return total + counter.n, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// progressInterval is how often a transfer in progress is reported.
+const progressInterval = 250 * time.Millisecond
+
+// progressReader reports how much of a body has been read, of total bytes
+// or -1 when that is not known, and the rate in bytes per second, at most
+// every progressInterval. A body read before the first report is never
+// reported, so no progress bar flashes up for it; one that was is reported
+// once more when it ends.
+type progressReader struct {
+	reader     io.Reader
+	sent       int64
+	total      int64
+	onProgress func(sent, total int64, rate float64)
+
+	lastReport time.Time
+	lastSent   int64
+	rate       float64
+	reported   bool
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	now := time.Now()
+	// The rate is timed from the first bytes, not the wait for them
+	if r.lastReport.IsZero() {
+		r.lastReport = now
+	}
+	r.sent += int64(n)
+
+	elapsed := now.Sub(r.lastReport)
+	if elapsed < progressInterval && (err == nil || !r.reported) {
+		return n, err
+	}
+	if elapsed >= progressInterval {
+		rate := float64(r.sent-r.lastSent) / elapsed.Seconds()
+		// Smoothed, so the rate shown does not jump with every report
+		if r.reported {
+			rate = 0.7*r.rate + 0.3*rate
+		}
+		r.rate, r.lastReport, r.lastSent = rate, now, r.sent
+	}
+	r.reported = true
+	r.onProgress(r.sent, r.total, r.rate)
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	if closer, ok := r.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// wrongLengthMaxWait bounds how long a body shorter than the Content-Length
+// announced for it holds the request open when there is no timeout.
+const wrongLengthMaxWait = time.Minute
+
+// wrongLengthBody returns body for sending with a Content-Length of length
+// instead of its own, for fault injection. With a larger length the whole
+// body is sent and the request then held open, so the server waits for
+// bytes that never come, until ctx ends or the timeout passes. With a
+// smaller one only that many bytes go out, as net/http never writes past
+// the Content-Length.
+func wrongLengthBody(ctx context.Context, body *requestBody, length int64, timeout time.Duration) io.ReadCloser {
+	var reader io.Reader = strings.NewReader("")
+	if body.reader != nil {
+		reader = body.reader
+	}
+	closer, _ := reader.(io.Closer)
+
+	if length <= body.contentLength {
+		reader = io.LimitReader(reader, length)
+	} else {
+		if timeout <= 0 {
+			timeout = wrongLengthMaxWait
+		}
+		hold := &holdReader{ctx: ctx, timeout: timeout, closed: make(chan struct{})}
+		reader = io.MultiReader(reader, hold)
+		closer = closerFunc(func() error {
+			hold.once.Do(func() { close(hold.closed) })
+			if body.reader != nil {
+				if c, ok := body.reader.(io.Closer); ok {
+					return c.Close()
+				}
+			}
+			return nil
+		})
+	}
+	if closer == nil {
+		return io.NopCloser(reader)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, closer}
+}
+
+// holdReader blocks until ctx ends, the timeout passes or it is closed, and
+// then ends the body.
+type holdReader struct {
+	ctx     context.Context
+	timeout time.Duration
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (r *holdReader) Read(p []byte) (int, error) {
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+	select {
+	case <-r.ctx.Done():
+	case <-timer.C:
+	case <-r.closed:
+	}
+	return 0, io.EOF
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// describeProgress describes a transfer in progress for its progress bar,
+// e.g. "Receiving: 2.0 MB of 8.0 MB, 1.5 MB/s". total is -1 when the size
+// is not known.
+func describeProgress(verb string, done, total int64, rate float64) string {
+	text := verb + ": " + ui.FormatBytes(done)
+	if total >= 0 {
+		text += " of " + ui.FormatBytes(total)
+	}
+	if rate > 0 {
+		text += ", " + ui.FormatBytes(int64(rate)) + "/s"
+	}
+	return text
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golem/protobuf"
+	"golem/ui"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// charsetNames are offered to decode a response body with when its server
+// declares the wrong charset.
+var charsetNames = []string{
+	"UTF-8", "ISO-8859-1", "ISO-8859-15", "windows-1252", "windows-1251", "KOI8-R",
+	"Shift_JIS", "EUC-JP", "ISO-2022-JP", "GBK", "GB18030", "Big5", "EUC-KR",
+	"UTF-16LE", "UTF-16BE",
+}
+
+// metaCharsetPattern finds the charset of an HTML page in a
+// <meta charset="..."> tag or a <meta http-equiv="Content-Type"
+// content="text/html; charset=..."> one.
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([\w.:-]+)`)
+
+// metaScanSize is how much of an HTML page is searched for a <meta> charset,
+// as browsers do.
+const metaScanSize = 1024
+
+// responseCharset returns the charset a response body declares: by a byte
+// order mark for HTML, the charset parameter of its Content-Type, or a
+// <meta> tag for HTML, in that order. "" means none does, and the body is
+// taken to be UTF-8. Images and protobuf messages are binary, so have none.
+func responseCharset(contentType string, body []byte) string {
+	if ui.IsImageContentType(contentType) && !ui.IsXMLContentType(contentType) || protobuf.IsContentType(contentType) {
+		return ""
+	}
+	html := ui.IsHTMLContentType(contentType)
+	if html {
+		switch {
+		case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+			return "UTF-8"
+		case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+			return "UTF-16LE"
+		case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+			return "UTF-16BE"
+		}
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return params["charset"]
+	}
+	if html {
+		if match := metaCharsetPattern.FindSubmatch(body[:min(len(body), metaScanSize)]); match != nil {
+			return string(match[1])
+		}
+	}
+	return ""
+}
+
+// isKnownCharset reports whether bodies in the charset name can be decoded.
+func isKnownCharset(name string) bool {
+	_, err := htmlindex.Get(name)
+	return err == nil
+}
+
+// decodeBodyText returns body decoded from charset, or as it is when
+// charset is "" or unknown.
+func decodeBodyText(body []byte, charset string) string {
+	if charset != "" {
+		if decoded, err := decodeCharset(body, charset); err == nil {
+			return decoded
+		}
+	}
+	return string(body)
+}
+
+// decodeCharset returns body decoded from the charset name to UTF-8, as
+// browsers decode it: ISO-8859-1 is read as windows-1252, for example.
+// Bytes that are not valid in the charset become U+FFFD.
+func decodeCharset(body []byte, name string) (string, error) {
+	encoding, err := htmlindex.Get(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown charset %q", name)
+	}
+	if encoding == unicode.UTF8 {
+		return string(body), nil
+	}
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", fmt.Errorf("could not decode %s: %w", name, err)
+	}
+	return strings.TrimPrefix(string(decoded), "\uFEFF"), nil
+}
+
+// storedResponseBody returns the body of response to record in history and
+// the charset it is in: the bytes as received when it declares a charset, so
+// that it is decoded the same way again and the mock server replays it
+// unchanged.
+func storedResponseBody(response *ResponseInfo) (body, charset string) {
+	if response.Charset == "" || response.RawBody == nil {
+		return response.Body, ""
+	}
+	return string(response.RawBody), response.Charset
+}
@@ -0,0 +1,145 @@
+// Package codegen renders a request as a ready-to-run snippet in another
+// language or tool. Each language is a template in templates/ with a quoting
+// function for its string literals, so adding one takes a template and an
+// entry in languages.
+package codegen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Header is a request header; repeated names are sent as separate headers
+// where the language allows it.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// FormField is one part of a multipart form. For file fields Value is the
+// path of the file.
+type FormField struct {
+	Name   string
+	Value  string
+	IsFile bool
+}
+
+// Request is the request to render. Values are used as they are, so
+// {{variable}} placeholders end up in the snippet unresolved. At most one
+// of Body, BodyFile and Form is set.
+type Request struct {
+	Method  string
+	URL     string
+	Headers []Header
+
+	Body     string
+	BodyFile string
+	Form     []FormField
+
+	// BasicAuth sends Username and Password; other schemes are headers
+	BasicAuth bool
+	Username  string
+	Password  string
+}
+
+type language struct {
+	name  string
+	file  string
+	quote func(string) string
+	// format tidies the output, or is nil
+	format func([]byte) ([]byte, error)
+}
+
+var languages = []language{
+	{name: "curl", file: "curl.tmpl", quote: shellQuote},
+	{name: "Go (net/http)", file: "go.tmpl", quote: goQuote, format: format.Source},
+	{name: "Python (requests)", file: "python.tmpl", quote: jsonQuote},
+	{name: "JavaScript (fetch)", file: "javascript.tmpl", quote: jsonQuote},
+}
+
+// Languages lists the names Generate accepts, in the order to offer them.
+func Languages() []string {
+	names := make([]string, len(languages))
+	for i, l := range languages {
+		names[i] = l.name
+	}
+	return names
+}
+
+// Generate renders request in the named language.
+func Generate(name string, request Request) (string, error) {
+	for _, l := range languages {
+		if l.name == name {
+			return l.generate(request)
+		}
+	}
+	return "", fmt.Errorf("no code generator for %s", name)
+}
+
+func (l language) generate(request Request) (string, error) {
+	tmpl, err := template.New(l.file).Funcs(template.FuncMap{
+		"quote": l.quote,
+		"base":  filepath.Base,
+		"join": func(parts ...string) string {
+			return strings.Join(parts, "")
+		},
+		"hasFiles": func(fields []FormField) bool {
+			for _, field := range fields {
+				if field.IsFile {
+					return true
+				}
+			}
+			return false
+		},
+	}).ParseFS(templateFiles, "templates/"+l.file)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, request); err != nil {
+		return "", err
+	}
+	if l.format == nil {
+		return out.String(), nil
+	}
+	formatted, err := l.format(out.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("generated %s does not parse: %w", l.name, err)
+	}
+	return string(formatted), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// goQuote prefers a raw string literal for text with line breaks, such as
+// a JSON body.
+func goQuote(s string) string {
+	if strings.Contains(s, "\n") && !strings.ContainsAny(s, "`\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// jsonQuote quotes s as a JSON string, which is also a valid Python and
+// JavaScript string literal.
+func jsonQuote(s string) string {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(out.String(), "\n")
+}
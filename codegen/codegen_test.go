@@ -0,0 +1,106 @@
+package codegen
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var goldenRequests = []struct {
+	name    string
+	request Request
+}{
+	{"get", Request{
+		Method:  "GET",
+		URL:     "https://api.example.com/users?page=2&q=it's",
+		Headers: []Header{{Name: "Accept", Value: "application/json"}, {Name: "X-Trace", Value: "{{traceId}}"}},
+	}},
+	{"json_body", Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/users",
+		Headers: []Header{{Name: "Content-Type", Value: "application/json"}},
+		Body:    "{\n  \"name\": \"Ada\",\n  \"bio\": \"says \\\"hi\\\"\"\n}",
+	}},
+	{"backtick_body", Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/markdown",
+		Headers: []Header{{Name: "Content-Type", Value: "text/markdown"}},
+		Body:    "Run `go test`\nthen ship it",
+	}},
+	{"body_file", Request{
+		Method:   "PUT",
+		URL:      "https://api.example.com/upload",
+		Headers:  []Header{{Name: "Content-Type", Value: "application/octet-stream"}},
+		BodyFile: "/tmp/data files/report.bin",
+	}},
+	{"form_files", Request{
+		Method: "POST",
+		URL:    "https://api.example.com/form",
+		Form: []FormField{
+			{Name: "title", Value: "Quarterly report"},
+			{Name: "file", Value: "/home/me/report.pdf", IsFile: true},
+		},
+	}},
+	{"basic_auth", Request{
+		Method:    "DELETE",
+		URL:       "https://api.example.com/users/7",
+		BasicAuth: true,
+		Username:  "admin",
+		Password:  "p@ss'word",
+	}},
+}
+
+// TestGenerateGolden renders each request in each language and compares it
+// with testdata/<request>.<template>.golden; run with -update to rewrite them.
+func TestGenerateGolden(t *testing.T) {
+	for _, l := range languages {
+		for _, tc := range goldenRequests {
+			golden := filepath.Join("testdata", tc.name+"."+strings.TrimSuffix(l.file, ".tmpl")+".golden")
+			t.Run(filepath.Base(golden), func(t *testing.T) {
+				got, err := Generate(l.name, tc.request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *update {
+					if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(want) {
+					t.Errorf("output differs from %s:\n--- got\n%s\n--- want\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateUnknownLanguage(t *testing.T) {
+	if _, err := Generate("COBOL", Request{Method: "GET", URL: "https://example.com"}); err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+}
+
+func TestGoQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", `"plain"`},
+		{"two\nlines", "`two\nlines`"},
+		{"a `tick`\nand a line", "\"a `tick`\\nand a line\""},
+		{"crlf\r\nline", `"crlf\r\nline"`},
+	}
+	for _, tc := range tests {
+		if got := goQuote(tc.in); got != tc.want {
+			t.Errorf("goQuote(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// maxDiffCells bounds the line diff table; larger bodies are compared line
+// by line at the same position instead.
+const maxDiffCells = 4_000_000
+
+// newComparisonID returns a random ID linking the history entries of one
+// environment comparison.
+func newComparisonID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// comparableBody returns body as the lines to diff. JSON is re-encoded with
+// sorted keys and fixed indentation, so key order and formatting do not
+// show up as changes.
+func comparableBody(body string) (lines []string, structural bool) {
+	trimmed := strings.TrimSpace(body)
+	if trimmed != "" && json.Valid([]byte(trimmed)) {
+		decoder := json.NewDecoder(strings.NewReader(trimmed))
+		decoder.UseNumber()
+		var value interface{}
+		if decoder.Decode(&value) == nil {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if encoder.Encode(value) == nil {
+				return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"), true
+			}
+		}
+	}
+	return bodyLines(body), false
+}
+
+func bodyLines(body string) []string {
+	if body == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(body, "\n"), "\n")
+}
+
+// comparableHeaders returns the headers as sorted "Name: value" lines.
+func comparableHeaders(headers []ResponseHeader) []string {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		lines[i] = header.Key + ": " + header.Value
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// diffLines aligns a and b side by side. Lines in the longest common
+// subsequence share a row; the lines between them are paired up as changed
+// rows, with an empty side where one has more lines than the other.
+func diffLines(a, b []string) []ui.DiffRow {
+	if len(a)*len(b) > maxDiffCells {
+		var rows []ui.DiffRow
+		for i := 0; i < len(a) || i < len(b); i++ {
+			row := ui.DiffRow{Left: lineAt(a, i), Right: lineAt(b, i)}
+			row.Changed = i >= len(a) || i >= len(b) || a[i] != b[i]
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var rows []ui.DiffRow
+	var removed, added []string
+	flush := func() {
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			rows = append(rows, ui.DiffRow{Left: lineAt(removed, k), Right: lineAt(added, k), Changed: true})
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			flush()
+			rows = append(rows, ui.DiffRow{Left: a[i], Right: b[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	removed = append(removed, a[i:]...)
+	added = append(added, b[j:]...)
+	flush()
+	return rows
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+// compareResponses diffs the outcome of sending the same request in two
+// environments; a failed send is shown as its error.
+func compareResponses(left, right ui.ComparisonSide, leftResponse, rightResponse *ResponseInfo, leftErr, rightErr error) ui.Comparison {
+	comparison := ui.Comparison{Left: left, Right: right}
+
+	var leftHeaders, rightHeaders, leftBody, rightBody []string
+	leftJSON, rightJSON := false, false
+	if leftErr != nil {
+		comparison.Left.Status = fmt.Sprintf("Error: %v", leftErr)
+	} else {
+		comparison.Left.Status = leftResponse.Status
+		leftHeaders = comparableHeaders(leftResponse.Headers)
+		leftBody, leftJSON = comparableBody(leftResponse.Body)
+	}
+	if rightErr != nil {
+		comparison.Right.Status = fmt.Sprintf("Error: %v", rightErr)
+	} else {
+		comparison.Right.Status = rightResponse.Status
+		rightHeaders = comparableHeaders(rightResponse.Headers)
+		rightBody, rightJSON = comparableBody(rightResponse.Body)
+	}
+
+	// JSON on one side only is compared as sent
+	comparison.StructuralJSON = leftJSON && rightJSON
+	if leftJSON && !comparison.StructuralJSON {
+		leftBody = bodyLines(leftResponse.Body)
+	}
+	if rightJSON && !comparison.StructuralJSON {
+		rightBody = bodyLines(rightResponse.Body)
+	}
+
+	comparison.Headers = diffLines(leftHeaders, rightHeaders)
+	comparison.Body = diffLines(leftBody, rightBody)
+	return comparison
+}
+
+// compareWithStored diffs the response to a request resent from the history
+// against the response stored with its entry.
+func compareWithStored(item *storage.RequestHistory, response *ResponseInfo, err error) ui.Comparison {
+	stored := storedResponse(item)
+	var storedErr error
+	if stored == nil {
+		storedErr = errors.New("the request failed, so no response was stored")
+	}
+	comparison := compareResponses(
+		ui.ComparisonSide{Environment: "Stored " + item.Timestamp.Local().Format("2 Jan 15:04:05")},
+		ui.ComparisonSide{Environment: "Resent"},
+		stored, response, storedErr, err)
+	comparison.Title = "Resend Comparison"
+	return comparison
+}
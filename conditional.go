@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// responseValidator returns the ETag and Last-Modified values of response
+// as a validator for url, or nil when it has neither.
+func responseValidator(url string, response *ResponseInfo) *storage.Validator {
+	validator := &storage.Validator{URL: url, UpdatedAt: time.Now()}
+	for _, header := range response.Headers {
+		switch {
+		case strings.EqualFold(header.Key, "ETag"):
+			validator.ETag = header.Value
+		case strings.EqualFold(header.Key, "Last-Modified"):
+			validator.LastModified = header.Value
+		}
+	}
+	if validator.ETag == "" && validator.LastModified == "" {
+		return nil
+	}
+	return validator
+}
+
+// conditionalHeaders returns the If-None-Match and If-Modified-Since
+// headers that make a request conditional on validator, leaving out those
+// headers already sets.
+func conditionalHeaders(headers []ui.KeyValue, validator *storage.Validator) []ui.KeyValue {
+	var added []ui.KeyValue
+	if _, ok := findHeader(headers, "If-None-Match"); !ok && validator.ETag != "" {
+		added = append(added, ui.KeyValue{Key: "If-None-Match", Value: validator.ETag})
+	}
+	if _, ok := findHeader(headers, "If-Modified-Since"); !ok && validator.LastModified != "" {
+		added = append(added, ui.KeyValue{Key: "If-Modified-Since", Value: validator.LastModified})
+	}
+	return added
+}
+
+// isConditionalMethod tells whether responses to method can be revalidated;
+// conditional headers on other methods are preconditions for changing the
+// resource, which is not what they are added for.
+func isConditionalMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// describeConditional says which conditional headers were added to a
+// request, for the indicator under the response status.
+func describeConditional(added []ui.KeyValue, automatic bool) string {
+	names := make([]string, len(added))
+	for i, header := range added {
+		names[i] = fmt.Sprintf("%s: %s", header.Key, header.Value)
+	}
+	source := "from the last response"
+	if automatic {
+		source = "from the remembered validators"
+	}
+	return "Conditional request: sent " + strings.Join(names, ", ") + " " + source
+}
+
+// describeNotModified is shown in place of the empty body of a 304 Not
+// Modified response.
+func describeNotModified(request *RequestInfo) string {
+	var conditions []string
+	for _, name := range []string{"If-None-Match", "If-Modified-Since"} {
+		if value, ok := findHeader(request.Headers, name); ok {
+			conditions = append(conditions, fmt.Sprintf("%s: %s", name, value))
+		}
+	}
+	if len(conditions) == 0 {
+		return "304 Not Modified: the server says the copy the client holds is still current, and sent no body."
+	}
+	return "304 Not Modified: the copy validated by " + strings.Join(conditions, " and ") +
+		" is still current, so the server sent no body."
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"golem/storage"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cookieJar is the cookie jar shared by all requests. Matching is left to
+// net/http/cookiejar; every cookie it accepts is also written to the cookies
+// table so the jar survives a restart.
+type cookieJar struct {
+	mu  sync.Mutex
+	db  *storage.DB
+	jar *cookiejar.Jar
+}
+
+func newCookieJar(db *storage.DB) *cookieJar {
+	j := &cookieJar{db: db}
+	j.Reload()
+	return j
+}
+
+// Reload rebuilds the jar from the database, e.g. after cookies were deleted
+// in the cookie manager.
+func (j *cookieJar) Reload() {
+	jar, _ := cookiejar.New(nil)
+
+	cookies, err := j.db.GetCookies()
+	if err != nil {
+		fmt.Printf("Error loading cookies: %v\n", err)
+	}
+	for _, cookie := range cookies {
+		if cookie.Expires != nil && cookie.Expires.Before(time.Now()) {
+			j.db.DeleteCookie(cookie.ID)
+			continue
+		}
+		jar.SetCookies(storedCookieURL(cookie), []*http.Cookie{httpCookie(cookie)})
+	}
+
+	j.mu.Lock()
+	j.jar = jar
+	j.mu.Unlock()
+}
+
+func (j *cookieJar) current() *cookiejar.Jar {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar
+}
+
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.current().Cookies(u)
+}
+
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.current().SetCookies(u, cookies)
+
+	for _, c := range cookies {
+		cookie, ok := storedCookie(u, c)
+		if !ok {
+			continue
+		}
+
+		var err error
+		if cookie.Expires != nil && !cookie.Expires.After(time.Now()) {
+			err = j.db.DeleteCookieByKey(cookie.Domain, cookie.Path, cookie.Name)
+		} else {
+			err = j.db.SaveCookie(cookie)
+		}
+		if err != nil {
+			fmt.Printf("Error saving cookie %s: %v\n", c.Name, err)
+		}
+	}
+}
+
+// storedCookie converts a Set-Cookie received from u for storage, applying
+// the same defaults as the jar. Cookies for a domain u may not set are
+// skipped, since the jar rejects them too.
+func storedCookie(u *url.URL, c *http.Cookie) (*storage.Cookie, bool) {
+	host := strings.ToLower(u.Hostname())
+	cookie := &storage.Cookie{
+		Domain:   host,
+		Path:     c.Path,
+		Name:     c.Name,
+		Value:    c.Value,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		HostOnly: true,
+	}
+
+	if c.Domain != "" {
+		domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			return nil, false
+		}
+		cookie.Domain = domain
+		cookie.HostOnly = false
+	}
+
+	if cookie.Path == "" || cookie.Path[0] != '/' {
+		cookie.Path = defaultCookiePath(u.Path)
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		expired := time.Unix(0, 0)
+		cookie.Expires = &expired
+	case c.MaxAge > 0:
+		expires := time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		cookie.Expires = &expires
+	case !c.Expires.IsZero():
+		expires := c.Expires
+		cookie.Expires = &expires
+	}
+
+	return cookie, true
+}
+
+// defaultCookiePath is the directory of the request path, as in RFC 6265
+// section 5.1.4.
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// storedCookieURL is a URL the stored cookie could have been set from.
+func storedCookieURL(cookie *storage.Cookie) *url.URL {
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: cookie.Domain, Path: cookie.Path}
+}
+
+func httpCookie(cookie *storage.Cookie) *http.Cookie {
+	c := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+	}
+	if !cookie.HostOnly {
+		c.Domain = cookie.Domain
+	}
+	if cookie.Expires != nil {
+		c.Expires = *cookie.Expires
+	}
+	return c
+}
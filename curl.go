@@ -0,0 +1,605 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golem/ui"
+)
+
+// curlCommand is a request parsed from a curl command line, such as one
+// copied from a browser's developer tools.
+type curlCommand struct {
+	Method  string
+	URL     string
+	Headers []ui.KeyValue
+	// Body is the raw body, or the file path for BodyTypeBinary
+	BodyType   string
+	Body       string
+	FormFields []ui.FormField
+	Auth       ui.AuthConfig
+	Insecure   bool
+	// UnixSocket is the path of the Unix socket to connect to, if any
+	UnixSocket string
+
+	// Unsupported lists the options that were ignored, as written
+	Unsupported []string
+}
+
+// curlShortOptions maps short options to their long names.
+var curlShortOptions = map[byte]string{
+	'X': "--request",
+	'H': "--header",
+	'd': "--data",
+	'u': "--user",
+	'F': "--form",
+	'k': "--insecure",
+	'A': "--user-agent",
+	'e': "--referer",
+	'b': "--cookie",
+	'I': "--head",
+	'G': "--get",
+	'L': "--location",
+	's': "--silent",
+	'S': "--show-error",
+	'v': "--verbose",
+	'i': "--include",
+	'f': "--fail",
+	'o': "--output",
+	'O': "--remote-name",
+	'm': "--max-time",
+	'x': "--proxy",
+	'w': "--write-out",
+	'c': "--cookie-jar",
+	'T': "--upload-file",
+	'E': "--cert",
+	'r': "--range",
+	'K': "--config",
+	'U': "--proxy-user",
+	'C': "--continue-at",
+	'y': "--speed-time",
+	'Y': "--speed-limit",
+	'z': "--time-cond",
+	'D': "--dump-header",
+	'Q': "--quote",
+	'P': "--ftp-port",
+	't': "--telnet-option",
+	'h': "--help",
+	'V': "--version",
+	'n': "--netrc",
+	'N': "--no-buffer",
+	'j': "--junk-session-cookies",
+	'g': "--globoff",
+	'l': "--list-only",
+	'p': "--proxytunnel",
+	'q': "--disable",
+	'R': "--remote-time",
+	'Z': "--parallel",
+	'J': "--remote-header-name",
+	'a': "--append",
+	'B': "--use-ascii",
+	'M': "--manual",
+	'#': "--progress-bar",
+	'0': "--http1.0",
+	'1': "--tlsv1",
+	'2': "--sslv2",
+	'3': "--sslv3",
+	'4': "--ipv4",
+	'6': "--ipv6",
+}
+
+// curlValueOptions are the options that take a value, whether supported
+// or not, so the value is not mistaken for the URL. curl has no syntax that
+// tells them apart, so the list follows curl's manual.
+var curlValueOptions = map[string]bool{
+	"--abstract-unix-socket": true, "--alt-svc": true, "--aws-sigv4": true,
+	"--cacert": true, "--capath": true, "--cert": true, "--cert-type": true,
+	"--ciphers": true, "--config": true, "--connect-timeout": true,
+	"--connect-to": true, "--continue-at": true, "--cookie": true,
+	"--cookie-jar": true, "--create-file-mode": true, "--crlfile": true,
+	"--curves": true, "--data": true, "--data-ascii": true,
+	"--data-binary": true, "--data-raw": true, "--data-urlencode": true,
+	"--delegation": true, "--dns-interface": true, "--dns-ipv4-addr": true,
+	"--dns-ipv6-addr": true, "--dns-servers": true, "--doh-url": true,
+	"--dump-header": true, "--ech": true, "--egd-file": true,
+	"--engine": true, "--etag-compare": true, "--etag-save": true,
+	"--expect100-timeout": true, "--form": true, "--form-string": true,
+	"--ftp-account": true, "--ftp-alternative-to-user": true,
+	"--ftp-method": true, "--ftp-port": true, "--ftp-ssl-ccc-mode": true,
+	"--happy-eyeballs-timeout-ms": true, "--haproxy-clientip": true,
+	"--header": true, "--hostpubmd5": true, "--hostpubsha256": true,
+	"--hsts": true, "--interface": true, "--ip-tos": true,
+	"--ipfs-gateway": true, "--json": true, "--keepalive-cnt": true,
+	"--keepalive-time": true, "--key": true, "--key-type": true,
+	"--krb": true, "--libcurl": true, "--limit-rate": true,
+	"--local-port": true, "--login-options": true, "--mail-auth": true,
+	"--mail-from": true, "--mail-rcpt": true, "--max-filesize": true,
+	"--max-redirs": true, "--max-time": true, "--netrc-file": true,
+	"--noproxy": true, "--oauth2-bearer": true, "--output": true,
+	"--output-dir": true, "--parallel-max": true, "--pass": true,
+	"--pinnedpubkey": true, "--preproxy": true, "--proto": true,
+	"--proto-default": true, "--proto-redir": true, "--proxy": true,
+	"--proxy-cacert": true, "--proxy-capath": true, "--proxy-cert": true,
+	"--proxy-cert-type": true, "--proxy-ciphers": true,
+	"--proxy-crlfile": true, "--proxy-header": true, "--proxy-key": true,
+	"--proxy-key-type": true, "--proxy-pass": true,
+	"--proxy-pinnedpubkey": true, "--proxy-service-name": true,
+	"--proxy-tls13-ciphers": true, "--proxy-tlsauthtype": true,
+	"--proxy-tlspassword": true, "--proxy-tlsuser": true,
+	"--proxy-user": true, "--proxy1.0": true, "--pubkey": true,
+	"--quote": true, "--random-file": true, "--range": true, "--rate": true,
+	"--referer": true, "--request": true, "--request-target": true,
+	"--resolve": true, "--retry": true, "--retry-delay": true,
+	"--retry-max-time": true, "--sasl-authzid": true, "--service-name": true,
+	"--socks4": true, "--socks4a": true, "--socks5": true,
+	"--socks5-gssapi-service": true, "--socks5-hostname": true,
+	"--speed-limit": true, "--speed-time": true, "--stderr": true,
+	"--telnet-option": true, "--tftp-blksize": true, "--time-cond": true,
+	"--tls-max": true, "--tls13-ciphers": true, "--tlsauthtype": true,
+	"--tlspassword": true, "--tlsuser": true, "--trace": true,
+	"--trace-ascii": true, "--trace-config": true, "--unix-socket": true,
+	"--upload-file": true, "--url": true, "--url-query": true, "--user": true,
+	"--user-agent": true, "--variable": true, "--vlan-priority": true,
+	"--write-out": true,
+}
+
+// curlIgnoredOptions only change what curl prints or are the default here,
+// so they are dropped without a warning.
+var curlIgnoredOptions = map[string]bool{
+	"--location":          true,
+	"--silent":            true,
+	"--show-error":        true,
+	"--verbose":           true,
+	"--include":           true,
+	"--fail":              true,
+	"--compressed":        true,
+	"--progress-bar":      true,
+	"--no-progress-meter": true,
+}
+
+// parseCurlCommand parses a curl command line, quoted as for a POSIX shell.
+// Options golem has no equivalent for are listed in Unsupported rather than
+// failing the import.
+func parseCurlCommand(command string) (*curlCommand, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("not a curl command")
+	}
+
+	cmd := &curlCommand{}
+	var (
+		method     string
+		head, get  bool
+		data       []string
+		dataFile   string
+		jsonData   bool
+		urls       []string
+		queries    []string
+		formFields []ui.FormField
+	)
+
+	addHeader := func(name, value string) {
+		cmd.Headers = append(cmd.Headers, ui.KeyValue{Key: name, Value: value})
+	}
+	unsupported := func(option string) {
+		cmd.Unsupported = append(cmd.Unsupported, option)
+	}
+
+	// apply handles one option; written is how it appeared, for warnings
+	apply := func(name, written, value string) {
+		switch name {
+		case "--request":
+			method = value
+		case "--header":
+			key, headerValue, ok := strings.Cut(value, ":")
+			switch {
+			case ok && strings.TrimSpace(headerValue) == "":
+				// "Name:" removes a header curl would add by itself
+			case ok:
+				addHeader(strings.TrimSpace(key), strings.TrimSpace(headerValue))
+			case strings.HasSuffix(value, ";"):
+				// "Name;" sends the header with an empty value
+				addHeader(strings.TrimSpace(strings.TrimSuffix(value, ";")), "")
+			default:
+				unsupported(written + " " + value)
+			}
+		case "--data", "--data-ascii", "--data-binary", "--json":
+			if name == "--json" {
+				jsonData = true
+			}
+			if file, ok := strings.CutPrefix(value, "@"); ok {
+				dataFile = file
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			if encoded, ok := curlURLEncode(value); ok {
+				data = append(data, encoded)
+			} else {
+				unsupported(written + " " + value)
+			}
+		case "--url-query":
+			// A leading + adds the value as it is
+			if raw, ok := strings.CutPrefix(value, "+"); ok {
+				queries = append(queries, raw)
+			} else if encoded, ok := curlURLEncode(value); ok {
+				queries = append(queries, encoded)
+			} else {
+				unsupported(written + " " + value)
+			}
+		case "--unix-socket":
+			cmd.UnixSocket = value
+		case "--form", "--form-string":
+			key, fieldValue, _ := strings.Cut(value, "=")
+			if name == "--form" {
+				if path, ok := strings.CutPrefix(fieldValue, "@"); ok {
+					// Drop ";type=..." and other field parameters
+					path, _, _ = strings.Cut(path, ";")
+					formFields = append(formFields, ui.FormField{Key: key, Value: path, IsFile: true})
+					return
+				}
+				if strings.HasPrefix(fieldValue, "<") {
+					unsupported(written + " " + value)
+					return
+				}
+			}
+			formFields = append(formFields, ui.FormField{Key: key, Value: fieldValue})
+		case "--user":
+			username, password, _ := strings.Cut(value, ":")
+			cmd.Auth = ui.AuthConfig{Type: ui.AuthTypeBasic, Username: username, Password: password}
+		case "--oauth2-bearer":
+			cmd.Auth = ui.AuthConfig{Type: ui.AuthTypeBearer, Token: value}
+		case "--url":
+			urls = append(urls, value)
+		case "--insecure":
+			cmd.Insecure = true
+		case "--user-agent":
+			addHeader("User-Agent", value)
+		case "--referer":
+			addHeader("Referer", value)
+		case "--cookie":
+			// Without a "=" the value names a cookie file
+			if !strings.Contains(value, "=") {
+				unsupported(written + " " + value)
+				return
+			}
+			addHeader("Cookie", value)
+		case "--head":
+			head = true
+		case "--get":
+			get = true
+		default:
+			if !curlIgnoredOptions[name] {
+				unsupported(written)
+			}
+		}
+	}
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			urls = append(urls, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			var value string
+			if curlValueOptions[arg] {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%s needs a value", arg)
+				}
+				i++
+				value = args[i]
+			}
+			apply(arg, arg, value)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short options may be grouped, as in -sSL, and the last may
+			// take its value attached, as in -XPOST
+			for j := 1; j < len(arg); j++ {
+				written := "-" + arg[j:j+1]
+				name, ok := curlShortOptions[arg[j]]
+				if !ok {
+					unsupported(written)
+					continue
+				}
+				if !curlValueOptions[name] {
+					apply(name, written, "")
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return nil, fmt.Errorf("%s needs a value", written)
+					}
+					i++
+					value = args[i]
+				}
+				apply(name, written, value)
+				break
+			}
+		default:
+			urls = append(urls, arg)
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, errors.New("the curl command has no URL")
+	}
+	if len(urls) > 1 {
+		unsupported("more than one URL; only " + urls[0] + " was imported")
+	}
+	cmd.URL = urls[0]
+
+	if len(data) > 0 && len(formFields) > 0 {
+		return nil, errors.New("the curl command sends both data (-d) and a form (-F)")
+	}
+	if dataFile != "" && len(data) > 1 {
+		return nil, errors.New("a body read from a file (-d @file) cannot be combined with other data")
+	}
+
+	addQuery := func(parts []string) {
+		separator := "?"
+		if strings.Contains(cmd.URL, "?") {
+			separator = "&"
+		}
+		cmd.URL += separator + strings.Join(parts, "&")
+	}
+	if len(queries) > 0 {
+		addQuery(queries)
+	}
+
+	switch {
+	case get && len(data) > 0:
+		addQuery(data)
+	case len(formFields) > 0:
+		cmd.BodyType = ui.BodyTypeMultipart
+		cmd.FormFields = formFields
+	case dataFile != "":
+		cmd.BodyType = ui.BodyTypeBinary
+		cmd.Body = dataFile
+	case len(data) > 0:
+		cmd.BodyType = ui.BodyTypeRaw
+		cmd.Body = strings.Join(data, "&")
+	}
+
+	// curl labels data as a form unless told otherwise
+	hasData := len(data) > 0 && !get
+	if hasData {
+		contentType := "application/x-www-form-urlencoded"
+		if jsonData {
+			contentType = "application/json"
+			if _, ok := findHeader(cmd.Headers, "Accept"); !ok {
+				addHeader("Accept", "application/json")
+			}
+		}
+		if _, ok := findHeader(cmd.Headers, "Content-Type"); !ok {
+			addHeader("Content-Type", contentType)
+		}
+	}
+
+	switch {
+	case method != "":
+		cmd.Method = strings.ToUpper(method)
+	case head:
+		cmd.Method = "HEAD"
+	case hasData || len(formFields) > 0:
+		cmd.Method = "POST"
+	default:
+		cmd.Method = "GET"
+	}
+	return cmd, nil
+}
+
+// curlURLEncode encodes the value of a --data-urlencode or --url-query the
+// way curl does. Only the inline forms are understood; it reports false for
+// "name@file", which reads a file.
+func curlURLEncode(value string) (string, bool) {
+	key, content, ok := strings.Cut(value, "=")
+	switch {
+	case ok && key == "":
+		return curlEscape(content), true
+	case ok:
+		return key + "=" + curlEscape(content), true
+	case strings.Contains(value, "@"):
+		return "", false
+	}
+	return curlEscape(value), true
+}
+
+// curlEscape percent-encodes s the way --data-urlencode does.
+func curlEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// splitShellWords splits a command line into words the way a POSIX shell
+// would, handling single and double quotes, $'...' strings, backslash
+// escapes and line continuations. Nothing is expanded.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				i++
+				continue
+			}
+			if next, size := continuation(s[i+1:]); next {
+				i += 1 + size
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(s[i+1:])
+			word.WriteRune(r)
+			inWord = true
+			i += 1 + size
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			inWord = true
+			i += end + 2
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := readANSIString(s[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i += 2 + n
+		case c == '"':
+			n, err := readDoubleQuoted(s[i+1:], &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i += 1 + n
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if unicode.IsSpace(r) {
+				if inWord {
+					words = append(words, word.String())
+					word.Reset()
+					inWord = false
+				}
+			} else {
+				word.WriteRune(r)
+				inWord = true
+			}
+			i += size
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// continuation reports whether s starts with a line break, as after the
+// backslash of a continued line, and how long the break is.
+func continuation(s string) (bool, int) {
+	switch {
+	case strings.HasPrefix(s, "\r\n"):
+		return true, 2
+	case strings.HasPrefix(s, "\n"):
+		return true, 1
+	}
+	return false, 0
+}
+
+// readDoubleQuoted writes the contents of a double-quoted string, s starting
+// after the opening quote, and returns how much of s it used including the
+// closing quote. A backslash only escapes the characters it does in a shell.
+func readDoubleQuoted(s string, word *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return i + 1, nil
+		case '\\':
+			if i+1 < len(s) {
+				if next, size := continuation(s[i+1:]); next {
+					i += size
+					continue
+				}
+				if strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+			}
+		}
+		word.WriteByte(s[i])
+	}
+	return 0, errors.New("unterminated double quote")
+}
+
+// readANSIString writes the contents of a $'...' string, s starting after
+// the opening quote, and returns how much of s it used including the closing
+// quote. Browsers use these when copying requests with special characters.
+func readANSIString(s string, word *strings.Builder) (int, error) {
+	simple := map[byte]byte{
+		'n': '\n', 't': '\t', 'r': '\r', 'a': '\a', 'b': '\b', 'f': '\f',
+		'v': '\v', 'e': 0x1b, 'E': 0x1b, '\\': '\\', '\'': '\'', '"': '"', '?': '?',
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			word.WriteByte(c)
+			continue
+		}
+		i++
+		if b, ok := simple[s[i]]; ok {
+			word.WriteByte(b)
+			continue
+		}
+		// Numeric escapes: \xHH, \uHHHH, \UHHHHHHHH and octal \NNN
+		var base, max int
+		switch s[i] {
+		case 'x':
+			base, max = 16, 2
+		case 'u':
+			base, max = 16, 4
+		case 'U':
+			base, max = 16, 8
+		default:
+			if s[i] >= '0' && s[i] <= '7' {
+				base, max = 8, 3
+			}
+		}
+		if base == 0 {
+			// Not an escape after all
+			word.WriteByte('\\')
+			word.WriteByte(s[i])
+			continue
+		}
+		// Octal digits start right after the backslash
+		start := i + 1
+		if base == 8 {
+			start = i
+		}
+		end := start
+		for end < len(s) && end-start < max && isDigit(s[end], base) {
+			end++
+		}
+		if end == start {
+			word.WriteByte('\\')
+			word.WriteByte(s[i])
+			continue
+		}
+		n, _ := strconv.ParseUint(s[start:end], base, 32)
+		if s[i] == 'u' || s[i] == 'U' {
+			word.WriteRune(rune(n))
+		} else {
+			word.WriteByte(byte(n))
+		}
+		i = end - 1
+	}
+	return 0, errors.New("unterminated $'...' string")
+}
+
+func isDigit(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '7':
+		return true
+	case base == 8:
+		return false
+	case c >= '8' && c <= '9':
+		return true
+	case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golem/ui"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"plain", `curl https://example.com`, []string{"curl", "https://example.com"}},
+		{"single quotes keep everything", `curl -H 'X-A: "b" $c \d'`, []string{"curl", "-H", `X-A: "b" $c \d`}},
+		{"double quotes escape only some", `curl -d "a\"b\\c\$d\e"`, []string{"curl", "-d", `a"b\c$d\e`}},
+		{"single inside double", `curl -d "it's"`, []string{"curl", "-d", "it's"}},
+		{"double inside single", `curl -d '{"a":1}'`, []string{"curl", "-d", `{"a":1}`}},
+		{"quote concatenation", `curl -d 'it'\''s'`, []string{"curl", "-d", "it's"}},
+		{"adjacent quoted parts", `curl -H "X-A: "'b'c`, []string{"curl", "-H", "X-A: bc"}},
+		{"ansi escapes", `curl -d $'line\none\ttab \'q\' \x41\101é'`, []string{"curl", "-d", "line\none\ttab 'q' AAé"}},
+		{"ansi unknown escape kept", `curl -d $'a\qb'`, []string{"curl", "-d", `a\qb`}},
+		{"line continuations", "curl \\\n  -X POST \\\r\n  https://example.com", []string{"curl", "-X", "POST", "https://example.com"}},
+		{"continuation in double quotes", "curl -d \"a\\\nb\"", []string{"curl", "-d", "ab"}},
+		{"escaped space", `curl https://example.com/a\ b`, []string{"curl", "https://example.com/a b"}},
+		{"empty quoted word", `curl -H ''`, []string{"curl", "-H", ""}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := splitShellWords(tc.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitShellWordsUnterminated(t *testing.T) {
+	for _, command := range []string{`curl 'a`, `curl "a`, `curl $'a`} {
+		if _, err := splitShellWords(command); err == nil {
+			t.Errorf("%s: expected an error", command)
+		}
+	}
+}
+
+func TestParseCurlCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    curlCommand
+	}{
+		{
+			name:    "get",
+			command: `curl https://example.com/a`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a"},
+		},
+		{
+			name:    "grouped short flags",
+			command: `curl -sSLk https://example.com/a`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a", Insecure: true},
+		},
+		{
+			name:    "attached value",
+			command: `curl -XPOST -HAccept:text/plain https://example.com/a`,
+			want: curlCommand{Method: "POST", URL: "https://example.com/a",
+				Headers: []ui.KeyValue{{Key: "Accept", Value: "text/plain"}}},
+		},
+		{
+			name:    "grouped flags ending in a value option",
+			command: `curl -sX PUT https://example.com/a`,
+			want:    curlCommand{Method: "PUT", URL: "https://example.com/a"},
+		},
+		{
+			name:    "data implies post and a form content type",
+			command: `curl https://example.com/a -d 'a=1' -d b=2`,
+			want: curlCommand{Method: "POST", URL: "https://example.com/a", BodyType: ui.BodyTypeRaw, Body: "a=1&b=2",
+				Headers: []ui.KeyValue{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}}},
+		},
+		{
+			name:    "browser copy with ansi body",
+			command: "curl 'https://example.com/a' \\\n  -H 'content-type: application/json' \\\n  --data-raw $'{\"note\":\"it\\'s\\n\"}'",
+			want: curlCommand{Method: "POST", URL: "https://example.com/a", BodyType: ui.BodyTypeRaw, Body: "{\"note\":\"it's\n\"}",
+				Headers: []ui.KeyValue{{Key: "content-type", Value: "application/json"}}},
+		},
+		{
+			name:    "json",
+			command: `curl --json '{"a":1}' https://example.com/a`,
+			want: curlCommand{Method: "POST", URL: "https://example.com/a", BodyType: ui.BodyTypeRaw, Body: `{"a":1}`,
+				Headers: []ui.KeyValue{{Key: "Accept", Value: "application/json"}, {Key: "Content-Type", Value: "application/json"}}},
+		},
+		{
+			name:    "get with data",
+			command: `curl -G -d q=a --data-urlencode 'name=a b' https://example.com/a?x=1`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a?x=1&q=a&name=a%20b"},
+		},
+		{
+			name:    "form with a file",
+			command: `curl -F 'title=Report' -F 'file=@/tmp/r.pdf;type=application/pdf' https://example.com/a`,
+			want: curlCommand{Method: "POST", URL: "https://example.com/a", BodyType: ui.BodyTypeMultipart,
+				FormFields: []ui.FormField{{Key: "title", Value: "Report"}, {Key: "file", Value: "/tmp/r.pdf", IsFile: true}}},
+		},
+		{
+			name:    "basic auth",
+			command: `curl -u 'me:p:w' https://example.com/a`,
+			want: curlCommand{Method: "GET", URL: "https://example.com/a",
+				Auth: ui.AuthConfig{Type: ui.AuthTypeBasic, Username: "me", Password: "p:w"}},
+		},
+		{
+			name:    "dump header does not take the url",
+			command: `curl -D - https://example.com/a`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a", Unsupported: []string{"-D"}},
+		},
+		{
+			name:    "long dump header",
+			command: `curl --dump-header headers.txt https://example.com/a`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a", Unsupported: []string{"--dump-header"}},
+		},
+		{
+			name:    "retry options",
+			command: `curl --retry 2 --retry-delay 3 --retry-max-time 10 https://example.com/a`,
+			want: curlCommand{Method: "GET", URL: "https://example.com/a",
+				Unsupported: []string{"--retry", "--retry-delay", "--retry-max-time"}},
+		},
+		{
+			name:    "url query",
+			command: `curl --url-query 'a=b c' --url-query +raw=%41 https://example.com/a`,
+			want:    curlCommand{Method: "GET", URL: "https://example.com/a?a=b%20c&raw=%41"},
+		},
+		{
+			name:    "unix socket",
+			command: `curl --unix-socket /var/run/docker.sock http://localhost/v1.43/containers/json`,
+			want:    curlCommand{Method: "GET", URL: "http://localhost/v1.43/containers/json", UnixSocket: "/var/run/docker.sock"},
+		},
+		{
+			name: "tls and proxy options",
+			command: `curl --cert-type PEM --key-type PEM --pass secret --proxy-header 'X-A: b' --noproxy '*' ` +
+				`--max-filesize 100 --ciphers ECDHE https://example.com/a`,
+			want: curlCommand{Method: "GET", URL: "https://example.com/a", Unsupported: []string{
+				"--cert-type", "--key-type", "--pass", "--proxy-header", "--noproxy", "--max-filesize", "--ciphers"}},
+		},
+		{
+			name:    "end of options",
+			command: `curl -X DELETE -- -weird-host`,
+			want:    curlCommand{Method: "DELETE", URL: "-weird-host"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseCurlCommand(tc.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseCurlCommandErrors(t *testing.T) {
+	for _, command := range []string{
+		`wget https://example.com`,
+		`curl`,
+		`curl -s`,
+		`curl https://example.com -H`,
+		`curl https://example.com -d a=1 -F b=2`,
+	} {
+		if _, err := parseCurlCommand(command); err == nil {
+			t.Errorf("%s: expected an error", command)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// downloadThreshold is the Content-Length above which a body is offered for
+// saving to a file rather than loaded into the response area.
+const downloadThreshold = 50 << 20
+
+// fileExtensions are the extensions given to saved bodies of common
+// types; other types get the first extension the system knows for them.
+var fileExtensions = map[string]string{
+	"application/json":         ".json",
+	"application/xml":          ".xml",
+	"text/xml":                 ".xml",
+	"text/html":                ".html",
+	"text/plain":               ".txt",
+	"text/csv":                 ".csv",
+	"text/css":                 ".css",
+	"text/javascript":          ".js",
+	"application/javascript":   ".js",
+	"application/pdf":          ".pdf",
+	"application/zip":          ".zip",
+	"application/gzip":         ".gz",
+	"application/octet-stream": ".bin",
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/svg+xml":            ".svg",
+}
+
+// downloadFileName suggests a name for a downloaded body.
+func downloadFileName(resp *http.Response) string {
+	return suggestFileName(resp.Header.Get("Content-Disposition"), resp.Header.Get("Content-Type"), resp.Request.URL.Path)
+}
+
+// responseFileName suggests a name for saving the body of response, which
+// was received for requestURL.
+func responseFileName(response *ResponseInfo, requestURL string) string {
+	var contentDisposition string
+	for _, header := range response.Headers {
+		if strings.EqualFold(header.Key, "Content-Disposition") {
+			contentDisposition = header.Value
+		}
+	}
+	var urlPath string
+	if u, err := url.Parse(finalURL(requestURL, response)); err == nil {
+		urlPath = u.Path
+	}
+	return suggestFileName(contentDisposition, responseContentType(response), urlPath)
+}
+
+// suggestFileName returns the filename from a Content-Disposition header,
+// or else the last segment of the URL path, with an extension for the
+// Content-Type when it has none.
+func suggestFileName(contentDisposition, contentType, urlPath string) string {
+	if _, params, err := mime.ParseMediaType(contentDisposition); err == nil {
+		// filename* is decoded into filename by ParseMediaType. Only the base
+		// name is used, whatever directories the server puts in it.
+		if name := filepath.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "" && name != "." && name != "/" {
+			return name
+		}
+	}
+	name := path.Base(urlPath)
+	if name == "" || name == "." || name == "/" {
+		name = "download"
+	}
+	if path.Ext(name) == "" {
+		name += contentTypeExtension(contentType)
+	}
+	return name
+}
+
+// contentTypeExtension returns the file extension for contentType, or ""
+// when there is none.
+func contentTypeExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if extension, ok := fileExtensions[mediaType]; ok {
+		return extension
+	}
+	if strings.HasSuffix(mediaType, "+json") {
+		return ".json"
+	}
+	if strings.HasSuffix(mediaType, "+xml") {
+		return ".xml"
+	}
+	if extensions, err := mime.ExtensionsByType(mediaType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
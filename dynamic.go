@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRandomIntMax    = 1000
+	defaultRandomStringLen = 16
+	maxRandomStringLen     = 4096
+
+	randomStringAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// dynamicVariables are the built-in variables given a fresh value on every
+// send. They are listed for users in ui.DynamicVariables. A user variable of
+// the same name takes precedence.
+var dynamicVariables = map[string]func(args []string) (string, error){
+	"uuid":         generateUUID,
+	"timestamp":    generateTimestamp,
+	"isoTimestamp": generateISOTimestamp,
+	"randomInt":    generateRandomInt,
+	"randomString": generateRandomString,
+}
+
+func noArguments(args []string) error {
+	if len(args) > 0 {
+		return errors.New("takes no arguments")
+	}
+	return nil
+}
+
+// generateUUID returns a random (version 4) UUID.
+func generateUUID(args []string) (string, error) {
+	if err := noArguments(args); err != nil {
+		return "", err
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// generateTimestamp returns the Unix time in seconds.
+func generateTimestamp(args []string) (string, error) {
+	if err := noArguments(args); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(time.Now().Unix(), 10), nil
+}
+
+// generateISOTimestamp returns the time in UTC with milliseconds, e.g.
+// 2024-05-01T12:00:00.000Z.
+func generateISOTimestamp(args []string) (string, error) {
+	if err := noArguments(args); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), nil
+}
+
+// generateRandomInt takes optional min and max arguments, both inclusive,
+// defaulting to 0 and 1000.
+func generateRandomInt(args []string) (string, error) {
+	low, high := int64(0), int64(defaultRandomIntMax)
+	switch len(args) {
+	case 0:
+	case 2:
+		var err error
+		if low, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return "", fmt.Errorf("invalid minimum %q", args[0])
+		}
+		if high, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return "", fmt.Errorf("invalid maximum %q", args[1])
+		}
+		if low > high {
+			return "", errors.New("the minimum is greater than the maximum")
+		}
+	default:
+		return "", errors.New("takes a minimum and a maximum, e.g. {{randomInt 1 100}}")
+	}
+
+	// Computed in big.Int so the full int64 range cannot overflow
+	span := new(big.Int).Sub(big.NewInt(high), big.NewInt(low))
+	n, err := rand.Int(rand.Reader, span.Add(span, big.NewInt(1)))
+	if err != nil {
+		return "", err
+	}
+	return n.Add(n, big.NewInt(low)).String(), nil
+}
+
+// generateRandomString returns letters and digits, 16 unless a length is
+// given.
+func generateRandomString(args []string) (string, error) {
+	length := defaultRandomStringLen
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		if length, err = strconv.Atoi(args[0]); err != nil || length < 1 || length > maxRandomStringLen {
+			return "", fmt.Errorf("invalid length %q: use 1 to %d", args[0], maxRandomStringLen)
+		}
+	default:
+		return "", errors.New("takes a length, e.g. {{randomString 16}}")
+	}
+
+	limit := big.NewInt(int64(len(randomStringAlphabet)))
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(randomStringAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// dynamicKey identifies a dynamic placeholder by its name and arguments,
+// e.g. "randomInt 1 100", however it is spaced.
+func dynamicKey(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
+// applyDynamicVariables returns a copy of request with the built-in dynamic
+// variables evaluated, and the values used by dynamicKey. Each distinct
+// placeholder gets one value per send, so {{uuid}} in the URL and the body
+// are the same.
+func applyDynamicVariables(request RequestInfo) (RequestInfo, map[string]string, error) {
+	values := make(map[string]string)
+	var firstErr error
+
+	request = substituteRequest(request, func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name, args := parsePlaceholder(placeholder)
+			generate, ok := dynamicVariables[name]
+			if !ok {
+				return placeholder
+			}
+			key := dynamicKey(name, args)
+			if value, ok := values[key]; ok {
+				return value
+			}
+			value, err := generate(args)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("{{%s}}: %w", key, err)
+				}
+				return placeholder
+			}
+			values[key] = value
+			return value
+		})
+	})
+
+	return request, values, firstErr
+}
+
+// replayDynamicValues substitutes values recorded by applyDynamicVariables
+// into text, leaving other placeholders as they are.
+func replayDynamicValues(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := values[dynamicKey(parsePlaceholder(placeholder))]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
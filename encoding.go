@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodeBody reverses the Content-Encoding of a response body.
+func decodeBody(contentEncoding string, data []byte) ([]byte, error) {
+	reader, err := decodeReader(contentEncoding, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// decodeReader returns a reader of body with its Content-Encoding reversed
+// as it is read. Encodings are listed in the order they were applied, so
+// they are undone from the end.
+func decodeReader(contentEncoding string, body io.Reader) (io.Reader, error) {
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gzip body: %w", err)
+			}
+			body = &decodingReader{reader: gz, encoding: encoding}
+		case "deflate":
+			body = &decodingReader{reader: deflateReader(body), encoding: encoding}
+		case "br":
+			body = &decodingReader{reader: brotli.NewReader(body), encoding: encoding}
+		default:
+			return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+		}
+	}
+	return body, nil
+}
+
+// decodingReader names the encoding in the errors of its reader.
+type decodingReader struct {
+	reader   io.Reader
+	encoding string
+}
+
+func (r *decodingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("invalid %s body: %w", r.encoding, err)
+	}
+	return n, err
+}
+
+// deflateReader accepts both zlib-wrapped data, which is what the spec calls
+// deflate, and the raw deflate streams some servers send instead.
+func deflateReader(body io.Reader) io.Reader {
+	buffered := bufio.NewReader(body)
+	if header, err := buffered.Peek(2); err == nil && isZlibHeader(header) {
+		if zr, err := zlib.NewReader(buffered); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(buffered)
+}
+
+// isZlibHeader reports whether header, the first two bytes of a stream, is
+// a zlib header: deflate compression and a valid check value.
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
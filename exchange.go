@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golem/ui"
+)
+
+// errBinaryBody is returned when a body that is not text is to be copied.
+var errBinaryBody = errors.New("the body is binary data and cannot be copied as text")
+
+// responseCopyText returns the text the copy bar copies for what, one of
+// the ui.Copy constants.
+func responseCopyText(what string, request *RequestInfo, response *ResponseInfo, mask bool) (string, error) {
+	switch what {
+	case ui.CopyBody, ui.CopyEscapedBody:
+		if response.DownloadPath != "" {
+			return "", fmt.Errorf("the body was saved to %s", response.DownloadPath)
+		}
+		if response.BodyFile != "" {
+			return "", fmt.Errorf("the body is %s and only part of it is loaded; save it to a file instead", ui.FormatBytes(int64(response.Size)))
+		}
+		if what == ui.CopyEscapedBody {
+			return escapedBody(response.Body)
+		}
+		if !utf8.ValidString(response.Body) {
+			return "", errBinaryBody
+		}
+		return response.Body, nil
+	case ui.CopyHeaders:
+		return headersText(response), nil
+	case ui.CopyStatusLine:
+		return statusLine(response), nil
+	case ui.CopyExchange:
+		return formatExchange(request, response, mask)
+	}
+	return "", fmt.Errorf("nothing to copy for %q", what)
+}
+
+// statusLine returns the status line of response, e.g. "HTTP/1.1 200 OK".
+func statusLine(response *ResponseInfo) string {
+	return strings.TrimSpace(response.Proto + " " + response.Status)
+}
+
+// headersText returns the headers of response as "Name: value" lines,
+// sorted by name so the same response always gives the same text. Repeated
+// headers keep the order they were received in.
+func headersText(response *ResponseInfo) string {
+	headers := make([]ResponseHeader, len(response.Headers))
+	copy(headers, response.Headers)
+	sort.SliceStable(headers, func(i, j int) bool {
+		return strings.ToLower(headers[i].Key) < strings.ToLower(headers[j].Key)
+	})
+
+	var text strings.Builder
+	for _, header := range headers {
+		fmt.Fprintf(&text, "%s: %s\n", header.Key, header.Value)
+	}
+	return text.String()
+}
+
+// escapedBody returns body as a JSON string literal, quotes included, for
+// pasting into source code or a JSON document.
+func escapedBody(body string) (string, error) {
+	if !utf8.ValidString(body) {
+		return "", errBinaryBody
+	}
+	var text bytes.Buffer
+	encoder := json.NewEncoder(&text)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(body); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// formatExchange renders a request and its response as plain text for bug
+// reports: a summary line, the request as sent and the response, each in
+// its own section. The layout is always the same; the request part is that
+// of the request preview, so with mask set the Authorization credentials
+// are masked. The response body is cut at previewBodyLimit.
+func formatExchange(request *RequestInfo, response *ResponseInfo, mask bool) (string, error) {
+	requestText, err := previewRequest(request, mask)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s → %s in %d ms\n\n", request.Method, request.URL, response.Status, response.ResponseTime.Milliseconds())
+	text.WriteString("=== Request ===\n")
+	text.WriteString(strings.TrimRight(requestText, "\n"))
+	text.WriteString("\n\n=== Response ===\n")
+	text.WriteString(statusLine(response) + "\n")
+	text.WriteString(headersText(response))
+	text.WriteString("\n")
+
+	switch {
+	case response.DownloadPath != "":
+		fmt.Fprintf(&text, "(%d bytes saved to %s)", response.Size, response.DownloadPath)
+	case !utf8.ValidString(completeRunes(response.Body)):
+		fmt.Fprintf(&text, "(%d bytes of binary data)", response.Size)
+	case response.Size > previewBodyLimit:
+		// The limit may cut a character in half
+		body := response.Body[:min(previewBodyLimit, len(response.Body))]
+		for !utf8.ValidString(body) {
+			body = body[:len(body)-1]
+		}
+		text.WriteString(body)
+		fmt.Fprintf(&text, "\n… (%d more bytes)", response.Size-len(body))
+	default:
+		text.WriteString(response.Body)
+	}
+	return strings.TrimRight(text.String(), "\n") + "\n", nil
+}
+
+// cookieSnapshot is a cookie jar that always returns the same cookies.
+type cookieSnapshot []*http.Cookie
+
+func (s cookieSnapshot) Cookies(*url.URL) []*http.Cookie {
+	return s
+}
+
+func (s cookieSnapshot) SetCookies(*url.URL, []*http.Cookie) {}
+
+// withCookieSnapshot returns request with the cookies its jar holds for it
+// now, so that it can be rendered later with the cookies that were sent
+// rather than those the response set.
+func withCookieSnapshot(request RequestInfo) RequestInfo {
+	if request.CookieJar == nil {
+		return request
+	}
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		request.CookieJar = nil
+		return request
+	}
+	request.CookieJar = cookieSnapshot(request.CookieJar.Cookies(u))
+	return request
+}
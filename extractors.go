@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golem/ui"
+)
+
+// maxExtractedDisplay shortens long extracted values, e.g. JWTs, in the
+// summary under the response.
+const maxExtractedDisplay = 40
+
+// extractionResult is the outcome of one extractor. Secret reports that the
+// variable is secret, so its value is not shown.
+type extractionResult struct {
+	Extractor ui.Extractor
+	Value     string
+	Secret    bool
+	Err       error
+}
+
+// extractValue finds the value extractor refers to in response.
+func extractValue(extractor ui.Extractor, response *ResponseInfo) (string, error) {
+	switch extractor.Source {
+	case ui.ExtractJSONPath:
+		values, err := evaluateJSONPath(response.Body, extractor.Expression)
+		if err != nil {
+			return "", err
+		}
+		if len(values) == 0 {
+			return "", errors.New("no match")
+		}
+		return jsonValueText(values[0]), nil
+	case ui.ExtractHeader:
+		for _, header := range response.Headers {
+			if strings.EqualFold(header.Key, extractor.Expression) {
+				return header.Value, nil
+			}
+		}
+		return "", errors.New("no such header")
+	case ui.ExtractRegex:
+		pattern, err := regexp.Compile(extractor.Expression)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+		match := pattern.FindStringSubmatch(response.Body)
+		if match == nil {
+			return "", errors.New("no match")
+		}
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	}
+	return "", fmt.Errorf("unknown source %q", extractor.Source)
+}
+
+// runExtractors stores the values found in response in variables. A variable
+// whose extractor finds nothing keeps its value, and the result says so.
+func runExtractors(extractors []ui.Extractor, response *ResponseInfo, variables *scriptVariables) []extractionResult {
+	results := make([]extractionResult, len(extractors))
+	for i, extractor := range extractors {
+		result := extractionResult{Extractor: extractor}
+		result.Value, result.Err = extractValue(extractor, response)
+		if result.Err == nil {
+			result.Secret, result.Err = variables.set(extractor.Variable, result.Value)
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// describeExtractions summarizes results, one line per extractor.
+func describeExtractions(results []extractionResult) string {
+	lines := make([]string, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			lines[i] = fmt.Sprintf("✗ {{%s}} not updated: %s: %v", result.Extractor.Variable, result.Extractor.Expression, result.Err)
+			continue
+		}
+
+		value := result.Value
+		if result.Secret {
+			value = secretMask
+		} else if runes := []rune(value); len(runes) > maxExtractedDisplay {
+			value = string(runes[:maxExtractedDisplay]) + "…"
+		}
+		lines[i] = fmt.Sprintf("✓ {{%s}} = %s", result.Extractor.Variable, value)
+	}
+	return "Extracted:\n" + strings.Join(lines, "\n")
+}
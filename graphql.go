@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golem/graphql"
+	"golem/storage"
+	"golem/ui"
+)
+
+// introspectionRequest turns request, as built from the editors, into the
+// introspection query for its URL. The headers, auth and options are kept,
+// as an endpoint usually wants the same credentials for both; the
+// pre-request script is not, being written for the request's own query.
+func introspectionRequest(request RequestInfo) RequestInfo {
+	body, _ := json.Marshal(map[string]string{"query": graphql.IntrospectionQuery})
+	request.Method = http.MethodPost
+	request.BodyType = ui.BodyTypeGraphQL
+	request.Body = string(body)
+	request.Script = ""
+	request.DownloadToFile = false
+
+	headers := make([]ui.KeyValue, 0, len(request.Headers)+1)
+	for _, header := range request.Headers {
+		if !header.Disabled && strings.EqualFold(header.Key, "Content-Type") {
+			continue
+		}
+		headers = append(headers, header)
+	}
+	request.Headers = append(headers, ui.KeyValue{Key: "Content-Type", Value: "application/json"})
+	return request
+}
+
+// introspectGraphQL sends an introspection request, with its variables
+// resolved, and returns the schema in the response: parsed, and as the
+// JSON to cache. An endpoint that disables introspection gives an error
+// wrapping graphql.ErrNoSchema.
+func introspectGraphQL(db *storage.DB, request RequestInfo) (*graphql.Schema, []byte, error) {
+	request, _, err := prepareSend(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := executeWithAuth(db, &request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer removeBodyFile(response)
+
+	data, err := graphql.SchemaFromResponse([]byte(response.Body))
+	if err != nil {
+		if response.StatusCode >= 400 {
+			return nil, nil, fmt.Errorf("%s: %w", response.Status, err)
+		}
+		return nil, nil, err
+	}
+	schema, err := graphql.ParseSchema(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema, data, nil
+}
@@ -0,0 +1,179 @@
+package graphql
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadSchema(t *testing.T) *Schema {
+	t.Helper()
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := SchemaFromResponse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestParseSchema(t *testing.T) {
+	schema := loadSchema(t)
+
+	if got, want := schema.RootTypes(), []string{"Query", "Mutation"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("root types = %v, want %v", got, want)
+	}
+	signatures := map[string]string{
+		"user":   "user(id: ID!): User",
+		"users":  "users(first: Int = 10): [User!]!",
+		"search": "search(text: String!): [SearchResult]",
+	}
+	for name, want := range signatures {
+		if got := schema.Types["Query"].Field(name).Signature(); got != want {
+			t.Errorf("%s: signature = %q, want %q", name, got, want)
+		}
+	}
+	if got := schema.Types["Query"].Field("users").Type.Named(); got != "User" {
+		t.Errorf("users: named type = %q, want User", got)
+	}
+}
+
+func TestSchemaFromResponseWithoutSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"introspection disabled", `{"errors":[{"message":"GraphQL introspection is not allowed"}]}`},
+		{"null data", `{"data":null}`},
+		{"no __schema", `{"data":{}}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := SchemaFromResponse([]byte(tc.body)); !errors.Is(err, ErrNoSchema) {
+				t.Errorf("error = %v, want ErrNoSchema", err)
+			}
+		})
+	}
+
+	if _, err := SchemaFromResponse([]byte("<html>Forbidden</html>")); err == nil || errors.Is(err, ErrNoSchema) {
+		t.Errorf("HTML response: error = %v, want a JSON error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := loadSchema(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []Problem
+	}{
+		{
+			name:  "valid query",
+			query: "query User($id: ID!) {\n  user(id: $id) { id name posts(limit: 3) { title } }\n  __typename\n}",
+		},
+		{
+			name:  "fragments and unions",
+			query: "{\n  search(text: \"golem\") {\n    __typename\n    ... on User { ...UserFields }\n    ... on Post { title }\n  }\n}\nfragment UserFields on User { id name }",
+		},
+		{
+			name:  "introspection fields",
+			query: "{ __schema { types { name } } __type(name: \"User\") { kind } }",
+		},
+		{
+			name:  "unknown field",
+			query: "{\n  user(id: 1) { nam }\n}",
+			want:  []Problem{{Line: 2, Column: 17, Length: 3, Message: `Cannot query field "nam" on type "User"`}},
+		},
+		{
+			name:  "unknown argument",
+			query: "{ users(frist: 5) { id } }",
+			want:  []Problem{{Line: 1, Column: 9, Length: 5, Message: `Unknown argument "frist" on field "Query.users"`}},
+		},
+		{
+			name:  "aliased field",
+			query: "{ me: user(id: 1) { handle: login } you: viewer { id } }",
+			want:  []Problem{{Line: 1, Column: 42, Length: 6, Message: `Cannot query field "viewer" on type "Query"`}},
+		},
+		{
+			name:  "missing required argument",
+			query: "{ user { id } }",
+			want:  []Problem{{Line: 1, Column: 3, Length: 4, Message: `Field "user" argument "id" of type "ID!" is required but not provided`}},
+		},
+		{
+			name:  "selection on a scalar",
+			query: "{ user(id: 1) { name { first } } }",
+			want:  []Problem{{Line: 1, Column: 17, Length: 4, Message: `Field "name" must not have a selection since type "String" has no subfields`}},
+		},
+		{
+			name:  "object without a selection",
+			query: "mutation { createUser(name: \"Ada\", role: ADMIN) }",
+			want:  []Problem{{Line: 1, Column: 12, Length: 10, Message: `Field "createUser" of type "User" must have a selection of subfields`}},
+		},
+		{
+			name:  "field of a union",
+			query: "{ search(text: \"x\") { id } }",
+			want:  []Problem{{Line: 1, Column: 23, Length: 2, Message: `Cannot query field "id" on type "SearchResult"`}},
+		},
+		{
+			name:  "no subscription type",
+			query: "subscription { users { id } }",
+			want:  []Problem{{Line: 1, Column: 1, Length: 12, Message: `The schema has no subscription type`}},
+		},
+		{
+			name:  "unknown fragment and type",
+			query: "query ($x: Cursor) { users { ...Missing ... on Comment { id } } }",
+			want: []Problem{
+				{Line: 1, Column: 12, Length: 6, Message: `Unknown type "Cursor"`},
+				{Line: 1, Column: 33, Length: 7, Message: `Unknown fragment "Missing"`},
+				{Line: 1, Column: 48, Length: 7, Message: `Unknown type "Comment"`},
+			},
+		},
+		{
+			name:  "several problems in order",
+			query: "{\n  users { id nam }\n  usr { id }\n}",
+			want: []Problem{
+				{Line: 2, Column: 14, Length: 3, Message: `Cannot query field "nam" on type "User"`},
+				{Line: 3, Column: 3, Length: 3, Message: `Cannot query field "usr" on type "Query"`},
+			},
+		},
+		{
+			name:  "syntax error",
+			query: "{\n  user(id: 1) { id \n}",
+			want:  []Problem{{Line: 3, Column: 2, Length: 1, Message: `Expected "}", found the end of the query`}},
+		},
+		{
+			name:  "unterminated string",
+			query: "{ search(text: \"golem) { id } }",
+			want:  []Problem{{Line: 1, Column: 16, Length: 16, Message: `Unterminated string`}},
+		},
+		{
+			name:  "columns count characters",
+			query: "# Größe\n{ users { id } }\n{ ünknown }",
+			want:  []Problem{{Line: 3, Column: 3, Length: 1, Message: `Unexpected character 'ü'`}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Validate(schema, tc.query); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("problems:\n got %v\nwant %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutSchema(t *testing.T) {
+	if got := Validate(nil, "{ anything(at: all) { goes } }"); got != nil {
+		t.Errorf("problems = %v, want none", got)
+	}
+	if got := Validate(nil, "{ unclosed"); len(got) != 1 {
+		t.Errorf("problems = %v, want the syntax error", got)
+	}
+}
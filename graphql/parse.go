@@ -0,0 +1,546 @@
+package graphql
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Problem is an error in a query, about the token at the 1-based Line and
+// Column, which is Length characters long.
+type Problem struct {
+	Line    int
+	Column  int
+	Length  int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	line   int
+	column int
+	length int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end of the query"
+	case tokenString:
+		return "a string"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+func (t token) problem(format string, args ...any) *Problem {
+	return &Problem{Line: t.line, Column: t.column, Length: max(t.length, 1), Message: fmt.Sprintf(format, args...)}
+}
+
+// lexer splits a query into tokens, skipping white space, commas and
+// comments. Columns count characters, not bytes.
+type lexer struct {
+	source    []rune
+	position  int
+	line      int
+	lineStart int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: []rune(source), line: 1}
+}
+
+func (l *lexer) peekRune(offset int) rune {
+	if l.position+offset < len(l.source) {
+		return l.source[l.position+offset]
+	}
+	return utf8.RuneError
+}
+
+func (l *lexer) skipIgnored() {
+	for l.position < len(l.source) {
+		switch r := l.source[l.position]; {
+		case r == '\n':
+			l.position++
+			l.line++
+			l.lineStart = l.position
+		case r == '\r':
+			l.position++
+			if l.peekRune(0) == '\n' {
+				l.position++
+			}
+			l.line++
+			l.lineStart = l.position
+		case r == ' ' || r == '\t' || r == ',' || r == '\uFEFF':
+			l.position++
+		case r == '#':
+			for l.position < len(l.source) && l.source[l.position] != '\n' && l.source[l.position] != '\r' {
+				l.position++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, *Problem) {
+	l.skipIgnored()
+	start := l.position
+	tok := token{line: l.line, column: start - l.lineStart + 1}
+	if start >= len(l.source) {
+		return tok, nil
+	}
+
+	finish := func(kind tokenKind) (token, *Problem) {
+		tok.kind = kind
+		tok.value = string(l.source[start:l.position])
+		tok.length = l.position - start
+		return tok, nil
+	}
+
+	switch r := l.source[start]; {
+	case r == '.':
+		if l.peekRune(1) == '.' && l.peekRune(2) == '.' {
+			l.position += 3
+			return finish(tokenPunctuator)
+		}
+		tok.length = 1
+		return tok, tok.problem("Unexpected \".\"; a spread is written \"...\"")
+	case isPunctuator(r):
+		l.position++
+		return finish(tokenPunctuator)
+	case isNameStart(r):
+		for l.position < len(l.source) && isNameContinue(l.source[l.position]) {
+			l.position++
+		}
+		return finish(tokenName)
+	case r == '-' || isDigit(r):
+		return l.number(start, tok)
+	case r == '"':
+		if l.peekRune(1) == '"' && l.peekRune(2) == '"' {
+			return l.blockString(start, tok)
+		}
+		return l.string(start, tok)
+	default:
+		tok.length = 1
+		return tok, tok.problem("Unexpected character %q", r)
+	}
+}
+
+func (l *lexer) number(start int, tok token) (token, *Problem) {
+	digits := func() int {
+		n := 0
+		for l.position < len(l.source) && isDigit(l.source[l.position]) {
+			l.position++
+			n++
+		}
+		return n
+	}
+
+	kind := tokenInt
+	if l.source[l.position] == '-' {
+		l.position++
+	}
+	ok := digits() > 0
+	if ok && l.peekRune(0) == '.' {
+		kind = tokenFloat
+		l.position++
+		ok = digits() > 0
+	}
+	if ok && (l.peekRune(0) == 'e' || l.peekRune(0) == 'E') {
+		kind = tokenFloat
+		l.position++
+		if l.peekRune(0) == '+' || l.peekRune(0) == '-' {
+			l.position++
+		}
+		ok = digits() > 0
+	}
+	if ok && l.position < len(l.source) && (isNameStart(l.source[l.position]) || l.source[l.position] == '.') {
+		ok = false
+	}
+
+	tok.kind = kind
+	tok.value = string(l.source[start:l.position])
+	tok.length = l.position - start
+	if !ok {
+		return tok, tok.problem("Invalid number %q", tok.value)
+	}
+	return tok, nil
+}
+
+func (l *lexer) string(start int, tok token) (token, *Problem) {
+	l.position++
+	for l.position < len(l.source) {
+		switch l.source[l.position] {
+		case '"':
+			l.position++
+			tok.kind = tokenString
+			tok.value = string(l.source[start:l.position])
+			tok.length = l.position - start
+			return tok, nil
+		case '\\':
+			l.position += 2
+		case '\n', '\r':
+			tok.length = l.position - start
+			return tok, tok.problem("Unterminated string")
+		default:
+			l.position++
+		}
+	}
+	tok.length = len(l.source) - start
+	return tok, tok.problem("Unterminated string")
+}
+
+// blockString reads a """block string""", which may span lines.
+func (l *lexer) blockString(start int, tok token) (token, *Problem) {
+	l.position += 3
+	for l.position < len(l.source) {
+		switch {
+		case l.source[l.position] == '"' && l.peekRune(1) == '"' && l.peekRune(2) == '"':
+			l.position += 3
+			tok.kind = tokenString
+			tok.value = string(l.source[start:l.position])
+			tok.length = l.position - start
+			return tok, nil
+		case l.source[l.position] == '\\' && l.peekRune(1) == '"' && l.peekRune(2) == '"' && l.peekRune(3) == '"':
+			l.position += 4
+		case l.source[l.position] == '\n':
+			l.position++
+			l.line++
+			l.lineStart = l.position
+		default:
+			l.position++
+		}
+	}
+	tok.length = 3
+	return tok, tok.problem("Unterminated block string")
+}
+
+func isPunctuator(r rune) bool {
+	switch r {
+	case '!', '$', '&', '(', ')', ':', '=', '@', '[', ']', '{', '}', '|':
+		return true
+	}
+	return false
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')
+}
+
+func isNameContinue(r rune) bool {
+	return isNameStart(r) || isDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// A document is a parsed query: its operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  []*fragment
+}
+
+type operation struct {
+	// kind is query, mutation or subscription
+	kind      token
+	variables []variableDefinition
+	selection []*selection
+}
+
+type variableDefinition struct {
+	name     token
+	typeName token
+}
+
+type fragment struct {
+	name          token
+	typeCondition token
+	selection     []*selection
+}
+
+type selectionKind int
+
+const (
+	selectField selectionKind = iota
+	selectFragmentSpread
+	selectInlineFragment
+)
+
+// selection is a field, a fragment spread or an inline fragment. name is
+// the field name or the name of the fragment spread; typeCondition is the
+// type of an inline fragment, if it has one.
+type selection struct {
+	kind          selectionKind
+	name          token
+	arguments     []token
+	typeCondition *token
+	// selection is nil for a field without a selection set
+	selection []*selection
+}
+
+// parser builds a document from the tokens of a query. The first problem
+// stops it: tok then stays at the end, so that every loop finishes.
+type parser struct {
+	lexer   *lexer
+	tok     token
+	problem *Problem
+}
+
+func parse(query string) (*document, *Problem) {
+	p := &parser{lexer: newLexer(query)}
+	p.advance()
+
+	doc := &document{}
+	if p.tok.kind == tokenEOF && p.problem == nil {
+		return nil, p.tok.problem("The query is empty")
+	}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.tok.kind == tokenName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			doc.fragments = append(doc.fragments, p.fragment())
+		default:
+			p.fail("Expected an operation or fragment, found %s", p.tok)
+		}
+	}
+	return doc, p.problem
+}
+
+func (p *parser) advance() {
+	if p.problem != nil {
+		return
+	}
+	tok, problem := p.lexer.next()
+	if problem != nil {
+		p.problem = problem
+		p.tok = token{kind: tokenEOF}
+		return
+	}
+	p.tok = tok
+}
+
+func (p *parser) fail(format string, args ...any) {
+	if p.problem == nil {
+		p.problem = p.tok.problem(format, args...)
+	}
+	p.tok = token{kind: tokenEOF}
+}
+
+// peek reports whether the current token is the punctuator value.
+func (p *parser) peek(value string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == value
+}
+
+// skip moves past the punctuator value if it is the current token.
+func (p *parser) skip(value string) bool {
+	if p.peek(value) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+// more reports whether a list goes on before the punctuator end, moving
+// past end when it does not. A query that ends first is a problem.
+func (p *parser) more(end string) bool {
+	if p.skip(end) {
+		return false
+	}
+	if p.tok.kind == tokenEOF {
+		p.fail("Expected %q, found %s", end, p.tok)
+		return false
+	}
+	return true
+}
+
+func (p *parser) expect(value string) {
+	if !p.skip(value) {
+		p.fail("Expected %q, found %s", value, p.tok)
+	}
+}
+
+func (p *parser) name() token {
+	tok := p.tok
+	if tok.kind != tokenName {
+		p.fail("Expected a name, found %s", tok)
+		return tok
+	}
+	p.advance()
+	return tok
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.tok}
+	if p.peek("{") {
+		op.kind = token{kind: tokenName, value: "query", line: p.tok.line, column: p.tok.column, length: 1}
+	} else {
+		p.advance()
+		if p.tok.kind == tokenName {
+			p.advance()
+		}
+		if p.skip("(") {
+			for p.more(")") {
+				op.variables = append(op.variables, p.variableDefinition())
+			}
+		}
+		p.directives()
+	}
+	op.selection = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDefinition() variableDefinition {
+	p.expect("$")
+	def := variableDefinition{name: p.name()}
+	p.expect(":")
+	def.typeName = p.typeReference()
+	if p.skip("=") {
+		p.value(true)
+	}
+	p.directives()
+	return def
+}
+
+// typeReference reads a type such as [ID!]! and returns its named type.
+func (p *parser) typeReference() token {
+	var named token
+	if p.skip("[") {
+		named = p.typeReference()
+		p.expect("]")
+	} else {
+		named = p.name()
+	}
+	p.skip("!")
+	return named
+}
+
+func (p *parser) fragment() *fragment {
+	p.advance()
+	f := &fragment{name: p.name()}
+	if f.name.value == "on" {
+		p.fail("A fragment cannot be named \"on\"")
+	}
+	if p.tok.kind != tokenName || p.tok.value != "on" {
+		p.fail("Expected \"on\", found %s", p.tok)
+	}
+	p.advance()
+	f.typeCondition = p.name()
+	p.directives()
+	f.selection = p.selectionSet()
+	return f
+}
+
+func (p *parser) selectionSet() []*selection {
+	p.expect("{")
+	selections := []*selection{}
+	for p.more("}") {
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 && p.problem == nil {
+		p.fail("A selection set cannot be empty")
+	}
+	return selections
+}
+
+func (p *parser) selection() *selection {
+	if !p.skip("...") {
+		return p.field()
+	}
+
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		s := &selection{kind: selectFragmentSpread, name: p.name()}
+		p.directives()
+		return s
+	}
+	s := &selection{kind: selectInlineFragment}
+	if p.tok.kind == tokenName {
+		p.advance()
+		typeCondition := p.name()
+		s.typeCondition = &typeCondition
+	}
+	p.directives()
+	s.selection = p.selectionSet()
+	return s
+}
+
+func (p *parser) field() *selection {
+	s := &selection{kind: selectField, name: p.name()}
+	if p.skip(":") {
+		s.name = p.name()
+	}
+	s.arguments = p.arguments()
+	p.directives()
+	if p.peek("{") {
+		s.selection = p.selectionSet()
+	}
+	return s
+}
+
+// arguments reads the arguments of a field or directive, if any, and
+// returns their names.
+func (p *parser) arguments() []token {
+	if !p.skip("(") {
+		return nil
+	}
+	var names []token
+	for p.more(")") {
+		names = append(names, p.name())
+		p.expect(":")
+		p.value(false)
+	}
+	return names
+}
+
+func (p *parser) directives() {
+	for p.skip("@") {
+		p.name()
+		p.arguments()
+	}
+}
+
+// value reads a value. Variables are not allowed in a constant one, such as
+// a default.
+func (p *parser) value(constant bool) {
+	switch {
+	case p.peek("$"):
+		if constant {
+			p.fail("Variables are not allowed here")
+			return
+		}
+		p.advance()
+		p.name()
+	case p.skip("["):
+		for p.more("]") {
+			p.value(constant)
+		}
+	case p.skip("{"):
+		for p.more("}") {
+			p.name()
+			p.expect(":")
+			p.value(constant)
+		}
+	case p.tok.kind == tokenName || p.tok.kind == tokenInt || p.tok.kind == tokenFloat || p.tok.kind == tokenString:
+		p.advance()
+	default:
+		p.fail("Expected a value, found %s", p.tok)
+	}
+}
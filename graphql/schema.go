@@ -0,0 +1,318 @@
+// Package graphql reads the schema of a GraphQL endpoint from the response
+// to the introspection query, and checks queries against it: their syntax,
+// and the fields and arguments they use.
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// IntrospectionQuery is the standard introspection query, as GraphiQL and
+// other clients send it. Type references are followed seven levels deep,
+// enough for the likes of [[String!]!]!.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// ErrNoSchema is returned for a response to the introspection query without
+// a schema, which is what endpoints that disable introspection send.
+var ErrNoSchema = errors.New("the response has no schema; introspection may be disabled")
+
+// The kinds of type in a schema.
+const (
+	KindScalar      = "SCALAR"
+	KindObject      = "OBJECT"
+	KindInterface   = "INTERFACE"
+	KindUnion       = "UNION"
+	KindEnum        = "ENUM"
+	KindInputObject = "INPUT_OBJECT"
+	KindList        = "LIST"
+	KindNonNull     = "NON_NULL"
+)
+
+// Schema is the schema of a GraphQL endpoint, as introspected.
+type Schema struct {
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+	// Types are the named types by name, the introspection types such as
+	// __Schema and __Type among them
+	Types map[string]*Type
+}
+
+type Type struct {
+	Kind          string        `json:"kind"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Fields        []*Field      `json:"fields"`
+	InputFields   []*InputValue `json:"inputFields"`
+	EnumValues    []EnumValue   `json:"enumValues"`
+	PossibleTypes []TypeRef     `json:"possibleTypes"`
+}
+
+type Field struct {
+	Name              string        `json:"name"`
+	Description       string        `json:"description"`
+	Args              []*InputValue `json:"args"`
+	Type              TypeRef       `json:"type"`
+	IsDeprecated      bool          `json:"isDeprecated"`
+	DeprecationReason string        `json:"deprecationReason"`
+}
+
+// InputValue is an argument or a field of an input object. DefaultValue is
+// nil when it has no default.
+type InputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         TypeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type EnumValue struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsDeprecated bool   `json:"isDeprecated"`
+}
+
+// TypeRef refers to a named type, or wraps one in a list or non-null type.
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// String returns the type as it is written in GraphQL, e.g. [ID!]!.
+func (t TypeRef) String() string {
+	switch {
+	case t.OfType == nil:
+		return t.Name
+	case t.Kind == KindNonNull:
+		return t.OfType.String() + "!"
+	case t.Kind == KindList:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// Named returns the name of the named type t refers to, unwrapping lists
+// and non-null types.
+func (t TypeRef) Named() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	return t.Name
+}
+
+// HasFields reports whether fields are selected from t: objects and
+// interfaces.
+func (t *Type) HasFields() bool {
+	return t.Kind == KindObject || t.Kind == KindInterface
+}
+
+// IsComposite reports whether t has a selection set of its own: objects,
+// interfaces and unions.
+func (t *Type) IsComposite() bool {
+	return t.HasFields() || t.Kind == KindUnion
+}
+
+// Field returns the field named name, or nil when t has none.
+func (t *Type) Field(name string) *Field {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// Arg returns the argument named name, or nil when f has none.
+func (f *Field) Arg(name string) *InputValue {
+	for _, arg := range f.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// Signature returns the field as a schema lists it, e.g.
+// user(id: ID!): User.
+func (f *Field) Signature() string {
+	var out strings.Builder
+	out.WriteString(f.Name)
+	if len(f.Args) > 0 {
+		args := make([]string, len(f.Args))
+		for i, arg := range f.Args {
+			args[i] = arg.Name + ": " + arg.Type.String()
+			if arg.DefaultValue != nil {
+				args[i] += " = " + *arg.DefaultValue
+			}
+		}
+		out.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	out.WriteString(": " + f.Type.String())
+	return out.String()
+}
+
+// RootTypes returns the names of the query, mutation and subscription types
+// the schema has, in that order.
+func (s *Schema) RootTypes() []string {
+	var names []string
+	for _, name := range []string{s.QueryType, s.MutationType, s.SubscriptionType} {
+		if name != "" && s.Types[name] != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// field returns the field named name of parent, the introspection fields
+// __typename, and __schema and __type of the query type, among them.
+func (s *Schema) field(parent *Type, name string) *Field {
+	switch {
+	case name == "__typename":
+		return &Field{Name: name, Type: TypeRef{Kind: KindNonNull, OfType: &TypeRef{Kind: KindScalar, Name: "String"}}}
+	case name == "__schema" && parent.Name == s.QueryType:
+		return &Field{Name: name, Type: TypeRef{Kind: KindNonNull, OfType: &TypeRef{Kind: KindObject, Name: "__Schema"}}}
+	case name == "__type" && parent.Name == s.QueryType:
+		nameArg := &InputValue{Name: "name", Type: TypeRef{Kind: KindNonNull, OfType: &TypeRef{Kind: KindScalar, Name: "String"}}}
+		return &Field{Name: name, Args: []*InputValue{nameArg}, Type: TypeRef{Kind: KindObject, Name: "__Type"}}
+	}
+	return parent.Field(name)
+}
+
+// SchemaFromResponse returns the __schema object of a response to the
+// introspection query. A response without one gives ErrNoSchema, with the
+// errors the endpoint sent instead.
+func SchemaFromResponse(body []byte) (json.RawMessage, error) {
+	var response struct {
+		Data *struct {
+			Schema json.RawMessage `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("the response is not GraphQL: %w", err)
+	}
+	if response.Data != nil && len(response.Data.Schema) > 0 && string(response.Data.Schema) != "null" {
+		return response.Data.Schema, nil
+	}
+	if len(response.Errors) == 0 {
+		return nil, ErrNoSchema
+	}
+	messages := make([]string, len(response.Errors))
+	for i, e := range response.Errors {
+		messages[i] = e.Message
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNoSchema, strings.Join(messages, "; "))
+}
+
+// ParseSchema reads the __schema object of an introspection response.
+func ParseSchema(data []byte) (*Schema, error) {
+	var introspected struct {
+		QueryType        *struct{ Name string } `json:"queryType"`
+		MutationType     *struct{ Name string } `json:"mutationType"`
+		SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+		Types            []*Type                `json:"types"`
+	}
+	if err := json.Unmarshal(data, &introspected); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if introspected.QueryType == nil {
+		return nil, errors.New("invalid schema: it has no query type")
+	}
+
+	schema := &Schema{
+		QueryType: introspected.QueryType.Name,
+		Types:     make(map[string]*Type, len(introspected.Types)),
+	}
+	if introspected.MutationType != nil {
+		schema.MutationType = introspected.MutationType.Name
+	}
+	if introspected.SubscriptionType != nil {
+		schema.SubscriptionType = introspected.SubscriptionType.Name
+	}
+	for _, t := range introspected.Types {
+		schema.Types[t.Name] = t
+	}
+	return schema, nil
+}
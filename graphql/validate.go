@@ -0,0 +1,140 @@
+package graphql
+
+import "sort"
+
+// Validate checks query and returns its problems, ordered by where they
+// are. A syntax error is the only problem reported, as nothing after it
+// can be read. With a nil schema only the syntax is checked; otherwise
+// the operation types, the fields and arguments, the fragments and the
+// variable types must be those of schema.
+func Validate(schema *Schema, query string) []Problem {
+	doc, problem := parse(query)
+	if problem != nil {
+		return []Problem{*problem}
+	}
+	if schema == nil {
+		return nil
+	}
+
+	v := &validator{schema: schema, fragments: make(map[string]bool, len(doc.fragments))}
+	for _, f := range doc.fragments {
+		v.fragments[f.name.value] = true
+	}
+
+	for _, op := range doc.operations {
+		for _, def := range op.variables {
+			if schema.Types[def.typeName.value] == nil {
+				v.report(def.typeName, "Unknown type %q", def.typeName.value)
+			}
+		}
+
+		var root string
+		switch op.kind.value {
+		case "query":
+			root = schema.QueryType
+		case "mutation":
+			root = schema.MutationType
+		case "subscription":
+			root = schema.SubscriptionType
+		}
+		if root == "" || schema.Types[root] == nil {
+			v.report(op.kind, "The schema has no %s type", op.kind.value)
+			continue
+		}
+		v.checkSelection(schema.Types[root], op.selection)
+	}
+
+	for _, f := range doc.fragments {
+		if t := v.conditionType(f.typeCondition); t != nil {
+			v.checkSelection(t, f.selection)
+		}
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i], v.problems[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.problems
+}
+
+type validator struct {
+	schema    *Schema
+	fragments map[string]bool
+	problems  []Problem
+}
+
+func (v *validator) report(tok token, format string, args ...any) {
+	v.problems = append(v.problems, *tok.problem(format, args...))
+}
+
+// conditionType returns the type a fragment applies to, reporting it when
+// there is no such type or it has no fields.
+func (v *validator) conditionType(name token) *Type {
+	t := v.schema.Types[name.value]
+	switch {
+	case t == nil:
+		v.report(name, "Unknown type %q", name.value)
+		return nil
+	case !t.IsComposite():
+		v.report(name, "A fragment cannot apply to %q, which has no fields", name.value)
+		return nil
+	}
+	return t
+}
+
+func (v *validator) checkSelection(parent *Type, selections []*selection) {
+	for _, s := range selections {
+		switch s.kind {
+		case selectFragmentSpread:
+			if !v.fragments[s.name.value] {
+				v.report(s.name, "Unknown fragment %q", s.name.value)
+			}
+		case selectInlineFragment:
+			t := parent
+			if s.typeCondition != nil {
+				t = v.conditionType(*s.typeCondition)
+			}
+			if t != nil {
+				v.checkSelection(t, s.selection)
+			}
+		default:
+			v.checkField(parent, s)
+		}
+	}
+}
+
+func (v *validator) checkField(parent *Type, s *selection) {
+	field := v.schema.field(parent, s.name.value)
+	if field == nil {
+		v.report(s.name, "Cannot query field %q on type %q", s.name.value, parent.Name)
+		return
+	}
+
+	given := make(map[string]bool, len(s.arguments))
+	for _, arg := range s.arguments {
+		given[arg.value] = true
+		if field.Arg(arg.value) == nil {
+			v.report(arg, "Unknown argument %q on field \"%s.%s\"", arg.value, parent.Name, field.Name)
+		}
+	}
+	for _, arg := range field.Args {
+		if arg.Type.Kind == KindNonNull && arg.DefaultValue == nil && !given[arg.Name] {
+			v.report(s.name, "Field %q argument %q of type %q is required but not provided", field.Name, arg.Name, arg.Type.String())
+		}
+	}
+
+	t := v.schema.Types[field.Type.Named()]
+	switch {
+	case t == nil:
+		return
+	case !t.IsComposite() && s.selection != nil:
+		v.report(s.name, "Field %q must not have a selection since type %q has no subfields", field.Name, field.Type.String())
+	case t.IsComposite() && s.selection == nil:
+		v.report(s.name, "Field %q of type %q must have a selection of subfields", field.Name, field.Type.String())
+	case t.IsComposite():
+		v.checkSelection(t, s.selection)
+	}
+}
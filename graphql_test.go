@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"golem/graphql"
+	"golem/ui"
+)
+
+func TestIntrospectGraphQL(t *testing.T) {
+	schemaResponse, err := os.ReadFile("graphql/testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *http.Request
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		var payload struct{ Query string }
+		json.NewDecoder(r.Body).Decode(&payload)
+		gotQuery = payload.Query
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"message":"not signed in"}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(schemaResponse)
+	}))
+	defer server.Close()
+
+	// The request's own method, body type and script are replaced; its
+	// headers and auth are kept
+	request := introspectionRequest(RequestInfo{
+		Method:   http.MethodGet,
+		URL:      server.URL,
+		Headers:  []ui.KeyValue{{Key: "Content-Type", Value: "text/plain"}, {Key: "X-Tenant", Value: "acme"}},
+		BodyType: ui.BodyTypeRaw,
+		Body:     "{ users { id } }",
+		Auth:     ui.AuthConfig{Type: ui.AuthTypeBearer, Token: "secret"},
+		Script:   `request.body = "changed"`,
+	})
+	schema, data, err := introspectGraphQL(nil, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Content-Type") != "application/json" || got.Header.Get("X-Tenant") != "acme" {
+		t.Errorf("sent %s with Content-Type %q and X-Tenant %q", got.Method, got.Header.Get("Content-Type"), got.Header.Get("X-Tenant"))
+	}
+	if gotQuery != graphql.IntrospectionQuery {
+		t.Errorf("sent the query %q", gotQuery)
+	}
+	if schema.QueryType != "Query" || schema.Types["User"] == nil {
+		t.Errorf("schema = %+v", schema)
+	}
+	if cached, err := graphql.ParseSchema(data); err != nil || cached.Types["User"] == nil {
+		t.Errorf("the JSON to cache does not parse again: %v", err)
+	}
+
+	// An endpoint that refuses introspection
+	request.Auth = ui.AuthConfig{}
+	_, _, err = introspectGraphQL(nil, request)
+	if !errors.Is(err, graphql.ErrNoSchema) || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "not signed in") {
+		t.Errorf("error = %v, want ErrNoSchema with the status and message", err)
+	}
+}
@@ -0,0 +1,301 @@
+// Package grpc calls unary gRPC methods of servers that support reflection,
+// converting messages to and from JSON.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The reflection service under its released and its older alpha name, which
+// many servers still register alone. The messages are the same on the wire.
+var reflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+var ErrNoReflection = errors.New("the server does not support reflection, so its services cannot be listed")
+
+// Method is a method of a service, with a JSON template of its request.
+type Method struct {
+	Name            string
+	ClientStreaming bool
+	ServerStreaming bool
+	RequestTemplate string
+}
+
+// Unary reports whether the method takes and returns a single message, the
+// only kind that can be invoked.
+func (m Method) Unary() bool {
+	return !m.ClientStreaming && !m.ServerStreaming
+}
+
+// Response is the outcome of a call. Code is OK unless the call failed, and
+// Message is the response message as JSON when it succeeded.
+type Response struct {
+	Code          codes.Code
+	StatusMessage string
+	Message       string
+	Header        metadata.MD
+	Trailer       metadata.MD
+}
+
+// Client is a connection to one server with the descriptors found through
+// reflection so far.
+type Client struct {
+	conn *grpclib.ClientConn
+
+	mu       sync.Mutex
+	services map[string]protoreflect.ServiceDescriptor
+	types    map[string]*dynamicpb.Types
+}
+
+// Dial connects to address, a host:port. A nil tlsConfig connects in
+// plaintext.
+func Dial(address string, tlsConfig *tls.Config) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpclib.NewClient(address, grpclib.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn:     conn,
+		services: make(map[string]protoreflect.ServiceDescriptor),
+		types:    make(map[string]*dynamicpb.Types),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// reflect sends request to the reflection service and returns its answer.
+func (c *Client) reflect(ctx context.Context, request *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	for _, method := range reflectionMethods {
+		stream, err := c.conn.NewStream(ctx, &grpclib.StreamDesc{ClientStreams: true, ServerStreams: true}, method)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.SendMsg(request); err != nil {
+			return nil, err
+		}
+		if err := stream.CloseSend(); err != nil {
+			return nil, err
+		}
+
+		response := &reflectionpb.ServerReflectionResponse{}
+		err = stream.RecvMsg(response)
+		if status.Code(err) == codes.Unimplemented {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if errResponse := response.GetErrorResponse(); errResponse != nil {
+			return nil, fmt.Errorf("reflection: %s", errResponse.GetErrorMessage())
+		}
+		return response, nil
+	}
+	return nil, ErrNoReflection
+}
+
+// Services lists the services of the server, apart from reflection itself.
+func (c *Client) Services(ctx context.Context) ([]string, error) {
+	response, err := c.reflect(ctx, &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		switch service.GetName() {
+		case "grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection":
+			continue
+		}
+		services = append(services, service.GetName())
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// Methods lists the methods of service.
+func (c *Client) Methods(ctx context.Context, service string) ([]Method, error) {
+	descriptor, _, err := c.service(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := descriptor.Methods()
+	list := make([]Method, methods.Len())
+	for i := range list {
+		method := methods.Get(i)
+		template, _ := protojson.MarshalOptions{EmitUnpopulated: true, Multiline: true, Indent: "  "}.
+			Marshal(dynamicpb.NewMessage(method.Input()))
+		list[i] = Method{
+			Name:            string(method.Name()),
+			ClientStreaming: method.IsStreamingClient(),
+			ServerStreaming: method.IsStreamingServer(),
+			RequestTemplate: string(template),
+		}
+	}
+	return list, nil
+}
+
+// service finds the descriptor of service and the types of its files,
+// fetching them through reflection the first time.
+func (c *Client) service(ctx context.Context, service string) (protoreflect.ServiceDescriptor, *dynamicpb.Types, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if descriptor, ok := c.services[service]; ok {
+		return descriptor, c.types[service], nil
+	}
+
+	response, err := c.reflect(ctx, &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The server sends the file with its dependencies, but may leave out
+	// some, e.g. well-known types, which are then looked up locally or asked
+	// for by name
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	if err := addFiles(files, response); err != nil {
+		return nil, nil, err
+	}
+	for {
+		missing := missingDependencies(files)
+		if len(missing) == 0 {
+			break
+		}
+		for _, name := range missing {
+			if local, err := protoregistry.GlobalFiles.FindFileByPath(name); err == nil {
+				files[name] = protodesc.ToFileDescriptorProto(local)
+				continue
+			}
+			response, err := c.reflect(ctx, &reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := addFiles(files, response); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := files[name]; !ok {
+				return nil, nil, fmt.Errorf("the server did not send %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid descriptors from the server: %w", err)
+	}
+	found, err := registry.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %s not found", service)
+	}
+	descriptor, ok := found.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a service", service)
+	}
+
+	c.services[service] = descriptor
+	c.types[service] = dynamicpb.NewTypes(registry)
+	return descriptor, c.types[service], nil
+}
+
+func addFiles(files map[string]*descriptorpb.FileDescriptorProto, response *reflectionpb.ServerReflectionResponse) error {
+	for _, data := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, file); err != nil {
+			return fmt.Errorf("invalid descriptor from the server: %w", err)
+		}
+		files[file.GetName()] = file
+	}
+	return nil
+}
+
+func missingDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		for _, dependency := range file.GetDependency() {
+			if _, ok := files[dependency]; !ok && !seen[dependency] {
+				seen[dependency] = true
+				missing = append(missing, dependency)
+			}
+		}
+	}
+	return missing
+}
+
+// Invoke calls a unary method with the request message given as JSON and
+// md as metadata. A call the server rejects is not an error; its status is
+// in the response.
+func (c *Client) Invoke(ctx context.Context, service, method, requestJSON string, md metadata.MD) (*Response, error) {
+	serviceDescriptor, types, err := c.service(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("service %s has no method %s", service, method)
+	}
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return nil, fmt.Errorf("%s is a streaming method; only unary methods can be invoked", method)
+	}
+
+	request := dynamicpb.NewMessage(methodDescriptor.Input())
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal([]byte(requestJSON), request); err != nil {
+		return nil, fmt.Errorf("invalid request message: %w", err)
+	}
+	reply := dynamicpb.NewMessage(methodDescriptor.Output())
+
+	response := &Response{}
+	err = c.conn.Invoke(metadata.NewOutgoingContext(ctx, md), "/"+service+"/"+method, request, reply,
+		grpclib.Header(&response.Header), grpclib.Trailer(&response.Trailer))
+	callStatus := status.Convert(err)
+	response.Code = callStatus.Code()
+	response.StatusMessage = callStatus.Message()
+	if err != nil {
+		return response, nil
+	}
+
+	message, err := protojson.MarshalOptions{Resolver: types, Multiline: true, Indent: "  "}.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert the response to JSON: %w", err)
+	}
+	response.Message = string(message)
+	return response, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golem/ui"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcTLSConfig builds the TLS settings of a gRPC connection from the trusted
+// CAs, client certificates and verification setting of request, whose URL
+// names the server.
+func grpcTLSConfig(request *RequestInfo) (*tls.Config, error) {
+	transport, err := newTransport(request)
+	if err != nil {
+		return nil, err
+	}
+	if transport.TLSClientConfig == nil {
+		return &tls.Config{}, nil
+	}
+	return transport.TLSClientConfig, nil
+}
+
+// grpcHistoryURL records a call in the history as grpc:// or, with TLS,
+// grpcs://host:port/package.Service/Method.
+func grpcHistoryURL(call ui.GRPCCall) string {
+	scheme := "grpc"
+	if call.TLS {
+		scheme = "grpcs"
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, call.Address, call.Service, call.Method)
+}
+
+// parseGRPCHistoryURL reverses grpcHistoryURL.
+func parseGRPCHistoryURL(historyURL string) (ui.GRPCCall, bool) {
+	var call ui.GRPCCall
+	scheme, rest, ok := strings.Cut(historyURL, "://")
+	if !ok || (scheme != "grpc" && scheme != "grpcs") {
+		return call, false
+	}
+	call.TLS = scheme == "grpcs"
+
+	address, fullMethod, ok := strings.Cut(rest, "/")
+	if !ok {
+		return call, false
+	}
+	slash := strings.LastIndex(fullMethod, "/")
+	if slash == -1 {
+		return call, false
+	}
+	call.Address = address
+	call.Service = fullMethod[:slash]
+	call.Method = fullMethod[slash+1:]
+	return call, true
+}
+
+// grpcMetadata converts the rows of the metadata table; keys are lower case
+// in gRPC.
+func grpcMetadata(pairs []ui.KeyValue) metadata.MD {
+	md := metadata.MD{}
+	for _, pair := range pairs {
+		if pair.Key != "" {
+			md.Append(pair.Key, pair.Value)
+		}
+	}
+	return md
+}
+
+// describeGRPCMetadata lists the response headers and trailers, one per line.
+func describeGRPCMetadata(header, trailer metadata.MD) string {
+	var text strings.Builder
+	for _, part := range []struct {
+		title string
+		md    metadata.MD
+	}{{"Headers", header}, {"Trailers", trailer}} {
+		fmt.Fprintf(&text, "%s:\n", part.title)
+		keys := make([]string, 0, len(part.md))
+		for key := range part.md {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) == 0 {
+			text.WriteString("  (none)\n")
+		}
+		for _, key := range keys {
+			for _, value := range part.md[key] {
+				fmt.Fprintf(&text, "  %s: %s\n", key, value)
+			}
+		}
+		text.WriteString("\n")
+	}
+	return strings.TrimSuffix(text.String(), "\n")
+}
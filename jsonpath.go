@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one step of a parsed JSON path: an object key, an array
+// index (negative counts from the end), or a wildcard over every element.
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the subset of JSONPath used for assertions and
+// extractors: $, .key, ['key'] or ["key"], [n], [*] and .*. A final .length
+// gives the length of an array, object or string when there is no such key.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("a JSON path starts with $")
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			steps = append(steps, jsonPathStep{wildcard: true})
+			rest = rest[2:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in %q", path)
+			}
+			steps = append(steps, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("missing ] in %q", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if inner == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				continue
+			}
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index [%s] in %q", inner, path)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", rest, path)
+		}
+	}
+	return steps, nil
+}
+
+// evaluateJSONPath returns the values path matches in the JSON document
+// body; none is not an error.
+func evaluateJSONPath(body, path string) ([]interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	// Numbers are kept as written so large IDs are not rounded
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("the response is not JSON: %w", err)
+	}
+
+	current := []interface{}{document}
+	for i, step := range steps {
+		last := i == len(steps)-1
+		var next []interface{}
+		for _, value := range current {
+			next = append(next, applyJSONPathStep(value, step, last)...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func applyJSONPathStep(value interface{}, step jsonPathStep, last bool) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			values := make([]interface{}, 0, len(v))
+			for _, element := range v {
+				values = append(values, element)
+			}
+			return values
+		}
+		if element, ok := v[step.key]; ok && !step.isIndex {
+			return []interface{}{element}
+		}
+		if last && step.key == "length" {
+			return []interface{}{json.Number(strconv.Itoa(len(v)))}
+		}
+	case []interface{}:
+		switch {
+		case step.wildcard:
+			return v
+		case step.isIndex:
+			index := step.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index >= 0 && index < len(v) {
+				return []interface{}{v[index]}
+			}
+		case last && step.key == "length":
+			return []interface{}{json.Number(strconv.Itoa(len(v)))}
+		}
+	case string:
+		if last && step.key == "length" {
+			return []interface{}{json.Number(strconv.Itoa(len([]rune(v))))}
+		}
+	}
+	return nil
+}
+
+// jsonValueText returns a matched value as text: strings as they are, other
+// values as JSON.
+func jsonValueText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// filterPath turns a jq-style path such as .data.items[].id into the JSON
+// path $.data.items[*].id; a JSON path is returned as it is.
+func filterPath(expression string) string {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, ".") {
+		return expression
+	}
+	path := "$" + strings.ReplaceAll(expression, "[]", "[*]")
+	if path == "$." {
+		return "$"
+	}
+	return path
+}
+
+// filterJSON returns the values expression selects in body as indented
+// JSON: the value itself for a path to one value, or an array for a path
+// with a wildcard. Keys of objects come out sorted.
+func filterJSON(body, expression string) (string, error) {
+	path := filterPath(expression)
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	values, err := evaluateJSONPath(body, path)
+	if err != nil {
+		return "", err
+	}
+
+	var result interface{} = values
+	wildcard := false
+	for _, step := range steps {
+		wildcard = wildcard || step.wildcard
+	}
+	if !wildcard {
+		if len(values) == 0 {
+			return "", fmt.Errorf("nothing matches %s", path)
+		}
+		result = values[0]
+	}
+
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// matchJSONPath reports whether expression selects anything in body, a
+// record of an NDJSON response. A body that is not JSON matches nothing.
+func matchJSONPath(body, expression string) (bool, error) {
+	path := filterPath(expression)
+	if _, err := parseJSONPath(path); err != nil {
+		return false, err
+	}
+	values, err := evaluateJSONPath(body, path)
+	return err == nil && len(values) > 0, nil
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golem/ui"
+)
+
+// jwtFields are the names of JSON response fields that are looked at for
+// tokens.
+var jwtFields = map[string]bool{"token": true, "access_token": true, "id_token": true}
+
+// jwksTimeout bounds fetching a JWKS to verify a signature with.
+const jwksTimeout = 10 * time.Second
+
+// jwtHeader is the part of a JWT header that matters for verifying it.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// splitJWT returns the three parts of a compact JWT with the header and
+// payload decoded, or an error for anything else, e.g. an encrypted JWE.
+func splitJWT(token string) (header, payload, signature []byte, err error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, nil, nil, fmt.Errorf("a JWT has three parts separated by dots, this has %d", len(parts))
+	}
+	decoded := make([][]byte, 3)
+	for i, part := range parts {
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, nil, nil, fmt.Errorf("part %d of the JWT is not base64url: %w", i+1, err)
+		}
+	}
+	if !json.Valid(decoded[0]) || !json.Valid(decoded[1]) {
+		return nil, nil, nil, errors.New("the header or payload of the JWT is not JSON")
+	}
+	return decoded[0], decoded[1], decoded[2], nil
+}
+
+// looksLikeJWT reports whether text is a JWT with a header naming its
+// algorithm.
+func looksLikeJWT(text string) bool {
+	header, _, _, err := splitJWT(text)
+	if err != nil {
+		return false
+	}
+	var h jwtHeader
+	return json.Unmarshal(header, &h) == nil && h.Algorithm != ""
+}
+
+// decodeJWT decodes token for the Decode JWT dialog; source tells where it
+// was found.
+func decodeJWT(source, token string) (ui.JWTInfo, error) {
+	header, payload, _, err := splitJWT(token)
+	if err != nil {
+		return ui.JWTInfo{}, err
+	}
+	info := ui.JWTInfo{
+		Source:  source,
+		Token:   strings.TrimSpace(token),
+		Header:  ui.FormatJSON(string(header), ui.BodyViewPretty),
+		Payload: ui.FormatJSON(string(payload), ui.BodyViewPretty),
+	}
+
+	var claims map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if decoder.Decode(&claims) == nil {
+		for _, name := range []string{"iat", "nbf", "exp"} {
+			if number, ok := claims[name].(json.Number); ok {
+				if seconds, err := number.Float64(); err == nil {
+					info.Times = append(info.Times, ui.JWTTime{Claim: name, Time: time.Unix(int64(seconds), 0)})
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// requestJWT returns the JWT the request sends as a bearer token, from its
+// auth settings or an Authorization header.
+func requestJWT(request *RequestInfo) (string, bool) {
+	token := ""
+	switch request.Auth.Type {
+	case ui.AuthTypeBearer, ui.AuthTypeOAuth2:
+		token = request.Auth.Token
+	case ui.AuthTypeNone:
+		if value, ok := findHeader(request.Headers, "Authorization"); ok {
+			scheme, credentials, _ := strings.Cut(strings.TrimSpace(value), " ")
+			if strings.EqualFold(scheme, "Bearer") {
+				token = credentials
+			}
+		}
+	}
+	token = strings.TrimSpace(token)
+	return token, looksLikeJWT(token)
+}
+
+// findJWTs returns the JWTs of an exchange, decoded: the bearer token of the
+// request and the JWTs in the token, access_token and id_token fields of a
+// JSON response body, at any depth.
+func findJWTs(request *RequestInfo, response *ResponseInfo) []ui.JWTInfo {
+	var found []ui.JWTInfo
+	if token, ok := requestJWT(request); ok {
+		if info, err := decodeJWT("Request Authorization header", token); err == nil {
+			found = append(found, info)
+		}
+	}
+
+	// A body loaded in part is not valid JSON
+	if response == nil || response.BodyFile != "" {
+		return found
+	}
+	var body any
+	if json.Unmarshal([]byte(response.Body), &body) != nil {
+		return found
+	}
+	var walk func(value any, path string)
+	walk = func(value any, path string) {
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fieldPath := path + "." + key
+				if text, ok := v[key].(string); ok && jwtFields[strings.ToLower(key)] && looksLikeJWT(text) {
+					if info, err := decodeJWT("Response "+fieldPath, text); err == nil {
+						found = append(found, info)
+					}
+					continue
+				}
+				walk(v[key], fieldPath)
+			}
+		case []any:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(body, "$")
+	return found
+}
+
+// verifyJWT checks the signature of token against key, which is an HMAC
+// secret, a PEM public key or certificate, a JWK or JWKS, or the URL of a
+// JWKS to fetch. It describes the key that verified it. The algorithm must
+// suit the key: a public key is never taken as an HMAC secret, or anyone
+// holding it could sign tokens that verify.
+func verifyJWT(token, key string) (string, error) {
+	header, _, signature, err := splitJWT(token)
+	if err != nil {
+		return "", err
+	}
+	var h jwtHeader
+	if err := json.Unmarshal(header, &h); err != nil {
+		return "", err
+	}
+	token = strings.TrimSpace(token)
+	signed := []byte(token[:strings.LastIndex(token, ".")])
+
+	key = strings.TrimSpace(key)
+	isURL := strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://")
+	isPEM := strings.HasPrefix(key, "-----BEGIN")
+	isJWK := strings.HasPrefix(key, "{")
+	isHMAC := strings.HasPrefix(h.Algorithm, "HS")
+	switch {
+	case key == "":
+		return "", errors.New("enter a secret, a public key or a JWKS URL")
+	case h.Algorithm == "none":
+		return "", errors.New("the token is not signed (alg none)")
+	case isHMAC && (isPEM || isJWK || isURL):
+		return "", fmt.Errorf("the token is signed with %s, which needs a secret rather than a public key", h.Algorithm)
+	case isHMAC:
+		if err := verifyJWTSignature(h.Algorithm, []byte(key), signed, signature); err != nil {
+			return "", err
+		}
+		return "Signature verified with the secret", nil
+	case !isPEM && !isJWK && !isURL:
+		return "", fmt.Errorf("the token is signed with %s, which needs a public key, a JWK or JWKS, or a JWKS URL rather than a secret", h.Algorithm)
+	case isPEM:
+		publicKey, err := parsePEMPublicKey(key)
+		if err != nil {
+			return "", err
+		}
+		if err := verifyJWTSignature(h.Algorithm, publicKey, signed, signature); err != nil {
+			return "", err
+		}
+		return "Signature verified with the public key", nil
+	}
+
+	var jwks []byte
+	if isURL {
+		if jwks, err = fetchJWKS(key); err != nil {
+			return "", err
+		}
+	} else {
+		jwks = []byte(key)
+	}
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		return "", err
+	}
+	tried := 0
+	for _, k := range keys {
+		if h.KeyID != "" && k.KeyID != "" && k.KeyID != h.KeyID {
+			continue
+		}
+		// A key that names its algorithm is used with no other
+		if k.Algorithm != "" && k.Algorithm != h.Algorithm {
+			continue
+		}
+		publicKey, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		tried++
+		if verifyJWTSignature(h.Algorithm, publicKey, signed, signature) == nil {
+			if k.KeyID != "" {
+				return fmt.Sprintf("Signature verified with the key %q", k.KeyID), nil
+			}
+			return "Signature verified with the JWK", nil
+		}
+	}
+	if tried == 0 && h.KeyID != "" {
+		return "", fmt.Errorf("no usable key with the ID %q", h.KeyID)
+	}
+	if tried == 0 {
+		return "", fmt.Errorf("no usable key for %s", h.Algorithm)
+	}
+	return "", errors.New("the signature does not match")
+}
+
+// verifyJWTSignature checks signature over signed with algorithm, an
+// HMAC secret being a []byte and other keys crypto.PublicKeys.
+func verifyJWTSignature(algorithm string, key any, signed, signature []byte) error {
+	if len(algorithm) < 5 {
+		return fmt.Errorf("the algorithm %q is not supported", algorithm)
+	}
+	var hash crypto.Hash
+	switch algorithm[len(algorithm)-3:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	}
+	mismatch := errors.New("the signature does not match")
+
+	switch {
+	case algorithm == "EdDSA":
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("EdDSA needs an Ed25519 key")
+		}
+		if !ed25519.Verify(publicKey, signed, signature) {
+			return mismatch
+		}
+		return nil
+	case hash == 0:
+		return fmt.Errorf("the algorithm %s is not supported", algorithm)
+	}
+	digest := hash.New()
+	digest.Write(signed)
+	sum := digest.Sum(nil)
+
+	switch algorithm[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%s needs a secret", algorithm)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return mismatch
+		}
+		return nil
+	case "RS", "PS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s needs an RSA key", algorithm)
+		}
+		if algorithm[:2] == "PS" {
+			err := rsa.VerifyPSS(publicKey, hash, sum, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+			if err != nil {
+				return mismatch
+			}
+			return nil
+		}
+		if rsa.VerifyPKCS1v15(publicKey, hash, sum, signature) != nil {
+			return mismatch
+		}
+		return nil
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s needs an ECDSA key", algorithm)
+		}
+		if curve := esCurves[algorithm]; curve == nil || publicKey.Curve != curve {
+			return fmt.Errorf("%s needs a key on the curve %s", algorithm, esCurveNames[algorithm])
+		}
+		// The signature is r and s side by side, each the size of the curve
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return mismatch
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, sum, r, s) {
+			return mismatch
+		}
+		return nil
+	}
+	return fmt.Errorf("the algorithm %s is not supported", algorithm)
+}
+
+// esCurves are the curves of the ECDSA algorithms, which are each used with
+// only one.
+var esCurves = map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}
+
+// esCurveNames are the JWK names of esCurves.
+var esCurveNames = map[string]string{"ES256": "P-256", "ES384": "P-384", "ES512": "P-521"}
+
+// parsePEMPublicKey reads a PEM public key or certificate.
+func parsePEMPublicKey(text string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(text))
+	if block == nil {
+		return nil, errors.New("the key is not valid PEM")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// jwk is a JSON Web Key; only the members of public keys are read.
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	// Algorithm is empty when the key may be used with any that suits it
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// parseJWKS reads a JWK Set, or a single JWK.
+func parseJWKS(data []byte) ([]jwk, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("the key is not a secret, PEM, JWK or JWKS: %w", err)
+	}
+	if len(set.Keys) > 0 {
+		return set.Keys, nil
+	}
+	var single jwk
+	if json.Unmarshal(data, &single) == nil && single.KeyType != "" {
+		return []jwk{single}, nil
+	}
+	return nil, errors.New("the JWKS has no keys")
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.KeyType {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("the curve %s is not supported", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("the curve %s is not supported", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("the key type %s is not supported", k.KeyType)
+}
+
+// fetchJWKS downloads a JWKS. It is only called with a URL the user typed
+// in to verify a signature.
+func fetchJWKS(jwksURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch the JWKS: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// signTestJWT returns a token with the header and payload given, signed by
+// sign over its first two parts.
+func signTestJWT(t *testing.T, header string, sign func(signed []byte) []byte) string {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+	signed := encode([]byte(header)) + "." + encode([]byte(`{"sub":"1234567890","name":"Ada"}`))
+	return signed + "." + encode(sign([]byte(signed)))
+}
+
+func hmacSHA256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	encode := base64.RawURLEncoding.EncodeToString
+	rsaJWK := func(alg string) string {
+		data, _ := json.Marshal(map[string]string{
+			"kty": "RSA", "kid": "k1", "alg": alg,
+			"n": encode(rsaKey.N.Bytes()), "e": encode([]byte{1, 0, 1}),
+		})
+		return string(data)
+	}
+	signRS256 := func(signed []byte) []byte {
+		sum := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecDER}))
+	signES256WithP384 := func(signed []byte) []byte {
+		sum := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(r.FillBytes(make([]byte, 48)), s.FillBytes(make([]byte, 48))...)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		key   string
+		// wantErr is part of the error expected, empty when it verifies
+		wantErr string
+	}{
+		{"hmac secret", signTestJWT(t, `{"alg":"HS256"}`, hmacSHA256([]byte("s3cret"))), "s3cret", ""},
+		{"wrong hmac secret", signTestJWT(t, `{"alg":"HS256"}`, hmacSHA256([]byte("s3cret"))), "other", "does not match"},
+		{"rsa pem", signTestJWT(t, `{"alg":"RS256"}`, signRS256), rsaPEM, ""},
+		{"rsa jwk", signTestJWT(t, `{"alg":"RS256","kid":"k1"}`, signRS256), rsaJWK("RS256"), ""},
+		{"rsa jwks", signTestJWT(t, `{"alg":"RS256","kid":"k1"}`, signRS256), `{"keys":[` + rsaJWK("") + `]}`, ""},
+		// A token forged by using the public key as the HMAC secret
+		{"hmac with a pem", signTestJWT(t, `{"alg":"HS256"}`, hmacSHA256([]byte(rsaPEM))), rsaPEM, "needs a secret"},
+		{"hmac with a jwk", signTestJWT(t, `{"alg":"HS256"}`, hmacSHA256([]byte(rsaJWK("")))), rsaJWK(""), "needs a secret"},
+		{"hmac with a jwks url", signTestJWT(t, `{"alg":"HS256"}`, hmacSHA256([]byte("https://example.com/jwks"))), "https://example.com/jwks", "needs a secret"},
+		{"rsa with a secret", signTestJWT(t, `{"alg":"RS256"}`, signRS256), "s3cret", "needs a public key"},
+		{"jwk for another algorithm", signTestJWT(t, `{"alg":"RS256","kid":"k1"}`, signRS256), rsaJWK("PS256"), "no usable key"},
+		{"rsa key for ecdsa", signTestJWT(t, `{"alg":"ES256"}`, signRS256), rsaPEM, "needs an ECDSA key"},
+		{"ecdsa key on the wrong curve", signTestJWT(t, `{"alg":"ES256"}`, signES256WithP384), ecPEM, "curve P-256"},
+		{"none", signTestJWT(t, `{"alg":"none"}`, func([]byte) []byte { return nil }), "s3cret", "not signed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifyJWT(tc.token, tc.key)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != "" && err == nil:
+				t.Errorf("verified, want an error containing %q", tc.wantErr)
+			case tc.wantErr != "" && !strings.Contains(err.Error(), tc.wantErr):
+				t.Errorf("got error %q, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// DefaultMaxDisplaySize is how much of a response body is loaded for
+// display unless Settings say otherwise.
+const DefaultMaxDisplaySize = 5 << 20
+
+// readBody reads body into memory. With a positive limit a longer body is
+// not: its first limit bytes are returned and the whole of it is written to
+// a temporary file, whose path is returned. size is the length of the body
+// either way. Nothing is left behind on an error.
+func readBody(body io.Reader, limit int64) (data []byte, path string, size int64, err error) {
+	if limit <= 0 {
+		data, err = io.ReadAll(body)
+		return data, "", int64(len(data)), err
+	}
+
+	data, err = io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil || int64(len(data)) <= limit {
+		return data, "", int64(len(data)), err
+	}
+
+	file, err := os.CreateTemp("", "golem-body-*")
+	if err != nil {
+		return nil, "", 0, err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		size, err = io.Copy(file, body)
+		size += int64(len(data))
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, "", 0, err
+	}
+	return data[:limit], file.Name(), size, nil
+}
+
+// decodeBodyFile reverses the Content-Encoding of the body in the file at
+// path, read as readBody does. The file is removed once decoded; on an
+// error it is left as it is.
+func decodeBodyFile(contentEncoding, path string, limit int64) (data []byte, decodedPath string, size int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer file.Close()
+
+	reader, err := decodeReader(contentEncoding, file)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	data, decodedPath, size, err = readBody(reader, limit)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	os.Remove(path)
+	return data, decodedPath, size, nil
+}
+
+// spillBody keeps the first limit bytes of a body already in memory and
+// writes the whole of it to a temporary file, as readBody does for a body
+// that is still to be read.
+func spillBody(data []byte, limit int64) ([]byte, string, error) {
+	if limit <= 0 || int64(len(data)) <= limit {
+		return data, "", nil
+	}
+	file, err := os.CreateTemp("", "golem-body-*")
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, "", err
+	}
+	return data[:limit], file.Name(), nil
+}
+
+// readBodyPart returns up to limit bytes of the body in the file at path,
+// from offset on.
+func readBodyPart(path string, offset, limit int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.NewSectionReader(file, offset, limit))
+}
+
+// copyBodyFile writes the body in the file at path to w.
+func copyBodyFile(w io.Writer, path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return io.Copy(w, file)
+}
+
+// removeBodyFile removes the temporary file of a large response body, if it
+// has one.
+func removeBodyFile(response *ResponseInfo) {
+	if response != nil && response.BodyFile != "" {
+		os.Remove(response.BodyFile)
+	}
+}
+
+// completeRunes drops the end of body if it is a character cut in half, as
+// the loaded part of a large body may be.
+func completeRunes(body string) string {
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRuneInString(body[i:]) {
+				return body[:i]
+			}
+			break
+		}
+	}
+	return body
+}
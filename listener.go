@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// listenerMaxBody is how much of a received body is kept; the rest is read
+// and dropped so the sender still gets the canned response.
+const listenerMaxBody = 10 << 20
+
+// listenerShutdownTimeout is how long Stop waits for requests in progress.
+const listenerShutdownTimeout = 5 * time.Second
+
+// webhookListener is a local HTTP server that records every request it
+// receives, for testing webhooks and OAuth callbacks. Received requests are
+// passed to onRequest from the server's goroutines.
+type webhookListener struct {
+	server    *http.Server
+	URL       string
+	config    ui.ListenerConfig
+	onRequest func(*storage.ListenerRequest)
+}
+
+// startListener listens on config.Address and config.Port, where port 0
+// picks a free one, and answers every request with the canned response.
+func startListener(config ui.ListenerConfig, onRequest func(*storage.ListenerRequest)) (*webhookListener, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(config.Address, strconv.Itoa(config.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &webhookListener{
+		URL:       localServerURL(ln.Addr().(*net.TCPAddr)) + "/",
+		config:    config,
+		onRequest: onRequest,
+	}
+	l.server = &http.Server{Handler: l, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := l.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error running the webhook listener: %v\n", err)
+		}
+	}()
+	return l, nil
+}
+
+// localServerURL is the URL a server listening on addr is reached at from
+// this machine: localhost when it listens on the loopback or every
+// interface, otherwise the address itself.
+func localServerURL(addr *net.TCPAddr) string {
+	host := "localhost"
+	if !addr.IP.IsLoopback() && !addr.IP.IsUnspecified() {
+		host = addr.IP.String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(addr.Port))
+}
+
+func (l *webhookListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, listenerMaxBody))
+	if err != nil {
+		fmt.Printf("Error reading a webhook body: %v\n", err)
+	}
+	io.Copy(io.Discard, r.Body)
+
+	// The Host header is kept apart from the others by net/http
+	headers := r.Header.Clone()
+	headers.Set("Host", r.Host)
+	headersJSON, _ := json.Marshal(headers)
+
+	if l.onRequest != nil {
+		l.onRequest(&storage.ListenerRequest{
+			Method:     r.Method,
+			Path:       r.URL.RequestURI(),
+			Headers:    string(headersJSON),
+			Body:       string(body),
+			RemoteAddr: r.RemoteAddr,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	w.WriteHeader(l.config.Status)
+	io.WriteString(w, l.config.Body)
+}
+
+// Stop shuts the server down, letting requests in progress finish first.
+func (l *webhookListener) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), listenerShutdownTimeout)
+	defer cancel()
+	return l.server.Shutdown(ctx)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golem/storage"
+	"golem/ui"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// loadTestBodySample is how much of each response body is kept
+	loadTestBodySample = 256
+	// loadTestMaxRecords caps the per-request records kept for export;
+	// counters and percentiles still cover every request
+	loadTestMaxRecords       = 50000
+	loadTestProgressInterval = 250 * time.Millisecond
+)
+
+// loadTestRecord is one request in a load test.
+type loadTestRecord struct {
+	StartMs    float64 `json:"start_ms"`
+	LatencyMs  float64 `json:"latency_ms"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Size       int     `json:"size"`
+	Error      string  `json:"error,omitempty"`
+	BodySample string  `json:"body_sample,omitempty"`
+}
+
+// loadTestResult collects the outcome of a load test as it runs.
+type loadTestResult struct {
+	mu        sync.Mutex
+	config    ui.LoadTestConfig
+	started   time.Time
+	finished  time.Time
+	completed int
+	errors    int
+	latencies []time.Duration
+	records   []loadTestRecord
+}
+
+// add records a finished request. A request counts as an error when it got
+// no response or a 4xx/5xx status.
+func (r *loadTestResult) add(start time.Time, response *ResponseInfo, err error) {
+	latency := time.Since(start)
+	record := loadTestRecord{
+		StartMs:   milliseconds(start.Sub(r.started)),
+		LatencyMs: milliseconds(latency),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.StatusCode = response.StatusCode
+		record.Size = response.Size
+		record.BodySample = truncateBody(response.Body, loadTestBodySample)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.completed++
+	if err != nil || response.StatusCode >= 400 {
+		r.errors++
+	}
+	r.latencies = append(r.latencies, latency)
+	if len(r.records) < loadTestMaxRecords {
+		r.records = append(r.records, record)
+	}
+}
+
+func truncateBody(body string, max int) string {
+	if len(body) <= max {
+		return body
+	}
+	return body[:max]
+}
+
+// stats computes the live counters; percentiles sort a copy of the
+// latencies so requests can keep completing meanwhile.
+func (r *loadTestResult) stats() ui.LoadTestStats {
+	r.mu.Lock()
+	end := r.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	stats := ui.LoadTestStats{
+		Completed: r.completed,
+		Errors:    r.errors,
+		Elapsed:   end.Sub(r.started),
+	}
+	latencies := append([]time.Duration(nil), r.latencies...)
+	r.mu.Unlock()
+
+	if stats.Elapsed > 0 {
+		stats.RPS = float64(stats.Completed) / stats.Elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.P50 = percentile(latencies, 50)
+		stats.P95 = percentile(latencies, 95)
+		stats.P99 = percentile(latencies, 99)
+	}
+	return stats
+}
+
+// runLoadTest sends request from config.Concurrency workers until the
+// request count or duration is reached or ctx is cancelled. onProgress is
+// called periodically from another goroutine.
+func runLoadTest(ctx context.Context, db *storage.DB, request RequestInfo, config ui.LoadTestConfig, onProgress func(ui.LoadTestStats)) (*loadTestResult, error) {
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+
+	// One transport for every worker so connections are reused
+	transport, err := newTransport(&request)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+	transport.MaxIdleConnsPerHost = config.Concurrency
+
+	request.Transport = transport
+	request.Context = ctx
+	request.OnUploadProgress = nil
+
+	result := &loadTestResult{config: config, started: time.Now()}
+
+	// The bounded channel keeps the producer at most one batch ahead of the
+	// workers
+	jobs := make(chan struct{}, config.Concurrency)
+	go func() {
+		defer close(jobs)
+		for i := 0; config.Requests == 0 || i < config.Requests; i++ {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				// Dynamic variables such as {{uuid}} differ for every request,
+				// and the pre-request script runs for each
+				info, _, err := prepareSend(request)
+				start := time.Now()
+				var response *ResponseInfo
+				if err == nil {
+					response, err = executeWithAuth(db, &info)
+				}
+				// Requests cut off by Stop or the end of the duration are not counted
+				if errors.Is(err, errRequestCancelled) {
+					return
+				}
+				result.add(start, response, err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(loadTestProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			onProgress(result.stats())
+		case <-done:
+			result.mu.Lock()
+			result.finished = time.Now()
+			result.mu.Unlock()
+			return result, nil
+		}
+	}
+}
+
+// writeJSON exports the summary and the per-request records.
+func (r *loadTestResult) writeJSON(w io.Writer) error {
+	stats := r.stats()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export := struct {
+		Concurrency int              `json:"concurrency"`
+		Requests    int              `json:"requests,omitempty"`
+		DurationS   float64          `json:"duration_s,omitempty"`
+		Started     time.Time        `json:"started"`
+		Completed   int              `json:"completed"`
+		Errors      int              `json:"errors"`
+		ElapsedS    float64          `json:"elapsed_s"`
+		RPS         float64          `json:"rps"`
+		P50Ms       float64          `json:"p50_ms"`
+		P95Ms       float64          `json:"p95_ms"`
+		P99Ms       float64          `json:"p99_ms"`
+		Records     []loadTestRecord `json:"records"`
+	}{
+		Concurrency: r.config.Concurrency,
+		Requests:    r.config.Requests,
+		DurationS:   r.config.Duration.Seconds(),
+		Started:     r.started,
+		Completed:   stats.Completed,
+		Errors:      stats.Errors,
+		ElapsedS:    stats.Elapsed.Seconds(),
+		RPS:         stats.RPS,
+		P50Ms:       milliseconds(stats.P50),
+		P95Ms:       milliseconds(stats.P95),
+		P99Ms:       milliseconds(stats.P99),
+		Records:     r.records,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// writeCSV exports one row per request.
+func (r *loadTestResult) writeCSV(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"start_ms", "latency_ms", "status_code", "size", "error"})
+	for _, record := range r.records {
+		status := ""
+		if record.StatusCode != 0 {
+			status = strconv.Itoa(record.StatusCode)
+		}
+		writer.Write([]string{
+			strconv.FormatFloat(record.StartMs, 'f', 3, 64),
+			strconv.FormatFloat(record.LatencyMs, 'f', 3, 64),
+			status,
+			strconv.Itoa(record.Size),
+			record.Error,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *loadTestResult) export(format string, w io.Writer) error {
+	switch format {
+	case "json":
+		return r.writeJSON(w)
+	case "csv":
+		return r.writeCSV(w)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
@@ -1,15 +1,33 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"golem/graphql"
+	"golem/grpc"
+	"golem/oauth"
+	"golem/protobuf"
+	"golem/secrets"
 	"golem/storage"
 	"golem/ui"
 	"image/color"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -19,6 +37,7 @@ import (
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"google.golang.org/grpc/codes"
 )
 
 type AppPreferences struct {
@@ -26,6 +45,152 @@ type AppPreferences struct {
 	WindowHeight float32
 	LastURL      string
 	LastMethod   string
+	Timeout      int
+
+	FollowRedirects bool
+	MaxRedirects    int
+	UseCookies      bool
+	HTTPVersion     string
+	AcceptEncoding  string
+
+	Proxy              ui.ProxyConfig
+	ClientCertificates []ui.ClientCertificate
+	SkipTLSVerify      bool
+	CAFiles            []string
+	UseSystemCAs       bool
+	DefaultHeaders     []ui.KeyValue
+	HostOverrides      []ui.KeyValue
+	Listener           ui.ListenerConfig
+	MockPort           int
+	// MockLAN lets other machines reach the mock server
+	MockLAN bool
+	// CopyCredentials leaves Authorization values unmasked in copied
+	// exchanges
+	CopyCredentials bool
+	// BodyTextStyle is the word wrap and font of the request and response
+	// bodies
+	BodyTextStyle ui.BodyTextStyle
+	// LineNumbers shows the response body with line numbers
+	LineNumbers bool
+	// Links makes the URLs in the response body links
+	Links bool
+	// MaxDisplaySize is how much of a response body is loaded for display
+	MaxDisplaySize int64
+	// ProtoFiles are the descriptor sets and .proto files protobuf
+	// responses are decoded with
+	ProtoFiles []string
+	// TimeThresholds colour response times as fast or slow
+	TimeThresholds ui.TimeThresholds
+	// AutoRevalidate remembers the ETag and Last-Modified of each URL and
+	// makes later GET and HEAD requests to it conditional
+	AutoRevalidate bool
+	// HistoryRetention limits how many history entries are kept and how
+	// much of each response body
+	HistoryRetention ui.HistoryRetention
+	// GroupHistory collapses runs of the same request in the history list
+	GroupHistory bool
+
+	// ActiveEnvironment is the ID of the environment whose variables are
+	// used, or 0 for the global variables only
+	ActiveEnvironment int
+}
+
+type RequestInfo struct {
+	Method     string
+	URL        string
+	Headers    []ui.KeyValue // Disabled rows are kept for the history but not sent
+	BodyType   string
+	Body       string
+	BodyFile   string
+	FormFields []ui.FormField
+	Auth       ui.AuthConfig
+	Timeout    time.Duration
+
+	// Params are the rows of the Params tab, recorded in the history; the
+	// enabled ones are already in the URL
+	Params []ui.KeyValue
+	// PathVariables are the values of the :name segments of URL, put in
+	// place when variables are resolved
+	PathVariables []ui.KeyValue
+
+	FollowRedirects bool
+	MaxRedirects    int
+	HTTPVersion     string
+	AcceptEncoding  string
+	Proxy           ui.ProxyConfig
+	// IPVersion forces IPv4 or IPv6, or is ui.IPVersionAny
+	IPVersion string
+	// UnixSocket is the path of a Unix socket to send the request over
+	// instead of connecting to the URL's host
+	UnixSocket string
+	// Chunked sends the body with Transfer-Encoding: chunked and no
+	// Content-Length
+	Chunked bool
+	// ContentLength, for fault injection, is announced in place of the
+	// body's length; nil sends the real length
+	ContentLength *int64
+	// HostOverrides send connections for a hostname to another address,
+	// keeping the Host header and TLS server name
+	HostOverrides []ui.KeyValue
+
+	ClientCertificates []ui.ClientCertificate
+	InsecureSkipVerify bool
+	CAFiles            []string
+	UseSystemCAs       bool
+	// SNIFromHost takes the TLS server name from a Host header rather than
+	// the URL, so the certificate is checked against it too
+	SNIFromHost bool
+
+	// CookieJar is nil when cookies should be neither sent nor stored
+	CookieJar http.CookieJar
+
+	// OnUploadProgress is called as a large request body is sent, with the
+	// bytes sent of total and the rate in bytes per second. It may still be
+	// called after the response has arrived.
+	OnUploadProgress func(sent, total int64, rate float64)
+
+	// Transport is shared between requests in a load test so connections are
+	// reused; nil builds a transport for this request only
+	Transport *http.Transport
+
+	// Context cancels the request when the Cancel button is pressed; nil
+	// means the request cannot be cancelled
+	Context context.Context
+
+	// Script is the pre-request script run before each send; Variables lets
+	// it read and set variables. ScriptLog collects what the script passed
+	// to console.log, shown with the response.
+	Script    string
+	Variables *scriptVariables
+	ScriptLog []string
+
+	// OnEvent is called from the sending goroutine for each event of a
+	// text/event-stream response, which is then read until it ends or is
+	// cancelled, without a timeout; nil reads it like any other body
+	OnEvent func(event serverEvent, count int)
+
+	// DownloadToFile saves the body to a file instead of loading it. Bodies
+	// larger than downloadThreshold are offered for saving too. OnDownload
+	// is called from the sending goroutine to choose the file, returning nil
+	// to load the body after all; offered tells which case it is. A nil
+	// OnDownload always loads the body.
+	DownloadToFile bool
+	OnDownload     func(fileName string, size int64, offered bool) (file io.WriteCloser, path string)
+	// OnDownloadProgress is called from the sending goroutine as the body
+	// is received, whether it is saved or loaded, with the bytes received of
+	// total, -1 when the size is not known, and the rate in bytes per second
+	OnDownloadProgress func(received, total int64, rate float64)
+
+	// MaxDisplaySize is how much of the body is held in memory; the whole
+	// of a longer one is written to a temporary file. 0 reads any body into
+	// memory.
+	MaxDisplaySize int64
+
+	// OnBodyChunk is called from the sending goroutine with each part of a
+	// body as it arrives, with the bytes and parts so far, and cancelling
+	// then keeps the body read until then. A body the server compresses
+	// itself can only be decoded at the end, so it is not passed on.
+	OnBodyChunk func(chunk []byte, received int64, chunks int)
 }
 
 type ResponseHeader struct {
@@ -33,12 +198,65 @@ type ResponseHeader struct {
 	Value string
 }
 
+// RedirectHop is one redirect response that was followed on the way to the
+// final response.
+type RedirectHop struct {
+	Status   string
+	Location string
+	Elapsed  time.Duration
+}
+
 type ResponseInfo struct {
-	Body         string
-	Headers      []ResponseHeader
-	Status       string
-	Size         int
-	ResponseTime time.Duration
+	// Body is the body decoded to UTF-8 from the charset it declares
+	Body string
+	// RawBody holds the bytes of the body as received, after decoding any
+	// Content-Encoding, for saving it unchanged; it is nil for event
+	// streams and downloads
+	RawBody []byte
+	// Charset is the charset the body declares, "" when it declares none
+	// and is taken to be UTF-8
+	Charset string
+	// BodyFile is a temporary file holding the whole body when it is larger
+	// than the request's MaxDisplaySize, in which case Body and RawBody hold
+	// only its first MaxDisplaySize bytes and Size is that of the whole. It
+	// is removed with removeBodyFile once the response is no longer shown.
+	BodyFile   string
+	Headers    []ResponseHeader
+	Status     string
+	StatusCode int
+	Proto      string
+	Size       int
+	// WireSize is the body size before decoding, or -1 when the transport
+	// decompressed it transparently
+	WireSize        int
+	ContentEncoding string
+	ResponseTime    time.Duration
+	Redirects       []RedirectHop
+
+	// Events are the first maxStreamEvents events of an event stream, out of
+	// EventCount, and nil for other responses; StreamStopped is set when the
+	// stream was cancelled rather than ended
+	Events        []serverEvent
+	EventCount    int
+	StreamStopped bool
+
+	// Partial is set when reading the body was cancelled; Body is what
+	// arrived until then
+	Partial bool
+
+	// DownloadPath is the file the body was saved to, in which case Body is
+	// empty
+	DownloadPath string
+
+	// Timing breaks down the time of the final request
+	Timing *requestTiming
+	// RemoteAddr is the IP address and port the final request was sent to
+	RemoteAddr string
+	// HostOverride describes the host override the final request was sent
+	// with, if any
+	HostOverride string
+	// TLS describes the TLS connection of the final request, nil without TLS
+	TLS *ui.TLSInfo
 }
 
 func loadPreferencesFromDB(db *storage.DB) *AppPreferences {
@@ -46,6 +264,18 @@ func loadPreferencesFromDB(db *storage.DB) *AppPreferences {
 		WindowWidth:  800,
 		WindowHeight: 600,
 		LastMethod:   "GET",
+		Timeout:      ui.DefaultTimeoutSeconds,
+
+		FollowRedirects: true,
+		MaxRedirects:    ui.DefaultMaxRedirects,
+		UseCookies:      true,
+
+		UseSystemCAs: true,
+		Listener:     ui.DefaultListenerConfig(),
+		MockPort:     ui.DefaultMockPort,
+
+		MaxDisplaySize: DefaultMaxDisplaySize,
+		TimeThresholds: ui.DefaultTimeThresholds(),
 	}
 
 	allPrefs, err := db.GetAllPreferences()
@@ -74,6 +304,143 @@ func loadPreferencesFromDB(db *storage.DB) *AppPreferences {
 		prefs.LastMethod = method
 	}
 
+	if timeout, ok := allPrefs["request_timeout"]; ok {
+		if t, err := strconv.Atoi(timeout); err == nil && t >= 0 {
+			prefs.Timeout = t
+		}
+	}
+
+	if follow, ok := allPrefs["follow_redirects"]; ok {
+		prefs.FollowRedirects = follow != "false"
+	}
+
+	if max, ok := allPrefs["max_redirects"]; ok {
+		if m, err := strconv.Atoi(max); err == nil && m >= 0 {
+			prefs.MaxRedirects = m
+		}
+	}
+
+	if cookies, ok := allPrefs["use_cookie_jar"]; ok {
+		prefs.UseCookies = cookies != "false"
+	}
+
+	if version, ok := allPrefs["http_version"]; ok {
+		prefs.HTTPVersion = version
+	}
+
+	if encoding, ok := allPrefs["accept_encoding"]; ok {
+		prefs.AcceptEncoding = encoding
+	}
+
+	if proxy, ok := allPrefs["proxy"]; ok && proxy != "" {
+		if err := json.Unmarshal([]byte(proxy), &prefs.Proxy); err != nil {
+			fmt.Printf("Error parsing proxy settings: %v\n", err)
+		}
+	}
+
+	if certs, ok := allPrefs["client_certificates"]; ok && certs != "" {
+		if err := json.Unmarshal([]byte(certs), &prefs.ClientCertificates); err != nil {
+			fmt.Printf("Error parsing client certificate settings: %v\n", err)
+		}
+	}
+
+	if skip, ok := allPrefs["skip_tls_verify"]; ok {
+		prefs.SkipTLSVerify = skip == "true"
+	}
+
+	if files, ok := allPrefs["ca_files"]; ok && files != "" {
+		if err := json.Unmarshal([]byte(files), &prefs.CAFiles); err != nil {
+			fmt.Printf("Error parsing CA file settings: %v\n", err)
+		}
+	}
+
+	if system, ok := allPrefs["use_system_cas"]; ok {
+		prefs.UseSystemCAs = system != "false"
+	}
+
+	if environment, ok := allPrefs["active_environment"]; ok {
+		if id, err := strconv.Atoi(environment); err == nil {
+			prefs.ActiveEnvironment = id
+		}
+	}
+
+	if headers, ok := allPrefs["default_headers"]; ok && headers != "" {
+		if err := json.Unmarshal([]byte(headers), &prefs.DefaultHeaders); err != nil {
+			fmt.Printf("Error parsing default headers: %v\n", err)
+		}
+	}
+
+	if overrides, ok := allPrefs["host_overrides"]; ok && overrides != "" {
+		if err := json.Unmarshal([]byte(overrides), &prefs.HostOverrides); err != nil {
+			fmt.Printf("Error parsing host overrides: %v\n", err)
+		}
+	}
+
+	if port, ok := allPrefs["mock_port"]; ok {
+		if p, err := strconv.Atoi(port); err == nil {
+			prefs.MockPort = p
+		}
+	}
+
+	if style, ok := allPrefs["body_text_style"]; ok && style != "" {
+		if err := json.Unmarshal([]byte(style), &prefs.BodyTextStyle); err != nil {
+			fmt.Printf("Error parsing body text style: %v\n", err)
+		}
+	}
+
+	if size, ok := allPrefs["max_display_size"]; ok {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil && n > 0 {
+			prefs.MaxDisplaySize = n
+		}
+	}
+
+	if files, ok := allPrefs["proto_files"]; ok && files != "" {
+		if err := json.Unmarshal([]byte(files), &prefs.ProtoFiles); err != nil {
+			fmt.Printf("Error parsing protobuf descriptor settings: %v\n", err)
+		}
+	}
+
+	if thresholds, ok := allPrefs["time_thresholds"]; ok && thresholds != "" {
+		if err := json.Unmarshal([]byte(thresholds), &prefs.TimeThresholds); err != nil {
+			fmt.Printf("Error parsing response time thresholds: %v\n", err)
+		}
+	}
+
+	if retention, ok := allPrefs["history_retention"]; ok && retention != "" {
+		if err := json.Unmarshal([]byte(retention), &prefs.HistoryRetention); err != nil {
+			fmt.Printf("Error parsing history retention settings: %v\n", err)
+		}
+	}
+
+	if mockLAN, ok := allPrefs["mock_lan"]; ok {
+		prefs.MockLAN = mockLAN == "true"
+	}
+	if groupHistory, ok := allPrefs["group_history"]; ok {
+		prefs.GroupHistory = groupHistory == "true"
+	}
+
+	if lineNumbers, ok := allPrefs["line_numbers"]; ok {
+		prefs.LineNumbers = lineNumbers == "true"
+	}
+
+	if links, ok := allPrefs["body_links"]; ok {
+		prefs.Links = links == "true"
+	}
+
+	if copyCredentials, ok := allPrefs["copy_credentials"]; ok {
+		prefs.CopyCredentials = copyCredentials == "true"
+	}
+
+	if autoRevalidate, ok := allPrefs["auto_revalidate"]; ok {
+		prefs.AutoRevalidate = autoRevalidate == "true"
+	}
+
+	if listener, ok := allPrefs["listener"]; ok && listener != "" {
+		if err := json.Unmarshal([]byte(listener), &prefs.Listener); err != nil {
+			fmt.Printf("Error parsing listener settings: %v\n", err)
+		}
+	}
+
 	return prefs
 }
 
@@ -82,22 +449,387 @@ func savePreferencesToDB(db *storage.DB, prefs *AppPreferences) {
 	db.SetPreference("window_height", fmt.Sprintf("%f", prefs.WindowHeight))
 	db.SetPreference("last_url", prefs.LastURL)
 	db.SetPreference("last_method", prefs.LastMethod)
+	db.SetPreference("request_timeout", strconv.Itoa(prefs.Timeout))
+	db.SetPreference("follow_redirects", strconv.FormatBool(prefs.FollowRedirects))
+	db.SetPreference("max_redirects", strconv.Itoa(prefs.MaxRedirects))
+	db.SetPreference("use_cookie_jar", strconv.FormatBool(prefs.UseCookies))
+	db.SetPreference("http_version", prefs.HTTPVersion)
+	db.SetPreference("accept_encoding", prefs.AcceptEncoding)
+
+	proxyJSON, _ := json.Marshal(prefs.Proxy)
+	db.SetPreference("proxy", string(proxyJSON))
+
+	certsJSON, _ := json.Marshal(prefs.ClientCertificates)
+	db.SetPreference("client_certificates", string(certsJSON))
+	db.SetPreference("skip_tls_verify", strconv.FormatBool(prefs.SkipTLSVerify))
+
+	caFilesJSON, _ := json.Marshal(prefs.CAFiles)
+	db.SetPreference("ca_files", string(caFilesJSON))
+	db.SetPreference("use_system_cas", strconv.FormatBool(prefs.UseSystemCAs))
+
+	defaultHeadersJSON, _ := json.Marshal(prefs.DefaultHeaders)
+	db.SetPreference("default_headers", string(defaultHeadersJSON))
+	hostOverridesJSON, _ := json.Marshal(prefs.HostOverrides)
+	db.SetPreference("host_overrides", string(hostOverridesJSON))
+	listenerJSON, _ := json.Marshal(prefs.Listener)
+	db.SetPreference("listener", string(listenerJSON))
+	db.SetPreference("mock_port", strconv.Itoa(prefs.MockPort))
+	db.SetPreference("mock_lan", strconv.FormatBool(prefs.MockLAN))
+	db.SetPreference("copy_credentials", strconv.FormatBool(prefs.CopyCredentials))
+	bodyTextStyleJSON, _ := json.Marshal(prefs.BodyTextStyle)
+	db.SetPreference("body_text_style", string(bodyTextStyleJSON))
+	db.SetPreference("line_numbers", strconv.FormatBool(prefs.LineNumbers))
+	db.SetPreference("body_links", strconv.FormatBool(prefs.Links))
+	db.SetPreference("max_display_size", strconv.FormatInt(prefs.MaxDisplaySize, 10))
+	protoFilesJSON, _ := json.Marshal(prefs.ProtoFiles)
+	db.SetPreference("proto_files", string(protoFilesJSON))
+	thresholdsJSON, _ := json.Marshal(prefs.TimeThresholds)
+	db.SetPreference("time_thresholds", string(thresholdsJSON))
+	db.SetPreference("auto_revalidate", strconv.FormatBool(prefs.AutoRevalidate))
+	retentionJSON, _ := json.Marshal(prefs.HistoryRetention)
+	db.SetPreference("history_retention", string(retentionJSON))
+	db.SetPreference("group_history", strconv.FormatBool(prefs.GroupHistory))
+	db.SetPreference("active_environment", strconv.Itoa(prefs.ActiveEnvironment))
+}
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+var errRequestCancelled = errors.New("request cancelled")
+
+// describeRedirects summarises the redirect chain for the response view. A
+// redirect that was not followed is shown with its Location instead.
+func describeRedirects(response *ResponseInfo) string {
+	if len(response.Redirects) > 0 {
+		lines := []string{fmt.Sprintf("Redirects (%d):", len(response.Redirects))}
+		for i, hop := range response.Redirects {
+			lines = append(lines, fmt.Sprintf("%d. %s → %s (%d ms)", i+1, hop.Status, hop.Location, hop.Elapsed.Milliseconds()))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if response.StatusCode >= 300 && response.StatusCode < 400 {
+		for _, header := range response.Headers {
+			if strings.EqualFold(header.Key, "Location") {
+				return fmt.Sprintf("Redirect not followed → Location: %s", header.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// isTimeout reports whether err was caused by the client timeout expiring.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func timeoutError(timeout time.Duration) error {
+	return fmt.Errorf("client timeout: no complete response within the configured limit of %s", timeout)
+}
+
+// withCookieHeader adds the rows from the Cookies tab as a single Cookie
+// header, merged into any Cookie header already in the headers table.
+func withCookieHeader(headers []ui.KeyValue, cookies []ui.KeyValue) []ui.KeyValue {
+	cookieHeader := ui.CookieHeader(cookies)
+	if cookieHeader == "" {
+		return headers
+	}
+
+	merged := make([]ui.KeyValue, 0, len(headers)+1)
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, "Cookie") && !header.Disabled {
+			cookieHeader = header.Value + "; " + cookieHeader
+			continue
+		}
+		merged = append(merged, header)
+	}
+	return append(merged, ui.KeyValue{Key: "Cookie", Value: cookieHeader})
+}
+
+// storedParams returns the params rows to store with a request, or "" when
+// the URL holds them all because none is disabled or described.
+func storedParams(params []ui.KeyValue) string {
+	for _, param := range params {
+		if param.Disabled || param.Description != "" {
+			paramsJSON, _ := json.Marshal(params)
+			return string(paramsJSON)
+		}
+	}
+	return ""
+}
+
+// storedPathVariables returns the path variable values to store with a
+// request, or "" when the URL has none.
+func storedPathVariables(pathVariables []ui.KeyValue) string {
+	if len(pathVariables) == 0 {
+		return ""
+	}
+	pathVariablesJSON, _ := json.Marshal(pathVariables)
+	return string(pathVariablesJSON)
+}
+
+// withDefaultHeaders adds the default headers from the settings that the
+// request does not already set; request headers win regardless of case.
+func withDefaultHeaders(headers []ui.KeyValue, defaults []ui.KeyValue) []ui.KeyValue {
+	merged := make([]ui.KeyValue, 0, len(defaults)+len(headers))
+	for _, header := range defaults {
+		if header.Key == "" {
+			continue
+		}
+		if _, ok := findHeader(headers, header.Key); !ok {
+			merged = append(merged, header)
+		}
+	}
+	return append(merged, headers...)
+}
+
+// responseContentType returns the Content-Type of response, or "".
+func responseContentType(response *ResponseInfo) string {
+	for _, header := range response.Headers {
+		if strings.EqualFold(header.Key, "Content-Type") {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// statusColor is the colour of the Status label for status: green for
+// success, blue for a redirect, orange for a client error and red for a
+// server error.
+func statusColor(status string) color.Color {
+	if status == "" {
+		return color.White
+	}
+	switch status[0] {
+	case '2':
+		return color.RGBA{R: 0, G: 200, B: 0, A: 255} // Green
+	case '3':
+		return color.RGBA{R: 0, G: 100, B: 255, A: 255} // Blue
+	case '4':
+		return color.RGBA{R: 255, G: 165, B: 0, A: 255} // Orange
+	case '5':
+		return color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+	}
+	return color.White
+}
+
+// responseHeaderPairs returns the headers of response as rows for the
+// Headers tab.
+func responseHeaderPairs(response *ResponseInfo) []ui.KeyValue {
+	pairs := make([]ui.KeyValue, len(response.Headers))
+	for i, header := range response.Headers {
+		pairs[i] = ui.KeyValue{Key: header.Key, Value: header.Value}
+	}
+	return pairs
+}
+
+// setCookieValues returns the Set-Cookie headers of response.
+func setCookieValues(response *ResponseInfo) []string {
+	var values []string
+	for _, header := range response.Headers {
+		if strings.EqualFold(header.Key, "Set-Cookie") {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+// finalURL returns the URL the response came from: the last redirect
+// followed, or the URL requested.
+func finalURL(requestURL string, response *ResponseInfo) string {
+	if len(response.Redirects) > 0 {
+		return response.Redirects[len(response.Redirects)-1].Location
+	}
+	return requestURL
+}
+
+// fetchPreviewImage requests an image of an HTML preview, failing unless
+// the server answers with a 2xx status.
+func fetchPreviewImage(request *RequestInfo) ([]byte, error) {
+	response, err := executeRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("the server answered %s", response.Status)
+	}
+	return []byte(response.Body), nil
+}
+
+// findHeader returns the value of the first enabled header matching name
+// case-insensitively.
+func findHeader(headers []ui.KeyValue, name string) (string, bool) {
+	for _, header := range headers {
+		if !header.Disabled && strings.EqualFold(header.Key, name) {
+			return header.Value, true
+		}
+	}
+	return "", false
+}
+
+// newHTTPRequest builds the request as it goes on the wire, apart from what
+// the client and transport add themselves: cookies from the jar, the default
+// User-Agent and transparent gzip. Its body is opened but not read, so the
+// request must be sent or its body closed.
+func newHTTPRequest(ctx context.Context, request *RequestInfo) (*http.Request, error) {
+	reqBody, err := buildRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	var signed []byte
+	if request.Auth.Type == ui.AuthTypeHMAC {
+		if signed, err = signedBody(request, reqBody); err != nil {
+			return nil, err
+		}
+	}
+
+	requestURL := request.URL
+	if _, httpURL, ok := splitUnixURL(requestURL); ok {
+		requestURL = httpURL
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, requestURL, reqBody.reader)
+	if err != nil {
+		if closer, ok := reqBody.reader.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+	if reqBody.reader != nil {
+		req.ContentLength = reqBody.contentLength
+	}
+	switch {
+	case request.ContentLength != nil:
+		req.Body = wrongLengthBody(ctx, reqBody, *request.ContentLength, request.Timeout)
+		req.ContentLength = *request.ContentLength
+	case request.Chunked && reqBody.reader != nil:
+		// An unknown length makes net/http chunk the body over HTTP/1.1
+		req.ContentLength = -1
+	}
+
+	// Repeated keys are sent as multiple values of the same header. A Host
+	// header replaces the host from the URL, e.g. for a Unix socket.
+	for _, header := range request.Headers {
+		if header.Key == "" || header.Disabled {
+			continue
+		}
+		if strings.EqualFold(header.Key, "Host") {
+			req.Host = header.Value
+			continue
+		}
+		req.Header.Add(header.Key, header.Value)
+	}
+
+	if request.AcceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", request.AcceptEncoding)
+	}
+
+	// Generated bodies such as multipart forms need their own boundary
+	if reqBody.contentType != "" {
+		req.Header.Set("Content-Type", reqBody.contentType)
+	}
+
+	switch request.Auth.Type {
+	case ui.AuthTypeBasic:
+		req.SetBasicAuth(request.Auth.Username, request.Auth.Password)
+	case ui.AuthTypeBearer, ui.AuthTypeOAuth2:
+		req.Header.Set("Authorization", "Bearer "+request.Auth.Token)
+	case ui.AuthTypeHMAC:
+		// Signed last so the signature covers the final headers and body
+		if err := signRequest(req, signed, request.Auth); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+
+	return req, nil
 }
 
-func executeRequest(method, url string) (*ResponseInfo, error) {
+func executeRequest(request *RequestInfo) (*ResponseInfo, error) {
 	startTime := time.Now()
 
+	transport := request.Transport
+	if transport == nil {
+		var err error
+		transport, err = newTransport(request)
+		if err != nil {
+			return nil, err
+		}
+		defer transport.CloseIdleConnections()
+	}
+
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: transport,
+		Jar:       request.CookieJar,
+	}
+
+	var redirects []RedirectHop
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if !request.FollowRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(redirects) >= request.MaxRedirects {
+			return fmt.Errorf("%w: stopped after %d", errTooManyRedirects, request.MaxRedirects)
+		}
+		redirects = append(redirects, RedirectHop{
+			Status:   next.Response.Status,
+			Location: next.URL.String(),
+			Elapsed:  time.Since(startTime),
+		})
+		return nil
+	}
+
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The timeout covers the whole exchange except an event stream, which may
+	// last as long as it likes once it has started. A zero timeout means
+	// waiting indefinitely.
+	requestCtx, stopRequest := context.WithCancel(ctx)
+	defer stopRequest()
+	var timedOut atomic.Bool
+	var timeoutTimer *time.Timer
+	if request.Timeout > 0 {
+		timeoutTimer = time.AfterFunc(request.Timeout, func() {
+			timedOut.Store(true)
+			stopRequest()
+		})
+		defer timeoutTimer.Stop()
 	}
 
-	req, err := http.NewRequest(method, url, nil)
+	req, err := newHTTPRequest(requestCtx, request)
 	if err != nil {
 		return nil, err
 	}
 
+	trace := &timingTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	proxied := usesProxy(transport, req)
+
 	resp, err := client.Do(req)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, errRequestCancelled
+		}
+		if timedOut.Load() || (request.Timeout > 0 && isTimeout(err)) {
+			return nil, timeoutError(request.Timeout)
+		}
+		if errors.Is(err, errTooManyRedirects) {
+			return nil, fmt.Errorf("%w redirects (the limit can be changed in Options)", errors.Unwrap(err))
+		}
+		if proxied {
+			return nil, proxyError(err)
+		}
+		if request.HTTPVersion == ui.HTTPVersionHTTP2 {
+			return nil, fmt.Errorf("HTTP/2 was required but could not be negotiated: %w", err)
+		}
 		return nil, err
 	}
 	defer func(Body io.ReadCloser) {
@@ -107,26 +839,217 @@ func executeRequest(method, url string) (*ResponseInfo, error) {
 		}
 	}(resp.Body)
 
-	body, err := io.ReadAll(resp.Body)
+	if request.HTTPVersion == ui.HTTPVersionHTTP2 && resp.ProtoMajor != 2 {
+		return nil, fmt.Errorf("HTTP/2 was required but the server responded with %s", resp.Proto)
+	}
+
+	if proxied && resp.StatusCode == http.StatusProxyAuthRequired {
+		return nil, errProxyAuth
+	}
+
+	// After redirects the final host may be another one; behind a proxy the
+	// proxy resolves it
+	var hostOverride string
+	if !usesProxy(transport, resp.Request) {
+		if override, ok := findHostOverride(request.HostOverrides, resp.Request.URL.Hostname()); ok {
+			hostOverride = override.Key + " → " + override.Value
+		}
+	}
+
+	security := tlsDetails(resp.TLS, resp.Request.URL.Hostname(), !request.InsecureSkipVerify)
+
+	responseHeaders := make([]ResponseHeader, 0)
+	for key, values := range resp.Header {
+		for _, value := range values {
+			responseHeaders = append(responseHeaders, ResponseHeader{key, value})
+		}
+	}
+
+	// Events are passed on as they arrive. A stream the server compresses
+	// itself can only be decoded at the end, so it is read like any other body.
+	if request.OnEvent != nil && isEventStream(resp.Header) && resp.Header.Get("Content-Encoding") == "" {
+		if timeoutTimer != nil {
+			timeoutTimer.Stop()
+		}
+		events, count, size, err := readEventStream(resp.Body, request.OnEvent)
+		trace.bodyRead()
+		// Cancelling ends the stream; the events so far are the response
+		stopped := errors.Is(ctx.Err(), context.Canceled)
+		if err != nil && !stopped {
+			if timedOut.Load() {
+				return nil, timeoutError(request.Timeout)
+			}
+			return nil, err
+		}
+
+		wireSize, contentEncoding := size, ""
+		if resp.Uncompressed {
+			wireSize, contentEncoding = -1, "gzip"
+		}
+		return &ResponseInfo{
+			Body:            formatEvents(events, count),
+			Headers:         responseHeaders,
+			Status:          resp.Status,
+			StatusCode:      resp.StatusCode,
+			Proto:           resp.Proto,
+			Size:            size,
+			WireSize:        wireSize,
+			ContentEncoding: contentEncoding,
+			ResponseTime:    time.Since(startTime),
+			Redirects:       redirects,
+			Events:          events,
+			EventCount:      count,
+			StreamStopped:   stopped,
+			Timing:          trace.timing(),
+			RemoteAddr:      trace.remoteAddr(),
+			HostOverride:    hostOverride,
+			TLS:             security,
+		}, nil
+	}
+
+	// The body is counted as it arrives, for a progress bar
+	var responseBody io.Reader = resp.Body
+	if request.OnDownloadProgress != nil {
+		responseBody = &progressReader{reader: resp.Body, total: resp.ContentLength, onProgress: request.OnDownloadProgress}
+	}
+
+	if request.OnDownload != nil && request.Method != http.MethodHead &&
+		(request.DownloadToFile || resp.ContentLength > downloadThreshold) {
+		// Choosing the file and saving a large body may take longer than the
+		// timeout; the request can still be cancelled
+		if timeoutTimer != nil {
+			timeoutTimer.Stop()
+		}
+		file, path := request.OnDownload(downloadFileName(resp), resp.ContentLength, !request.DownloadToFile)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			if file != nil {
+				file.Close()
+				os.Remove(path)
+			}
+			return nil, errRequestCancelled
+		}
+		if file == nil && request.DownloadToFile {
+			return nil, errRequestCancelled
+		}
+		if file != nil {
+			// Copied without holding the body in memory
+			size, err := io.Copy(file, responseBody)
+			trace.bodyRead()
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				if errors.Is(ctx.Err(), context.Canceled) {
+					return nil, errRequestCancelled
+				}
+				return nil, fmt.Errorf("could not save the response to %s: %w", path, err)
+			}
+
+			// The body is saved as received; the transport decodes only the
+			// gzip it asked for itself
+			wireSize, contentEncoding := int(size), resp.Header.Get("Content-Encoding")
+			if resp.Uncompressed {
+				wireSize, contentEncoding = -1, "gzip"
+			}
+			return &ResponseInfo{
+				Headers:         responseHeaders,
+				Status:          resp.Status,
+				StatusCode:      resp.StatusCode,
+				Proto:           resp.Proto,
+				Size:            int(size),
+				WireSize:        wireSize,
+				ContentEncoding: contentEncoding,
+				ResponseTime:    time.Since(startTime),
+				Redirects:       redirects,
+				DownloadPath:    path,
+				Timing:          trace.timing(),
+				RemoteAddr:      trace.remoteAddr(),
+				HostOverride:    hostOverride,
+				TLS:             security,
+			}, nil
+		}
+	}
+
+	// A body over the display limit is kept in a temporary file rather than
+	// in memory
+	bodyReader := responseBody
+	var chunks *chunkReader
+	if request.OnBodyChunk != nil && resp.Header.Get("Content-Encoding") == "" {
+		chunks = &chunkReader{reader: responseBody, ctx: ctx, onChunk: request.OnBodyChunk}
+		bodyReader = chunks
+	}
+	body, bodyFile, readSize, err := readBody(bodyReader, request.MaxDisplaySize)
+	trace.bodyRead()
 	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, errRequestCancelled
+		}
+		if timedOut.Load() || (request.Timeout > 0 && isTimeout(err)) {
+			return nil, timeoutError(request.Timeout)
+		}
 		return nil, err
 	}
 
 	responseTime := time.Since(startTime)
 
-	headers := make([]ResponseHeader, 0)
-	for key, values := range resp.Header {
-		for _, value := range values {
-			headers = append(headers, ResponseHeader{key, value})
+	// The transport only decodes gzip it asked for itself; anything else,
+	// including an Accept-Encoding header typed into the headers table,
+	// arrives still encoded
+	wireSize := int(readSize)
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	if resp.Uncompressed {
+		wireSize = -1
+		contentEncoding = "gzip"
+	} else if contentEncoding != "" && bodyFile != "" {
+		decoded, decodedFile, decodedSize, err := decodeBodyFile(contentEncoding, bodyFile, request.MaxDisplaySize)
+		if err != nil {
+			contentEncoding = fmt.Sprintf("%s (not decoded: %v)", contentEncoding, err)
+		} else {
+			body, bodyFile, readSize = decoded, decodedFile, decodedSize
+		}
+	} else if contentEncoding != "" {
+		decoded, err := decodeBody(contentEncoding, body)
+		if err != nil {
+			contentEncoding = fmt.Sprintf("%s (not decoded: %v)", contentEncoding, err)
+		} else {
+			body, readSize = decoded, int64(len(decoded))
+			// Decoding may take the body over the limit
+			if shown, file, err := spillBody(decoded, request.MaxDisplaySize); err == nil {
+				body, bodyFile = shown, file
+			}
 		}
 	}
 
+	// HEAD responses have no body, but report the size it would have had
+	size := int(readSize)
+	if request.Method == http.MethodHead && resp.ContentLength >= 0 {
+		size = int(resp.ContentLength)
+		wireSize = size
+	}
+
+	// The body is shown in UTF-8 whatever charset it declares
+	bodyCharset := responseCharset(resp.Header.Get("Content-Type"), body)
+
 	return &ResponseInfo{
-		Body:         string(body),
-		Headers:      headers,
-		Status:       resp.Status,
-		Size:         len(body),
-		ResponseTime: responseTime,
+		Body:            decodeBodyText(body, bodyCharset),
+		RawBody:         body,
+		Charset:         bodyCharset,
+		BodyFile:        bodyFile,
+		Partial:         chunks != nil && chunks.stopped,
+		Headers:         responseHeaders,
+		Status:          resp.Status,
+		StatusCode:      resp.StatusCode,
+		Proto:           resp.Proto,
+		Size:            size,
+		WireSize:        wireSize,
+		ContentEncoding: contentEncoding,
+		ResponseTime:    responseTime,
+		Redirects:       redirects,
+		Timing:          trace.timing(),
+		RemoteAddr:      trace.remoteAddr(),
+		HostOverride:    hostOverride,
+		TLS:             security,
 	}, nil
 }
 
@@ -146,6 +1069,8 @@ func main() {
 	// Load preferences from the database
 	prefs := loadPreferencesFromDB(db)
 
+	cookieJar := newCookieJar(db)
+
 	// Set a window close handler to save preferences
 	w.SetCloseIntercept(func() {
 		size := w.Canvas().Size()
@@ -156,168 +1081,2886 @@ func main() {
 		w.Close()
 	})
 
-	methodDropdown := widget.NewSelect(
-		[]string{"GET", "POST", "PUT", "PATCH", "DELETE"},
-		func(value string) {
-			prefs.LastMethod = value
-			savePreferencesToDB(db, prefs)
-		},
-	)
-	if prefs.LastMethod != "" {
-		methodDropdown.SetSelected(prefs.LastMethod)
+	// previewShown is set while the request preview is open beside the
+	// editors, which render it again whenever they change
+	var previewShown bool
+	var updatePreview func()
+	// showCachedSchema checks a GraphQL query against the schema cached for
+	// the URL, once the URL or environment changes
+	var showCachedSchema func()
+	requestChanged := func() {
+		if previewShown && updatePreview != nil {
+			updatePreview()
+		}
+		if showCachedSchema != nil {
+			showCachedSchema()
+		}
+	}
+
+	bodyEditor := ui.NewBodyEditor(w)
+	bodyEditor.AllowSnippets(db)
+	bodyEditor.OnChanged = requestChanged
+
+	methodSelector := ui.NewMethodSelector(w)
+	methodSelector.OnChanged = func(method string) {
+		bodyEditor.SetMethod(method)
+		prefs.LastMethod = method
+		savePreferencesToDB(db, prefs)
+		requestChanged()
+	}
+	methodSelector.SetSelected(prefs.LastMethod)
+	bodyEditor.SetMethod(methodSelector.Selected())
+
+	paramsEditor := ui.NewParamsEditor()
+
+	urlEntry := ui.NewURLEntry(db, w)
+	urlEntry.SetPlaceHolder("Enter URL...")
+	if prefs.LastURL != "" {
+		urlEntry.SetText(prefs.LastURL)
+		paramsEditor.SetURL(prefs.LastURL)
+	}
+	urlEntry.OnChanged = func(text string) {
+		paramsEditor.SetURL(text)
+		prefs.LastURL = text
+		savePreferencesToDB(db, prefs)
+		requestChanged()
+	}
+	paramsEditor.OnURLChanged = func(newURL string) {
+		urlEntry.SetText(newURL)
+	}
+	paramsEditor.OnPathVariablesChanged = requestChanged
+	urlEntry.OnSuggestionChosen = func(suggestion *storage.URLSuggestion) {
+		method := suggestion.Method
+		if method == "" || method == methodSelector.Selected() {
+			return
+		}
+		dialog.ShowConfirm("Restore Method",
+			fmt.Sprintf("This URL was last requested with %s. Switch the method to %s?", method, method),
+			func(confirmed bool) {
+				if confirmed {
+					methodSelector.SetSelected(method)
+					methodSelector.OnChanged(method)
+				}
+			}, w)
+	}
+
+	statusLabel := canvas.NewText("Status: -", color.White)
+	sizeLabel := widget.NewLabel("Size: -")
+	timeLabel := widget.NewLabel("Time: -")
+
+	// Says when If-None-Match or If-Modified-Since was added to the request;
+	// Forget drops the validators remembered for its URL
+	conditionalLabel := widget.NewLabel("")
+	conditionalLabel.Wrapping = fyne.TextWrapWord
+	conditionalLabel.Importance = widget.WarningImportance
+	var conditionalURL string
+	forgetValidatorsButton := widget.NewButton("Forget", nil)
+	conditionalRow := container.NewBorder(nil, nil, nil, forgetValidatorsButton, conditionalLabel)
+	conditionalRow.Hide()
+
+	// The charset the body is decoded with, which can be changed for servers
+	// that declare the wrong one
+	charsetSelect := widget.NewSelect(nil, nil)
+	charsetSelect.Hide()
+
+	// What the status code means, expanded below the stats row
+	statusHint := ui.NewStatusHint()
+	// The size breakdown in exact bytes, likewise
+	sizeHint := ui.NewSizeHint()
+
+	statsRow := container.NewGridWithColumns(4,
+		container.NewHBox(statusLabel, statusHint.GetButton()),
+		container.NewBorder(nil, nil, nil, sizeHint.GetButton(), sizeLabel),
+		timeLabel,
+		charsetSelect,
+	)
+
+	// The address the request went to, for DNS and dual-stack debugging
+	remoteAddrLabel := widget.NewLabel("")
+	remoteAddrLabel.Hide()
+
+	// Says the response shown was loaded from the history, not just received
+	storedLabel := widget.NewLabel("")
+	storedLabel.Importance = widget.WarningImportance
+	storedLabel.Wrapping = fyne.TextWrapWord
+	storedBanner := container.NewBorder(nil, nil, widget.NewIcon(theme.HistoryIcon()), nil, storedLabel)
+	storedBanner.Hide()
+
+	tlsWarningLabel := widget.NewLabel("TLS certificate verification is disabled")
+	tlsWarningLabel.Importance = widget.DangerImportance
+	tlsWarning := container.NewHBox(widget.NewIcon(theme.WarningIcon()), tlsWarningLabel)
+	tlsWarning.Hide()
+
+	var uploadSent, uploadTotal int64
+	var uploadRate float64
+	uploadProgress := widget.NewProgressBar()
+	uploadProgress.TextFormatter = func() string {
+		return describeProgress("Sending", uploadSent, uploadTotal, uploadRate)
+	}
+	uploadProgress.Hide()
+
+	// The size of the body may not be known, so its progress shows the
+	// bytes received; downloadVerb tells whether it is saved or loaded
+	var downloadReceived, downloadTotal int64
+	var downloadRate float64
+	downloadVerb := "Receiving"
+	downloadProgress := widget.NewProgressBar()
+	downloadProgress.TextFormatter = func() string {
+		return describeProgress(downloadVerb, downloadReceived, downloadTotal, downloadRate)
+	}
+	downloadProgress.Hide()
+
+	redirectsLabel := widget.NewLabel("")
+	redirectsLabel.Wrapping = fyne.TextWrapBreak
+	redirectsLabel.TextStyle = fyne.TextStyle{Bold: true}
+	redirectsLabel.Hide()
+
+	repeatLabel := widget.NewLabel("")
+	repeatLabel.Wrapping = fyne.TextWrapWord
+	repeatLabel.TextStyle = fyne.TextStyle{Bold: true}
+	repeatLabel.Hide()
+
+	testsLabel := widget.NewLabel("")
+	testsLabel.TextStyle = fyne.TextStyle{Bold: true}
+	testsLabel.Hide()
+
+	extractionsLabel := widget.NewLabel("")
+	extractionsLabel.Wrapping = fyne.TextWrapBreak
+	extractionsLabel.Hide()
+
+	scriptLogLabel := widget.NewLabel("")
+	scriptLogLabel.Wrapping = fyne.TextWrapBreak
+	scriptLogLabel.Hide()
+
+	eventsLabel := widget.NewLabel("")
+	eventsLabel.TextStyle = fyne.TextStyle{Bold: true}
+	eventsLabel.Hide()
+
+	responseArea := ui.NewResponseBodyView(w)
+	responseArea.OnFilter = filterJSON
+	responseArea.OnMatchRecord = matchJSONPath
+
+	// setBodyTextStyle shows the request and response bodies with style and
+	// remembers it
+	setBodyTextStyle := func(style ui.BodyTextStyle) {
+		prefs.BodyTextStyle = style
+		responseArea.SetTextStyle(style)
+		bodyEditor.SetTextStyle(style)
+		savePreferencesToDB(db, prefs)
+	}
+	responseArea.OnTextStyleChanged = setBodyTextStyle
+	responseArea.SetTextStyle(prefs.BodyTextStyle)
+	bodyEditor.SetTextStyle(prefs.BodyTextStyle)
+	responseArea.SetLineNumbers(prefs.LineNumbers)
+	responseArea.OnLineNumbersChanged = func(on bool) {
+		prefs.LineNumbers = on
+		savePreferencesToDB(db, prefs)
+	}
+	responseArea.SetLinks(prefs.Links)
+	responseArea.OnLinksChanged = func(on bool) {
+		prefs.Links = on
+		savePreferencesToDB(db, prefs)
+	}
+	responseArea.OnOpenURL = func(url string) {
+		urlEntry.SetText(url)
+	}
+	responseArea.SetText("Response will appear here...")
+	jsonTree := ui.NewJSONTreeView()
+	jsonTree.OnOpenURL = responseArea.OnOpenURL
+	responseArea.OnProtobufDecoded = jsonTree.SetJSON
+	// The message types protobuf responses are decoded as; a file that no
+	// longer loads is flagged in Settings
+	protoRegistry, _ := protobuf.NewRegistry(prefs.ProtoFiles)
+	responsePreview := ui.NewResponsePreview(w)
+
+	// The request and response of the last send, for the response toolbar
+	var shownRequest *RequestInfo
+	var shownResponse *ResponseInfo
+	// bodyCharset is the charset the shown body is decoded with: the one it
+	// declares unless another is chosen in the charset select
+	var bodyCharset string
+
+	// showResponseBody shows the body of response in the Body, Tree and
+	// Preview tabs
+	showResponseBody := func(response *ResponseInfo) {
+		contentType := responseContentType(response)
+		if response.BodyFile != "" && len(response.RawBody) < response.Size {
+			// Too large to format or preview
+			if ui.IsImageContentType(contentType) && !ui.IsXMLContentType(contentType) {
+				responseArea.SetText(fmt.Sprintf("The body is an image (%s) of %s, too large to preview; use Save Response to keep it", contentType, ui.FormatBytes(int64(response.Size))))
+			} else {
+				responseArea.SetPartialBody(response.Body, contentType, len(response.RawBody), response.Size)
+			}
+			return
+		}
+		switch {
+		case ui.IsImageContentType(contentType) && !ui.IsXMLContentType(contentType):
+			// Binary, so only shown in the Preview tab
+			responseArea.SetText(fmt.Sprintf("The body is an image (%s), shown in the Preview tab", contentType))
+		case protobuf.IsContentType(contentType):
+			// Decoded, which fills the Tree tab too
+			responseArea.SetProtobuf(response.Body, contentType, protoRegistry)
+		default:
+			responseArea.SetBody(response.Body, contentType)
+			if responseArea.IsJSON() {
+				jsonTree.SetJSON(response.Body)
+			} else {
+				jsonTree.SetJSON("")
+			}
+		}
+		switch {
+		case ui.IsHTMLContentType(contentType):
+			responsePreview.SetHTML(response.Body)
+		case ui.IsImageContentType(contentType):
+			responsePreview.SetImage(response.Body, contentType)
+		}
+	}
+
+	// showCharset offers the charsets the body of response can be decoded
+	// with, first that it declares
+	showCharset := func(response *ResponseInfo) {
+		bodyCharset = response.Charset
+		if len(response.RawBody) == 0 {
+			charsetSelect.Hide()
+			return
+		}
+		declared := response.Charset
+		switch {
+		case declared == "":
+			declared = "UTF-8"
+		case !isKnownCharset(declared):
+			declared += ", unknown, shown as UTF-8"
+		}
+		charsetSelect.Options = append([]string{"Auto (" + declared + ")"}, charsetNames...)
+		charsetSelect.Selected = charsetSelect.Options[0]
+		charsetSelect.Refresh()
+		charsetSelect.Show()
+	}
+	charsetSelect.OnChanged = func(choice string) {
+		response := shownResponse
+		if response == nil || response.RawBody == nil {
+			return
+		}
+		bodyCharset = response.Charset
+		if choice != charsetSelect.Options[0] {
+			bodyCharset = choice
+		}
+		response.Body = decodeBodyText(response.RawBody, bodyCharset)
+		showResponseBody(response)
+	}
+	responseToolbar := ui.NewResponseToolbar(w)
+	responseToolbar.OnCopy = func(what string) (string, error) {
+		return responseCopyText(what, shownRequest, shownResponse, !prefs.CopyCredentials)
+	}
+	// The body is written as the bytes received, so binary bodies are saved
+	// intact; a large body is copied from its temporary file
+	saveResponse := func() {
+		response := shownResponse
+		if response.DownloadPath != "" {
+			dialog.ShowInformation("Save Response", "The body was already saved to "+response.DownloadPath, w)
+			return
+		}
+		data := response.RawBody
+		if data == nil {
+			data = []byte(response.Body)
+		}
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			var size int64
+			if response.BodyFile != "" {
+				size, err = copyBodyFile(writer, response.BodyFile)
+			} else {
+				var n int
+				n, err = writer.Write(data)
+				size = int64(n)
+			}
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			ui.ShowToast(fmt.Sprintf("Saved %s to %s", ui.FormatBytes(size), writer.URI().Path()), w)
+		}, w)
+		save.SetFileName(responseFileName(response, shownRequest.URL))
+		save.Show()
+	}
+	responseToolbar.OnSave = saveResponse
+	responseToolbar.OnDecodeJWT = func() {
+		if tokens := findJWTs(shownRequest, shownResponse); len(tokens) > 0 {
+			ui.ShowJWTDialog(tokens, verifyJWT, w)
+		}
+	}
+	responseArea.OnSaveAll = saveResponse
+	// Load More reads the next part of a large body from its temporary file,
+	// so the response holds as much of the body as is shown
+	responseArea.OnLoadMore = func() (string, int, error) {
+		response := shownResponse
+		more, err := readBodyPart(response.BodyFile, int64(len(response.RawBody)), prefs.MaxDisplaySize)
+		if err != nil {
+			return "", 0, err
+		}
+		text := decodeBodyText(more, bodyCharset)
+		response.Body += text
+		response.RawBody = append(response.RawBody, more...)
+		return text, len(response.RawBody), nil
+	}
+
+	responseHeaders := ui.NewResponseHeadersView(w)
+	responseHeaders.OnOpenURL = func(url string) {
+		urlEntry.SetText(url)
+	}
+	responseHeadersTab := container.NewTabItem("Headers", responseHeaders.GetContainer())
+
+	// showResponseHeaders updates the Headers tab, with the count in its title
+	showResponseHeaders := func(headers []ui.KeyValue, requestURL string) {
+		responseHeaders.SetHeaders(headers, requestURL)
+		if count := responseHeaders.Count(); count > 0 {
+			responseHeadersTab.Text = fmt.Sprintf("Headers (%d)", count)
+		} else {
+			responseHeadersTab.Text = "Headers"
+		}
+	}
+
+	responseCookies := ui.NewResponseCookiesView()
+	responseCookiesTab := container.NewTabItem("Cookies", responseCookies.GetContainer())
+
+	// showResponseCookies updates the Cookies tab, with the count in its title
+	showResponseCookies := func(setCookies []string, requestURL string) {
+		responseCookies.SetCookies(setCookies, requestURL)
+		if count := responseCookies.Count(); count > 0 {
+			responseCookiesTab.Text = fmt.Sprintf("Cookies (%d)", count)
+		} else {
+			responseCookiesTab.Text = "Cookies"
+		}
+	}
+
+	timingView := ui.NewTimingView()
+	securityView := ui.NewSecurityView()
+
+	responseTabs := container.NewAppTabs(
+		container.NewTabItem("Body", responseArea.GetContainer()),
+		responseHeadersTab,
+		container.NewTabItem("Tree", jsonTree.GetContainer()),
+		container.NewTabItem("Preview", responsePreview.GetContainer()),
+		responseCookiesTab,
+		container.NewTabItem("Timing", timingView.GetContainer()),
+		container.NewTabItem("Security", securityView.GetContainer()),
+	)
+
+	headersEditor := ui.NewKeyValueEditorWithDescriptions("Header", "Value", "Add Header")
+	headersEditor.AllowBulkEdit()
+	headersEditor.KeySuggestions = func() []string {
+		used, err := db.GetHeaderNames(ui.UsedHeaderNameLimit)
+		if err != nil {
+			fmt.Printf("Error loading header names: %v\n", err)
+		}
+		return ui.HeaderNameSuggestions(used)
+	}
+	headersEditor.ValueSuggestions = func(key string) ([]string, string) {
+		return ui.HeaderValueSuggestions(key), ui.HeaderValueSeparator(key)
+	}
+	cookiesEditor := ui.NewKeyValueEditor("Cookie", "Value", "Add Cookie")
+	authEditor := ui.NewAuthEditor()
+
+	// Auth settings replace any Authorization header typed into the table
+	updateAuthWarning := func() {
+		_, ok := findHeader(headersEditor.GetPairs(), authEditor.GetConfig().Header())
+		authEditor.SetHeaderOverridden(ok)
+	}
+	headersEditor.OnChanged = func() {
+		updateAuthWarning()
+		requestChanged()
+	}
+	cookiesEditor.OnChanged = requestChanged
+
+	responseCookies.OnAddToJar = func(cookie *http.Cookie, requestURL string) error {
+		u, err := url.Parse(requestURL)
+		if err != nil {
+			return err
+		}
+		if _, ok := storedCookie(u, cookie); !ok {
+			return fmt.Errorf("%s cannot set cookies for %s", u.Hostname(), cookie.Domain)
+		}
+		cookieJar.SetCookies(u, []*http.Cookie{cookie})
+		return nil
+	}
+	// A cookie of the same name in the Cookies tab is replaced
+	responseCookies.OnAddToRequest = func(name, value string) {
+		pairs := cookiesEditor.GetPairs()
+		replaced := false
+		for i := range pairs {
+			if pairs[i].Key == name {
+				pairs[i].Value = value
+				replaced = true
+			}
+		}
+		if !replaced {
+			pairs = append(pairs, ui.KeyValue{Key: name, Value: value})
+		}
+		cookiesEditor.SetPairs(pairs)
+		requestChanged()
+	}
+	authEditor.OnChanged = func() {
+		updateAuthWarning()
+		requestChanged()
+		if auth := authEditor.GetConfig(); auth.Type == ui.AuthTypeOAuth2 {
+			authEditor.SetTokenStatus(describeOAuthToken(loadOAuthToken(db, auth)))
+		}
+	}
+	authEditor.OnGetToken = func(auth ui.AuthConfig) {
+		startOAuthFlow(a, w, db, auth, func(token *oauth.Token) {
+			authEditor.SetTokenStatus(describeOAuthToken(token))
+		})
+	}
+
+	optionsEditor := ui.NewRequestOptionsEditor()
+	optionsEditor.SetTimeout(prefs.Timeout)
+	optionsEditor.SetFollowRedirects(prefs.FollowRedirects)
+	optionsEditor.SetMaxRedirects(prefs.MaxRedirects)
+	optionsEditor.SetUseCookies(prefs.UseCookies)
+	optionsEditor.SetHTTPVersion(prefs.HTTPVersion)
+	optionsEditor.SetAcceptEncoding(prefs.AcceptEncoding)
+	// Kept visible for as long as certificates would not be verified
+	updateTLSWarning := func() {
+		if skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify) {
+			tlsWarning.Show()
+		} else {
+			tlsWarning.Hide()
+		}
+	}
+	updateTLSWarning()
+
+	optionsEditor.OnChanged = func() {
+		updateTLSWarning()
+		prefs.Timeout = optionsEditor.GetTimeout()
+		prefs.FollowRedirects = optionsEditor.GetFollowRedirects()
+		prefs.MaxRedirects = optionsEditor.GetMaxRedirects()
+		prefs.UseCookies = optionsEditor.GetUseCookies()
+		prefs.HTTPVersion = optionsEditor.GetHTTPVersion()
+		prefs.AcceptEncoding = optionsEditor.GetAcceptEncoding()
+		savePreferencesToDB(db, prefs)
+		requestChanged()
+	}
+
+	scriptEditor := ui.NewScriptEditor()
+	testsEditor := ui.NewTestsEditor()
+	extractorsEditor := ui.NewExtractorsEditor()
+	webSocketPanel := ui.NewWebSocketPanel(w)
+	grpcPanel := ui.NewGRPCPanel(w)
+	listenerPanel := ui.NewListenerPanel(w)
+	mockPanel := ui.NewMockPanel(w)
+
+	requestTabs := container.NewAppTabs(
+		container.NewTabItem("Params", paramsEditor.GetContainer()),
+		container.NewTabItem("Headers", headersEditor.GetContainer()),
+		container.NewTabItem("Cookies", cookiesEditor.GetContainer()),
+		container.NewTabItem("Body", bodyEditor.GetContainer()),
+		container.NewTabItem("Auth", authEditor.GetContainer()),
+		container.NewTabItem("Options", optionsEditor.GetContainer()),
+		container.NewTabItem("Script", scriptEditor.GetContainer()),
+		container.NewTabItem("Tests", testsEditor.GetContainer()),
+		container.NewTabItem("Extract", extractorsEditor.GetContainer()),
+	)
+
+	// The saved request currently in the editor, if it was opened from a collection
+	var currentSavedRequest *storage.SavedRequest
+
+	// The notes of the saved request, collapsible above the editors
+	notesLabel := widget.NewLabel("")
+	notesLabel.Wrapping = fyne.TextWrapWord
+	notesAccordion := widget.NewAccordion(widget.NewAccordionItem("Notes", notesLabel))
+	notesAccordion.Hide()
+	showNotes := func(notes string) {
+		notesLabel.SetText(notes)
+		if notes == "" {
+			notesAccordion.Hide()
+		} else {
+			notesAccordion.Show()
+		}
+	}
+
+	loadRequest := func(url, method, headersJSON, paramsJSON, pathVariablesJSON, bodyType, body string) {
+		urlEntry.SetText(url)
+		var params []ui.KeyValue
+		if paramsJSON != "" {
+			if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+				fmt.Printf("Error parsing stored params: %v\n", err)
+			}
+		}
+		paramsEditor.SetParams(url, params)
+		var pathVariables []ui.KeyValue
+		if pathVariablesJSON != "" {
+			if err := json.Unmarshal([]byte(pathVariablesJSON), &pathVariables); err != nil {
+				fmt.Printf("Error parsing stored path variables: %v\n", err)
+			}
+		}
+		paramsEditor.SetPathVariables(pathVariables)
+		methodSelector.SetSelected(method)
+		bodyEditor.SetMethod(method)
+
+		var headers []ui.KeyValue
+		if headersJSON != "" {
+			if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+				fmt.Printf("Error parsing stored headers: %v\n", err)
+			}
+		}
+
+		// The Cookie header is edited in the Cookies tab
+		var otherHeaders, cookies []ui.KeyValue
+		for _, header := range headers {
+			if strings.EqualFold(header.Key, "Cookie") && !header.Disabled {
+				cookies = append(cookies, ui.ParseCookieHeader(header.Value)...)
+			} else {
+				otherHeaders = append(otherHeaders, header)
+			}
+		}
+		headersEditor.SetPairs(otherHeaders)
+		cookiesEditor.SetPairs(cookies)
+		updateAuthWarning()
+
+		bodyEditor.SetBodyType(bodyType)
+		switch bodyType {
+		case ui.BodyTypeMultipart:
+			var fields []ui.FormField
+			if err := json.Unmarshal([]byte(body), &fields); err != nil {
+				fmt.Printf("Error parsing stored form fields: %v\n", err)
+			}
+			bodyEditor.SetFormFields(fields)
+			bodyEditor.SetBinaryFile("")
+			bodyEditor.SetBody("")
+			bodyEditor.SetGraphQL("")
+		case ui.BodyTypeBinary:
+			if contentType, ok := findHeader(headers, "Content-Type"); ok {
+				bodyEditor.SetContentType(contentType)
+			}
+			bodyEditor.SetFormFields(nil)
+			bodyEditor.SetBinaryFile(body)
+			bodyEditor.SetBody("")
+			bodyEditor.SetGraphQL("")
+		case ui.BodyTypeGraphQL:
+			bodyEditor.SetFormFields(nil)
+			bodyEditor.SetBinaryFile("")
+			bodyEditor.SetBody("")
+			bodyEditor.SetGraphQL(body)
+		default:
+			if contentType, ok := findHeader(headers, "Content-Type"); ok {
+				bodyEditor.SetContentType(contentType)
+			}
+			bodyEditor.SetFormFields(nil)
+			bodyEditor.SetBinaryFile("")
+			bodyEditor.SetBody(body)
+			bodyEditor.SetGraphQL("")
+		}
+		bodyEditor.SetBodySource(ui.BodySourceInline)
+		bodyEditor.SetBodyFile("")
+	}
+
+	// storedBody serializes the body editor for history and saved requests.
+	// Multipart forms keep field names and file paths, never file contents.
+	storedBody := func() (bodyType, body string) {
+		bodyType = bodyEditor.GetBodyType()
+		switch bodyType {
+		case ui.BodyTypeMultipart:
+			if fields := bodyEditor.GetFormFields(); len(fields) > 0 {
+				fieldsJSON, _ := json.Marshal(fields)
+				body = string(fieldsJSON)
+			}
+		case ui.BodyTypeBinary:
+			body = bodyEditor.GetBinaryFile()
+		case ui.BodyTypeGraphQL:
+			body = bodyEditor.GetGraphQL()
+		default:
+			body = bodyEditor.GetBody()
+		}
+		return bodyType, body
+	}
+
+	// The HTTP, WebSocket and gRPC tabs
+	var modeTabs *container.AppTabs
+	webSocketTab := container.NewTabItem("WebSocket", webSocketPanel.GetContainer())
+	grpcTab := container.NewTabItem("gRPC", grpcPanel.GetContainer())
+	listenerTab := container.NewTabItem("Listener", listenerPanel.GetContainer())
+	mockTab := container.NewTabItem("Mock", mockPanel.GetContainer())
+
+	// webSocket is the open WebSocket connection, if any
+	var webSocket *webSocketSession
+
+	// loadWebSocketSession shows a session from the history in the WebSocket tab
+	loadWebSocketSession := func(item *storage.RequestHistory) {
+		if webSocket != nil {
+			dialog.ShowInformation("WebSocket Connected", "Close the connection before opening a saved session.", w)
+			return
+		}
+
+		var headers []ui.KeyValue
+		if item.Headers != "" {
+			if err := json.Unmarshal([]byte(item.Headers), &headers); err != nil {
+				fmt.Printf("Error parsing stored headers: %v\n", err)
+			}
+		}
+		var messages []ui.WebSocketMessage
+		if item.Transcript != "" {
+			if err := json.Unmarshal([]byte(item.Transcript), &messages); err != nil {
+				fmt.Printf("Error parsing stored transcript: %v\n", err)
+			}
+		}
+
+		webSocketPanel.SetURL(item.URL)
+		webSocketPanel.SetHeaders(headers)
+		webSocketPanel.SetMessages(messages)
+		modeTabs.Select(webSocketTab)
+	}
+
+	// loadGRPCCall shows a call from the history in the gRPC tab
+	loadGRPCCall := func(item *storage.RequestHistory) {
+		call, ok := parseGRPCHistoryURL(item.URL)
+		if !ok {
+			fmt.Printf("Error parsing stored gRPC call: %s\n", item.URL)
+			return
+		}
+		call.Request = item.Body
+		if item.Headers != "" {
+			if err := json.Unmarshal([]byte(item.Headers), &call.Metadata); err != nil {
+				fmt.Printf("Error parsing stored metadata: %v\n", err)
+			}
+		}
+		grpcPanel.SetCall(call)
+		modeTabs.Select(grpcTab)
+	}
+
+	// clearResponse empties the response area, the stats and the response
+	// tabs, and hides the labels below the stats
+	clearResponse := func() {
+		responseArea.SetText("")
+		jsonTree.SetJSON("")
+		responsePreview.Clear()
+		responseToolbar.SetEnabled(false)
+		removeBodyFile(shownResponse)
+		statusLabel.Text = "Status: -"
+		statusLabel.Color = color.White
+		statusLabel.Refresh()
+		statusHint.SetStatus(0)
+		sizeLabel.SetText("Size: -")
+		sizeHint.SetSizes(nil)
+		timeLabel.Importance = widget.MediumImportance
+		timeLabel.SetText("Time: -")
+		charsetSelect.Hide()
+		storedBanner.Hide()
+		remoteAddrLabel.Hide()
+		redirectsLabel.Hide()
+		repeatLabel.Hide()
+		testsLabel.Hide()
+		testsEditor.SetResults(nil)
+		extractionsLabel.Hide()
+		scriptLogLabel.Hide()
+		eventsLabel.Hide()
+		showResponseHeaders(nil, "")
+		showResponseCookies(nil, "")
+		timingView.SetTiming(nil, 0, false)
+		securityView.SetTLS(nil)
+		responseTabs.Refresh()
+	}
+
+	// showStoredResponse shows the response a history entry recorded as a
+	// send would, under a banner saying when it was received
+	showStoredResponse := func(item *storage.RequestHistory) {
+		clearResponse()
+		conditionalRow.Hide()
+		shownRequest, shownResponse = nil, nil
+		storedLabel.SetText(describeStored(item))
+		storedBanner.Show()
+
+		response := storedResponse(item)
+		if response == nil {
+			responseArea.SetText("The request failed, so there is no stored response")
+			statusLabel.Text = "Status: Error"
+			statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+			statusLabel.Refresh()
+			return
+		}
+
+		request := storedRequest(item)
+		if response.DownloadPath != "" {
+			responseArea.SetText(fmt.Sprintf("Saved %d bytes to %s", response.Size, response.DownloadPath))
+		} else if response.Body == "" {
+			responseArea.SetText("(no body)")
+		} else {
+			showResponseBody(response)
+		}
+		statusLabel.Text = fmt.Sprintf("Status: %s (%s)", response.Status, response.Proto)
+		statusLabel.Color = statusColor(response.Status)
+		statusLabel.Refresh()
+		statusHint.SetStatus(response.StatusCode)
+		if response.RemoteAddr != "" {
+			remoteAddrLabel.Importance = widget.MediumImportance
+			remoteAddrLabel.SetText("Remote address: " + response.RemoteAddr)
+			remoteAddrLabel.Show()
+		}
+		sizes := storedSizes(item, response)
+		sizeLabel.SetText(describeSize(response, sizes))
+		sizeHint.SetSizes(sizes)
+		showCharset(response)
+		timeLabel.Importance = prefs.TimeThresholds.Importance(response.ResponseTime)
+		timeLabel.SetText(fmt.Sprintf("Time: %.2f ms", float64(response.ResponseTime.Milliseconds())))
+		if response.Timing != nil {
+			timingView.SetTiming(response.Timing.phases(), response.Timing.Total, response.Timing.Reused)
+		}
+		securityView.SetTLS(response.TLS)
+
+		shownRequest, shownResponse = request, response
+		responseToolbar.SetEnabled(true)
+		responseToolbar.ShowDecodeJWT(len(findJWTs(request, response)) > 0)
+		responseToolbar.ShowRevalidate(isConditionalMethod(request.Method) && responseValidator(request.URL, response) != nil)
+		showResponseHeaders(responseHeaderPairs(response), request.URL)
+		showResponseCookies(setCookieValues(response), request.URL)
+		responseTabs.Refresh()
+
+		var results []ui.AssertionResult
+		if item.TestResults != "" && json.Unmarshal([]byte(item.TestResults), &results) == nil && len(results) > 0 {
+			testsEditor.SetResults(results)
+			testsLabel.SetText(ui.SummarizeResults(results))
+			testsLabel.Importance = widget.SuccessImportance
+			if !ui.AllPassed(results) {
+				testsLabel.Importance = widget.DangerImportance
+			}
+			testsLabel.Show()
+		}
+	}
+
+	// loadHistoryRequest puts the HTTP request of a history entry in the
+	// editors
+	loadHistoryRequest := func(item *storage.RequestHistory) {
+		modeTabs.SelectIndex(0) // HTTP
+		currentSavedRequest = nil
+		showNotes("")
+		responseArea.SetFilter("")
+		loadRequest(item.URL, item.Method, item.Headers, item.Params, item.PathVariables, item.BodyType, item.Body)
+		optionsEditor.SetUnixSocket(item.UnixSocket)
+	}
+
+	// Create a history panel
+	var historyPanel *ui.HistoryPanel
+	onRequestLoad := func(item *storage.RequestHistory) {
+		switch item.Kind {
+		case storage.HistoryKindWebSocket:
+			loadWebSocketSession(item)
+			return
+		case storage.HistoryKindGRPC:
+			loadGRPCCall(item)
+			return
+		}
+		loadHistoryRequest(item)
+		showStoredResponse(item)
+
+		// Offer the {{uuid}} etc. values of that send so it can be reproduced
+		var values map[string]string
+		if item.DynamicValues == "" || json.Unmarshal([]byte(item.DynamicValues), &values) != nil || len(values) == 0 {
+			return
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines := make([]string, len(keys))
+		for i, key := range keys {
+			lines[i] = fmt.Sprintf("{{%s}} = %s", key, values[key])
+		}
+
+		dialog.ShowConfirm("Dynamic Values",
+			"This send used:\n\n"+strings.Join(lines, "\n")+"\n\nReplace the placeholders with these values to reproduce it?",
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				// The generated values contain no JSON special characters, so
+				// they can be substituted into the stored headers and form fields
+				loadRequest(replayDynamicValues(item.URL, values), item.Method,
+					replayDynamicValues(item.Headers, values), item.Params, item.PathVariables, item.BodyType,
+					replayDynamicValues(item.Body, values))
+			}, w)
+	}
+	historyPanel = ui.NewHistoryPanel(db, onRequestLoad, w)
+	historyPanel.SetTimeThresholds(prefs.TimeThresholds)
+	historyPanel.SetRetention(prefs.HistoryRetention)
+	historyPanel.SetGrouped(prefs.GroupHistory)
+	historyPanel.OnGroupedChanged = func(on bool) {
+		prefs.GroupHistory = on
+		savePreferencesToDB(db, prefs)
+	}
+	a.Lifecycle().SetOnStarted(historyPanel.PruneHistory)
+
+	collectionsPanel := ui.NewCollectionsPanel(db, func(req *storage.SavedRequest) {
+		modeTabs.SelectIndex(0) // HTTP
+		currentSavedRequest = req
+		showNotes(req.Notes)
+		responseArea.SetFilter(req.ResponseFilter)
+		loadRequest(req.URL, req.Method, req.Headers, req.Params, req.PathVariables, req.BodyType, req.Body)
+		if req.BodySource == ui.BodySourceFile {
+			bodyEditor.SetBodyFile(req.BodyFile)
+			bodyEditor.SetBodySource(req.BodySource)
+		}
+
+		var auth ui.AuthConfig
+		if req.Auth != "" {
+			if err := json.Unmarshal([]byte(req.Auth), &auth); err != nil {
+				fmt.Printf("Error parsing stored auth: %v\n", err)
+			}
+		}
+		authEditor.SetConfig(auth)
+		if auth.Type == ui.AuthTypeOAuth2 {
+			authEditor.SetTokenStatus(describeOAuthToken(loadOAuthToken(db, auth)))
+		}
+		scriptEditor.SetScript(req.Script)
+
+		var assertions []ui.Assertion
+		if req.Tests != "" {
+			if err := json.Unmarshal([]byte(req.Tests), &assertions); err != nil {
+				fmt.Printf("Error parsing stored tests: %v\n", err)
+			}
+		}
+		testsEditor.SetAssertions(assertions)
+
+		var extractors []ui.Extractor
+		if req.Extractors != "" {
+			if err := json.Unmarshal([]byte(req.Extractors), &extractors); err != nil {
+				fmt.Printf("Error parsing stored extractors: %v\n", err)
+			}
+		}
+		extractorsEditor.SetExtractors(extractors)
+	}, w)
+
+	saveRequest := func() {
+		saved := &storage.SavedRequest{
+			URL:    urlEntry.Text,
+			Method: methodSelector.Selected(),
+		}
+		saved.BodyType, saved.Body = storedBody()
+		if saved.BodyType == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile {
+			saved.BodySource = ui.BodySourceFile
+			saved.BodyFile = bodyEditor.GetBodyFile()
+		}
+		saved.Script = scriptEditor.GetScript()
+		if assertions := testsEditor.GetAssertions(); len(assertions) > 0 {
+			testsJSON, _ := json.Marshal(assertions)
+			saved.Tests = string(testsJSON)
+		}
+		if extractors := extractorsEditor.GetExtractors(); len(extractors) > 0 {
+			extractorsJSON, _ := json.Marshal(extractors)
+			saved.Extractors = string(extractorsJSON)
+		}
+		saved.ResponseFilter = responseArea.GetFilter()
+		if currentSavedRequest != nil {
+			saved.ID = currentSavedRequest.ID
+			saved.Name = currentSavedRequest.Name
+			saved.CollectionID = currentSavedRequest.CollectionID
+			saved.Notes = currentSavedRequest.Notes
+		}
+
+		if headers := withCookieHeader(headersEditor.GetPairs(), cookiesEditor.GetPairs()); len(headers) > 0 {
+			headersJSON, _ := json.Marshal(headers)
+			saved.Headers = string(headersJSON)
+		}
+		saved.Params = storedParams(paramsEditor.GetParams())
+		if pathVariables := paramsEditor.GetPathVariables(); len(pathVariables) > 0 {
+			pathVariablesJSON, _ := json.Marshal(pathVariables)
+			saved.PathVariables = string(pathVariablesJSON)
+		}
+		if auth := authEditor.GetConfig(); auth.Type != ui.AuthTypeNone {
+			authJSON, _ := json.Marshal(auth.ForStorage())
+			saved.Auth = string(authJSON)
+		}
+
+		collectionsPanel.ShowSaveDialog(saved, func(req *storage.SavedRequest) {
+			currentSavedRequest = req
+			showNotes(req.Notes)
+		})
+	}
+
+	// currentRequest builds the request described by the editors. Context and
+	// progress reporting are left to the caller.
+	currentRequest := func() RequestInfo {
+		url := urlEntry.Text
+		method := methodSelector.Selected()
+		headers := withCookieHeader(headersEditor.GetPairs(), cookiesEditor.GetPairs())
+		bodyType, _ := storedBody()
+		body := bodyEditor.GetBody()
+		if bodyType == ui.BodyTypeGraphQL {
+			body = bodyEditor.GetGraphQL()
+		}
+		// A body read from a file is filled in by readBodyFile
+		fromFile := bodyType == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile
+		if fromFile {
+			body = ""
+		}
+		formFields := bodyEditor.GetFormFields()
+		bodyFile := bodyEditor.GetBinaryFile()
+		auth := authEditor.GetConfig()
+		timeout := time.Duration(optionsEditor.GetTimeout()) * time.Second
+		proxy := optionsEditor.GetProxy().Resolve(prefs.Proxy)
+		insecure := skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify)
+
+		var requestJar http.CookieJar
+		if optionsEditor.GetUseCookies() {
+			requestJar = cookieJar
+		}
+
+		// An explicit Content-Type header takes precedence over the body editor
+		if (bodyType == ui.BodyTypeRaw && (body != "" || fromFile)) || (bodyType == ui.BodyTypeBinary && bodyFile != "") || (bodyType == ui.BodyTypeGraphQL && body != "") {
+			if _, ok := findHeader(headers, "Content-Type"); !ok {
+				if contentType := bodyEditor.GetContentType(); contentType != "" {
+					headers = append(headers, ui.KeyValue{Key: "Content-Type", Value: contentType})
+				}
+			}
+		}
+		headers = withDefaultHeaders(headers, prefs.DefaultHeaders)
+
+		return RequestInfo{
+			Method:     method,
+			URL:        url,
+			Headers:    headers,
+			BodyType:   bodyType,
+			Body:       body,
+			BodyFile:   bodyFile,
+			FormFields: formFields,
+			Auth:       auth,
+			Timeout:    timeout,
+			Params:     paramsEditor.GetParams(),
+
+			PathVariables: paramsEditor.GetPathVariables(),
+
+			FollowRedirects: optionsEditor.GetFollowRedirects(),
+			MaxRedirects:    optionsEditor.GetMaxRedirects(),
+			HTTPVersion:     optionsEditor.GetHTTPVersion(),
+			AcceptEncoding:  optionsEditor.GetAcceptEncoding(),
+			Proxy:           proxy,
+			IPVersion:       optionsEditor.GetIPVersion(),
+			UnixSocket:      optionsEditor.GetUnixSocket(),
+			Chunked:         optionsEditor.GetChunked(),
+			ContentLength:   optionsEditor.GetWrongContentLength(),
+			HostOverrides:   prefs.HostOverrides,
+
+			ClientCertificates: prefs.ClientCertificates,
+			InsecureSkipVerify: insecure,
+			CAFiles:            prefs.CAFiles,
+			UseSystemCAs:       prefs.UseSystemCAs,
+			SNIFromHost:        optionsEditor.GetSNIFromHost(),
+			CookieJar:          requestJar,
+
+			Script: scriptEditor.GetScript(),
+
+			DownloadToFile: optionsEditor.GetDownloadToFile(),
+		}
+	}
+
+	// vault decrypts secret variables; it is unlocked when first needed
+	vault := secrets.NewVault()
+
+	environmentSelector := ui.NewEnvironmentSelector(db)
+	environmentSelector.SetSelected(prefs.ActiveEnvironment)
+	environmentSelector.OnChanged = func(environmentID int) {
+		prefs.ActiveEnvironment = environmentID
+		savePreferencesToDB(db, prefs)
+		requestChanged()
+	}
+
+	// readBodyFile fills in a raw body read from a file, which happens anew on
+	// every send. A file that has gone missing can be located again, which
+	// also updates the saved request; the request is not sent either way.
+	// Nor is a GraphQL request whose variables are not a JSON object.
+	readBodyFile := func(request *RequestInfo) bool {
+		if request.BodyType == ui.BodyTypeGraphQL {
+			if err := bodyEditor.GraphQLVariablesError(); err != nil {
+				dialog.ShowError(err, w)
+				return false
+			}
+		}
+		if request.BodyType != ui.BodyTypeRaw || bodyEditor.GetBodySource() != ui.BodySourceFile {
+			return true
+		}
+		path := bodyEditor.GetBodyFile()
+		if path == "" {
+			dialog.ShowError(errors.New("choose the file the request body is read from"), w)
+			return false
+		}
+
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			dialog.ShowConfirm("Body File Missing",
+				fmt.Sprintf("The request body is read from %s, which no longer exists.\n\nChoose where the file is now?", path),
+				func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+						if err != nil {
+							dialog.ShowError(err, w)
+							return
+						}
+						if reader == nil {
+							return
+						}
+						reader.Close()
+
+						newPath := reader.URI().Path()
+						bodyEditor.SetBodyFile(newPath)
+						if currentSavedRequest != nil && currentSavedRequest.BodySource == ui.BodySourceFile {
+							currentSavedRequest.BodyFile = newPath
+							if err := db.UpdateSavedRequest(currentSavedRequest); err != nil {
+								dialog.ShowError(err, w)
+							}
+						}
+					}, w)
+				}, w)
+			return false
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("cannot read the body file: %w", err), w)
+			return false
+		}
+		request.Body = string(data)
+		return true
+	}
+
+	// resolveRequestIn substitutes {{variables}} from an environment and the
+	// globals, and returns the URL with secret values masked for history. A
+	// request with variables that have no value is not sent, nor one using
+	// secrets while they are locked.
+	resolveRequestIn := func(request RequestInfo, environmentID int) (RequestInfo, string, bool) {
+		variables, err := db.GetResolvedVariables(environmentID)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return request, "", false
+		}
+
+		resolved, missing, err := resolveVariables(request, variables, vault.Decrypt)
+		if errors.Is(err, secrets.ErrLocked) && vault.Unlock(db) == nil {
+			resolved, missing, err = resolveVariables(request, variables, vault.Decrypt)
+		}
+		if errors.Is(err, secrets.ErrLocked) {
+			ui.UnlockSecrets(db, vault, w, func() {
+				dialog.ShowInformation("Secrets Unlocked", "Send the request again to use the secret variables.", w)
+			})
+			return request, "", false
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+			return request, "", false
+		}
+		// Catch bad {{randomInt}} etc. arguments before anything is sent
+		if _, _, err := applyDynamicVariables(resolved); err != nil {
+			dialog.ShowError(err, w)
+			return request, "", false
+		}
+		if len(missing) > 0 {
+			dialog.ShowInformation("Unresolved Variables",
+				"These variables have no value:\n\n"+strings.Join(missing, "\n")+"\n\nDefine them under Variables before sending.", w)
+			return request, "", false
+		}
+		resolved.Variables = &scriptVariables{db: db, vault: vault, environmentID: environmentID}
+		return resolved, maskVariables(applyPathVariables(request.URL, request.PathVariables), variables), true
+	}
+
+	// checkPathVariables points out the path variables without a value in the
+	// Params tab; the request is not sent until they have one.
+	checkPathVariables := func() bool {
+		if paramsEditor.CheckPathVariables() {
+			return true
+		}
+		requestTabs.SelectIndex(0) // Params
+		return false
+	}
+
+	// resolveRequest resolves request in the active environment.
+	resolveRequest := func(request RequestInfo) (RequestInfo, string, bool) {
+		return resolveRequestIn(request, environmentSelector.Selected())
+	}
+
+	// graphQLSchemaURL is the URL whose schema the GraphQL editor shows.
+	// Schemas are cached by the URL with its variables substituted, secret
+	// ones masked, so that each environment has its own.
+	var graphQLSchemaURL string
+	showCachedSchema = func() {
+		if bodyEditor.GetBodyType() != ui.BodyTypeGraphQL {
+			return
+		}
+		variables, err := db.GetResolvedVariables(environmentSelector.Selected())
+		if err != nil {
+			fmt.Printf("Error loading variables: %v\n", err)
+			return
+		}
+		url := maskVariables(applyPathVariables(urlEntry.Text, paramsEditor.GetPathVariables()), variables)
+		if url == graphQLSchemaURL {
+			return
+		}
+		graphQLSchemaURL = url
+
+		cached, err := db.GetGraphQLSchema(url)
+		if err != nil {
+			fmt.Printf("Error loading GraphQL schema: %v\n", err)
+		}
+		if cached == nil {
+			bodyEditor.SetGraphQLSchema(nil, time.Time{})
+			return
+		}
+		schema, err := graphql.ParseSchema([]byte(cached.Schema))
+		if err != nil {
+			fmt.Printf("Error parsing cached GraphQL schema: %v\n", err)
+			bodyEditor.SetGraphQLSchema(nil, time.Time{})
+			return
+		}
+		bodyEditor.SetGraphQLSchema(schema, cached.FetchedAt)
+	}
+
+	// Introspect in GraphQL mode sends the introspection query to the URL
+	// and caches the schema; Refresh Schema does so again
+	bodyEditor.OnIntrospect = func() {
+		template := currentRequest()
+		if template.URL == "" {
+			dialog.ShowInformation("Introspect", "Enter the URL of the GraphQL endpoint first.", w)
+			return
+		}
+		if !checkPathVariables() {
+			return
+		}
+		request, url, ok := resolveRequest(introspectionRequest(template))
+		if !ok {
+			return
+		}
+
+		bodyEditor.ShowIntrospecting()
+		go func() {
+			schema, data, err := introspectGraphQL(db, request)
+			fyne.Do(func() {
+				if err != nil {
+					bodyEditor.ShowIntrospectionError(err)
+					return
+				}
+				cached := &storage.GraphQLSchema{URL: url, Schema: string(data), FetchedAt: time.Now()}
+				if err := db.SaveGraphQLSchema(cached); err != nil {
+					fmt.Printf("Error caching GraphQL schema: %v\n", err)
+				}
+				// Shown even if the URL has changed since, as it was asked for
+				graphQLSchemaURL = url
+				bodyEditor.SetGraphQLSchema(schema, cached.FetchedAt)
+			})
+		}()
+	}
+
+	// Decode JWT in the Auth tab decodes the bearer token as it would be
+	// sent, with its variables resolved
+	authEditor.OnDecodeJWT = func() {
+		variables, err := db.GetResolvedVariables(environmentSelector.Selected())
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		resolved, _, err := resolveVariables(currentRequest(), variables, vault.Decrypt)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		token, _ := requestJWT(&resolved)
+		info, err := decodeJWT("Bearer token", token)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("the bearer token is not a JWT: %w", err), w)
+			return
+		}
+		ui.ShowJWTDialog([]ui.JWTInfo{info}, verifyJWT, w)
+	}
+
+	// previewText renders the request in the editors without sending it.
+	// Unlike a send it shows no dialogs; what cannot be resolved yet is left
+	// in place and explained in the note.
+	previewText := func(mask bool) (text, note string) {
+		request := currentRequest()
+		if request.URL == "" {
+			return "", "Enter a URL to preview the request"
+		}
+
+		var notes []string
+		if request.BodyType == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile {
+			data, err := os.ReadFile(bodyEditor.GetBodyFile())
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("The body file cannot be read: %v", err))
+			}
+			request.Body = string(data)
+		}
+
+		variables, err := db.GetResolvedVariables(environmentSelector.Selected())
+		if err != nil {
+			return "", err.Error()
+		}
+		var decrypt func(string) (string, error)
+		if !mask {
+			decrypt = vault.Decrypt
+		}
+		resolved, missing, err := resolveVariables(request, variables, decrypt)
+		if err != nil {
+			resolved, missing, _ = resolveVariables(request, variables, nil)
+			mask = true
+			notes = append(notes, fmt.Sprintf("Secret values are masked: %v", err))
+		}
+		if len(missing) > 0 {
+			notes = append(notes, "These variables have no value: "+strings.Join(missing, ", "))
+		}
+		if _, values, _ := applyDynamicVariables(resolved); len(values) > 0 {
+			notes = append(notes, "{{uuid}} and other dynamic values are generated anew on every send")
+		}
+		if resolved.Script != "" {
+			notes = append(notes, "The pre-request script runs when sending and may change the request")
+		}
+		if resolved.Chunked && resolved.HTTPVersion == ui.HTTPVersionHTTP2 {
+			notes = append(notes, "Over HTTP/2 the body goes out in DATA frames rather than chunks")
+		}
+		if host, ok := findHeader(resolved.Headers, "Host"); ok && strings.HasPrefix(strings.ToLower(resolved.URL), "https://") {
+			if serverName := tlsServerName(&resolved); serverName == hostOnly(host) {
+				notes = append(notes, fmt.Sprintf("The connection goes to the host in the URL, with %s as the TLS server name (SNI)", serverName))
+			} else {
+				notes = append(notes, fmt.Sprintf("The connection and the TLS server name (SNI) follow the host in the URL, %s, not the Host header", serverName))
+			}
+		}
+		if resolved.ContentLength != nil {
+			notes = append(notes, "The Content-Length is deliberately wrong (fault injection in Options)")
+		}
+		if resolved.Auth.Type == ui.AuthTypeHMAC && !mask {
+			notes = append(notes, "The HMAC timestamp and nonce, and so the signature, are generated anew on every send")
+		}
+		if resolved.Auth.Type == ui.AuthTypeOAuth2 {
+			if token := loadOAuthToken(db, resolved.Auth); token != nil {
+				resolved.Auth.Token = token.AccessToken
+			} else {
+				notes = append(notes, "There is no OAuth2 token yet")
+			}
+		}
+
+		text, err = previewRequest(&resolved, mask)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("The request cannot be built: %v", err))
+		}
+		return text, strings.Join(notes, "\n")
+	}
+
+	requestPreview := ui.NewRequestPreview()
+	requestPreview.OnMaskChanged = requestChanged
+	updatePreview = func() {
+		requestPreview.SetPreview(previewText(requestPreview.GetMaskSecrets()))
+	}
+
+	submitButton := widget.NewButtonWithIcon("Submit", theme.MediaPlayIcon(), nil)
+	submitButton.Importance = widget.HighImportance
+	repeatButton := widget.NewButton("Send ×N", nil)
+
+	// cancelRequest is set while a request is in flight
+	var cancelRequest context.CancelFunc
+	cancelButton := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		if cancelRequest != nil {
+			cancelRequest()
+		}
+	})
+	cancelButton.Hide()
+
+	setRunning := func(cancel context.CancelFunc) {
+		cancelRequest = cancel
+		if cancel != nil {
+			submitButton.Disable()
+			repeatButton.Disable()
+			cancelButton.Show()
+		} else {
+			submitButton.Enable()
+			repeatButton.Enable()
+			cancelButton.Hide()
+		}
+	}
+
+	// revalidation is the validator of the shown response while Revalidate
+	// sends the request again
+	var revalidation *storage.Validator
+	// resendComparison is the history entry whose stored response the next
+	// one is compared with, while Resend and Compare sends it again
+	var resendComparison *storage.RequestHistory
+
+	forgetValidatorsButton.OnTapped = func() {
+		if err := db.DeleteValidator(conditionalURL); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		forgetValidatorsButton.Hide()
+		ui.ShowToast("Forgot the validators of "+conditionalURL, w)
+	}
+
+	// Extract submit logic into a function for reuse. count > 1 sends the
+	// request that many times, waiting delay between sends.
+	submitRequest := func(count int, delay time.Duration) {
+		if cancelRequest != nil {
+			return
+		}
+		validator := revalidation
+		revalidation = nil
+		compareWith := resendComparison
+		resendComparison = nil
+
+		template := currentRequest()
+		assertions := testsEditor.GetAssertions()
+		extractors := extractorsEditor.GetExtractors()
+		_, storedRequestBody := storedBody()
+		url := template.URL
+		method := template.Method
+
+		if url == "" {
+			storedBanner.Hide()
+			responseArea.SetText("Error: Please enter a URL")
+			statusLabel.Text = "Status: Error"
+			statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+			statusLabel.Refresh()
+			sizeLabel.SetText("Size: -")
+			sizeHint.SetSizes(nil)
+			timeLabel.Importance = widget.MediumImportance
+			timeLabel.SetText("Time: -")
+			return
+		}
+
+		if !checkPathVariables() {
+			return
+		}
+
+		// History records the body as it was read from the file
+		if !readBodyFile(&template) {
+			return
+		}
+		if bodyEditor.GetBodyType() == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile {
+			storedRequestBody = template.Body
+		}
+
+		requestInfo, maskedURL, ok := resolveRequest(template)
+		if !ok {
+			return
+		}
+
+		requestInfo.MaxDisplaySize = prefs.MaxDisplaySize
+
+		// Revalidate, or remembered validators when conditional requests are
+		// automatic, add If-None-Match and If-Modified-Since unless the
+		// request sets them itself; history records them as sent
+		automatic := false
+		if validator == nil && prefs.AutoRevalidate && isConditionalMethod(method) {
+			var err error
+			if validator, err = db.GetValidator(requestInfo.URL); err != nil {
+				fmt.Printf("Error loading validators: %v\n", err)
+			}
+			automatic = validator != nil
+		}
+		conditionalRow.Hide()
+		if validator != nil {
+			if added := conditionalHeaders(requestInfo.Headers, validator); len(added) > 0 {
+				requestInfo.Headers = append(requestInfo.Headers, added...)
+				template.Headers = append(template.Headers, added...)
+				conditionalURL = requestInfo.URL
+				conditionalLabel.SetText(describeConditional(added, automatic))
+				if automatic {
+					forgetValidatorsButton.Show()
+				} else {
+					forgetValidatorsButton.Hide()
+				}
+				conditionalRow.Show()
+			}
+		}
+
+		clearResponse()
+		responseArea.SetText("Loading...")
+		statusLabel.Text = "Status: Loading..."
+		statusLabel.Refresh()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		setRunning(cancel)
+
+		requestInfo.Context = ctx
+		// finished is set on the main thread once the send is over, so
+		// progress reported late, as the rest of an upload the server did
+		// not wait for, does not show the bars again
+		finished := false
+		requestInfo.OnUploadProgress = func(sent, total int64, rate float64) {
+			fyne.Do(func() {
+				if finished {
+					return
+				}
+				uploadSent, uploadTotal, uploadRate = sent, total, rate
+				uploadProgress.Show()
+				uploadProgress.SetValue(float64(sent) / float64(total))
+			})
+		}
+		requestInfo.OnEvent = func(event serverEvent, count int) {
+			fyne.Do(func() {
+				if count == 1 {
+					responseArea.SetText("")
+					statusLabel.Text = "Status: Streaming events..."
+					statusLabel.Refresh()
+				}
+				if count <= maxStreamEvents {
+					responseArea.Append(event.String())
+				}
+				eventsLabel.SetText(fmt.Sprintf("Events: %d (streaming, Cancel to stop)", count))
+				eventsLabel.Show()
+			})
+		}
+
+		// The file for a download is chosen on the main thread while the
+		// sending goroutine waits. Repeated sends always load the body.
+		var stream *bodyStream
+		if count == 1 {
+			requestInfo.OnDownload = func(fileName string, size int64, offered bool) (io.WriteCloser, string) {
+				chosen := make(chan fyne.URIWriteCloser, 1)
+				fyne.Do(func() {
+					statusLabel.Text = "Status: Choosing where to save..."
+					statusLabel.Refresh()
+					ui.ShowDownloadDialog(fileName, size, offered, func(writer fyne.URIWriteCloser) {
+						chosen <- writer
+					}, w)
+				})
+				select {
+				case writer := <-chosen:
+					if writer == nil {
+						return nil, ""
+					}
+					fyne.Do(func() {
+						statusLabel.Text = "Status: Saving..."
+						statusLabel.Refresh()
+						downloadReceived, downloadTotal, downloadRate, downloadVerb = 0, size, 0, "Saving"
+						downloadProgress.SetValue(0)
+						downloadProgress.Show()
+					})
+					return writer, writer.URI().Path()
+				case <-ctx.Done():
+					// A file chosen after cancelling is removed again
+					go func() {
+						if writer := <-chosen; writer != nil {
+							writer.Close()
+							os.Remove(writer.URI().Path())
+						}
+					}()
+					return nil, ""
+				}
+			}
+			// The body is shown as it arrives, up to the display limit, while
+			// it looks like text; cancelling keeps what arrived
+			var shownBytes int64
+			var carry []byte
+			receiving, binary := false, false
+			stream = &bodyStream{show: func(data []byte, received int64, chunks int) {
+				if !receiving {
+					receiving = true
+					responseArea.SetText("")
+					statusLabel.Text = "Status: Receiving..."
+					statusLabel.Refresh()
+				}
+				sizeLabel.SetText(fmt.Sprintf("Size: %s in %d chunks (receiving, Cancel to stop)", ui.FormatBytes(received), chunks))
+				if binary || shownBytes >= requestInfo.MaxDisplaySize {
+					return
+				}
+				data = append(carry, data[:min(int64(len(data)), requestInfo.MaxDisplaySize-shownBytes)]...)
+				// A character cut in half waits for the rest of it
+				text := completeRunes(string(data))
+				carry = data[len(text):]
+				if !utf8.ValidString(text) {
+					binary = true
+					responseArea.SetText("Receiving binary data...")
+					return
+				}
+				shownBytes += int64(len(text))
+				responseArea.Append(text)
+			}}
+			requestInfo.OnBodyChunk = stream.add
+			requestInfo.OnDownloadProgress = func(received, total int64, rate float64) {
+				fyne.Do(func() {
+					if finished {
+						return
+					}
+					downloadReceived, downloadTotal, downloadRate = received, total, rate
+					downloadProgress.Show()
+					if total > 0 {
+						downloadProgress.SetValue(float64(received) / float64(total))
+					} else {
+						downloadProgress.Refresh()
+					}
+				})
+			}
+		}
+
+		go func() {
+			defer cancel()
+
+			var response *ResponseInfo
+			var err error
+			var results []repeatResult
+			// sent is the request of the last send, with the cookies it had
+			var sent RequestInfo
+			// dynamicValues are those of the last send, whose response is shown
+			var dynamicValues map[string]string
+			// scriptLog is what the script of the last send logged, shown even
+			// when the script failed
+			var scriptLog []string
+			for i := 0; i < count; i++ {
+				if i > 0 && delay > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(delay):
+					}
+				}
+				if ctx.Err() != nil {
+					break
+				}
+				if count > 1 {
+					fyne.Do(func() {
+						statusLabel.Text = fmt.Sprintf("Status: Sending %d of %d...", i+1, count)
+						statusLabel.Refresh()
+					})
+				}
+
+				// Dynamic variables get fresh values and the script runs for
+				// every send, and OAuth2 sets the token on the request, so each
+				// send gets a copy
+				info, values, sendErr := prepareSend(requestInfo)
+				scriptLog = info.ScriptLog
+				var sendResponse *ResponseInfo
+				if sendErr == nil {
+					dynamicValues = values
+					sent = withCookieSnapshot(info)
+					sendResponse, sendErr = executeWithAuth(db, &info)
+					sent.Auth = info.Auth
+				}
+				if errors.Is(sendErr, errRequestCancelled) {
+					// Earlier sends still make up the summary
+					if len(results) == 0 {
+						response, err = sendResponse, sendErr
+					}
+					break
+				}
+				// Only the body of the last send is kept
+				removeBodyFile(response)
+				response, err = sendResponse, sendErr
+
+				result := repeatResult{Err: err}
+				if err == nil {
+					result.Status = response.Status
+					result.ResponseTime = response.ResponseTime
+					result.Size = response.Size
+				}
+				results = append(results, result)
+			}
+
+			var stats *repeatStats
+			if count > 1 {
+				summary := newRepeatStats(count, delay, results)
+				summary.Cancelled = len(results) < count
+				stats = &summary
+			}
+
+			// Create a history entry
+			historyEntry := &storage.RequestHistory{
+				URL:       url,
+				Method:    method,
+				BodyType:  requestInfo.BodyType,
+				Body:      storedRequestBody,
+				Timestamp: time.Now(),
+
+				InsecureTLS: requestInfo.InsecureSkipVerify,
+				UnixSocket:  requestInfo.UnixSocket,
+			}
+
+			// The template is kept so the request can be reloaded with its
+			// placeholders; secret values are not recorded
+			if resolvedURL := replayDynamicValues(maskedURL, dynamicValues); resolvedURL != url {
+				historyEntry.ResolvedURL = resolvedURL
+			}
+			if len(dynamicValues) > 0 {
+				dynamicJSON, _ := json.Marshal(dynamicValues)
+				historyEntry.DynamicValues = string(dynamicJSON)
+			}
+
+			if stats != nil {
+				statsJSON, _ := json.Marshal(stats)
+				historyEntry.Stats = string(statsJSON)
+			}
+
+			if len(template.Headers) > 0 {
+				requestHeadersJSON, _ := json.Marshal(template.Headers)
+				historyEntry.Headers = string(requestHeadersJSON)
+			}
+			historyEntry.Params = storedParams(template.Params)
+			historyEntry.PathVariables = storedPathVariables(template.PathVariables)
+
+			// Extracted values are stored before the UI is updated so the
+			// next request can use them
+			var extractions string
+			if len(extractors) > 0 && err == nil {
+				if response.StatusCode < 400 {
+					extractions = describeExtractions(runExtractors(extractors, response, requestInfo.Variables))
+				} else {
+					extractions = fmt.Sprintf("Extractors skipped: the response status is %s, variables not updated", response.Status)
+				}
+			}
+
+			// Use the main thread for UI updates
+			fyne.Do(func() {
+				if stream != nil {
+					stream.close()
+				}
+				finished = true
+				uploadProgress.Hide()
+				downloadProgress.Hide()
+				downloadVerb = "Receiving"
+
+				if err != nil {
+					historyEntry.ResponseStatus = "Error"
+					responseText := fmt.Sprintf("Error: %v", err)
+
+					responseArea.SetText(responseText)
+					statusLabel.Text = "Status: Error"
+					statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+					statusLabel.Refresh()
+					sizeLabel.SetText("Size: -")
+					sizeHint.SetSizes(nil)
+					timeLabel.Importance = widget.MediumImportance
+					timeLabel.SetText("Time: -")
+				} else {
+					// Update history entry with response data
+					historyEntry.ResponseStatus = response.Status
+					historyEntry.ResponseBody, historyEntry.ResponseCharset = storedResponseBody(response)
+					historyEntry.DownloadPath = response.DownloadPath
+					historyEntry.Partial = response.Partial
+					historyEntry.ResponseTimeMs = int(response.ResponseTime.Milliseconds())
+					historyEntry.ResponseSize = response.Size
+					historyEntry.RedirectCount = len(response.Redirects)
+					historyEntry.Protocol = response.Proto
+					historyEntry.RemoteAddr = response.RemoteAddr
+
+					headersJSON, _ := json.Marshal(response.Headers)
+					historyEntry.ResponseHeaders = string(headersJSON)
+
+					if response.Timing != nil {
+						timingJSON, _ := json.Marshal(response.Timing)
+						historyEntry.Timing = string(timingJSON)
+						timingView.SetTiming(response.Timing.phases(), response.Timing.Total, response.Timing.Reused)
+					}
+					sizes := responseSizes(response)
+					sizesJSON, _ := json.Marshal(sizes)
+					historyEntry.Sizes = string(sizesJSON)
+					if response.TLS != nil {
+						tlsJSON, _ := json.Marshal(response.TLS)
+						historyEntry.TLS = string(tlsJSON)
+					}
+					securityView.SetTLS(response.TLS)
+
+					if response.Events != nil {
+						eventsJSON, _ := json.Marshal(response.Events)
+						historyEntry.Events = string(eventsJSON)
+
+						ending := "stream ended"
+						if response.StreamStopped {
+							ending = "stopped"
+						}
+						eventsLabel.SetText(fmt.Sprintf("Events: %d (%s)", response.EventCount, ending))
+						eventsLabel.Show()
+					}
+
+					if response.DownloadPath != "" {
+						responseArea.SetText(fmt.Sprintf("Saved %d bytes to %s", response.Size, response.DownloadPath))
+					} else if response.StatusCode == http.StatusNotModified && response.Body == "" {
+						responseArea.SetText(describeNotModified(&sent))
+					} else if response.Body == "" && method == http.MethodHead {
+						responseArea.SetText("(no body)")
+					} else {
+						showResponseBody(response)
+					}
+					statusLabel.Text = fmt.Sprintf("Status: %s (%s)", response.Status, response.Proto)
+					statusHint.SetStatus(response.StatusCode)
+					statusLabel.Color = statusColor(response.Status)
+					statusLabel.Refresh()
+
+					if response.RemoteAddr != "" {
+						remoteAddr := "Remote address: " + response.RemoteAddr
+						remoteAddrLabel.Importance = widget.MediumImportance
+						if response.HostOverride != "" {
+							remoteAddr += " (host override " + response.HostOverride + ")"
+							remoteAddrLabel.Importance = widget.WarningImportance
+						}
+						remoteAddrLabel.SetText(remoteAddr)
+						remoteAddrLabel.Show()
+					}
+
+					sizeLabel.SetText(describeSize(response, sizes))
+					sizeHint.SetSizes(sizes)
+					showCharset(response)
+					shownRequest, shownResponse = &sent, response
+					responseToolbar.SetEnabled(true)
+					responseToolbar.ShowDecodeJWT(len(findJWTs(&sent, response)) > 0)
+					responseToolbar.ShowRevalidate(isConditionalMethod(method) && responseValidator(requestInfo.URL, response) != nil)
+
+					// The validators of a fresh response are remembered for the
+					// next conditional request; a 304 leaves them as they were
+					if prefs.AutoRevalidate && isConditionalMethod(method) && response.StatusCode >= 200 && response.StatusCode < 300 {
+						if validator := responseValidator(requestInfo.URL, response); validator != nil {
+							if err := db.SaveValidator(validator); err != nil {
+								fmt.Printf("Error saving validators: %v\n", err)
+							}
+						}
+					}
+
+					showResponseHeaders(responseHeaderPairs(response), finalURL(requestInfo.URL, response))
+					showResponseCookies(setCookieValues(response), finalURL(requestInfo.URL, response))
+					responseTabs.Refresh()
+
+					if redirects := describeRedirects(response); redirects != "" {
+						redirectsLabel.SetText(redirects)
+						redirectsLabel.Show()
+					}
+					timeLabel.Importance = prefs.TimeThresholds.Importance(response.ResponseTime)
+					timeLabel.SetText(fmt.Sprintf("Time: %.2f ms", float64(response.ResponseTime.Milliseconds())))
+
+					if len(assertions) > 0 {
+						results := evaluateAssertions(assertions, response)
+						resultsJSON, _ := json.Marshal(results)
+						historyEntry.TestResults = string(resultsJSON)
+
+						testsEditor.SetResults(results)
+						testsLabel.SetText(ui.SummarizeResults(results))
+						testsLabel.Importance = widget.SuccessImportance
+						if !ui.AllPassed(results) {
+							testsLabel.Importance = widget.DangerImportance
+						}
+						testsLabel.Show()
+					}
+				}
+
+				if stats != nil {
+					// Coloured by the p95, as the slow sends are what stand out
+					repeatLabel.Importance = widget.MediumImportance
+					if stats.Sent > stats.Errors {
+						repeatLabel.Importance = prefs.TimeThresholds.Importance(time.Duration(stats.P95Ms * float64(time.Millisecond)))
+					}
+					repeatLabel.SetText(stats.String())
+					repeatLabel.Show()
+				}
+				if extractions != "" {
+					extractionsLabel.SetText(extractions)
+					extractionsLabel.Show()
+				}
+				if len(scriptLog) > 0 {
+					scriptLogLabel.SetText("Script log:\n" + strings.Join(scriptLog, "\n"))
+					scriptLogLabel.Show()
+				}
+				setRunning(nil)
+
+				// Add to history
+				historyPanel.AddToHistory(historyEntry)
+
+				if compareWith != nil && !errors.Is(err, errRequestCancelled) {
+					ui.ShowComparisonDialog(compareWithStored(compareWith, response, err), w)
+				}
+			})
+		}()
+	}
+
+	submitButton.OnTapped = func() {
+		submitRequest(1, 0)
+	}
+
+	// Resend loads an HTTP history entry into the editors and sends it
+	// again, as if it had been opened and submitted
+	historyPanel.OnResend = func(item *storage.RequestHistory, compare bool) {
+		if cancelRequest != nil {
+			dialog.ShowInformation("Resend", "Wait for the request being sent to finish, or cancel it, first.", w)
+			return
+		}
+		loadHistoryRequest(item)
+		if compare {
+			resendComparison = item
+		}
+		submitRequest(1, 0)
+	}
+
+	// Revalidate sends the request again, conditional on the validators of
+	// the shown response
+	responseToolbar.OnRevalidate = func() {
+		if shownResponse == nil {
+			return
+		}
+		revalidation = responseValidator(shownRequest.URL, shownResponse)
+		submitRequest(1, 0)
+	}
+
+	// The last repeat settings are offered again next time
+	repeatCount, repeatDelay := 10, time.Duration(0)
+	repeatButton.OnTapped = func() {
+		ui.ShowRepeatDialog(repeatCount, repeatDelay, func(count int, delay time.Duration) {
+			repeatCount, repeatDelay = count, delay
+			submitRequest(count, delay)
+		}, w)
+	}
+
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), saveRequest)
+
+	settingsButton := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		settings := ui.Settings{
+			Proxy:              prefs.Proxy,
+			ClientCertificates: prefs.ClientCertificates,
+			SkipTLSVerify:      prefs.SkipTLSVerify,
+			CAFiles:            prefs.CAFiles,
+			UseSystemCAs:       prefs.UseSystemCAs,
+			DefaultHeaders:     prefs.DefaultHeaders,
+			HostOverrides:      prefs.HostOverrides,
+			CopyCredentials:    prefs.CopyCredentials,
+			MaxDisplaySize:     prefs.MaxDisplaySize,
+			ProtoFiles:         prefs.ProtoFiles,
+			TimeThresholds:     prefs.TimeThresholds,
+			AutoRevalidate:     prefs.AutoRevalidate,
+			HistoryRetention:   prefs.HistoryRetention,
+		}
+		ui.ShowSettingsDialog(settings, func(settings ui.Settings) {
+			prefs.Proxy = settings.Proxy
+			prefs.ClientCertificates = settings.ClientCertificates
+			prefs.SkipTLSVerify = settings.SkipTLSVerify
+			prefs.CAFiles = settings.CAFiles
+			prefs.UseSystemCAs = settings.UseSystemCAs
+			prefs.DefaultHeaders = settings.DefaultHeaders
+			prefs.HostOverrides = settings.HostOverrides
+			prefs.CopyCredentials = settings.CopyCredentials
+			prefs.MaxDisplaySize = settings.MaxDisplaySize
+			prefs.TimeThresholds = settings.TimeThresholds
+			prefs.AutoRevalidate = settings.AutoRevalidate
+			prefs.HistoryRetention = settings.HistoryRetention
+			historyPanel.SetTimeThresholds(prefs.TimeThresholds)
+			historyPanel.SetRetention(prefs.HistoryRetention)
+			historyPanel.PruneHistory()
+			if !slices.Equal(prefs.ProtoFiles, settings.ProtoFiles) {
+				prefs.ProtoFiles = settings.ProtoFiles
+				var err error
+				if protoRegistry, err = protobuf.NewRegistry(prefs.ProtoFiles); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}
+			savePreferencesToDB(db, prefs)
+			updateTLSWarning()
+		}, w)
+	})
+
+	loadTestButton := widget.NewButton("Load Test", func() {
+		loadTest := ui.ShowLoadTestDialog(prefs.TimeThresholds, w)
+
+		var result *loadTestResult
+		var stop context.CancelFunc
+		loadTest.OnStart = func(config ui.LoadTestConfig) {
+			request := currentRequest()
+			if cancelRequest != nil || request.URL == "" {
+				dialog.ShowError(errors.New("enter a URL and wait for the current request to finish"), w)
+				loadTest.Finished(ui.LoadTestStats{})
+				return
+			}
+			if !checkPathVariables() {
+				dialog.ShowError(errors.New("enter the path variables marked in the Params tab"), w)
+				loadTest.Finished(ui.LoadTestStats{})
+				return
+			}
+			if !readBodyFile(&request) {
+				loadTest.Finished(ui.LoadTestStats{})
+				return
+			}
+			request, _, ok := resolveRequest(request)
+			if !ok {
+				loadTest.Finished(ui.LoadTestStats{})
+				return
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stop = cancel
+			setRunning(cancel)
+
+			go func() {
+				defer cancel()
+				r, err := runLoadTest(ctx, db, request, config, func(stats ui.LoadTestStats) {
+					fyne.Do(func() {
+						loadTest.Update(stats)
+					})
+				})
+
+				fyne.Do(func() {
+					setRunning(nil)
+					if err != nil {
+						dialog.ShowError(err, w)
+						loadTest.Finished(ui.LoadTestStats{})
+						return
+					}
+					result = r
+					loadTest.Finished(r.stats())
+				})
+			}()
+		}
+		loadTest.OnStop = func() {
+			if stop != nil {
+				stop()
+			}
+		}
+		loadTest.OnExport = func(format string, writer io.Writer) error {
+			if result == nil {
+				return errors.New("no load test results to export")
+			}
+			return result.export(format, writer)
+		}
+	})
+
+	// Images of an HTML preview are fetched with the proxy and TLS settings
+	// of the request options, and never with its headers or cookies
+	responsePreview.OnLoadImage = func(url string) ([]byte, error) {
+		return fetchPreviewImage(&RequestInfo{
+			Method:          http.MethodGet,
+			URL:             url,
+			Timeout:         time.Duration(optionsEditor.GetTimeout()) * time.Second,
+			FollowRedirects: true,
+			MaxRedirects:    ui.DefaultMaxRedirects,
+			Proxy:           optionsEditor.GetProxy().Resolve(prefs.Proxy),
+
+			HostOverrides:      prefs.HostOverrides,
+			InsecureSkipVerify: skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify),
+			CAFiles:            prefs.CAFiles,
+			UseSystemCAs:       prefs.UseSystemCAs,
+		})
+	}
+
+	// webSocketEntry describes the last WebSocket connection for the history
+	var webSocketEntry *storage.RequestHistory
+	webSocketPanel.OnConnect = func(url string, headers []ui.KeyValue) {
+		// The connection uses the proxy, TLS and timeout settings of the
+		// HTTP request options
+		template := RequestInfo{
+			URL:     url,
+			Headers: withDefaultHeaders(headers, prefs.DefaultHeaders),
+			Timeout: time.Duration(optionsEditor.GetTimeout()) * time.Second,
+			Proxy:   optionsEditor.GetProxy().Resolve(prefs.Proxy),
+
+			HostOverrides:      prefs.HostOverrides,
+			ClientCertificates: prefs.ClientCertificates,
+			InsecureSkipVerify: skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify),
+			CAFiles:            prefs.CAFiles,
+			UseSystemCAs:       prefs.UseSystemCAs,
+		}
+		if optionsEditor.GetUseCookies() {
+			template.CookieJar = cookieJar
+		}
+
+		request, maskedURL, ok := resolveRequest(template)
+		if !ok {
+			return
+		}
+		request, dynamicValues, err := applyDynamicVariables(request)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+
+		entry := &storage.RequestHistory{
+			Kind:        storage.HistoryKindWebSocket,
+			URL:         url,
+			Method:      "WS",
+			InsecureTLS: request.InsecureSkipVerify,
+		}
+		resolvedURL := replayDynamicValues(maskedURL, dynamicValues)
+		if resolvedURL != url {
+			entry.ResolvedURL = resolvedURL
+		}
+		if len(dynamicValues) > 0 {
+			dynamicJSON, _ := json.Marshal(dynamicValues)
+			entry.DynamicValues = string(dynamicJSON)
+		}
+		if len(template.Headers) > 0 {
+			headersJSON, _ := json.Marshal(template.Headers)
+			entry.Headers = string(headersJSON)
+		}
+		webSocketEntry = entry
+
+		webSocketPanel.SetMessages(nil)
+		webSocketPanel.SetConnecting()
+		webSocketPanel.AddMessage(ui.WebSocketMessage{Time: time.Now(), Type: ui.WebSocketEvent, Text: "Connecting to " + resolvedURL})
+
+		go func() {
+			session, response, err := dialWebSocket(&request,
+				func(message ui.WebSocketMessage) {
+					fyne.Do(func() {
+						webSocketPanel.AddMessage(message)
+					})
+				},
+				func() {
+					fyne.Do(func() {
+						webSocket = nil
+						webSocketPanel.SetDisconnected()
+					})
+				})
+
+			fyne.Do(func() {
+				entry.ResponseStatus = "Error"
+				if response != nil {
+					entry.ResponseStatus = response.Status
+					headersJSON, _ := json.Marshal(response.Header)
+					entry.ResponseHeaders = string(headersJSON)
+				}
+				if err != nil {
+					webSocketPanel.AddMessage(ui.WebSocketMessage{Time: time.Now(), Type: ui.WebSocketError, Text: err.Error()})
+					webSocketPanel.SetDisconnected()
+					return
+				}
+				webSocket = session
+				webSocketPanel.AddMessage(ui.WebSocketMessage{Time: time.Now(), Type: ui.WebSocketEvent, Text: "Connected: " + response.Status})
+				webSocketPanel.SetConnected(response.Status)
+			})
+			// Frames are read once the connection is shown as open, so the
+			// log stays in order
+			if err == nil {
+				go session.read()
+			}
+		}()
+	}
+	webSocketPanel.OnSend = func(message ui.WebSocketMessage) {
+		if webSocket != nil {
+			go webSocket.send(message)
+		}
+	}
+	webSocketPanel.OnPing = func() {
+		if webSocket != nil {
+			go webSocket.ping()
+		}
+	}
+	webSocketPanel.OnClose = func() {
+		if webSocket != nil {
+			go webSocket.close()
+		}
+	}
+	webSocketPanel.OnSave = func(messages []ui.WebSocketMessage) {
+		if webSocketEntry == nil || len(messages) == 0 {
+			return
+		}
+		entry := *webSocketEntry
+		entry.Timestamp = time.Now()
+		transcriptJSON, _ := json.Marshal(messages)
+		entry.Transcript = string(transcriptJSON)
+		for _, message := range messages {
+			if message.Direction == ui.WebSocketReceived {
+				entry.ResponseSize += message.Size()
+			}
+		}
+		entry.ResponseTimeMs = int(messages[len(messages)-1].Time.Sub(messages[0].Time).Milliseconds())
+		historyPanel.AddToHistory(&entry)
+	}
+
+	// grpcClient is the connection of the gRPC tab, kept for as long as the
+	// address and TLS setting stay the same
+	var grpcClient *grpc.Client
+	var grpcTarget string
+	grpcConnect := func(address string, useTLS bool) (*grpc.Client, error) {
+		target := address
+		if useTLS {
+			target = "tls:" + address
+		}
+		if grpcClient != nil && grpcTarget == target {
+			return grpcClient, nil
+		}
+		if grpcClient != nil {
+			grpcClient.Close()
+			grpcClient = nil
+		}
+
+		var tlsConfig *tls.Config
+		if useTLS {
+			var err error
+			tlsConfig, err = grpcTLSConfig(&RequestInfo{
+				URL:                "https://" + address,
+				ClientCertificates: prefs.ClientCertificates,
+				InsecureSkipVerify: skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify),
+				CAFiles:            prefs.CAFiles,
+				UseSystemCAs:       prefs.UseSystemCAs,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		client, err := grpc.Dial(address, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		grpcClient, grpcTarget = client, target
+		return client, nil
+	}
+	// grpcContext limits reflection and calls to the request timeout
+	grpcContext := func() (context.Context, context.CancelFunc) {
+		if timeout := optionsEditor.GetTimeout(); timeout > 0 {
+			return context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+		}
+		return context.WithCancel(context.Background())
+	}
+	// resolveGRPCAddress substitutes {{variables}} in a server address
+	resolveGRPCAddress := func(address string) (string, bool) {
+		resolved, _, ok := resolveRequest(RequestInfo{URL: address})
+		return resolved.URL, ok
+	}
+
+	grpcPanel.OnLoadServices = func(address string, useTLS bool) {
+		address, ok := resolveGRPCAddress(address)
+		if !ok {
+			return
+		}
+		client, err := grpcConnect(address, useTLS)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		go func() {
+			ctx, cancel := grpcContext()
+			defer cancel()
+			services, err := client.Services(ctx)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				grpcPanel.SetServices(services)
+			})
+		}()
+	}
+	grpcPanel.OnServiceSelected = func(address string, useTLS bool, service string) {
+		address, ok := resolveGRPCAddress(address)
+		if !ok {
+			return
+		}
+		client, err := grpcConnect(address, useTLS)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		go func() {
+			ctx, cancel := grpcContext()
+			defer cancel()
+			methods, err := client.Methods(ctx, service)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				grpcPanel.SetMethods(methods)
+			})
+		}()
+	}
+	grpcPanel.OnInvoke = func(call ui.GRPCCall) {
+		// The address, metadata values and message may use {{variables}}
+		template := RequestInfo{URL: call.Address, Headers: call.Metadata, Body: call.Request}
+		request, maskedAddress, ok := resolveRequest(template)
+		if !ok {
+			return
+		}
+		request, dynamicValues, err := applyDynamicVariables(request)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		client, err := grpcConnect(request.URL, call.TLS)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+
+		entry := &storage.RequestHistory{
+			Kind:        storage.HistoryKindGRPC,
+			URL:         grpcHistoryURL(call),
+			Method:      "gRPC",
+			Body:        call.Request,
+			InsecureTLS: call.TLS && skipTLSVerify(optionsEditor.GetTLSVerify(), prefs.SkipTLSVerify),
+		}
+		resolvedCall := call
+		resolvedCall.Address = replayDynamicValues(maskedAddress, dynamicValues)
+		if resolvedURL := grpcHistoryURL(resolvedCall); resolvedURL != entry.URL {
+			entry.ResolvedURL = resolvedURL
+		}
+		if len(dynamicValues) > 0 {
+			dynamicJSON, _ := json.Marshal(dynamicValues)
+			entry.DynamicValues = string(dynamicJSON)
+		}
+		if len(call.Metadata) > 0 {
+			metadataJSON, _ := json.Marshal(call.Metadata)
+			entry.Headers = string(metadataJSON)
+		}
+
+		grpcPanel.SetRunning(true)
+		go func() {
+			ctx, cancel := grpcContext()
+			defer cancel()
+			started := time.Now()
+			response, err := client.Invoke(ctx, call.Service, call.Method, request.Body, grpcMetadata(request.Headers))
+			elapsed := time.Since(started)
+
+			fyne.Do(func() {
+				grpcPanel.SetRunning(false)
+				entry.Timestamp = time.Now()
+				entry.ResponseTimeMs = int(elapsed.Milliseconds())
+				if err != nil {
+					entry.ResponseStatus = "Error"
+					grpcPanel.SetResult("Error", false, fmt.Sprintf("Error: %v", err), "")
+				} else {
+					status := response.Code.String()
+					if response.StatusMessage != "" {
+						status += ": " + response.StatusMessage
+					}
+					metadataText := describeGRPCMetadata(response.Header, response.Trailer)
+
+					entry.ResponseStatus = status
+					entry.ResponseBody = response.Message
+					entry.ResponseSize = len(response.Message)
+					metadataJSON, _ := json.Marshal(map[string]interface{}{"headers": response.Header, "trailers": response.Trailer})
+					entry.ResponseHeaders = string(metadataJSON)
+
+					grpcPanel.SetResult(fmt.Sprintf("%s (%d ms)", status, elapsed.Milliseconds()), response.Code == codes.OK, response.Message, metadataText)
+				}
+				historyPanel.AddToHistory(entry)
+			})
+		}()
+	}
+
+	// listener is the running webhook listener, if any
+	var listener *webhookListener
+	listenerPanel.SetConfig(prefs.Listener)
+	if requests, err := db.GetListenerRequests(ui.MaxListenerRequests); err != nil {
+		fmt.Printf("Error loading listener requests: %v\n", err)
 	} else {
-		methodDropdown.SetSelected("GET")
+		listenerPanel.SetRequests(requests)
+	}
+	listenerPanel.OnStart = func(config ui.ListenerConfig) {
+		prefs.Listener = config
+		savePreferencesToDB(db, prefs)
+		started, err := startListener(config, func(req *storage.ListenerRequest) {
+			if err := db.SaveListenerRequest(req); err != nil {
+				fmt.Printf("Error saving listener request: %v\n", err)
+			}
+			fyne.Do(func() {
+				listenerPanel.AddRequest(req)
+			})
+		})
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		listener = started
+		listenerPanel.SetRunning(listener.URL)
+	}
+	listenerPanel.OnStop = func() {
+		if listener == nil {
+			return
+		}
+		if err := listener.Stop(); err != nil {
+			fmt.Printf("Error stopping the webhook listener: %v\n", err)
+		}
+		listener = nil
+		listenerPanel.SetStopped()
+	}
+	listenerPanel.OnClear = func() {
+		if err := db.ClearListenerRequests(); err != nil {
+			dialog.ShowError(err, w)
+		}
 	}
 
-	urlEntry := widget.NewEntry()
-	urlEntry.SetPlaceHolder("Enter URL...")
-	if prefs.LastURL != "" {
-		urlEntry.SetText(prefs.LastURL)
+	// mock is the running mock server, if any
+	var mock *mockServer
+	mockPanel.SetPort(prefs.MockPort)
+	mockPanel.SetLAN(prefs.MockLAN)
+	reloadMockCollections := func() {
+		collections, err := db.GetCollections()
+		if err != nil {
+			fmt.Printf("Error loading collections: %v\n", err)
+			return
+		}
+		mockPanel.SetCollections(collections)
 	}
-	urlEntry.OnChanged = func(text string) {
-		prefs.LastURL = text
+	reloadMockCollections()
+	mockPanel.OnStart = func(collectionID *int, port int, lan bool) {
+		prefs.MockPort = port
+		prefs.MockLAN = lan
 		savePreferencesToDB(db, prefs)
+
+		routes, warnings, err := buildMockRoutes(db, collectionID)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if len(routes) == 0 {
+			dialog.ShowError(errors.New("the collection has no saved requests with a response in the history"), w)
+			return
+		}
+		started, err := startMockServer(port, lan, routes, func(entry ui.MockLogEntry) {
+			fyne.Do(func() {
+				mockPanel.AddEntry(entry)
+			})
+		})
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		mock = started
+		mockPanel.SetRunning(mock.URL, len(routes))
+		if len(warnings) > 0 {
+			dialog.ShowInformation("Mock Server Started with Warnings", strings.Join(warnings, "\n"), w)
+		}
+	}
+	mockPanel.OnStop = func() {
+		if mock == nil {
+			return
+		}
+		if err := mock.Stop(); err != nil {
+			fmt.Printf("Error stopping the mock server: %v\n", err)
+		}
+		mock = nil
+		mockPanel.SetStopped()
 	}
 
-	statusLabel := canvas.NewText("Status: -", color.White)
-	sizeLabel := widget.NewLabel("Size: -")
-	timeLabel := widget.NewLabel("Time: -")
+	// Monitored saved requests are run on a schedule while the app is open
+	monitorsPanel := ui.NewMonitorsPanel()
+	var monitors *monitorScheduler
+	reloadMonitors := func() {
+		saved, err := db.GetMonitoredRequests()
+		if err != nil {
+			fmt.Printf("Error loading monitors: %v\n", err)
+			return
+		}
+		var statuses []*ui.MonitorStatus
+		for _, req := range saved {
+			if config := monitorConfig(req); config != nil {
+				statuses = append(statuses, &ui.MonitorStatus{ID: req.ID, Name: req.Name, Config: *config})
+			}
+		}
+		monitorsPanel.SetMonitors(statuses)
+	}
 
-	statsRow := container.NewGridWithColumns(3,
-		statusLabel,
-		sizeLabel,
-		timeLabel,
-	)
+	// checkMonitor sends a monitored request with the settings and variables
+	// of a normal send, but reports problems in the monitors panel instead
+	// of dialogs. A new failure raises a desktop notification.
+	checkMonitor := func(ctx context.Context, id int) {
+		saved, err := db.GetSavedRequest(id)
+		var config *ui.MonitorConfig
+		if err == nil {
+			config = monitorConfig(saved)
+		}
+		if config == nil {
+			// Deleted or no longer monitored
+			monitors.Stop(id)
+			reloadMonitors()
+			return
+		}
 
-	responseArea := widget.NewMultiLineEntry()
-	responseArea.Disable()
-	responseArea.SetText("Response will appear here...")
+		report := func(ok bool, result string) {
+			if wasOK := monitorsPanel.SetResult(id, ok, result, time.Now()); wasOK && !ok {
+				a.SendNotification(fyne.NewNotification("Monitor failing: "+saved.Name, result))
+			}
+		}
+
+		template, err := savedRequestInfo(saved, prefs, cookieJar)
+		if err != nil {
+			report(false, err.Error())
+			return
+		}
+		variables, err := db.GetResolvedVariables(environmentSelector.Selected())
+		if err != nil {
+			report(false, err.Error())
+			return
+		}
+		request, missing, err := resolveVariables(template, variables, vault.Decrypt)
+		if errors.Is(err, secrets.ErrLocked) {
+			report(false, "secret variables are locked; send a request that uses them to unlock")
+			return
+		}
+		if err != nil {
+			report(false, err.Error())
+			return
+		}
+		if len(missing) > 0 {
+			report(false, "no value for "+strings.Join(missing, ", "))
+			return
+		}
+		request.Variables = &scriptVariables{db: db, vault: vault, environmentID: environmentSelector.Selected()}
+		request.Context = ctx
+		maskedURL := maskVariables(applyPathVariables(template.URL, template.PathVariables), variables)
 
-	responseScroll := container.NewScroll(responseArea)
-	responseScroll.SetMinSize(fyne.NewSize(600, 400))
+		go func() {
+			info, dynamicValues, err := prepareSend(request)
+			var response *ResponseInfo
+			if err == nil {
+				response, err = executeWithAuth(db, &info)
+			}
+			if ctx.Err() != nil {
+				return
+			}
 
-	// Create a history panel
-	var historyPanel *ui.HistoryPanel
-	onRequestLoad := func(url, method string) {
-		urlEntry.SetText(url)
-		methodDropdown.SetSelected(method)
+			fyne.Do(func() {
+				entry := &storage.RequestHistory{
+					URL:       saved.URL,
+					Method:    saved.Method,
+					BodyType:  saved.BodyType,
+					Body:      saved.Body,
+					Timestamp: time.Now(),
+					Source:    storage.HistorySourceMonitor,
+
+					InsecureTLS: request.InsecureSkipVerify,
+				}
+				if resolvedURL := replayDynamicValues(maskedURL, dynamicValues); resolvedURL != saved.URL {
+					entry.ResolvedURL = resolvedURL
+				}
+				if len(dynamicValues) > 0 {
+					dynamicJSON, _ := json.Marshal(dynamicValues)
+					entry.DynamicValues = string(dynamicJSON)
+				}
+				if len(template.Headers) > 0 {
+					requestHeadersJSON, _ := json.Marshal(template.Headers)
+					entry.Headers = string(requestHeadersJSON)
+				}
+				entry.Params = saved.Params
+				entry.PathVariables = saved.PathVariables
+
+				if err != nil {
+					entry.ResponseStatus = "Error"
+					historyPanel.AddToHistory(entry)
+					report(false, err.Error())
+					return
+				}
+				entry.ResponseStatus = response.Status
+				entry.ResponseBody, entry.ResponseCharset = storedResponseBody(response)
+				entry.ResponseTimeMs = int(response.ResponseTime.Milliseconds())
+				entry.ResponseSize = response.Size
+				entry.RedirectCount = len(response.Redirects)
+				entry.Protocol = response.Proto
+				entry.RemoteAddr = response.RemoteAddr
+				headersJSON, _ := json.Marshal(response.Headers)
+				entry.ResponseHeaders = string(headersJSON)
+				if response.Timing != nil {
+					timingJSON, _ := json.Marshal(response.Timing)
+					entry.Timing = string(timingJSON)
+				}
+				sizesJSON, _ := json.Marshal(responseSizes(response))
+				entry.Sizes = string(sizesJSON)
+				if response.TLS != nil {
+					tlsJSON, _ := json.Marshal(response.TLS)
+					entry.TLS = string(tlsJSON)
+				}
+				historyPanel.AddToHistory(entry)
+
+				result := fmt.Sprintf("%s (%d ms)", response.Status, response.ResponseTime.Milliseconds())
+				if response.StatusCode != config.ExpectedStatus {
+					result += fmt.Sprintf(", expected %d", config.ExpectedStatus)
+				}
+				report(response.StatusCode == config.ExpectedStatus, result)
+			})
+		}()
 	}
-	historyPanel = ui.NewHistoryPanel(db, onRequestLoad, w)
+	monitors = newMonitorScheduler(func(ctx context.Context, id int) {
+		fyne.Do(func() {
+			if ctx.Err() == nil {
+				checkMonitor(ctx, id)
+			}
+		})
+	})
 
-	// Extract submit logic into a function for reuse
-	submitRequest := func() {
-		url := urlEntry.Text
-		method := methodDropdown.Selected
+	editMonitor := func(id int) {
+		saved, err := db.GetSavedRequest(id)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		ui.ShowMonitorDialog(saved.Name, monitorConfig(saved), func(config *ui.MonitorConfig) {
+			monitor := ""
+			if config != nil {
+				monitorJSON, _ := json.Marshal(config)
+				monitor = string(monitorJSON)
+			}
+			if err := db.SetSavedRequestMonitor(id, monitor); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			reloadMonitors()
+			if config != nil {
+				monitors.Start(id, time.Duration(config.IntervalMinutes)*time.Minute)
+			} else {
+				monitors.Stop(id)
+			}
+		}, w)
+	}
+	collectionsPanel.OnMonitor = func(req *storage.SavedRequest) {
+		editMonitor(req.ID)
+	}
+	monitorsPanel.OnEdit = editMonitor
+	monitorsPanel.OnRunNow = func(id int) {
+		checkMonitor(context.Background(), id)
+	}
 
-		if url == "" {
-			responseArea.SetText("Error: Please enter a URL")
-			statusLabel.Text = "Status: Error"
-			statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
-			statusLabel.Refresh()
-			sizeLabel.SetText("Size: -")
-			timeLabel.SetText("Time: -")
+	// startMonitors schedules every monitored request
+	startMonitors := func() {
+		if saved, err := db.GetMonitoredRequests(); err == nil {
+			for _, req := range saved {
+				if config := monitorConfig(req); config != nil {
+					monitors.Start(req.ID, time.Duration(config.IntervalMinutes)*time.Minute)
+				}
+			}
+		}
+	}
+	reloadMonitors()
+	startMonitors()
+
+	// The local servers are shut down with the window so their ports are
+	// released, and the monitors stop with it
+	w.SetOnClosed(func() {
+		if listener != nil {
+			listener.Stop()
+		}
+		if mock != nil {
+			mock.Stop()
+		}
+		monitors.StopAll()
+		removeBodyFile(shownResponse)
+	})
+
+	variablesButton := widget.NewButton("Variables", func() {
+		ui.ShowVariablesDialog(db, vault, environmentSelector.Reload, w)
+	})
+
+	cookiesButton := widget.NewButton("Cookies", func() {
+		ui.ShowCookieManager(db, cookieJar.Reload, w)
+	})
+
+	// workspaceImported shows what a workspace import changed. The settings
+	// in memory are read again too, as they are all saved whenever one
+	// changes; the window and the request in the editors stay as they are.
+	workspaceImported := func() {
+		imported := loadPreferencesFromDB(db)
+		imported.WindowWidth, imported.WindowHeight = prefs.WindowWidth, prefs.WindowHeight
+		imported.LastURL, imported.LastMethod = prefs.LastURL, prefs.LastMethod
+
+		optionsEditor.SetTimeout(imported.Timeout)
+		optionsEditor.SetFollowRedirects(imported.FollowRedirects)
+		optionsEditor.SetMaxRedirects(imported.MaxRedirects)
+		optionsEditor.SetUseCookies(imported.UseCookies)
+		optionsEditor.SetHTTPVersion(imported.HTTPVersion)
+		optionsEditor.SetAcceptEncoding(imported.AcceptEncoding)
+		responseArea.SetTextStyle(imported.BodyTextStyle)
+		bodyEditor.SetTextStyle(imported.BodyTextStyle)
+		responseArea.SetLineNumbers(imported.LineNumbers)
+		responseArea.SetLinks(imported.Links)
+		historyPanel.SetTimeThresholds(imported.TimeThresholds)
+		historyPanel.SetRetention(imported.HistoryRetention)
+		historyPanel.SetGrouped(imported.GroupHistory)
+		listenerPanel.SetConfig(imported.Listener)
+		mockPanel.SetPort(imported.MockPort)
+		mockPanel.SetLAN(imported.MockLAN)
+		if !slices.Equal(prefs.ProtoFiles, imported.ProtoFiles) {
+			var err error
+			if protoRegistry, err = protobuf.NewRegistry(imported.ProtoFiles); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+		// The editors above report what they were set to as changes
+		*prefs = *imported
+		environmentSelector.Reload()
+		environmentSelector.SetSelected(prefs.ActiveEnvironment)
+		prefs.ActiveEnvironment = environmentSelector.Selected()
+		savePreferencesToDB(db, prefs)
+		updateTLSWarning()
+
+		// Replacing may have deleted the request in the editors
+		if currentSavedRequest != nil {
+			if _, err := db.GetSavedRequest(currentSavedRequest.ID); err != nil {
+				currentSavedRequest = nil
+			}
+		}
+		collectionsPanel.Refresh()
+		reloadMockCollections()
+		monitors.StopAll()
+		reloadMonitors()
+		startMonitors()
+		requestChanged()
+	}
+
+	var workspaceButton *widget.Button
+	workspaceButton = widget.NewButton("Workspace", func() {
+		menu := fyne.NewMenu("",
+			fyne.NewMenuItem("Export Workspace...", func() {
+				ui.ShowWorkspaceExport(db, vault, w)
+			}),
+			fyne.NewMenuItem("Import Workspace...", func() {
+				ui.ShowWorkspaceImport(db, vault, workspaceImported, w)
+			}),
+		)
+		widget.ShowPopUpMenuAtRelativePosition(menu, w.Canvas(), fyne.NewPos(0, workspaceButton.Size().Height), workspaceButton)
+	})
+
+	// The preview opens beside the request editors
+	requestPane := container.NewStack(requestTabs)
+	var previewButton *widget.Button
+	previewButton = widget.NewButtonWithIcon("Preview", theme.VisibilityIcon(), func() {
+		previewShown = !previewShown
+		if previewShown {
+			previewSplit := container.NewHSplit(requestTabs, requestPreview.GetContainer())
+			previewSplit.SetOffset(0.55)
+			requestPane.Objects = []fyne.CanvasObject{previewSplit}
+			previewButton.SetIcon(theme.VisibilityOffIcon())
+			updatePreview()
+		} else {
+			requestPane.Objects = []fyne.CanvasObject{requestTabs}
+			previewButton.SetIcon(theme.VisibilityIcon())
+		}
+		requestPane.Refresh()
+	})
+
+	// The snippet keeps variables as placeholders, so secrets stay out of it
+	codeLanguage := ""
+	codeButton := widget.NewButton("Code", func() {
+		request := currentRequest()
+		var rawBodyFile string
+		if request.BodyType == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile {
+			rawBodyFile = bodyEditor.GetBodyFile()
+		}
+		ui.ShowCodeDialog(codegenRequest(&request, rawBodyFile), codeLanguage, func(language string) {
+			codeLanguage = language
+		}, w)
+	})
+
+	// compareEnvironments sends the request in the editors in two
+	// environments at once and shows the differences between the responses.
+	// Both sends are recorded in the history with the same comparison ID.
+	compareEnvironments := func(left, right *storage.Environment) {
+		if cancelRequest != nil {
 			return
 		}
+		template := currentRequest()
+		if template.URL == "" {
+			dialog.ShowError(errors.New("enter a URL"), w)
+			return
+		}
+		if !checkPathVariables() {
+			return
+		}
+		_, storedRequestBody := storedBody()
+		if !readBodyFile(&template) {
+			return
+		}
+		if bodyEditor.GetBodyType() == ui.BodyTypeRaw && bodyEditor.GetBodySource() == ui.BodySourceFile {
+			storedRequestBody = template.Body
+		}
 
-		responseArea.SetText("Loading...")
-		statusLabel.Text = "Status: Loading..."
+		sides := [2]ui.ComparisonSide{{Environment: "No environment"}, {Environment: "No environment"}}
+		var requests [2]RequestInfo
+		var maskedURLs [2]string
+		for i, environment := range []*storage.Environment{left, right} {
+			environmentID := 0
+			if environment != nil {
+				environmentID = environment.ID
+				sides[i].Environment = environment.Name
+			}
+			var ok bool
+			requests[i], maskedURLs[i], ok = resolveRequestIn(template, environmentID)
+			if !ok {
+				return
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		setRunning(cancel)
+		storedBanner.Hide()
+		statusLabel.Text = "Status: Comparing environments..."
 		statusLabel.Color = color.White
 		statusLabel.Refresh()
-		sizeLabel.SetText("Size: -")
-		timeLabel.SetText("Time: -")
 
+		comparisonID := newComparisonID()
 		go func() {
-			response, err := executeRequest(method, url)
+			defer cancel()
 
-			// Create a history entry
-			historyEntry := &storage.RequestHistory{
-				URL:       url,
-				Method:    method,
-				Timestamp: time.Now(),
+			var responses [2]*ResponseInfo
+			var dynamicValues [2]map[string]string
+			var errs [2]error
+			var wg sync.WaitGroup
+			for i := range requests {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					requests[i].Context = ctx
+					info, values, err := prepareSend(requests[i])
+					dynamicValues[i] = values
+					if err == nil {
+						responses[i], err = executeWithAuth(db, &info)
+					}
+					errs[i] = err
+				}()
 			}
+			wg.Wait()
 
-			if err != nil {
-				historyEntry.ResponseStatus = "Error"
-				responseText := fmt.Sprintf("Error: %v", err)
-
-				// Use the main thread for UI updates
-				responseArea.SetText(responseText)
-				statusLabel.Text = "Status: Error"
-				statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+			fyne.Do(func() {
+				setRunning(nil)
+				statusLabel.Text = "Status: Compared environments"
 				statusLabel.Refresh()
-				sizeLabel.SetText("Size: -")
-				timeLabel.SetText("Time: -")
-			} else {
-				// Update history entry with response data
-				historyEntry.ResponseStatus = response.Status
-				historyEntry.ResponseBody = response.Body
-				historyEntry.ResponseTimeMs = int(response.ResponseTime.Milliseconds())
-				historyEntry.ResponseSize = response.Size
 
-				headersJSON, _ := json.Marshal(response.Headers)
-				historyEntry.ResponseHeaders = string(headersJSON)
-
-				// Use the main thread for UI updates
-				responseArea.SetText(response.Body)
-				statusLabel.Text = fmt.Sprintf("Status: %s", response.Status)
-
-				// Set color based on status code
-				if len(response.Status) > 0 {
-					switch response.Status[0] {
-					case '2':
-						statusLabel.Color = color.RGBA{R: 0, G: 200, B: 0, A: 255} // Green
-					case '3':
-						statusLabel.Color = color.RGBA{R: 0, G: 100, B: 255, A: 255} // Blue
-					case '4':
-						statusLabel.Color = color.RGBA{R: 255, G: 165, B: 0, A: 255} // Orange
-					case '5':
-						statusLabel.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
-					default:
-						statusLabel.Color = color.White
+				for i := range requests {
+					entry := &storage.RequestHistory{
+						URL:          template.URL,
+						Method:       template.Method,
+						BodyType:     template.BodyType,
+						Body:         storedRequestBody,
+						Timestamp:    time.Now(),
+						ComparisonID: comparisonID,
+
+						InsecureTLS: template.InsecureSkipVerify,
+						UnixSocket:  template.UnixSocket,
+					}
+					if resolvedURL := replayDynamicValues(maskedURLs[i], dynamicValues[i]); resolvedURL != template.URL {
+						entry.ResolvedURL = resolvedURL
+					}
+					if len(dynamicValues[i]) > 0 {
+						dynamicJSON, _ := json.Marshal(dynamicValues[i])
+						entry.DynamicValues = string(dynamicJSON)
 					}
+					if len(template.Headers) > 0 {
+						requestHeadersJSON, _ := json.Marshal(template.Headers)
+						entry.Headers = string(requestHeadersJSON)
+					}
+					entry.Params = storedParams(template.Params)
+					entry.PathVariables = storedPathVariables(template.PathVariables)
+					if errs[i] != nil {
+						entry.ResponseStatus = "Error"
+					} else {
+						response := responses[i]
+						entry.ResponseStatus = response.Status
+						entry.ResponseBody, entry.ResponseCharset = storedResponseBody(response)
+						entry.ResponseTimeMs = int(response.ResponseTime.Milliseconds())
+						entry.ResponseSize = response.Size
+						entry.RedirectCount = len(response.Redirects)
+						entry.Protocol = response.Proto
+						entry.RemoteAddr = response.RemoteAddr
+						headersJSON, _ := json.Marshal(response.Headers)
+						entry.ResponseHeaders = string(headersJSON)
+						if response.Timing != nil {
+							timingJSON, _ := json.Marshal(response.Timing)
+							entry.Timing = string(timingJSON)
+						}
+						sizesJSON, _ := json.Marshal(responseSizes(response))
+						entry.Sizes = string(sizesJSON)
+						if response.TLS != nil {
+							tlsJSON, _ := json.Marshal(response.TLS)
+							entry.TLS = string(tlsJSON)
+						}
+					}
+					historyPanel.AddToHistory(entry)
 				}
-				statusLabel.Refresh()
-
-				sizeLabel.SetText(fmt.Sprintf("Size: %d bytes", response.Size))
-				timeLabel.SetText(fmt.Sprintf("Time: %.2f ms", float64(response.ResponseTime.Milliseconds())))
-			}
 
-			// Add to history
-			historyPanel.AddToHistory(historyEntry)
+				if errors.Is(errs[0], errRequestCancelled) || errors.Is(errs[1], errRequestCancelled) {
+					return
+				}
+				ui.ShowComparisonDialog(compareResponses(sides[0], sides[1], responses[0], responses[1], errs[0], errs[1]), w)
+			})
 		}()
 	}
+	compareButton := widget.NewButton("Compare", func() {
+		environments, err := db.GetEnvironments()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		ui.ShowCompareEnvironmentsDialog(environments, compareEnvironments, w)
+	})
 
-	submitButton := widget.NewButtonWithIcon("Submit", theme.MediaPlayIcon(), submitRequest)
-	submitButton.Importance = widget.HighImportance
+	// importCurl replaces the request with a curl command; options with no
+	// equivalent here are listed rather than failing the import
+	importCurl := func(command string) {
+		parsed, err := parseCurlCommand(command)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("cannot import the curl command: %w", err), w)
+			return
+		}
+
+		headersJSON, _ := json.Marshal(parsed.Headers)
+		body := parsed.Body
+		if parsed.BodyType == ui.BodyTypeMultipart {
+			fieldsJSON, _ := json.Marshal(parsed.FormFields)
+			body = string(fieldsJSON)
+		}
+		modeTabs.SelectIndex(0) // HTTP
+		currentSavedRequest = nil
+		showNotes("")
+		responseArea.SetFilter("")
+		loadRequest(parsed.URL, parsed.Method, string(headersJSON), "", "", parsed.BodyType, body)
+		authEditor.SetConfig(parsed.Auth)
+		updateAuthWarning()
+		if parsed.Insecure {
+			optionsEditor.SetTLSVerify(ui.TLSVerifySkip)
+		} else {
+			optionsEditor.SetTLSVerify(ui.TLSVerifyDefault)
+		}
+		optionsEditor.SetUnixSocket(parsed.UnixSocket)
+
+		if len(parsed.Unsupported) > 0 {
+			dialog.ShowInformation("Imported with Warnings",
+				"These parts of the command are not supported and were left out:\n\n"+strings.Join(parsed.Unsupported, "\n"), w)
+		}
+	}
+	importCurlButton := widget.NewButton("Import curl", func() {
+		ui.ShowCurlImportDialog("", importCurl, w)
+	})
+	urlEntry.OnCurlPasted = func(command string) {
+		ui.ShowCurlImportDialog(command, importCurl, w)
+	}
 
 	topBar := container.NewBorder(
 		nil,
 		nil,
-		methodDropdown,
-		submitButton,
+		methodSelector.GetContainer(),
+		container.NewHBox(saveButton, submitButton, cancelButton, repeatButton, loadTestButton, previewButton, codeButton, compareButton, importCurlButton, environmentSelector.GetContainer(), variablesButton, cookiesButton, workspaceButton, settingsButton),
 		urlEntry,
 	)
 
-	topSection := container.NewVBox(
-		topBar,
-		statsRow,
+	responseSection := container.NewBorder(
+		container.NewVBox(storedBanner, statsRow, statusHint.GetContainer(), sizeHint.GetContainer(), remoteAddrLabel, conditionalRow, tlsWarning, uploadProgress, downloadProgress, redirectsLabel, repeatLabel, testsLabel, extractionsLabel, scriptLogLabel, eventsLabel, responseToolbar.GetContainer()),
+		nil,
+		nil,
+		nil,
+		responseTabs,
 	)
 
+	requestSplit := container.NewVSplit(requestPane, responseSection)
+	requestSplit.SetOffset(0.3)
+
 	// Create main content with split view
-	mainContent := container.NewBorder(
-		topSection,
+	httpTab := container.NewTabItem("HTTP", container.NewBorder(
+		container.NewVBox(topBar, notesAccordion),
 		nil,
 		nil,
 		nil,
-		responseScroll,
+		requestSplit,
+	))
+	modeTabs = container.NewAppTabs(httpTab, webSocketTab, grpcTab, listenerTab, mockTab)
+	modeTabs.OnSelected = func(tab *container.TabItem) {
+		// Collections may have been added or removed since the tab was shown
+		if tab == mockTab {
+			reloadMockCollections()
+		}
+	}
+
+	sidebar := container.NewAppTabs(
+		container.NewTabItemWithIcon("History", theme.HistoryIcon(), historyPanel.GetContainer()),
+		container.NewTabItemWithIcon("Collections", theme.FolderIcon(), collectionsPanel.GetContainer()),
+		container.NewTabItemWithIcon("Monitors", theme.MediaRecordIcon(), monitorsPanel.GetContainer()),
 	)
 
-	// Create a split container with the history panel on the left
+	// Create a split container with the history and collections on the left
 	content := container.NewHSplit(
-		historyPanel.GetContainer(),
-		mainContent,
+		sidebar,
+		modeTabs,
 	)
-	content.SetOffset(0.3) // History panel takes 30% of the width
+	content.SetOffset(0.3) // Sidebar takes 30% of the width
 
 	w.SetContent(content)
 
@@ -327,7 +3970,9 @@ func main() {
 		Modifier: fyne.KeyModifierControl,
 	}
 	w.Canvas().AddShortcut(ctrlEnterShortcut, func(shortcut fyne.Shortcut) {
-		submitRequest()
+		if modeTabs.Selected() == httpTab {
+			submitRequest(1, 0)
+		}
 	})
 
 	// Also support Ctrl+Enter with the Enter key (numpad)
@@ -336,7 +3981,9 @@ func main() {
 		Modifier: fyne.KeyModifierControl,
 	}
 	w.Canvas().AddShortcut(ctrlEnterNumpad, func(shortcut fyne.Shortcut) {
-		submitRequest()
+		if modeTabs.Selected() == httpTab {
+			submitRequest(1, 0)
+		}
 	})
 
 	// Ctrl+Q: Quit application
@@ -348,6 +3995,40 @@ func main() {
 		w.Close()
 	})
 
+	// Ctrl+F: Find in the response body
+	ctrlFShortcut := &desktop.CustomShortcut{
+		KeyName:  fyne.KeyF,
+		Modifier: fyne.KeyModifierControl,
+	}
+	w.Canvas().AddShortcut(ctrlFShortcut, func(shortcut fyne.Shortcut) {
+		if modeTabs.Selected() == httpTab {
+			responseTabs.SelectIndex(0) // Body
+			responseArea.ShowFind()
+		}
+	})
+
+	// Ctrl+G: Go to a line of the response body
+	ctrlGShortcut := &desktop.CustomShortcut{
+		KeyName:  fyne.KeyG,
+		Modifier: fyne.KeyModifierControl,
+	}
+	w.Canvas().AddShortcut(ctrlGShortcut, func(shortcut fyne.Shortcut) {
+		if modeTabs.Selected() == httpTab {
+			responseTabs.SelectIndex(0) // Body
+			responseArea.ShowGoToLine()
+		}
+	})
+
+	// Ctrl+= and Ctrl+-: Larger and smaller body text
+	for _, key := range []fyne.KeyName{fyne.KeyEqual, fyne.KeyPlus} {
+		w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: key, Modifier: fyne.KeyModifierControl}, func(shortcut fyne.Shortcut) {
+			setBodyTextStyle(prefs.BodyTextStyle.Resized(1))
+		})
+	}
+	w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyMinus, Modifier: fyne.KeyModifierControl}, func(shortcut fyne.Shortcut) {
+		setBodyTextStyle(prefs.BodyTextStyle.Resized(-1))
+	})
+
 	// F6: Focus URL field (like browsers)
 	w.Canvas().SetOnTypedKey(func(key *fyne.KeyEvent) {
 		if key.Name == fyne.KeyF6 {
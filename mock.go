@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// mockSkippedHeaders are recorded response headers that described the
+// original transfer rather than the body the mock serves.
+var mockSkippedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Date":              true,
+}
+
+// mockRoute answers requests for a saved request's method and path with the
+// response last recorded for it in the history.
+type mockRoute struct {
+	Name     string
+	Method   string
+	Path     string
+	segments []string // "" for a {{variable}}, {name} or :name segment, which matches any value
+	Status   int
+	Headers  []ResponseHeader
+	Body     string
+}
+
+// mockRoutePath returns the path of a saved request's URL. A leading
+// {{variable}} is taken to stand for the scheme and host, like {{baseUrl}}.
+func mockRoutePath(rawURL string) string {
+	path := strings.TrimSpace(rawURL)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+	} else if strings.HasPrefix(path, "{{") {
+		if end := strings.Index(path, "}}"); end >= 0 {
+			path = path[end+2:]
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		// Whatever precedes the first slash is the host
+		if i := strings.Index(path, "/"); i >= 0 {
+			path = path[i:]
+		} else {
+			path = "/"
+		}
+	}
+	return path
+}
+
+func mockSegments(path string) []string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")) || strings.HasPrefix(segment, ":") {
+			segments[i] = ""
+		}
+	}
+	return segments
+}
+
+// key identifies the requests the route matches, so two routes with the same
+// key conflict.
+func (r *mockRoute) key() string {
+	pattern := make([]string, len(r.segments))
+	for i, segment := range r.segments {
+		pattern[i] = segment
+		if segment == "" {
+			pattern[i] = "*"
+		}
+	}
+	return r.Method + " /" + strings.Join(pattern, "/")
+}
+
+func (r *mockRoute) wildcards() int {
+	n := 0
+	for _, segment := range r.segments {
+		if segment == "" {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *mockRoute) matches(method, path string) bool {
+	if method != r.Method {
+		return false
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(r.segments) {
+		return false
+	}
+	for i, segment := range r.segments {
+		if segment != "" && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildMockRoutes turns the saved requests of a collection, or the unsorted
+// ones when collectionID is nil, into routes. Requests with no response in
+// the history and routes that conflict with an earlier one are left out and
+// described in the warnings.
+func buildMockRoutes(db *storage.DB, collectionID *int) ([]*mockRoute, []string, error) {
+	saved, err := db.GetSavedRequests(collectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var routes []*mockRoute
+	var warnings []string
+	byKey := map[string]*mockRoute{}
+	for _, req := range saved {
+		route := &mockRoute{Name: req.Name, Method: req.Method, Path: mockRoutePath(req.URL)}
+		route.segments = mockSegments(route.Path)
+
+		if existing, ok := byKey[route.key()]; ok {
+			warnings = append(warnings, fmt.Sprintf("%q conflicts with %q (%s %s); the first one is served", req.Name, existing.Name, route.Method, existing.Path))
+			continue
+		}
+
+		recorded, err := db.GetLatestResponse(req.URL, req.Method)
+		if err != nil {
+			return nil, nil, err
+		}
+		if recorded == nil {
+			warnings = append(warnings, fmt.Sprintf("%q has no response in the history; send it once to record one", req.Name))
+			continue
+		}
+		status, _, _ := strings.Cut(strings.TrimSpace(recorded.ResponseStatus), " ")
+		route.Status, _ = strconv.Atoi(status)
+		if route.Status < 100 || route.Status > 999 {
+			warnings = append(warnings, fmt.Sprintf("%q has no HTTP status in the history (%s)", req.Name, recorded.ResponseStatus))
+			continue
+		}
+		route.Body = recorded.ResponseBody
+		if recorded.ResponseHeaders != "" {
+			if err := json.Unmarshal([]byte(recorded.ResponseHeaders), &route.Headers); err != nil {
+				fmt.Printf("Error parsing recorded response headers: %v\n", err)
+			}
+		}
+
+		byKey[route.key()] = route
+		routes = append(routes, route)
+	}
+
+	// A literal segment is more specific than a variable one
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].wildcards() < routes[j].wildcards()
+	})
+	return routes, warnings, nil
+}
+
+// mockServer serves mock routes on a local port. Every request is passed to
+// onRequest from the server's goroutines.
+type mockServer struct {
+	server    *http.Server
+	URL       string
+	routes    []*mockRoute
+	onRequest func(ui.MockLogEntry)
+}
+
+// startMockServer serves routes on port of the loopback, or of every
+// interface with lan, as the responses may hold tokens.
+func startMockServer(port int, lan bool, routes []*mockRoute, onRequest func(ui.MockLogEntry)) (*mockServer, error) {
+	host := "127.0.0.1"
+	if lan {
+		host = ""
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mockServer{
+		URL:       localServerURL(ln.Addr().(*net.TCPAddr)),
+		routes:    routes,
+		onRequest: onRequest,
+	}
+	m.server = &http.Server{Handler: m, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := m.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error running the mock server: %v\n", err)
+		}
+	}()
+	return m, nil
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entry := ui.MockLogEntry{Time: time.Now(), Method: r.Method, Path: r.URL.RequestURI()}
+	defer func() {
+		if m.onRequest != nil {
+			m.onRequest(entry)
+		}
+	}()
+
+	for _, route := range m.routes {
+		if !route.matches(r.Method, r.URL.Path) {
+			continue
+		}
+		for _, header := range route.Headers {
+			if !mockSkippedHeaders[http.CanonicalHeaderKey(header.Key)] {
+				w.Header().Add(header.Key, header.Value)
+			}
+		}
+		allowAnyOrigin(w.Header())
+		w.WriteHeader(route.Status)
+		w.Write([]byte(route.Body))
+		entry.Status = route.Status
+		entry.Route = route.Name
+		return
+	}
+
+	allowAnyOrigin(w.Header())
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		entry.Status = http.StatusNoContent
+		entry.Route = "CORS preflight"
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("no mock route for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+	entry.Status = http.StatusNotFound
+}
+
+// allowAnyOrigin lets a frontend served from another port call the mock,
+// unless the recorded response already has a CORS policy.
+func allowAnyOrigin(header http.Header) {
+	if header.Get("Access-Control-Allow-Origin") == "" {
+		header.Set("Access-Control-Allow-Origin", "*")
+	}
+}
+
+// Stop shuts the server down, letting requests in progress finish first.
+func (m *mockServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), listenerShutdownTimeout)
+	defer cancel()
+	return m.server.Shutdown(ctx)
+}
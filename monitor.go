@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// monitorScheduler runs each monitored request on its own ticker while the
+// app is open. onDue is called from the ticker goroutine, once right away
+// and then every interval, with a context that is cancelled when the
+// monitor is stopped.
+type monitorScheduler struct {
+	mu     sync.Mutex
+	cancel map[int]context.CancelFunc
+	onDue  func(ctx context.Context, id int)
+}
+
+func newMonitorScheduler(onDue func(ctx context.Context, id int)) *monitorScheduler {
+	return &monitorScheduler{cancel: map[int]context.CancelFunc{}, onDue: onDue}
+}
+
+// Start (re)schedules the saved request id, replacing an earlier schedule.
+func (s *monitorScheduler) Start(id int, interval time.Duration) {
+	s.Stop(id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel[id] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.onDue(ctx, id)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *monitorScheduler) Stop(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancel[id]; ok {
+		cancel()
+		delete(s.cancel, id)
+	}
+}
+
+// StopAll stops every monitor and cancels the checks in progress.
+func (s *monitorScheduler) StopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, cancel := range s.cancel {
+		cancel()
+		delete(s.cancel, id)
+	}
+}
+
+// monitorConfig returns the schedule stored with a saved request, or nil if
+// it is not monitored.
+func monitorConfig(saved *storage.SavedRequest) *ui.MonitorConfig {
+	if saved.Monitor == "" {
+		return nil
+	}
+	var config ui.MonitorConfig
+	if err := json.Unmarshal([]byte(saved.Monitor), &config); err != nil {
+		fmt.Printf("Error parsing monitor: %v\n", err)
+		return nil
+	}
+	if config.IntervalMinutes < 1 {
+		config.IntervalMinutes = ui.DefaultMonitorInterval
+	}
+	return &config
+}
+
+// savedRequestInfo builds the request a saved request describes, with the
+// client settings from prefs in place of the Options tab, which belongs to
+// the request in the editors. Must be called on the main thread.
+func savedRequestInfo(saved *storage.SavedRequest, prefs *AppPreferences, jar http.CookieJar) (RequestInfo, error) {
+	request := RequestInfo{
+		Method:   saved.Method,
+		URL:      saved.URL,
+		BodyType: saved.BodyType,
+		Timeout:  time.Duration(prefs.Timeout) * time.Second,
+
+		FollowRedirects: prefs.FollowRedirects,
+		MaxRedirects:    prefs.MaxRedirects,
+		HTTPVersion:     prefs.HTTPVersion,
+		AcceptEncoding:  prefs.AcceptEncoding,
+		Proxy:           prefs.Proxy,
+		HostOverrides:   prefs.HostOverrides,
+
+		ClientCertificates: prefs.ClientCertificates,
+		InsecureSkipVerify: prefs.SkipTLSVerify,
+		CAFiles:            prefs.CAFiles,
+		UseSystemCAs:       prefs.UseSystemCAs,
+
+		Script: saved.Script,
+	}
+	if prefs.UseCookies {
+		request.CookieJar = jar
+	}
+
+	if saved.Headers != "" {
+		if err := json.Unmarshal([]byte(saved.Headers), &request.Headers); err != nil {
+			return request, fmt.Errorf("stored headers: %w", err)
+		}
+	}
+	request.Headers = withDefaultHeaders(request.Headers, prefs.DefaultHeaders)
+
+	if saved.PathVariables != "" {
+		if err := json.Unmarshal([]byte(saved.PathVariables), &request.PathVariables); err != nil {
+			return request, fmt.Errorf("stored path variables: %w", err)
+		}
+	}
+	if missing := ui.PathVariableNames(applyPathVariables(saved.URL, request.PathVariables)); len(missing) > 0 {
+		return request, fmt.Errorf("no value for the path variables %s", strings.Join(missing, ", "))
+	}
+
+	if saved.Auth != "" {
+		if err := json.Unmarshal([]byte(saved.Auth), &request.Auth); err != nil {
+			return request, fmt.Errorf("stored auth: %w", err)
+		}
+	}
+
+	switch saved.BodyType {
+	case ui.BodyTypeMultipart:
+		if saved.Body != "" {
+			if err := json.Unmarshal([]byte(saved.Body), &request.FormFields); err != nil {
+				return request, fmt.Errorf("stored form fields: %w", err)
+			}
+		}
+	case ui.BodyTypeBinary:
+		request.BodyFile = saved.Body
+	case ui.BodyTypeGraphQL:
+		// Sent as JSON, which the editors add the header for
+		request.Body = saved.Body
+		if _, ok := findHeader(request.Headers, "Content-Type"); !ok && saved.Body != "" {
+			request.Headers = append(request.Headers, ui.KeyValue{Key: "Content-Type", Value: "application/json"})
+		}
+	default:
+		request.Body = saved.Body
+		if saved.BodySource == ui.BodySourceFile {
+			data, err := os.ReadFile(saved.BodyFile)
+			if err != nil {
+				return request, fmt.Errorf("cannot read the body file: %w", err)
+			}
+			request.Body = string(data)
+		}
+	}
+	return request, nil
+}
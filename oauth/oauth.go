@@ -0,0 +1,282 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const callbackPath = "/callback"
+
+var ErrCancelled = errors.New("authorization cancelled")
+
+type Config struct {
+	AuthURL      string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	RedirectPort int
+}
+
+func (c Config) RedirectURI() string {
+	return fmt.Sprintf("http://127.0.0.1:%d%s", c.RedirectPort, callbackPath)
+}
+
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+func (t *Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// Flow is a single authorization code + PKCE attempt. It owns a temporary
+// loopback HTTP server that receives the redirect from the browser.
+type Flow struct {
+	config   Config
+	verifier string
+	state    string
+	server   *http.Server
+	listener net.Listener
+	result   chan callbackResult
+	cancel   chan struct{}
+}
+
+// StartFlow starts listening for the redirect on the configured port. The
+// caller must eventually call Wait or Cancel so the listener is shut down.
+func StartFlow(config Config) (*Flow, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", config.RedirectPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on redirect port %d: %w", config.RedirectPort, err)
+	}
+
+	f := &Flow{
+		config:   config,
+		verifier: verifier,
+		state:    state,
+		listener: listener,
+		result:   make(chan callbackResult, 1),
+		cancel:   make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, f.handleCallback)
+	f.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := f.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			f.deliver(callbackResult{err: err})
+		}
+	}()
+
+	return f, nil
+}
+
+// AuthCodeURL is the URL to open in the browser.
+func (f *Flow) AuthCodeURL() string {
+	challenge := sha256.Sum256([]byte(f.verifier))
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", f.config.ClientID)
+	params.Set("redirect_uri", f.config.RedirectURI())
+	params.Set("state", f.state)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+	if f.config.Scope != "" {
+		params.Set("scope", f.config.Scope)
+	}
+
+	separator := "?"
+	if strings.Contains(f.config.AuthURL, "?") {
+		separator = "&"
+	}
+	return f.config.AuthURL + separator + params.Encode()
+}
+
+func (f *Flow) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var result callbackResult
+	switch {
+	case query.Get("state") != f.state:
+		result.err = errors.New("authorization response has an unexpected state")
+	case query.Get("error") != "":
+		result.err = fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+	case query.Get("code") == "":
+		result.err = errors.New("authorization response did not include a code")
+	default:
+		result.code = query.Get("code")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if result.err != nil {
+		fmt.Fprintf(w, "<html><body><h3>Authorization failed</h3><p>%s</p></body></html>", html.EscapeString(result.err.Error()))
+	} else {
+		fmt.Fprint(w, "<html><body><h3>Authorization complete</h3><p>You can close this window and return to Golem.</p></body></html>")
+	}
+
+	f.deliver(result)
+}
+
+func (f *Flow) deliver(result callbackResult) {
+	select {
+	case f.result <- result:
+	default:
+	}
+}
+
+// Wait blocks until the browser redirects back, then exchanges the code for
+// a token. The listener is always shut down before Wait returns.
+func (f *Flow) Wait(ctx context.Context) (*Token, error) {
+	defer f.shutdown()
+
+	var result callbackResult
+	select {
+	case result = <-f.result:
+	case <-f.cancel:
+		return nil, ErrCancelled
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", f.config.RedirectURI())
+	form.Set("code_verifier", f.verifier)
+
+	return requestToken(ctx, f.config, form)
+}
+
+// Cancel aborts a pending Wait and shuts the listener down.
+func (f *Flow) Cancel() {
+	select {
+	case <-f.cancel:
+	default:
+		close(f.cancel)
+	}
+	f.shutdown()
+}
+
+func (f *Flow) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	f.server.Shutdown(ctx)
+	// Serve may not have started yet, in which case Shutdown has no
+	// listener to close
+	f.listener.Close()
+}
+
+// Refresh exchanges a refresh token for a new access token. The previous
+// refresh token is kept when the server does not rotate it.
+func Refresh(ctx context.Context, config Config, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	token, err := requestToken(ctx, config, form)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func requestToken(ctx context.Context, config Config, form url.Values) (*Token, error) {
+	form.Set("client_id", config.ClientID)
+	if config.ClientSecret != "" {
+		form.Set("client_secret", config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed tokenResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if parsed.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", parsed.Error, parsed.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || parsed.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned %s without an access token", resp.Status)
+	}
+
+	token := &Token{
+		AccessToken:  parsed.AccessToken,
+		TokenType:    parsed.TokenType,
+		RefreshToken: parsed.RefreshToken,
+	}
+	if parsed.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golem/oauth"
+	"golem/storage"
+	"golem/ui"
+	"net/http"
+	"net/url"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+func oauthConfig(auth ui.AuthConfig) oauth.Config {
+	return oauth.Config{
+		AuthURL:      auth.AuthURL,
+		TokenURL:     auth.TokenURL,
+		ClientID:     auth.ClientID,
+		ClientSecret: auth.ClientSecret,
+		Scope:        auth.Scope,
+		RedirectPort: auth.RedirectPort,
+	}
+}
+
+// Tokens are stored in the preferences table, namespaced by client and
+// token endpoint so different providers don't overwrite each other.
+func oauthTokenKey(auth ui.AuthConfig) string {
+	return fmt.Sprintf("oauth2_token:%s@%s", auth.ClientID, auth.TokenURL)
+}
+
+func loadOAuthToken(db *storage.DB, auth ui.AuthConfig) *oauth.Token {
+	pref, err := db.GetPreference(oauthTokenKey(auth))
+	if err != nil || pref == nil {
+		return nil
+	}
+
+	var token oauth.Token
+	if err := json.Unmarshal([]byte(pref.Value), &token); err != nil {
+		fmt.Printf("Error parsing stored OAuth2 token: %v\n", err)
+		return nil
+	}
+	return &token
+}
+
+func saveOAuthToken(db *storage.DB, auth ui.AuthConfig, token *oauth.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return db.SetPreference(oauthTokenKey(auth), string(data))
+}
+
+func describeOAuthToken(token *oauth.Token) string {
+	switch {
+	case token == nil:
+		return "No token"
+	case token.Expiry.IsZero():
+		return "Token stored"
+	case token.Expired():
+		return "Token expired at " + token.Expiry.Format("Jan 2 15:04")
+	default:
+		return "Token valid until " + token.Expiry.Format("Jan 2 15:04")
+	}
+}
+
+func refreshOAuthToken(db *storage.DB, auth ui.AuthConfig, token *oauth.Token) (*oauth.Token, error) {
+	if token.RefreshToken == "" {
+		return nil, errors.New("OAuth2 token has expired and no refresh token is available; click Get Token")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	refreshed, err := oauth.Refresh(ctx, oauthConfig(auth), token.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh OAuth2 token: %w", err)
+	}
+	if err := saveOAuthToken(db, auth, refreshed); err != nil {
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// executeWithAuth fills in the OAuth2 access token before sending and
+// retries once with a refreshed token when the server answers 401.
+func executeWithAuth(db *storage.DB, request *RequestInfo) (*ResponseInfo, error) {
+	if request.Auth.Type != ui.AuthTypeOAuth2 {
+		return executeRequest(request)
+	}
+
+	token := loadOAuthToken(db, request.Auth)
+	if token == nil {
+		return nil, errors.New("no OAuth2 token; click Get Token in the Auth tab")
+	}
+	if token.Expired() {
+		refreshed, err := refreshOAuthToken(db, request.Auth, token)
+		if err != nil {
+			return nil, err
+		}
+		token = refreshed
+	}
+
+	request.Auth.Token = token.AccessToken
+	response, err := executeRequest(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || token.RefreshToken == "" {
+		return response, err
+	}
+
+	refreshed, refreshErr := refreshOAuthToken(db, request.Auth, token)
+	if refreshErr != nil {
+		fmt.Printf("Error refreshing OAuth2 token after 401: %v\n", refreshErr)
+		return response, nil
+	}
+	request.Auth.Token = refreshed.AccessToken
+	return executeRequest(request)
+}
+
+// startOAuthFlow runs the authorization code flow: it opens the browser,
+// waits for the redirect on the loopback listener and stores the token.
+func startOAuthFlow(a fyne.App, w fyne.Window, db *storage.DB, auth ui.AuthConfig, onToken func(token *oauth.Token)) {
+	if auth.AuthURL == "" || auth.TokenURL == "" || auth.ClientID == "" {
+		dialog.ShowError(errors.New("auth URL, token URL and client ID are required"), w)
+		return
+	}
+
+	flow, err := oauth.StartFlow(oauthConfig(auth))
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+
+	authURL, err := url.Parse(flow.AuthCodeURL())
+	if err != nil {
+		flow.Cancel()
+		dialog.ShowError(err, w)
+		return
+	}
+
+	waiting := dialog.NewCustom("OAuth 2.0", "Cancel",
+		widget.NewLabel("Complete the authorization in your browser..."), w)
+	waiting.SetOnClosed(flow.Cancel)
+	waiting.Show()
+
+	if err := a.OpenURL(authURL); err != nil {
+		fmt.Printf("Error opening browser: %v\n", err)
+	}
+
+	go func() {
+		token, err := flow.Wait(context.Background())
+		if err == nil {
+			err = saveOAuthToken(db, auth, token)
+		}
+
+		fyne.Do(func() {
+			waiting.Hide()
+			if errors.Is(err, oauth.ErrCancelled) {
+				return
+			}
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			onToken(token)
+		})
+	}()
+}
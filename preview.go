@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golem/ui"
+)
+
+// previewBodyLimit is how much of the body the request preview shows.
+const previewBodyLimit = 64 << 10
+
+// previewRequest renders request as the HTTP/1.1 message sent for it,
+// including the headers the client and transport add, with line breaks in
+// place of CRLF. Over HTTP/2 the same headers go out in binary frames. With
+// mask set, the credentials in Authorization headers and the HMAC signature
+// are masked.
+func previewRequest(request *RequestInfo, mask bool) (string, error) {
+	// The body is shown as it is, whatever length is announced for it
+	withoutFault := *request
+	withoutFault.ContentLength = nil
+	req, err := newHTTPRequest(context.Background(), &withoutFault)
+	if err != nil {
+		return "", err
+	}
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if request.ContentLength != nil {
+		if req.Body == nil {
+			req.Body = http.NoBody
+		}
+		req.ContentLength = *request.ContentLength
+	}
+
+	if request.CookieJar != nil {
+		for _, cookie := range request.CookieJar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "Go-http-client/1.1")
+	}
+	if request.AcceptEncoding == "" && req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	switch {
+	case req.Body == nil:
+	case req.ContentLength >= 0:
+		req.Header.Set("Content-Length", fmt.Sprint(req.ContentLength))
+	default:
+		req.Header.Set("Transfer-Encoding", "chunked")
+	}
+
+	if mask {
+		for _, key := range []string{"Authorization", "Proxy-Authorization"} {
+			for i, value := range req.Header[key] {
+				scheme, _, _ := strings.Cut(value, " ")
+				req.Header[key][i] = scheme + " " + secretMask
+			}
+		}
+		// Signed with the mask rather than the secret, so it is no use
+		if request.Auth.Type == ui.AuthTypeHMAC && req.Header.Get(request.Auth.SignatureHeader) != "" {
+			req.Header.Set(request.Auth.SignatureHeader, secretMask)
+		}
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s HTTP/1.1\n", req.Method, req.URL.RequestURI())
+	fmt.Fprintf(&text, "Host: %s\n", req.Host)
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			fmt.Fprintf(&text, "%s: %s\n", key, value)
+		}
+	}
+	text.WriteString("\n")
+
+	if req.Body == nil {
+		return text.String(), nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, previewBodyLimit))
+	if err != nil {
+		return "", err
+	}
+	// The limit may cut a character in half
+	if len(body) == previewBodyLimit {
+		for i := 0; i < utf8.UTFMax-1; i++ {
+			if r, size := utf8.DecodeLastRune(body); r != utf8.RuneError || size != 1 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	}
+	if !utf8.Valid(body) {
+		fmt.Fprintf(&text, "(%d bytes of binary data)", req.ContentLength)
+		return text.String(), nil
+	}
+	text.Write(body)
+	switch {
+	case int64(len(body)) < req.ContentLength:
+		fmt.Fprintf(&text, "\n… (%d more bytes)", req.ContentLength-int64(len(body)))
+	case req.ContentLength < 0 && len(body) >= previewBodyLimit-utf8.UTFMax:
+		text.WriteString("\n… (more)")
+	}
+	return text.String(), nil
+}
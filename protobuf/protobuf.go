@@ -0,0 +1,499 @@
+// Package protobuf decodes protobuf messages without generated code, by the
+// descriptors in descriptor sets or .proto files, and shows the wire format
+// of messages there is no descriptor for.
+package protobuf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// IsContentType reports whether contentType is a protobuf message.
+func IsContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/x-protobuf", "application/protobuf",
+		"application/vnd.google.protobuf", "application/x-google-protobuf":
+		return true
+	}
+	return false
+}
+
+// MessageTypeHint returns the message type a protobuf Content-Type names in
+// its messageType or proto parameter, as some servers send, or "".
+func MessageTypeHint(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"messagetype", "proto"} {
+		if value := params[name]; value != "" {
+			// e.g. proto=file.proto/pkg.Message or type.googleapis.com/pkg.Message
+			return value[strings.LastIndex(value, "/")+1:]
+		}
+	}
+	return ""
+}
+
+// LoadFile reads the file descriptors in a descriptor set, as written by
+// protoc --descriptor_set_out, or compiles a .proto file into one with
+// protoc, which must then be on the PATH.
+func LoadFile(path string) (*descriptorpb.FileDescriptorSet, error) {
+	if strings.EqualFold(filepath.Ext(path), ".proto") {
+		return compileProto(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor file %s: %w", filepath.Base(path), err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil || len(set.GetFile()) == 0 {
+		return nil, fmt.Errorf("descriptor file %s: not a descriptor set; write one with protoc --include_imports --descriptor_set_out", filepath.Base(path))
+	}
+	return set, nil
+}
+
+// compileProto compiles a .proto file, with the files it imports from its
+// directory, into a descriptor set.
+func compileProto(path string) (*descriptorpb.FileDescriptorSet, error) {
+	protoc, err := exec.LookPath("protoc")
+	if err != nil {
+		return nil, fmt.Errorf("%s: compiling a .proto file needs protoc on the PATH; add a descriptor set written with protoc --include_imports --descriptor_set_out instead", filepath.Base(path))
+	}
+	out, err := os.CreateTemp("", "golem-descriptors-*")
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	command := exec.Command(protoc, "--include_imports", "--descriptor_set_out="+out.Name(),
+		"--proto_path="+filepath.Dir(path), path)
+	if output, err := command.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s: protoc failed: %s", filepath.Base(path), strings.TrimSpace(string(output)))
+	}
+	return LoadFile(out.Name())
+}
+
+// Registry holds the message types of the descriptor files loaded.
+type Registry struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types
+	names []string
+}
+
+// NewRegistry loads the descriptor files at paths. The registry holds the
+// message types of the files that loaded even when others did not; the
+// error says which did not.
+func NewRegistry(paths []string) (*Registry, error) {
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	var errs []error
+	for _, path := range paths {
+		set, err := LoadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range set.GetFile() {
+			if _, ok := files[file.GetName()]; !ok {
+				files[file.GetName()] = file
+			}
+		}
+	}
+	// Well-known types left out of a set are taken from those built in
+	for _, file := range files {
+		for _, dependency := range file.GetDependency() {
+			if _, ok := files[dependency]; ok {
+				continue
+			}
+			if local, err := protoregistry.GlobalFiles.FindFileByPath(dependency); err == nil {
+				files[dependency] = protodesc.ToFileDescriptorProto(local)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry := &Registry{files: new(protoregistry.Files)}
+	if len(set.File) > 0 {
+		loaded, err := (protodesc.FileOptions{AllowUnresolvable: true}).NewFiles(set)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid descriptors: %w", err))
+		} else {
+			registry.files = loaded
+		}
+	}
+	registry.types = dynamicpb.NewTypes(registry.files)
+
+	registry.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		if !strings.HasPrefix(file.Path(), "google/protobuf/") {
+			registry.addNames(file.Messages())
+		}
+		return true
+	})
+	sort.Strings(registry.names)
+	return registry, errors.Join(errs...)
+}
+
+func (r *Registry) addNames(messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		if !message.IsMapEntry() {
+			r.names = append(r.names, string(message.FullName()))
+		}
+		r.addNames(message.Messages())
+	}
+}
+
+// MessageTypes returns the full names of the message types, sorted, leaving
+// out the well-known types.
+func (r *Registry) MessageTypes() []string {
+	if r == nil {
+		return nil
+	}
+	return r.names
+}
+
+// Decode decodes data as a message of messageType and returns it as
+// indented JSON. Fields the type does not have are kept under their tag
+// number in brackets, e.g. "[7]", with their value read from the wire.
+func (r *Registry) Decode(data []byte, messageType string) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("unknown message type %s", messageType)
+	}
+	descriptor, err := r.files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return "", fmt.Errorf("unknown message type %s", messageType)
+	}
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return "", fmt.Errorf("%s is not a message type", messageType)
+	}
+
+	message := dynamicpb.NewMessage(messageDescriptor)
+	if err := (proto.UnmarshalOptions{Resolver: r.types}).Unmarshal(data, message); err != nil {
+		return "", err
+	}
+	encoder := jsonEncoder{marshal: protojson.MarshalOptions{Resolver: r.types}}
+	compact, err := encoder.message(message)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// jsonEncoder writes a message as protojson does, adding its unknown
+// fields. Messages without any are left to protojson, which also knows the
+// JSON forms of the well-known types.
+type jsonEncoder struct {
+	marshal protojson.MarshalOptions
+}
+
+func (e jsonEncoder) message(m protoreflect.Message) ([]byte, error) {
+	if !hasUnknown(m) {
+		return e.marshal.Marshal(m.Interface())
+	}
+
+	object := &jsonObject{}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if !m.Has(field) {
+			continue
+		}
+		value, err := e.field(field, m.Get(field))
+		if err != nil {
+			return nil, err
+		}
+		object.add(field.JSONName(), value)
+	}
+	for _, field := range wireFields(m.GetUnknown()) {
+		object.add(fmt.Sprintf("[%d]", field.number), field.value)
+	}
+	return object.bytes(), nil
+}
+
+func (e jsonEncoder) field(field protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	switch {
+	case field.IsList():
+		list := value.List()
+		var out bytes.Buffer
+		out.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			element, err := e.single(field, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			out.Write(element)
+		}
+		out.WriteByte(']')
+		return out.Bytes(), nil
+	case field.IsMap():
+		entries := value.Map()
+		keys := make([]protoreflect.MapKey, 0, entries.Len())
+		entries.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		object := &jsonObject{}
+		for _, key := range keys {
+			element, err := e.single(field.MapValue(), entries.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			object.add(key.String(), element)
+		}
+		return object.bytes(), nil
+	}
+	return e.single(field, value)
+}
+
+func (e jsonEncoder) single(field protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return e.message(value.Message())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return jsonString(string(enumValue.Name())), nil
+		}
+		return []byte(strconv.Itoa(int(value.Enum()))), nil
+	case protoreflect.BoolKind:
+		return []byte(strconv.FormatBool(value.Bool())), nil
+	case protoreflect.StringKind:
+		return jsonString(value.String()), nil
+	case protoreflect.BytesKind:
+		return jsonString(base64.StdEncoding.EncodeToString(value.Bytes())), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return []byte(strconv.FormatInt(value.Int(), 10)), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return []byte(strconv.FormatUint(value.Uint(), 10)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// As strings, as protojson writes them
+		return jsonString(strconv.FormatInt(value.Int(), 10)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return jsonString(strconv.FormatUint(value.Uint(), 10)), nil
+	case protoreflect.FloatKind:
+		return jsonFloat(value.Float(), 32), nil
+	case protoreflect.DoubleKind:
+		return jsonFloat(value.Float(), 64), nil
+	}
+	return nil, fmt.Errorf("field %s has an unsupported kind %s", field.FullName(), field.Kind())
+}
+
+// hasUnknown reports whether m or a message in it has unknown fields.
+func hasUnknown(m protoreflect.Message) bool {
+	if len(m.GetUnknown()) > 0 {
+		return true
+	}
+	found := false
+	m.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsList() && field.Message() != nil:
+			list := value.List()
+			for i := 0; i < list.Len() && !found; i++ {
+				found = hasUnknown(list.Get(i).Message())
+			}
+		case field.IsMap() && field.MapValue().Message() != nil:
+			value.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				found = hasUnknown(value.Message())
+				return !found
+			})
+		case !field.IsList() && !field.IsMap() && field.Message() != nil:
+			found = hasUnknown(value.Message())
+		}
+		return !found
+	})
+	return found
+}
+
+// jsonObject builds a JSON object with its keys in the order added.
+type jsonObject struct {
+	out bytes.Buffer
+}
+
+func (o *jsonObject) add(key string, value []byte) {
+	if o.out.Len() > 0 {
+		o.out.WriteByte(',')
+	}
+	o.out.Write(jsonString(key))
+	o.out.WriteByte(':')
+	o.out.Write(value)
+}
+
+func (o *jsonObject) bytes() []byte {
+	return append(append([]byte{'{'}, o.out.Bytes()...), '}')
+}
+
+func jsonString(s string) []byte {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
+
+// jsonFloat writes f as protojson does, with NaN and the infinities as
+// strings.
+func jsonFloat(f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return jsonString("NaN")
+	case math.IsInf(f, 1):
+		return jsonString("Infinity")
+	case math.IsInf(f, -1):
+		return jsonString("-Infinity")
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+// wireField is a field read from the wire without a descriptor, with its
+// value as JSON; a field repeated on the wire is read as an array.
+type wireField struct {
+	number protowire.Number
+	value  []byte
+}
+
+// wireFields reads the fields in data as JSON values: varints and fixed
+// numbers as numbers, and length-delimited values as text when they are
+// printable, as an object when they are a message, and as base64
+// otherwise. Reading stops at the first malformed field.
+func wireFields(data []byte) []wireField {
+	var numbers []protowire.Number
+	values := make(map[protowire.Number][][]byte)
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			break
+		}
+		data = data[n:]
+
+		var value []byte
+		switch wireType {
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(data)
+			value = []byte(strconv.FormatUint(v, 10))
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			value = []byte(strconv.FormatUint(uint64(v), 10))
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(data)
+			value = []byte(strconv.FormatUint(v, 10))
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(data)
+			value = wireBytesJSON(v)
+		case protowire.StartGroupType:
+			var v []byte
+			v, n = protowire.ConsumeGroup(number, data)
+			value = wireObject(wireFields(v))
+		default:
+			n = -1
+		}
+		if n < 0 {
+			break
+		}
+		data = data[n:]
+
+		if _, ok := values[number]; !ok {
+			numbers = append(numbers, number)
+		}
+		values[number] = append(values[number], value)
+	}
+
+	fields := make([]wireField, 0, len(numbers))
+	for _, number := range numbers {
+		value := values[number][0]
+		if len(values[number]) > 1 {
+			value = append(append([]byte{'['}, bytes.Join(values[number], []byte{','})...), ']')
+		}
+		fields = append(fields, wireField{number: number, value: value})
+	}
+	return fields
+}
+
+func wireBytesJSON(data []byte) []byte {
+	if isPrintable(data) {
+		return jsonString(string(data))
+	}
+	if isMessage(data) {
+		return wireObject(wireFields(data))
+	}
+	return jsonString(base64.StdEncoding.EncodeToString(data))
+}
+
+func wireObject(fields []wireField) []byte {
+	object := &jsonObject{}
+	for _, field := range fields {
+		object.add(fmt.Sprintf("[%d]", field.number), field.value)
+	}
+	return object.bytes()
+}
+
+// isPrintable reports whether data is text: valid UTF-8 without control
+// characters other than whitespace.
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isMessage reports whether data reads as a message to its end.
+func isMessage(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	for len(data) > 0 {
+		_, wireType, n := protowire.ConsumeField(data)
+		if n < 0 || wireType == protowire.EndGroupType {
+			return false
+		}
+		data = data[n:]
+	}
+	return true
+}
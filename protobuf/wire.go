@@ -0,0 +1,121 @@
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxDumpBytes bounds the bytes of a length-delimited value shown in hex.
+const maxDumpBytes = 64
+
+// Dump describes the wire format of a message there is no descriptor for,
+// one field per line: its number, wire type and value, with the length of
+// length-delimited values. Those holding a message are dumped below them,
+// indented. A message that is malformed is dumped up to where it goes
+// wrong, and the error says where.
+func Dump(data []byte) (string, error) {
+	var out strings.Builder
+	err := dump(&out, data, 0, 0)
+	return strings.TrimSuffix(out.String(), "\n"), err
+}
+
+// dump writes the fields in data, which starts at offset in the message, at
+// depth levels of indentation.
+func dump(out *strings.Builder, data []byte, offset, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for position := 0; position < len(data); {
+		number, wireType, n := protowire.ConsumeTag(data[position:])
+		if n < 0 {
+			return fmt.Errorf("malformed tag at byte %d: %w", offset+position, protowire.ParseError(n))
+		}
+		start := position
+		position += n
+		rest := data[position:]
+
+		switch wireType {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(rest)
+			if n < 0 {
+				return fmt.Errorf("field %d at byte %d: %w", number, offset+start, protowire.ParseError(n))
+			}
+			position += n
+			fmt.Fprintf(out, "%s%d varint: %s\n", indent, number, describeVarint(v))
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(rest)
+			if n < 0 {
+				return fmt.Errorf("field %d at byte %d: %w", number, offset+start, protowire.ParseError(n))
+			}
+			position += n
+			fmt.Fprintf(out, "%s%d i32: %d (float %s)\n", indent, number, v,
+				strconv.FormatFloat(float64(math.Float32frombits(v)), 'g', -1, 32))
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(rest)
+			if n < 0 {
+				return fmt.Errorf("field %d at byte %d: %w", number, offset+start, protowire.ParseError(n))
+			}
+			position += n
+			fmt.Fprintf(out, "%s%d i64: %d (double %s)\n", indent, number, v,
+				strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64))
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(rest)
+			if n < 0 {
+				return fmt.Errorf("field %d at byte %d: %w", number, offset+start, protowire.ParseError(n))
+			}
+			valueOffset := offset + position + n - len(v)
+			position += n
+			switch {
+			case isPrintable(v):
+				fmt.Fprintf(out, "%s%d len(%d): %s\n", indent, number, len(v), strconv.Quote(string(v)))
+			case isMessage(v):
+				fmt.Fprintf(out, "%s%d len(%d): message\n", indent, number, len(v))
+				if err := dump(out, v, valueOffset, depth+1); err != nil {
+					return err
+				}
+			default:
+				fmt.Fprintf(out, "%s%d len(%d): %s\n", indent, number, len(v), hexBytes(v))
+			}
+		case protowire.StartGroupType:
+			v, n := protowire.ConsumeGroup(number, rest)
+			if n < 0 {
+				return fmt.Errorf("group %d at byte %d: %w", number, offset+start, protowire.ParseError(n))
+			}
+			fmt.Fprintf(out, "%s%d group\n", indent, number)
+			if err := dump(out, v, offset+position, depth+1); err != nil {
+				return err
+			}
+			position += n
+		default:
+			return fmt.Errorf("field %d at byte %d has the unexpected wire type %d", number, offset+start, wireType)
+		}
+	}
+	return nil
+}
+
+// describeVarint shows a varint as unsigned, and also as an int64 when it
+// is negative as one, as negative int32 and int64 values are sent.
+func describeVarint(v uint64) string {
+	if int64(v) < 0 {
+		return fmt.Sprintf("%d (int64 %d)", v, int64(v))
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// hexBytes shows data in hex, up to maxDumpBytes of it.
+func hexBytes(data []byte) string {
+	var out strings.Builder
+	for i, b := range data {
+		if i == maxDumpBytes {
+			out.WriteString(" …")
+			break
+		}
+		if i > 0 {
+			out.WriteByte(' ')
+		}
+		fmt.Fprintf(&out, "%02x", b)
+	}
+	return out.String()
+}
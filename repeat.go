@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// repeatResult is the outcome of one send in a repeated run.
+type repeatResult struct {
+	Status       string
+	ResponseTime time.Duration
+	Size         int
+	Err          error
+}
+
+// repeatStats summarises a repeated run. Times are in milliseconds so the
+// JSON stored in history stays readable.
+type repeatStats struct {
+	Requested  int            `json:"requested"`
+	Sent       int            `json:"sent"`
+	Errors     int            `json:"errors"`
+	MinMs      float64        `json:"min_ms"`
+	AvgMs      float64        `json:"avg_ms"`
+	P95Ms      float64        `json:"p95_ms"`
+	MaxMs      float64        `json:"max_ms"`
+	TotalBytes int            `json:"total_bytes"`
+	Statuses   map[string]int `json:"statuses"`
+	DelayMs    int64          `json:"delay_ms,omitempty"`
+	Cancelled  bool           `json:"cancelled,omitempty"`
+}
+
+// newRepeatStats computes the timing figures over the sends that got a
+// response; failed sends are only counted.
+func newRepeatStats(requested int, delay time.Duration, results []repeatResult) repeatStats {
+	stats := repeatStats{
+		Requested: requested,
+		Sent:      len(results),
+		Statuses:  make(map[string]int),
+		DelayMs:   delay.Milliseconds(),
+	}
+
+	var times []time.Duration
+	var total time.Duration
+	for _, result := range results {
+		if result.Err != nil {
+			stats.Errors++
+			stats.Statuses["Error"]++
+			continue
+		}
+		stats.Statuses[result.Status]++
+		stats.TotalBytes += result.Size
+		times = append(times, result.ResponseTime)
+		total += result.ResponseTime
+	}
+	if len(times) == 0 {
+		return stats
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	stats.MinMs = milliseconds(times[0])
+	stats.MaxMs = milliseconds(times[len(times)-1])
+	stats.AvgMs = milliseconds(total / time.Duration(len(times)))
+	stats.P95Ms = milliseconds(percentile(times, 95))
+	return stats
+}
+
+// percentile uses the nearest-rank method on sorted times.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (s repeatStats) String() string {
+	statuses := make([]string, 0, len(s.Statuses))
+	for status, count := range s.Statuses {
+		statuses = append(statuses, fmt.Sprintf("%s ×%d", status, count))
+	}
+	sort.Strings(statuses)
+
+	sent := fmt.Sprintf("Sent %d of %d", s.Sent, s.Requested)
+	if s.Cancelled {
+		sent += " (cancelled)"
+	}
+	return fmt.Sprintf("%s — min %.2f ms, avg %.2f ms, p95 %.2f ms, max %.2f ms, %d bytes total\nStatus codes: %s",
+		sent, s.MinMs, s.AvgMs, s.P95Ms, s.MaxMs, s.TotalBytes, strings.Join(statuses, ", "))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golem/secrets"
+	"golem/storage"
+	"golem/ui"
+
+	"github.com/dop251/goja"
+)
+
+// scriptTimeout stops scripts that never finish, e.g. an endless loop.
+const scriptTimeout = 5 * time.Second
+
+// scriptVariables gives the pre-request script access to the variables of
+// the active environment, or the globals when there is none.
+type scriptVariables struct {
+	db            *storage.DB
+	vault         *secrets.Vault
+	environmentID int
+}
+
+// get returns the value the variable resolves to, decrypting secrets.
+func (v *scriptVariables) get(name string) (string, bool, error) {
+	variables, err := v.db.GetResolvedVariables(v.environmentID)
+	if err != nil {
+		return "", false, err
+	}
+	variable, ok := variables[name]
+	if !ok {
+		return "", false, nil
+	}
+	if !variable.Secret {
+		return variable.Value, true, nil
+	}
+	value, err := v.vault.Decrypt(variable.Value)
+	return value, err == nil, err
+}
+
+// set stores the variable in the active environment, or the globals. A
+// variable that is secret stays secret; the result reports whether it is.
+func (v *scriptVariables) set(name, value string) (bool, error) {
+	if !ui.ValidVariableName(name) {
+		return false, fmt.Errorf("invalid variable name %q", name)
+	}
+
+	var existing []*storage.Variable
+	var err error
+	if v.environmentID == 0 {
+		existing, err = v.db.GetVariables()
+	} else {
+		existing, err = v.db.GetEnvironmentVariables(v.environmentID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	variable := &storage.Variable{Name: name, Value: value}
+	for _, e := range existing {
+		if e.Name == name && e.Secret {
+			variable.Secret = true
+			if variable.Value, err = v.vault.Encrypt(value); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	if v.environmentID == 0 {
+		return variable.Secret, v.db.SetVariable(variable)
+	}
+	return variable.Secret, v.db.SetEnvironmentVariable(v.environmentID, variable)
+}
+
+// prepareSend gets request ready for one send: the dynamic variables are
+// evaluated, then the pre-request script is run. It returns the dynamic
+// values used.
+func prepareSend(request RequestInfo) (RequestInfo, map[string]string, error) {
+	request, values, err := applyDynamicVariables(request)
+	if err != nil || request.Script == "" {
+		return request, values, err
+	}
+	if err := runPreRequestScript(&request); err != nil {
+		if errors.Is(err, errRequestCancelled) {
+			return request, values, err
+		}
+		return request, values, fmt.Errorf("pre-request script: %w", err)
+	}
+	return request, values, nil
+}
+
+// runPreRequestScript runs request.Script, which may change the URL, headers
+// and body of request. A script error or exception is returned so the send
+// can be aborted.
+func runPreRequestScript(request *RequestInfo) error {
+	vm := goja.New()
+
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, scriptTimeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		vm.Interrupt(ctx.Err())
+	})
+	defer stop()
+
+	if err := setUpScriptAPI(vm, request); err != nil {
+		return err
+	}
+
+	_, err := vm.RunString(request.Script)
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return errRequestCancelled
+		}
+		return fmt.Errorf("stopped after %v", scriptTimeout)
+	}
+	return err
+}
+
+// setUpScriptAPI defines the request, env, crypto and console objects.
+func setUpScriptAPI(vm *goja.Runtime, request *RequestInfo) error {
+	property := func(object *goja.Object, name string, get func() string, set func(string)) error {
+		getter := vm.ToValue(func(goja.FunctionCall) goja.Value { return vm.ToValue(get()) })
+		var setter goja.Value
+		if set != nil {
+			setter = vm.ToValue(func(call goja.FunctionCall) goja.Value {
+				set(call.Argument(0).String())
+				return goja.Undefined()
+			})
+		}
+		return object.DefineAccessorProperty(name, getter, setter, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	}
+
+	requestObject := vm.NewObject()
+	properties := []struct {
+		name string
+		get  func() string
+		set  func(string)
+	}{
+		{"method", func() string { return request.Method }, nil},
+		{"url", func() string { return request.URL }, func(value string) { request.URL = value }},
+		{"path", func() string {
+			parsed, err := url.Parse(request.URL)
+			if err != nil {
+				return ""
+			}
+			return parsed.RequestURI()
+		}, nil},
+		{"body", func() string { return request.Body }, func(value string) { request.Body = value }},
+	}
+	for _, p := range properties {
+		if err := property(requestObject, p.name, p.get, p.set); err != nil {
+			return err
+		}
+	}
+
+	headers := vm.NewObject()
+	headers.Set("get", func(name string) goja.Value {
+		for _, header := range request.Headers {
+			if !header.Disabled && strings.EqualFold(header.Key, name) {
+				return vm.ToValue(header.Value)
+			}
+		}
+		return goja.Undefined()
+	})
+	headers.Set("set", func(name, value string) {
+		request.Headers = append(removeHeader(request.Headers, name), ui.KeyValue{Key: name, Value: value})
+	})
+	headers.Set("remove", func(name string) {
+		request.Headers = removeHeader(request.Headers, name)
+	})
+	requestObject.Set("headers", headers)
+
+	env := vm.NewObject()
+	env.Set("get", func(name string) (goja.Value, error) {
+		if request.Variables == nil {
+			return goja.Undefined(), nil
+		}
+		value, ok, err := request.Variables.get(name)
+		if err != nil || !ok {
+			return goja.Undefined(), err
+		}
+		return vm.ToValue(value), nil
+	})
+	env.Set("set", func(name, value string) error {
+		if request.Variables == nil {
+			return errors.New("variables are not available")
+		}
+		_, err := request.Variables.set(name, value)
+		return err
+	})
+
+	cryptoObject := vm.NewObject()
+	cryptoObject.Set("sha256", func(data, encoding string) (string, error) {
+		sum := sha256.Sum256([]byte(data))
+		return encodeDigest(sum[:], encoding)
+	})
+	cryptoObject.Set("hmacSHA256", func(key, data, encoding string) (string, error) {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(data))
+		return encodeDigest(mac.Sum(nil), encoding)
+	})
+
+	console := vm.NewObject()
+	console.Set("log", func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, argument := range call.Arguments {
+			parts[i] = argument.String()
+		}
+		request.ScriptLog = append(request.ScriptLog, strings.Join(parts, " "))
+		return goja.Undefined()
+	})
+
+	for name, object := range map[string]*goja.Object{
+		"request": requestObject,
+		"env":     env,
+		"crypto":  cryptoObject,
+		"console": console,
+	} {
+		if err := vm.Set(name, object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeHeader returns headers without those named name, in any case.
+func removeHeader(headers []ui.KeyValue, name string) []ui.KeyValue {
+	kept := make([]ui.KeyValue, 0, len(headers))
+	for _, header := range headers {
+		if !strings.EqualFold(header.Key, name) {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+// encodeDigest encodes sum as hex unless base64 is asked for.
+func encodeDigest(sum []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	}
+	return "", fmt.Errorf("unknown encoding %q: use hex or base64", encoding)
+}
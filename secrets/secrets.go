@@ -0,0 +1,255 @@
+// Package secrets encrypts secret variable values at rest. The AES-256-GCM
+// key is kept in the OS keyring where one is available, or derived from a
+// passphrase the user enters once per session.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golem/storage"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// encryptedPrefix marks stored values that are encrypted, so plaintext
+	// left from before a variable was made secret can be recognised
+	encryptedPrefix = "enc:v1:"
+
+	keyringService = "golem"
+	keyringUser    = "secret-variables"
+
+	// Preference keys
+	keySourcePref = "secrets_key_source"
+	saltPref      = "secrets_salt"
+	checkPref     = "secrets_check"
+
+	keySourceKeyring    = "keyring"
+	keySourcePassphrase = "passphrase"
+
+	checkPlaintext = "golem"
+)
+
+var (
+	ErrLocked             = errors.New("secret variables are locked")
+	ErrPassphraseRequired = errors.New("a passphrase is required to unlock secret variables")
+	ErrWrongPassphrase    = errors.New("wrong passphrase")
+)
+
+// Vault holds the key once unlocked. It is safe for concurrent use.
+type Vault struct {
+	mu  sync.Mutex
+	key []byte
+}
+
+func NewVault() *Vault {
+	return &Vault{}
+}
+
+func (v *Vault) Unlocked() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.key != nil
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// HasPassphrase reports whether secrets are protected by a passphrase that
+// was set earlier, as opposed to one still to be chosen.
+func HasPassphrase(db *storage.DB) bool {
+	return preference(db, keySourcePref) == keySourcePassphrase
+}
+
+// Unlock loads the key from the OS keyring, creating it on first use. It
+// returns ErrPassphraseRequired when secrets use a passphrase or no keyring
+// is available.
+func (v *Vault) Unlock(db *storage.DB) error {
+	if v.Unlocked() {
+		return nil
+	}
+
+	switch preference(db, keySourcePref) {
+	case keySourcePassphrase:
+		return ErrPassphraseRequired
+	case keySourceKeyring:
+		encoded, err := keyring.Get(keyringService, keyringUser)
+		if err != nil {
+			return fmt.Errorf("reading the secrets key from the OS keyring: %w", err)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid secrets key in the OS keyring: %w", err)
+		}
+		return v.unlockChecked(db, key, errors.New("the secrets key in the OS keyring does not match"))
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, keyringUser, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return ErrPassphraseRequired
+	}
+	return v.setUp(db, keySourceKeyring, key)
+}
+
+// UnlockWithPassphrase derives the key from passphrase. The first call sets
+// the passphrase; later calls check it.
+func (v *Vault) UnlockWithPassphrase(db *storage.DB, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("enter a passphrase")
+	}
+
+	if !HasPassphrase(db) {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		key, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		if err := db.SetPreference(saltPref, base64.StdEncoding.EncodeToString(salt)); err != nil {
+			return err
+		}
+		return v.setUp(db, keySourcePassphrase, key)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(preference(db, saltPref))
+	if err != nil {
+		return fmt.Errorf("invalid passphrase salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	return v.unlockChecked(db, key, ErrWrongPassphrase)
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// setUp records how the key is obtained and a value encrypted with it, which
+// later unlocks use to check the key.
+func (v *Vault) setUp(db *storage.DB, source string, key []byte) error {
+	check, err := encrypt(key, checkPlaintext)
+	if err != nil {
+		return err
+	}
+	if err := db.SetPreference(checkPref, check); err != nil {
+		return err
+	}
+	if err := db.SetPreference(keySourcePref, source); err != nil {
+		return err
+	}
+	return v.unlocked(db, key)
+}
+
+func (v *Vault) unlockChecked(db *storage.DB, key []byte, mismatch error) error {
+	if plaintext, err := decrypt(key, preference(db, checkPref)); err != nil || plaintext != checkPlaintext {
+		return mismatch
+	}
+	return v.unlocked(db, key)
+}
+
+// unlocked keeps key and encrypts secret values still stored in plaintext,
+// such as those of variables made secret before any key existed.
+func (v *Vault) unlocked(db *storage.DB, key []byte) error {
+	v.mu.Lock()
+	v.key = key
+	v.mu.Unlock()
+	return db.EncryptSecretVariables(v.Encrypt, IsEncrypted)
+}
+
+func (v *Vault) currentKey() ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return nil, ErrLocked
+	}
+	return v.key, nil
+}
+
+func (v *Vault) Encrypt(plaintext string) (string, error) {
+	key, err := v.currentKey()
+	if err != nil {
+		return "", err
+	}
+	return encrypt(key, plaintext)
+}
+
+// Decrypt returns the plaintext of a value from Encrypt. Values that are not
+// encrypted are returned unchanged.
+func (v *Vault) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	key, err := v.currentKey()
+	if err != nil {
+		return "", err
+	}
+	return decrypt(key, value)
+}
+
+func encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt secret value")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func preference(db *storage.DB, key string) string {
+	pref, err := db.GetPreference(key)
+	if err != nil || pref == nil {
+		return ""
+	}
+	return pref.Value
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"golem/ui"
+)
+
+// tlsDetails describes the TLS connection of a response for the Security
+// tab, or returns nil for a plain HTTP one. host is the hostname the request
+// was sent to; when verification was skipped the leaf certificate is checked
+// against it here, so a mismatch can be flagged.
+func tlsDetails(state *tls.ConnectionState, host string, verified bool) *ui.TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &ui.TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		ALPN:        state.NegotiatedProtocol,
+		Verified:    verified,
+	}
+	// The server name sent is the one the certificate must match, e.g. that
+	// of a Host header
+	if state.ServerName != "" {
+		host = state.ServerName
+	}
+	if len(state.PeerCertificates) > 0 && !verified {
+		if err := state.PeerCertificates[0].VerifyHostname(host); err != nil {
+			info.HostnameError = err.Error()
+		}
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, certificateDetails(cert))
+	}
+	return info
+}
+
+func certificateDetails(cert *x509.Certificate) ui.CertificateInfo {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	sha256Sum := sha256.Sum256(cert.Raw)
+	sha1Sum := sha1.Sum(cert.Raw)
+	return ui.CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SANs:               names,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		KeyType:            publicKeyType(cert),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		SerialNumber:       fingerprint(cert.SerialNumber.Bytes()),
+		SHA256:             fingerprint(sha256Sum[:]),
+		SHA1:               fingerprint(sha1Sum[:]),
+	}
+}
+
+// publicKeyType returns the algorithm and size of the key of cert, e.g.
+// "RSA 2048 bits" or "ECDSA P-256".
+func publicKeyType(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bits", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// fingerprint formats data as colon-separated hex, as openssl does.
+func fingerprint(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"golem/ui"
+)
+
+// minThroughputTransfer is the shortest body transfer a throughput is
+// derived from; below it the rate says more about timer resolution than
+// about the connection.
+const minThroughputTransfer = time.Millisecond
+
+// responseSizes breaks the size of response down into its status line,
+// headers and body, counting the body as it was transferred when it was
+// compressed.
+func responseSizes(response *ResponseInfo) *ui.SizeBreakdown {
+	sizes := &ui.SizeBreakdown{
+		Body:     int64(response.Size),
+		Wire:     int64(response.Size),
+		Encoding: response.ContentEncoding,
+		// "HTTP/1.1 200 OK\r\n"
+		StatusLine: int64(len(response.Proto) + 1 + len(response.Status) + 2),
+	}
+	if response.ContentEncoding != "" {
+		sizes.Wire = int64(response.WireSize)
+	}
+	for _, header := range response.Headers {
+		// "Key: value\r\n"
+		sizes.Headers += int64(len(header.Key) + 2 + len(header.Value) + 2)
+	}
+	// The blank line ending the headers
+	sizes.Headers += 2
+
+	sizes.Total = sizes.StatusLine + sizes.Headers
+	if sizes.Wire >= 0 {
+		sizes.Total += sizes.Wire
+	} else {
+		sizes.Total += sizes.Body
+	}
+
+	if response.Timing != nil && response.Timing.Transfer >= minThroughputTransfer {
+		transferred := sizes.Body
+		if sizes.Wire >= 0 {
+			transferred = sizes.Wire
+		}
+		sizes.Transfer = response.Timing.Transfer
+		sizes.Throughput = float64(transferred) / response.Timing.Transfer.Seconds()
+	}
+	return sizes
+}
+
+// describeSize formats the Size label from the size breakdown.
+func describeSize(response *ResponseInfo, sizes *ui.SizeBreakdown) string {
+	size := sizes.Summary()
+	if response.Partial {
+		size += " (partial, cancelled while receiving)"
+	}
+	return size
+}
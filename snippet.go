@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+
+	"golem/codegen"
+	"golem/ui"
+)
+
+// codegenRequest converts request, as built from the editors before
+// variables are resolved, for code generation. rawBodyFile is the file a raw
+// body is read from, if any. The OAuth2 access token and HMAC signature are
+// not written out.
+func codegenRequest(request *RequestInfo, rawBodyFile string) codegen.Request {
+	out := codegen.Request{
+		Method: request.Method,
+		URL:    applyPathVariables(request.URL, request.PathVariables),
+	}
+
+	for _, header := range request.Headers {
+		if header.Key == "" || header.Disabled {
+			continue
+		}
+		// The auth settings take the place of an Authorization header
+		if auth := request.Auth.Header(); auth != "" && strings.EqualFold(header.Key, auth) {
+			continue
+		}
+		out.Headers = append(out.Headers, codegen.Header{Name: header.Key, Value: header.Value})
+	}
+	if request.AcceptEncoding != "" {
+		out.Headers = append(out.Headers, codegen.Header{Name: "Accept-Encoding", Value: request.AcceptEncoding})
+	}
+
+	switch request.Auth.Type {
+	case ui.AuthTypeBasic:
+		out.BasicAuth = true
+		out.Username = request.Auth.Username
+		out.Password = request.Auth.Password
+	case ui.AuthTypeBearer:
+		out.Headers = append(out.Headers, codegen.Header{Name: "Authorization", Value: "Bearer " + request.Auth.Token})
+	case ui.AuthTypeOAuth2:
+		out.Headers = append(out.Headers, codegen.Header{Name: "Authorization", Value: "Bearer <access token>"})
+	case ui.AuthTypeHMAC:
+		// The signature depends on the time, so it is left to the caller
+		if request.Auth.TimestampHeader != "" {
+			out.Headers = append(out.Headers, codegen.Header{Name: request.Auth.TimestampHeader, Value: "<timestamp>"})
+		}
+		if request.Auth.NonceHeader != "" {
+			out.Headers = append(out.Headers, codegen.Header{Name: request.Auth.NonceHeader, Value: "<nonce>"})
+		}
+		if request.Auth.SignatureHeader != "" {
+			out.Headers = append(out.Headers, codegen.Header{Name: request.Auth.SignatureHeader, Value: "<HMAC signature>"})
+		}
+	}
+
+	switch request.BodyType {
+	case ui.BodyTypeMultipart:
+		for _, field := range request.FormFields {
+			if field.Key == "" {
+				continue
+			}
+			out.Form = append(out.Form, codegen.FormField{Name: field.Key, Value: field.Value, IsFile: field.IsFile})
+		}
+	case ui.BodyTypeBinary:
+		out.BodyFile = request.BodyFile
+	default:
+		if rawBodyFile != "" {
+			out.BodyFile = rawBodyFile
+		} else {
+			out.Body = request.Body
+		}
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"golem/ui"
+)
+
+// errSOCKSProxy marks failures of the SOCKS handshake, including the proxy
+// reporting that it could not reach the host, so they are not mistaken for
+// errors from the host itself.
+var errSOCKSProxy = errors.New("SOCKS proxy error")
+
+// isSOCKSProxy reports whether proxyURL is a SOCKS5 proxy; socks5h:// asks
+// for host names to be resolved by the proxy.
+func isSOCKSProxy(proxyURL *url.URL) bool {
+	return proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h"
+}
+
+// newSOCKSDialer returns a dial function that connects through the SOCKS5
+// proxy at proxyURL. With remoteDNS the proxy resolves host names, which is
+// what split-horizon DNS behind a tunnel needs; otherwise they are resolved
+// here, over network when an IP version is forced. Host overrides apply to
+// the address asked of the proxy.
+func newSOCKSDialer(proxyURL *url.URL, remoteDNS bool, overrides []ui.KeyValue, network string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	var auth *proxy.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "1080")
+	}
+
+	forward := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	dialer, err := proxy.SOCKS5("tcp", proxyAddr, auth, forward)
+	if err != nil {
+		return nil, err
+	}
+	contextDialer := dialer.(proxy.ContextDialer)
+
+	// LookupIP takes ip, ip4 or ip6 where dialing takes tcp, tcp4 or tcp6
+	ipNetwork := "ip" + strings.TrimPrefix(network, "tcp")
+
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		if target, ok := overrideAddress(overrides, addr); ok {
+			addr = target
+		} else if !remoteDNS {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := net.DefaultResolver.LookupIP(ctx, ipNetwork, host)
+			if err != nil {
+				return nil, fmt.Errorf("%w (resolving host names through the proxy can be turned on in the proxy settings)", err)
+			}
+			addr = net.JoinHostPort(ips[0].String(), port)
+		}
+
+		conn, err := contextDialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %v", errSOCKSProxy, proxyAddr, err)
+		}
+		return conn, nil
+	}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxStreamEvents caps the events kept from an event stream for the response
+// area and the history; later events are only counted.
+const maxStreamEvents = 1000
+
+// maxEventLine is the longest line accepted in an event stream.
+const maxEventLine = 16 << 20
+
+// serverEvent is one event of a text/event-stream response.
+type serverEvent struct {
+	Time  time.Time `json:"time"`
+	ID    string    `json:"id,omitempty"`
+	Event string    `json:"event"`
+	Data  string    `json:"data"`
+}
+
+// String formats the event for the response area.
+func (e serverEvent) String() string {
+	header := fmt.Sprintf("[%s] event: %s", e.Time.Format("15:04:05.000"), e.Event)
+	if e.ID != "" {
+		header += "  id: " + e.ID
+	}
+	return header + "\n" + e.Data + "\n\n"
+}
+
+func isEventStream(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// readEventStream parses the events in body as the SSE spec describes until
+// the stream ends or fails, calling onEvent with each event and the number
+// received so far. It returns the first maxStreamEvents events, the total
+// count and the number of bytes read.
+func readEventStream(body io.Reader, onEvent func(event serverEvent, count int)) ([]serverEvent, int, int, error) {
+	counter := &countingWriter{}
+	scanner := bufio.NewScanner(io.TeeReader(body, counter))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLine)
+	scanner.Split(scanEventStreamLines)
+
+	// Not nil even when empty, which marks the response as streamed
+	events := []serverEvent{}
+	count := 0
+	// The last event ID carries over to later events that do not set one
+	var lastID, eventType string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line dispatches the event, unless it has no data
+			if data.Len() > 0 {
+				event := serverEvent{
+					Time:  time.Now(),
+					ID:    lastID,
+					Event: eventType,
+					Data:  strings.TrimSuffix(data.String(), "\n"),
+				}
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				count++
+				if len(events) < maxStreamEvents {
+					events = append(events, event)
+				}
+				if onEvent != nil {
+					onEvent(event, count)
+				}
+			}
+			eventType = ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				lastID = value
+			}
+		}
+	}
+	// An event without its closing blank line is discarded, as the spec says
+	return events, count, int(counter.n), scanner.Err()
+}
+
+// scanEventStreamLines splits an event stream into lines ending in CRLF, LF
+// or CR.
+func scanEventStreamLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				// The LF of a CRLF may be in the next read
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// formatEvents is the response area text for events, noting any beyond the
+// cap that were only counted.
+func formatEvents(events []serverEvent, count int) string {
+	var text strings.Builder
+	for _, event := range events {
+		text.WriteString(event.String())
+	}
+	if count > len(events) {
+		fmt.Fprintf(&text, "(%d more events not shown)\n", count-len(events))
+	}
+	return text.String()
+}
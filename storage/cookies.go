@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Cookie is a cookie kept by the shared cookie jar. Cookies without Expires
+// are session cookies, which are kept until deleted.
+type Cookie struct {
+	ID       int        `json:"id"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"http_only"`
+	HostOnly bool       `json:"host_only"`
+}
+
+// SaveCookie inserts the cookie, replacing any cookie with the same domain,
+// path and name.
+func (db *DB) SaveCookie(cookie *Cookie) error {
+	_, err := db.Exec(
+		`INSERT INTO cookies (domain, path, name, value, expires, secure, http_only, host_only)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(domain, path, name) DO UPDATE SET
+		 value = excluded.value,
+		 expires = excluded.expires,
+		 secure = excluded.secure,
+		 http_only = excluded.http_only,
+		 host_only = excluded.host_only`,
+		cookie.Domain, cookie.Path, cookie.Name, cookie.Value, cookie.Expires,
+		cookie.Secure, cookie.HttpOnly, cookie.HostOnly,
+	)
+	return err
+}
+
+func (db *DB) GetCookies() ([]*Cookie, error) {
+	rows, err := db.Query(`
+		SELECT id, domain, path, name, value, expires, secure, http_only, host_only
+		FROM cookies
+		ORDER BY domain, name, path
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cookies []*Cookie
+	for rows.Next() {
+		var cookie Cookie
+		var expires sql.NullTime
+		err := rows.Scan(
+			&cookie.ID, &cookie.Domain, &cookie.Path, &cookie.Name, &cookie.Value,
+			&expires, &cookie.Secure, &cookie.HttpOnly, &cookie.HostOnly,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if expires.Valid {
+			cookie.Expires = &expires.Time
+		}
+		cookies = append(cookies, &cookie)
+	}
+
+	return cookies, rows.Err()
+}
+
+func (db *DB) DeleteCookie(id int) error {
+	_, err := db.Exec("DELETE FROM cookies WHERE id = ?", id)
+	return err
+}
+
+// DeleteCookieByKey removes a cookie the server has expired.
+func (db *DB) DeleteCookieByKey(domain, path, name string) error {
+	_, err := db.Exec("DELETE FROM cookies WHERE domain = ? AND path = ? AND name = ?", domain, path, name)
+	return err
+}
+
+func (db *DB) DeleteCookiesForDomain(domain string) error {
+	_, err := db.Exec("DELETE FROM cookies WHERE domain = ?", domain)
+	return err
+}
+
+func (db *DB) ClearCookies() error {
+	_, err := db.Exec("DELETE FROM cookies")
+	return err
+}
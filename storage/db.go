@@ -90,12 +90,35 @@ func (db *DB) migrate() error {
 		method TEXT NOT NULL,
 		headers TEXT,
 		body TEXT,
+		body_type TEXT DEFAULT '',
 		timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		response_status TEXT,
 		response_body TEXT,
 		response_headers TEXT,
 		response_time_ms INTEGER,
 		response_size INTEGER,
+		redirect_count INTEGER DEFAULT 0,
+		insecure_tls BOOLEAN DEFAULT 0,
+		protocol TEXT DEFAULT '',
+		stats TEXT DEFAULT '',
+		resolved_url TEXT DEFAULT '',
+		dynamic_values TEXT DEFAULT '',
+		test_results TEXT DEFAULT '',
+		kind TEXT DEFAULT '',
+		transcript TEXT DEFAULT '',
+		events TEXT DEFAULT '',
+		download_path TEXT DEFAULT '',
+		timing TEXT DEFAULT '',
+		remote_addr TEXT DEFAULT '',
+		unix_socket TEXT DEFAULT '',
+		source TEXT DEFAULT '',
+		comparison_id TEXT DEFAULT '',
+		params TEXT DEFAULT '',
+		path_variables TEXT DEFAULT '',
+		partial BOOLEAN DEFAULT 0,
+		tls TEXT DEFAULT '',
+		response_charset TEXT DEFAULT '',
+		sizes TEXT DEFAULT '',
 		is_favorite BOOLEAN DEFAULT 0,
 		collection_id INTEGER,
 		FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE SET NULL
@@ -108,19 +131,191 @@ func (db *DB) migrate() error {
 		method TEXT NOT NULL,
 		headers TEXT,
 		body TEXT,
+		body_type TEXT DEFAULT '',
+		body_source TEXT DEFAULT '',
+		body_file TEXT DEFAULT '',
+		auth TEXT DEFAULT '',
+		script TEXT DEFAULT '',
+		tests TEXT DEFAULT '',
+		extractors TEXT DEFAULT '',
+		monitor TEXT DEFAULT '',
+		notes TEXT DEFAULT '',
+		params TEXT DEFAULT '',
+		path_variables TEXT DEFAULT '',
+		response_filter TEXT DEFAULT '',
 		collection_id INTEGER,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
 	);
 
+	CREATE TABLE IF NOT EXISTS cookies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		domain TEXT NOT NULL,
+		path TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT NOT NULL,
+		expires TIMESTAMP,
+		secure BOOLEAN DEFAULT 0,
+		http_only BOOLEAN DEFAULT 0,
+		host_only BOOLEAN DEFAULT 0,
+		UNIQUE (domain, path, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS variables (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		value TEXT DEFAULT '',
+		secret BOOLEAN DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS environments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS environment_variables (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		environment_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT DEFAULT '',
+		secret BOOLEAN DEFAULT 0,
+		UNIQUE (environment_id, name),
+		FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS listener_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		headers TEXT DEFAULT '',
+		body TEXT DEFAULT '',
+		remote_addr TEXT DEFAULT '',
+		timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS snippets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		body TEXT DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS validators (
+		url TEXT PRIMARY KEY,
+		etag TEXT DEFAULT '',
+		last_modified TEXT DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS graphql_schemas (
+		url TEXT PRIMARY KEY,
+		schema TEXT NOT NULL,
+		fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_request_history_timestamp ON request_history(timestamp DESC);
 	CREATE INDEX IF NOT EXISTS idx_request_history_url ON request_history(url);
+	CREATE INDEX IF NOT EXISTS idx_request_history_url_recent ON request_history(url, timestamp, method);
 	CREATE INDEX IF NOT EXISTS idx_request_history_method ON request_history(method);
 	CREATE INDEX IF NOT EXISTS idx_saved_requests_collection ON saved_requests(collection_id);
 	`
 
-	_, err := db.conn.Exec(schema)
-	return err
+	if _, err := db.conn.Exec(schema); err != nil {
+		return err
+	}
+
+	return db.addMissingColumns()
+}
+
+// columnMigrations lists columns added after the initial schema. Databases
+// created by older versions get them through ALTER TABLE on startup.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"saved_requests", "auth", "TEXT DEFAULT ''"},
+	{"request_history", "body_type", "TEXT DEFAULT ''"},
+	{"saved_requests", "body_type", "TEXT DEFAULT ''"},
+	{"request_history", "redirect_count", "INTEGER DEFAULT 0"},
+	{"request_history", "insecure_tls", "BOOLEAN DEFAULT 0"},
+	{"request_history", "protocol", "TEXT DEFAULT ''"},
+	{"request_history", "stats", "TEXT DEFAULT ''"},
+	{"request_history", "resolved_url", "TEXT DEFAULT ''"},
+	{"request_history", "dynamic_values", "TEXT DEFAULT ''"},
+	{"saved_requests", "script", "TEXT DEFAULT ''"},
+	{"request_history", "test_results", "TEXT DEFAULT ''"},
+	{"saved_requests", "tests", "TEXT DEFAULT ''"},
+	{"saved_requests", "extractors", "TEXT DEFAULT ''"},
+	{"request_history", "kind", "TEXT DEFAULT ''"},
+	{"request_history", "transcript", "TEXT DEFAULT ''"},
+	{"request_history", "events", "TEXT DEFAULT ''"},
+	{"request_history", "download_path", "TEXT DEFAULT ''"},
+	{"saved_requests", "body_source", "TEXT DEFAULT ''"},
+	{"saved_requests", "body_file", "TEXT DEFAULT ''"},
+	{"request_history", "timing", "TEXT DEFAULT ''"},
+	{"request_history", "remote_addr", "TEXT DEFAULT ''"},
+	{"request_history", "unix_socket", "TEXT DEFAULT ''"},
+	{"saved_requests", "monitor", "TEXT DEFAULT ''"},
+	{"request_history", "source", "TEXT DEFAULT ''"},
+	{"request_history", "comparison_id", "TEXT DEFAULT ''"},
+	{"saved_requests", "notes", "TEXT DEFAULT ''"},
+	{"saved_requests", "params", "TEXT DEFAULT ''"},
+	{"request_history", "params", "TEXT DEFAULT ''"},
+	{"saved_requests", "path_variables", "TEXT DEFAULT ''"},
+	{"request_history", "path_variables", "TEXT DEFAULT ''"},
+	{"variables", "secret", "BOOLEAN DEFAULT 0"},
+	{"environment_variables", "secret", "BOOLEAN DEFAULT 0"},
+	{"saved_requests", "response_filter", "TEXT DEFAULT ''"},
+	{"request_history", "partial", "BOOLEAN DEFAULT 0"},
+	{"request_history", "tls", "TEXT DEFAULT ''"},
+	{"request_history", "response_charset", "TEXT DEFAULT ''"},
+	{"request_history", "sizes", "TEXT DEFAULT ''"},
+}
+
+func (db *DB) addMissingColumns() error {
+	for _, m := range columnMigrations {
+		exists, err := db.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
 func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
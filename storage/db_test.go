@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestDB opens an empty, migrated database in a temporary directory.
+func newTestDB(t testing.TB) *DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "golem.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	db := &DB{conn: conn}
+	if err := db.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seedHistory adds count entries to the history, one a minute from
+// start, each with a request body of 2 KB and a response body of 20 KB.
+// Entry i has the method methods[i%4] and the status statuses[i%5], and
+// every tenth is a favorite.
+func seedHistory(t testing.TB, db *DB, count int, start time.Time) {
+	t.Helper()
+	methods := []string{"GET", "POST", "PUT", "DELETE"}
+	statuses := []string{"200 OK", "301 Moved Permanently", "404 Not Found", "500 Internal Server Error", HistoryStatusError}
+	body := strings.Repeat("b", 2<<10)
+	responseBody := strings.Repeat("r", 20<<10)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for i := 0; i < count; i++ {
+		req := &RequestHistory{
+			Method:          methods[i%4],
+			URL:             fmt.Sprintf("https://api.example.com/items/%d", i),
+			Headers:         `[{"key":"Accept","value":"application/json"}]`,
+			Body:            body,
+			Timestamp:       start.Add(time.Duration(i) * time.Minute),
+			ResponseStatus:  statuses[i%5],
+			ResponseBody:    responseBody,
+			ResponseHeaders: `[{"Key":"Content-Type","Value":"application/json"}]`,
+			ResponseSize:    len(responseBody),
+			IsFavorite:      i%10 == 0,
+		}
+		if _, err := tx.Exec(insertRequestHistoryQuery, requestHistoryArgs(req)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestHistorySummariesLargeHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("seeds 10,000 entries")
+	}
+	const count = 10000
+	db := newTestDB(t)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	seedHistory(t, db, count, start)
+
+	// Every page is newest first and without the bodies
+	seen := make(map[int]bool, count)
+	var previous time.Time
+	for offset := 0; ; offset += 100 {
+		page, err := db.GetRequestHistorySummaries(HistoryFilter{}, 100, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, entry := range page {
+			if entry.ResponseBody != "" || entry.Body != "" || entry.Headers != "" || entry.ResponseHeaders != "" {
+				t.Fatalf("entry %d has its bodies or headers", entry.ID)
+			}
+			if !previous.IsZero() && entry.Timestamp.After(previous) {
+				t.Fatalf("entry %d at %v comes after one at %v", entry.ID, entry.Timestamp, previous)
+			}
+			previous = entry.Timestamp
+			seen[entry.ID] = true
+		}
+	}
+	if len(seen) != count {
+		t.Errorf("paged through %d entries, want %d", len(seen), count)
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   int
+	}{
+		{"2xx", HistoryFilter{StatusClass: HistoryStatus2xx}, 2000},
+		{"3xx", HistoryFilter{StatusClass: HistoryStatus3xx}, 2000},
+		{"5xx", HistoryFilter{StatusClass: HistoryStatus5xx}, 2000},
+		{"errors", HistoryFilter{StatusClass: HistoryStatusError}, 2000},
+		{"method", HistoryFilter{Method: "POST"}, 2500},
+		{"method and status", HistoryFilter{Method: "GET", StatusClass: HistoryStatus2xx}, 500},
+		{"favorites", HistoryFilter{FavoritesOnly: true}, 1000},
+		{"date range", HistoryFilter{From: start.Add(1000 * time.Minute), To: start.Add(2000 * time.Minute)}, 1000},
+		{"from", HistoryFilter{From: start.Add(9990 * time.Minute)}, 10},
+		// items/42, items/420 to 429 and items/4200 to 4299
+		{"search", HistoryFilter{Search: "items/42"}, 111},
+		// items/420 and items/4200, 4210 and so on to 4290
+		{"search and favorites", HistoryFilter{Search: "items/42", FavoritesOnly: true}, 11},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			total, err := db.GetRequestHistoryCount(tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tc.want {
+				t.Errorf("count = %d, want %d", total, tc.want)
+			}
+
+			paged := 0
+			for offset := 0; offset < count; offset += 250 {
+				page, err := db.GetRequestHistorySummaries(tc.filter, 250, offset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				for _, entry := range page {
+					if entry.ResponseBody != "" {
+						t.Fatalf("entry %d has its response body", entry.ID)
+					}
+				}
+				paged += len(page)
+			}
+			if paged != tc.want {
+				t.Errorf("paged through %d entries, want %d", paged, tc.want)
+			}
+		})
+	}
+}
+
+func BenchmarkRequestHistorySummaries(b *testing.B) {
+	db := newTestDB(b)
+	seedHistory(b, db, 10000, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	filter := HistoryFilter{StatusClass: HistoryStatus2xx}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetRequestHistorySummaries(filter, 100, (i*100)%2000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Environment is a named set of variables, e.g. dev, staging or prod. Its
+// variables take precedence over the global ones while it is active.
+type Environment struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EnvironmentExport is the JSON file format used to share an environment.
+type EnvironmentExport struct {
+	Name      string     `json:"name"`
+	Variables []Variable `json:"variables"`
+}
+
+func (db *DB) CreateEnvironment(name string) (*Environment, error) {
+	result, err := db.Exec(
+		"INSERT INTO environments (name, created_at) VALUES (?, CURRENT_TIMESTAMP)",
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Environment{ID: int(id), Name: name, CreatedAt: time.Now()}, nil
+}
+
+func (db *DB) GetEnvironments() ([]*Environment, error) {
+	rows, err := db.Query("SELECT id, name, created_at FROM environments ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var environments []*Environment
+	for rows.Next() {
+		var environment Environment
+		if err := rows.Scan(&environment.ID, &environment.Name, &environment.CreatedAt); err != nil {
+			return nil, err
+		}
+		environments = append(environments, &environment)
+	}
+	return environments, rows.Err()
+}
+
+func (db *DB) DeleteEnvironment(id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Foreign keys are not enabled, so remove the variables explicitly
+	if _, err := tx.Exec("DELETE FROM environment_variables WHERE environment_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM environments WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db *DB) GetEnvironmentVariables(environmentID int) ([]*Variable, error) {
+	rows, err := db.Query(
+		"SELECT id, name, value, secret FROM environment_variables WHERE environment_id = ? ORDER BY name",
+		environmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variables []*Variable
+	for rows.Next() {
+		var variable Variable
+		if err := rows.Scan(&variable.ID, &variable.Name, &variable.Value, &variable.Secret); err != nil {
+			return nil, err
+		}
+		variables = append(variables, &variable)
+	}
+	return variables, rows.Err()
+}
+
+// ReplaceEnvironmentVariables stores variables in place of all existing
+// variables of the environment.
+func (db *DB) ReplaceEnvironmentVariables(environmentID int, variables []*Variable) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM environment_variables WHERE environment_id = ?", environmentID); err != nil {
+		return err
+	}
+	for _, variable := range variables {
+		if _, err := tx.Exec(
+			"INSERT INTO environment_variables (environment_id, name, value, secret) VALUES (?, ?, ?, ?)",
+			environmentID, variable.Name, variable.Value, variable.Secret,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const setEnvironmentVariableQuery = `INSERT INTO environment_variables (environment_id, name, value, secret) VALUES (?, ?, ?, ?)
+ ON CONFLICT (environment_id, name) DO UPDATE SET value = excluded.value, secret = excluded.secret`
+
+// SetEnvironmentVariable adds the variable to the environment or updates
+// the one with its name.
+func (db *DB) SetEnvironmentVariable(environmentID int, variable *Variable) error {
+	_, err := db.Exec(setEnvironmentVariableQuery, environmentID, variable.Name, variable.Value, variable.Secret)
+	return err
+}
+
+// MergeEnvironmentVariables adds variables to the environment, updating
+// those with their names and keeping the rest.
+func (db *DB) MergeEnvironmentVariables(environmentID int, variables []*Variable) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, variable := range variables {
+		if _, err := tx.Exec(setEnvironmentVariableQuery, environmentID, variable.Name, variable.Value, variable.Secret); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetResolvedVariables returns the global variables overridden by those of
+// the environment, by name; environmentID 0 means no environment.
+func (db *DB) GetResolvedVariables(environmentID int) (map[string]*Variable, error) {
+	globals, err := db.GetVariables()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]*Variable, len(globals))
+	for _, variable := range globals {
+		resolved[variable.Name] = variable
+	}
+	if environmentID == 0 {
+		return resolved, nil
+	}
+
+	variables, err := db.GetEnvironmentVariables(environmentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range variables {
+		resolved[variable.Name] = variable
+	}
+	return resolved, nil
+}
+
+// ExportEnvironment writes the environment to a JSON file. Secret variables
+// are left out unless decrypt is given, in which case they are written in
+// plaintext.
+func (db *DB) ExportEnvironment(environment *Environment, filepath string, decrypt func(string) (string, error)) error {
+	variables, err := db.GetEnvironmentVariables(environment.ID)
+	if err != nil {
+		return err
+	}
+
+	export := EnvironmentExport{Name: environment.Name, Variables: []Variable{}}
+	for _, variable := range variables {
+		value := variable.Value
+		if variable.Secret {
+			if decrypt == nil {
+				continue
+			}
+			if value, err = decrypt(value); err != nil {
+				return err
+			}
+		}
+		export.Variables = append(export.Variables, Variable{Name: variable.Name, Value: value, Secret: variable.Secret})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath, data)
+}
+
+// ImportEnvironment reads an exported environment. The variables are merged
+// into an environment with the same name rather than duplicating it, so
+// those it has that the file has not, such as secrets left out of an
+// export, are kept. Secret values in the file are stored through encrypt.
+func (db *DB) ImportEnvironment(filepath string, encrypt func(string) (string, error)) (*Environment, error) {
+	data, err := readFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var export EnvironmentExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Name == "" {
+		return nil, errors.New("the file has no environment name")
+	}
+
+	variables := make([]*Variable, len(export.Variables))
+	for i := range export.Variables {
+		variable := &export.Variables[i]
+		if variable.Secret {
+			if variable.Value, err = encrypt(variable.Value); err != nil {
+				return nil, err
+			}
+		}
+		variables[i] = variable
+	}
+
+	environments, err := db.GetEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	var environment *Environment
+	for _, existing := range environments {
+		if existing.Name == export.Name {
+			environment = existing
+			break
+		}
+	}
+	if environment == nil {
+		if environment, err = db.CreateEnvironment(export.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.MergeEnvironmentVariables(environment.ID, variables); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GraphQLSchema is the introspection result last fetched from a GraphQL
+// endpoint, kept until it is refreshed by hand.
+type GraphQLSchema struct {
+	URL string `json:"url"`
+	// Schema is the __schema object of the introspection response, as JSON
+	Schema    string    `json:"schema"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// GetGraphQLSchema returns the schema cached for url, or nil when there is
+// none.
+func (db *DB) GetGraphQLSchema(url string) (*GraphQLSchema, error) {
+	var schema GraphQLSchema
+	err := db.QueryRow(
+		"SELECT url, schema, fetched_at FROM graphql_schemas WHERE url = ?", url,
+	).Scan(&schema.URL, &schema.Schema, &schema.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// SaveGraphQLSchema caches the schema for its URL, replacing the one fetched
+// before.
+func (db *DB) SaveGraphQLSchema(schema *GraphQLSchema) error {
+	_, err := db.Exec(`INSERT INTO graphql_schemas (url, schema, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET schema = excluded.schema, fetched_at = excluded.fetched_at`,
+		schema.URL, schema.Schema, schema.FetchedAt)
+	return err
+}
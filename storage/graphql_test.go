@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGraphQLSchemaCache(t *testing.T) {
+	db := newTestDB(t)
+	const url = "https://api.example.com/graphql"
+
+	if cached, err := db.GetGraphQLSchema(url); err != nil || cached != nil {
+		t.Fatalf("before introspection: got %v, %v; want nothing", cached, err)
+	}
+
+	fetched := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	for _, schema := range []string{`{"queryType":{"name":"Query"}}`, `{"queryType":{"name":"Root"}}`} {
+		if err := db.SaveGraphQLSchema(&GraphQLSchema{URL: url, Schema: schema, FetchedAt: fetched}); err != nil {
+			t.Fatal(err)
+		}
+		fetched = fetched.Add(time.Hour)
+	}
+
+	// A refresh replaces the schema fetched before
+	cached, err := db.GetGraphQLSchema(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Schema != `{"queryType":{"name":"Root"}}` || !cached.FetchedAt.Equal(fetched.Add(-time.Hour)) {
+		t.Errorf("cached = %+v, want the refreshed schema", cached)
+	}
+	if other, err := db.GetGraphQLSchema(url + "/v2"); err != nil || other != nil {
+		t.Errorf("another URL: got %v, %v; want nothing", other, err)
+	}
+}
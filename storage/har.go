@@ -0,0 +1,588 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The HAR 1.2 format, as described at
+// http://www.softwareishard.com/blog/har-12-spec/. Fields other tools add,
+// such as Chrome's _initiator, are ignored on import.
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string     `json:"mimeType"`
+	Params   []harParam `json:"params,omitempty"`
+	Text     string     `json:"text"`
+}
+
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harContent struct {
+	Size        int64  `json:"size"`
+	Compression int64  `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+}
+
+// harTimings are in milliseconds, -1 for a phase that did not happen.
+// Connect includes SSL.
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// The shapes other packages store in the JSON columns of request_history
+// and saved_requests, as far as the HAR and Postman exports need them
+type (
+	storedHeader struct {
+		Key         string `json:"key"`
+		Value       string `json:"value"`
+		Disabled    bool   `json:"disabled,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+	storedResponseHeader struct {
+		Key   string
+		Value string
+	}
+	storedFormField struct {
+		Key    string `json:"key"`
+		Value  string `json:"value"`
+		IsFile bool   `json:"file,omitempty"`
+	}
+	storedTiming struct {
+		DNS      time.Duration `json:"dns"`
+		Connect  time.Duration `json:"connect"`
+		TLS      time.Duration `json:"tls"`
+		Send     time.Duration `json:"send"`
+		Wait     time.Duration `json:"wait"`
+		Transfer time.Duration `json:"transfer"`
+		Total    time.Duration `json:"total"`
+		Reused   bool          `json:"reused"`
+	}
+	storedSizes struct {
+		Body       int64  `json:"body"`
+		Wire       int64  `json:"wire"`
+		Encoding   string `json:"encoding,omitempty"`
+		Headers    int64  `json:"headers"`
+		StatusLine int64  `json:"status_line"`
+		Total      int64  `json:"total"`
+	}
+)
+
+// bodyTypeMultipart is the body type of a request whose body is stored as
+// its form fields.
+const bodyTypeMultipart = "multipart"
+
+// bodyTypeGraphQL is the body type of a GraphQL request, whose body is
+// stored as the JSON it is sent as.
+const bodyTypeGraphQL = "graphql"
+
+// harTimeLayout is how HAR times are written, in ISO 8601 with milliseconds.
+const harTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// ExportHistoryHAR writes the HTTP requests in the history to w as a HAR 1.2
+// log, oldest first, each entry as it is read. WebSocket sessions and gRPC
+// calls have no HAR form and are left out.
+func (db *DB) ExportHistoryHAR(w io.Writer) error {
+	rows, err := db.Query(`SELECT ` + requestHistoryColumns + ` FROM request_history WHERE kind = '' ORDER BY timestamp`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	creator, _ := json.Marshal(harCreator{Name: "Golem", Version: harCreatorVersion()})
+	if _, err := fmt.Fprintf(w, "{\n  \"log\": {\n    \"version\": \"1.2\",\n    \"creator\": %s,\n    \"entries\": [", creator); err != nil {
+		return err
+	}
+	separator := "\n"
+	for rows.Next() {
+		req, err := scanRequestHistory(rows)
+		if err != nil {
+			return err
+		}
+		entry, err := json.MarshalIndent(harEntryOf(req), "      ", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, separator+"      "+string(entry)); err != nil {
+			return err
+		}
+		separator = ",\n"
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n    ]\n  }\n}\n")
+	return err
+}
+
+// harCreatorVersion is the version golem was built as, "(devel)" for a
+// local build.
+func harCreatorVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// harEntryOf describes a history entry as a HAR entry.
+func harEntryOf(req *RequestHistory) harEntry {
+	entry := harEntry{
+		StartedDateTime: req.Timestamp.Format(harTimeLayout),
+		Request:         harRequestOf(req),
+		Response:        harResponseOf(req),
+		Timings:         harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: float64(req.ResponseTimeMs)},
+	}
+
+	var timing storedTiming
+	if req.Timing != "" && json.Unmarshal([]byte(req.Timing), &timing) == nil {
+		entry.Timings = harTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Send:    milliseconds(timing.Send),
+			Wait:    milliseconds(timing.Wait),
+			Receive: milliseconds(timing.Transfer),
+		}
+		if !timing.Reused {
+			entry.Timings.DNS = milliseconds(timing.DNS)
+			entry.Timings.Connect = milliseconds(timing.Connect + timing.TLS)
+			if timing.TLS > 0 {
+				entry.Timings.SSL = milliseconds(timing.TLS)
+			}
+		}
+	}
+	if timing.Total > 0 {
+		entry.Time = milliseconds(timing.Total)
+	} else {
+		for _, phase := range []float64{entry.Timings.DNS, entry.Timings.Connect, entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+			entry.Time += max(phase, 0)
+		}
+	}
+
+	// Imported entries have the address without a port
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		entry.ServerIPAddress = host
+	} else if req.UnixSocket == "" {
+		entry.ServerIPAddress = req.RemoteAddr
+	}
+	if entry.Response.Status == 0 {
+		entry.Comment = "The request failed without a response"
+	}
+	return entry
+}
+
+func harRequestOf(req *RequestHistory) harRequest {
+	request := harRequest{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: harHTTPVersion(req.Protocol),
+		Cookies:     []harCookie{},
+		Headers:     []harNameValue{},
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+	}
+	if req.ResolvedURL != "" {
+		request.URL = req.ResolvedURL
+	}
+
+	var headers []storedHeader
+	if req.Headers != "" {
+		json.Unmarshal([]byte(req.Headers), &headers)
+	}
+	header := http.Header{}
+	for _, h := range headers {
+		if !h.Disabled {
+			request.Headers = append(request.Headers, harNameValue{Name: h.Key, Value: h.Value})
+			header.Add(h.Key, h.Value)
+		}
+	}
+	for _, cookie := range (&http.Request{Header: header}).Cookies() {
+		request.Cookies = append(request.Cookies, harCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	if parsed, err := url.Parse(request.URL); err == nil {
+		request.QueryString = queryPairs(parsed.RawQuery)
+	}
+
+	switch {
+	case req.BodyType == bodyTypeMultipart:
+		var fields []storedFormField
+		json.Unmarshal([]byte(req.Body), &fields)
+		postData := &harPostData{MimeType: "multipart/form-data"}
+		for _, field := range fields {
+			if field.IsFile {
+				postData.Params = append(postData.Params, harParam{Name: field.Key, FileName: field.Value})
+			} else {
+				postData.Params = append(postData.Params, harParam{Name: field.Key, Value: field.Value})
+			}
+		}
+		request.PostData = postData
+		request.BodySize = -1
+	case (req.BodyType == "" || req.BodyType == bodyTypeGraphQL) && req.Body != "":
+		request.PostData = &harPostData{MimeType: header.Get("Content-Type"), Text: req.Body}
+		request.BodySize = int64(len(req.Body))
+	case req.Body != "":
+		// A binary body is stored as the path of its file
+		request.PostData = &harPostData{MimeType: header.Get("Content-Type")}
+		request.BodySize = -1
+	}
+	return request
+}
+
+func harResponseOf(req *RequestHistory) harResponse {
+	response := harResponse{
+		HTTPVersion: harHTTPVersion(req.Protocol),
+		Cookies:     []harCookie{},
+		Headers:     []harNameValue{},
+		Content:     harContent{MimeType: "x-unknown"},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	code, text, _ := strings.Cut(req.ResponseStatus, " ")
+	if status, err := strconv.Atoi(code); err == nil {
+		response.Status, response.StatusText = status, text
+	} else {
+		// A failed request, recorded as Chrome does
+		response.HTTPVersion = ""
+		return response
+	}
+
+	var headers []storedResponseHeader
+	if req.ResponseHeaders != "" {
+		json.Unmarshal([]byte(req.ResponseHeaders), &headers)
+	}
+	header := http.Header{}
+	for _, h := range headers {
+		response.Headers = append(response.Headers, harNameValue{Name: h.Key, Value: h.Value})
+		header.Add(h.Key, h.Value)
+	}
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		harCookie := harCookie{Name: cookie.Name, Value: cookie.Value, Path: cookie.Path, Domain: cookie.Domain, HTTPOnly: cookie.HttpOnly, Secure: cookie.Secure}
+		if !cookie.Expires.IsZero() {
+			harCookie.Expires = cookie.Expires.Format(harTimeLayout)
+		}
+		response.Cookies = append(response.Cookies, harCookie)
+	}
+	response.RedirectURL = header.Get("Location")
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		response.Content.MimeType = contentType
+	}
+
+	response.Content.Size = int64(req.ResponseSize)
+	if req.DownloadPath == "" {
+		// Bodies that are not UTF-8 text, being binary or in another
+		// charset, are kept as received in base64
+		if utf8.ValidString(req.ResponseBody) && req.ResponseCharset == "" {
+			response.Content.Text = req.ResponseBody
+		} else {
+			response.Content.Text = base64.StdEncoding.EncodeToString([]byte(req.ResponseBody))
+			response.Content.Encoding = "base64"
+		}
+	}
+
+	var sizes storedSizes
+	if req.Sizes != "" && json.Unmarshal([]byte(req.Sizes), &sizes) == nil {
+		response.HeadersSize = sizes.StatusLine + sizes.Headers
+		response.BodySize = sizes.Wire
+		if sizes.Wire >= 0 && sizes.Wire < response.Content.Size {
+			response.Content.Compression = response.Content.Size - sizes.Wire
+		}
+	} else if header.Get("Content-Encoding") == "" {
+		response.BodySize = int64(req.ResponseSize)
+	}
+	return response
+}
+
+// harHTTPVersion is protocol as HAR writes it, HTTP/1.1 when unknown.
+func harHTTPVersion(protocol string) string {
+	if protocol == "" {
+		return "HTTP/1.1"
+	}
+	return protocol
+}
+
+// queryPairs lists the parameters of a query string in their order, which
+// url.Values does not keep.
+func queryPairs(query string) []harNameValue {
+	pairs := []harNameValue{}
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		pairs = append(pairs, harNameValue{Name: name, Value: value})
+	}
+	return pairs
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// ImportHAR adds the entries of a HAR log read from r to the history and
+// returns how many there were.
+func (db *DB) ImportHAR(r io.Reader) (int, error) {
+	var har struct {
+		Log harLog `json:"log"`
+	}
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return 0, fmt.Errorf("not a HAR file: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for i, entry := range har.Log.Entries {
+		req, err := historyOfHAREntry(entry)
+		if err != nil {
+			return 0, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(insertRequestHistoryQuery, requestHistoryArgs(req)...); err != nil {
+			return 0, err
+		}
+	}
+	return len(har.Log.Entries), tx.Commit()
+}
+
+// historyOfHAREntry maps a HAR entry back to a history entry.
+func historyOfHAREntry(entry harEntry) (*RequestHistory, error) {
+	started, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startedDateTime %q", entry.StartedDateTime)
+	}
+	req := &RequestHistory{
+		Method: entry.Request.Method,
+		URL:    entry.Request.URL,
+		// In local time, as entries recorded here are
+		Timestamp:      started.Local(),
+		ResponseTimeMs: int(math.Round(entry.Time)),
+		RemoteAddr:     entry.ServerIPAddress,
+	}
+
+	// HTTP/2 pseudo-headers such as :authority are not headers to send
+	var headers []storedHeader
+	for _, header := range entry.Request.Headers {
+		if !strings.HasPrefix(header.Name, ":") {
+			headers = append(headers, storedHeader{Key: header.Name, Value: header.Value})
+		}
+	}
+	if len(headers) > 0 {
+		headersJSON, _ := json.Marshal(headers)
+		req.Headers = string(headersJSON)
+	}
+
+	if postData := entry.Request.PostData; postData != nil {
+		mediaType, _, _ := mime.ParseMediaType(postData.MimeType)
+		switch {
+		case postData.Text != "" || len(postData.Params) == 0:
+			req.Body = postData.Text
+		case mediaType == "multipart/form-data":
+			var fields []storedFormField
+			for _, param := range postData.Params {
+				if param.FileName != "" {
+					fields = append(fields, storedFormField{Key: param.Name, Value: param.FileName, IsFile: true})
+				} else {
+					fields = append(fields, storedFormField{Key: param.Name, Value: param.Value})
+				}
+			}
+			fieldsJSON, _ := json.Marshal(fields)
+			req.BodyType, req.Body = bodyTypeMultipart, string(fieldsJSON)
+		default:
+			values := url.Values{}
+			for _, param := range postData.Params {
+				values.Add(param.Name, param.Value)
+			}
+			req.Body = values.Encode()
+		}
+	}
+
+	response := entry.Response
+	if response.Status == 0 {
+		req.ResponseStatus = "Error"
+		return req, nil
+	}
+	statusText := response.StatusText
+	if statusText == "" {
+		statusText = http.StatusText(response.Status)
+	}
+	req.ResponseStatus = strings.TrimSpace(fmt.Sprintf("%d %s", response.Status, statusText))
+	req.Protocol = protocolOfHAR(response.HTTPVersion)
+
+	var responseHeaders []storedResponseHeader
+	header := http.Header{}
+	for _, h := range response.Headers {
+		if !strings.HasPrefix(h.Name, ":") {
+			responseHeaders = append(responseHeaders, storedResponseHeader{Key: h.Name, Value: h.Value})
+			header.Add(h.Name, h.Value)
+		}
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = response.Content.MimeType
+	}
+	if len(responseHeaders) > 0 {
+		headersJSON, _ := json.Marshal(responseHeaders)
+		req.ResponseHeaders = string(headersJSON)
+	}
+
+	req.ResponseBody = response.Content.Text
+	if response.Content.Encoding == "base64" {
+		body, err := base64.StdEncoding.DecodeString(response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 response body: %w", err)
+		}
+		req.ResponseBody = string(body)
+		// Bytes in another charset are decoded again when shown
+		if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" && !strings.EqualFold(params["charset"], "utf-8") {
+			req.ResponseCharset = params["charset"]
+		}
+	}
+	req.ResponseSize = int(response.Content.Size)
+	if req.ResponseSize < 0 || req.ResponseSize < len(req.ResponseBody) && response.Content.Compression == 0 {
+		req.ResponseSize = len(req.ResponseBody)
+	}
+
+	// The status line is counted in with the headers
+	if response.HeadersSize >= 0 && response.BodySize >= 0 {
+		sizesJSON, _ := json.Marshal(storedSizes{
+			Body:     int64(req.ResponseSize),
+			Wire:     response.BodySize,
+			Encoding: header.Get("Content-Encoding"),
+			Headers:  response.HeadersSize,
+			Total:    response.HeadersSize + response.BodySize,
+		})
+		req.Sizes = string(sizesJSON)
+	}
+
+	timings := entry.Timings
+	timing := storedTiming{
+		Send:     harDuration(timings.Send),
+		Wait:     harDuration(timings.Wait),
+		Transfer: harDuration(timings.Receive),
+		Total:    harDuration(entry.Time),
+		Reused:   timings.Connect < 0,
+	}
+	if !timing.Reused {
+		timing.DNS = harDuration(timings.DNS)
+		timing.TLS = harDuration(timings.SSL)
+		timing.Connect = harDuration(timings.Connect) - timing.TLS
+	}
+	timingJSON, _ := json.Marshal(timing)
+	req.Timing = string(timingJSON)
+	return req, nil
+}
+
+// protocolOfHAR reads the httpVersion of a HAR response, which browsers
+// write in several ways, such as "http/2.0" or "h2".
+func protocolOfHAR(version string) string {
+	switch strings.ToLower(version) {
+	case "":
+		return ""
+	case "h2", "http/2", "http/2.0":
+		return "HTTP/2.0"
+	case "h3", "http/3", "http/3.0":
+		return "HTTP/3.0"
+	}
+	return strings.ToUpper(version)
+}
+
+// harDuration converts HAR milliseconds, -1 for none, to a duration.
+func harDuration(ms float64) time.Duration {
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// importChromeHAR imports testdata/chrome.har, exported from Chrome's
+// DevTools, into a new database.
+func importChromeHAR(t *testing.T) *DB {
+	t.Helper()
+	db := newTestDB(t)
+	file, err := os.Open(filepath.Join("testdata", "chrome.har"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	count, err := db.ImportHAR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Fatalf("imported %d entries, want 5", count)
+	}
+	return db
+}
+
+func TestImportHAR(t *testing.T) {
+	db := importChromeHAR(t)
+	history, err := db.GetRequestHistory(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Newest first
+	want := []RequestHistory{
+		{
+			Method: "DELETE", URL: "http://localhost:9999/jobs/3",
+			Headers:        `[{"key":"Accept","value":"*/*"}]`,
+			ResponseStatus: "Error", ResponseTimeMs: 3,
+		},
+		{
+			Method: "GET", URL: "https://legacy.example.com/motd",
+			Headers:         `[{"key":"Accept","value":"text/plain"}]`,
+			ResponseStatus:  "200 OK",
+			ResponseHeaders: `[{"Key":"Content-Type","Value":"text/plain; charset=iso-8859-1"},{"Key":"Content-Length","Value":"10"}]`,
+			ResponseBody:    "Caf\xe9 cr\xe8me", ResponseCharset: "iso-8859-1", ResponseSize: 10, ResponseTimeMs: 61,
+			Protocol: "HTTP/1.1", RemoteAddr: "203.0.113.9",
+			Timing: `{"dns":3000000,"connect":12000000,"tls":0,"send":200000,"wait":44800000,"transfer":500000,"total":61000000,"reused":false}`,
+			Sizes:  `{"body":10,"wire":10,"headers":95,"status_line":0,"total":105}`,
+		},
+		{
+			Method: "GET", URL: "https://cdn.example.com/logo.png",
+			Headers:         `[{"key":"accept","value":"image/avif,image/webp,*/*"}]`,
+			ResponseStatus:  "200 OK",
+			ResponseHeaders: `[{"Key":"content-type","Value":"image/png"},{"Key":"content-length","Value":"16"}]`,
+			ResponseBody:    "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", ResponseSize: 16, ResponseTimeMs: 21,
+			Protocol: "HTTP/3.0", RemoteAddr: "151.101.1.57",
+			Timing: `{"dns":0,"connect":0,"tls":0,"send":100000,"wait":18200000,"transfer":1600000,"total":20500000,"reused":true}`,
+		},
+		{
+			Method: "GET", URL: "https://api.example.com/users?page=2&q=a%20b",
+			Headers:         `[{"key":"accept","value":"application/json"},{"key":"authorization","value":"Bearer abc.def.ghi"},{"key":"cookie","value":"session=s1"}]`,
+			ResponseStatus:  "200 OK",
+			ResponseHeaders: `[{"Key":"content-encoding","Value":"gzip"},{"Key":"content-type","Value":"application/json; charset=utf-8"},{"Key":"date","Value":"Sat, 14 Mar 2026 09:26:53 GMT"}]`,
+			ResponseBody:    `{"users":[{"id":7,"name":"Ada"}],"p":2}`, ResponseSize: 38, ResponseTimeMs: 152,
+			Protocol: "HTTP/2.0", RemoteAddr: "93.184.215.14",
+			Timing: `{"dns":0,"connect":0,"tls":0,"send":250000,"wait":140130000,"transfer":10000000,"total":152480000,"reused":true}`,
+		},
+		{
+			Method: "POST", URL: "https://app.example.com/login",
+			Headers:         `[{"key":"Host","value":"app.example.com"},{"key":"Content-Type","value":"application/x-www-form-urlencoded"},{"key":"Content-Length","value":"29"}]`,
+			Body:            "user=ada&password=p%40ss+word",
+			ResponseStatus:  "302 Found",
+			ResponseHeaders: `[{"Key":"Location","Value":"/home"},{"Key":"Set-Cookie","Value":"session=s2; Path=/; HttpOnly; Secure"},{"Key":"Content-Length","Value":"0"}]`,
+			ResponseTimeMs:  89, Protocol: "HTTP/1.1", RemoteAddr: "[2606:2800:21f:cb07:6820:80da:af6b:8b2c]",
+			Timing: `{"dns":12500000,"connect":15500000,"tls":30100000,"send":300000,"wait":40200000,"transfer":900000,"total":88900000,"reused":false}`,
+			Sizes:  `{"body":0,"wire":0,"headers":121,"status_line":0,"total":121}`,
+		},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d history entries, want %d", len(history), len(want))
+	}
+	if started := history[3].Timestamp; !started.Equal(time.Date(2026, 3, 14, 9, 26, 53, 589000000, time.UTC)) {
+		t.Errorf("got the startedDateTime %v", started)
+	}
+	for i, got := range history {
+		w := want[i]
+		// Only the columns the import sets are compared
+		got.ID, got.Timestamp = 0, time.Time{}
+		if *got != w {
+			t.Errorf("entry %d:\ngot  %+v\nwant %+v", i, *got, w)
+		}
+	}
+}
+
+func TestImportHARErrors(t *testing.T) {
+	for _, har := range []string{
+		`not json`,
+		`{"log":{"entries":[{"startedDateTime":"yesterday"}]}}`,
+		`{"log":{"entries":[{"startedDateTime":"2026-03-14T09:26:53Z","response":{"status":200,"content":{"text":"%%%","encoding":"base64"}}}]}}`,
+	} {
+		db := newTestDB(t)
+		if _, err := db.ImportHAR(strings.NewReader(har)); err == nil {
+			t.Errorf("%s: expected an error", har)
+		}
+		if count, err := db.GetRequestHistoryCount(HistoryFilter{}); err != nil || count != 0 {
+			t.Errorf("%s: %d entries kept after a failed import (%v)", har, count, err)
+		}
+	}
+}
+
+// TestExportHistoryHAR exports the Chrome entries along with one recorded
+// here and compares the log with testdata/chrome.export.har.golden; run with
+// -update to rewrite it.
+func TestExportHistoryHAR(t *testing.T) {
+	// Times are written in the local time zone
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	db := importChromeHAR(t)
+	if err := db.SaveRequestHistory(&RequestHistory{
+		Method:          "POST",
+		URL:             "https://{{host}}/upload",
+		ResolvedURL:     "https://api.example.com/upload",
+		Headers:         `[{"key":"Authorization","value":"Bearer t"},{"key":"X-Off","value":"1","disabled":true}]`,
+		BodyType:        bodyTypeMultipart,
+		Body:            `[{"key":"title","value":"Report"},{"key":"file","value":"/tmp/r.pdf","file":true}]`,
+		Timestamp:       time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC),
+		ResponseStatus:  "201 Created",
+		ResponseHeaders: `[{"Key":"Content-Type","Value":"application/json"},{"Key":"Set-Cookie","Value":"id=9; Path=/; Expires=Sun, 15 Mar 2026 10:00:00 GMT"}]`,
+		ResponseBody:    `{"id":9}`,
+		ResponseSize:    8,
+		ResponseTimeMs:  120,
+		Protocol:        "HTTP/2.0",
+		RemoteAddr:      "93.184.215.14:443",
+		Timing:          `{"dns":2000000,"connect":10000000,"tls":20000000,"send":1000000,"wait":80000000,"transfer":7000000,"total":120000000,"reused":false}`,
+		Sizes:           `{"body":8,"wire":8,"headers":90,"status_line":20,"total":118}`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Neither has a HAR form
+	for _, kind := range []string{HistoryKindWebSocket, HistoryKindGRPC} {
+		if err := db.SaveRequestHistory(&RequestHistory{Method: "GET", URL: "wss://example.com", Kind: kind, Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := db.ExportHistoryHAR(&out); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Replace(out.String(), `"version":"`+harCreatorVersion()+`"`, `"version":"(devel)"`, 1)
+
+	golden := filepath.Join("testdata", "chrome.export.har.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s:\n--- got\n%s\n--- want\n%s", golden, got, want)
+	}
+
+	// What was exported imports again
+	if count, err := newTestDB(t).ImportHAR(strings.NewReader(got)); err != nil || count != 6 {
+		t.Errorf("imported %d entries of the export (%v), want 6", count, err)
+	}
+}
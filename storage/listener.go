@@ -0,0 +1,58 @@
+package storage
+
+import "time"
+
+// ListenerRequest is a request received by the webhook listener.
+type ListenerRequest struct {
+	ID         int       `json:"id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`              // Path and query string as sent
+	Headers    string    `json:"headers,omitempty"` // JSON of the request headers
+	Body       string    `json:"body,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+func (db *DB) SaveListenerRequest(req *ListenerRequest) error {
+	result, err := db.Exec(
+		`INSERT INTO listener_requests (method, path, headers, body, remote_addr, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+		req.Method, req.Path, req.Headers, req.Body, req.RemoteAddr, req.Timestamp,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		req.ID = int(id)
+	}
+	return err
+}
+
+// GetListenerRequests returns the latest limit received requests, oldest
+// first as they are shown in the log.
+func (db *DB) GetListenerRequests(limit int) ([]*ListenerRequest, error) {
+	rows, err := db.Query(`
+		SELECT id, method, path, headers, body, remote_addr, timestamp FROM (
+			SELECT * FROM listener_requests ORDER BY timestamp DESC, id DESC LIMIT ?
+		) ORDER BY timestamp, id`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*ListenerRequest
+	for rows.Next() {
+		var req ListenerRequest
+		if err := rows.Scan(&req.ID, &req.Method, &req.Path, &req.Headers, &req.Body, &req.RemoteAddr, &req.Timestamp); err != nil {
+			return nil, err
+		}
+		requests = append(requests, &req)
+	}
+	return requests, rows.Err()
+}
+
+func (db *DB) ClearListenerRequests() error {
+	_, err := db.Exec("DELETE FROM listener_requests")
+	return err
+}
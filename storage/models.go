@@ -2,9 +2,13 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,25 +31,73 @@ type RequestHistory struct {
 	Method          string    `json:"method"`
 	Headers         string    `json:"headers,omitempty"`
 	Body            string    `json:"body,omitempty"`
+	BodyType        string    `json:"body_type,omitempty"`
 	Timestamp       time.Time `json:"timestamp"`
 	ResponseStatus  string    `json:"response_status,omitempty"`
 	ResponseBody    string    `json:"response_body,omitempty"`
 	ResponseHeaders string    `json:"response_headers,omitempty"`
 	ResponseTimeMs  int       `json:"response_time_ms"`
 	ResponseSize    int       `json:"response_size"`
+	RedirectCount   int       `json:"redirect_count,omitempty"`
+	InsecureTLS     bool      `json:"insecure_tls,omitempty"`
+	Protocol        string    `json:"protocol,omitempty"`
+	Stats           string    `json:"stats,omitempty"`            // JSON summary of a repeated send
+	ResolvedURL     string    `json:"resolved_url,omitempty"`     // URL after {{variable}} substitution, if it differs
+	DynamicValues   string    `json:"dynamic_values,omitempty"`   // JSON of the {{uuid}} etc. values used
+	TestResults     string    `json:"test_results,omitempty"`     // JSON of the assertion results
+	Kind            string    `json:"kind,omitempty"`             // HistoryKindWebSocket or HistoryKindGRPC, or empty for an HTTP request
+	Transcript      string    `json:"transcript,omitempty"`       // JSON of the messages of a WebSocket session
+	Events          string    `json:"events,omitempty"`           // JSON of the events of a text/event-stream response
+	DownloadPath    string    `json:"download_path,omitempty"`    // File the response body was saved to instead of ResponseBody
+	Timing          string    `json:"timing,omitempty"`           // JSON of the DNS, connect, TLS, wait and transfer durations
+	RemoteAddr      string    `json:"remote_addr,omitempty"`      // IP address and port the final request was sent to
+	UnixSocket      string    `json:"unix_socket,omitempty"`      // Unix socket the request was sent over, set in Options
+	Source          string    `json:"source,omitempty"`           // HistorySourceMonitor for a monitor run, or empty when sent from the editors
+	ComparisonID    string    `json:"comparison_id,omitempty"`    // Shared by the two sends of an environment comparison
+	Params          string    `json:"params,omitempty"`           // JSON of the query parameter rows, when some are disabled or described
+	PathVariables   string    `json:"path_variables,omitempty"`   // JSON of the values of the :name path segments of URL
+	Partial         bool      `json:"partial,omitempty"`          // The body was cut short by cancelling; ResponseBody is what arrived
+	TLS             string    `json:"tls,omitempty"`              // JSON of the TLS version, cipher suite and certificate chain
+	ResponseCharset string    `json:"response_charset,omitempty"` // Charset ResponseBody is in, when it is not UTF-8; ResponseBody keeps the bytes as received
+	Sizes           string    `json:"sizes,omitempty"`            // JSON of the status line, header, body and wire sizes and the throughput
 	IsFavorite      bool      `json:"is_favorite"`
 	CollectionID    *int      `json:"collection_id,omitempty"`
 }
 
+// History entries record an HTTP request unless their kind says otherwise.
+const (
+	HistoryKindWebSocket = "websocket"
+	HistoryKindGRPC      = "grpc"
+)
+
+// HistorySourceMonitor marks entries recorded by a monitor's scheduled run.
+const HistorySourceMonitor = "monitor"
+
 type SavedRequest struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	URL          string    `json:"url"`
-	Method       string    `json:"method"`
-	Headers      string    `json:"headers,omitempty"`
-	Body         string    `json:"body,omitempty"`
-	CollectionID *int      `json:"collection_id,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	Method         string    `json:"method"`
+	Headers        string    `json:"headers,omitempty"`
+	Body           string    `json:"body,omitempty"`
+	BodyType       string    `json:"body_type,omitempty"`
+	BodySource     string    `json:"body_source,omitempty"` // Where a raw body comes from: inline in Body, or read from BodyFile on every send
+	BodyFile       string    `json:"body_file,omitempty"`
+	Auth           string    `json:"auth,omitempty"`
+	Script         string    `json:"script,omitempty"`          // Pre-request script (JavaScript)
+	Tests          string    `json:"tests,omitempty"`           // JSON of the assertions checked after a send
+	Extractors     string    `json:"extractors,omitempty"`      // JSON of the response values copied into variables
+	Monitor        string    `json:"monitor,omitempty"`         // JSON of the schedule the request is run on, if it is monitored
+	Notes          string    `json:"notes,omitempty"`           // What to know when sending the request, shown when it is opened
+	Params         string    `json:"params,omitempty"`          // JSON of the query parameter rows, when some are disabled or described
+	PathVariables  string    `json:"path_variables,omitempty"`  // JSON of the values of the :name path segments of URL
+	ResponseFilter string    `json:"response_filter,omitempty"` // JSON path the response body is filtered by after a send
+	CollectionID   *int      `json:"collection_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
 func (db *DB) GetPreference(key string) (*Preference, error) {
@@ -91,18 +143,108 @@ func (db *DB) GetAllPreferences() (map[string]string, error) {
 	return prefs, rows.Err()
 }
 
-func (db *DB) SaveRequestHistory(req *RequestHistory) error {
-	result, err := db.Exec(
-		`INSERT INTO request_history (
-			url, method, headers, body, timestamp,
-			response_status, response_body, response_headers,
-			response_time_ms, response_size, is_favorite, collection_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		req.URL, req.Method, req.Headers, req.Body, req.Timestamp,
+const requestHistoryColumns = `id, url, method, headers, body, body_type, timestamp,
+	response_status, response_body, response_headers,
+	response_time_ms, response_size, redirect_count, insecure_tls, protocol, stats, resolved_url, dynamic_values, test_results, kind, transcript, events, download_path, timing, remote_addr, unix_socket, source, comparison_id, params, path_variables, partial, tls, response_charset, sizes, is_favorite, collection_id`
+
+// requestHistorySummaryColumns are requestHistoryColumns with the bulky
+// ones, which a history row does not show, left empty, and the transcript
+// cut down to the type of each message; Summary does the same to an entry
+// in memory.
+const requestHistorySummaryColumns = `id, url, method, '' AS headers, '' AS body, body_type, timestamp,
+	response_status, '' AS response_body, '' AS response_headers,
+	response_time_ms, response_size, redirect_count, insecure_tls, protocol, stats, resolved_url, '' AS dynamic_values, test_results, kind,
+	CASE WHEN json_valid(transcript) THEN (SELECT json_group_array(json_object('type', json_extract(value, '$.type'))) FROM json_each(transcript)) ELSE '' END AS transcript,
+	'' AS events, download_path, '' AS timing, remote_addr, unix_socket, source, comparison_id, '' AS params, '' AS path_variables, partial, '' AS tls, response_charset, '' AS sizes, is_favorite, collection_id`
+
+// Summary returns a copy of req without the fields a history row does not
+// show, as listed by GetRequestHistorySummaries.
+func (req *RequestHistory) Summary() *RequestHistory {
+	summary := *req
+	summary.Headers = ""
+	summary.Body = ""
+	summary.ResponseBody = ""
+	summary.ResponseHeaders = ""
+	summary.DynamicValues = ""
+	summary.Events = ""
+	summary.Timing = ""
+	summary.Params = ""
+	summary.PathVariables = ""
+	summary.TLS = ""
+	summary.Sizes = ""
+	summary.Transcript = summarizeTranscript(req.Transcript)
+	return &summary
+}
+
+// summarizeTranscript keeps only the type of each message of a transcript,
+// which is all a history row counts.
+func summarizeTranscript(transcript string) string {
+	var messages []struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal([]byte(transcript), &messages) != nil {
+		return ""
+	}
+	summary, _ := json.Marshal(messages)
+	return string(summary)
+}
+
+const insertRequestHistoryQuery = `INSERT INTO request_history (
+	url, method, headers, body, body_type, timestamp,
+	response_status, response_body, response_headers,
+	response_time_ms, response_size, redirect_count, insecure_tls, protocol, stats, resolved_url, dynamic_values, test_results, kind, transcript, events, download_path, timing, remote_addr, unix_socket, source, comparison_id, params, path_variables, partial, tls, response_charset, sizes, is_favorite, collection_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func requestHistoryArgs(req *RequestHistory) []interface{} {
+	return []interface{}{
+		req.URL, req.Method, req.Headers, req.Body, req.BodyType, req.Timestamp,
 		req.ResponseStatus, req.ResponseBody, req.ResponseHeaders,
-		req.ResponseTimeMs, req.ResponseSize, req.IsFavorite, req.CollectionID,
+		req.ResponseTimeMs, req.ResponseSize, req.RedirectCount, req.InsecureTLS, req.Protocol, req.Stats, req.ResolvedURL, req.DynamicValues, req.TestResults, req.Kind, req.Transcript, req.Events, req.DownloadPath, req.Timing, req.RemoteAddr, req.UnixSocket, req.Source, req.ComparisonID, req.Params, req.PathVariables, req.Partial, req.TLS, req.ResponseCharset, req.Sizes, req.IsFavorite, req.CollectionID,
+	}
+}
+
+func scanRequestHistory(row rowScanner) (*RequestHistory, error) {
+	var req RequestHistory
+	var collectionID sql.NullInt64
+
+	err := row.Scan(
+		&req.ID, &req.URL, &req.Method, &req.Headers, &req.Body, &req.BodyType, &req.Timestamp,
+		&req.ResponseStatus, &req.ResponseBody, &req.ResponseHeaders,
+		&req.ResponseTimeMs, &req.ResponseSize, &req.RedirectCount, &req.InsecureTLS, &req.Protocol, &req.Stats, &req.ResolvedURL, &req.DynamicValues, &req.TestResults, &req.Kind, &req.Transcript, &req.Events, &req.DownloadPath, &req.Timing, &req.RemoteAddr, &req.UnixSocket, &req.Source, &req.ComparisonID, &req.Params, &req.PathVariables, &req.Partial, &req.TLS, &req.ResponseCharset, &req.Sizes, &req.IsFavorite, &collectionID,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if collectionID.Valid {
+		id := int(collectionID.Int64)
+		req.CollectionID = &id
+	}
+
+	return &req, nil
+}
+
+func (db *DB) queryRequestHistory(query string, args ...interface{}) ([]*RequestHistory, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*RequestHistory
+	for rows.Next() {
+		req, err := scanRequestHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, req)
+	}
+
+	return history, rows.Err()
+}
 
+func (db *DB) SaveRequestHistory(req *RequestHistory) error {
+	result, err := db.Exec(insertRequestHistoryQuery, requestHistoryArgs(req)...)
 	if err != nil {
 		return err
 	}
@@ -116,86 +258,257 @@ func (db *DB) SaveRequestHistory(req *RequestHistory) error {
 
 func (db *DB) GetRequestHistory(limit int, offset int) ([]*RequestHistory, error) {
 	query := `
-		SELECT id, url, method, headers, body, timestamp,
-			   response_status, response_body, response_headers,
-			   response_time_ms, response_size, is_favorite, collection_id
+		SELECT ` + requestHistoryColumns + `
 		FROM request_history
 		ORDER BY timestamp DESC
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := db.Query(query, limit, offset)
+	return db.queryRequestHistory(query, limit, offset)
+}
+
+func (db *DB) SearchRequestHistory(searchTerm string, limit int) ([]*RequestHistory, error) {
+	query := `
+		SELECT ` + requestHistoryColumns + `
+		FROM request_history
+		WHERE url LIKE ? OR method LIKE ? OR response_status LIKE ?
+		ORDER BY timestamp DESC
+		LIMIT ?
+	`
+
+	searchPattern := "%" + searchTerm + "%"
+	return db.queryRequestHistory(query, searchPattern, searchPattern, searchPattern, limit)
+}
+
+// The status classes a HistoryFilter can select; HistoryStatusError is a
+// request that got no response.
+const (
+	HistoryStatus2xx   = "2xx"
+	HistoryStatus3xx   = "3xx"
+	HistoryStatus4xx   = "4xx"
+	HistoryStatus5xx   = "5xx"
+	HistoryStatusError = "Error"
+)
+
+// HistoryFilter narrows down the entries GetRequestHistorySummaries
+// returns; the zero value matches every entry.
+type HistoryFilter struct {
+	// Search matches the URL, method or status
+	Search string
+	// Method matches the method exactly
+	Method string
+	// StatusClass is one of the HistoryStatus constants
+	StatusClass   string
+	FavoritesOnly bool
+	// From and To bound the time of an entry, From included and To not; a
+	// zero time leaves that end open
+	From time.Time
+	To   time.Time
+}
+
+// GetRequestHistorySummaries returns a page of the history entries matching
+// every condition of filter, newest first, without the bodies and other
+// fields a history row does not show; GetRequestHistoryByID returns the
+// whole of one when it is opened.
+func (db *DB) GetRequestHistorySummaries(filter HistoryFilter, limit, offset int) ([]*RequestHistory, error) {
+	where, args, err := filter.where()
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + requestHistorySummaryColumns + `
+		FROM request_history` + where + `
+		ORDER BY timestamp DESC
+		LIMIT ? OFFSET ?
+	`
+	return db.queryRequestHistory(query, append(args, limit, offset)...)
+}
+
+// GetRequestHistoryCount returns the number of history entries matching
+// filter.
+func (db *DB) GetRequestHistoryCount(filter HistoryFilter) (int, error) {
+	where, args, err := filter.where()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM request_history"+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetRequestHistoryByID returns the history entry with id, nil if there is
+// none.
+func (db *DB) GetRequestHistoryByID(id int) (*RequestHistory, error) {
+	req, err := scanRequestHistory(db.QueryRow(
+		`SELECT `+requestHistoryColumns+` FROM request_history WHERE id = ?`, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return req, err
+}
+
+// where builds the WHERE clause of the conditions of filter, "" for none.
+func (filter HistoryFilter) where() (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		conditions = append(conditions, "(url LIKE ? OR method LIKE ? OR response_status LIKE ?)")
+		args = append(args, searchPattern, searchPattern, searchPattern)
+	}
+	if filter.Method != "" {
+		conditions = append(conditions, "method = ?")
+		args = append(args, filter.Method)
+	}
+	switch filter.StatusClass {
+	case "":
+	case HistoryStatus2xx, HistoryStatus3xx, HistoryStatus4xx, HistoryStatus5xx:
+		conditions = append(conditions, "response_status LIKE ?")
+		args = append(args, filter.StatusClass[:1]+"__ %")
+	case HistoryStatusError:
+		conditions = append(conditions, "response_status = ?")
+		args = append(args, HistoryStatusError)
+	default:
+		return "", nil, fmt.Errorf("unknown status class %q", filter.StatusClass)
+	}
+	if filter.FavoritesOnly {
+		conditions = append(conditions, "is_favorite = 1")
+	}
+	// Timestamps are stored as text in local time, so the bounds are too,
+	// which keeps the comparison on the timestamp index
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.From.Local().Round(0))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.To.Local().Round(0))
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return `
+		WHERE ` + strings.Join(conditions, " AND "), args, nil
+}
+
+// GetHistoryMethods returns the methods used in the history, in order.
+func (db *DB) GetHistoryMethods() ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT method FROM request_history ORDER BY method")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []*RequestHistory
+	var methods []string
 	for rows.Next() {
-		var req RequestHistory
-		var collectionID sql.NullInt64
-
-		err := rows.Scan(
-			&req.ID, &req.URL, &req.Method, &req.Headers, &req.Body, &req.Timestamp,
-			&req.ResponseStatus, &req.ResponseBody, &req.ResponseHeaders,
-			&req.ResponseTimeMs, &req.ResponseSize, &req.IsFavorite, &collectionID,
-		)
-		if err != nil {
+		var method string
+		if err := rows.Scan(&method); err != nil {
 			return nil, err
 		}
-
-		if collectionID.Valid {
-			id := int(collectionID.Int64)
-			req.CollectionID = &id
-		}
-
-		history = append(history, &req)
+		methods = append(methods, method)
 	}
+	return methods, rows.Err()
+}
 
-	return history, rows.Err()
+// UpdateRequestHistoryFavorite stars or unstars a history entry.
+func (db *DB) UpdateRequestHistoryFavorite(id int, favorite bool) error {
+	_, err := db.Exec("UPDATE request_history SET is_favorite = ? WHERE id = ?", favorite, id)
+	return err
 }
 
-func (db *DB) SearchRequestHistory(searchTerm string, limit int) ([]*RequestHistory, error) {
+// URLSuggestion is a URL from the history with the method last used for it.
+type URLSuggestion struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+}
+
+// GetDistinctURLs returns HTTP history URLs containing text, each once. URLs
+// that start with text come first, then the most recently used.
+func (db *DB) GetDistinctURLs(text string, limit int) ([]*URLSuggestion, error) {
+	// The method is the one from the row with the latest timestamp
 	query := `
-		SELECT id, url, method, headers, body, timestamp,
-			   response_status, response_body, response_headers,
-			   response_time_ms, response_size, is_favorite, collection_id
+		SELECT url, method, MAX(timestamp) AS last_used
 		FROM request_history
-		WHERE url LIKE ? OR method LIKE ? OR response_status LIKE ?
-		ORDER BY timestamp DESC
+		WHERE kind = '' AND url LIKE ? ESCAPE '\'
+		GROUP BY url
+		ORDER BY url LIKE ? ESCAPE '\' DESC, last_used DESC
 		LIMIT ?
 	`
 
-	searchPattern := "%" + searchTerm + "%"
-	rows, err := db.Query(query, searchPattern, searchPattern, searchPattern, limit)
+	escaped := escapeLike(text)
+	rows, err := db.Query(query, "%"+escaped+"%", escaped+"%", limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []*RequestHistory
+	var suggestions []*URLSuggestion
 	for rows.Next() {
-		var req RequestHistory
-		var collectionID sql.NullInt64
-
-		err := rows.Scan(
-			&req.ID, &req.URL, &req.Method, &req.Headers, &req.Body, &req.Timestamp,
-			&req.ResponseStatus, &req.ResponseBody, &req.ResponseHeaders,
-			&req.ResponseTimeMs, &req.ResponseSize, &req.IsFavorite, &collectionID,
-		)
-		if err != nil {
+		var suggestion URLSuggestion
+		var lastUsed interface{}
+		if err := rows.Scan(&suggestion.URL, &suggestion.Method, &lastUsed); err != nil {
 			return nil, err
 		}
+		suggestions = append(suggestions, &suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// GetHeaderNames returns the names of the request headers sent over HTTP
+// and WebSocket, each once, most used first.
+func (db *DB) GetHeaderNames(limit int) ([]string, error) {
+	query := `
+		SELECT json_extract(header.value, '$.key') AS name
+		FROM request_history,
+			json_each(CASE WHEN json_valid(request_history.headers) THEN request_history.headers ELSE '[]' END) AS header
+		WHERE kind IN ('', ?)
+		GROUP BY name
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+	`
 
-		if collectionID.Valid {
-			id := int(collectionID.Int64)
-			req.CollectionID = &id
+	rows, err := db.Query(query, HistoryKindWebSocket, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name.String != "" {
+			names = append(names, name.String)
 		}
+	}
+	return names, rows.Err()
+}
 
-		history = append(history, &req)
+// GetLatestResponse returns the latest HTTP history entry for method and url
+// that got a response, or nil if there is none.
+func (db *DB) GetLatestResponse(url, method string) (*RequestHistory, error) {
+	req, err := scanRequestHistory(db.QueryRow(
+		`SELECT `+requestHistoryColumns+`
+		 FROM request_history
+		 WHERE kind = '' AND url = ? AND method = ? AND response_status NOT IN ('', 'Error')
+		 ORDER BY timestamp DESC
+		 LIMIT 1`,
+		url, method,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return req, err
+}
 
-	return history, rows.Err()
+// escapeLike escapes the LIKE wildcards in text for use with ESCAPE '\'.
+func escapeLike(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(text)
 }
 
 func (db *DB) DeleteRequestHistory(id int) error {
@@ -203,8 +516,127 @@ func (db *DB) DeleteRequestHistory(id int) error {
 	return err
 }
 
-func (db *DB) ClearRequestHistory() error {
-	_, err := db.Exec("DELETE FROM request_history")
+// DeleteRequestHistoryEntries deletes the history entries with ids at once.
+func (db *DB) DeleteRequestHistoryEntries(ids []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM request_history WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// restoreRequestHistoryQuery inserts a history entry with its own ID.
+var restoreRequestHistoryQuery = "INSERT INTO request_history (" + requestHistoryColumns + ") VALUES (?" +
+	strings.Repeat(", ?", strings.Count(requestHistoryColumns, ",")) + ")"
+
+// RestoreRequestHistory puts deleted history entries back as they were,
+// IDs included.
+func (db *DB) RestoreRequestHistory(history []*RequestHistory) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, req := range history {
+		args := append([]interface{}{req.ID}, requestHistoryArgs(req)...)
+		if _, err := tx.Exec(restoreRequestHistoryQuery, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneResult tells what PruneRequestHistory deleted: the IDs of the
+// entries and about how many bytes of data they held.
+type PruneResult struct {
+	IDs   []int
+	Bytes int64
+}
+
+// requestHistoryBytes adds up the sizes of the columns of an entry that
+// can grow large.
+const requestHistoryBytes = `length(CAST(url AS BLOB))` +
+	` + COALESCE(length(CAST(headers AS BLOB)), 0) + COALESCE(length(CAST(body AS BLOB)), 0)` +
+	` + COALESCE(length(CAST(response_body AS BLOB)), 0) + COALESCE(length(CAST(response_headers AS BLOB)), 0)` +
+	` + length(CAST(stats AS BLOB)) + length(CAST(dynamic_values AS BLOB)) + length(CAST(test_results AS BLOB))` +
+	` + length(CAST(transcript AS BLOB)) + length(CAST(events AS BLOB)) + length(CAST(timing AS BLOB))` +
+	` + length(CAST(params AS BLOB)) + length(CAST(path_variables AS BLOB)) + length(CAST(tls AS BLOB))` +
+	` + length(CAST(sizes AS BLOB))`
+
+// PruneRequestHistory deletes the entries beyond the newest maxEntries and
+// those older than maxDays days; 0 leaves either unlimited. Starred entries
+// are never deleted, nor counted against maxEntries. The space the entries
+// took is reused by later ones rather than returned.
+func (db *DB) PruneRequestHistory(maxEntries, maxDays int) (*PruneResult, error) {
+	result := &PruneResult{}
+	var conditions []string
+	var args []interface{}
+	if maxDays > 0 {
+		// Timestamps are stored as text in local time, as in HistoryFilter
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, time.Now().AddDate(0, 0, -maxDays).Local().Round(0))
+	}
+	if maxEntries > 0 {
+		conditions = append(conditions, `id NOT IN (
+			SELECT id FROM request_history WHERE is_favorite = 0 ORDER BY timestamp DESC LIMIT ?
+		)`)
+		args = append(args, maxEntries)
+	}
+	if len(conditions) == 0 {
+		return result, nil
+	}
+	where := " WHERE is_favorite = 0 AND (" + strings.Join(conditions, " OR ") + ")"
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, "+requestHistoryBytes+" FROM request_history"+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		var size int64
+		if err := rows.Scan(&id, &size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.IDs = append(result.IDs, id)
+		result.Bytes += size
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.IDs) == 0 {
+		return result, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM request_history"+where, args...); err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// ClearRequestHistory deletes the history, keeping the starred entries
+// unless includeFavorites is set.
+func (db *DB) ClearRequestHistory(includeFavorites bool) error {
+	query := "DELETE FROM request_history WHERE is_favorite = 0"
+	if includeFavorites {
+		query = "DELETE FROM request_history"
+	}
+	_, err := db.Exec(query)
 	return err
 }
 
@@ -250,17 +682,37 @@ func (db *DB) GetCollections() ([]*Collection, error) {
 }
 
 func (db *DB) DeleteCollection(id int) error {
-	_, err := db.Exec("DELETE FROM collections WHERE id = ?", id)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// SQLite only enforces ON DELETE CASCADE when foreign keys are enabled on
+	// the connection, so remove the collection's requests explicitly.
+	if _, err := tx.Exec("DELETE FROM saved_requests WHERE collection_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collections WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const insertSavedRequestQuery = `INSERT INTO saved_requests (
+	name, url, method, headers, body, body_type, body_source, body_file, auth, script, tests, extractors, notes, params, path_variables, response_filter, collection_id, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+
+func savedRequestArgs(req *SavedRequest) []interface{} {
+	return []interface{}{
+		req.Name, req.URL, req.Method, req.Headers, req.Body, req.BodyType, req.BodySource, req.BodyFile, req.Auth, req.Script, req.Tests, req.Extractors,
+		req.Notes, req.Params, req.PathVariables, req.ResponseFilter, req.CollectionID,
+	}
 }
 
 func (db *DB) SaveRequest(req *SavedRequest) error {
-	result, err := db.Exec(
-		`INSERT INTO saved_requests (
-			name, url, method, headers, body, collection_id, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
-		req.Name, req.URL, req.Method, req.Headers, req.Body, req.CollectionID,
-	)
+	result, err := db.Exec(insertSavedRequestQuery, savedRequestArgs(req)...)
 
 	if err != nil {
 		return err
@@ -273,19 +725,92 @@ func (db *DB) SaveRequest(req *SavedRequest) error {
 	return err
 }
 
+// ImportCollection creates collection with requests in it, all or nothing,
+// setting their IDs.
+func (db *DB) ImportCollection(collection *Collection, requests []*SavedRequest) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO collections (name, description, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)",
+		collection.Name, collection.Description,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	collectionID := int(id)
+
+	for _, req := range requests {
+		req.CollectionID = &collectionID
+		result, err := tx.Exec(insertSavedRequestQuery, savedRequestArgs(req)...)
+		if err != nil {
+			return err
+		}
+		if id, err := result.LastInsertId(); err == nil {
+			req.ID = int(id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	collection.ID = collectionID
+	collection.CreatedAt = time.Now()
+	return nil
+}
+
+const updateSavedRequestQuery = `UPDATE saved_requests SET
+	name = ?, url = ?, method = ?, headers = ?, body = ?, body_type = ?, body_source = ?, body_file = ?, auth = ?,
+	script = ?, tests = ?, extractors = ?, notes = ?, params = ?, path_variables = ?, response_filter = ?, collection_id = ?
+ WHERE id = ?`
+
+func (db *DB) UpdateSavedRequest(req *SavedRequest) error {
+	_, err := db.Exec(updateSavedRequestQuery, append(savedRequestArgs(req), req.ID)...)
+	return err
+}
+
+const savedRequestColumns = `id, name, url, method, headers, body, body_type, body_source, body_file, auth, script, tests, extractors, monitor, notes, params, path_variables, response_filter, collection_id, created_at`
+
+func scanSavedRequest(row rowScanner) (*SavedRequest, error) {
+	var req SavedRequest
+	var collectionID sql.NullInt64
+
+	err := row.Scan(
+		&req.ID, &req.Name, &req.URL, &req.Method,
+		&req.Headers, &req.Body, &req.BodyType, &req.BodySource, &req.BodyFile, &req.Auth, &req.Script, &req.Tests, &req.Extractors, &req.Monitor, &req.Notes, &req.Params, &req.PathVariables, &req.ResponseFilter, &collectionID, &req.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if collectionID.Valid {
+		id := int(collectionID.Int64)
+		req.CollectionID = &id
+	}
+
+	return &req, nil
+}
+
 func (db *DB) GetSavedRequests(collectionID *int) ([]*SavedRequest, error) {
 	var rows *sql.Rows
 	var err error
 
 	if collectionID != nil {
 		rows, err = db.Query(
-			`SELECT id, name, url, method, headers, body, collection_id, created_at
+			`SELECT `+savedRequestColumns+`
 			 FROM saved_requests WHERE collection_id = ? ORDER BY name`,
 			*collectionID,
 		)
 	} else {
 		rows, err = db.Query(
-			`SELECT id, name, url, method, headers, body, collection_id, created_at
+			`SELECT ` + savedRequestColumns + `
 			 FROM saved_requests WHERE collection_id IS NULL ORDER BY name`,
 		)
 	}
@@ -297,40 +822,22 @@ func (db *DB) GetSavedRequests(collectionID *int) ([]*SavedRequest, error) {
 
 	var requests []*SavedRequest
 	for rows.Next() {
-		var req SavedRequest
-		var collID sql.NullInt64
-
-		err := rows.Scan(
-			&req.ID, &req.Name, &req.URL, &req.Method,
-			&req.Headers, &req.Body, &collID, &req.CreatedAt,
-		)
+		req, err := scanSavedRequest(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if collID.Valid {
-			id := int(collID.Int64)
-			req.CollectionID = &id
-		}
-
-		requests = append(requests, &req)
+		requests = append(requests, req)
 	}
 
 	return requests, rows.Err()
 }
 
 func (db *DB) GetSavedRequest(id int) (*SavedRequest, error) {
-	var req SavedRequest
-	var collectionID sql.NullInt64
-
-	err := db.QueryRow(
-		`SELECT id, name, url, method, headers, body, collection_id, created_at
+	req, err := scanSavedRequest(db.QueryRow(
+		`SELECT `+savedRequestColumns+`
 		 FROM saved_requests WHERE id = ?`,
 		id,
-	).Scan(
-		&req.ID, &req.Name, &req.URL, &req.Method,
-		&req.Headers, &req.Body, &collectionID, &req.CreatedAt,
-	)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("saved request not found")
@@ -339,12 +846,37 @@ func (db *DB) GetSavedRequest(id int) (*SavedRequest, error) {
 		return nil, err
 	}
 
-	if collectionID.Valid {
-		id := int(collectionID.Int64)
-		req.CollectionID = &id
+	return req, nil
+}
+
+// SetSavedRequestMonitor stores the schedule of a monitored request, or ""
+// to stop monitoring it. Saving the request from the editors keeps it.
+func (db *DB) SetSavedRequestMonitor(id int, monitor string) error {
+	_, err := db.Exec("UPDATE saved_requests SET monitor = ? WHERE id = ?", monitor, id)
+	return err
+}
+
+// GetMonitoredRequests returns the saved requests that have a monitor.
+func (db *DB) GetMonitoredRequests() ([]*SavedRequest, error) {
+	rows, err := db.Query(
+		`SELECT ` + savedRequestColumns + `
+		 FROM saved_requests WHERE monitor != '' ORDER BY name`,
+	)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return &req, nil
+	var requests []*SavedRequest
+	for rows.Next() {
+		req, err := scanSavedRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+
+	return requests, rows.Err()
 }
 
 func (db *DB) DeleteSavedRequest(id int) error {
@@ -366,40 +898,78 @@ func (db *DB) ExportHistory(filepath string) error {
 	return writeFile(filepath, data)
 }
 
-func (db *DB) ImportHistory(filepath string) error {
-	data, err := readFile(filepath)
+// ExportHistoryCSV writes the whole history to w as CSV, newest first, one
+// row per entry as it is read, with the request and response bodies when
+// includeBodies is set.
+func (db *DB) ExportHistoryCSV(w io.Writer, includeBodies bool) error {
+	columns := requestHistorySummaryColumns
+	header := []string{"timestamp", "method", "url", "status", "response_time_ms", "response_size", "is_favorite"}
+	if includeBodies {
+		columns = requestHistoryColumns
+		header = append(header, "request_body", "response_body")
+	}
+	rows, err := db.Query(`SELECT ` + columns + ` FROM request_history ORDER BY timestamp DESC`)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	for rows.Next() {
+		req, err := scanRequestHistory(rows)
+		if err != nil {
+			return err
+		}
+		record := []string{
+			req.Timestamp.Format(time.RFC3339),
+			req.Method,
+			req.URL,
+			req.ResponseStatus,
+			strconv.Itoa(req.ResponseTimeMs),
+			strconv.Itoa(req.ResponseSize),
+			strconv.FormatBool(req.IsFavorite),
+		}
+		if includeBodies {
+			record = append(record, req.Body, req.ResponseBody)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportHistory adds the entries of a JSON export to the history and returns
+// how many there were.
+func (db *DB) ImportHistory(filepath string) (int, error) {
+	data, err := readFile(filepath)
+	if err != nil {
+		return 0, err
+	}
 
 	var history []*RequestHistory
 	if err := json.Unmarshal(data, &history); err != nil {
-		return err
+		return 0, err
 	}
 
 	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer tx.Rollback()
 
 	for _, req := range history {
-		_, err := tx.Exec(
-			`INSERT INTO request_history (
-				url, method, headers, body, timestamp,
-				response_status, response_body, response_headers,
-				response_time_ms, response_size, is_favorite, collection_id
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			req.URL, req.Method, req.Headers, req.Body, req.Timestamp,
-			req.ResponseStatus, req.ResponseBody, req.ResponseHeaders,
-			req.ResponseTimeMs, req.ResponseSize, req.IsFavorite, req.CollectionID,
-		)
-		if err != nil {
-			return err
+		if _, err := tx.Exec(insertRequestHistoryQuery, requestHistoryArgs(req)...); err != nil {
+			return 0, err
 		}
 	}
 
-	return tx.Commit()
+	return len(history), tx.Commit()
 }
 
 func writeFile(filepath string, data []byte) error {
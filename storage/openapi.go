@@ -0,0 +1,862 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec is an OpenAPI 3.x or Swagger 2.0 document, as far as it
+// describes the requests to send. Swagger 2.0 keeps its definitions and
+// shared parameters at the top level, OpenAPI 3 under components.
+type openAPISpec struct {
+	OpenAPI openAPIVersion `json:"openapi"`
+	Swagger openAPIVersion `json:"swagger"`
+	Info    struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Servers  []openAPIServer                       `json:"servers"`
+	Paths    map[string]map[string]json.RawMessage `json:"paths"`
+	Webhooks map[string]map[string]json.RawMessage `json:"webhooks"`
+
+	Components struct {
+		Schemas       map[string]*openAPISchema      `json:"schemas"`
+		Parameters    map[string]*openAPIParameter   `json:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+	} `json:"components"`
+
+	Host        string                       `json:"host"`
+	BasePath    string                       `json:"basePath"`
+	Schemes     []string                     `json:"schemes"`
+	Consumes    []string                     `json:"consumes"`
+	Definitions map[string]*openAPISchema    `json:"definitions"`
+	Parameters  map[string]*openAPIParameter `json:"parameters"`
+}
+
+// openAPIVersion is a version number, which YAML reads as a number when it
+// is not quoted, such as swagger: 2.0.
+type openAPIVersion string
+
+func (v *openAPIVersion) UnmarshalJSON(data []byte) error {
+	var version any
+	if err := json.Unmarshal(data, &version); err != nil {
+		return err
+	}
+	switch version := version.(type) {
+	case string:
+		*v = openAPIVersion(version)
+	case float64:
+		*v = openAPIVersion(strconv.FormatFloat(version, 'f', 1, 64))
+	}
+	return nil
+}
+
+type openAPIServer struct {
+	URL       string `json:"url"`
+	Variables map[string]struct {
+		Default string `json:"default"`
+	} `json:"variables"`
+}
+
+type openAPIOperation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description"`
+	Deprecated  bool                `json:"deprecated"`
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+	Servers     []openAPIServer     `json:"servers"`
+	Consumes    []string            `json:"consumes"`
+}
+
+type openAPIParameter struct {
+	Ref         string                    `json:"$ref"`
+	Name        string                    `json:"name"`
+	In          string                    `json:"in"`
+	Description string                    `json:"description"`
+	Required    bool                      `json:"required"`
+	Schema      *openAPISchema            `json:"schema"`
+	Example     any                       `json:"example"`
+	Examples    map[string]openAPIExample `json:"examples"`
+
+	// Swagger 2.0 describes parameters other than the body inline
+	Type    string `json:"type"`
+	Default any    `json:"default"`
+	Enum    []any  `json:"enum"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                      `json:"$ref"`
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema   *openAPISchema            `json:"schema"`
+	Example  any                       `json:"example"`
+	Examples map[string]openAPIExample `json:"examples"`
+}
+
+type openAPIExample struct {
+	Value any `json:"value"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       any                       `json:"type"` // A list of types in OpenAPI 3.1
+	Format     string                    `json:"format"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Required   []string                  `json:"required"`
+	AllOf      []*openAPISchema          `json:"allOf"`
+	OneOf      []*openAPISchema          `json:"oneOf"`
+	AnyOf      []*openAPISchema          `json:"anyOf"`
+	Example    any                       `json:"example"`
+	Examples   []any                     `json:"examples"`
+	Default    any                       `json:"default"`
+	Enum       []any                     `json:"enum"`
+	Const      any                       `json:"const"`
+}
+
+// openAPIMethods are the operations a path item can have, in the order the
+// requests of a path are listed.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// openAPISampleDepth is how deeply nested objects in a body are filled in.
+const openAPISampleDepth = 8
+
+// openAPIPathVariablePattern matches a path segment that is a template
+// expression, which the editor takes as a path variable.
+var openAPIPathVariablePattern = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_-]*)\}$`)
+
+// errExternalRef is returned for a $ref to another document, which an
+// imported file cannot follow.
+var errExternalRef = errors.New("refers to another document")
+
+// OpenAPIImport is the collection made from an OpenAPI or Swagger document.
+type OpenAPIImport struct {
+	Name        string
+	Description string
+	Requests    []*SavedRequest
+	// Skipped lists the operations that were left out and why
+	Skipped []string
+}
+
+// ParseOpenAPI makes a saved request of each operation of an OpenAPI 3.x or
+// Swagger 2.0 document, in JSON or YAML. Each request has the method, the
+// path joined to the first server URL, or to {{baseUrl}} when there is none,
+// the required headers, the query parameters as param rows, optional ones
+// disabled, the path parameters as path variables, and a body made from the
+// example of the request schema or from its required fields.
+func ParseOpenAPI(data []byte) (*OpenAPIImport, error) {
+	var spec openAPISpec
+	if err := decodeOpenAPI(data, &spec); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasPrefix(string(spec.OpenAPI), "3."):
+	case spec.Swagger == "2.0":
+	case spec.OpenAPI != "" || spec.Swagger != "":
+		return nil, fmt.Errorf("OpenAPI version %s%s is not supported, only 3.x and Swagger 2.0", spec.OpenAPI, spec.Swagger)
+	default:
+		return nil, errors.New("not an OpenAPI or Swagger document")
+	}
+
+	result := &OpenAPIImport{Name: spec.Info.Title, Description: strings.TrimSpace(spec.Info.Description)}
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := spec.Paths[path]
+		if raw, ok := item["$ref"]; ok {
+			var ref string
+			json.Unmarshal(raw, &ref)
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: the path item is a $ref to %s, which is not followed", path, ref))
+			continue
+		}
+		var shared []*openAPIParameter
+		var servers []openAPIServer
+		if raw, ok := item["parameters"]; ok {
+			json.Unmarshal(raw, &shared)
+		}
+		if raw, ok := item["servers"]; ok {
+			json.Unmarshal(raw, &servers)
+		}
+
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			name := strings.ToUpper(method) + " " + path
+			var operation openAPIOperation
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			if len(operation.Servers) == 0 {
+				operation.Servers = servers
+			}
+			req, err := spec.request(strings.ToUpper(method), path, &operation, shared)
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			result.Requests = append(result.Requests, req)
+		}
+	}
+
+	// Webhooks are requests the API sends, not ones to send to it
+	var webhooks []string
+	for name, item := range spec.Webhooks {
+		for _, method := range openAPIMethods {
+			if _, ok := item[method]; ok {
+				webhooks = append(webhooks, fmt.Sprintf("%s webhook %s: sent by the API rather than to it", strings.ToUpper(method), name))
+			}
+		}
+	}
+	sort.Strings(webhooks)
+	result.Skipped = append(result.Skipped, webhooks...)
+	return result, nil
+}
+
+// decodeOpenAPI reads a JSON or YAML document into spec. YAML is turned
+// into JSON first, so that spec needs only JSON tags.
+func decodeOpenAPI(data []byte, spec *openAPISpec) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return json.Unmarshal(data, spec)
+	}
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(jsonCompatible(document))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, spec)
+}
+
+// jsonCompatible converts the maps YAML decodes with keys other than
+// strings, such as response codes, to maps JSON can encode, and dates back
+// to how they were written.
+func jsonCompatible(value any) any {
+	switch value := value.(type) {
+	case time.Time:
+		if value.Equal(value.Truncate(24 * time.Hour)) {
+			return value.Format(time.DateOnly)
+		}
+		return value.Format(time.RFC3339Nano)
+	case map[string]any:
+		for key, item := range value {
+			value[key] = jsonCompatible(item)
+		}
+		return value
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []any:
+		for i, item := range value {
+			value[i] = jsonCompatible(item)
+		}
+		return value
+	}
+	return value
+}
+
+// request makes the saved request of an operation on path, whose path item
+// shares the parameters shared.
+func (s *openAPISpec) request(method, path string, operation *openAPIOperation, shared []*openAPIParameter) (*SavedRequest, error) {
+	req := &SavedRequest{Method: method, Name: operation.Summary}
+	if req.Name == "" {
+		req.Name = operation.OperationID
+	}
+	// The tree shows the method before the name
+	if req.Name == "" {
+		req.Name = path
+	}
+	req.Notes = strings.TrimSpace(operation.Description)
+	if operation.Deprecated {
+		req.Notes = strings.TrimSpace("Deprecated. " + req.Notes)
+	}
+
+	parameters, err := s.parameters(shared, operation.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers, query, pathValues []storedHeader
+	var formFields []storedFormField
+	var body any
+	hasBody, hasForm, hasFile := false, false, false
+	for _, parameter := range parameters {
+		value, err := s.parameterValue(parameter)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", parameter.Name, err)
+		}
+		row := storedHeader{Key: parameter.Name, Value: value, Disabled: !parameter.Required, Description: strings.TrimSpace(parameter.Description)}
+		switch parameter.In {
+		case "path":
+			row.Disabled = false
+			pathValues = append(pathValues, row)
+		case "query":
+			query = append(query, row)
+		case "header":
+			// OpenAPI has Content-Type, Accept and Authorization come from
+			// the body, responses and security instead
+			switch strings.ToLower(parameter.Name) {
+			case "content-type", "accept", "authorization":
+			default:
+				headers = append(headers, row)
+			}
+		case "body":
+			if body, err = s.sample(parameter.Schema, 0, map[string]bool{}); err != nil {
+				return nil, fmt.Errorf("body: %w", err)
+			}
+			hasBody = true
+		case "formData":
+			hasForm = true
+			if parameter.Type == "file" {
+				hasFile = true
+			}
+			if parameter.Required {
+				formFields = append(formFields, storedFormField{Key: parameter.Name, Value: value, IsFile: parameter.Type == "file"})
+			}
+		}
+	}
+
+	req.URL = s.serverURL(operation.Servers) + path
+	var enabled []string
+	for _, row := range query {
+		if !row.Disabled {
+			part := url.QueryEscape(row.Key)
+			if row.Value != "" {
+				part += "=" + url.QueryEscape(row.Value)
+			}
+			enabled = append(enabled, part)
+		}
+	}
+	if len(enabled) > 0 {
+		req.URL += "?" + strings.Join(enabled, "&")
+	}
+	for _, row := range query {
+		if row.Disabled || row.Description != "" {
+			paramsJSON, _ := json.Marshal(query)
+			req.Params = string(paramsJSON)
+			break
+		}
+	}
+	// Path variables are stored in the order of the URL, as the editor
+	// lists them
+	var pathVariables []storedHeader
+	for _, name := range openAPIPathVariables(path) {
+		variable := storedHeader{Key: name}
+		for _, row := range pathValues {
+			if row.Key == name {
+				variable = row
+			}
+		}
+		pathVariables = append(pathVariables, variable)
+	}
+	if len(pathVariables) > 0 {
+		pathVariablesJSON, _ := json.Marshal(pathVariables)
+		req.PathVariables = string(pathVariablesJSON)
+	}
+
+	contentType := ""
+	switch {
+	case operation.RequestBody != nil:
+		var err error
+		if contentType, err = s.requestBody(req, operation.RequestBody); err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+	case hasBody:
+		contentType = "application/json"
+		if consumes := s.consumes(operation); len(consumes) > 0 {
+			contentType = preferredContentType(consumes)
+		}
+		req.Body = sampleText(body, contentType)
+	case hasFile || hasForm && slices.Contains(s.consumes(operation), "multipart/form-data"):
+		// The editor sets the boundary of a multipart body
+		setFormFields(req, formFields)
+	case hasForm:
+		contentType = "application/x-www-form-urlencoded"
+		req.Body = encodeForm(formFields)
+	}
+
+	if contentType != "" {
+		headers = append([]storedHeader{{Key: "Content-Type", Value: contentType}}, headers...)
+	}
+	if len(headers) > 0 {
+		headersJSON, _ := json.Marshal(headers)
+		req.Headers = string(headersJSON)
+	}
+	return req, nil
+}
+
+// parameters resolves the parameters of an operation, its own replacing
+// those of the path item with the same name and location.
+func (s *openAPISpec) parameters(shared, own []*openAPIParameter) ([]*openAPIParameter, error) {
+	var parameters []*openAPIParameter
+	for _, list := range [][]*openAPIParameter{shared, own} {
+		for _, parameter := range list {
+			parameter, err := s.resolveParameter(parameter)
+			if err != nil {
+				return nil, err
+			}
+			replaced := false
+			for i, existing := range parameters {
+				if existing.Name == parameter.Name && existing.In == parameter.In {
+					parameters[i], replaced = parameter, true
+				}
+			}
+			if !replaced {
+				parameters = append(parameters, parameter)
+			}
+		}
+	}
+	return parameters, nil
+}
+
+func (s *openAPISpec) resolveParameter(parameter *openAPIParameter) (*openAPIParameter, error) {
+	for seen := 0; parameter != nil && parameter.Ref != ""; seen++ {
+		name, err := refName(parameter.Ref, "#/components/parameters/", "#/parameters/")
+		if err != nil {
+			return nil, err
+		}
+		next := s.Components.Parameters[name]
+		if next == nil {
+			next = s.Parameters[name]
+		}
+		if next == nil || seen > openAPISampleDepth {
+			return nil, fmt.Errorf("parameter %s is not defined", parameter.Ref)
+		}
+		parameter = next
+	}
+	if parameter == nil {
+		return nil, errors.New("empty parameter")
+	}
+	return parameter, nil
+}
+
+// parameterValue is the value a parameter is given: its example, or that of
+// its schema, or its default or first allowed value, or else empty.
+func (s *openAPISpec) parameterValue(parameter *openAPIParameter) (string, error) {
+	if parameter.Example != nil {
+		return sampleString(parameter.Example), nil
+	}
+	if example, ok := firstExample(parameter.Examples); ok {
+		return sampleString(example), nil
+	}
+	if parameter.Default != nil {
+		return sampleString(parameter.Default), nil
+	}
+	if len(parameter.Enum) > 0 {
+		return sampleString(parameter.Enum[0]), nil
+	}
+	if parameter.Schema == nil || parameter.In == "body" {
+		return "", nil
+	}
+	schema, err := s.resolveSchema(parameter.Schema)
+	if err != nil {
+		return "", err
+	}
+	// Only a value the schema gives, not the default of its type, which
+	// would send 0 or false without being asked to
+	for _, value := range append([]any{schema.Example, schema.Default, schema.Const}, schema.Enum...) {
+		if value != nil {
+			return sampleString(value), nil
+		}
+	}
+	if len(schema.Examples) > 0 {
+		return sampleString(schema.Examples[0]), nil
+	}
+	return "", nil
+}
+
+// requestBody gives req the body of an OpenAPI 3 request body and returns
+// its content type. JSON is preferred when there is a choice, then forms.
+func (s *openAPISpec) requestBody(req *SavedRequest, requestBody *openAPIRequestBody) (string, error) {
+	for seen := 0; requestBody.Ref != ""; seen++ {
+		name, err := refName(requestBody.Ref, "#/components/requestBodies/")
+		if err != nil {
+			return "", err
+		}
+		next := s.Components.RequestBodies[name]
+		if next == nil || seen > openAPISampleDepth {
+			return "", fmt.Errorf("%s is not defined", requestBody.Ref)
+		}
+		requestBody = next
+	}
+	if len(requestBody.Content) == 0 {
+		return "", nil
+	}
+
+	contentTypes := make([]string, 0, len(requestBody.Content))
+	for contentType := range requestBody.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	contentType := preferredContentType(contentTypes)
+	media := requestBody.Content[contentType]
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var sample any
+	if media.Example != nil {
+		sample = media.Example
+	} else if example, ok := firstExample(media.Examples); ok {
+		sample = example
+	} else {
+		var err error
+		if sample, err = s.sample(media.Schema, 0, map[string]bool{}); err != nil {
+			return "", err
+		}
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		schema, err := s.resolveSchema(media.Schema)
+		if err != nil {
+			return "", err
+		}
+		fields := formFields(sample)
+		for i, field := range fields {
+			if property, err := s.resolveSchema(schema.Properties[field.Key]); err == nil && property.Format == "binary" {
+				fields[i].Value, fields[i].IsFile = "", true
+			}
+		}
+		setFormFields(req, fields)
+		return "", nil
+	case "application/x-www-form-urlencoded":
+		req.Body = encodeForm(formFields(sample))
+	default:
+		req.Body = sampleText(sample, mediaType)
+	}
+	if strings.Contains(contentType, "*") {
+		return "", nil
+	}
+	return contentType, nil
+}
+
+// consumes are the content types a Swagger 2.0 operation takes.
+func (s *openAPISpec) consumes(operation *openAPIOperation) []string {
+	if len(operation.Consumes) > 0 {
+		return operation.Consumes
+	}
+	return s.Consumes
+}
+
+// preferredContentType picks the content type to send from those a body may
+// have: JSON when there is a choice, then forms, then the first.
+func preferredContentType(contentTypes []string) string {
+	return slices.MinFunc(contentTypes, func(a, b string) int {
+		return contentTypeRank(a) - contentTypeRank(b)
+	})
+}
+
+// contentTypeRank orders content types by preference.
+func contentTypeRank(contentType string) int {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json":
+		return 0
+	case strings.HasSuffix(mediaType, "+json"):
+		return 1
+	case mediaType == "multipart/form-data":
+		return 2
+	case mediaType == "application/x-www-form-urlencoded":
+		return 3
+	}
+	return 4
+}
+
+// formFields are the fields of an object sample, in name order.
+func formFields(sample any) []storedFormField {
+	object, _ := sample.(map[string]any)
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]storedFormField, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, storedFormField{Key: name, Value: sampleString(object[name])})
+	}
+	return fields
+}
+
+// setFormFields gives req a multipart body of fields, an empty list when
+// there are none.
+func setFormFields(req *SavedRequest, fields []storedFormField) {
+	if fields == nil {
+		fields = []storedFormField{}
+	}
+	fieldsJSON, _ := json.Marshal(fields)
+	req.BodyType, req.Body = bodyTypeMultipart, string(fieldsJSON)
+}
+
+func encodeForm(fields []storedFormField) string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, url.QueryEscape(field.Key)+"="+url.QueryEscape(field.Value))
+	}
+	return strings.Join(values, "&")
+}
+
+// serverURL is the first of servers, or of the document's, with its
+// variables at their defaults and without a trailing slash. {{baseUrl}}
+// stands in for a server or Swagger host the document does not give.
+func (s *openAPISpec) serverURL(servers []openAPIServer) string {
+	if s.Swagger != "" {
+		if s.Host == "" {
+			return "{{baseUrl}}" + strings.TrimSuffix(s.BasePath, "/")
+		}
+		scheme := "https"
+		if len(s.Schemes) > 0 {
+			scheme = s.Schemes[0]
+		}
+		return scheme + "://" + s.Host + strings.TrimSuffix(s.BasePath, "/")
+	}
+	if len(servers) == 0 {
+		servers = s.Servers
+	}
+	if len(servers) == 0 {
+		return "{{baseUrl}}"
+	}
+	server := servers[0].URL
+	for name, variable := range servers[0].Variables {
+		server = strings.ReplaceAll(server, "{"+name+"}", variable.Default)
+	}
+	return strings.TrimSuffix(server, "/")
+}
+
+// resolveSchema follows the $ref of schema, if it has one.
+func (s *openAPISpec) resolveSchema(schema *openAPISchema) (*openAPISchema, error) {
+	for seen := 0; schema != nil && schema.Ref != ""; seen++ {
+		name, err := refName(schema.Ref, "#/components/schemas/", "#/definitions/")
+		if err != nil {
+			return nil, err
+		}
+		next := s.Components.Schemas[name]
+		if next == nil {
+			next = s.Definitions[name]
+		}
+		if next == nil || seen > openAPISampleDepth {
+			return nil, fmt.Errorf("schema %s is not defined", schema.Ref)
+		}
+		schema = next
+	}
+	if schema == nil {
+		return &openAPISchema{}, nil
+	}
+	return schema, nil
+}
+
+// sample is a value of schema: its example, default or first allowed value
+// when it has one, else the required fields of an object, and otherwise the
+// zero value of its type. seen holds the schemas being filled in, so that
+// one containing itself stops.
+func (s *openAPISpec) sample(schema *openAPISchema, depth int, seen map[string]bool) (any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if ref := schema.Ref; ref != "" {
+		if seen[ref] || depth > openAPISampleDepth {
+			return nil, nil
+		}
+		seen[ref] = true
+		defer delete(seen, ref)
+	}
+	schema, err := s.resolveSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range []any{schema.Example, schema.Default, schema.Const} {
+		if value != nil {
+			return value, nil
+		}
+	}
+	if len(schema.Examples) > 0 {
+		return schema.Examples[0], nil
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0], nil
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, part := range schema.AllOf {
+			value, err := s.sample(part, depth+1, seen)
+			if err != nil {
+				return nil, err
+			}
+			object, ok := value.(map[string]any)
+			if !ok {
+				return value, nil
+			}
+			for key, item := range object {
+				merged[key] = item
+			}
+		}
+		if len(schema.Properties) == 0 {
+			return merged, nil
+		}
+		object, err := s.sampleObject(schema, depth, seen)
+		if err != nil {
+			return nil, err
+		}
+		for key, item := range object {
+			merged[key] = item
+		}
+		return merged, nil
+	}
+	for _, choices := range [][]*openAPISchema{schema.OneOf, schema.AnyOf} {
+		if len(choices) > 0 {
+			return s.sample(choices[0], depth+1, seen)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return s.sampleObject(schema, depth, seen)
+	case "array":
+		return []any{}, nil
+	case "string":
+		return "", nil
+	case "integer", "number":
+		return 0, nil
+	case "boolean":
+		return false, nil
+	}
+	return nil, nil
+}
+
+// sampleObject fills in the required properties of an object schema.
+func (s *openAPISpec) sampleObject(schema *openAPISchema, depth int, seen map[string]bool) (map[string]any, error) {
+	object := map[string]any{}
+	if depth > openAPISampleDepth {
+		return object, nil
+	}
+	for _, name := range schema.Required {
+		value, err := s.sample(schema.Properties[name], depth+1, seen)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		object[name] = value
+	}
+	return object, nil
+}
+
+// schemaType is the type of schema, the first besides null of a list of
+// types, or object when it only has properties.
+func schemaType(schema *openAPISchema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if len(schema.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// sampleText writes a body sample in the media type of the body: JSON, or
+// a string as it is.
+func sampleText(sample any, mediaType string) string {
+	if text, ok := sample.(string); ok && !strings.Contains(mediaType, "json") {
+		return text
+	}
+	if sample == nil {
+		return ""
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sample); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// sampleString writes a parameter value: strings and numbers as they are,
+// anything else as JSON.
+func sampleString(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		// Not %v, which writes large numbers with an exponent
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int, bool:
+		return fmt.Sprint(value)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// firstExample is the value of the first of examples by name.
+func firstExample(examples map[string]openAPIExample) (any, bool) {
+	names := make([]string, 0, len(examples))
+	for name, example := range examples {
+		if example.Value != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	sort.Strings(names)
+	return examples[names[0]].Value, true
+}
+
+// openAPIPathVariables are the names of the {name} segments of an OpenAPI
+// path, in order and each once.
+func openAPIPathVariables(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		match := openAPIPathVariablePattern.FindStringSubmatch(segment)
+		if match != nil && !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// refName is the name a local $ref gives under one of prefixes.
+func refName(ref string, prefixes ...string) (string, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return "", fmt.Errorf("%s %w", ref, errExternalRef)
+	}
+	for _, prefix := range prefixes {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			return strings.NewReplacer("~1", "/", "~0", "~").Replace(name), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a reference that can be followed", ref)
+}
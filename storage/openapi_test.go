@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const openAPI3Document = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "description": "The pet store.\n"},
+  "servers": [{"url": "https://{region}.pets.example.com/v1/", "variables": {"region": {"default": "eu"}}}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "summary": "List pets",
+        "parameters": [
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer", "default": 20}},
+          {"name": "tag", "in": "query", "description": "Only pets with the tag", "schema": {"type": "string"}},
+          {"name": "X-Request-ID", "in": "header", "required": true, "example": "r-1"},
+          {"name": "X-Debug", "in": "header", "schema": {"type": "boolean"}},
+          {"name": "Authorization", "in": "header", "required": true}
+        ]
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {"$ref": "#/components/requestBodies/NewPet"}
+      }
+    },
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "string", "example": "p7"}}],
+      "put": {
+        "summary": "Upload a photo",
+        "deprecated": true,
+        "servers": [{"url": "https://upload.pets.example.com"}],
+        "requestBody": {"content": {"multipart/form-data": {"schema": {
+          "type": "object",
+          "required": ["caption", "photo"],
+          "properties": {"caption": {"type": "string", "example": "Rex"}, "photo": {"type": "string", "format": "binary"}}
+        }}}}
+      },
+      "patch": {
+        "summary": "Rename a pet",
+        "requestBody": {"content": {"application/x-www-form-urlencoded": {"schema": {
+          "type": "object",
+          "required": ["name"],
+          "properties": {"name": {"type": "string", "example": "Rex Jr"}}
+        }}}}
+      },
+      "delete": {
+        "summary": "Remove a pet",
+        "parameters": [{"$ref": "https://example.com/common.json#/Force"}]
+      }
+    },
+    "/shared": {"$ref": "#/components/pathItems/Shared"}
+  },
+  "webhooks": {"petAdopted": {"post": {"summary": "A pet was adopted"}}},
+  "components": {
+    "requestBodies": {
+      "NewPet": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+    },
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["name", "age", "owner"],
+        "properties": {
+          "name": {"type": "string"},
+          "age": {"type": "integer"},
+          "owner": {"$ref": "#/components/schemas/Owner"},
+          "nickname": {"type": "string"}
+        }
+      },
+      "Owner": {"type": "object", "required": ["email"], "properties": {"email": {"type": "string", "example": "ada@example.com"}}}
+    }
+  }
+}`
+
+const swagger2Document = `swagger: "2.0"
+info:
+  title: Legacy
+host: legacy.example.com
+basePath: /api/
+schemes: [http]
+consumes: [multipart/form-data]
+paths:
+  /avatar:
+    post:
+      summary: Set the avatar
+      parameters:
+        - {name: caption, in: formData, type: string}
+  /files:
+    get:
+      summary: List files
+      parameters:
+        - {name: max, in: query, type: integer, default: 1000000}
+    post:
+      summary: Upload a file
+      parameters:
+        - {name: file, in: formData, type: file, required: true}
+        - {name: label, in: formData, type: string}
+  /login:
+    post:
+      summary: Log in
+      consumes: [application/x-www-form-urlencoded]
+      parameters:
+        - {name: user, in: formData, type: string, required: true, default: ada}
+        - {name: remember, in: formData, type: boolean}
+  /notes/{id}:
+    put:
+      summary: Save a note
+      consumes: [application/json]
+      parameters:
+        - {name: id, in: path, type: integer, required: true}
+        - name: note
+          in: body
+          schema: {$ref: "#/definitions/Note"}
+definitions:
+  Note:
+    type: object
+    required: [text]
+    properties:
+      text: {type: string}
+      pinned: {type: boolean}
+`
+
+func TestParseOpenAPI3(t *testing.T) {
+	result, err := ParseOpenAPI([]byte(openAPI3Document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "Pets" || result.Description != "The pet store." {
+		t.Errorf("name, description = %q, %q", result.Name, result.Description)
+	}
+
+	want := []*SavedRequest{
+		{
+			Name: "List pets", Method: "GET",
+			URL:     "https://eu.pets.example.com/v1/pets?limit=20",
+			Headers: `[{"key":"X-Request-ID","value":"r-1"},{"key":"X-Debug","value":"","disabled":true}]`,
+			Params:  `[{"key":"limit","value":"20"},{"key":"tag","value":"","disabled":true,"description":"Only pets with the tag"}]`,
+		},
+		{
+			Name: "createPet", Method: "POST",
+			URL:     "https://eu.pets.example.com/v1/pets",
+			Headers: `[{"key":"Content-Type","value":"application/json"}]`,
+			Body:    "{\n  \"age\": 0,\n  \"name\": \"\",\n  \"owner\": {\n    \"email\": \"ada@example.com\"\n  }\n}",
+		},
+		{
+			Name: "Upload a photo", Method: "PUT", Notes: "Deprecated.",
+			URL:           "https://upload.pets.example.com/pets/{petId}",
+			BodyType:      bodyTypeMultipart,
+			Body:          `[{"key":"caption","value":"Rex"},{"key":"photo","value":"","file":true}]`,
+			PathVariables: `[{"key":"petId","value":"p7"}]`,
+		},
+		{
+			Name: "Rename a pet", Method: "PATCH",
+			URL:           "https://eu.pets.example.com/v1/pets/{petId}",
+			Headers:       `[{"key":"Content-Type","value":"application/x-www-form-urlencoded"}]`,
+			Body:          "name=Rex+Jr",
+			PathVariables: `[{"key":"petId","value":"p7"}]`,
+		},
+	}
+	if !reflect.DeepEqual(result.Requests, want) {
+		t.Errorf("requests:\n got %s\nwant %s", jsonText(result.Requests), jsonText(want))
+	}
+
+	wantSkipped := []string{
+		"DELETE /pets/{petId}: https://example.com/common.json#/Force refers to another document",
+		"/shared: the path item is a $ref to #/components/pathItems/Shared, which is not followed",
+		"POST webhook petAdopted: sent by the API rather than to it",
+	}
+	if !reflect.DeepEqual(result.Skipped, wantSkipped) {
+		t.Errorf("skipped:\n got %q\nwant %q", result.Skipped, wantSkipped)
+	}
+}
+
+func TestParseSwagger2(t *testing.T) {
+	result, err := ParseOpenAPI([]byte(swagger2Document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*SavedRequest{
+		{
+			// A form without required fields is an empty list, not null
+			Name: "Set the avatar", Method: "POST",
+			URL:      "http://legacy.example.com/api/avatar",
+			BodyType: bodyTypeMultipart,
+			Body:     "[]",
+		},
+		{
+			// The document's multipart consumes is for the operations
+			// with a form, not a GET
+			Name: "List files", Method: "GET",
+			URL:    "http://legacy.example.com/api/files",
+			Params: `[{"key":"max","value":"1000000","disabled":true}]`,
+		},
+		{
+			Name: "Upload a file", Method: "POST",
+			URL:      "http://legacy.example.com/api/files",
+			BodyType: bodyTypeMultipart,
+			Body:     `[{"key":"file","value":"","file":true}]`,
+		},
+		{
+			Name: "Log in", Method: "POST",
+			URL:     "http://legacy.example.com/api/login",
+			Headers: `[{"key":"Content-Type","value":"application/x-www-form-urlencoded"}]`,
+			Body:    "user=ada",
+		},
+		{
+			Name: "Save a note", Method: "PUT",
+			URL:           "http://legacy.example.com/api/notes/{id}",
+			Headers:       `[{"key":"Content-Type","value":"application/json"}]`,
+			Body:          "{\n  \"text\": \"\"\n}",
+			PathVariables: `[{"key":"id","value":""}]`,
+		},
+	}
+	if !reflect.DeepEqual(result.Requests, want) {
+		t.Errorf("requests:\n got %s\nwant %s", jsonText(result.Requests), jsonText(want))
+	}
+	if len(result.Skipped) != 0 {
+		t.Errorf("skipped = %q, want none", result.Skipped)
+	}
+}
+
+func TestParseSwagger2WithoutHost(t *testing.T) {
+	document := strings.Replace(swagger2Document, "host: legacy.example.com\n", "", 1)
+	result, err := ParseOpenAPI([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Requests[0].URL, "{{baseUrl}}/api/avatar"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestParseOpenAPIErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"OpenAPI 4", `{"openapi": "4.0.0", "paths": {}}`, "OpenAPI version 4.0.0 is not supported"},
+		{"Swagger 1.2", "swagger: '1.2'\n", "OpenAPI version 1.2 is not supported"},
+		{"not OpenAPI", `{"info": {"title": "Nope"}}`, "not an OpenAPI or Swagger document"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseOpenAPI([]byte(tc.document)); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+)
+
+// postmanSchema is the Postman Collection format the export follows.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// The Postman Collection v2.1 format, as far as saved requests can fill it
+type postmanCollection struct {
+	Info postmanInfo   `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method      string        `json:"method"`
+	Header      []postmanPair `json:"header"`
+	Body        *postmanBody  `json:"body,omitempty"`
+	URL         postmanURL    `json:"url"`
+	Auth        *postmanAuth  `json:"auth,omitempty"`
+	Description string        `json:"description,omitempty"`
+}
+
+type postmanPair struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Src         string `json:"src,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string        `json:"raw"`
+	Protocol string        `json:"protocol,omitempty"`
+	Host     []string      `json:"host,omitempty"`
+	Port     string        `json:"port,omitempty"`
+	Path     []string      `json:"path,omitempty"`
+	Query    []postmanPair `json:"query,omitempty"`
+	Variable []postmanPair `json:"variable,omitempty"`
+	Hash     string        `json:"hash,omitempty"`
+}
+
+type postmanBody struct {
+	Mode     string              `json:"mode"`
+	Raw      string              `json:"raw,omitempty"`
+	FormData []postmanPair       `json:"formdata,omitempty"`
+	File     *postmanFile        `json:"file,omitempty"`
+	GraphQL  *postmanGraphQL     `json:"graphql,omitempty"`
+	Options  *postmanBodyOptions `json:"options,omitempty"`
+}
+
+// postmanGraphQL is a GraphQL body, whose variables Postman keeps as text.
+type postmanGraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+type postmanFile struct {
+	Src string `json:"src"`
+}
+
+type postmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanAuth struct {
+	Type   string        `json:"type"`
+	Basic  []postmanPair `json:"basic,omitempty"`
+	Bearer []postmanPair `json:"bearer,omitempty"`
+	OAuth2 []postmanPair `json:"oauth2,omitempty"`
+}
+
+// storedAuth is the auth of a saved request, as the ui package stores it.
+type storedAuth struct {
+	Type         string `json:"type"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Token        string `json:"token"`
+	AuthURL      string `json:"auth_url"`
+	TokenURL     string `json:"token_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// ExportCollectionPostman writes the requests saved in collection to w as a
+// Postman Collection v2.1, a collection with ID 0 being the requests in
+// none. {{variables}} and :path variables mean the same in Postman and are
+// written as they are. Pre-request scripts, tests and HMAC signing have no
+// Postman equivalent and are left out.
+func (db *DB) ExportCollectionPostman(collection *Collection, w io.Writer) error {
+	var collectionID *int
+	if collection.ID != 0 {
+		collectionID = &collection.ID
+	}
+	requests, err := db.GetSavedRequests(collectionID)
+	if err != nil {
+		return err
+	}
+
+	export := postmanCollection{
+		Info: postmanInfo{Name: collection.Name, Description: collection.Description, Schema: postmanSchema},
+		Item: []postmanItem{},
+	}
+	for _, req := range requests {
+		export.Item = append(export.Item, postmanItem{Name: req.Name, Request: postmanRequestOf(req)})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(export)
+}
+
+func postmanRequestOf(req *SavedRequest) postmanRequest {
+	request := postmanRequest{
+		Method:      req.Method,
+		Header:      []postmanPair{},
+		URL:         postmanURLOf(req),
+		Auth:        postmanAuthOf(req.Auth),
+		Description: req.Notes,
+	}
+
+	var headers []storedHeader
+	if req.Headers != "" {
+		json.Unmarshal([]byte(req.Headers), &headers)
+	}
+	contentType := ""
+	for _, h := range headers {
+		request.Header = append(request.Header, postmanPair{Key: h.Key, Value: h.Value, Disabled: h.Disabled, Description: h.Description})
+		if !h.Disabled && strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.Value
+		}
+	}
+
+	switch {
+	case req.BodyType == bodyTypeMultipart:
+		var fields []storedFormField
+		json.Unmarshal([]byte(req.Body), &fields)
+		body := &postmanBody{Mode: "formdata", FormData: []postmanPair{}}
+		for _, field := range fields {
+			if field.IsFile {
+				body.FormData = append(body.FormData, postmanPair{Key: field.Key, Type: "file", Src: field.Value})
+			} else {
+				body.FormData = append(body.FormData, postmanPair{Key: field.Key, Value: field.Value, Type: "text"})
+			}
+		}
+		request.Body = body
+	case req.BodyType == bodyTypeGraphQL:
+		if req.Body != "" {
+			request.Body = &postmanBody{Mode: "graphql", GraphQL: postmanGraphQLOf(req.Body)}
+		}
+	case req.BodyType != "":
+		// A binary body is stored as the path of its file
+		if req.Body != "" {
+			request.Body = &postmanBody{Mode: "file", File: &postmanFile{Src: req.Body}}
+		}
+	case req.BodySource != "":
+		if req.BodyFile != "" {
+			request.Body = &postmanBody{Mode: "file", File: &postmanFile{Src: req.BodyFile}}
+		}
+	case req.Body != "":
+		request.Body = &postmanBody{Mode: "raw", Raw: req.Body}
+		if language := postmanLanguage(contentType); language != "" {
+			request.Body.Options = &postmanBodyOptions{}
+			request.Body.Options.Raw.Language = language
+		}
+	}
+	return request
+}
+
+// postmanGraphQLOf converts a stored GraphQL body. Variables that were not
+// valid JSON are stored as a string, and exported as they were typed.
+func postmanGraphQLOf(body string) *postmanGraphQL {
+	var payload struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+	json.Unmarshal([]byte(body), &payload)
+
+	graphQL := &postmanGraphQL{Query: payload.Query}
+	var indented bytes.Buffer
+	switch {
+	case len(payload.Variables) == 0 || string(payload.Variables) == "null":
+	case json.Unmarshal(payload.Variables, &graphQL.Variables) == nil:
+	case json.Indent(&indented, payload.Variables, "", "  ") == nil:
+		graphQL.Variables = indented.String()
+	}
+	return graphQL
+}
+
+// postmanLanguage is the raw body language Postman highlights a body of
+// contentType as, or "" for plain text.
+func postmanLanguage(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	case mediaType == "text/html":
+		return "html"
+	case mediaType == "application/javascript" || mediaType == "text/javascript":
+		return "javascript"
+	}
+	return ""
+}
+
+// postmanURLOf splits the URL of req the way Postman does, without parsing
+// it, as the host is often a {{variable}}. The query comes from the stored
+// parameter rows when there are some, as they keep the disabled ones.
+func postmanURLOf(req *SavedRequest) postmanURL {
+	u := postmanURL{Raw: req.URL}
+	rest := req.URL
+	if protocol, after, ok := strings.Cut(rest, "://"); ok {
+		u.Protocol, rest = protocol, after
+	}
+	rest, u.Hash, _ = strings.Cut(rest, "#")
+	rest, query, _ := strings.Cut(rest, "?")
+	host, path, hasPath := strings.Cut(rest, "/")
+	// A port is only split off a host that is not a bare {{variable}} or
+	// IPv6 address
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "}}") && !strings.HasSuffix(host, "]") {
+		host, u.Port = host[:i], host[i+1:]
+	}
+	if host != "" {
+		u.Host = strings.Split(host, ".")
+	}
+	if hasPath {
+		u.Path = strings.Split(path, "/")
+	}
+
+	var params []storedHeader
+	if req.Params != "" {
+		json.Unmarshal([]byte(req.Params), &params)
+	}
+	if len(params) > 0 {
+		for _, param := range params {
+			u.Query = append(u.Query, postmanPair{Key: param.Key, Value: param.Value, Disabled: param.Disabled, Description: param.Description})
+		}
+	} else if query != "" {
+		// Left encoded, as Postman keeps them
+		for _, part := range strings.Split(query, "&") {
+			key, value, _ := strings.Cut(part, "=")
+			u.Query = append(u.Query, postmanPair{Key: key, Value: value})
+		}
+	}
+
+	var variables []storedHeader
+	if req.PathVariables != "" {
+		json.Unmarshal([]byte(req.PathVariables), &variables)
+	}
+	for _, variable := range variables {
+		u.Variable = append(u.Variable, postmanPair{Key: variable.Key, Value: variable.Value, Description: variable.Description})
+	}
+	return u
+}
+
+// postmanAuthOf describes the stored auth of a request in Postman's terms,
+// or returns nil when it has none Postman knows.
+func postmanAuthOf(stored string) *postmanAuth {
+	var auth storedAuth
+	if stored == "" || json.Unmarshal([]byte(stored), &auth) != nil {
+		return nil
+	}
+	switch auth.Type {
+	case "basic":
+		return &postmanAuth{Type: "basic", Basic: []postmanPair{
+			{Key: "username", Value: auth.Username, Type: "string"},
+			{Key: "password", Value: auth.Password, Type: "string"},
+		}}
+	case "bearer":
+		return &postmanAuth{Type: "bearer", Bearer: []postmanPair{
+			{Key: "token", Value: auth.Token, Type: "string"},
+		}}
+	case "oauth2":
+		return &postmanAuth{Type: "oauth2", OAuth2: []postmanPair{
+			{Key: "grant_type", Value: "authorization_code", Type: "string"},
+			{Key: "authUrl", Value: auth.AuthURL, Type: "string"},
+			{Key: "accessTokenUrl", Value: auth.TokenURL, Type: "string"},
+			{Key: "clientId", Value: auth.ClientID, Type: "string"},
+			{Key: "clientSecret", Value: auth.ClientSecret, Type: "string"},
+			{Key: "scope", Value: auth.Scope, Type: "string"},
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// loadPostmanSchema reads testdata/postman-collection-v2.1.0.json, the
+// Postman Collection v2.1.0 schema without its descriptions.
+func loadPostmanSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "postman-collection-v2.1.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// validateJSONSchema checks value against schema and returns what is wrong
+// with it. Only the keywords the Postman schema uses are known: $ref to its
+// definitions, type, enum, const, properties, required, items, oneOf, anyOf,
+// minimum and maxLength.
+func validateJSONSchema(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		definitions, _ := root["definitions"].(map[string]any)
+		definition, ok := definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown $ref %s", path, ref)}
+		}
+		return validateJSONSchema(root, definition, value, path)
+	}
+
+	var problems []string
+	if types, ok := schema["type"]; ok {
+		var names []string
+		switch types := types.(type) {
+		case string:
+			names = []string{types}
+		case []any:
+			for _, name := range types {
+				names = append(names, name.(string))
+			}
+		}
+		if !slices.ContainsFunc(names, func(name string) bool { return jsonSchemaType(value, name) }) {
+			return []string{fmt.Sprintf("%s: %s is not of type %s", path, jsonText(value), strings.Join(names, " or "))}
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(v any) bool { return jsonText(v) == jsonText(value) }) {
+		problems = append(problems, fmt.Sprintf("%s: %s is not one of %s", path, jsonText(value), jsonText(enum)))
+	}
+	if constant, ok := schema["const"]; ok && jsonText(constant) != jsonText(value) {
+		problems = append(problems, fmt.Sprintf("%s: %s is not %s", path, jsonText(value), jsonText(constant)))
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if number, ok := value.(float64); ok && number < minimum {
+			problems = append(problems, fmt.Sprintf("%s: %v is less than %v", path, number, minimum))
+		}
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok {
+		if text, ok := value.(string); ok && float64(len([]rune(text))) > maxLength {
+			problems = append(problems, fmt.Sprintf("%s: %q is longer than %v", path, text, maxLength))
+		}
+	}
+
+	if object, ok := value.(map[string]any); ok {
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := object[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: %s is missing", path, name))
+				}
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			names := make([]string, 0, len(object))
+			for name := range object {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if property, ok := properties[name].(map[string]any); ok {
+					problems = append(problems, validateJSONSchema(root, property, object[name], path+"."+name)...)
+				}
+			}
+		}
+	}
+	if array, ok := value.([]any); ok {
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range array {
+				problems = append(problems, validateJSONSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, option := range oneOf {
+			if len(validateJSONSchema(root, option.(map[string]any), value, path)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			problems = append(problems, fmt.Sprintf("%s: %s matches %d of the oneOf schemas, not 1", path, jsonText(value), matched))
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if !slices.ContainsFunc(anyOf, func(option any) bool {
+			return len(validateJSONSchema(root, option.(map[string]any), value, path)) == 0
+		}) {
+			problems = append(problems, fmt.Sprintf("%s: %s matches none of the anyOf schemas", path, jsonText(value)))
+		}
+	}
+	return problems
+}
+
+// jsonSchemaType reports whether value, as encoding/json decodes it into an
+// any, is of the JSON Schema type name.
+func jsonSchemaType(value any, name string) bool {
+	switch value := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case float64:
+		return name == "number" || name == "integer" && value == math.Trunc(value)
+	case []any:
+		return name == "array"
+	case map[string]any:
+		return name == "object"
+	}
+	return false
+}
+
+func jsonText(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := loadPostmanSchema(t)
+	for _, document := range []string{
+		`{"item":[]}`,
+		`{"info":{"name":"A"},"item":[]}`,
+		`{"info":{"name":"A","schema":"s"},"item":[{"name":"r"}]}`,
+		`{"info":{"name":"A","schema":"s"},"item":[{"request":{"header":[{"key":"Accept"}]}}]}`,
+		`{"info":{"name":"A","schema":"s"},"item":[{"request":{"body":{"mode":"binary"}}}]}`,
+		`{"info":{"name":"A","schema":"s"},"item":[{"request":{"auth":{"type":"jwt"}}}]}`,
+		`{"info":{"name":"A","schema":"s"},"item":[{"request":{"url":{"variable":[{"value":"1"}]}}}]}`,
+		`{"info":{"name":"A","schema":"s","version":{"major":-1,"minor":0,"patch":0}},"item":[]}`,
+	} {
+		var value any
+		if err := json.Unmarshal([]byte(document), &value); err != nil {
+			t.Fatal(err)
+		}
+		if len(validateJSONSchema(schema, schema, value, "$")) == 0 {
+			t.Errorf("%s: expected it not to match the schema", document)
+		}
+	}
+}
+
+func TestExportCollectionPostmanSchema(t *testing.T) {
+	db := newTestDB(t)
+	collection, err := db.CreateCollection("Users API", "Everything about users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := []*SavedRequest{
+		{
+			Name: "List users", Method: "GET",
+			URL:     "https://{{host}}:8443/users?page=2&sort=name#top",
+			Headers: `[{"key":"Accept","value":"application/json","description":"Always JSON"},{"key":"X-Debug","value":"1","disabled":true}]`,
+			Params:  `[{"key":"page","value":"2"},{"key":"sort","value":"name","description":"A field"},{"key":"limit","value":"10","disabled":true}]`,
+			Auth:    `{"type":"bearer","token":"{{token}}"}`,
+			Notes:   "Needs the staging environment",
+		},
+		{
+			Name: "Get user", Method: "GET",
+			URL:           "{{baseUrl}}/users/:id",
+			PathVariables: `[{"key":"id","value":"7","description":"The user"}]`,
+			Auth:          `{"type":"basic","username":"me","password":"{{password}}"}`,
+		},
+		{
+			Name: "Create user", Method: "POST",
+			URL:     "http://localhost:3000/users",
+			Headers: `[{"key":"Content-Type","value":"application/json"}]`,
+			Body:    `{"name":"Ada"}`,
+			Auth:    `{"type":"oauth2","auth_url":"https://id.example.com/auth","token_url":"https://id.example.com/token","client_id":"c","client_secret":"s","scope":"users"}`,
+		},
+		{
+			Name: "Upload avatar", Method: "PUT",
+			URL:      "https://[::1]/users/7/avatar",
+			BodyType: bodyTypeMultipart,
+			Body:     `[{"key":"title","value":"Me"},{"key":"file","value":"/tmp/me.png","file":true}]`,
+		},
+		{
+			Name: "Replace export", Method: "PUT",
+			URL:        "https://api.example.com/exports/1",
+			BodySource: "file", BodyFile: "/tmp/export.csv",
+		},
+		{Name: "Purge cache", Method: "PURGE", URL: "api.example.com/cache", Auth: `{"type":"hmac"}`},
+		{
+			Name: "Search users", Method: "POST",
+			URL:      "{{baseUrl}}/graphql",
+			BodyType: bodyTypeGraphQL,
+			Body:     `{"query":"query ($q: String!) { search(text: $q) { id } }","variables":{"q":"Ada"}}`,
+		},
+	}
+	for _, req := range requests {
+		req.CollectionID = &collection.ID
+		if err := db.SaveRequest(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := db.ExportCollectionPostman(collection, &out); err != nil {
+		t.Fatal(err)
+	}
+	var exported any
+	if err := json.Unmarshal(out.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	schema := loadPostmanSchema(t)
+	for _, problem := range validateJSONSchema(schema, schema, exported, "$") {
+		t.Error(problem)
+	}
+	items := exported.(map[string]any)["item"].([]any)
+	if len(items) != len(requests) {
+		t.Fatalf("exported %d requests, want %d", len(items), len(requests))
+	}
+
+	// Postman keeps GraphQL variables as text
+	var body any
+	for _, item := range items {
+		if item := item.(map[string]any); item["name"] == "Search users" {
+			body = item["request"].(map[string]any)["body"]
+		}
+	}
+	want := `{"graphql":{"query":"query ($q: String!) { search(text: $q) { id } }","variables":"{\n  \"q\": \"Ada\"\n}"},"mode":"graphql"}`
+	if got := jsonText(body); got != want {
+		t.Errorf("GraphQL body:\n got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Snippet is a named piece of body text that can be inserted into the body
+// editor. ${name} and ${name:default} placeholders are asked for on insert.
+type Snippet struct {
+	ID        int       `json:"-"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"-"`
+}
+
+const saveSnippetQuery = `INSERT INTO snippets (name, body) VALUES (?, ?)
+	ON CONFLICT(name) DO UPDATE SET body = excluded.body`
+
+func (db *DB) GetSnippets() ([]*Snippet, error) {
+	rows, err := db.Query("SELECT id, name, body, created_at FROM snippets ORDER BY name COLLATE NOCASE")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []*Snippet
+	for rows.Next() {
+		var snippet Snippet
+		if err := rows.Scan(&snippet.ID, &snippet.Name, &snippet.Body, &snippet.CreatedAt); err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, &snippet)
+	}
+	return snippets, rows.Err()
+}
+
+// SaveSnippet stores a snippet, replacing the body of one with the same name.
+func (db *DB) SaveSnippet(name, body string) error {
+	_, err := db.Exec(saveSnippetQuery, name, body)
+	return err
+}
+
+func (db *DB) DeleteSnippet(id int) error {
+	_, err := db.Exec("DELETE FROM snippets WHERE id = ?", id)
+	return err
+}
+
+// ExportSnippets writes all snippets to a JSON file for sharing.
+func (db *DB) ExportSnippets(filepath string) error {
+	snippets, err := db.GetSnippets()
+	if err != nil {
+		return err
+	}
+	if snippets == nil {
+		snippets = []*Snippet{}
+	}
+
+	data, err := json.MarshalIndent(snippets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath, data)
+}
+
+// ImportSnippets reads exported snippets and returns how many there were.
+// Snippets with the same name as an existing one replace it.
+func (db *DB) ImportSnippets(filepath string) (int, error) {
+	data, err := readFile(filepath)
+	if err != nil {
+		return 0, err
+	}
+
+	var snippets []*Snippet
+	if err := json.Unmarshal(data, &snippets); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	count := 0
+	for _, snippet := range snippets {
+		if snippet.Name == "" {
+			continue
+		}
+		if _, err := tx.Exec(saveSnippetQuery, snippet.Name, snippet.Body); err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	return count, tx.Commit()
+}
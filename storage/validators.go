@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Validator is the ETag and Last-Modified value last received for a URL,
+// sent back as If-None-Match and If-Modified-Since when conditional
+// requests are automatic.
+type Validator struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// GetValidator returns the validator remembered for url, or nil when there
+// is none.
+func (db *DB) GetValidator(url string) (*Validator, error) {
+	var validator Validator
+	err := db.QueryRow(
+		"SELECT url, etag, last_modified, updated_at FROM validators WHERE url = ?", url,
+	).Scan(&validator.URL, &validator.ETag, &validator.LastModified, &validator.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &validator, nil
+}
+
+// SaveValidator remembers the validator for its URL, replacing the one
+// received before.
+func (db *DB) SaveValidator(validator *Validator) error {
+	_, err := db.Exec(`INSERT INTO validators (url, etag, last_modified, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET etag = excluded.etag, last_modified = excluded.last_modified, updated_at = excluded.updated_at`,
+		validator.URL, validator.ETag, validator.LastModified, validator.UpdatedAt)
+	return err
+}
+
+func (db *DB) DeleteValidator(url string) error {
+	_, err := db.Exec("DELETE FROM validators WHERE url = ?", url)
+	return err
+}
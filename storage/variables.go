@@ -0,0 +1,108 @@
+package storage
+
+// Variable is a value substituted for {{name}} placeholders when a request
+// is sent. The stored value of a secret variable is encrypted; see the
+// secrets package.
+type Variable struct {
+	ID     int    `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+func (db *DB) GetVariables() ([]*Variable, error) {
+	rows, err := db.Query(`SELECT id, name, value, secret FROM variables ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variables []*Variable
+	for rows.Next() {
+		var variable Variable
+		if err := rows.Scan(&variable.ID, &variable.Name, &variable.Value, &variable.Secret); err != nil {
+			return nil, err
+		}
+		variables = append(variables, &variable)
+	}
+	return variables, rows.Err()
+}
+
+// ReplaceVariables stores variables in place of all existing ones, as edited
+// in the variables dialog.
+func (db *DB) ReplaceVariables(variables []*Variable) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM variables`); err != nil {
+		return err
+	}
+	for _, variable := range variables {
+		if _, err := tx.Exec(
+			`INSERT INTO variables (name, value, secret) VALUES (?, ?, ?)`,
+			variable.Name, variable.Value, variable.Secret,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetVariable adds the global variable or updates the one with its name.
+func (db *DB) SetVariable(variable *Variable) error {
+	_, err := db.Exec(
+		`INSERT INTO variables (name, value, secret) VALUES (?, ?, ?)
+		 ON CONFLICT (name) DO UPDATE SET value = excluded.value, secret = excluded.secret`,
+		variable.Name, variable.Value, variable.Secret,
+	)
+	return err
+}
+
+// EncryptSecretVariables encrypts the values of secret variables, global or
+// of an environment, that are still stored as plaintext.
+func (db *DB) EncryptSecretVariables(encrypt func(string) (string, error), isEncrypted func(string) bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"variables", "environment_variables"} {
+		rows, err := tx.Query(`SELECT id, value FROM ` + table + ` WHERE secret = 1`)
+		if err != nil {
+			return err
+		}
+		plaintext := make(map[int]string)
+		for rows.Next() {
+			var id int
+			var value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			if !isEncrypted(value) {
+				plaintext[id] = value
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, value := range plaintext {
+			encrypted, err := encrypt(value)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE `+table+` SET value = ? WHERE id = ?`, encrypted, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
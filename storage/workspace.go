@@ -0,0 +1,610 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// workspaceVersion is the version of the Workspace file format.
+const workspaceVersion = 1
+
+// Workspace is the JSON file format that moves the collections, saved
+// requests, variables, environments and settings of one installation to
+// another. The history is not part of it; see ExportHistory.
+type Workspace struct {
+	Version     int           `json:"version"`
+	Collections []*Collection `json:"collections"`
+	// Requests refer to Collections by the IDs they have in the file
+	Requests     []*SavedRequest         `json:"requests"`
+	Variables    []Variable              `json:"variables"`
+	Environments []*WorkspaceEnvironment `json:"environments"`
+	Preferences  map[string]string       `json:"preferences"`
+}
+
+// WorkspaceEnvironment is an environment in a Workspace, with the ID the
+// active_environment preference refers to it by.
+type WorkspaceEnvironment struct {
+	ID int `json:"id"`
+	EnvironmentExport
+}
+
+// activeEnvironmentPreference holds the ID of the environment in use.
+const activeEnvironmentPreference = "active_environment"
+
+// Preferences that hold credentials, which go in a workspace file only with
+// the secrets
+const (
+	proxyPreference          = "proxy"
+	defaultHeadersPreference = "default_headers"
+)
+
+// workspacePreference reports whether a preference belongs in a workspace
+// file. The size of the window and the request last edited are of this
+// installation, the secrets key and OAuth tokens of this machine and user.
+func workspacePreference(key string) bool {
+	switch key {
+	case "window_width", "window_height", "last_url", "last_method":
+		return false
+	}
+	return !strings.HasPrefix(key, "secrets_") && !strings.HasPrefix(key, "oauth2_token:")
+}
+
+// withoutCredentials returns the value of the preference key without the
+// proxy user name and password or the Authorization and
+// Proxy-Authorization default headers, and how many of those it had.
+func withoutCredentials(key, value string) (string, int) {
+	switch key {
+	case proxyPreference:
+		var proxy map[string]any
+		if json.Unmarshal([]byte(value), &proxy) != nil {
+			return value, 0
+		}
+		_, hasUsername := proxy["username"]
+		_, hasPassword := proxy["password"]
+		if !hasUsername && !hasPassword {
+			return value, 0
+		}
+		delete(proxy, "username")
+		delete(proxy, "password")
+		data, _ := json.Marshal(proxy)
+		return string(data), 1
+	case defaultHeadersPreference:
+		var headers []storedHeader
+		if json.Unmarshal([]byte(value), &headers) != nil {
+			return value, 0
+		}
+		kept := []storedHeader{}
+		for _, header := range headers {
+			if !isCredentialHeader(header.Key) {
+				kept = append(kept, header)
+			}
+		}
+		if len(kept) == len(headers) {
+			return value, 0
+		}
+		data, _ := json.Marshal(kept)
+		return string(data), len(headers) - len(kept)
+	}
+	return value, 0
+}
+
+// withCredentials returns value, the preference key from a workspace file,
+// with the credentials of current, its value here, that the file has not
+// got: the proxy user name and password when the file's proxy is the same
+// host without them, and the Authorization default headers when it has
+// none. A file exported without the secrets then leaves them as they are.
+func withCredentials(key, value, current string) string {
+	switch key {
+	case proxyPreference:
+		var proxy, currentProxy map[string]any
+		if json.Unmarshal([]byte(value), &proxy) != nil || json.Unmarshal([]byte(current), &currentProxy) != nil {
+			return value
+		}
+		_, hasUsername := proxy["username"]
+		_, hasPassword := proxy["password"]
+		if hasUsername || hasPassword || proxy["host"] != currentProxy["host"] {
+			return value
+		}
+		for _, field := range []string{"username", "password"} {
+			if credential, ok := currentProxy[field]; ok {
+				proxy[field] = credential
+			}
+		}
+		data, _ := json.Marshal(proxy)
+		return string(data)
+	case defaultHeadersPreference:
+		var headers, currentHeaders []storedHeader
+		if json.Unmarshal([]byte(value), &headers) != nil || json.Unmarshal([]byte(current), &currentHeaders) != nil {
+			return value
+		}
+		for _, header := range headers {
+			if isCredentialHeader(header.Key) {
+				return value
+			}
+		}
+		kept := len(headers)
+		for _, header := range currentHeaders {
+			if isCredentialHeader(header.Key) {
+				headers = append(headers, header)
+			}
+		}
+		if len(headers) == kept {
+			return value
+		}
+		data, _ := json.Marshal(headers)
+		return string(data)
+	}
+	return value
+}
+
+func isCredentialHeader(name string) bool {
+	return strings.EqualFold(name, "Authorization") || strings.EqualFold(name, "Proxy-Authorization")
+}
+
+// authCredentialFields are the fields of a saved request's auth that hold
+// a credential: the basic password, the bearer token, the OAuth 2.0 client
+// secret and the HMAC secret.
+var authCredentialFields = []string{"password", "token", "client_secret", "secret"}
+
+// isVariableReference reports whether value is only a {{variable}}, which
+// names a credential without holding it.
+func isVariableReference(value string) bool {
+	name, ok := strings.CutPrefix(strings.TrimSpace(value), "{{")
+	if !ok {
+		return false
+	}
+	name, ok = strings.CutSuffix(name, "}}")
+	return ok && name != "" && !strings.Contains(name, "{{") && !strings.Contains(name, "}}")
+}
+
+// requestWithoutCredentials returns the headers and auth of a saved request
+// without the credentials written into them: the Authorization and
+// Proxy-Authorization headers and the credential fields of the auth, unless
+// they are a {{variable}}, and how many of those it had.
+func requestWithoutCredentials(headers, auth string) (string, string, int) {
+	count := 0
+	var rows []storedHeader
+	if json.Unmarshal([]byte(headers), &rows) == nil {
+		kept := []storedHeader{}
+		for _, row := range rows {
+			if !isCredentialHeader(row.Key) || isVariableReference(row.Value) {
+				kept = append(kept, row)
+			}
+		}
+		if len(kept) < len(rows) {
+			count += len(rows) - len(kept)
+			data, _ := json.Marshal(kept)
+			headers = string(data)
+		}
+	}
+
+	var config map[string]any
+	if json.Unmarshal([]byte(auth), &config) == nil {
+		stripped := 0
+		for _, field := range authCredentialFields {
+			if value, ok := config[field].(string); ok && value != "" && !isVariableReference(value) {
+				delete(config, field)
+				stripped++
+			}
+		}
+		if stripped > 0 {
+			count += stripped
+			data, _ := json.Marshal(config)
+			auth = string(data)
+		}
+	}
+	return headers, auth, count
+}
+
+// requestWithCredentials returns the headers and auth of a saved request
+// from a workspace file with the credentials of the same request here,
+// currentHeaders and currentAuth, that the file has not got: the
+// Authorization headers when it has none, and the credential fields of an
+// auth of the same type.
+func requestWithCredentials(headers, auth, currentHeaders, currentAuth string) (string, string) {
+	var rows, currentRows []storedHeader
+	if json.Unmarshal([]byte(headers), &rows) == nil && json.Unmarshal([]byte(currentHeaders), &currentRows) == nil &&
+		!slices.ContainsFunc(rows, func(row storedHeader) bool { return isCredentialHeader(row.Key) }) {
+		kept := len(rows)
+		for _, row := range currentRows {
+			if isCredentialHeader(row.Key) {
+				rows = append(rows, row)
+			}
+		}
+		if len(rows) > kept {
+			data, _ := json.Marshal(rows)
+			headers = string(data)
+		}
+	}
+
+	var config, currentConfig map[string]any
+	if json.Unmarshal([]byte(auth), &config) != nil || json.Unmarshal([]byte(currentAuth), &currentConfig) != nil ||
+		config["type"] != currentConfig["type"] {
+		return headers, auth
+	}
+	restored := false
+	for _, field := range authCredentialFields {
+		if _, ok := config[field]; ok {
+			continue
+		}
+		if credential, ok := currentConfig[field]; ok {
+			config[field] = credential
+			restored = true
+		}
+	}
+	if restored {
+		data, _ := json.Marshal(config)
+		auth = string(data)
+	}
+	return headers, auth
+}
+
+// CountWorkspaceCredentials counts the proxy credentials, the Authorization
+// default headers and the credentials written into saved requests, which a
+// workspace file holds only with the secrets.
+func (db *DB) CountWorkspaceCredentials() (int, error) {
+	preferences, err := db.GetAllPreferences()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for key, value := range preferences {
+		_, credentials := withoutCredentials(key, value)
+		count += credentials
+	}
+
+	rows, err := db.Query("SELECT headers, auth FROM saved_requests")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var headers, auth string
+		if err := rows.Scan(&headers, &auth); err != nil {
+			return 0, err
+		}
+		_, _, credentials := requestWithoutCredentials(headers, auth)
+		count += credentials
+	}
+	return count, rows.Err()
+}
+
+// ExportWorkspace writes the collections, saved requests, variables,
+// environments and preferences to a JSON file. Secret variables, the proxy
+// credentials, the Authorization default headers and the credentials in
+// saved requests are left out unless decrypt is given, in which case they
+// are written in plaintext.
+func (db *DB) ExportWorkspace(filepath string, decrypt func(string) (string, error)) error {
+	workspace := Workspace{
+		Version:      workspaceVersion,
+		Collections:  []*Collection{},
+		Requests:     []*SavedRequest{},
+		Variables:    []Variable{},
+		Environments: []*WorkspaceEnvironment{},
+		Preferences:  map[string]string{},
+	}
+
+	collections, err := db.GetCollections()
+	if err != nil {
+		return err
+	}
+	workspace.Collections = append(workspace.Collections, collections...)
+	// Those in no collection come first, then each collection's
+	for _, collection := range append([]*Collection{nil}, collections...) {
+		var collectionID *int
+		if collection != nil {
+			collectionID = &collection.ID
+		}
+		requests, err := db.GetSavedRequests(collectionID)
+		if err != nil {
+			return err
+		}
+		if decrypt == nil {
+			for _, req := range requests {
+				req.Headers, req.Auth, _ = requestWithoutCredentials(req.Headers, req.Auth)
+			}
+		}
+		workspace.Requests = append(workspace.Requests, requests...)
+	}
+
+	exported := func(variables []*Variable) ([]Variable, error) {
+		result := []Variable{}
+		for _, variable := range variables {
+			value := variable.Value
+			if variable.Secret {
+				if decrypt == nil {
+					continue
+				}
+				var err error
+				if value, err = decrypt(value); err != nil {
+					return nil, err
+				}
+			}
+			result = append(result, Variable{Name: variable.Name, Value: value, Secret: variable.Secret})
+		}
+		return result, nil
+	}
+
+	variables, err := db.GetVariables()
+	if err != nil {
+		return err
+	}
+	if workspace.Variables, err = exported(variables); err != nil {
+		return err
+	}
+
+	environments, err := db.GetEnvironments()
+	if err != nil {
+		return err
+	}
+	for _, environment := range environments {
+		variables, err := db.GetEnvironmentVariables(environment.ID)
+		if err != nil {
+			return err
+		}
+		export := &WorkspaceEnvironment{ID: environment.ID, EnvironmentExport: EnvironmentExport{Name: environment.Name}}
+		if export.Variables, err = exported(variables); err != nil {
+			return err
+		}
+		workspace.Environments = append(workspace.Environments, export)
+	}
+
+	preferences, err := db.GetAllPreferences()
+	if err != nil {
+		return err
+	}
+	for key, value := range preferences {
+		if !workspacePreference(key) {
+			continue
+		}
+		if decrypt == nil {
+			value, _ = withoutCredentials(key, value)
+		}
+		workspace.Preferences[key] = value
+	}
+
+	data, err := json.MarshalIndent(workspace, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath, data)
+}
+
+// ImportWorkspace reads an exported workspace, all or nothing, and returns
+// it. With replace, the collections, saved requests, variables and
+// environments here are deleted first; otherwise the file is merged in,
+// updating the collections, environments and variables with the names of
+// those in the file and the requests with the same name in the same
+// collection. Preferences and requests in the file are set either way,
+// keeping the credentials here that the file was exported without. Secret values in the
+// file are stored through encrypt.
+func (db *DB) ImportWorkspace(filepath string, replace bool, encrypt func(string) (string, error)) (*Workspace, error) {
+	data, err := readFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var workspace Workspace
+	if err := json.Unmarshal(data, &workspace); err != nil {
+		return nil, err
+	}
+	if workspace.Version == 0 {
+		return nil, errors.New("not a workspace file")
+	}
+	if workspace.Version > workspaceVersion {
+		return nil, fmt.Errorf("the workspace file is of version %d, newer than this version of Golem reads", workspace.Version)
+	}
+
+	// Encrypted before anything is changed, as encrypt may need the user to
+	// unlock the secrets first
+	encryptSecrets := func(variables []Variable) error {
+		for i := range variables {
+			if variables[i].Secret {
+				var err error
+				if variables[i].Value, err = encrypt(variables[i].Value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := encryptSecrets(workspace.Variables); err != nil {
+		return nil, err
+	}
+	for _, environment := range workspace.Environments {
+		if err := encryptSecrets(environment.Variables); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		// Foreign keys are not enforced, so nothing cascades
+		for _, table := range []string{"saved_requests", "collections", "environment_variables", "environments", "variables"} {
+			if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec("UPDATE request_history SET collection_id = NULL"); err != nil {
+			return nil, err
+		}
+	}
+
+	collectionIDs, err := importCollections(tx, workspace.Collections)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range workspace.Requests {
+		if req.CollectionID != nil {
+			if id, ok := collectionIDs[*req.CollectionID]; ok {
+				req.CollectionID = &id
+			} else {
+				req.CollectionID = nil
+			}
+		}
+		if err := importSavedRequest(tx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, variable := range workspace.Variables {
+		if _, err := tx.Exec(
+			`INSERT INTO variables (name, value, secret) VALUES (?, ?, ?)
+			 ON CONFLICT (name) DO UPDATE SET value = excluded.value, secret = excluded.secret`,
+			variable.Name, variable.Value, variable.Secret,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	environmentIDs, err := importEnvironments(tx, workspace.Environments)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range workspace.Preferences {
+		if !workspacePreference(key) {
+			continue
+		}
+		if key == activeEnvironmentPreference {
+			// Merging keeps the environment in use here; replacing selects
+			// the file's, or none if the file has not got it
+			if !replace {
+				continue
+			}
+			id, _ := strconv.Atoi(value)
+			value = strconv.Itoa(environmentIDs[id])
+		}
+		if key == proxyPreference || key == defaultHeadersPreference {
+			var current string
+			err := tx.QueryRow("SELECT value FROM preferences WHERE key = ?", key).Scan(&current)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			value = withCredentials(key, value, current)
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO preferences (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+			 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`,
+			key, value,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// importCollections adds the collections of a workspace file, reusing those
+// with the same name, and returns the IDs they have here by their IDs in the
+// file.
+func importCollections(tx *sql.Tx, collections []*Collection) (map[int]int, error) {
+	ids := map[int]int{}
+	for _, collection := range collections {
+		var id int
+		err := tx.QueryRow("SELECT id FROM collections WHERE name = ? ORDER BY id LIMIT 1", collection.Name).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			result, err := tx.Exec(
+				"INSERT INTO collections (name, description, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)",
+				collection.Name, collection.Description,
+			)
+			if err != nil {
+				return nil, err
+			}
+			newID, err := result.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+			id = int(newID)
+		case err != nil:
+			return nil, err
+		default:
+			if _, err := tx.Exec("UPDATE collections SET description = ? WHERE id = ?", collection.Description, id); err != nil {
+				return nil, err
+			}
+		}
+		ids[collection.ID] = id
+	}
+	return ids, nil
+}
+
+// importSavedRequest adds req, or updates the request with its name in its
+// collection in place so that it keeps its ID and the credentials the file
+// has not got, and sets the monitor it has in the file.
+func importSavedRequest(tx *sql.Tx, req *SavedRequest) error {
+	var id int
+	var headers, auth string
+	err := tx.QueryRow(
+		"SELECT id, headers, auth FROM saved_requests WHERE name = ? AND collection_id IS ? ORDER BY id LIMIT 1",
+		req.Name, req.CollectionID,
+	).Scan(&id, &headers, &auth)
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.Exec(insertSavedRequestQuery, savedRequestArgs(req)...)
+		if err != nil {
+			return err
+		}
+		newID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id = int(newID)
+	case err != nil:
+		return err
+	default:
+		req.Headers, req.Auth = requestWithCredentials(req.Headers, req.Auth, headers, auth)
+		if _, err := tx.Exec(updateSavedRequestQuery, append(savedRequestArgs(req), id)...); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec("UPDATE saved_requests SET monitor = ? WHERE id = ?", req.Monitor, id)
+	req.ID = id
+	return err
+}
+
+// importEnvironments adds the environments of a workspace file, merging
+// their variables into those with the same name, and returns the IDs they
+// have here by their IDs in the file.
+func importEnvironments(tx *sql.Tx, environments []*WorkspaceEnvironment) (map[int]int, error) {
+	ids := map[int]int{}
+	for _, environment := range environments {
+		var id int
+		err := tx.QueryRow("SELECT id FROM environments WHERE name = ?", environment.Name).Scan(&id)
+		if err == sql.ErrNoRows {
+			result, err := tx.Exec("INSERT INTO environments (name, created_at) VALUES (?, CURRENT_TIMESTAMP)", environment.Name)
+			if err != nil {
+				return nil, err
+			}
+			newID, err := result.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+			id = int(newID)
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, variable := range environment.Variables {
+			if _, err := tx.Exec(setEnvironmentVariableQuery, id, variable.Name, variable.Value, variable.Secret); err != nil {
+				return nil, err
+			}
+		}
+		ids[environment.ID] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWorkspaceCredentials(t *testing.T) {
+	const (
+		proxy   = `{"mode":"custom","host":"proxy.example.com:3128","username":"me","password":"p"}`
+		headers = `[{"key":"User-Agent","value":"golem"},{"key":"authorization","value":"Bearer t"}]`
+	)
+	db := newTestDB(t)
+	for key, value := range map[string]string{proxyPreference: proxy, defaultHeadersPreference: headers} {
+		if err := db.SetPreference(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count, err := db.CountWorkspaceCredentials(); err != nil || count != 2 {
+		t.Fatalf("counted %d credentials (%v), want 2", count, err)
+	}
+
+	exported := func(decrypt func(string) (string, error)) (string, map[string]string) {
+		path := filepath.Join(t.TempDir(), "workspace.json")
+		if err := db.ExportWorkspace(path, decrypt); err != nil {
+			t.Fatal(err)
+		}
+		data, err := readFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var workspace Workspace
+		if err := json.Unmarshal(data, &workspace); err != nil {
+			t.Fatal(err)
+		}
+		return path, workspace.Preferences
+	}
+
+	withoutPath, without := exported(nil)
+	if got, want := without[proxyPreference], `{"host":"proxy.example.com:3128","mode":"custom"}`; got != want {
+		t.Errorf("proxy without the secrets: got %s, want %s", got, want)
+	}
+	if got, want := without[defaultHeadersPreference], `[{"key":"User-Agent","value":"golem"}]`; got != want {
+		t.Errorf("default headers without the secrets: got %s, want %s", got, want)
+	}
+	_, with := exported(func(value string) (string, error) { return value, nil })
+	if with[proxyPreference] != proxy || with[defaultHeadersPreference] != headers {
+		t.Errorf("with the secrets: got %s and %s", with[proxyPreference], with[defaultHeadersPreference])
+	}
+
+	// A file without them leaves the credentials here as they are
+	other := newTestDB(t)
+	if err := other.SetPreference(proxyPreference, `{"mode":"custom","host":"proxy.example.com:3128","username":"you","password":"q"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SetPreference(defaultHeadersPreference, `[{"key":"Authorization","value":"Basic eW91"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.ImportWorkspace(withoutPath, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	preferences, err := other.GetAllPreferences()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := preferences[proxyPreference], `{"host":"proxy.example.com:3128","mode":"custom","password":"q","username":"you"}`; got != want {
+		t.Errorf("imported proxy: got %s, want %s", got, want)
+	}
+	if got, want := preferences[defaultHeadersPreference], `[{"key":"User-Agent","value":"golem"},{"key":"Authorization","value":"Basic eW91"}]`; got != want {
+		t.Errorf("imported default headers: got %s, want %s", got, want)
+	}
+
+	// but not those of another proxy
+	if err := other.SetPreference(proxyPreference, `{"mode":"custom","host":"other.example.com:8080","username":"you","password":"q"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.ImportWorkspace(withoutPath, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if preference, err := other.GetPreference(proxyPreference); err != nil || preference.Value != without[proxyPreference] {
+		t.Errorf("imported proxy in place of another: got %+v (%v), want %s", preference, err, without[proxyPreference])
+	}
+}
+
+func TestWorkspaceRoundTrip(t *testing.T) {
+	source := newTestDB(t)
+	users, err := source.CreateCollection("Users", "The users API")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := source.CreateCollection("Orders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []*SavedRequest{
+		{
+			Name: "List users", Method: "GET", URL: "{{host}}/users", CollectionID: &users.ID,
+			Headers: `[{"key":"Authorization","value":"Bearer literal"},{"key":"Accept","value":"application/json"}]`,
+			Auth:    `{"type":"bearer","token":"t0ken"}`,
+		},
+		{
+			Name: "Get user", Method: "GET", URL: "{{host}}/users/1", CollectionID: &users.ID,
+			Headers: `[{"key":"Authorization","value":"{{auth}}"}]`,
+			Auth:    `{"type":"hmac","secret":"{{hmacSecret}}","algorithm":"sha256"}`,
+		},
+		{Name: "List orders", Method: "GET", URL: "{{host}}/orders", CollectionID: &orders.ID, Auth: `{"type":"basic","username":"ada","password":"pw"}`},
+		{Name: "Ping", Method: "GET", URL: "{{host}}/ping"},
+	} {
+		if err := source.SaveRequest(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.SetVariable(&Variable{Name: "host", Value: "https://api.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.SetVariable(&Variable{Name: "apiKey", Value: "encrypted", Secret: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.CreateEnvironment("Dev"); err != nil {
+		t.Fatal(err)
+	}
+	staging, err := source.CreateEnvironment("Staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, variable := range []*Variable{{Name: "host", Value: "https://staging.example.com"}, {Name: "password", Value: "encrypted", Secret: true}} {
+		if err := source.SetEnvironmentVariable(staging.ID, variable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.SetPreference(activeEnvironmentPreference, strconv.Itoa(staging.ID)); err != nil {
+		t.Fatal(err)
+	}
+	// 3 in "List users", 1 in "List orders"; the {{variables}} are not
+	// credentials
+	if count, err := source.CountWorkspaceCredentials(); err != nil || count != 3 {
+		t.Fatalf("counted %d credentials (%v), want 3", count, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "workspace.json")
+	if err := source.ExportWorkspace(path, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// target has its own collections, environments and history, the
+	// request "List users" with its own credentials, and Users, Orders and
+	// Staging come to other IDs than those in the file
+	target := func(t *testing.T) *DB {
+		db := newTestDB(t)
+		local, err := db.CreateCollection("Local", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mine, err := db.CreateCollection("Users", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range []*SavedRequest{
+			{Name: "Mine", Method: "GET", URL: "https://local.test", CollectionID: &local.ID},
+			{
+				Name: "List users", Method: "GET", URL: "https://old.example.com/users", CollectionID: &mine.ID,
+				Headers: `[{"key":"Authorization","value":"Bearer mine"}]`,
+				Auth:    `{"type":"bearer","token":"my-token"}`,
+			},
+		} {
+			if err := db.SaveRequest(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range []string{"Local", "Staging"} {
+			if _, err := db.CreateEnvironment(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.SetPreference(activeEnvironmentPreference, "1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveRequestHistory(&RequestHistory{Method: "GET", URL: "https://local.test", CollectionID: &local.ID}); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+
+	collectionIDs := func(t *testing.T, db *DB) map[string]int {
+		collections, err := db.GetCollections()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := map[string]int{}
+		for _, collection := range collections {
+			ids[collection.Name] = collection.ID
+		}
+		return ids
+	}
+	requestsOf := func(t *testing.T, db *DB, collectionID int) map[string]*SavedRequest {
+		requests, err := db.GetSavedRequests(&collectionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := map[string]*SavedRequest{}
+		for _, req := range requests {
+			byName[req.Name] = req
+		}
+		return byName
+	}
+
+	t.Run("merge", func(t *testing.T) {
+		db := target(t)
+		if _, err := db.ImportWorkspace(path, false, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		ids := collectionIDs(t, db)
+		if want := map[string]int{"Local": 1, "Users": 2, "Orders": 3}; !reflect.DeepEqual(ids, want) {
+			t.Fatalf("collections = %v, want %v", ids, want)
+		}
+		if ids["Users"] == users.ID || ids["Orders"] == orders.ID {
+			t.Fatalf("collections = %v, want other IDs than Users %d and Orders %d", ids, users.ID, orders.ID)
+		}
+		userRequests := requestsOf(t, db, ids["Users"])
+		if len(userRequests) != 2 {
+			t.Fatalf("requests in Users = %v, want List users and Get user", userRequests)
+		}
+		// Updated from the file, keeping the credentials it was exported
+		// without
+		listUsers := userRequests["List users"]
+		if listUsers.URL != "{{host}}/users" || *listUsers.CollectionID != ids["Users"] {
+			t.Errorf("List users = %+v", listUsers)
+		}
+		if got, want := listUsers.Headers, `[{"key":"Accept","value":"application/json"},{"key":"Authorization","value":"Bearer mine"}]`; got != want {
+			t.Errorf("List users headers = %s, want %s", got, want)
+		}
+		if got, want := listUsers.Auth, `{"token":"my-token","type":"bearer"}`; got != want {
+			t.Errorf("List users auth = %s, want %s", got, want)
+		}
+		getUser := userRequests["Get user"]
+		if got, want := getUser.Auth, `{"type":"hmac","secret":"{{hmacSecret}}","algorithm":"sha256"}`; got != want {
+			t.Errorf("Get user auth = %s, want %s", got, want)
+		}
+		if got, want := getUser.Headers, `[{"key":"Authorization","value":"{{auth}}"}]`; got != want {
+			t.Errorf("Get user headers = %s, want %s", got, want)
+		}
+		listOrders := requestsOf(t, db, ids["Orders"])["List orders"]
+		if listOrders == nil || *listOrders.CollectionID != ids["Orders"] {
+			t.Fatalf("List orders = %+v, want it in Orders", listOrders)
+		}
+		if got, want := listOrders.Auth, `{"type":"basic","username":"ada"}`; got != want {
+			t.Errorf("List orders auth = %s, want %s", got, want)
+		}
+		if mine := requestsOf(t, db, ids["Local"]); mine["Mine"] == nil {
+			t.Errorf("the request in Local was not kept")
+		}
+
+		history, err := db.GetRequestHistory(10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].CollectionID == nil || *history[0].CollectionID != ids["Local"] {
+			t.Errorf("history = %+v, want it still in Local", history)
+		}
+		if preference, err := db.GetPreference(activeEnvironmentPreference); err != nil || preference.Value != "1" {
+			t.Errorf("active environment = %+v (%v), want 1, kept when merging", preference, err)
+		}
+
+		variables, err := db.GetVariables()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(variables) != 1 || variables[0].Name != "host" {
+			t.Errorf("variables = %+v, want only host without the secret", variables)
+		}
+		environments, err := db.GetEnvironments()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(environments) != 3 {
+			t.Fatalf("environments = %+v, want Local, Staging and Dev", environments)
+		}
+		stagingVariables, err := db.GetEnvironmentVariables(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stagingVariables) != 1 || stagingVariables[0].Name != "host" {
+			t.Errorf("Staging variables = %+v, want only host without the secret", stagingVariables)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		db := target(t)
+		if _, err := db.ImportWorkspace(path, true, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		ids := collectionIDs(t, db)
+		if len(ids) != 2 || ids["Local"] != 0 || ids["Users"] == users.ID || ids["Orders"] == orders.ID {
+			t.Fatalf("collections = %v, want only Users and Orders, at other IDs than %d and %d", ids, users.ID, orders.ID)
+		}
+		for name, collection := range map[string]string{"List users": "Users", "Get user": "Users", "List orders": "Orders"} {
+			if req := requestsOf(t, db, ids[collection])[name]; req == nil || *req.CollectionID != ids[collection] {
+				t.Errorf("%s = %+v, want it in %s", name, req, collection)
+			}
+		}
+		// Nothing here to keep the credentials of
+		listUsers := requestsOf(t, db, ids["Users"])["List users"]
+		if got, want := listUsers.Auth, `{"type":"bearer"}`; got != want {
+			t.Errorf("List users auth = %s, want %s", got, want)
+		}
+		ungrouped, err := db.GetSavedRequests(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ungrouped) != 1 || ungrouped[0].Name != "Ping" {
+			t.Errorf("requests in no collection = %+v, want Ping", ungrouped)
+		}
+
+		history, err := db.GetRequestHistory(10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].CollectionID != nil {
+			t.Errorf("history = %+v, want it in no collection", history)
+		}
+
+		environments, err := db.GetEnvironments()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stagingID := 0
+		for _, environment := range environments {
+			if environment.Name == "Staging" {
+				stagingID = environment.ID
+			}
+		}
+		if stagingID == 0 || stagingID == staging.ID {
+			t.Fatalf("environments = %+v, want Staging at another ID than %d", environments, staging.ID)
+		}
+		if preference, err := db.GetPreference(activeEnvironmentPreference); err != nil || preference.Value != strconv.Itoa(stagingID) {
+			t.Errorf("active environment = %+v (%v), want %d", preference, err, stagingID)
+		}
+	})
+}
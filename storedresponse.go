@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// storedRequest rebuilds the request a history entry recorded, as sent, for
+// copying the exchange it was part of.
+func storedRequest(item *storage.RequestHistory) *RequestInfo {
+	request := &RequestInfo{
+		Method:   item.Method,
+		URL:      item.URL,
+		BodyType: item.BodyType,
+		Body:     item.Body,
+	}
+	if item.ResolvedURL != "" {
+		request.URL = item.ResolvedURL
+	}
+	if item.Headers != "" {
+		if err := json.Unmarshal([]byte(item.Headers), &request.Headers); err != nil {
+			fmt.Printf("Error parsing stored headers: %v\n", err)
+		}
+	}
+	if item.BodyType == ui.BodyTypeMultipart {
+		if err := json.Unmarshal([]byte(item.Body), &request.FormFields); err != nil {
+			fmt.Printf("Error parsing stored form fields: %v\n", err)
+		}
+		request.Body = ""
+	}
+	return request
+}
+
+// storedResponse rebuilds the response a history entry recorded, or returns
+// nil when the request failed without one.
+func storedResponse(item *storage.RequestHistory) *ResponseInfo {
+	if item.ResponseStatus == "" || item.ResponseStatus == "Error" {
+		return nil
+	}
+	response := &ResponseInfo{
+		Status:       item.ResponseStatus,
+		Proto:        item.Protocol,
+		Size:         item.ResponseSize,
+		WireSize:     item.ResponseSize,
+		ResponseTime: time.Duration(item.ResponseTimeMs) * time.Millisecond,
+		Partial:      item.Partial,
+		DownloadPath: item.DownloadPath,
+		RemoteAddr:   item.RemoteAddr,
+		Charset:      item.ResponseCharset,
+	}
+	code, _, _ := strings.Cut(item.ResponseStatus, " ")
+	response.StatusCode, _ = strconv.Atoi(code)
+
+	if item.ResponseHeaders != "" {
+		if err := json.Unmarshal([]byte(item.ResponseHeaders), &response.Headers); err != nil {
+			fmt.Printf("Error parsing stored response headers: %v\n", err)
+		}
+	}
+	if item.DownloadPath == "" {
+		// The body is stored as received when it declares a charset
+		response.RawBody = []byte(item.ResponseBody)
+		response.Body = decodeBodyText(response.RawBody, item.ResponseCharset)
+	}
+	if item.Timing != "" {
+		var timing requestTiming
+		if err := json.Unmarshal([]byte(item.Timing), &timing); err != nil {
+			fmt.Printf("Error parsing stored timing: %v\n", err)
+		} else {
+			response.Timing = &timing
+		}
+	}
+	if item.TLS != "" {
+		var tls ui.TLSInfo
+		if err := json.Unmarshal([]byte(item.TLS), &tls); err != nil {
+			fmt.Printf("Error parsing stored TLS details: %v\n", err)
+		} else {
+			response.TLS = &tls
+		}
+	}
+	return response
+}
+
+// storedSizes returns the size breakdown a history entry recorded, or
+// works it out from response for entries recorded before there was one.
+func storedSizes(item *storage.RequestHistory, response *ResponseInfo) *ui.SizeBreakdown {
+	if item.Sizes != "" {
+		var sizes ui.SizeBreakdown
+		if err := json.Unmarshal([]byte(item.Sizes), &sizes); err == nil {
+			return &sizes
+		}
+	}
+	return responseSizes(response)
+}
+
+// describeStored is the banner over a response shown from the history,
+// noting when only the start of its body was stored.
+func describeStored(item *storage.RequestHistory) string {
+	text := "Viewing stored response from " + item.Timestamp.Local().Format("2 Jan 2006 15:04:05")
+	if item.DownloadPath == "" && !item.Partial && len(item.ResponseBody) < item.ResponseSize {
+		text += fmt.Sprintf(" (only %s of the %s body was stored)",
+			ui.FormatBytes(int64(len(item.ResponseBody))), ui.FormatBytes(int64(item.ResponseSize)))
+	}
+	return text
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// chunkReader passes each part of a body to onChunk as it is read, with the
+// bytes and parts read so far. Once ctx is cancelled reading ends as if the
+// body had, so what arrived before is kept; stopped tells it did.
+type chunkReader struct {
+	reader   io.Reader
+	ctx      context.Context
+	onChunk  func(chunk []byte, received int64, chunks int)
+	received int64
+	chunks   int
+	stopped  bool
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.received += int64(n)
+		r.chunks++
+		r.onChunk(p[:n], r.received, r.chunks)
+	}
+	if err != nil && err != io.EOF && errors.Is(r.ctx.Err(), context.Canceled) {
+		r.stopped = true
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// bodyStreamDelay is how often the parts of a body that arrived are shown.
+const bodyStreamDelay = 250 * time.Millisecond
+
+// bodyStream collects the parts of a body as they arrive on the sending
+// goroutine and passes them to show on the main thread, at most every
+// bodyStreamDelay so a body arriving in many small parts does not lay the
+// text out for each of them.
+type bodyStream struct {
+	show func(data []byte, received int64, chunks int)
+
+	mu        sync.Mutex
+	pending   []byte
+	received  int64
+	chunks    int
+	scheduled bool
+	closed    bool
+}
+
+// add is an OnBodyChunk.
+func (s *bodyStream) add(chunk []byte, received int64, chunks int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.pending = append(s.pending, chunk...)
+	s.received, s.chunks = received, chunks
+	if !s.scheduled {
+		s.scheduled = true
+		time.AfterFunc(bodyStreamDelay, func() {
+			fyne.Do(s.flush)
+		})
+	}
+}
+
+func (s *bodyStream) flush() {
+	s.mu.Lock()
+	data, received, chunks, closed := s.pending, s.received, s.chunks, s.closed
+	s.pending, s.scheduled = nil, false
+	s.mu.Unlock()
+	if !closed {
+		s.show(data, received, chunks)
+	}
+}
+
+// close stops showing parts, once the response is shown in full. It is
+// called on the main thread, so no part is shown after it.
+func (s *bodyStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.pending = nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"golem/ui"
+)
+
+// requestTiming is where the time of the final request went, after any
+// redirects. Phases that did not happen, such as DNS for an IP address or
+// everything before sending on a reused connection, are zero.
+type requestTiming struct {
+	DNS      time.Duration `json:"dns"`
+	Connect  time.Duration `json:"connect"`
+	TLS      time.Duration `json:"tls"`
+	Send     time.Duration `json:"send"`
+	Wait     time.Duration `json:"wait"`
+	Transfer time.Duration `json:"transfer"`
+	Total    time.Duration `json:"total"`
+	Reused   bool          `json:"reused"`
+}
+
+// timingTrace records the events of an httptrace.ClientTrace. The callbacks
+// may come from the transport's dialing goroutines, hence the lock.
+type timingTrace struct {
+	mu     sync.Mutex
+	events timingEvents
+}
+
+type timingEvents struct {
+	start                time.Time
+	dnsStart, dnsDone    time.Time
+	connectStart         time.Time
+	connectDone          time.Time
+	tlsStart, tlsDone    time.Time
+	gotConn, wrote       time.Time
+	firstByte, bodyEnded time.Time
+	reused               bool
+	remoteAddr           string
+}
+
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	record := func(at *time.Time) {
+		t.mu.Lock()
+		*at = time.Now()
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		// Every redirect starts over, so only the final request is kept
+		GetConn: func(string) {
+			t.mu.Lock()
+			t.events = timingEvents{start: time.Now()}
+			t.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { record(&t.events.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { record(&t.events.dnsDone) },
+		// With several addresses the connections are raced; the phase runs
+		// from the first attempt to the last to finish
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.events.connectStart.IsZero() {
+				t.events.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone:       func(string, string, error) { record(&t.events.connectDone) },
+		TLSHandshakeStart: func() { record(&t.events.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { record(&t.events.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.events.gotConn = time.Now()
+			t.events.reused = info.Reused
+			t.events.remoteAddr = info.Conn.RemoteAddr().String()
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&t.events.wrote) },
+		GotFirstResponseByte: func() { record(&t.events.firstByte) },
+	}
+}
+
+// bodyRead marks the end of the transfer.
+func (t *timingTrace) bodyRead() {
+	t.mu.Lock()
+	t.events.bodyEnded = time.Now()
+	t.mu.Unlock()
+}
+
+// remoteAddr is the IP address and port the final request went to; through
+// a proxy it is the proxy's.
+func (t *timingTrace) remoteAddr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.events.remoteAddr
+}
+
+func (t *timingTrace) timing() *requestTiming {
+	t.mu.Lock()
+	e := t.events
+	t.mu.Unlock()
+
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return &requestTiming{
+		DNS:      between(e.dnsStart, e.dnsDone),
+		Connect:  between(e.connectStart, e.connectDone),
+		TLS:      between(e.tlsStart, e.tlsDone),
+		Send:     between(e.gotConn, e.wrote),
+		Wait:     between(e.wrote, e.firstByte),
+		Transfer: between(e.firstByte, e.bodyEnded),
+		Total:    between(e.start, e.bodyEnded),
+		Reused:   e.reused,
+	}
+}
+
+// phases lays the timing out as a waterfall, each phase starting where the
+// one before it ended.
+func (t *requestTiming) phases() []ui.TimingPhase {
+	phases := []ui.TimingPhase{
+		{Name: "DNS lookup", Duration: t.DNS},
+		{Name: "TCP connect", Duration: t.Connect},
+		{Name: "TLS handshake", Duration: t.TLS},
+		{Name: "Request sent", Duration: t.Send},
+		{Name: "Waiting (TTFB)", Duration: t.Wait},
+		{Name: "Content transfer", Duration: t.Transfer},
+	}
+	var start time.Duration
+	for i := range phases {
+		phases[i].Start = start
+		start += phases[i].Duration
+	}
+	return phases
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golem/ui"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// tlsServerName returns the name sent in the TLS handshake for request:
+// the host of the URL, or of the Host header when SNIFromHost is set.
+func tlsServerName(request *RequestInfo) string {
+	if host, ok := findHeader(request.Headers, "Host"); ok && request.SNIFromHost && host != "" {
+		return hostOnly(host)
+	}
+	if target, err := url.Parse(request.URL); err == nil {
+		return target.Hostname()
+	}
+	return ""
+}
+
+// hostOnly strips the port from a host as written in a Host header.
+func hostOnly(host string) string {
+	return (&url.URL{Host: host}).Hostname()
+}
+
+// newTransport builds the transport for a single request from its proxy and
+// TLS settings.
+func newTransport(request *RequestInfo) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Once the default transport has been used its TLS config advertises h2,
+	// which would override the protocol choice below
+	transport.TLSClientConfig = nil
+	transport.TLSNextProto = nil
+
+	overrides := ui.EnabledPairs(request.HostOverrides)
+
+	// A SOCKS proxy is dialled through rather than handed to the transport,
+	// so host names can be resolved on either side of it
+	var socksDial func(ctx context.Context, network, addr string) (net.Conn, error)
+	switch request.Proxy.Mode {
+	case ui.ProxyModeNone:
+		transport.Proxy = nil
+	case ui.ProxyModeManual:
+		proxyURL, err := parseProxyURL(request.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		if isSOCKSProxy(proxyURL) {
+			transport.Proxy = nil
+			remoteDNS := request.Proxy.RemoteDNS || proxyURL.Scheme == "socks5h"
+			socksDial, err = newSOCKSDialer(proxyURL, remoteDNS, overrides, request.IPVersion)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	default:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	// The certificate is picked for the request's host; it is also offered
+	// to any host the request is redirected to
+	if target, err := url.Parse(request.URL); err == nil {
+		if cert, ok := matchClientCertificate(request.ClientCertificates, target.Hostname()); ok {
+			tlsCert, err := cert.Load()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig(transport).Certificates = []tls.Certificate{tlsCert}
+		}
+	}
+
+	// An explicit Accept-Encoding is decoded by executeRequest instead, so
+	// the compressed size can be reported
+	transport.DisableCompression = request.AcceptEncoding != ""
+
+	switch request.HTTPVersion {
+	case ui.HTTPVersionHTTP1:
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		transport.Protocols = &protocols
+	case ui.HTTPVersionHTTP2:
+		// Plain http:// URLs need HTTP/2 with prior knowledge (h2c)
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+	}
+
+	if len(request.CAFiles) > 0 {
+		pool, err := rootCAs(request.CAFiles, request.UseSystemCAs)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig(transport).RootCAs = pool
+	}
+
+	if request.InsecureSkipVerify {
+		tlsConfig(transport).InsecureSkipVerify = true
+	}
+
+	// The server name also goes to any host the request is redirected to
+	if _, ok := findHeader(request.Headers, "Host"); ok && request.SNIFromHost {
+		tlsConfig(transport).ServerName = tlsServerName(request)
+	}
+
+	// A Unix socket takes the place of the proxy, host overrides and IP
+	// version, since every connection goes to it
+	if socket := unixSocket(request); socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		return transport, nil
+	}
+
+	if socksDial != nil {
+		transport.DialContext = socksDial
+		return transport, nil
+	}
+
+	// Host overrides and a forced IP version only change where connections
+	// go; the Host header and TLS server name still come from the URL.
+	// Through an HTTP proxy they apply to the connection to the proxy
+	if len(overrides) > 0 || request.IPVersion != ui.IPVersionAny {
+		transport.DialContext = newDialer(overrides, request.IPVersion)
+	}
+
+	return transport, nil
+}
+
+// unixURLPrefix starts a URL naming a Unix socket and the path to request
+// on it, as in unix:///var/run/docker.sock:/v1.41/containers/json.
+const unixURLPrefix = "unix://"
+
+// splitUnixURL splits a unix:// URL into the socket path and an http:// URL
+// for the request path on it; ok is false for other URLs.
+func splitUnixURL(rawURL string) (socket, httpURL string, ok bool) {
+	rest, ok := strings.CutPrefix(rawURL, unixURLPrefix)
+	if !ok {
+		return "", "", false
+	}
+	socket, requestPath, _ := strings.Cut(rest, ":")
+	if !strings.HasPrefix(requestPath, "/") {
+		requestPath = "/" + requestPath
+	}
+	return socket, "http://localhost" + requestPath, true
+}
+
+// unixSocket returns the socket request is sent over, named by a unix://
+// URL or the Unix socket option, or "" when it connects to the URL's host.
+func unixSocket(request *RequestInfo) string {
+	if socket, _, ok := splitUnixURL(request.URL); ok {
+		return socket
+	}
+	return request.UnixSocket
+}
+
+// newDialer returns a dial function that sends connections for overridden
+// hosts to their override address and connects over network, tcp4 or tcp6,
+// unless it is ui.IPVersionAny.
+func newDialer(overrides []ui.KeyValue, network string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	return func(ctx context.Context, defaultNetwork, addr string) (net.Conn, error) {
+		if target, ok := overrideAddress(overrides, addr); ok {
+			addr = target
+		}
+		if network == ui.IPVersionAny {
+			return dialer.DialContext(ctx, defaultNetwork, addr)
+		}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, ipVersionError(ctx, err, network, addr)
+		}
+		return conn, nil
+	}
+}
+
+// ipVersionError explains a failure to connect over network, tcp4 or tcp6,
+// when the host has no address of that family.
+func ipVersionError(ctx context.Context, err error, network, addr string) error {
+	family := "IPv4"
+	if network == ui.IPVersion6 {
+		family = "IPv6"
+	}
+
+	// The resolver only asks for addresses of the family, so a host with
+	// only the other kind looks as if it did not exist
+	var addrErr *net.AddrError
+	var dnsErr *net.DNSError
+	if !errors.As(err, &addrErr) && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	if net.ParseIP(host) != nil {
+		return fmt.Errorf("%s is not an %s address", host, family)
+	}
+	ips, lookupErr := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if lookupErr == nil && len(ips) > 0 {
+		return fmt.Errorf("%s has no %s address, only %s", host, family, ips[0].IP)
+	}
+	return err
+}
+
+// findHostOverride returns the enabled override for host, matched without
+// regard to case.
+func findHostOverride(overrides []ui.KeyValue, host string) (ui.KeyValue, bool) {
+	for _, override := range overrides {
+		if !override.Disabled && strings.EqualFold(override.Key, host) {
+			return override, true
+		}
+	}
+	return ui.KeyValue{}, false
+}
+
+// overrideAddress rewrites addr, a host and port, when its host is
+// overridden. An override without a port keeps the original one.
+func overrideAddress(overrides []ui.KeyValue, addr string) (string, bool) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", false
+	}
+	override, ok := findHostOverride(overrides, host)
+	if !ok {
+		return "", false
+	}
+	target := strings.TrimSpace(override.Value)
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target, true
+	}
+	return net.JoinHostPort(strings.Trim(target, "[]"), port), true
+}
+
+// rootCAs builds the pool of trusted roots from the configured CA files,
+// optionally on top of the system roots.
+func rootCAs(files []string, includeSystem bool) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if includeSystem {
+		if systemPool, err := x509.SystemCertPool(); err == nil {
+			pool = systemPool
+		}
+	}
+
+	for _, file := range files {
+		certs, err := ui.LoadCAFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			pool.AddCert(cert)
+		}
+	}
+	return pool, nil
+}
+
+// skipTLSVerify resolves a request's TLS verification override against the
+// setting.
+func skipTLSVerify(override string, skipByDefault bool) bool {
+	switch override {
+	case ui.TLSVerifyOn:
+		return false
+	case ui.TLSVerifySkip:
+		return true
+	default:
+		return skipByDefault
+	}
+}
+
+func tlsConfig(transport *http.Transport) *tls.Config {
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	return transport.TLSClientConfig
+}
+
+// matchClientCertificate returns the first certificate whose host pattern
+// matches host. Patterns use shell-style wildcards such as *.example.com.
+func matchClientCertificate(certs []ui.ClientCertificate, host string) (ui.ClientCertificate, bool) {
+	host = strings.ToLower(host)
+	for _, cert := range certs {
+		if matched, _ := path.Match(strings.ToLower(cert.HostPattern), host); matched {
+			return cert, true
+		}
+	}
+	return ui.ClientCertificate{}, false
+}
+
+func parseProxyURL(config ui.ProxyConfig) (*url.URL, error) {
+	host := strings.TrimSpace(config.Host)
+	if host == "" {
+		return nil, errors.New("manual proxy is selected but no proxy host is set")
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+
+	proxyURL, err := url.Parse(host)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy address %q", config.Host)
+	}
+	if config.Username != "" {
+		proxyURL.User = url.UserPassword(config.Username, config.Password)
+	}
+	return proxyURL, nil
+}
+
+// usesProxy reports whether req will be sent through a proxy.
+func usesProxy(transport *http.Transport, req *http.Request) bool {
+	if transport.Proxy == nil {
+		return false
+	}
+	proxyURL, err := transport.Proxy(req)
+	return err == nil && proxyURL != nil
+}
+
+var errProxyAuth = errors.New("proxy authentication failed (407 Proxy Authentication Required); check the proxy username and password")
+
+// proxyError replaces the transport's terse errors for proxy failures, such as
+// a bare EOF when the proxy drops the connection.
+func proxyError(err error) error {
+	if strings.Contains(err.Error(), http.StatusText(http.StatusProxyAuthRequired)) {
+		return errProxyAuth
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return fmt.Errorf("could not connect to the proxy: %v", opErr.Err)
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errors.New("the proxy closed the connection unexpectedly; check the proxy address and credentials")
+	}
+	return err
+}
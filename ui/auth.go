@@ -0,0 +1,420 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	AuthTypeNone   = ""
+	AuthTypeBasic  = "basic"
+	AuthTypeBearer = "bearer"
+	AuthTypeOAuth2 = "oauth2"
+	AuthTypeHMAC   = "hmac"
+)
+
+// The hash algorithms and signature encodings offered for HMAC signing.
+const (
+	HMACSHA256 = "SHA-256"
+	HMACSHA1   = "SHA-1"
+	HMACSHA512 = "SHA-512"
+
+	SignatureHex    = "hex"
+	SignatureBase64 = "base64"
+)
+
+// defaultStringToSign is the string-to-sign template of a new HMAC config.
+const defaultStringToSign = "{method}\n{path}\n{timestamp}\n{nonce}\n{body}"
+
+const defaultRedirectPort = 8765
+
+var authTypeLabels = []struct {
+	authType string
+	label    string
+}{
+	{AuthTypeNone, "No Auth"},
+	{AuthTypeBasic, "Basic"},
+	{AuthTypeBearer, "Bearer Token"},
+	{AuthTypeOAuth2, "OAuth 2.0 (Authorization Code)"},
+	{AuthTypeHMAC, "HMAC Signature"},
+}
+
+type AuthConfig struct {
+	Type         string `json:"type,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	SavePassword bool   `json:"save_password,omitempty"`
+	Token        string `json:"token,omitempty"`
+	AuthURL      string `json:"auth_url,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	RedirectPort int    `json:"redirect_port,omitempty"`
+
+	// HMAC signing. The secret is usually a {{variable}} marked secret, so
+	// only the reference is stored with the request.
+	Algorithm       string `json:"algorithm,omitempty"`
+	StringToSign    string `json:"string_to_sign,omitempty"`
+	Secret          string `json:"secret,omitempty"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+	Encoding        string `json:"encoding,omitempty"`
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+	NonceHeader     string `json:"nonce_header,omitempty"`
+}
+
+// Header returns the request header the auth type sets.
+func (c AuthConfig) Header() string {
+	switch c.Type {
+	case AuthTypeNone:
+		return ""
+	case AuthTypeHMAC:
+		return c.SignatureHeader
+	default:
+		return "Authorization"
+	}
+}
+
+// ForStorage returns a copy of the config that is safe to persist, dropping
+// the password, client secret and a signing secret typed in directly unless
+// the user opted in to saving them.
+// OAuth2 access tokens are kept in preferences rather than with the request.
+func (c AuthConfig) ForStorage() AuthConfig {
+	if !c.SavePassword {
+		c.Password = ""
+		c.ClientSecret = ""
+		if !isVariableReference(c.Secret) {
+			c.Secret = ""
+		}
+	}
+	if c.Type == AuthTypeOAuth2 {
+		c.Token = ""
+	}
+	return c
+}
+
+// isVariableReference reports whether text is a single {{variable}}.
+func isVariableReference(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "{{") && strings.HasSuffix(text, "}}") && strings.Count(text, "{{") == 1
+}
+
+type AuthEditor struct {
+	container         *fyne.Container
+	typeSelect        *widget.Select
+	usernameEntry     *widget.Entry
+	passwordEntry     *widget.Entry
+	savePasswordCheck *widget.Check
+	tokenEntry        *widget.Entry
+	authURLEntry      *widget.Entry
+	tokenURLEntry     *widget.Entry
+	clientIDEntry     *widget.Entry
+	clientSecretEntry *widget.Entry
+	scopeEntry        *widget.Entry
+	redirectPortEntry *widget.Entry
+	saveSecretCheck   *widget.Check
+	tokenStatusLabel  *widget.Label
+	algorithmSelect   *widget.Select
+	stringToSignEntry *widget.Entry
+	secretEntry       *widget.Entry
+	saveHMACCheck     *widget.Check
+	signatureEntry    *widget.Entry
+	encodingSelect    *widget.Select
+	timestampEntry    *widget.Entry
+	nonceEntry        *widget.Entry
+	basicForm         *fyne.Container
+	bearerForm        *fyne.Container
+	oauth2Form        *fyne.Container
+	hmacForm          *fyne.Container
+	overrideWarning   *widget.Label
+	OnChanged         func()
+	OnGetToken        func(config AuthConfig)
+	// OnDecodeJWT shows the bearer token of the request decoded
+	OnDecodeJWT func()
+}
+
+func NewAuthEditor() *AuthEditor {
+	a := &AuthEditor{}
+	a.createUI()
+	return a
+}
+
+func (a *AuthEditor) createUI() {
+	a.usernameEntry = widget.NewEntry()
+	a.usernameEntry.SetPlaceHolder("Username")
+
+	a.passwordEntry = widget.NewPasswordEntry()
+	a.passwordEntry.SetPlaceHolder("Password")
+
+	a.savePasswordCheck = widget.NewCheck("Save password with request", nil)
+
+	a.basicForm = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Username", a.usernameEntry),
+			widget.NewFormItem("Password", a.passwordEntry),
+		),
+		a.savePasswordCheck,
+	)
+
+	a.tokenEntry = widget.NewPasswordEntry()
+	a.tokenEntry.SetPlaceHolder("Token")
+
+	decodeButton := widget.NewButton("Decode JWT", func() {
+		if a.OnDecodeJWT != nil {
+			a.OnDecodeJWT()
+		}
+	})
+
+	a.bearerForm = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Token", a.tokenEntry),
+		),
+		container.NewHBox(decodeButton),
+	)
+
+	a.authURLEntry = widget.NewEntry()
+	a.authURLEntry.SetPlaceHolder("https://provider.example.com/authorize")
+	a.tokenURLEntry = widget.NewEntry()
+	a.tokenURLEntry.SetPlaceHolder("https://provider.example.com/token")
+	a.clientIDEntry = widget.NewEntry()
+	a.clientSecretEntry = widget.NewPasswordEntry()
+	a.clientSecretEntry.SetPlaceHolder("Optional for public clients")
+	a.scopeEntry = widget.NewEntry()
+	a.scopeEntry.SetPlaceHolder("openid profile")
+	a.redirectPortEntry = widget.NewEntry()
+	a.redirectPortEntry.SetText(strconv.Itoa(defaultRedirectPort))
+	a.saveSecretCheck = widget.NewCheck("Save client secret with request", nil)
+	a.tokenStatusLabel = widget.NewLabel("No token")
+
+	getTokenButton := widget.NewButton("Get Token", func() {
+		if a.OnGetToken != nil {
+			a.OnGetToken(a.GetConfig())
+		}
+	})
+
+	a.oauth2Form = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Auth URL", a.authURLEntry),
+			widget.NewFormItem("Token URL", a.tokenURLEntry),
+			widget.NewFormItem("Client ID", a.clientIDEntry),
+			widget.NewFormItem("Client Secret", a.clientSecretEntry),
+			widget.NewFormItem("Scope", a.scopeEntry),
+			widget.NewFormItem("Redirect Port", a.redirectPortEntry),
+		),
+		a.saveSecretCheck,
+		container.NewBorder(nil, nil, getTokenButton, nil, a.tokenStatusLabel),
+	)
+
+	a.createHMACForm()
+
+	a.overrideWarning = widget.NewLabel("")
+	a.overrideWarning.Importance = widget.WarningImportance
+	a.overrideWarning.Hide()
+
+	labels := make([]string, len(authTypeLabels))
+	for i, t := range authTypeLabels {
+		labels[i] = t.label
+	}
+	a.typeSelect = widget.NewSelect(labels, func(string) {
+		a.updateVisibility()
+		if a.OnChanged != nil {
+			a.OnChanged()
+		}
+	})
+
+	a.container = container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("Type:"), nil, a.typeSelect),
+			a.overrideWarning,
+		),
+		nil,
+		nil,
+		nil,
+		container.NewVScroll(container.NewVBox(a.basicForm, a.bearerForm, a.oauth2Form, a.hmacForm)),
+	)
+
+	a.typeSelect.SetSelected(authTypeLabels[0].label)
+}
+
+// createHMACForm builds the HMAC settings. Unlike the other forms they
+// change the preview as they are edited, which helps to debug a signature.
+func (a *AuthEditor) createHMACForm() {
+	changed := func() {
+		if a.OnChanged != nil {
+			a.OnChanged()
+		}
+	}
+	onText := func(string) { changed() }
+
+	a.algorithmSelect = widget.NewSelect([]string{HMACSHA256, HMACSHA1, HMACSHA512}, onText)
+	a.algorithmSelect.SetSelected(HMACSHA256)
+
+	a.stringToSignEntry = widget.NewMultiLineEntry()
+	a.stringToSignEntry.SetMinRowsVisible(5)
+	a.stringToSignEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	a.stringToSignEntry.SetText(defaultStringToSign)
+	a.stringToSignEntry.OnChanged = onText
+
+	a.secretEntry = widget.NewEntry()
+	a.secretEntry.SetPlaceHolder("{{signing_secret}}")
+	a.secretEntry.OnChanged = onText
+	a.saveHMACCheck = widget.NewCheck("Save secret with request even if it is not a variable", nil)
+
+	a.signatureEntry = widget.NewEntry()
+	a.signatureEntry.SetText("X-Signature")
+	a.signatureEntry.OnChanged = onText
+	a.encodingSelect = widget.NewSelect([]string{SignatureHex, SignatureBase64}, onText)
+	a.encodingSelect.SetSelected(SignatureHex)
+
+	a.timestampEntry = widget.NewEntry()
+	a.timestampEntry.SetPlaceHolder("Not sent")
+	a.timestampEntry.SetText("X-Timestamp")
+	a.timestampEntry.OnChanged = onText
+	a.nonceEntry = widget.NewEntry()
+	a.nonceEntry.SetPlaceHolder("Not sent")
+	a.nonceEntry.SetText("X-Nonce")
+	a.nonceEntry.OnChanged = onText
+
+	help := widget.NewLabel("Placeholders: {method}, {path}, {query}, {body}, {timestamp} (Unix seconds) and {nonce}. " +
+		"The signature is computed once the body is final and shows in the Preview tab. " +
+		"Keep the secret in a variable marked secret and reference it here.")
+	help.Wrapping = fyne.TextWrapWord
+
+	a.hmacForm = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Algorithm", a.algorithmSelect),
+			widget.NewFormItem("String to sign", a.stringToSignEntry),
+			widget.NewFormItem("Secret", a.secretEntry),
+			widget.NewFormItem("Signature header", a.signatureEntry),
+			widget.NewFormItem("Encoding", a.encodingSelect),
+			widget.NewFormItem("Timestamp header", a.timestampEntry),
+			widget.NewFormItem("Nonce header", a.nonceEntry),
+		),
+		a.saveHMACCheck,
+		help,
+	)
+}
+
+func (a *AuthEditor) selectedType() string {
+	for _, t := range authTypeLabels {
+		if t.label == a.typeSelect.Selected {
+			return t.authType
+		}
+	}
+	return AuthTypeNone
+}
+
+func (a *AuthEditor) updateVisibility() {
+	a.basicForm.Hide()
+	a.bearerForm.Hide()
+	a.oauth2Form.Hide()
+	a.hmacForm.Hide()
+
+	switch a.selectedType() {
+	case AuthTypeBasic:
+		a.basicForm.Show()
+	case AuthTypeBearer:
+		a.bearerForm.Show()
+	case AuthTypeOAuth2:
+		a.oauth2Form.Show()
+	case AuthTypeHMAC:
+		a.hmacForm.Show()
+	}
+}
+
+// SetTokenStatus describes the stored OAuth2 token next to the Get Token button.
+func (a *AuthEditor) SetTokenStatus(status string) {
+	a.tokenStatusLabel.SetText(status)
+}
+
+// SetHeaderOverridden shows a warning that the header of the selected auth
+// type set in the headers table, usually Authorization, will be replaced.
+func (a *AuthEditor) SetHeaderOverridden(overridden bool) {
+	if header := a.GetConfig().Header(); overridden && header != "" {
+		a.overrideWarning.SetText(fmt.Sprintf("Warning: the %s header set in Headers will be replaced", header))
+		a.overrideWarning.Show()
+	} else {
+		a.overrideWarning.Hide()
+	}
+}
+
+func (a *AuthEditor) GetConfig() AuthConfig {
+	config := AuthConfig{Type: a.selectedType()}
+	switch config.Type {
+	case AuthTypeBasic:
+		config.Username = a.usernameEntry.Text
+		config.Password = a.passwordEntry.Text
+		config.SavePassword = a.savePasswordCheck.Checked
+	case AuthTypeBearer:
+		config.Token = a.tokenEntry.Text
+	case AuthTypeOAuth2:
+		config.AuthURL = a.authURLEntry.Text
+		config.TokenURL = a.tokenURLEntry.Text
+		config.ClientID = a.clientIDEntry.Text
+		config.ClientSecret = a.clientSecretEntry.Text
+		config.Scope = a.scopeEntry.Text
+		config.SavePassword = a.saveSecretCheck.Checked
+		config.RedirectPort, _ = strconv.Atoi(a.redirectPortEntry.Text)
+		if config.RedirectPort == 0 {
+			config.RedirectPort = defaultRedirectPort
+		}
+	case AuthTypeHMAC:
+		config.Algorithm = a.algorithmSelect.Selected
+		config.StringToSign = a.stringToSignEntry.Text
+		config.Secret = a.secretEntry.Text
+		config.SavePassword = a.saveHMACCheck.Checked
+		config.SignatureHeader = strings.TrimSpace(a.signatureEntry.Text)
+		config.Encoding = a.encodingSelect.Selected
+		config.TimestampHeader = strings.TrimSpace(a.timestampEntry.Text)
+		config.NonceHeader = strings.TrimSpace(a.nonceEntry.Text)
+	}
+	return config
+}
+
+func (a *AuthEditor) SetConfig(config AuthConfig) {
+	a.usernameEntry.SetText(config.Username)
+	a.passwordEntry.SetText(config.Password)
+	a.savePasswordCheck.SetChecked(config.SavePassword && config.Type == AuthTypeBasic)
+	a.tokenEntry.SetText(config.Token)
+	a.authURLEntry.SetText(config.AuthURL)
+	a.tokenURLEntry.SetText(config.TokenURL)
+	a.clientIDEntry.SetText(config.ClientID)
+	a.clientSecretEntry.SetText(config.ClientSecret)
+	a.scopeEntry.SetText(config.Scope)
+	a.saveSecretCheck.SetChecked(config.SavePassword && config.Type == AuthTypeOAuth2)
+
+	port := config.RedirectPort
+	if port == 0 {
+		port = defaultRedirectPort
+	}
+	a.redirectPortEntry.SetText(strconv.Itoa(port))
+
+	// A config of another type leaves the HMAC defaults in place
+	if config.Type == AuthTypeHMAC {
+		a.algorithmSelect.SetSelected(config.Algorithm)
+		a.stringToSignEntry.SetText(config.StringToSign)
+		a.signatureEntry.SetText(config.SignatureHeader)
+		a.encodingSelect.SetSelected(config.Encoding)
+		a.timestampEntry.SetText(config.TimestampHeader)
+		a.nonceEntry.SetText(config.NonceHeader)
+	}
+	a.secretEntry.SetText(config.Secret)
+	a.saveHMACCheck.SetChecked(config.SavePassword && config.Type == AuthTypeHMAC)
+
+	label := authTypeLabels[0].label
+	for _, t := range authTypeLabels {
+		if t.authType == config.Type {
+			label = t.label
+		}
+	}
+	a.typeSelect.SetSelected(label)
+}
+
+func (a *AuthEditor) GetContainer() *fyne.Container {
+	return a.container
+}
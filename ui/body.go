@@ -0,0 +1,490 @@
+package ui
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golem/graphql"
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const customContentType = "Custom"
+
+const (
+	BodyTypeRaw       = ""
+	BodyTypeMultipart = "multipart"
+	BodyTypeBinary    = "binary"
+	// BodyTypeGraphQL is a query and its variables, sent and stored as the
+	// JSON {"query": ..., "variables": ...}
+	BodyTypeGraphQL = "graphql"
+)
+
+var bodyTypeLabels = []struct {
+	bodyType string
+	label    string
+}{
+	{BodyTypeRaw, "Raw"},
+	{BodyTypeMultipart, "Multipart Form"},
+	{BodyTypeBinary, "Binary File"},
+	{BodyTypeGraphQL, "GraphQL"},
+}
+
+// Where a raw body comes from. A file is read again on every send, so it can
+// be rewritten between runs.
+const (
+	BodySourceInline = ""
+	BodySourceFile   = "file"
+)
+
+var bodyContentTypes = []string{
+	"application/json",
+	"text/plain",
+	"application/xml",
+	customContentType,
+}
+
+type BodyEditor struct {
+	container         *fyne.Container
+	typeRadio         *widget.RadioGroup
+	rawSection        *fyne.Container
+	bodyEntry         *widget.Entry
+	bodyText          *bodyText
+	contentTypeSelect *widget.Select
+	customTypeEntry   *widget.Entry
+	bodyActions       *fyne.Container
+	fromFileCheck     *widget.Check
+	bodyFileRow       *fyne.Container
+	bodyFilePath      string
+	bodyFileLabel     *widget.Label
+	formEditor        *FormEditor
+	binarySection     *fyne.Container
+	binaryPath        string
+	binaryFileLabel   *widget.Label
+	binaryTypeEntry   *widget.Entry
+	graphQLEditor     *graphQLEditor
+	warningLabel      *widget.Label
+	noBodyLabel       *widget.Label
+	method            string
+	parentWindow      fyne.Window
+
+	// OnChanged is called whenever the body or its content type changes
+	OnChanged func()
+	// OnIntrospect is called when Introspect is tapped in GraphQL mode
+	OnIntrospect func()
+}
+
+func NewBodyEditor(parentWindow fyne.Window) *BodyEditor {
+	b := &BodyEditor{
+		method:       "GET",
+		formEditor:   NewFormEditor(parentWindow),
+		parentWindow: parentWindow,
+	}
+	b.formEditor.OnChanged = b.changed
+	b.graphQLEditor = newGraphQLEditor(b.graphQLChanged, func() {
+		if b.OnIntrospect != nil {
+			b.OnIntrospect()
+		}
+	})
+	b.createUI()
+	b.updateVisibility()
+	return b
+}
+
+// MethodAllowsBody reports whether a request body is expected for the method.
+// Custom methods such as PROPFIND or REPORT often carry one, so only the
+// standard methods without a body are excluded.
+func MethodAllowsBody(method string) bool {
+	switch method {
+	case "GET", "HEAD", "DELETE", "OPTIONS", "TRACE", "CONNECT":
+		return false
+	}
+	return true
+}
+
+func (b *BodyEditor) createUI() {
+	b.bodyEntry = widget.NewMultiLineEntry()
+	b.bodyEntry.SetPlaceHolder("Request body...")
+	b.bodyEntry.OnChanged = func(string) {
+		b.updateVisibility()
+		b.changed()
+	}
+
+	b.customTypeEntry = widget.NewEntry()
+	b.customTypeEntry.SetPlaceHolder("e.g. application/x-www-form-urlencoded")
+	b.customTypeEntry.Hide()
+	b.customTypeEntry.OnChanged = func(string) {
+		b.changed()
+	}
+
+	b.contentTypeSelect = widget.NewSelect(bodyContentTypes, func(value string) {
+		if value == customContentType {
+			b.customTypeEntry.Show()
+		} else {
+			b.customTypeEntry.Hide()
+		}
+		b.changed()
+	})
+	b.contentTypeSelect.SetSelected("application/json")
+
+	b.warningLabel = widget.NewLabel("")
+	b.warningLabel.Importance = widget.WarningImportance
+	b.warningLabel.Hide()
+
+	b.noBodyLabel = widget.NewLabel("")
+	b.noBodyLabel.Alignment = fyne.TextAlignCenter
+
+	b.bodyText = newBodyText(b.bodyEntry, b.bodyEntry)
+
+	b.bodyActions = container.NewHBox(newDynamicVariablesButton(b.bodyEntry))
+	typeRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Content-Type:"),
+		b.bodyActions,
+		container.NewGridWithColumns(2, b.contentTypeSelect, b.customTypeEntry),
+	)
+
+	b.bodyFileLabel = widget.NewLabel("No file selected")
+	b.bodyFileLabel.Wrapping = fyne.TextWrapBreak
+	chooseBodyFileButton := widget.NewButtonWithIcon("Choose File...", theme.FolderOpenIcon(), func() {
+		b.chooseFile(b.SetBodyFile)
+	})
+	b.bodyFileRow = container.NewBorder(nil, nil, chooseBodyFileButton, nil, b.bodyFileLabel)
+
+	b.fromFileCheck = widget.NewCheck("Read the body from a file on every send", func(bool) {
+		b.updateVisibility()
+		b.changed()
+	})
+
+	b.rawSection = container.NewBorder(
+		container.NewVBox(typeRow, b.fromFileCheck, b.bodyFileRow),
+		nil,
+		nil,
+		nil,
+		container.NewStack(b.bodyText.override, b.noBodyLabel),
+	)
+
+	b.binaryFileLabel = widget.NewLabel("No file selected")
+	b.binaryFileLabel.Wrapping = fyne.TextWrapBreak
+	b.binaryTypeEntry = widget.NewEntry()
+	b.binaryTypeEntry.SetPlaceHolder("application/octet-stream")
+	b.binaryTypeEntry.OnChanged = func(string) {
+		b.changed()
+	}
+
+	chooseButton := widget.NewButtonWithIcon("Choose File...", theme.FolderOpenIcon(), func() {
+		b.chooseFile(func(path string) {
+			b.SetBinaryFile(path)
+			b.binaryTypeEntry.SetText(mime.TypeByExtension(filepath.Ext(path)))
+		})
+	})
+
+	b.binarySection = container.NewVBox(
+		container.NewBorder(nil, nil, chooseButton, nil, b.binaryFileLabel),
+		widget.NewForm(widget.NewFormItem("Content-Type", b.binaryTypeEntry)),
+	)
+
+	labels := make([]string, len(bodyTypeLabels))
+	for i, t := range bodyTypeLabels {
+		labels[i] = t.label
+	}
+	b.typeRadio = widget.NewRadioGroup(labels, func(string) {
+		b.updateVisibility()
+		b.changed()
+	})
+	b.typeRadio.Horizontal = true
+	b.typeRadio.Required = true
+	b.typeRadio.SetSelected(bodyTypeLabels[0].label)
+
+	b.container = container.NewBorder(
+		container.NewVBox(b.typeRadio, b.warningLabel),
+		nil,
+		nil,
+		nil,
+		container.NewStack(b.rawSection, b.formEditor.GetContainer(), b.binarySection, b.graphQLEditor.container),
+	)
+}
+
+// AllowSnippets adds a Snippets button that inserts body snippets saved in
+// db and saves the body as one.
+func (b *BodyEditor) AllowSnippets(db *storage.DB) {
+	b.bodyActions.Objects = append([]fyne.CanvasObject{newSnippetsButton(db, b.bodyEntry, b.parentWindow)}, b.bodyActions.Objects...)
+	b.bodyActions.Refresh()
+}
+
+func (b *BodyEditor) chooseFile(onChosen func(path string)) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, b.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		onChosen(reader.URI().Path())
+	}, b.parentWindow)
+}
+
+func (b *BodyEditor) changed() {
+	if b.OnChanged != nil {
+		b.OnChanged()
+	}
+}
+
+// graphQLChanged shows the warning for a query added to a request whose
+// method has no body.
+func (b *BodyEditor) graphQLChanged() {
+	b.updateVisibility()
+	b.changed()
+}
+
+func (b *BodyEditor) hasBody() bool {
+	switch b.GetBodyType() {
+	case BodyTypeMultipart:
+		return len(b.formEditor.GetFields()) > 0
+	case BodyTypeBinary:
+		return b.binaryPath != ""
+	case BodyTypeGraphQL:
+		return b.graphQLEditor.body() != ""
+	default:
+		if b.fromFileCheck.Checked {
+			return b.bodyFilePath != ""
+		}
+		return b.bodyEntry.Text != ""
+	}
+}
+
+func (b *BodyEditor) updateVisibility() {
+	// Called from createUI before every widget exists
+	if b.container == nil {
+		return
+	}
+
+	hasBody := b.hasBody()
+	allowsBody := MethodAllowsBody(b.method)
+
+	b.rawSection.Hide()
+	b.formEditor.GetContainer().Hide()
+	b.binarySection.Hide()
+	b.graphQLEditor.container.Hide()
+	switch b.GetBodyType() {
+	case BodyTypeMultipart:
+		b.formEditor.GetContainer().Show()
+	case BodyTypeBinary:
+		b.binarySection.Show()
+	case BodyTypeGraphQL:
+		b.graphQLEditor.container.Show()
+	default:
+		b.rawSection.Show()
+	}
+
+	if b.fromFileCheck.Checked {
+		b.bodyFileRow.Show()
+	} else {
+		b.bodyFileRow.Hide()
+	}
+
+	// The inline text is kept while the body comes from a file, so
+	// unchecking brings it back
+	if b.fromFileCheck.Checked {
+		b.bodyEntry.Hide()
+		b.noBodyLabel.SetText("The file is read when the request is sent; {{variables}} in it are replaced")
+		b.noBodyLabel.Show()
+	} else if allowsBody || b.bodyEntry.Text != "" {
+		b.bodyEntry.Show()
+		b.noBodyLabel.Hide()
+	} else {
+		b.bodyEntry.Hide()
+		b.noBodyLabel.SetText(b.method + " requests do not send a body")
+		b.noBodyLabel.Show()
+	}
+
+	if !allowsBody && hasBody {
+		b.warningLabel.SetText("Warning: " + b.method + " requests usually have no body; it will still be sent")
+		b.warningLabel.Show()
+	} else {
+		b.warningLabel.Hide()
+	}
+}
+
+func (b *BodyEditor) SetMethod(method string) {
+	b.method = method
+	b.updateVisibility()
+}
+
+func (b *BodyEditor) GetBodyType() string {
+	for _, t := range bodyTypeLabels {
+		if t.label == b.typeRadio.Selected {
+			return t.bodyType
+		}
+	}
+	return BodyTypeRaw
+}
+
+func (b *BodyEditor) SetBodyType(bodyType string) {
+	for _, t := range bodyTypeLabels {
+		if t.bodyType == bodyType {
+			b.typeRadio.SetSelected(t.label)
+			return
+		}
+	}
+	b.typeRadio.SetSelected(bodyTypeLabels[0].label)
+}
+
+func (b *BodyEditor) GetBody() string {
+	return b.bodyEntry.Text
+}
+
+func (b *BodyEditor) SetBody(body string) {
+	b.bodyEntry.SetText(body)
+	b.updateVisibility()
+}
+
+// SetTextStyle sets how the raw body is shown, as in the response body.
+func (b *BodyEditor) SetTextStyle(style BodyTextStyle) {
+	b.bodyText.setStyle(style)
+}
+
+func (b *BodyEditor) GetFormFields() []FormField {
+	return b.formEditor.GetFields()
+}
+
+func (b *BodyEditor) SetFormFields(fields []FormField) {
+	b.formEditor.SetFields(fields)
+	b.updateVisibility()
+}
+
+func (b *BodyEditor) GetBinaryFile() string {
+	return b.binaryPath
+}
+
+// SetBinaryFile shows the chosen file with its current size. The file is only
+// read when the request is sent.
+func (b *BodyEditor) SetBinaryFile(path string) {
+	b.binaryPath = path
+	switch info, err := os.Stat(path); {
+	case path == "":
+		b.binaryFileLabel.SetText("No file selected")
+	case err != nil:
+		b.binaryFileLabel.SetText(fmt.Sprintf("%s (not found)", path))
+	default:
+		b.binaryFileLabel.SetText(fmt.Sprintf("%s (%d bytes)", path, info.Size()))
+	}
+	b.updateVisibility()
+	b.changed()
+}
+
+// GetBodySource returns BodySourceInline or BodySourceFile for a raw body.
+func (b *BodyEditor) GetBodySource() string {
+	if b.fromFileCheck.Checked {
+		return BodySourceFile
+	}
+	return BodySourceInline
+}
+
+func (b *BodyEditor) SetBodySource(source string) {
+	b.fromFileCheck.SetChecked(source == BodySourceFile)
+}
+
+// GetBodyFile returns the file a raw body is read from when its source is
+// BodySourceFile.
+func (b *BodyEditor) GetBodyFile() string {
+	return b.bodyFilePath
+}
+
+func (b *BodyEditor) SetBodyFile(path string) {
+	b.bodyFilePath = path
+	switch _, err := os.Stat(path); {
+	case path == "":
+		b.bodyFileLabel.SetText("No file selected")
+	case err != nil:
+		b.bodyFileLabel.SetText(fmt.Sprintf("%s (not found)", path))
+	default:
+		b.bodyFileLabel.SetText(path)
+	}
+	b.updateVisibility()
+	b.changed()
+}
+
+// GetGraphQL returns the GraphQL query and variables as the JSON they are
+// sent as, or "" when both are empty.
+func (b *BodyEditor) GetGraphQL() string {
+	return b.graphQLEditor.body()
+}
+
+// SetGraphQL shows a body returned by GetGraphQL.
+func (b *BodyEditor) SetGraphQL(body string) {
+	b.graphQLEditor.setBody(body)
+	b.updateVisibility()
+}
+
+// GraphQLVariablesError reports GraphQL variables that are not a JSON
+// object, with which the request is not sent.
+func (b *BodyEditor) GraphQLVariablesError() error {
+	return b.graphQLEditor.variablesError()
+}
+
+// SetGraphQLSchema checks the GraphQL query against schema, fetched at
+// fetchedAt, and shows it in the schema tree. A nil schema clears both.
+func (b *BodyEditor) SetGraphQLSchema(schema *graphql.Schema, fetchedAt time.Time) {
+	b.graphQLEditor.setSchema(schema, fetchedAt)
+}
+
+// ShowIntrospecting shows that the GraphQL endpoint is being introspected.
+func (b *BodyEditor) ShowIntrospecting() {
+	b.graphQLEditor.showIntrospecting()
+}
+
+// ShowIntrospectionError shows why introspection gave no schema.
+func (b *BodyEditor) ShowIntrospectionError(err error) {
+	b.graphQLEditor.showIntrospectionError(err)
+}
+
+// GetContentType returns the content type chosen for the current body type.
+func (b *BodyEditor) GetContentType() string {
+	switch b.GetBodyType() {
+	case BodyTypeGraphQL:
+		return "application/json"
+	case BodyTypeBinary:
+		if b.binaryTypeEntry.Text == "" {
+			return "application/octet-stream"
+		}
+		return b.binaryTypeEntry.Text
+	}
+	if b.contentTypeSelect.Selected == customContentType {
+		return b.customTypeEntry.Text
+	}
+	return b.contentTypeSelect.Selected
+}
+
+// SetContentType selects a known content type, or switches to the custom
+// entry for anything else. An empty value leaves the selection unchanged.
+func (b *BodyEditor) SetContentType(contentType string) {
+	if contentType == "" {
+		return
+	}
+	if b.GetBodyType() == BodyTypeBinary {
+		b.binaryTypeEntry.SetText(contentType)
+		return
+	}
+	for _, known := range bodyContentTypes {
+		if known == contentType && known != customContentType {
+			b.contentTypeSelect.SetSelected(known)
+			return
+		}
+	}
+	b.customTypeEntry.SetText(contentType)
+	b.contentTypeSelect.SetSelected(customContentType)
+}
+
+func (b *BodyEditor) GetContainer() *fyne.Container {
+	return b.container
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The text sizes the body views can be set to, in points.
+const (
+	minBodyTextSize = 8
+	maxBodyTextSize = 36
+)
+
+// bodyTextDelay is how long changes to the text style settle before they
+// are applied, so pressing Ctrl+= a few times lays the body out once.
+const bodyTextDelay = 150 * time.Millisecond
+
+// BodyTextStyle is how the request and response bodies are shown. A
+// TextSize of 0 is the size of the theme.
+type BodyTextStyle struct {
+	Wrap      bool    `json:"wrap"`
+	Monospace bool    `json:"monospace"`
+	TextSize  float32 `json:"text_size,omitempty"`
+}
+
+// Resized returns the style with the text size steps points larger, or
+// smaller for a negative steps, within the sizes allowed.
+func (s BodyTextStyle) Resized(steps int) BodyTextStyle {
+	s.TextSize = min(max(s.textSize()+float32(steps), minBodyTextSize), maxBodyTextSize)
+	return s
+}
+
+// textSize returns the text size of the style, that of the theme for 0.
+func (s BodyTextStyle) textSize() float32 {
+	if s.TextSize == 0 {
+		return fyne.CurrentApp().Settings().Theme().Size(theme.SizeNameText)
+	}
+	return s.TextSize
+}
+
+// bodyTextTheme is the theme of the application with another text size.
+type bodyTextTheme struct {
+	size float32
+}
+
+func (t *bodyTextTheme) base() fyne.Theme {
+	return fyne.CurrentApp().Settings().Theme()
+}
+
+func (t *bodyTextTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	return t.base().Color(name, variant)
+}
+
+func (t *bodyTextTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return t.base().Font(style)
+}
+
+func (t *bodyTextTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return t.base().Icon(name)
+}
+
+func (t *bodyTextTheme) Size(name fyne.ThemeSizeName) float32 {
+	if name == theme.SizeNameText && t.size > 0 {
+		return t.size
+	}
+	return t.base().Size(name)
+}
+
+// bodyText shows the entry of a body with a BodyTextStyle. Changing the
+// style only lays the text out again; it is not reformatted or copied.
+type bodyText struct {
+	entry    *widget.Entry
+	theme    *bodyTextTheme
+	override *container.ThemeOverride
+	style    BodyTextStyle
+	timer    *time.Timer
+	// wrapped holds the wrapping the entry had before the style set it
+	wrapped fyne.TextWrap
+}
+
+// newBodyText puts content, which holds entry, under a theme that sets its
+// text size.
+func newBodyText(entry *widget.Entry, content fyne.CanvasObject) *bodyText {
+	t := &bodyText{entry: entry, theme: &bodyTextTheme{}, wrapped: entry.Wrapping}
+	t.override = container.NewThemeOverride(content, t.theme)
+	return t
+}
+
+// setStyle applies style once changes pause for bodyTextDelay.
+func (t *bodyText) setStyle(style BodyTextStyle) {
+	if style == t.style {
+		return
+	}
+	t.style = style
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.timer = time.AfterFunc(bodyTextDelay, func() {
+		fyne.Do(t.apply)
+	})
+}
+
+func (t *bodyText) apply() {
+	t.entry.Wrapping = t.wrapped
+	if t.style.Wrap {
+		t.entry.Wrapping = fyne.TextWrapWord
+	}
+	t.entry.TextStyle.Monospace = t.style.Monospace
+	t.theme.size = t.style.TextSize
+	t.override.Refresh()
+}
+
+// bodyTextControls are the Wrap and Monospace toggles and the buttons that
+// make the text smaller and larger.
+type bodyTextControls struct {
+	container      *fyne.Container
+	wrapCheck      *widget.Check
+	monospaceCheck *widget.Check
+	sizeLabel      *widget.Label
+	style          BodyTextStyle
+	updating       bool
+}
+
+// newBodyTextControls passes each change of the style to onChanged.
+func newBodyTextControls(onChanged func(BodyTextStyle)) *bodyTextControls {
+	c := &bodyTextControls{}
+	c.wrapCheck = widget.NewCheck("Wrap", func(wrap bool) {
+		if !c.updating {
+			style := c.style
+			style.Wrap = wrap
+			onChanged(style)
+		}
+	})
+	c.monospaceCheck = widget.NewCheck("Monospace", func(monospace bool) {
+		if !c.updating {
+			style := c.style
+			style.Monospace = monospace
+			onChanged(style)
+		}
+	})
+	c.sizeLabel = widget.NewLabel("")
+	smallerButton := widget.NewButtonWithIcon("", theme.ZoomOutIcon(), func() {
+		onChanged(c.style.Resized(-1))
+	})
+	largerButton := widget.NewButtonWithIcon("", theme.ZoomInIcon(), func() {
+		onChanged(c.style.Resized(1))
+	})
+	c.container = container.NewHBox(c.wrapCheck, c.monospaceCheck, smallerButton, c.sizeLabel, largerButton)
+	c.set(BodyTextStyle{})
+	return c
+}
+
+// set shows style without passing it to onChanged.
+func (c *bodyTextControls) set(style BodyTextStyle) {
+	c.style = style
+	c.updating = true
+	c.wrapCheck.SetChecked(style.Wrap)
+	c.monospaceCheck.SetChecked(style.Monospace)
+	c.updating = false
+	c.sizeLabel.SetText(fmt.Sprintf("%gpt", style.textSize()))
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// LoadCAFile parses every certificate in a PEM file. Files without a
+// certificate, or where any certificate has expired, are rejected.
+func LoadCAFile(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("CA file %s: %w", filepath.Base(path), err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("CA file %s: %w", filepath.Base(path), err)
+		}
+		if time.Now().After(cert.NotAfter) {
+			return nil, fmt.Errorf("CA file %s: certificate %q expired on %s",
+				filepath.Base(path), cert.Subject.CommonName, cert.NotAfter.Format("Jan 2 2006"))
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("CA file %s: no PEM certificates found", filepath.Base(path))
+	}
+	return certs, nil
+}
+
+// CAFilesEditor lists the PEM files with additional trusted root
+// certificates. Files are checked when they are added.
+type CAFilesEditor struct {
+	container    *fyne.Container
+	rowsBox      *fyne.Container
+	files        []string
+	parentWindow fyne.Window
+}
+
+func NewCAFilesEditor(parentWindow fyne.Window) *CAFilesEditor {
+	c := &CAFilesEditor{parentWindow: parentWindow}
+
+	c.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add CA File", theme.ContentAddIcon(), c.chooseFile)
+
+	c.container = container.NewBorder(nil, container.NewHBox(addButton), nil, nil, c.rowsBox)
+	return c
+}
+
+func (c *CAFilesEditor) chooseFile() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, c.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		path := reader.URI().Path()
+		if _, err := LoadCAFile(path); err != nil {
+			dialog.ShowError(err, c.parentWindow)
+			return
+		}
+		c.SetFiles(append(c.files, path))
+	}, c.parentWindow)
+}
+
+func (c *CAFilesEditor) removeFile(index int) {
+	files := append([]string{}, c.files[:index]...)
+	c.SetFiles(append(files, c.files[index+1:]...))
+}
+
+func (c *CAFilesEditor) GetFiles() []string {
+	return c.files
+}
+
+func (c *CAFilesEditor) SetFiles(files []string) {
+	c.files = files
+	c.rowsBox.RemoveAll()
+	for i, file := range files {
+		index := i
+		status := widget.NewLabel(describeCAFile(file))
+		status.Wrapping = fyne.TextWrapWord
+		removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+			c.removeFile(index)
+		})
+		c.rowsBox.Add(container.NewBorder(nil, nil, nil, removeButton, status))
+	}
+	c.rowsBox.Refresh()
+}
+
+func describeCAFile(path string) string {
+	certs, err := LoadCAFile(path)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%d certificates)", filepath.Base(path), len(certs))
+}
+
+func (c *CAFilesEditor) GetContainer() *fyne.Container {
+	return c.container
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ClientCertificate is a PEM certificate and key pair sent to hosts matching
+// HostPattern, e.g. "api.internal.example.com" or "*.internal.example.com".
+type ClientCertificate struct {
+	HostPattern string `json:"host_pattern"`
+	CertFile    string `json:"cert_file"`
+	KeyFile     string `json:"key_file"`
+}
+
+// Load reads and parses the certificate and key files.
+func (c ClientCertificate) Load() (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("client certificate for %q: %w", c.HostPattern, err)
+	}
+	return cert, nil
+}
+
+// CertificatesEditor edits the list of client certificates. Each row is
+// loaded as soon as both files are chosen so problems show up immediately.
+type CertificatesEditor struct {
+	container    *fyne.Container
+	rowsBox      *fyne.Container
+	rows         []*certificateRow
+	parentWindow fyne.Window
+}
+
+type certificateRow struct {
+	patternEntry *widget.Entry
+	certFile     string
+	keyFile      string
+	certLabel    *widget.Label
+	keyLabel     *widget.Label
+	statusLabel  *widget.Label
+	container    *fyne.Container
+}
+
+func NewCertificatesEditor(parentWindow fyne.Window) *CertificatesEditor {
+	c := &CertificatesEditor{parentWindow: parentWindow}
+
+	c.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Certificate", theme.ContentAddIcon(), func() {
+		c.addRow(ClientCertificate{})
+	})
+
+	c.container = container.NewBorder(nil, container.NewHBox(addButton), nil, nil, c.rowsBox)
+	return c
+}
+
+func (c *CertificatesEditor) addRow(cert ClientCertificate) {
+	row := &certificateRow{
+		patternEntry: widget.NewEntry(),
+		certLabel:    widget.NewLabel(""),
+		keyLabel:     widget.NewLabel(""),
+		statusLabel:  widget.NewLabel(""),
+	}
+	row.patternEntry.SetPlaceHolder("Host, e.g. *.internal.example.com")
+	row.patternEntry.SetText(cert.HostPattern)
+	row.statusLabel.Wrapping = fyne.TextWrapWord
+
+	certButton := widget.NewButtonWithIcon("Certificate", theme.FolderOpenIcon(), func() {
+		c.chooseFile(func(file string) {
+			row.certFile = file
+			row.update()
+		})
+	})
+	keyButton := widget.NewButtonWithIcon("Key", theme.FolderOpenIcon(), func() {
+		c.chooseFile(func(file string) {
+			row.keyFile = file
+			row.update()
+		})
+	})
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		c.removeRow(row)
+	})
+
+	row.certFile = cert.CertFile
+	row.keyFile = cert.KeyFile
+	row.update()
+
+	row.container = container.NewVBox(
+		container.NewBorder(nil, nil, nil, removeButton, row.patternEntry),
+		container.NewBorder(nil, nil, certButton, nil, row.certLabel),
+		container.NewBorder(nil, nil, keyButton, nil, row.keyLabel),
+		row.statusLabel,
+		widget.NewSeparator(),
+	)
+
+	c.rows = append(c.rows, row)
+	c.rowsBox.Add(row.container)
+}
+
+func (c *CertificatesEditor) chooseFile(onChosen func(path string)) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, c.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		onChosen(reader.URI().Path())
+	}, c.parentWindow)
+}
+
+func (r *certificateRow) config() ClientCertificate {
+	return ClientCertificate{
+		HostPattern: r.patternEntry.Text,
+		CertFile:    r.certFile,
+		KeyFile:     r.keyFile,
+	}
+}
+
+func (r *certificateRow) update() {
+	r.certLabel.SetText(fileLabel(r.certFile))
+	r.keyLabel.SetText(fileLabel(r.keyFile))
+
+	if r.certFile == "" || r.keyFile == "" {
+		r.statusLabel.Importance = widget.MediumImportance
+		r.statusLabel.SetText("Choose a PEM certificate and key")
+		return
+	}
+
+	cert, err := r.config().Load()
+	if err != nil {
+		r.statusLabel.Importance = widget.DangerImportance
+		r.statusLabel.SetText(err.Error())
+		return
+	}
+	r.statusLabel.Importance = widget.SuccessImportance
+	r.statusLabel.SetText(describeCertificate(cert))
+}
+
+func fileLabel(path string) string {
+	if path == "" {
+		return "No file selected"
+	}
+	return filepath.Base(path)
+}
+
+func describeCertificate(cert tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "Loaded"
+	}
+	return fmt.Sprintf("Loaded %s, valid until %s", leaf.Subject.CommonName, leaf.NotAfter.Format("Jan 2 2006"))
+}
+
+func (c *CertificatesEditor) removeRow(row *certificateRow) {
+	for i, r := range c.rows {
+		if r == row {
+			c.rows = append(c.rows[:i], c.rows[i+1:]...)
+			break
+		}
+	}
+	c.rowsBox.Remove(row.container)
+}
+
+// Validate loads every certificate, returning the first problem found.
+func (c *CertificatesEditor) Validate() error {
+	for _, cert := range c.GetCertificates() {
+		if cert.HostPattern == "" {
+			return fmt.Errorf("client certificate %s has no host pattern", filepath.Base(cert.CertFile))
+		}
+		if _, err := path.Match(cert.HostPattern, ""); err != nil {
+			return fmt.Errorf("invalid host pattern %q: %w", cert.HostPattern, err)
+		}
+		if _, err := cert.Load(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCertificates returns the configured certificates, skipping empty rows.
+func (c *CertificatesEditor) GetCertificates() []ClientCertificate {
+	var certs []ClientCertificate
+	for _, row := range c.rows {
+		cert := row.config()
+		if cert.HostPattern == "" && cert.CertFile == "" && cert.KeyFile == "" {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+func (c *CertificatesEditor) SetCertificates(certs []ClientCertificate) {
+	c.rows = nil
+	c.rowsBox.RemoveAll()
+	for _, cert := range certs {
+		c.addRow(cert)
+	}
+	c.rowsBox.Refresh()
+}
+
+func (c *CertificatesEditor) GetContainer() *fyne.Container {
+	return c.container
+}
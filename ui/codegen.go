@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"golem/codegen"
+)
+
+// ShowCodeDialog shows request as a snippet in one of the codegen languages,
+// starting with language. onLanguageChanged is told when another language is
+// picked so it can be offered first next time.
+func ShowCodeDialog(request codegen.Request, language string, onLanguageChanged func(string), parentWindow fyne.Window) {
+	codeEntry := widget.NewMultiLineEntry()
+	codeEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	codeEntry.Disable()
+
+	render := func(name string) {
+		code, err := codegen.Generate(name, request)
+		if err != nil {
+			code = err.Error()
+		}
+		codeEntry.SetText(code)
+	}
+
+	languageSelect := widget.NewSelect(codegen.Languages(), func(name string) {
+		render(name)
+		if onLanguageChanged != nil {
+			onLanguageChanged(name)
+		}
+	})
+	if language == "" {
+		language = codegen.Languages()[0]
+	}
+	languageSelect.SetSelected(language)
+
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(codeEntry.Text)
+	})
+
+	note := widget.NewLabel("Variables are left as {{placeholders}}; replace them before running the code.")
+	note.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustom("Generate Code", "Close",
+		container.NewBorder(
+			container.NewBorder(nil, nil, nil, copyButton, languageSelect),
+			note, nil, nil,
+			codeEntry,
+		),
+		parentWindow)
+	d.Resize(fyne.NewSize(700, 500))
+	d.Show()
+}
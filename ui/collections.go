@@ -0,0 +1,459 @@
+package ui
+
+import (
+	"fmt"
+	"golem/storage"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	storagefilter "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	collectionNodePrefix = "c:"
+	requestNodePrefix    = "r:"
+	unsortedNodeID       = "c:0"
+	noCollectionLabel    = "(none)"
+)
+
+type CollectionsPanel struct {
+	container     *fyne.Container
+	tree          *widget.Tree
+	db            *storage.DB
+	collections   []*storage.Collection
+	children      map[string][]string
+	requests      map[string]*storage.SavedRequest
+	selectedNode  string
+	searchEntry   *widget.Entry
+	onRequestLoad func(req *storage.SavedRequest)
+	parentWindow  fyne.Window
+
+	// OnMonitor edits the monitor of the selected saved request
+	OnMonitor func(req *storage.SavedRequest)
+}
+
+func NewCollectionsPanel(db *storage.DB, onRequestLoad func(req *storage.SavedRequest), parentWindow fyne.Window) *CollectionsPanel {
+	cp := &CollectionsPanel{
+		db:            db,
+		onRequestLoad: onRequestLoad,
+		parentWindow:  parentWindow,
+		children:      map[string][]string{},
+		requests:      map[string]*storage.SavedRequest{},
+	}
+
+	cp.createUI()
+	cp.loadCollections()
+
+	return cp
+}
+
+func (cp *CollectionsPanel) createUI() {
+	cp.tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return cp.children[id]
+		},
+		func(id widget.TreeNodeID) bool {
+			return id == "" || strings.HasPrefix(id, collectionNodePrefix)
+		},
+		func(branch bool) fyne.CanvasObject {
+			if !branch {
+				return widget.NewLabel("Request name")
+			}
+			menuButton := widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), nil)
+			menuButton.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, menuButton, widget.NewLabel("Collection name"))
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			if !branch {
+				o.(*widget.Label).SetText(cp.nodeLabel(id))
+				return
+			}
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(cp.nodeLabel(id))
+			menuButton := row.Objects[1].(*widget.Button)
+			menuButton.OnTapped = func() {
+				cp.showCollectionMenu(id, menuButton)
+			}
+		},
+	)
+
+	cp.tree.OnSelected = func(id widget.TreeNodeID) {
+		cp.selectedNode = id
+		if req, ok := cp.requests[id]; ok {
+			cp.onRequestLoad(req)
+		}
+	}
+	cp.tree.OnUnselected = func(id widget.TreeNodeID) {
+		cp.selectedNode = ""
+	}
+
+	cp.searchEntry = widget.NewEntry()
+	cp.searchEntry.SetPlaceHolder("Search name, URL or notes...")
+	cp.searchEntry.OnChanged = func(string) {
+		cp.loadCollections()
+	}
+
+	newButton := widget.NewButtonWithIcon("New Collection", theme.FolderNewIcon(), func() {
+		cp.showNewCollectionDialog()
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		cp.deleteSelected()
+	})
+
+	importButton := widget.NewButtonWithIcon("Import OpenAPI", theme.UploadIcon(), cp.importOpenAPI)
+
+	monitorButton := widget.NewButtonWithIcon("Monitor", theme.HistoryIcon(), func() {
+		if req, ok := cp.requests[cp.selectedNode]; ok && cp.OnMonitor != nil {
+			cp.OnMonitor(req)
+		}
+	})
+
+	cp.container = container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Collections", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+			cp.searchEntry,
+		),
+		container.NewHBox(newButton, importButton, deleteButton, monitorButton),
+		nil,
+		nil,
+		cp.tree,
+	)
+}
+
+func (cp *CollectionsPanel) nodeLabel(id string) string {
+	if req, ok := cp.requests[id]; ok {
+		return fmt.Sprintf("%s  %s", req.Method, req.Name)
+	}
+	if id == unsortedNodeID {
+		return "Unsorted"
+	}
+	for _, col := range cp.collections {
+		if collectionNodePrefix+strconv.Itoa(col.ID) == id {
+			return col.Name
+		}
+	}
+	return id
+}
+
+// collection returns the collection of a branch node, one with ID 0 for
+// Unsorted.
+func (cp *CollectionsPanel) collection(id string) *storage.Collection {
+	for _, col := range cp.collections {
+		if collectionNodePrefix+strconv.Itoa(col.ID) == id {
+			return col
+		}
+	}
+	return &storage.Collection{Name: "Unsorted"}
+}
+
+// showCollectionMenu offers the actions on a collection in a menu below its
+// menu button.
+func (cp *CollectionsPanel) showCollectionMenu(id string, button *widget.Button) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Export for Postman...", func() {
+			cp.exportPostman(cp.collection(id))
+		}),
+	)
+	canvas := fyne.CurrentApp().Driver().CanvasForObject(button)
+	widget.ShowPopUpMenuAtRelativePosition(menu, canvas, fyne.NewPos(0, button.Size().Height), button)
+}
+
+// exportPostman writes the requests of collection to a file chosen by the
+// user as a Postman collection.
+func (cp *CollectionsPanel) exportPostman(collection *storage.Collection) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, cp.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := cp.db.ExportCollectionPostman(collection, writer); err != nil {
+			dialog.ShowError(err, cp.parentWindow)
+		} else {
+			dialog.ShowInformation("Success", "Collection exported successfully", cp.parentWindow)
+		}
+	}, cp.parentWindow)
+	// The name Postman gives its own exports
+	save.SetFileName(collection.Name + ".postman_collection.json")
+	save.Show()
+}
+
+// openAPISkippedShown is how many skipped operations the import summary
+// lists before only counting the rest.
+const openAPISkippedShown = 10
+
+// importOpenAPI makes a new collection of the operations of an OpenAPI or
+// Swagger document chosen by the user, and says which were skipped.
+func (cp *CollectionsPanel) importOpenAPI() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, cp.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, cp.parentWindow)
+			return
+		}
+		spec, err := storage.ParseOpenAPI(data)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("cannot import %s: %w", reader.URI().Name(), err), cp.parentWindow)
+			return
+		}
+
+		if len(spec.Requests) == 0 {
+			dialog.ShowError(fmt.Errorf("%s has no operations that could be imported", reader.URI().Name()), cp.parentWindow)
+			return
+		}
+
+		collection := &storage.Collection{Name: spec.Name, Description: spec.Description}
+		if collection.Name == "" {
+			collection.Name = strings.TrimSuffix(reader.URI().Name(), filepath.Ext(reader.URI().Name()))
+		}
+		if err := cp.db.ImportCollection(collection, spec.Requests); err != nil {
+			dialog.ShowError(err, cp.parentWindow)
+			return
+		}
+		cp.loadCollections()
+		cp.tree.OpenBranch(collectionNodePrefix + strconv.Itoa(collection.ID))
+
+		noun := "requests"
+		if len(spec.Requests) == 1 {
+			noun = "request"
+		}
+		summary := fmt.Sprintf("Imported %d %s into %q.", len(spec.Requests), noun, collection.Name)
+		if len(spec.Skipped) > 0 {
+			summary += fmt.Sprintf("\n\nSkipped %d:", len(spec.Skipped))
+			for i, skipped := range spec.Skipped {
+				if i == openAPISkippedShown {
+					summary += fmt.Sprintf("\n… and %d more", len(spec.Skipped)-i)
+					break
+				}
+				summary += "\n" + skipped
+			}
+		}
+		dialog.ShowInformation("Import OpenAPI", summary, cp.parentWindow)
+	}, cp.parentWindow)
+	open.SetFilter(storagefilter.NewExtensionFileFilter([]string{".yaml", ".yml", ".json"}))
+	open.Show()
+}
+
+func (cp *CollectionsPanel) loadCollections() {
+	collections, err := cp.db.GetCollections()
+	if err != nil {
+		dialog.ShowError(err, cp.parentWindow)
+		return
+	}
+
+	cp.collections = collections
+	cp.children = map[string][]string{}
+	cp.requests = map[string]*storage.SavedRequest{}
+
+	for _, col := range collections {
+		id := col.ID
+		nodeID := collectionNodePrefix + strconv.Itoa(id)
+		cp.children[""] = append(cp.children[""], nodeID)
+		cp.loadRequests(nodeID, &id)
+	}
+
+	cp.children[""] = append(cp.children[""], unsortedNodeID)
+	cp.loadRequests(unsortedNodeID, nil)
+
+	cp.tree.Refresh()
+	// Show where the matches are
+	if cp.searchTerm() != "" {
+		cp.tree.OpenAllBranches()
+	}
+}
+
+func (cp *CollectionsPanel) searchTerm() string {
+	return strings.ToLower(strings.TrimSpace(cp.searchEntry.Text))
+}
+
+// matches reports whether req fits the search, by its name, URL or notes.
+func (cp *CollectionsPanel) matches(req *storage.SavedRequest) bool {
+	term := cp.searchTerm()
+	return term == "" ||
+		strings.Contains(strings.ToLower(req.Name), term) ||
+		strings.Contains(strings.ToLower(req.URL), term) ||
+		strings.Contains(strings.ToLower(req.Notes), term)
+}
+
+func (cp *CollectionsPanel) loadRequests(nodeID string, collectionID *int) {
+	requests, err := cp.db.GetSavedRequests(collectionID)
+	if err != nil {
+		dialog.ShowError(err, cp.parentWindow)
+		return
+	}
+
+	for _, req := range requests {
+		if !cp.matches(req) {
+			continue
+		}
+		reqNodeID := requestNodePrefix + strconv.Itoa(req.ID)
+		cp.requests[reqNodeID] = req
+		cp.children[nodeID] = append(cp.children[nodeID], reqNodeID)
+	}
+}
+
+func (cp *CollectionsPanel) showNewCollectionDialog() {
+	nameEntry := widget.NewEntry()
+	descriptionEntry := widget.NewEntry()
+
+	dialog.ShowForm("New Collection", "Create", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Description", descriptionEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed || nameEntry.Text == "" {
+				return
+			}
+			if _, err := cp.db.CreateCollection(nameEntry.Text, descriptionEntry.Text); err != nil {
+				dialog.ShowError(err, cp.parentWindow)
+				return
+			}
+			cp.loadCollections()
+		}, cp.parentWindow)
+}
+
+func (cp *CollectionsPanel) deleteSelected() {
+	id := cp.selectedNode
+	if id == "" || id == unsortedNodeID {
+		return
+	}
+
+	if req, ok := cp.requests[id]; ok {
+		dialog.ShowConfirm("Delete Request",
+			fmt.Sprintf("Delete saved request %q?", req.Name),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := cp.db.DeleteSavedRequest(req.ID); err != nil {
+					dialog.ShowError(err, cp.parentWindow)
+					return
+				}
+				cp.tree.UnselectAll()
+				cp.loadCollections()
+			}, cp.parentWindow)
+		return
+	}
+
+	collectionID, err := strconv.Atoi(strings.TrimPrefix(id, collectionNodePrefix))
+	if err != nil {
+		return
+	}
+	dialog.ShowConfirm("Delete Collection",
+		fmt.Sprintf("Delete collection %q and all of its requests?", cp.nodeLabel(id)),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := cp.db.DeleteCollection(collectionID); err != nil {
+				dialog.ShowError(err, cp.parentWindow)
+				return
+			}
+			cp.tree.UnselectAll()
+			cp.loadCollections()
+		}, cp.parentWindow)
+}
+
+// ShowSaveDialog asks for a name and collection and then stores req. A request
+// that already has an ID is updated in place.
+func (cp *CollectionsPanel) ShowSaveDialog(req *storage.SavedRequest, onSaved func(req *storage.SavedRequest)) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(req.Name)
+	if req.Name == "" {
+		nameEntry.SetText(req.Method + " " + req.URL)
+	}
+
+	options := []string{noCollectionLabel}
+	for _, col := range cp.collections {
+		options = append(options, col.Name)
+	}
+	collectionSelect := widget.NewSelect(options, nil)
+	collectionSelect.SetSelectedIndex(0)
+	if req.CollectionID != nil {
+		for i, col := range cp.collections {
+			if col.ID == *req.CollectionID {
+				collectionSelect.SetSelectedIndex(i + 1)
+			}
+		}
+	}
+
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetText(req.Notes)
+	notesEntry.SetPlaceHolder("e.g. send X-Feature-Flag: beta or the server answers 403")
+	notesEntry.Wrapping = fyne.TextWrapWord
+	notesEntry.SetMinRowsVisible(4)
+
+	title := "Save Request"
+	if req.ID != 0 {
+		title = "Update Saved Request"
+	}
+
+	d := dialog.NewForm(title, "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Collection", collectionSelect),
+			widget.NewFormItem("Notes", notesEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed || nameEntry.Text == "" {
+				return
+			}
+
+			req.Name = nameEntry.Text
+			req.Notes = strings.TrimSpace(notesEntry.Text)
+			// Option 0 is "(none)"; the rest follow cp.collections
+			req.CollectionID = nil
+			if i := collectionSelect.SelectedIndex(); i > 0 {
+				id := cp.collections[i-1].ID
+				req.CollectionID = &id
+			}
+
+			var err error
+			if req.ID != 0 {
+				err = cp.db.UpdateSavedRequest(req)
+			} else {
+				err = cp.db.SaveRequest(req)
+			}
+			if err != nil {
+				dialog.ShowError(err, cp.parentWindow)
+				return
+			}
+
+			cp.loadCollections()
+			if onSaved != nil {
+				onSaved(req)
+			}
+		}, cp.parentWindow)
+	d.Resize(fyne.NewSize(500, 350))
+	d.Show()
+}
+
+func (cp *CollectionsPanel) GetContainer() *fyne.Container {
+	return cp.container
+}
+
+func (cp *CollectionsPanel) Refresh() {
+	cp.loadCollections()
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxDiffColumn is how many characters of a line each side of the diff
+// shows; longer lines end in an ellipsis.
+const maxDiffColumn = 100
+
+// DiffRow is one row of a side-by-side diff. A line only present on one
+// side leaves the other empty.
+type DiffRow struct {
+	Left    string
+	Right   string
+	Changed bool
+}
+
+// ComparisonSide is the response from one environment, or from one send of
+// a request resent from the history.
+type ComparisonSide struct {
+	Environment string
+	Status      string
+}
+
+// Comparison is the diff of the responses to one request sent in two
+// environments, or sent again from the history.
+type Comparison struct {
+	// Title names the dialog; empty means an environment comparison
+	Title       string
+	Left, Right ComparisonSide
+	Headers     []DiffRow
+	Body        []DiffRow
+	// StructuralJSON is set when both bodies are JSON and were compared with
+	// their keys sorted
+	StructuralJSON bool
+}
+
+var (
+	diffRemovedStyle = &widget.CustomTextGridStyle{BGColor: color.NRGBA{R: 220, G: 0, B: 0, A: 70}}
+	diffAddedStyle   = &widget.CustomTextGridStyle{BGColor: color.NRGBA{R: 0, G: 180, B: 0, A: 70}}
+)
+
+// ShowCompareEnvironmentsDialog asks for the two environments to send the
+// request in; nil stands for the global variables only.
+func ShowCompareEnvironmentsDialog(environments []*storage.Environment, onCompare func(left, right *storage.Environment), parentWindow fyne.Window) {
+	options := []string{noEnvironmentLabel}
+	for _, environment := range environments {
+		options = append(options, environment.Name)
+	}
+	// Option 0 is "No Environment"; the rest follow environments
+	environmentAt := func(index int) *storage.Environment {
+		if index <= 0 {
+			return nil
+		}
+		return environments[index-1]
+	}
+
+	leftSelect := widget.NewSelect(options, nil)
+	leftSelect.SetSelectedIndex(0)
+	rightSelect := widget.NewSelect(options, nil)
+	if len(options) > 1 {
+		rightSelect.SetSelectedIndex(1)
+	} else {
+		rightSelect.SetSelectedIndex(0)
+	}
+
+	dialog.ShowForm("Compare Environments", "Send Both", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Left", leftSelect),
+			widget.NewFormItem("Right", rightSelect),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if leftSelect.SelectedIndex() == rightSelect.SelectedIndex() {
+				dialog.ShowError(errors.New("choose two different environments"), parentWindow)
+				return
+			}
+			onCompare(environmentAt(leftSelect.SelectedIndex()), environmentAt(rightSelect.SelectedIndex()))
+		}, parentWindow)
+}
+
+// ShowComparisonDialog shows the statuses of both responses and their
+// headers and bodies side by side, with changed lines highlighted.
+func ShowComparisonDialog(comparison Comparison, parentWindow fyne.Window) {
+	statusLabel := func(side ComparisonSide) *widget.Label {
+		label := widget.NewLabel(side.Environment + ": " + side.Status)
+		label.Wrapping = fyne.TextWrapWord
+		if comparison.Left.Status != comparison.Right.Status {
+			label.Importance = widget.WarningImportance
+		}
+		return label
+	}
+
+	bodyNote := "Bodies compared line by line"
+	if comparison.StructuralJSON {
+		bodyNote = "JSON bodies compared with their keys sorted"
+	}
+	bodyTab := container.NewBorder(widget.NewLabel(bodyNote), nil, nil, nil, diffGrid(comparison.Body))
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Body", bodyTab),
+		container.NewTabItem("Headers", diffGrid(comparison.Headers)),
+	)
+
+	content := container.NewBorder(
+		container.NewGridWithColumns(2, statusLabel(comparison.Left), statusLabel(comparison.Right)),
+		nil, nil, nil,
+		tabs,
+	)
+
+	title := comparison.Title
+	if title == "" {
+		title = "Environment Comparison"
+	}
+	d := dialog.NewCustom(title, "Close", content, parentWindow)
+	d.Resize(fyne.NewSize(1000, 650))
+	d.Show()
+}
+
+// diffGrid lays rows out in two monospace columns, the removed side of a
+// changed row in red and the added side in green.
+func diffGrid(rows []DiffRow) *widget.TextGrid {
+	width := 0
+	for _, row := range rows {
+		width = max(width, min(len(diffRunes(row.Left)), maxDiffColumn))
+	}
+
+	grid := widget.NewTextGrid()
+	grid.Rows = make([]widget.TextGridRow, len(rows))
+	for i, row := range rows {
+		// An empty side is padding, not a changed line
+		var leftStyle, rightStyle widget.TextGridStyle
+		if row.Changed && row.Left != "" {
+			leftStyle = diffRemovedStyle
+		}
+		if row.Changed && row.Right != "" {
+			rightStyle = diffAddedStyle
+		}
+		cells := diffCells(row.Left, width, leftStyle)
+		cells = append(cells, diffCells(" │ ", 3, nil)...)
+		cells = append(cells, diffCells(row.Right, len(diffRunes(row.Right)), rightStyle)...)
+		grid.Rows[i] = widget.TextGridRow{Cells: cells}
+	}
+	if len(rows) == 0 {
+		grid.SetText("(empty on both sides)")
+	}
+	return grid
+}
+
+// diffCells returns text as width cells, truncated with an ellipsis or
+// padded with spaces.
+func diffCells(text string, width int, style widget.TextGridStyle) []widget.TextGridCell {
+	runes := diffRunes(text)
+	if len(runes) > width {
+		runes = append(runes[:max(width-1, 0)], '…')
+	}
+	cells := make([]widget.TextGridCell, width)
+	for i := range cells {
+		cells[i] = widget.TextGridCell{Rune: ' ', Style: style}
+		if i < len(runes) {
+			cells[i].Rune = runes[i]
+		}
+	}
+	return cells
+}
+
+func diffRunes(text string) []rune {
+	return []rune(strings.ReplaceAll(text, "\t", "    "))
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"golem/storage"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// CookieManager lists the cookies in the shared jar grouped by domain.
+type CookieManager struct {
+	db           *storage.DB
+	content      *fyne.Container
+	parentWindow fyne.Window
+	onChanged    func()
+}
+
+// ShowCookieManager opens the cookie manager. onChanged is called after
+// cookies have been deleted so the jar can be reloaded.
+func ShowCookieManager(db *storage.DB, onChanged func(), parentWindow fyne.Window) {
+	cm := &CookieManager{
+		db:           db,
+		content:      container.NewVBox(),
+		parentWindow: parentWindow,
+		onChanged:    onChanged,
+	}
+
+	clearButton := widget.NewButtonWithIcon("Clear All", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("Clear Cookies", "Delete every stored cookie?", func(confirmed bool) {
+			if confirmed {
+				cm.run(cm.db.ClearCookies())
+			}
+		}, parentWindow)
+	})
+
+	cm.load()
+
+	d := dialog.NewCustom("Cookies", "Close",
+		container.NewBorder(nil, container.NewHBox(clearButton), nil, nil, container.NewVScroll(cm.content)),
+		parentWindow)
+	d.Resize(fyne.NewSize(650, 450))
+	d.Show()
+}
+
+func (cm *CookieManager) load() {
+	cm.content.RemoveAll()
+
+	cookies, err := cm.db.GetCookies()
+	if err != nil {
+		dialog.ShowError(err, cm.parentWindow)
+		return
+	}
+	if len(cookies) == 0 {
+		cm.content.Add(widget.NewLabel("No cookies stored"))
+		cm.content.Refresh()
+		return
+	}
+
+	accordion := widget.NewAccordion()
+	var domainCookies []*storage.Cookie
+	for i, cookie := range cookies {
+		domainCookies = append(domainCookies, cookie)
+		// Cookies are ordered by domain, so a group ends where the domain changes
+		if i == len(cookies)-1 || cookies[i+1].Domain != cookie.Domain {
+			accordion.Append(cm.domainItem(cookie.Domain, domainCookies))
+			domainCookies = nil
+		}
+	}
+
+	cm.content.Add(accordion)
+	cm.content.Refresh()
+}
+
+func (cm *CookieManager) domainItem(domain string, cookies []*storage.Cookie) *widget.AccordionItem {
+	rows := container.NewVBox()
+	for _, cookie := range cookies {
+		id := cookie.ID
+		deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			cm.run(cm.db.DeleteCookie(id))
+		})
+
+		nameLabel := widget.NewLabel(fmt.Sprintf("%s = %s", cookie.Name, truncate(cookie.Value, 60)))
+		nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+		nameLabel.Truncation = fyne.TextTruncateEllipsis
+
+		rows.Add(container.NewBorder(nil, nil, nil, deleteButton,
+			container.NewVBox(nameLabel, widget.NewLabel(describeCookie(cookie)))))
+	}
+
+	deleteAllButton := widget.NewButton("Delete all for "+domain, func() {
+		cm.run(cm.db.DeleteCookiesForDomain(domain))
+	})
+	rows.Add(container.NewHBox(deleteAllButton))
+
+	return widget.NewAccordionItem(fmt.Sprintf("%s (%d)", domain, len(cookies)), rows)
+}
+
+func (cm *CookieManager) run(err error) {
+	if err != nil {
+		dialog.ShowError(err, cm.parentWindow)
+	}
+	cm.load()
+	if cm.onChanged != nil {
+		cm.onChanged()
+	}
+}
+
+func describeCookie(cookie *storage.Cookie) string {
+	parts := []string{"Path " + cookie.Path}
+	if cookie.Expires != nil {
+		parts = append(parts, "expires "+cookie.Expires.Local().Format("Jan 2 2006 15:04"))
+	} else {
+		parts = append(parts, "session")
+	}
+	if cookie.Secure {
+		parts = append(parts, "Secure")
+	}
+	if cookie.HttpOnly {
+		parts = append(parts, "HttpOnly")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
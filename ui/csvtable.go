@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// maxCSVProblems bounds the malformed rows listed above the table
+	maxCSVProblems = 20
+	// csvWidthSample is how many rows the column widths are measured on
+	csvWidthSample = 200
+	// maxCSVColumnWidth bounds the width of a column; longer values are
+	// truncated
+	maxCSVColumnWidth = 320
+)
+
+// IsCSVContentType reports whether contentType is comma- or tab-separated
+// values.
+func IsCSVContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/csv", "application/csv", "text/tab-separated-values":
+		return true
+	}
+	return false
+}
+
+// csvData is a parsed CSV body: its first record as the header, the other
+// records, and the malformed rows, which are kept as far as they could be
+// read.
+type csvData struct {
+	header   []string
+	rows     [][]string
+	lines    []int
+	problems []string
+	// malformed counts the problems, including those not listed
+	malformed int
+}
+
+// parseCSV reads body as CSV, or as TSV for a tab-separated Content-Type.
+// A row with another number of fields than the header is kept; one that
+// cannot be read at all is skipped. Either is reported with its line.
+func parseCSV(body, contentType string) csvData {
+	reader := csv.NewReader(strings.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = false
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/tab-separated-values" {
+		reader.Comma = '\t'
+	}
+
+	var data csvData
+	report := func(line int, problem string) {
+		data.malformed++
+		if len(data.problems) < maxCSVProblems {
+			data.problems = append(data.problems, fmt.Sprintf("Line %d: %s", line, problem))
+		}
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report(parseErr.StartLine, parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			report(0, err.Error())
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if data.header == nil {
+			data.header = record
+			continue
+		}
+		if len(record) != len(data.header) {
+			report(line, fmt.Sprintf("%d fields, the header has %d", len(record), len(data.header)))
+		}
+		data.rows = append(data.rows, record)
+		data.lines = append(data.lines, line)
+	}
+	return data
+}
+
+// csvTable shows a CSV body as a table under its header row, with the line
+// of each row beside it. Clicking a column header sorts by it, numerically
+// when the values are numbers; clicking it again reverses the order.
+type csvTable struct {
+	container    *fyne.Container
+	table        *widget.Table
+	summaryLabel *widget.Label
+	problemLabel *widget.Label
+	data         csvData
+	// order holds the indexes of the rows in the order shown
+	order      []int
+	sortColumn int
+	descending bool
+}
+
+func newCSVTable() *csvTable {
+	t := &csvTable{sortColumn: -1}
+
+	t.table = widget.NewTable(
+		func() (int, int) {
+			return len(t.order), len(t.data.header)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row >= len(t.order) {
+				label.SetText("")
+				return
+			}
+			row := t.data.rows[t.order[id.Row]]
+			if id.Col < len(row) {
+				label.SetText(row[id.Col])
+			} else {
+				label.SetText("")
+			}
+		},
+	)
+	t.table.ShowHeaderRow = true
+	t.table.ShowHeaderColumn = true
+	t.table.CreateHeader = func() fyne.CanvasObject {
+		button := widget.NewButton("", nil)
+		button.Importance = widget.LowImportance
+		button.Alignment = widget.ButtonAlignLeading
+		return button
+	}
+	t.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		button := o.(*widget.Button)
+		if id.Col < 0 {
+			// The line of the row in the body
+			button.OnTapped = nil
+			if id.Row < len(t.order) {
+				button.SetText(strconv.Itoa(t.data.lines[t.order[id.Row]]))
+			}
+			return
+		}
+		text := ""
+		if id.Col < len(t.data.header) {
+			text = t.data.header[id.Col]
+		}
+		if id.Col == t.sortColumn {
+			if t.descending {
+				text += " ▼"
+			} else {
+				text += " ▲"
+			}
+		}
+		button.SetText(text)
+		column := id.Col
+		button.OnTapped = func() {
+			t.sortBy(column)
+		}
+	}
+
+	t.summaryLabel = widget.NewLabel("")
+	t.problemLabel = widget.NewLabel("")
+	t.problemLabel.Importance = widget.WarningImportance
+	t.problemLabel.Wrapping = fyne.TextWrapWord
+	t.problemLabel.Hide()
+
+	t.container = container.NewBorder(
+		container.NewVBox(t.summaryLabel, t.problemLabel),
+		nil, nil, nil,
+		t.table,
+	)
+	return t
+}
+
+// set shows data in the order of the body.
+func (t *csvTable) set(data csvData) {
+	t.data = data
+	t.sortColumn, t.descending = -1, false
+	t.order = make([]int, len(data.rows))
+	for i := range t.order {
+		t.order[i] = i
+	}
+
+	summary := fmt.Sprintf("%d rows × %d columns", len(data.rows), len(data.header))
+	if data.malformed > 0 {
+		summary += fmt.Sprintf(", %d malformed", data.malformed)
+	}
+	t.summaryLabel.SetText(summary)
+	if len(data.problems) > 0 {
+		problems := strings.Join(data.problems, "\n")
+		if data.malformed > len(data.problems) {
+			problems += fmt.Sprintf("\n… and %d more", data.malformed-len(data.problems))
+		}
+		t.problemLabel.SetText(problems)
+		t.problemLabel.Show()
+	} else {
+		t.problemLabel.Hide()
+	}
+
+	t.table.SetColumnWidth(-1, csvCellWidth(strconv.Itoa(len(data.rows)+1))+theme.IconInlineSize())
+	for column := range data.header {
+		width := csvCellWidth(data.header[column] + " ▼")
+		for _, row := range data.rows[:min(len(data.rows), csvWidthSample)] {
+			if column < len(row) {
+				width = max(width, csvCellWidth(row[column]))
+			}
+		}
+		t.table.SetColumnWidth(column, min(width, maxCSVColumnWidth))
+	}
+	t.table.ScrollToTop()
+	t.table.Refresh()
+}
+
+// loading empties the table while a body is parsed.
+func (t *csvTable) loading() {
+	t.set(csvData{})
+	t.summaryLabel.SetText("Parsing...")
+}
+
+// sortBy sorts the rows by column, or reverses the order when they already
+// are. Values are compared as numbers when both are.
+func (t *csvTable) sortBy(column int) {
+	if column == t.sortColumn {
+		t.descending = !t.descending
+	} else {
+		t.sortColumn, t.descending = column, false
+	}
+	value := func(i int) string {
+		if row := t.data.rows[i]; column < len(row) {
+			return row[column]
+		}
+		return ""
+	}
+	sort.SliceStable(t.order, func(a, b int) bool {
+		x, y := value(t.order[a]), value(t.order[b])
+		if t.descending {
+			x, y = y, x
+		}
+		xNumber, xErr := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		yNumber, yErr := strconv.ParseFloat(strings.TrimSpace(y), 64)
+		if xErr == nil && yErr == nil {
+			return xNumber < yNumber
+		}
+		return x < y
+	})
+	t.table.Refresh()
+}
+
+// csvCellWidth returns the width a cell needs to show text in full.
+func csvCellWidth(text string) float32 {
+	size := fyne.MeasureText(text, theme.TextSize(), fyne.TextStyle{})
+	return size.Width + 4*theme.Padding()
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowCurlImportDialog asks for a curl command to replace the request with,
+// starting from command, which may be empty.
+func ShowCurlImportDialog(command string, onImport func(command string), parentWindow fyne.Window) {
+	commandEntry := widget.NewMultiLineEntry()
+	commandEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	commandEntry.Wrapping = fyne.TextWrapBreak
+	commandEntry.SetPlaceHolder("curl -X POST https://example.com -H 'Content-Type: application/json' -d '{}'")
+	commandEntry.SetText(command)
+	commandEntry.SetMinRowsVisible(10)
+
+	d := dialog.NewForm("Import curl", "Import", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Command", commandEntry),
+		},
+		func(confirmed bool) {
+			if confirmed && strings.TrimSpace(commandEntry.Text) != "" {
+				onImport(commandEntry.Text)
+			}
+		}, parentWindow)
+	d.Resize(fyne.NewSize(650, 350))
+	d.Show()
+}
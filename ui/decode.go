@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Decoders for text selected in the response body
+const (
+	decoderBase64    = "Base64"
+	decoderURL       = "URL encoding"
+	decoderTimestamp = "Unix timestamp"
+)
+
+// decodeSelection decodes text as decoder, one of the decoder constants.
+func decodeSelection(decoder, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	switch decoder {
+	case decoderBase64:
+		return decodeBase64(text)
+	case decoderURL:
+		return decodeURLText(text)
+	case decoderTimestamp:
+		return decodeTimestamp(text)
+	}
+	return "", fmt.Errorf("unknown decoder %q", decoder)
+}
+
+// decodeBase64 decodes standard or URL-safe base64, told apart by the
+// characters used, with or without padding.
+func decodeBase64(text string) (string, error) {
+	text = strings.Join(strings.Fields(text), "")
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(text, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(text, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	decoded, err := encoding.DecodeString(text)
+	if err != nil {
+		return "", fmt.Errorf("not valid base64: %w", err)
+	}
+	if !utf8.Valid(decoded) {
+		return "", fmt.Errorf("the decoded %d bytes are binary, not text", len(decoded))
+	}
+	return string(decoded), nil
+}
+
+// decodeURLText undoes percent-encoding, reading + as a space as in query
+// strings.
+func decodeURLText(text string) (string, error) {
+	decoded, err := url.QueryUnescape(text)
+	if err != nil {
+		return "", fmt.Errorf("not valid URL encoding: %w", err)
+	}
+	return decoded, nil
+}
+
+// decodeTimestamp reads a Unix time in seconds, milliseconds, microseconds
+// or nanoseconds, told apart by its size, as ISO 8601 time in UTC and
+// local time. Seconds may have a fraction.
+func decodeTimestamp(text string) (string, error) {
+	var t time.Time
+	unit := "seconds"
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		switch magnitude := max(n, -n); {
+		case magnitude >= 1e17:
+			unit, t = "nanoseconds", time.Unix(0, n)
+		case magnitude >= 1e14:
+			unit, t = "microseconds", time.UnixMicro(n)
+		case magnitude >= 1e11:
+			unit, t = "milliseconds", time.UnixMilli(n)
+		default:
+			t = time.Unix(n, 0)
+		}
+	} else {
+		seconds, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+			return "", fmt.Errorf("%q is not a number", text)
+		}
+		if math.Abs(seconds) >= 1e11 {
+			return "", fmt.Errorf("%q is too large to be a time in seconds", text)
+		}
+		whole, fraction := math.Modf(seconds)
+		t = time.Unix(int64(whole), int64(math.Round(fraction*1e6))*1e3)
+	}
+
+	return fmt.Sprintf("%s\n%s (local time)\nRead as %s since 1970-01-01 UTC",
+		t.UTC().Format(time.RFC3339Nano), t.Local().Format(time.RFC3339Nano), unit), nil
+}
+
+// showDecodedPopUp shows decoded in a pop-up over canvas at position, with a
+// button to copy it and, when it is JSON, one to pretty-print it.
+func showDecodedPopUp(title, decoded string, canvas fyne.Canvas, position fyne.Position) {
+	result := widget.NewMultiLineEntry()
+	result.SetText(decoded)
+	result.Wrapping = fyne.TextWrapWord
+	result.TextStyle.Monospace = true
+	result.Disable()
+	scroll := container.NewScroll(result)
+	scroll.SetMinSize(fyne.NewSize(420, 160))
+
+	var popUp *widget.PopUp
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(result.Text)
+	})
+	prettyButton := widget.NewButton("Pretty-print JSON", nil)
+	prettyButton.OnTapped = func() {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, []byte(decoded), "", "  "); err == nil {
+			result.SetText(pretty.String())
+			prettyButton.Disable()
+		}
+	}
+	if !json.Valid([]byte(decoded)) {
+		prettyButton.Hide()
+	}
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		popUp.Hide()
+	})
+	closeButton.Importance = widget.LowImportance
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, nil, closeButton,
+			widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		container.NewHBox(copyButton, prettyButton),
+		nil, nil,
+		scroll,
+	)
+	popUp = widget.NewPopUp(content, canvas)
+
+	canvasSize := canvas.Size()
+	size := popUp.MinSize()
+	position.X = max(0, min(position.X, canvasSize.Width-size.Width))
+	position.Y = max(0, min(position.Y, canvasSize.Height-size.Height))
+	popUp.ShowAtPosition(position)
+}
+
+// newDecodeButton offers the decoders in a popup menu; choosing one decodes
+// the text selected() returns and shows the result in a pop-up below the
+// button.
+func newDecodeButton(selected func() string, parentWindow fyne.Window) *widget.Button {
+	var button *widget.Button
+	button = widget.NewButtonWithIcon("Decode", theme.SearchReplaceIcon(), func() {
+		var items []*fyne.MenuItem
+		for _, decoder := range []string{decoderBase64, decoderURL, decoderTimestamp} {
+			items = append(items, fyne.NewMenuItem(decoder, func() {
+				text := selected()
+				if strings.TrimSpace(text) == "" {
+					dialog.ShowInformation("Decode", "Select text in the response body to decode it. Text cannot be selected while line numbers or links are on, or in a table or records.", parentWindow)
+					return
+				}
+				decoded, err := decodeSelection(decoder, text)
+				if err != nil {
+					dialog.ShowError(err, parentWindow)
+					return
+				}
+				position := fyne.CurrentApp().Driver().AbsolutePositionForObject(button)
+				showDecodedPopUp(decoder+" decoded", decoded, parentWindow.Canvas(),
+					position.Add(fyne.NewPos(0, button.Size().Height)))
+			}))
+		}
+
+		canvas := fyne.CurrentApp().Driver().CanvasForObject(button)
+		widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), canvas,
+			fyne.NewPos(0, button.Size().Height), button)
+	})
+	return button
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// FormatBytes formats a size for display, e.g. "312.5 MB".
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+// ShowDownloadDialog asks where to save a response body, suggesting
+// fileName. When offered is set the body was not asked to be downloaded but
+// is large, so loading it anyway is offered first. onChosen gets the file to
+// write, or nil when the body should not be saved.
+func ShowDownloadDialog(fileName string, size int64, offered bool, onChosen func(writer fyne.URIWriteCloser), parentWindow fyne.Window) {
+	showSave := func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				onChosen(nil)
+				return
+			}
+			onChosen(writer)
+		}, parentWindow)
+		save.SetFileName(fileName)
+		save.Show()
+	}
+
+	if !offered {
+		showSave()
+		return
+	}
+
+	confirm := dialog.NewConfirm("Large Response",
+		fmt.Sprintf("The response body is %s. Save it to a file instead of loading it into the response area?", FormatBytes(size)),
+		func(saveToFile bool) {
+			if saveToFile {
+				showSave()
+			} else {
+				onChosen(nil)
+			}
+		}, parentWindow)
+	confirm.SetConfirmText("Save to File")
+	confirm.SetDismissText("Load Anyway")
+	confirm.Show()
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DynamicVariables documents the built-in variables that get a fresh value
+// on every send.
+var DynamicVariables = []struct {
+	Placeholder string
+	Description string
+}{
+	{"{{uuid}}", "Random UUID"},
+	{"{{timestamp}}", "Unix time in seconds"},
+	{"{{isoTimestamp}}", "ISO 8601 time in UTC"},
+	{"{{randomInt 1 100}}", "Random integer from min to max"},
+	{"{{randomString 16}}", "Random letters and digits of the given length"},
+}
+
+// newDynamicVariablesButton shows the dynamic variables in a popup menu;
+// choosing one inserts it into entry at the cursor.
+func newDynamicVariablesButton(entry *widget.Entry) *widget.Button {
+	var button *widget.Button
+	button = widget.NewButton("{{…}}", func() {
+		items := make([]*fyne.MenuItem, len(DynamicVariables))
+		for i, variable := range DynamicVariables {
+			placeholder := variable.Placeholder
+			items[i] = fyne.NewMenuItem(placeholder+"  "+variable.Description, func() {
+				insertAtCursor(entry, placeholder)
+			})
+		}
+
+		canvas := fyne.CurrentApp().Driver().CanvasForObject(button)
+		widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), canvas,
+			fyne.NewPos(0, button.Size().Height), button)
+	})
+	return button
+}
+
+// insertAtCursor inserts text into entry at the cursor and moves the cursor
+// after it.
+func insertAtCursor(entry *widget.Entry, text string) {
+	lines := strings.Split(entry.Text, "\n")
+	row := min(entry.CursorRow, len(lines)-1)
+	line := []rune(lines[row])
+	column := min(entry.CursorColumn, len(line))
+
+	lines[row] = string(line[:column]) + text + string(line[column:])
+	entry.SetText(strings.Join(lines, "\n"))
+	entry.CursorRow = row
+	entry.CursorColumn = column + len([]rune(text))
+	entry.Refresh()
+	fyne.CurrentApp().Driver().CanvasForObject(entry).Focus(entry)
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"fmt"
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const noEnvironmentLabel = "No Environment"
+
+// EnvironmentSelector is the top bar dropdown choosing the environment whose
+// variables are used when sending.
+type EnvironmentSelector struct {
+	db           *storage.DB
+	selectWidget *widget.Select
+	container    *fyne.Container
+	environments []*storage.Environment
+	selected     int
+	OnChanged    func(environmentID int)
+}
+
+func NewEnvironmentSelector(db *storage.DB) *EnvironmentSelector {
+	s := &EnvironmentSelector{db: db}
+	s.selectWidget = widget.NewSelect(nil, func(label string) {
+		id := s.idForLabel(label)
+		if id == s.selected {
+			return
+		}
+		s.selected = id
+		if s.OnChanged != nil {
+			s.OnChanged(id)
+		}
+	})
+	s.container = container.NewStack(s.selectWidget)
+	s.Reload()
+	return s
+}
+
+// Reload refreshes the list after environments were added or deleted. If
+// the selected environment is gone the selection falls back to none.
+func (s *EnvironmentSelector) Reload() {
+	environments, err := s.db.GetEnvironments()
+	if err != nil {
+		fmt.Printf("Error loading environments: %v\n", err)
+	}
+	s.environments = environments
+
+	options := []string{noEnvironmentLabel}
+	for _, environment := range environments {
+		options = append(options, environment.Name)
+	}
+	s.selectWidget.SetOptions(options)
+
+	previous := s.selected
+	s.SetSelected(previous)
+	if s.selected != previous && s.OnChanged != nil {
+		s.OnChanged(s.selected)
+	}
+}
+
+func (s *EnvironmentSelector) idForLabel(label string) int {
+	for _, environment := range s.environments {
+		if environment.Name == label {
+			return environment.ID
+		}
+	}
+	return 0
+}
+
+// Selected returns the active environment ID, or 0 for none.
+func (s *EnvironmentSelector) Selected() int {
+	return s.selected
+}
+
+// SetSelected activates the environment with id without calling OnChanged.
+func (s *EnvironmentSelector) SetSelected(id int) {
+	s.selected = 0
+	label := noEnvironmentLabel
+	for _, environment := range s.environments {
+		if environment.ID == id {
+			s.selected = id
+			label = environment.Name
+			break
+		}
+	}
+	// The change callback compares against s.selected, so this is silent
+	s.selectWidget.SetSelected(label)
+}
+
+func (s *EnvironmentSelector) GetContainer() *fyne.Container {
+	return s.container
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	ExtractJSONPath = "jsonpath"
+	ExtractHeader   = "header"
+	ExtractRegex    = "regex"
+)
+
+var extractorSourceLabels = []struct {
+	source string
+	label  string
+	hint   string
+}{
+	{ExtractJSONPath, "JSON path", "e.g. $.token"},
+	{ExtractHeader, "Header", "Header name"},
+	{ExtractRegex, "Body regex", `e.g. "id":(\d+)`},
+}
+
+// Extractor copies a value from a successful response into a variable, e.g.
+// $.token into {{token}}. A regex takes its first group if it has one.
+type Extractor struct {
+	Source     string `json:"source"`
+	Expression string `json:"expression"`
+	Variable   string `json:"variable"`
+}
+
+// ExtractorsEditor edits the extractors of a request.
+type ExtractorsEditor struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+	rows      []*extractorRow
+}
+
+type extractorRow struct {
+	sourceSelect    *widget.Select
+	expressionEntry *widget.Entry
+	variableEntry   *widget.Entry
+	container       *fyne.Container
+}
+
+func NewExtractorsEditor() *ExtractorsEditor {
+	e := &ExtractorsEditor{}
+
+	e.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Extractor", theme.ContentAddIcon(), func() {
+		e.addRow(Extractor{Source: ExtractJSONPath})
+	})
+
+	hint := widget.NewLabel("After a successful response, extracted values are stored in the variables of the active environment, or the globals.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	e.container = container.NewBorder(
+		hint,
+		container.NewHBox(addButton),
+		nil,
+		nil,
+		container.NewVScroll(e.rowsBox),
+	)
+
+	return e
+}
+
+func (e *ExtractorsEditor) addRow(extractor Extractor) {
+	row := &extractorRow{
+		expressionEntry: widget.NewEntry(),
+		variableEntry:   widget.NewEntry(),
+	}
+
+	labels := make([]string, len(extractorSourceLabels))
+	for i, s := range extractorSourceLabels {
+		labels[i] = s.label
+	}
+	row.sourceSelect = widget.NewSelect(labels, func(label string) {
+		for _, s := range extractorSourceLabels {
+			if s.label == label {
+				row.expressionEntry.SetPlaceHolder(s.hint)
+			}
+		}
+	})
+	for _, s := range extractorSourceLabels {
+		if s.source == extractor.Source {
+			row.sourceSelect.SetSelected(s.label)
+		}
+	}
+	row.expressionEntry.SetText(extractor.Expression)
+	row.variableEntry.SetPlaceHolder("Variable name")
+	row.variableEntry.SetText(extractor.Variable)
+
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.removeRow(row)
+	})
+
+	row.container = container.NewBorder(nil, nil, row.sourceSelect, removeButton,
+		container.NewGridWithColumns(2, row.expressionEntry, row.variableEntry),
+	)
+
+	e.rows = append(e.rows, row)
+	e.rowsBox.Add(row.container)
+}
+
+func (e *ExtractorsEditor) removeRow(row *extractorRow) {
+	for i, r := range e.rows {
+		if r == row {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+	e.rowsBox.Remove(row.container)
+}
+
+// GetExtractors returns the rows with both an expression and a variable.
+func (e *ExtractorsEditor) GetExtractors() []Extractor {
+	extractors := make([]Extractor, 0, len(e.rows))
+	for _, row := range e.rows {
+		if row.expressionEntry.Text == "" || row.variableEntry.Text == "" {
+			continue
+		}
+		extractor := Extractor{
+			Expression: row.expressionEntry.Text,
+			Variable:   row.variableEntry.Text,
+		}
+		for _, s := range extractorSourceLabels {
+			if s.label == row.sourceSelect.Selected {
+				extractor.Source = s.source
+			}
+		}
+		extractors = append(extractors, extractor)
+	}
+	return extractors
+}
+
+func (e *ExtractorsEditor) SetExtractors(extractors []Extractor) {
+	e.rows = nil
+	e.rowsBox.RemoveAll()
+	for _, extractor := range extractors {
+		e.addRow(extractor)
+	}
+	e.rowsBox.Refresh()
+}
+
+func (e *ExtractorsEditor) GetContainer() *fyne.Container {
+	return e.container
+}
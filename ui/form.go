@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	formFieldText = "Text"
+	formFieldFile = "File"
+)
+
+// FormField is one part of a multipart/form-data body. For file fields Value
+// holds the path of the file on disk.
+type FormField struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	IsFile bool   `json:"file,omitempty"`
+}
+
+type FormEditor struct {
+	container    *fyne.Container
+	rowsBox      *fyne.Container
+	rows         []*formRow
+	parentWindow fyne.Window
+	OnChanged    func()
+}
+
+type formRow struct {
+	keyEntry   *widget.Entry
+	typeSelect *widget.Select
+	valueEntry *widget.Entry
+	fileLabel  *widget.Label
+	fileButton *widget.Button
+	filePath   string
+	container  *fyne.Container
+}
+
+func NewFormEditor(parentWindow fyne.Window) *FormEditor {
+	f := &FormEditor{parentWindow: parentWindow}
+
+	f.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Field", theme.ContentAddIcon(), func() {
+		f.addRow(FormField{})
+	})
+
+	f.container = container.NewBorder(
+		nil,
+		container.NewHBox(addButton),
+		nil,
+		nil,
+		container.NewVScroll(f.rowsBox),
+	)
+
+	return f
+}
+
+func (f *FormEditor) addRow(field FormField) {
+	row := &formRow{
+		keyEntry:   widget.NewEntry(),
+		valueEntry: widget.NewEntry(),
+		fileLabel:  widget.NewLabel("No file selected"),
+	}
+	row.keyEntry.SetPlaceHolder("Field")
+	row.keyEntry.SetText(field.Key)
+	row.valueEntry.SetPlaceHolder("Value")
+	row.keyEntry.OnChanged = func(string) { f.changed() }
+	row.valueEntry.OnChanged = func(string) { f.changed() }
+
+	row.fileButton = widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, f.parentWindow)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			row.setFile(reader.URI().Path())
+			f.changed()
+		}, f.parentWindow)
+	})
+
+	fileBox := container.NewBorder(nil, nil, row.fileButton, nil, row.fileLabel)
+	valueStack := container.NewStack(row.valueEntry, fileBox)
+
+	row.typeSelect = widget.NewSelect([]string{formFieldText, formFieldFile}, func(value string) {
+		if value == formFieldFile {
+			row.valueEntry.Hide()
+			fileBox.Show()
+		} else {
+			fileBox.Hide()
+			row.valueEntry.Show()
+		}
+		f.changed()
+	})
+
+	if field.IsFile {
+		row.setFile(field.Value)
+		row.typeSelect.SetSelected(formFieldFile)
+	} else {
+		row.valueEntry.SetText(field.Value)
+		row.typeSelect.SetSelected(formFieldText)
+	}
+
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		f.removeRow(row)
+	})
+
+	row.container = container.NewBorder(nil, nil, nil,
+		container.NewHBox(row.typeSelect, removeButton),
+		container.NewGridWithColumns(2, row.keyEntry, valueStack),
+	)
+
+	f.rows = append(f.rows, row)
+	f.rowsBox.Add(row.container)
+}
+
+func (r *formRow) setFile(path string) {
+	r.filePath = path
+	if path == "" {
+		r.fileLabel.SetText("No file selected")
+		return
+	}
+	r.fileLabel.SetText(filepath.Base(path))
+}
+
+func (f *FormEditor) removeRow(row *formRow) {
+	for i, r := range f.rows {
+		if r == row {
+			f.rows = append(f.rows[:i], f.rows[i+1:]...)
+			break
+		}
+	}
+	f.rowsBox.Remove(row.container)
+	f.changed()
+}
+
+func (f *FormEditor) changed() {
+	if f.OnChanged != nil {
+		f.OnChanged()
+	}
+}
+
+// GetFields returns the fields in display order, skipping rows with an empty name.
+func (f *FormEditor) GetFields() []FormField {
+	fields := make([]FormField, 0, len(f.rows))
+	for _, row := range f.rows {
+		if row.keyEntry.Text == "" {
+			continue
+		}
+		field := FormField{Key: row.keyEntry.Text}
+		if row.typeSelect.Selected == formFieldFile {
+			field.IsFile = true
+			field.Value = row.filePath
+		} else {
+			field.Value = row.valueEntry.Text
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (f *FormEditor) SetFields(fields []FormField) {
+	f.rows = nil
+	f.rowsBox.RemoveAll()
+	for _, field := range fields {
+		f.addRow(field)
+	}
+	f.rowsBox.Refresh()
+}
+
+func (f *FormEditor) GetContainer() *fyne.Container {
+	return f.container
+}
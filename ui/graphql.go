@@ -0,0 +1,388 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"golem/graphql"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxShownProblems bounds the query problems listed below the query; the
+// rest are counted.
+const maxShownProblems = 5
+
+// graphQLPayload is the JSON a GraphQL request is sent as, and stored as.
+// Variables that are not valid JSON are stored as a string, so that they
+// come back for editing; such a request is not sent.
+type graphQLPayload struct {
+	Query     string          `json:"query"`
+	Variables json.RawMessage `json:"variables,omitempty"`
+}
+
+// graphQLEditor edits the query and variables of a GraphQL request. With the
+// schema of the endpoint, which is introspected and cached per URL, the
+// query is checked as it is typed, the unknown fields and arguments are
+// underlined below it, and the schema can be browsed in a tree beside it.
+// Without one only the syntax is checked.
+type graphQLEditor struct {
+	container        fyne.CanvasObject
+	queryEntry       *widget.Entry
+	variablesEntry   *widget.Entry
+	problemsText     *widget.RichText
+	statusLabel      *widget.Label
+	introspectButton *widget.Button
+	tree             *widget.Tree
+	treeMessage      *widget.Label
+	descriptionLabel *widget.Label
+	schema           *graphql.Schema
+	fetchedAt        time.Time
+
+	onChanged    func()
+	onIntrospect func()
+}
+
+func newGraphQLEditor(onChanged, onIntrospect func()) *graphQLEditor {
+	e := &graphQLEditor{onChanged: onChanged, onIntrospect: onIntrospect}
+
+	e.queryEntry = widget.NewMultiLineEntry()
+	e.queryEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	e.queryEntry.SetPlaceHolder("query {\n  ...\n}")
+	e.queryEntry.OnChanged = func(string) {
+		e.validate()
+		e.onChanged()
+	}
+
+	e.variablesEntry = widget.NewMultiLineEntry()
+	e.variablesEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	e.variablesEntry.SetPlaceHolder(`{"id": "1"}`)
+	e.variablesEntry.OnChanged = func(string) {
+		e.onChanged()
+	}
+
+	e.problemsText = widget.NewRichText()
+	e.problemsText.Wrapping = fyne.TextWrapWord
+	e.problemsText.Hide()
+
+	e.statusLabel = widget.NewLabel("")
+	e.statusLabel.Wrapping = fyne.TextWrapWord
+	e.introspectButton = widget.NewButtonWithIcon("Introspect", theme.SearchIcon(), func() {
+		e.onIntrospect()
+	})
+
+	e.tree = widget.NewTree(e.childIDs, e.isBranch,
+		func(bool) fyne.CanvasObject {
+			label := widget.NewLabel("field")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TreeNodeID, _ bool, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(e.describe(id))
+		},
+	)
+	e.tree.OnSelected = func(id widget.TreeNodeID) {
+		e.descriptionLabel.SetText(e.explain(id))
+	}
+	e.treeMessage = widget.NewLabel("")
+	e.treeMessage.Alignment = fyne.TextAlignCenter
+	e.treeMessage.Wrapping = fyne.TextWrapWord
+	e.descriptionLabel = widget.NewLabel("")
+	e.descriptionLabel.Wrapping = fyne.TextWrapWord
+
+	editors := container.NewVSplit(
+		container.NewBorder(nil, e.problemsText, nil, nil, e.queryEntry),
+		container.NewBorder(widget.NewLabel("Variables (JSON)"), nil, nil, nil, e.variablesEntry),
+	)
+	editors.Offset = 0.7
+	schemaPanel := container.NewBorder(
+		widget.NewLabelWithStyle("Schema", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		e.descriptionLabel, nil, nil,
+		container.NewStack(e.tree, e.treeMessage),
+	)
+	split := container.NewHSplit(editors, schemaPanel)
+	split.Offset = 0.65
+
+	e.container = container.NewBorder(
+		container.NewBorder(nil, nil, nil, e.introspectButton, e.statusLabel),
+		nil, nil, nil,
+		split,
+	)
+	e.setSchema(nil, time.Time{})
+	return e
+}
+
+// body returns the request body, or "" when there is no query nor
+// variables.
+func (e *graphQLEditor) body() string {
+	query := e.queryEntry.Text
+	variables := strings.TrimSpace(e.variablesEntry.Text)
+	if query == "" && variables == "" {
+		return ""
+	}
+
+	payload := graphQLPayload{Query: query}
+	if variables != "" {
+		if json.Valid([]byte(variables)) {
+			payload.Variables = json.RawMessage(variables)
+		} else {
+			payload.Variables, _ = json.Marshal(variables)
+		}
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(payload)
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// setBody shows a stored body. One that is not a GraphQL payload, such as a
+// raw body switched to GraphQL, is taken as the query.
+func (e *graphQLEditor) setBody(body string) {
+	var payload graphQLPayload
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		e.queryEntry.SetText(body)
+		e.variablesEntry.SetText("")
+		return
+	}
+
+	variables := ""
+	var text string
+	var indented bytes.Buffer
+	switch {
+	case len(payload.Variables) == 0 || string(payload.Variables) == "null":
+	case json.Unmarshal(payload.Variables, &text) == nil:
+		variables = text
+	case json.Indent(&indented, payload.Variables, "", "  ") == nil:
+		variables = indented.String()
+	}
+	e.queryEntry.SetText(payload.Query)
+	e.variablesEntry.SetText(variables)
+}
+
+// variablesError reports variables that cannot be sent.
+func (e *graphQLEditor) variablesError() error {
+	variables := strings.TrimSpace(e.variablesEntry.Text)
+	if variables == "" {
+		return nil
+	}
+	var object map[string]any
+	if err := json.Unmarshal([]byte(variables), &object); err != nil {
+		return fmt.Errorf("the GraphQL variables are not a JSON object: %w", err)
+	}
+	return nil
+}
+
+// setSchema shows schema, fetched at fetchedAt, in the tree and checks the
+// query against it. A nil schema clears both.
+func (e *graphQLEditor) setSchema(schema *graphql.Schema, fetchedAt time.Time) {
+	e.schema = schema
+	e.fetchedAt = fetchedAt
+	e.introspectButton.Enable()
+	e.descriptionLabel.SetText("")
+	e.tree.UnselectAll()
+	e.tree.CloseAllBranches()
+	e.tree.Refresh()
+
+	if schema == nil {
+		e.introspectButton.SetText("Introspect")
+		e.statusLabel.SetText("There is no schema for this URL yet: introspect it to check fields and arguments")
+		e.treeMessage.SetText("The schema is shown here once the endpoint has been introspected")
+		e.treeMessage.Show()
+		e.tree.Hide()
+	} else {
+		e.introspectButton.SetText("Refresh Schema")
+		e.statusLabel.SetText("Schema fetched " + e.fetchedTime())
+		e.treeMessage.Hide()
+		e.tree.Show()
+	}
+	e.validate()
+}
+
+func (e *graphQLEditor) fetchedTime() string {
+	return e.fetchedAt.Local().Format("2 Jan 2006 15:04")
+}
+
+// showIntrospecting disables Introspect while the query is being sent.
+func (e *graphQLEditor) showIntrospecting() {
+	e.introspectButton.Disable()
+	e.statusLabel.SetText("Introspecting...")
+}
+
+// showIntrospectionError explains why there is no new schema. The cached
+// one, if any, is still used.
+func (e *graphQLEditor) showIntrospectionError(err error) {
+	e.introspectButton.Enable()
+	if e.schema != nil {
+		e.statusLabel.SetText(fmt.Sprintf("Introspection failed: %v. The schema fetched %s is still used.", err, e.fetchedTime()))
+		return
+	}
+	e.statusLabel.SetText(fmt.Sprintf("Introspection failed: %v. Only the syntax of the query is checked.", err))
+}
+
+// validate lists the problems of the query below it, each with its line
+// and the offending part underlined.
+func (e *graphQLEditor) validate() {
+	query := e.queryEntry.Text
+	if strings.TrimSpace(query) == "" {
+		e.problemsText.Hide()
+		return
+	}
+	problems := graphql.Validate(e.schema, query)
+	if len(problems) == 0 {
+		e.problemsText.Hide()
+		return
+	}
+
+	lines := strings.Split(strings.ReplaceAll(query, "\r\n", "\n"), "\n")
+	var segments []widget.RichTextSegment
+	for i, problem := range problems {
+		if i == maxShownProblems {
+			segments = append(segments, &widget.TextSegment{
+				Text:  fmt.Sprintf("and %d more", len(problems)-maxShownProblems),
+				Style: widget.RichTextStyle{ColorName: theme.ColorNameError},
+			})
+			break
+		}
+		segments = append(segments, &widget.TextSegment{
+			Text:  fmt.Sprintf("Line %d, column %d: %s", problem.Line, problem.Column, problem.Message),
+			Style: widget.RichTextStyle{ColorName: theme.ColorNameError},
+		})
+		if problem.Line <= len(lines) {
+			segments = append(segments, underlined(lines[problem.Line-1], problem.Column-1, problem.Length)...)
+		}
+	}
+	e.problemsText.Segments = segments
+	e.problemsText.Refresh()
+	e.problemsText.Show()
+}
+
+// underlined returns line in monospace with length characters from start
+// underlined. Its indentation is left out.
+func underlined(line string, start, length int) []widget.RichTextSegment {
+	runes := []rune(line)
+	indent := len(runes) - len([]rune(strings.TrimLeft(line, " \t")))
+	start = min(max(start, indent), len(runes))
+	end := min(start+length, len(runes))
+
+	code := fyne.TextStyle{Monospace: true}
+	return []widget.RichTextSegment{
+		&widget.TextSegment{Text: string(runes[indent:start]), Style: widget.RichTextStyle{Inline: true, TextStyle: code}},
+		&widget.TextSegment{Text: string(runes[start:end]), Style: widget.RichTextStyle{
+			Inline:    true,
+			ColorName: theme.ColorNameError,
+			TextStyle: fyne.TextStyle{Monospace: true, Underline: true},
+		}},
+		&widget.TextSegment{Text: string(runes[end:]), Style: widget.RichTextStyle{TextStyle: code}},
+	}
+}
+
+// The IDs of the schema tree are paths from a root type: field names, and
+// ~Type for the members of a union, separated by slashes, which names
+// cannot contain.
+
+// node returns the type at id and, unless id is a root type or a union
+// member, the field it is reached by.
+func (e *graphQLEditor) node(id widget.TreeNodeID) (*graphql.Type, *graphql.Field) {
+	if e.schema == nil || id == "" {
+		return nil, nil
+	}
+	parts := strings.Split(id, "/")
+	t := e.schema.Types[parts[0]]
+	var field *graphql.Field
+	for _, part := range parts[1:] {
+		if t == nil {
+			return nil, nil
+		}
+		if member, ok := strings.CutPrefix(part, "~"); ok {
+			t, field = e.schema.Types[member], nil
+			continue
+		}
+		if field = t.Field(part); field == nil {
+			return nil, nil
+		}
+		t = e.schema.Types[field.Type.Named()]
+	}
+	return t, field
+}
+
+func (e *graphQLEditor) childIDs(id widget.TreeNodeID) []widget.TreeNodeID {
+	if e.schema == nil {
+		return nil
+	}
+	if id == "" {
+		return e.schema.RootTypes()
+	}
+	t, _ := e.node(id)
+	if t == nil {
+		return nil
+	}
+	var children []widget.TreeNodeID
+	for _, field := range t.Fields {
+		children = append(children, id+"/"+field.Name)
+	}
+	for _, member := range t.PossibleTypes {
+		if t.Kind == graphql.KindUnion {
+			children = append(children, id+"/~"+member.Named())
+		}
+	}
+	return children
+}
+
+func (e *graphQLEditor) isBranch(id widget.TreeNodeID) bool {
+	if id == "" {
+		return true
+	}
+	t, _ := e.node(id)
+	return t != nil && t.IsComposite()
+}
+
+func (e *graphQLEditor) describe(id widget.TreeNodeID) string {
+	t, field := e.node(id)
+	switch {
+	case field != nil && field.IsDeprecated:
+		return field.Signature() + " (deprecated)"
+	case field != nil:
+		return field.Signature()
+	case t != nil && strings.Contains(id, "/"):
+		return "... on " + t.Name
+	case t != nil:
+		return t.Name
+	}
+	return ""
+}
+
+// explain returns what the schema says about the node at id, shown below
+// the tree when it is selected.
+func (e *graphQLEditor) explain(id widget.TreeNodeID) string {
+	t, field := e.node(id)
+	var lines []string
+	switch {
+	case field != nil:
+		lines = append(lines, field.Signature())
+		if field.Description != "" {
+			lines = append(lines, field.Description)
+		}
+		for _, arg := range field.Args {
+			if arg.Description != "" {
+				lines = append(lines, arg.Name+": "+arg.Description)
+			}
+		}
+		if field.IsDeprecated {
+			lines = append(lines, "Deprecated: "+field.DeprecationReason)
+		}
+	case t != nil:
+		lines = append(lines, t.Name)
+		if t.Description != "" {
+			lines = append(lines, t.Description)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	"golem/grpc"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// GRPCCall describes a unary call made from the gRPC tab.
+type GRPCCall struct {
+	Address  string
+	TLS      bool
+	Service  string
+	Method   string
+	Request  string
+	Metadata []KeyValue
+}
+
+// GRPCPanel is the gRPC tab: the server address, the services and methods
+// found through reflection, the request message as JSON with its metadata,
+// and the response. Reflection and calls are left to the callbacks; the Set
+// methods must be called on the main thread.
+type GRPCPanel struct {
+	container      *fyne.Container
+	addressEntry   *widget.Entry
+	tlsCheck       *widget.Check
+	loadButton     *widget.Button
+	serviceSelect  *widget.Select
+	methodSelect   *widget.Select
+	requestEntry   *widget.Entry
+	metadataEditor *KeyValueEditor
+	invokeButton   *widget.Button
+	statusLabel    *widget.Label
+	responseEntry  *widget.Entry
+	metadataView   *widget.Entry
+	methods        []grpc.Method
+	// loading is set while a call from the history is shown, so selecting
+	// its service does not list the methods
+	loading      bool
+	parentWindow fyne.Window
+
+	OnLoadServices    func(address string, useTLS bool)
+	OnServiceSelected func(address string, useTLS bool, service string)
+	OnInvoke          func(call GRPCCall)
+}
+
+func NewGRPCPanel(parentWindow fyne.Window) *GRPCPanel {
+	p := &GRPCPanel{parentWindow: parentWindow}
+
+	p.addressEntry = widget.NewEntry()
+	p.addressEntry.SetPlaceHolder("host:port")
+	p.tlsCheck = widget.NewCheck("TLS", nil)
+
+	p.loadButton = widget.NewButtonWithIcon("Load Services", theme.ViewRefreshIcon(), func() {
+		address := strings.TrimSpace(p.addressEntry.Text)
+		if address == "" {
+			dialog.ShowError(errors.New("enter the server address as host:port"), p.parentWindow)
+			return
+		}
+		if p.OnLoadServices != nil {
+			p.OnLoadServices(address, p.tlsCheck.Checked)
+		}
+	})
+
+	p.serviceSelect = widget.NewSelect(nil, func(service string) {
+		p.SetMethods(nil)
+		if service != "" && !p.loading && p.OnServiceSelected != nil {
+			p.OnServiceSelected(strings.TrimSpace(p.addressEntry.Text), p.tlsCheck.Checked, service)
+		}
+	})
+	p.serviceSelect.PlaceHolder = "(load the services first)"
+
+	p.methodSelect = widget.NewSelect(nil, func(label string) {
+		method, ok := p.selectedMethod()
+		if !ok {
+			return
+		}
+		if method.Unary() {
+			p.invokeButton.Enable()
+		} else {
+			p.invokeButton.Disable()
+		}
+		if !p.loading {
+			p.requestEntry.SetText(method.RequestTemplate)
+		}
+	})
+	p.methodSelect.PlaceHolder = "(select a service)"
+
+	p.requestEntry = widget.NewMultiLineEntry()
+	p.requestEntry.SetPlaceHolder(`{"name": "value"}`)
+	p.requestEntry.TextStyle = fyne.TextStyle{Monospace: true}
+
+	templateButton := widget.NewButton("Reset to Template", func() {
+		if method, ok := p.selectedMethod(); ok {
+			p.requestEntry.SetText(method.RequestTemplate)
+		}
+	})
+
+	p.metadataEditor = NewKeyValueEditor("Metadata key", "Value", "Add Metadata")
+
+	p.invokeButton = widget.NewButtonWithIcon("Invoke", theme.MediaPlayIcon(), p.invoke)
+	p.invokeButton.Importance = widget.HighImportance
+	p.invokeButton.Disable()
+
+	p.statusLabel = widget.NewLabel("Status: -")
+	p.statusLabel.TextStyle = fyne.TextStyle{Bold: true}
+	p.responseEntry = widget.NewMultiLineEntry()
+	p.responseEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	p.responseEntry.Disable()
+	p.metadataView = widget.NewMultiLineEntry()
+	p.metadataView.Disable()
+
+	topBar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(p.tlsCheck, p.loadButton, p.invokeButton),
+		p.addressEntry,
+	)
+	methodRow := container.NewGridWithColumns(2, p.serviceSelect, p.methodSelect)
+
+	requestTabs := container.NewAppTabs(
+		container.NewTabItem("Message", container.NewBorder(nil, container.NewHBox(templateButton), nil, nil, p.requestEntry)),
+		container.NewTabItem("Metadata", p.metadataEditor.GetContainer()),
+	)
+	responseTabs := container.NewAppTabs(
+		container.NewTabItem("Message", p.responseEntry),
+		container.NewTabItem("Headers & Trailers", p.metadataView),
+	)
+
+	split := container.NewVSplit(requestTabs, container.NewBorder(p.statusLabel, nil, nil, nil, responseTabs))
+	split.SetOffset(0.4)
+
+	p.container = container.NewBorder(container.NewVBox(topBar, methodRow), nil, nil, nil, split)
+	return p
+}
+
+func (p *GRPCPanel) selectedMethod() (grpc.Method, bool) {
+	for _, method := range p.methods {
+		if methodLabel(method) == p.methodSelect.Selected {
+			return method, true
+		}
+	}
+	return grpc.Method{}, false
+}
+
+func methodLabel(method grpc.Method) string {
+	if method.Unary() {
+		return method.Name
+	}
+	return method.Name + " (streaming, cannot be invoked)"
+}
+
+func (p *GRPCPanel) invoke() {
+	method, ok := p.selectedMethod()
+	if !ok {
+		dialog.ShowError(errors.New("select a service and method"), p.parentWindow)
+		return
+	}
+	if p.OnInvoke != nil {
+		p.OnInvoke(GRPCCall{
+			Address:  strings.TrimSpace(p.addressEntry.Text),
+			TLS:      p.tlsCheck.Checked,
+			Service:  p.serviceSelect.Selected,
+			Method:   method.Name,
+			Request:  p.requestEntry.Text,
+			Metadata: p.metadataEditor.GetPairs(),
+		})
+	}
+}
+
+// SetServices offers services for selection.
+func (p *GRPCPanel) SetServices(services []string) {
+	p.serviceSelect.ClearSelected()
+	p.serviceSelect.SetOptions(services)
+	p.serviceSelect.PlaceHolder = "(select a service)"
+	p.serviceSelect.Refresh()
+	p.SetMethods(nil)
+}
+
+// SetMethods offers the methods of the selected service; streaming methods
+// are listed but cannot be invoked.
+func (p *GRPCPanel) SetMethods(methods []grpc.Method) {
+	p.methods = methods
+	labels := make([]string, len(methods))
+	for i, method := range methods {
+		labels[i] = methodLabel(method)
+	}
+	p.methodSelect.ClearSelected()
+	p.methodSelect.SetOptions(labels)
+	p.invokeButton.Disable()
+}
+
+// SetCall shows a call from the history. Its service and method are offered
+// without asking the server, which is only contacted on Invoke.
+func (p *GRPCPanel) SetCall(call GRPCCall) {
+	p.loading = true
+	defer func() { p.loading = false }()
+
+	p.addressEntry.SetText(call.Address)
+	p.tlsCheck.SetChecked(call.TLS)
+	p.serviceSelect.SetOptions([]string{call.Service})
+	p.serviceSelect.SetSelected(call.Service)
+	p.SetMethods([]grpc.Method{{Name: call.Method, RequestTemplate: call.Request}})
+	p.methodSelect.SetSelected(call.Method)
+	p.requestEntry.SetText(call.Request)
+	p.metadataEditor.SetPairs(call.Metadata)
+}
+
+// SetRunning disables Invoke while a call is in flight.
+func (p *GRPCPanel) SetRunning(running bool) {
+	if running {
+		p.statusLabel.SetText("Status: Calling...")
+		p.invokeButton.Disable()
+		return
+	}
+	if method, ok := p.selectedMethod(); ok && method.Unary() {
+		p.invokeButton.Enable()
+	}
+}
+
+// SetResult shows the outcome of a call; ok colours the status as a success.
+func (p *GRPCPanel) SetResult(status string, ok bool, message, metadata string) {
+	p.statusLabel.SetText("Status: " + status)
+	p.statusLabel.Importance = widget.DangerImportance
+	if ok {
+		p.statusLabel.Importance = widget.SuccessImportance
+	}
+	p.statusLabel.Refresh()
+	p.responseEntry.SetText(message)
+	p.metadataView.SetText(metadata)
+}
+
+func (p *GRPCPanel) GetContainer() *fyne.Container {
+	return p.container
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+)
+
+// UsedHeaderNameLimit is how many header names from the history are offered
+// on top of the common ones.
+const UsedHeaderNameLimit = 200
+
+// commonHeaderNames are the request headers offered before any have been
+// used.
+var commonHeaderNames = []string{
+	"Accept",
+	"Accept-Charset",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Authorization",
+	"Cache-Control",
+	"Connection",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Type",
+	"Cookie",
+	"DNT",
+	"Expect",
+	"Forwarded",
+	"From",
+	"Host",
+	"If-Match",
+	"If-Modified-Since",
+	"If-None-Match",
+	"If-Range",
+	"If-Unmodified-Since",
+	"Idempotency-Key",
+	"Origin",
+	"Pragma",
+	"Prefer",
+	"Proxy-Authorization",
+	"Range",
+	"Referer",
+	"TE",
+	"Upgrade",
+	"User-Agent",
+	"Via",
+	"X-API-Key",
+	"X-Correlation-ID",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"X-HTTP-Method-Override",
+	"X-Request-ID",
+	"X-Requested-With",
+}
+
+var mimeTypes = []string{
+	"application/json",
+	"application/json; charset=utf-8",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"application/octet-stream",
+	"application/pdf",
+	"application/graphql",
+	"application/ld+json",
+	"application/problem+json",
+	"application/vnd.api+json",
+	"application/x-ndjson",
+	"multipart/form-data",
+	"text/plain",
+	"text/plain; charset=utf-8",
+	"text/html",
+	"text/csv",
+	"text/xml",
+	"text/event-stream",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"image/svg+xml",
+}
+
+// headerValueHints are the values offered for well-known headers, by
+// lower-case name. The headers whose value is a comma-separated list are
+// completed one item at a time, see HeaderValueSeparator.
+var headerValueHints = map[string][]string{
+	"accept":          append([]string{"*/*"}, mimeTypes...),
+	"content-type":    mimeTypes,
+	"accept-encoding": {"gzip", "deflate", "br", "zstd", "identity", "*"},
+	"accept-language": {"en-US", "en", "de", "fr", "es", "*"},
+	"authorization":   {"Bearer ", "Basic "},
+	"cache-control": {
+		"no-cache", "no-store", "no-transform", "only-if-cached",
+		"max-age=0", "max-stale", "min-fresh=", "must-revalidate",
+	},
+	"connection":       {"keep-alive", "close", "Upgrade"},
+	"content-encoding": {"gzip", "deflate", "br", "zstd"},
+	"expect":           {"100-continue"},
+	"pragma":           {"no-cache"},
+	"prefer":           {"return=minimal", "return=representation", "respond-async", "wait="},
+	"te":               {"trailers", "gzip", "deflate"},
+	"upgrade":          {"websocket", "h2c"},
+	"x-requested-with": {"XMLHttpRequest"},
+}
+
+// listValuedHeaders hold a comma-separated list of items.
+var listValuedHeaders = map[string]bool{
+	"accept":          true,
+	"accept-encoding": true,
+	"accept-language": true,
+	"cache-control":   true,
+	"connection":      true,
+	"te":              true,
+}
+
+// HeaderNameSuggestions returns the common header names and the names in
+// used, each once ignoring case, in alphabetical order.
+func HeaderNameSuggestions(used []string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range append(append([]string{}, commonHeaderNames...), used...) {
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// HeaderValueSuggestions returns the values offered for the header name, or
+// nil if it is not a well-known one.
+func HeaderValueSuggestions(name string) []string {
+	return headerValueHints[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// HeaderValueSeparator returns "," for headers whose value is a list, so
+// each item is completed on its own, and "" otherwise.
+func HeaderValueSeparator(name string) string {
+	if listValuedHeaders[strings.ToLower(strings.TrimSpace(name))] {
+		return ","
+	}
+	return ""
+}
@@ -1,33 +1,131 @@
 package ui
 
 import (
+	"encoding/json"
 	"fmt"
 	"golem/storage"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	storagefilter "fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
+// starPath is a five-pointed star; starOutlinePath cuts a smaller one,
+// drawn the other way round, out of it, leaving its outline.
+const (
+	starPath        = "M12 2.3L14.5 9.3L22 9.6L16.1 14.1L18.2 21.3L12 17.1L5.8 21.3L7.9 14.1L2 9.6L9.5 9.3Z"
+	starOutlinePath = starPath + "M12 5.3L10.2 10.3L4.9 10.5L9.1 13.7L7.6 18.9L12 15.9L16.4 18.9L14.9 13.7L19.1 10.5L13.8 10.3Z"
+)
+
+// starIcon marks a history entry that can be starred, starFilledIcon one
+// that is.
+var (
+	starIcon       = theme.NewThemedResource(starResource("star.svg", starOutlinePath))
+	starFilledIcon = theme.NewPrimaryThemedResource(starResource("star-filled.svg", starPath))
+)
+
+func starResource(name, path string) fyne.Resource {
+	return fyne.NewStaticResource(name, []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path fill="#000000" d="`+path+`"/></svg>`))
+}
+
+// HistoryRetention limits how much history is kept; 0 leaves a limit off.
+// Starred entries are kept regardless of MaxEntries and MaxDays.
+type HistoryRetention struct {
+	MaxEntries int `json:"max_entries"`
+	MaxDays    int `json:"max_days"`
+	// MaxBodyKB is how much of a response body is stored with an entry
+	MaxBodyKB int `json:"max_body_kb"`
+}
+
 type HistoryPanel struct {
-	container     *fyne.Container
-	historyList   *widget.List
-	searchEntry   *widget.Entry
+	container    *fyne.Container
+	historyList  *widget.List
+	searchEntry  *widget.Entry
+	methodSelect *widget.Select
+	statusSelect *widget.Select
+	// favoritesOnly limits the list to starred entries
+	favoritesOnly  bool
+	favoritesCheck *widget.Check
+	titleLabel     *widget.Label
+	clearFilters   *widget.Button
+	// dateSelect picks one of the dateRange options; a custom range is from
+	// customFrom up to customTo, either of which may be zero
+	dateSelect    *widget.Select
+	dateLabel     *widget.Label
+	previousDate  string
+	customFrom    time.Time
+	customTo      time.Time
 	db            *storage.DB
 	history       []*storage.RequestHistory
-	onRequestLoad func(url, method string)
-	parentWindow  fyne.Window
+	onRequestLoad func(item *storage.RequestHistory)
+	// grouped collapses each run of consecutive entries with the same
+	// method and URL into one row; a run is expanded when the ID of one of
+	// its entries is in expanded. rows are what the list shows of history.
+	grouped    bool
+	groupCheck *widget.Check
+	expanded   map[int]bool
+	rows       []historyRow
+	// selectedID is the entry selected in the list, 0 for none; reselecting
+	// is set while its row is selected again after the list changed, which
+	// does not load it again
+	selectedID  int
+	reselecting bool
+	// selectMode shows a check on each row, those checked being deleted
+	// together
+	selectMode   bool
+	checked      map[int]bool
+	selectButton *widget.Button
+	deleteButton *widget.Button
+	undoToast    *widget.PopUp
+	// total is the number of entries the search and filters match, of which
+	// history holds the pages loaded so far; generation counts the loads
+	// from the first page, so that a page arriving late is dropped
+	total       int
+	loadingMore bool
+	generation  int
+	// timeThresholds colour the response time of each entry
+	timeThresholds TimeThresholds
+	retention      HistoryRetention
+	parentWindow   fyne.Window
+
+	// OnResend sends an HTTP entry again, and compares the new response
+	// with the stored one when compare is set
+	OnResend func(item *storage.RequestHistory, compare bool)
+	// OnGroupedChanged is called when grouping is turned on or off
+	OnGroupedChanged func(on bool)
 }
 
-func NewHistoryPanel(db *storage.DB, onRequestLoad func(url, method string), parentWindow fyne.Window) *HistoryPanel {
+// historyRow is a row of the history list. The row of the newest entry of a
+// run has the run, newest first; when the run is expanded, its other
+// entries follow on rows of their own marked as members.
+type historyRow struct {
+	item   *storage.RequestHistory
+	run    []*storage.RequestHistory
+	member bool
+}
+
+func NewHistoryPanel(db *storage.DB, onRequestLoad func(item *storage.RequestHistory), parentWindow fyne.Window) *HistoryPanel {
 	hp := &HistoryPanel{
 		db:            db,
 		onRequestLoad: onRequestLoad,
 		parentWindow:  parentWindow,
 		history:       []*storage.RequestHistory{},
+		checked:       map[int]bool{},
+		expanded:      map[int]bool{},
+
+		timeThresholds: DefaultTimeThresholds(),
 	}
 
 	hp.createUI()
@@ -37,22 +135,63 @@ func NewHistoryPanel(db *storage.DB, onRequestLoad func(url, method string), par
 }
 
 func (hp *HistoryPanel) createUI() {
+	hp.titleLabel = widget.NewLabelWithStyle("Request History", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	hp.groupCheck = widget.NewCheck("Group repeats", func(on bool) {
+		hp.setGrouped(on)
+		if hp.OnGroupedChanged != nil {
+			hp.OnGroupedChanged(on)
+		}
+	})
 	hp.searchEntry = widget.NewEntry()
 	hp.searchEntry.SetPlaceHolder("Search history...")
-	hp.searchEntry.OnChanged = func(text string) {
-		hp.searchHistory(text)
+	hp.searchEntry.OnChanged = func(string) {
+		hp.loadHistory()
 	}
 
+	hp.favoritesCheck = widget.NewCheck("Favorites only", func(on bool) {
+		hp.favoritesOnly = on
+		hp.loadHistory()
+	})
+
 	searchBar := container.NewBorder(nil, nil, nil,
-		widget.NewButtonWithIcon("", theme.SearchIcon(), func() {
-			hp.searchHistory(hp.searchEntry.Text)
-		}),
+		widget.NewButtonWithIcon("", theme.SearchIcon(), hp.loadHistory),
 		hp.searchEntry,
 	)
 
+	hp.methodSelect = widget.NewSelect(hp.methodOptions(nil), func(string) {
+		hp.loadHistory()
+	})
+	hp.methodSelect.Selected = anyMethodOption
+	statusOptions := append([]string{anyStatusOption}, historyStatusClasses...)
+	hp.statusSelect = widget.NewSelect(statusOptions, func(string) {
+		hp.loadHistory()
+	})
+	hp.statusSelect.Selected = anyStatusOption
+	hp.clearFilters = widget.NewButtonWithIcon("Clear filters", theme.ContentClearIcon(), hp.clearFilter)
+	hp.clearFilters.Importance = widget.WarningImportance
+	hp.clearFilters.Hide()
+
+	hp.dateSelect = widget.NewSelect([]string{anyTimeOption, todayOption, lastWeekOption, customRangeOption}, func(option string) {
+		if option == customRangeOption {
+			hp.editCustomRange()
+			return
+		}
+		hp.previousDate = option
+		hp.loadHistory()
+	})
+	hp.dateSelect.Selected = anyTimeOption
+	hp.previousDate = anyTimeOption
+	hp.dateLabel = widget.NewLabel("")
+	hp.dateLabel.Hide()
+
+	filterBar := container.NewVBox(
+		container.NewHBox(hp.methodSelect, hp.statusSelect, hp.dateSelect),
+		container.NewHBox(hp.favoritesCheck, hp.dateLabel, hp.clearFilters),
+	)
+
 	hp.historyList = widget.NewList(
 		func() int {
-			return len(hp.history)
+			return len(hp.rows)
 		},
 		func() fyne.CanvasObject {
 			methodLabel := widget.NewLabel("METHOD")
@@ -60,13 +199,34 @@ func (hp *HistoryPanel) createUI() {
 			urlLabel := widget.NewLabel("https://example.com/api")
 			timeLabel := widget.NewLabel("2 min ago")
 			statusLabel := widget.NewLabel("200 OK")
+			durationLabel := widget.NewLabel("120 ms")
+			starButton := widget.NewButtonWithIcon("", starIcon, nil)
+			starButton.Importance = widget.LowImportance
+			menuButton := widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), nil)
+			menuButton.Importance = widget.LowImportance
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			deleteButton.Importance = widget.LowImportance
+			groupButton := widget.NewButtonWithIcon("", theme.MenuExpandIcon(), nil)
+			groupButton.Importance = widget.LowImportance
+			// Indents the entries of an expanded run
+			indent := canvas.NewRectangle(color.Transparent)
+			indent.SetMinSize(fyne.NewSize(theme.IconInlineSize(), 0))
 
 			topRow := container.NewHBox(
+				indent,
+				widget.NewCheck("", nil),
+				starButton,
+				groupButton,
 				methodLabel,
 				widget.NewSeparator(),
 				statusLabel,
 				widget.NewSeparator(),
+				durationLabel,
+				widget.NewSeparator(),
 				timeLabel,
+				layout.NewSpacer(),
+				menuButton,
+				deleteButton,
 			)
 
 			return container.NewVBox(
@@ -76,77 +236,249 @@ func (hp *HistoryPanel) createUI() {
 			)
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i >= len(hp.history) {
+			if i >= len(hp.rows) {
 				return
 			}
+			if i >= len(hp.rows)-historyPrefetch {
+				hp.loadMore()
+			}
 
-			item := hp.history[i]
+			row := hp.rows[i]
+			item := row.item
 			cont := o.(*fyne.Container)
 
 			// The structure is: VBox containing [HBox, Label, Separator]
 			hbox := cont.Objects[0].(*fyne.Container)
 			urlLabel := cont.Objects[1].(*widget.Label)
 
-			// HBox contains [Label, Separator, Label, Separator, Label]
-			methodLabel := hbox.Objects[0].(*widget.Label)
-			statusLabel := hbox.Objects[2].(*widget.Label)
-			timeLabel := hbox.Objects[4].(*widget.Label)
+			// HBox contains [Rectangle, Check, Button, Button, Label, Separator, Label, Separator, Label, Separator, Label, Spacer, Button, Button]
+			indent := hbox.Objects[0]
+			check := hbox.Objects[1].(*widget.Check)
+			starButton := hbox.Objects[2].(*widget.Button)
+			groupButton := hbox.Objects[3].(*widget.Button)
+			methodLabel := hbox.Objects[4].(*widget.Label)
+			statusLabel := hbox.Objects[6].(*widget.Label)
+			durationLabel := hbox.Objects[8].(*widget.Label)
+			durationSeparator := hbox.Objects[9]
+			timeLabel := hbox.Objects[10].(*widget.Label)
+			menuButton := hbox.Objects[12].(*widget.Button)
+			deleteButton := hbox.Objects[13].(*widget.Button)
+
+			// A run shows how many entries it has, and expands to list them
+			if row.member {
+				indent.Show()
+			} else {
+				indent.Hide()
+			}
+			if len(row.run) > 1 {
+				groupButton.SetText(fmt.Sprintf("×%d", len(row.run)))
+				if hp.isExpanded(row.run) {
+					groupButton.SetIcon(theme.MenuDropDownIcon())
+				} else {
+					groupButton.SetIcon(theme.MenuExpandIcon())
+				}
+				run := row.run
+				groupButton.OnTapped = func() {
+					hp.toggleExpanded(run)
+				}
+				groupButton.Show()
+			} else {
+				groupButton.Hide()
+			}
+
+			// In select mode each row has a check; otherwise the selected
+			// row can be deleted on its own
+			check.OnChanged = nil
+			check.SetChecked(hp.checked[item.ID])
+			check.OnChanged = func(on bool) {
+				hp.setChecked(item.ID, on)
+			}
+			if hp.selectMode {
+				check.Show()
+			} else {
+				check.Hide()
+			}
+			deleteButton.OnTapped = func() {
+				hp.deleteEntries([]int{item.ID})
+			}
+			if !hp.selectMode && item.ID == hp.selectedID {
+				deleteButton.Show()
+			} else {
+				deleteButton.Hide()
+			}
+
+			if item.IsFavorite {
+				starButton.SetIcon(starFilledIcon)
+			} else {
+				starButton.SetIcon(starIcon)
+			}
+			starButton.OnTapped = func() {
+				hp.toggleFavorite(item)
+			}
+
+			// Only HTTP requests can be sent again from here
+			if item.Kind == "" && hp.OnResend != nil && !hp.selectMode {
+				menuButton.OnTapped = func() {
+					hp.showRowMenu(item, menuButton)
+				}
+				menuButton.Show()
+			} else {
+				menuButton.Hide()
+			}
 
 			methodLabel.SetText(item.Method)
 			methodLabel.TextStyle = fyne.TextStyle{Bold: true}
 
-			urlLabel.SetText(item.URL)
-			statusLabel.SetText(item.ResponseStatus)
+			if item.ResolvedURL != "" {
+				urlLabel.SetText(item.URL + " → " + item.ResolvedURL)
+			} else {
+				urlLabel.SetText(item.URL)
+			}
+			status := item.ResponseStatus
+			if item.Source == storage.HistorySourceMonitor {
+				status += " (monitor)"
+			}
+			if item.ComparisonID != "" {
+				status += " (comparison " + item.ComparisonID[:min(len(item.ComparisonID), 6)] + ")"
+			}
+			if item.Stats != "" {
+				status += " (repeated)"
+			}
+			if item.RemoteAddr != "" {
+				status += " (" + item.RemoteAddr + ")"
+			}
+			if item.InsecureTLS {
+				status += " (TLS not verified)"
+			}
+			if item.TestResults != "" {
+				var results []AssertionResult
+				if json.Unmarshal([]byte(item.TestResults), &results) == nil && !AllPassed(results) {
+					status += " (tests failed)"
+				}
+			}
+			if item.Partial {
+				status += " (partial)"
+			}
+			if item.DownloadPath != "" {
+				status += fmt.Sprintf(" (saved %s to %s)", FormatBytes(int64(item.ResponseSize)), filepath.Base(item.DownloadPath))
+			}
+			if item.Kind == storage.HistoryKindWebSocket {
+				var messages []WebSocketMessage
+				if json.Unmarshal([]byte(item.Transcript), &messages) == nil {
+					frames := 0
+					for _, message := range messages {
+						if message.Type == WebSocketText || message.Type == WebSocketBinary {
+							frames++
+						}
+					}
+					status += fmt.Sprintf(" (%d messages)", frames)
+				}
+			}
+			statusLabel.Importance = StatusImportance(item.ResponseStatus)
+			statusLabel.SetText(status)
+
+			// The response time of an HTTP request, coloured by how slow it was
+			if item.Kind == "" && item.ResponseStatus != "Error" {
+				responseTime := time.Duration(item.ResponseTimeMs) * time.Millisecond
+				durationLabel.Importance = hp.timeThresholds.Importance(responseTime)
+				durationLabel.SetText(fmt.Sprintf("%d ms", item.ResponseTimeMs))
+				durationLabel.Show()
+				durationSeparator.Show()
+			} else {
+				durationLabel.Hide()
+				durationSeparator.Hide()
+			}
 
 			timeLabel.SetText(hp.formatTime(item.Timestamp))
+
+			// Rows are reused, so the controls shown and hidden above are laid
+			// out again
+			hbox.Refresh()
 		},
 	)
 
 	hp.historyList.OnSelected = func(id widget.ListItemID) {
-		if id >= 0 && id < len(hp.history) {
-			item := hp.history[id]
-			hp.onRequestLoad(item.URL, item.Method)
+		if id < 0 || id >= len(hp.rows) {
+			return
+		}
+		item := hp.rows[id].item
+		if hp.selectMode {
+			// A tap checks the row rather than loading it
+			hp.historyList.Unselect(id)
+			hp.setChecked(item.ID, !hp.checked[item.ID])
+			hp.historyList.RefreshItem(id)
+			return
+		}
+		hp.selectedID = item.ID
+		hp.historyList.RefreshItem(id)
+		if hp.reselecting {
+			return
+		}
+		// The list holds summaries; the whole entry is read when opened
+		full, err := hp.db.GetRequestHistoryByID(item.ID)
+		if err != nil {
+			dialog.ShowError(err, hp.parentWindow)
+			return
+		}
+		if full != nil {
+			hp.onRequestLoad(full)
+		}
+	}
+	hp.historyList.OnUnselected = func(id widget.ListItemID) {
+		if hp.selectMode || hp.reselecting || id < 0 || id >= len(hp.rows) {
+			return
+		}
+		if hp.rows[id].item.ID == hp.selectedID {
+			hp.selectedID = 0
 		}
+		hp.historyList.RefreshItem(id)
 	}
 
+	hp.selectButton = widget.NewButtonWithIcon("Select", theme.CheckButtonCheckedIcon(), func() {
+		hp.setSelectMode(!hp.selectMode)
+	})
+	hp.deleteButton = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		var ids []int
+		for _, item := range hp.history {
+			if hp.checked[item.ID] {
+				ids = append(ids, item.ID)
+			}
+		}
+		hp.deleteEntries(ids)
+	})
+	hp.deleteButton.Importance = widget.DangerImportance
+	hp.deleteButton.Hide()
+
 	clearButton := widget.NewButtonWithIcon("Clear History", theme.ContentClearIcon(), func() {
-		dialog.ShowConfirm("Clear History",
-			"Are you sure you want to clear all request history?",
+		includeFavorites := widget.NewCheck("Also delete starred requests", nil)
+		dialog.ShowCustomConfirm("Clear History", "Clear", "Cancel",
+			container.NewVBox(
+				widget.NewLabel("Are you sure you want to clear the request history?\nStarred requests are kept unless deleted too."),
+				includeFavorites,
+			),
 			func(confirmed bool) {
 				if confirmed {
-					hp.clearHistory()
+					hp.clearHistory(includeFavorites.Checked)
 				}
 			}, hp.parentWindow)
 	})
 
-	exportButton := widget.NewButtonWithIcon("Export", theme.DownloadIcon(), func() {
-		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
-			if err != nil {
-				dialog.ShowError(err, hp.parentWindow)
-				return
-			}
-			if writer == nil {
-				return
-			}
-			defer writer.Close()
-
-			if err := hp.db.ExportHistory(writer.URI().Path()); err != nil {
-				dialog.ShowError(err, hp.parentWindow)
-			} else {
-				dialog.ShowInformation("Success", "History exported successfully", hp.parentWindow)
-			}
-		}, hp.parentWindow)
-	})
+	exportButton := widget.NewButtonWithIcon("Export", theme.DownloadIcon(), hp.showExportDialog)
+	importButton := widget.NewButtonWithIcon("Import", theme.UploadIcon(), hp.importHistory)
 
 	buttonBar := container.NewHBox(
 		clearButton,
 		exportButton,
+		importButton,
+		hp.selectButton,
+		hp.deleteButton,
 	)
 
 	hp.container = container.NewBorder(
 		container.NewVBox(
-			widget.NewLabelWithStyle("Request History", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+			container.NewBorder(nil, nil, nil, hp.groupCheck, hp.titleLabel),
 			searchBar,
+			filterBar,
 		),
 		buttonBar,
 		nil,
@@ -155,40 +487,703 @@ func (hp *HistoryPanel) createUI() {
 	)
 }
 
+// historyPageSize is how many entries are loaded at a time, the next page
+// when the list is scrolled within historyPrefetch rows of its end.
+const (
+	historyPageSize = 100
+	historyPrefetch = 10
+)
+
+// The options of the method, status and date filters
+const (
+	anyMethodOption   = "Any method"
+	anyStatusOption   = "Any status"
+	anyTimeOption     = "Any time"
+	todayOption       = "Today"
+	lastWeekOption    = "Last 7 days"
+	customRangeOption = "Custom range…"
+)
+
+// historyTimeLayouts are the ways a custom range can be typed, the first
+// also the way it is shown.
+var historyTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+var historyStatusClasses = []string{
+	storage.HistoryStatus2xx,
+	storage.HistoryStatus3xx,
+	storage.HistoryStatus4xx,
+	storage.HistoryStatus5xx,
+	storage.HistoryStatusError,
+}
+
+// methodOptions lists the standard methods, then those of used that are
+// not, such as WS, gRPC or custom ones.
+func (hp *HistoryPanel) methodOptions(used []string) []string {
+	options := append([]string{anyMethodOption}, standardMethods...)
+	for _, method := range used {
+		if !slices.Contains(options, method) {
+			options = append(options, method)
+		}
+	}
+	return options
+}
+
+// filter is what the search and the filters above the list select.
+func (hp *HistoryPanel) filter() storage.HistoryFilter {
+	filter := storage.HistoryFilter{
+		Search:        hp.searchEntry.Text,
+		FavoritesOnly: hp.favoritesOnly,
+	}
+	if hp.methodSelect.Selected != anyMethodOption {
+		filter.Method = hp.methodSelect.Selected
+	}
+	if hp.statusSelect.Selected != anyStatusOption {
+		filter.StatusClass = hp.statusSelect.Selected
+	}
+
+	// The relative ranges are taken from now on every load, so that Today
+	// moves on at midnight
+	now := time.Now()
+	switch hp.dateSelect.Selected {
+	case todayOption:
+		filter.From = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	case lastWeekOption:
+		filter.From = now.AddDate(0, 0, -7)
+	case customRangeOption:
+		filter.From, filter.To = hp.customFrom, hp.customTo
+	}
+	return filter
+}
+
+// editCustomRange asks for the start and end of a custom date range; a
+// date alone is taken as the start of that day for From and its end for
+// To. Cancelling goes back to the range chosen before.
+func (hp *HistoryPanel) editCustomRange() {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(historyTimeLayouts[0])
+	}
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("2006-01-02 15:04, or empty for no start")
+	fromEntry.SetText(formatTime(hp.customFrom))
+	fromEntry.Validator = validateHistoryTime
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("2006-01-02 15:04, or empty for no end")
+	// An end typed as a date is stored as the start of the next day
+	if !hp.customTo.IsZero() && hp.customTo.Hour() == 0 && hp.customTo.Minute() == 0 {
+		toEntry.SetText(hp.customTo.AddDate(0, 0, -1).Format(historyTimeLayouts[1]))
+	} else {
+		toEntry.SetText(formatTime(hp.customTo))
+	}
+	toEntry.Validator = validateHistoryTime
+
+	form := dialog.NewForm("Custom Range", "Apply", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("From", fromEntry),
+			widget.NewFormItem("To", toEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				hp.dateSelect.Selected = hp.previousDate
+				hp.dateSelect.Refresh()
+				return
+			}
+			from, _, _ := parseHistoryTime(fromEntry.Text)
+			to, dateOnly, _ := parseHistoryTime(toEntry.Text)
+			if dateOnly {
+				to = to.AddDate(0, 0, 1)
+			}
+			if !from.IsZero() && !to.IsZero() && !to.After(from) {
+				dialog.ShowError(fmt.Errorf("the end of the range must be after its start"), hp.parentWindow)
+				hp.dateSelect.Selected = hp.previousDate
+				hp.dateSelect.Refresh()
+				return
+			}
+			hp.customFrom, hp.customTo = from, to
+			hp.previousDate = customRangeOption
+			hp.loadHistory()
+		}, hp.parentWindow)
+	form.Resize(fyne.NewSize(420, form.MinSize().Height))
+	form.Show()
+}
+
+// parseHistoryTime reads a time in local time typed in one of the
+// historyTimeLayouts, telling whether it was a date alone; "" is the zero
+// time.
+func parseHistoryTime(text string) (t time.Time, dateOnly bool, err error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, false, nil
+	}
+	for i, layout := range historyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, i == 1, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("enter a date as YYYY-MM-DD, optionally followed by a time as HH:MM")
+}
+
+func validateHistoryTime(text string) error {
+	_, _, err := parseHistoryTime(text)
+	return err
+}
+
+// describeRange shows a custom range, e.g. "From 2024-03-05 12:00 to
+// 2024-03-05 18:00".
+func describeRange(from, to time.Time) string {
+	switch {
+	case from.IsZero() && to.IsZero():
+		return "Any time"
+	case to.IsZero():
+		return "From " + from.Format(historyTimeLayouts[0])
+	case from.IsZero():
+		return "Before " + to.Format(historyTimeLayouts[0])
+	}
+	return "From " + from.Format(historyTimeLayouts[0]) + " to " + to.Format(historyTimeLayouts[0])
+}
+
+// clearFilter resets the search and the filters, showing all of the
+// history again.
+func (hp *HistoryPanel) clearFilter() {
+	hp.searchEntry.Text = ""
+	hp.searchEntry.Refresh()
+	hp.methodSelect.Selected = anyMethodOption
+	hp.methodSelect.Refresh()
+	hp.statusSelect.Selected = anyStatusOption
+	hp.statusSelect.Refresh()
+	hp.favoritesOnly = false
+	hp.favoritesCheck.Checked = false
+	hp.favoritesCheck.Refresh()
+	hp.dateSelect.Selected = anyTimeOption
+	hp.dateSelect.Refresh()
+	hp.previousDate = anyTimeOption
+	hp.customFrom, hp.customTo = time.Time{}, time.Time{}
+	hp.loadHistory()
+}
+
+// loadHistory lists the newest entries the search and filters select, and
+// shows whether any are active.
 func (hp *HistoryPanel) loadHistory() {
-	history, err := hp.db.GetRequestHistory(100, 0)
+	filter := hp.filter()
+	if hp.dateSelect.Selected == customRangeOption {
+		hp.dateLabel.SetText(describeRange(filter.From, filter.To))
+		hp.dateLabel.Show()
+	} else {
+		hp.dateLabel.Hide()
+	}
+	if filter == (storage.HistoryFilter{}) {
+		hp.clearFilters.Hide()
+	} else {
+		hp.clearFilters.Show()
+	}
+
+	if methods, err := hp.db.GetHistoryMethods(); err == nil {
+		hp.methodSelect.SetOptions(hp.methodOptions(methods))
+	}
+
+	total, err := hp.db.GetRequestHistoryCount(filter)
 	if err != nil {
 		dialog.ShowError(err, hp.parentWindow)
 		return
 	}
+	history, err := hp.db.GetRequestHistorySummaries(filter, historyPageSize, 0)
+	if err != nil {
+		dialog.ShowError(err, hp.parentWindow)
+		return
+	}
+
+	hp.generation++
+	hp.loadingMore = false
+	hp.total = total
+	hp.updateTitle()
+	hp.setHistory(history)
+}
+
+// loadMore loads the next page of entries in the background, unless it is
+// being loaded or there is none.
+func (hp *HistoryPanel) loadMore() {
+	if hp.loadingMore || len(hp.history) >= hp.total {
+		return
+	}
+	hp.loadingMore = true
+	filter, offset, generation := hp.filter(), len(hp.history), hp.generation
+
+	go func() {
+		page, err := hp.db.GetRequestHistorySummaries(filter, historyPageSize, offset)
+		fyne.Do(func() {
+			if generation != hp.generation {
+				return
+			}
+			hp.loadingMore = false
+			if err != nil {
+				dialog.ShowError(err, hp.parentWindow)
+				return
+			}
+			// Entries added or deleted since moved the offset; the rows
+			// already listed are not listed twice
+			listed := map[int]bool{}
+			for _, item := range hp.history {
+				listed[item.ID] = true
+			}
+			history := hp.history
+			for _, item := range page {
+				if !listed[item.ID] {
+					history = append(history, item)
+				}
+			}
+			if len(page) < historyPageSize {
+				// The count was off; there are no more
+				hp.total = len(history)
+				hp.updateTitle()
+			}
+			hp.setHistory(history)
+		})
+	}()
+}
+
+// updateTitle shows how many entries there are, or match the search and
+// filters.
+func (hp *HistoryPanel) updateTitle() {
+	noun := "requests"
+	if hp.total == 1 {
+		noun = "request"
+	}
+	if hp.filter() == (storage.HistoryFilter{}) {
+		hp.titleLabel.SetText(fmt.Sprintf("Request History (%d %s)", hp.total, noun))
+	} else {
+		hp.titleLabel.SetText(fmt.Sprintf("Request History (%d %s match)", hp.total, noun))
+	}
+}
 
+// setHistory shows history, keeping the selected entry selected, at its
+// new row, if it is still listed. In select mode the selection waits for
+// the mode to end.
+func (hp *HistoryPanel) setHistory(history []*storage.RequestHistory) {
 	hp.history = history
+	hp.rows = hp.groupRows()
+	if hp.selectMode {
+		// Rows are checked rather than selected
+		hp.historyList.Refresh()
+		return
+	}
+	hp.reselecting = true
+	hp.historyList.UnselectAll()
+	for i, row := range hp.rows {
+		if row.item.ID == hp.selectedID {
+			hp.historyList.Select(i)
+			break
+		}
+	}
+	hp.reselecting = false
 	hp.historyList.Refresh()
 }
 
-func (hp *HistoryPanel) searchHistory(searchTerm string) {
-	if searchTerm == "" {
-		hp.loadHistory()
+// groupRows lays history out in rows, a run of entries with the same
+// method and URL on one row while grouped.
+func (hp *HistoryPanel) groupRows() []historyRow {
+	var rows []historyRow
+	for i := 0; i < len(hp.history); {
+		end := i + 1
+		for hp.grouped && end < len(hp.history) && sameRequest(hp.history[i], hp.history[end]) {
+			end++
+		}
+		run := hp.history[i:end]
+		rows = append(rows, historyRow{item: run[0], run: run})
+		if len(run) > 1 && hp.isExpanded(run) {
+			for _, item := range run[1:] {
+				rows = append(rows, historyRow{item: item, member: true})
+			}
+		}
+		i = end
+	}
+	return rows
+}
+
+// sameRequest reports whether a and b were sent to the same URL with the
+// same method, and so are grouped.
+func sameRequest(a, b *storage.RequestHistory) bool {
+	return a.Kind == b.Kind && a.Method == b.Method && a.URL == b.URL
+}
+
+func (hp *HistoryPanel) isExpanded(run []*storage.RequestHistory) bool {
+	for _, item := range run {
+		if hp.expanded[item.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleExpanded lists the entries of run on their own rows, or folds them
+// back into its first.
+func (hp *HistoryPanel) toggleExpanded(run []*storage.RequestHistory) {
+	if hp.isExpanded(run) {
+		for _, item := range run {
+			delete(hp.expanded, item.ID)
+		}
+	} else {
+		hp.expanded[run[0].ID] = true
+	}
+	hp.setHistory(hp.history)
+}
+
+// SetGrouped groups runs of the same request, or lists every entry.
+func (hp *HistoryPanel) SetGrouped(on bool) {
+	hp.groupCheck.Checked = on
+	hp.groupCheck.Refresh()
+	hp.setGrouped(on)
+}
+
+func (hp *HistoryPanel) setGrouped(on bool) {
+	hp.grouped = on
+	hp.expanded = map[int]bool{}
+	hp.setHistory(hp.history)
+}
+
+// setSelectMode turns the checks on the rows on or off, off clearing them.
+func (hp *HistoryPanel) setSelectMode(on bool) {
+	hp.selectMode = on
+	hp.checked = map[int]bool{}
+	if on {
+		hp.selectButton.SetText("Done")
+		hp.deleteButton.Show()
+		hp.reselecting = true
+		hp.historyList.UnselectAll()
+		hp.reselecting = false
+	} else {
+		hp.selectButton.SetText("Select")
+		hp.deleteButton.Hide()
+		hp.setHistory(hp.history)
+	}
+	hp.updateDeleteButton()
+	hp.historyList.Refresh()
+}
+
+func (hp *HistoryPanel) setChecked(id int, on bool) {
+	if on {
+		hp.checked[id] = true
+	} else {
+		delete(hp.checked, id)
+	}
+	hp.updateDeleteButton()
+}
+
+// updateDeleteButton counts the checked entries on the Delete button.
+func (hp *HistoryPanel) updateDeleteButton() {
+	hp.deleteButton.SetText(fmt.Sprintf("Delete (%d)", len(hp.checked)))
+	if len(hp.checked) == 0 {
+		hp.deleteButton.Disable()
+	} else {
+		hp.deleteButton.Enable()
+	}
+}
+
+// deleteEntries deletes the entries with ids and takes their rows out of
+// the list, offering to undo it for a few seconds.
+func (hp *HistoryPanel) deleteEntries(ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	// The list holds summaries, so the whole entries are kept for undo
+	var removed []*storage.RequestHistory
+	for _, id := range ids {
+		full, err := hp.db.GetRequestHistoryByID(id)
+		if err != nil {
+			dialog.ShowError(err, hp.parentWindow)
+			return
+		}
+		if full != nil {
+			removed = append(removed, full)
+		}
+	}
+	if err := hp.db.DeleteRequestHistoryEntries(ids); err != nil {
+		dialog.ShowError(err, hp.parentWindow)
 		return
 	}
 
-	history, err := hp.db.SearchRequestHistory(searchTerm, 100)
-	if err != nil {
+	deleted := map[int]bool{}
+	for _, id := range ids {
+		deleted[id] = true
+		delete(hp.checked, id)
+	}
+	var kept []*storage.RequestHistory
+	for _, item := range hp.history {
+		if !deleted[item.ID] {
+			kept = append(kept, item)
+		}
+	}
+	if deleted[hp.selectedID] {
+		hp.selectedID = 0
+	}
+	hp.total -= len(removed)
+	hp.updateTitle()
+	hp.setHistory(kept)
+	hp.updateDeleteButton()
+
+	message := "Deleted 1 request from history"
+	if len(removed) != 1 {
+		message = fmt.Sprintf("Deleted %d requests from history", len(removed))
+	}
+	if hp.undoToast != nil {
+		hp.undoToast.Hide()
+	}
+	hp.undoToast = ShowUndoToast(message, func() {
+		hp.restoreEntries(removed)
+	}, hp.parentWindow)
+}
+
+// restoreEntries undoes deleteEntries, putting the rows back in their
+// place by time.
+func (hp *HistoryPanel) restoreEntries(removed []*storage.RequestHistory) {
+	if err := hp.db.RestoreRequestHistory(removed); err != nil {
 		dialog.ShowError(err, hp.parentWindow)
 		return
 	}
 
-	hp.history = history
+	history := append([]*storage.RequestHistory{}, hp.history...)
+	for _, item := range removed {
+		history = append(history, item.Summary())
+	}
+	hp.total += len(removed)
+	hp.updateTitle()
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.After(history[j].Timestamp)
+	})
+	hp.setHistory(history)
+}
+
+// clearHistory deletes the history, keeping the starred entries unless
+// includeFavorites is set.
+func (hp *HistoryPanel) clearHistory(includeFavorites bool) {
+	if err := hp.db.ClearRequestHistory(includeFavorites); err != nil {
+		dialog.ShowError(err, hp.parentWindow)
+		return
+	}
+
+	hp.loadHistory()
+}
+
+// toggleFavorite stars or unstars item; an unstarred entry leaves the list
+// while it shows only favorites.
+func (hp *HistoryPanel) toggleFavorite(item *storage.RequestHistory) {
+	if err := hp.db.UpdateRequestHistoryFavorite(item.ID, !item.IsFavorite); err != nil {
+		dialog.ShowError(err, hp.parentWindow)
+		return
+	}
+	item.IsFavorite = !item.IsFavorite
+
+	if hp.favoritesOnly && !item.IsFavorite {
+		for i, entry := range hp.history {
+			if entry == item {
+				hp.total--
+				hp.updateTitle()
+				hp.setHistory(append(hp.history[:i], hp.history[i+1:]...))
+				return
+			}
+		}
+	}
 	hp.historyList.Refresh()
 }
 
-func (hp *HistoryPanel) clearHistory() {
-	if err := hp.db.ClearRequestHistory(); err != nil {
+// showRowMenu offers the actions on the entry of a row in a menu below its
+// menu button.
+func (hp *HistoryPanel) showRowMenu(item *storage.RequestHistory, button *widget.Button) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Resend", func() {
+			hp.resend(item, false)
+		}),
+		fyne.NewMenuItem("Resend and Compare", func() {
+			hp.resend(item, true)
+		}),
+	)
+	canvas := fyne.CurrentApp().Driver().CanvasForObject(button)
+	widget.ShowPopUpMenuAtRelativePosition(menu, canvas, fyne.NewPos(0, button.Size().Height), button)
+}
+
+// resend reads the whole of an entry, as the list holds summaries, and
+// passes it to OnResend.
+func (hp *HistoryPanel) resend(item *storage.RequestHistory, compare bool) {
+	full, err := hp.db.GetRequestHistoryByID(item.ID)
+	if err != nil {
 		dialog.ShowError(err, hp.parentWindow)
 		return
 	}
+	if full == nil {
+		dialog.ShowInformation("Resend", "This history entry has been deleted.", hp.parentWindow)
+		return
+	}
+	hp.OnResend(full, compare)
+}
+
+// The formats history can be exported in
+const (
+	exportJSON = "JSON"
+	exportCSV  = "CSV"
+	exportHAR  = "HAR"
+)
+
+// showExportDialog asks for the format to export the history in, and for
+// CSV whether to include the bodies, then for the file to write. HAR, for
+// browsers and other HTTP tools, has only the HTTP requests.
+func (hp *HistoryPanel) showExportDialog() {
+	bodiesCheck := widget.NewCheck("Include request and response bodies", nil)
+	bodiesCheck.Disable()
+	formatSelect := widget.NewSelect([]string{exportJSON, exportCSV, exportHAR}, func(format string) {
+		// JSON and HAR always have the whole entries
+		if format == exportCSV {
+			bodiesCheck.Enable()
+		} else {
+			bodiesCheck.Disable()
+		}
+	})
+	formatSelect.SetSelected(exportJSON)
+
+	dialog.ShowForm("Export History", "Export", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Format", formatSelect),
+			widget.NewFormItem("", bodiesCheck),
+		},
+		func(confirmed bool) {
+			if confirmed {
+				hp.exportHistory(formatSelect.Selected, bodiesCheck.Checked)
+			}
+		}, hp.parentWindow)
+}
+
+// exportHistory writes the history to a file chosen by the user, in format
+// unless the name chosen ends in .json, .csv or .har.
+func (hp *HistoryPanel) exportHistory(format string, includeBodies bool) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, hp.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		switch strings.ToLower(writer.URI().Extension()) {
+		case ".json":
+			format = exportJSON
+		case ".csv":
+			format = exportCSV
+		case ".har":
+			format = exportHAR
+		}
+		switch format {
+		case exportCSV:
+			err = hp.db.ExportHistoryCSV(writer, includeBodies)
+		case exportHAR:
+			err = hp.db.ExportHistoryHAR(writer)
+		default:
+			err = hp.db.ExportHistory(writer.URI().Path())
+		}
+		if err != nil {
+			dialog.ShowError(err, hp.parentWindow)
+		} else {
+			dialog.ShowInformation("Success", "History exported successfully", hp.parentWindow)
+		}
+	}, hp.parentWindow)
+	save.SetFileName("history." + strings.ToLower(format))
+	save.Show()
+}
+
+// importHistory adds the requests in a file chosen by the user to the
+// history, from a HAR file or a JSON export.
+func (hp *HistoryPanel) importHistory() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, hp.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		var count int
+		if strings.ToLower(reader.URI().Extension()) == ".har" {
+			count, err = hp.db.ImportHAR(reader)
+		} else {
+			count, err = hp.db.ImportHistory(reader.URI().Path())
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("import failed: %w", err), hp.parentWindow)
+			return
+		}
+		hp.loadHistory()
+		noun := "requests"
+		if count == 1 {
+			noun = "request"
+		}
+		ShowToast(fmt.Sprintf("Imported %d %s", count, noun), hp.parentWindow)
+	}, hp.parentWindow)
+	open.SetFilter(storagefilter.NewExtensionFileFilter([]string{".har", ".json"}))
+	open.Show()
+}
+
+// SetRetention sets how much history is kept, taking effect from the next
+// entry added or PruneHistory.
+func (hp *HistoryPanel) SetRetention(retention HistoryRetention) {
+	hp.retention = retention
+}
+
+// PruneHistory deletes the entries the retention limits no longer keep, and
+// says how many that were and how much data they held.
+func (hp *HistoryPanel) PruneHistory() {
+	result := hp.prune()
+	if len(result.IDs) == 0 {
+		return
+	}
+	noun := "entries"
+	if len(result.IDs) == 1 {
+		noun = "entry"
+	}
+	ShowToast(fmt.Sprintf("Removed %d old history %s, %s", len(result.IDs), noun, FormatBytes(result.Bytes)), hp.parentWindow)
+}
+
+// prune deletes the entries the retention limits no longer keep and takes
+// their rows out of the list.
+func (hp *HistoryPanel) prune() *storage.PruneResult {
+	result, err := hp.db.PruneRequestHistory(hp.retention.MaxEntries, hp.retention.MaxDays)
+	if err != nil {
+		fmt.Printf("Failed to prune history: %v\n", err)
+		return &storage.PruneResult{}
+	}
+	if len(result.IDs) == 0 {
+		return result
+	}
 
-	hp.history = []*storage.RequestHistory{}
+	pruned := map[int]bool{}
+	for _, id := range result.IDs {
+		pruned[id] = true
+	}
+	var kept []*storage.RequestHistory
+	for _, item := range hp.history {
+		if !pruned[item.ID] {
+			kept = append(kept, item)
+		}
+	}
+	if pruned[hp.selectedID] {
+		hp.selectedID = 0
+	}
+	// Pruned entries not loaded yet may have matched the filters too, so
+	// the total is counted again
+	if total, err := hp.db.GetRequestHistoryCount(hp.filter()); err == nil {
+		hp.total = total
+	}
+	hp.updateTitle()
+	hp.setHistory(kept)
+	return result
+}
+
+// SetTimeThresholds sets the response times that colour entries as fast or
+// slow.
+func (hp *HistoryPanel) SetTimeThresholds(thresholds TimeThresholds) {
+	hp.timeThresholds = thresholds
 	hp.historyList.Refresh()
 }
 
@@ -223,16 +1218,38 @@ func (hp *HistoryPanel) formatTime(t time.Time) string {
 }
 
 func (hp *HistoryPanel) AddToHistory(req *storage.RequestHistory) {
-	if err := hp.db.SaveRequestHistory(req); err != nil {
+	stored := req
+	if limit := hp.retention.MaxBodyKB * 1024; limit > 0 && len(req.ResponseBody) > limit {
+		// A UTF-8 body is not cut inside a character
+		if req.ResponseCharset == "" {
+			for limit > 0 && !utf8.RuneStart(req.ResponseBody[limit]) {
+				limit--
+			}
+		}
+		truncated := *req
+		truncated.ResponseBody = req.ResponseBody[:limit]
+		stored = &truncated
+	}
+	if err := hp.db.SaveRequestHistory(stored); err != nil {
 		fmt.Printf("Failed to save request to history: %v\n", err)
 		return
 	}
+	req.ID = stored.ID
 
-	hp.history = append([]*storage.RequestHistory{req}, hp.history...)
-	if len(hp.history) > 100 {
-		hp.history = hp.history[:100]
+	// Whether a new entry belongs in a filtered list is up to the query
+	if hp.filter() != (storage.HistoryFilter{}) {
+		hp.prune()
+		hp.loadHistory()
+		return
 	}
-	hp.historyList.Refresh()
+	if !slices.Contains(hp.methodSelect.Options, req.Method) {
+		hp.methodSelect.SetOptions(append(hp.methodSelect.Options, req.Method))
+	}
+
+	hp.total++
+	hp.updateTitle()
+	hp.setHistory(append([]*storage.RequestHistory{req.Summary()}, hp.history...))
+	hp.prune()
 }
 
 func (hp *HistoryPanel) GetContainer() *fyne.Container {
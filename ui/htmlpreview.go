@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlSkippedElements hold nothing to read.
+var htmlSkippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// htmlBlockElements start a new paragraph.
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Header: true, atom.Footer: true, atom.Main: true, atom.Nav: true,
+	atom.Aside: true, atom.Blockquote: true, atom.Form: true, atom.Table: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Br: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Address: true,
+	atom.Fieldset: true, atom.Caption: true, atom.Details: true, atom.Summary: true,
+}
+
+// htmlDocument is an HTML page turned into rich text for reading. Images
+// are not part of the text; those with an absolute URL are listed so they
+// can be loaded on request.
+type htmlDocument struct {
+	segments []widget.RichTextSegment
+	images   []htmlImage
+}
+
+type htmlImage struct {
+	src string
+	alt string
+}
+
+// htmlConverter walks the tokens of a page, collecting the text of the
+// current paragraph until a block element ends it.
+type htmlConverter struct {
+	doc        htmlDocument
+	title      string
+	inline     []widget.RichTextSegment
+	skip       int
+	inTitle    bool
+	bold       int
+	italic     int
+	code       int
+	pre        int
+	preText    strings.Builder
+	link       string
+	linkText   strings.Builder
+	spaceAfter bool
+	// lists holds the next number of each open <ol>, or 0 for a <ul>
+	lists []int
+	cells int
+}
+
+// IsHTMLContentType reports whether contentType is an HTML page.
+func IsHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// convertHTML turns body into readable rich text: the title, headings,
+// paragraphs, lists, table rows, preformatted blocks, emphasis and links.
+// Scripts and styles are dropped and nothing is fetched. A link with an
+// absolute http(s) URL opens in the browser when clicked; any other link is
+// shown after its text.
+func convertHTML(body string) htmlDocument {
+	c := &htmlConverter{}
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			c.flush()
+			if c.title != "" {
+				title := &widget.TextSegment{Style: widget.RichTextStyleHeading, Text: c.title}
+				c.doc.segments = append([]widget.RichTextSegment{title}, c.doc.segments...)
+			}
+			return c.doc
+		case html.TextToken:
+			c.text(string(tokenizer.Text()))
+		case html.StartTagToken:
+			c.start(tokenizer.Token())
+		case html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			c.start(token)
+			c.end(token.DataAtom)
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			c.end(atom.Lookup(name))
+		}
+	}
+}
+
+func (c *htmlConverter) start(token html.Token) {
+	if token.DataAtom == atom.Title {
+		c.inTitle = true
+		return
+	}
+	if htmlSkippedElements[token.DataAtom] {
+		c.skip++
+	}
+	if c.skip > 0 {
+		return
+	}
+
+	switch token.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Tr:
+		c.flush()
+		c.cells = 0
+	case atom.Pre:
+		c.flush()
+		c.pre++
+	case atom.B, atom.Strong:
+		c.bold++
+	case atom.I, atom.Em, atom.Cite:
+		c.italic++
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		c.code++
+	case atom.A:
+		c.link = attribute(token, "href")
+		c.linkText.Reset()
+	case atom.Hr:
+		c.flush()
+		c.doc.segments = append(c.doc.segments, &widget.SeparatorSegment{})
+	case atom.Img:
+		alt := attribute(token, "alt")
+		if alt == "" {
+			alt = "image"
+		}
+		c.text("[" + alt + "]")
+		if src := attribute(token, "src"); isAbsoluteHTTPURL(src) {
+			c.doc.images = append(c.doc.images, htmlImage{src: src, alt: alt})
+		}
+	case atom.Ul, atom.Ol:
+		c.flush()
+		number := 0
+		if token.DataAtom == atom.Ol {
+			number = 1
+		}
+		c.lists = append(c.lists, number)
+	case atom.Li:
+		c.flush()
+		marker := "• "
+		if depth := len(c.lists); depth > 0 {
+			if number := c.lists[depth-1]; number > 0 {
+				marker = strconv.Itoa(number) + ". "
+				c.lists[depth-1]++
+			}
+			marker = strings.Repeat("    ", depth-1) + marker
+		}
+		c.add(marker, widget.RichTextStyleInline)
+	case atom.Td, atom.Th:
+		if c.cells > 0 {
+			c.add(" | ", widget.RichTextStyleInline)
+		}
+		c.cells++
+		if token.DataAtom == atom.Th {
+			c.bold++
+		}
+	default:
+		if htmlBlockElements[token.DataAtom] {
+			c.flush()
+		}
+	}
+}
+
+func (c *htmlConverter) end(name atom.Atom) {
+	if name == atom.Title {
+		c.inTitle = false
+		return
+	}
+	if htmlSkippedElements[name] && c.skip > 0 {
+		c.skip--
+		return
+	}
+	if c.skip > 0 {
+		return
+	}
+
+	switch name {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if c.link != "" {
+			c.endLink()
+		}
+		style := widget.RichTextStyleSubHeading
+		if name == atom.H1 || name == atom.H2 {
+			style = widget.RichTextStyleHeading
+		}
+		text := strings.TrimSpace(plainText(c.inline))
+		c.inline = nil
+		c.spaceAfter = false
+		if text != "" {
+			c.doc.segments = append(c.doc.segments, &widget.TextSegment{Style: style, Text: text})
+		}
+	case atom.Pre:
+		c.pre = max(c.pre-1, 0)
+		if c.pre == 0 {
+			if text := strings.Trim(c.preText.String(), "\n"); text != "" {
+				c.doc.segments = append(c.doc.segments, &widget.TextSegment{Style: widget.RichTextStyleCodeBlock, Text: text})
+			}
+			c.preText.Reset()
+		}
+	case atom.B, atom.Strong, atom.Th:
+		c.bold = max(c.bold-1, 0)
+	case atom.I, atom.Em, atom.Cite:
+		c.italic = max(c.italic-1, 0)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		c.code = max(c.code-1, 0)
+	case atom.A:
+		c.endLink()
+	case atom.Ul, atom.Ol:
+		c.flush()
+		if len(c.lists) > 0 {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+	case atom.Li, atom.Tr:
+		c.flush()
+	default:
+		if htmlBlockElements[name] {
+			c.flush()
+		}
+	}
+}
+
+// text adds text of the page with its whitespace collapsed, or as it is
+// inside <pre>.
+func (c *htmlConverter) text(text string) {
+	if c.inTitle {
+		c.title = strings.Join(strings.Fields(c.title+" "+text), " ")
+		return
+	}
+	if c.skip > 0 {
+		return
+	}
+	if c.pre > 0 {
+		c.preText.WriteString(text)
+		return
+	}
+
+	words := strings.Join(strings.Fields(text), " ")
+	startsWithSpace := text != "" && isHTMLSpace(text[0])
+	if words == "" {
+		c.spaceAfter = c.spaceAfter || startsWithSpace
+		return
+	}
+	if (startsWithSpace || c.spaceAfter) && (len(c.inline) > 0 || c.linkText.Len() > 0) {
+		words = " " + words
+	}
+	c.spaceAfter = isHTMLSpace(text[len(text)-1])
+
+	if c.link != "" {
+		c.linkText.WriteString(words)
+		return
+	}
+
+	style := widget.RichTextStyleInline
+	switch {
+	case c.code > 0:
+		style = widget.RichTextStyleCodeInline
+	case c.bold > 0:
+		style = widget.RichTextStyleStrong
+	case c.italic > 0:
+		style = widget.RichTextStyleEmphasis
+	}
+	c.add(words, style)
+}
+
+func (c *htmlConverter) add(text string, style widget.RichTextStyle) {
+	c.inline = append(c.inline, &widget.TextSegment{Style: style, Text: text})
+}
+
+// endLink adds the text of the link being read: clickable for an absolute
+// http(s) URL, or followed by the URL otherwise, so a relative link is
+// never requested against the wrong host.
+func (c *htmlConverter) endLink() {
+	text, href := c.linkText.String(), c.link
+	c.link = ""
+	c.linkText.Reset()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	if isAbsoluteHTTPURL(href) {
+		if trimmed := strings.TrimLeft(text, " "); trimmed != text {
+			c.add(" ", widget.RichTextStyleInline)
+			text = trimmed
+		}
+		target, _ := url.Parse(href)
+		c.inline = append(c.inline, &widget.HyperlinkSegment{Text: text, URL: target})
+		return
+	}
+	c.add(text, widget.RichTextStyleInline)
+	if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		c.add(" ["+href+"]", widget.RichTextStyleEmphasis)
+	}
+}
+
+// flush ends the current paragraph.
+func (c *htmlConverter) flush() {
+	if c.link != "" {
+		c.endLink()
+	}
+	c.spaceAfter = false
+	if strings.TrimSpace(plainText(c.inline)) != "" {
+		c.doc.segments = append(c.doc.segments, &widget.ParagraphSegment{Texts: c.inline})
+	}
+	c.inline = nil
+}
+
+func plainText(segments []widget.RichTextSegment) string {
+	var text strings.Builder
+	for _, segment := range segments {
+		text.WriteString(segment.Textual())
+	}
+	return text.String()
+}
+
+func attribute(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func isAbsoluteHTTPURL(rawURL string) bool {
+	target, err := url.Parse(rawURL)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != ""
+}
+
+func isHTMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
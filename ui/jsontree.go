@@ -0,0 +1,377 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// jsonTreeRootID is the node of the whole document; the IDs of the
+	// other nodes are their JSON paths from it
+	jsonTreeRootID = "$"
+	// expandAllLimit bounds how many branches Expand All opens, so a huge
+	// document does not lock the window
+	expandAllLimit = 2000
+	// jsonPreviewLength is how much of a value a node shows
+	jsonPreviewLength = 80
+)
+
+// jsonPathKeyPattern matches the keys a JSON path can write as .key.
+var jsonPathKeyPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$-]*$`)
+
+// jsonMember is a key of an object, in the order of the document.
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// jsonTreeNode is a node of the tree. Objects are []jsonMember and arrays
+// []any; the rest are json.Number, string, bool or nil.
+type jsonTreeNode struct {
+	key   string
+	value any
+}
+
+// JSONTreeView shows a JSON response as a tree of keys with their type and
+// a preview of their value. Children are listed only when a branch opens,
+// so arrays of many thousands of elements stay responsive. Selecting a node
+// shows its JSON path, and selecting a leaf copies its value. A string leaf
+// holding a URL has a button that loads it into the URL bar, or opens it in
+// the browser with Ctrl or Cmd held.
+type JSONTreeView struct {
+	// OnOpenURL loads the URL of a leaf into the URL bar
+	OnOpenURL func(url string)
+
+	container   *fyne.Container
+	tree        *widget.Tree
+	pathLabel   *widget.Label
+	statusLabel *widget.Label
+	content     *fyne.Container
+	messageView *widget.Label
+	loaded      bool
+	nodes       map[string]*jsonTreeNode
+	children    map[string][]string
+	generation  int
+}
+
+func NewJSONTreeView() *JSONTreeView {
+	v := &JSONTreeView{}
+
+	v.tree = widget.NewTree(v.childIDs, v.isBranch,
+		func(bool) fyne.CanvasObject {
+			label := widget.NewLabel("key")
+			label.Truncation = fyne.TextTruncateEllipsis
+			linkButton := widget.NewButton("Open", nil)
+			linkButton.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, linkButton, label)
+		},
+		func(id widget.TreeNodeID, _ bool, o fyne.CanvasObject) {
+			item := o.(*fyne.Container)
+			item.Objects[0].(*widget.Label).SetText(v.describe(id))
+			linkButton := item.Objects[1].(*widget.Button)
+			target, ok := v.link(id)
+			if !ok {
+				linkButton.Hide()
+				return
+			}
+			linkButton.OnTapped = func() {
+				openLink(target, v.OnOpenURL)
+			}
+			linkButton.Show()
+		},
+	)
+	v.tree.OnSelected = v.selectNode
+
+	v.pathLabel = widget.NewLabelWithStyle(jsonTreeRootID, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	v.pathLabel.Truncation = fyne.TextTruncateEllipsis
+	v.statusLabel = widget.NewLabel("")
+
+	copyPathButton := widget.NewButtonWithIcon("Copy Path", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(v.pathLabel.Text)
+		v.statusLabel.SetText("Path copied")
+	})
+	expandButton := widget.NewButton("Expand All", v.expandAll)
+	collapseButton := widget.NewButton("Collapse All", func() {
+		v.tree.CloseAllBranches()
+		v.tree.OpenBranch(jsonTreeRootID)
+	})
+
+	v.messageView = widget.NewLabel("")
+	v.messageView.Alignment = fyne.TextAlignCenter
+	v.messageView.Wrapping = fyne.TextWrapWord
+
+	v.content = container.NewBorder(
+		container.NewBorder(nil, nil, nil, container.NewHBox(expandButton, collapseButton), v.statusLabel),
+		container.NewBorder(nil, nil, nil, copyPathButton, v.pathLabel),
+		nil, nil,
+		v.tree,
+	)
+	v.container = container.NewStack(v.content, v.messageView)
+	v.SetJSON("")
+	return v
+}
+
+// SetJSON shows body as a tree, parsing it in the background; an empty body
+// clears the view.
+func (v *JSONTreeView) SetJSON(body string) {
+	v.generation++
+	v.reset(nil, false)
+	if strings.TrimSpace(body) == "" {
+		v.showMessage("The response body is not JSON")
+		return
+	}
+
+	v.showMessage("Parsing...")
+	generation := v.generation
+	go func() {
+		root, err := parseOrderedJSON(body)
+		fyne.Do(func() {
+			if generation != v.generation {
+				return
+			}
+			if err != nil {
+				v.showMessage(fmt.Sprintf("The response body is not valid JSON: %v", err))
+				return
+			}
+			v.reset(root, true)
+			v.messageView.Hide()
+			v.content.Show()
+			v.tree.OpenBranch(jsonTreeRootID)
+		})
+	}()
+}
+
+func (v *JSONTreeView) reset(root any, loaded bool) {
+	v.loaded = loaded
+	v.nodes = map[string]*jsonTreeNode{jsonTreeRootID: {key: jsonTreeRootID, value: root}}
+	v.children = map[string][]string{}
+	v.pathLabel.SetText(jsonTreeRootID)
+	v.statusLabel.SetText("")
+	v.tree.UnselectAll()
+	v.tree.CloseAllBranches()
+	v.tree.Refresh()
+}
+
+func (v *JSONTreeView) showMessage(message string) {
+	v.messageView.SetText(message)
+	v.messageView.Show()
+	v.content.Hide()
+}
+
+// childIDs lists the children of a node the first time it is asked for.
+func (v *JSONTreeView) childIDs(id widget.TreeNodeID) []widget.TreeNodeID {
+	if id == "" {
+		if !v.loaded {
+			return nil
+		}
+		return []string{jsonTreeRootID}
+	}
+	if ids, ok := v.children[id]; ok {
+		return ids
+	}
+	node := v.nodes[id]
+	if node == nil {
+		return nil
+	}
+
+	var ids []string
+	switch value := node.value.(type) {
+	case []jsonMember:
+		ids = make([]string, len(value))
+		for i, member := range value {
+			ids[i] = id + jsonPathKey(member.key)
+			v.nodes[ids[i]] = &jsonTreeNode{key: member.key, value: member.value}
+		}
+	case []any:
+		ids = make([]string, len(value))
+		for i, element := range value {
+			ids[i] = id + "[" + strconv.Itoa(i) + "]"
+			v.nodes[ids[i]] = &jsonTreeNode{key: "[" + strconv.Itoa(i) + "]", value: element}
+		}
+	}
+	v.children[id] = ids
+	return ids
+}
+
+func (v *JSONTreeView) isBranch(id widget.TreeNodeID) bool {
+	if id == "" {
+		return true
+	}
+	node := v.nodes[id]
+	if node == nil {
+		return false
+	}
+	switch node.value.(type) {
+	case []jsonMember, []any:
+		return true
+	}
+	return false
+}
+
+// describe returns the text of a node: its key, type and value preview.
+func (v *JSONTreeView) describe(id string) string {
+	node := v.nodes[id]
+	if node == nil {
+		return ""
+	}
+	switch value := node.value.(type) {
+	case []jsonMember:
+		return fmt.Sprintf("%s   object {%d}", node.key, len(value))
+	case []any:
+		return fmt.Sprintf("%s   array [%d]", node.key, len(value))
+	case string:
+		return fmt.Sprintf("%s   string   %s", node.key, truncatePreview(strconv.Quote(value)))
+	case json.Number:
+		return fmt.Sprintf("%s   number   %s", node.key, truncatePreview(value.String()))
+	case bool:
+		return fmt.Sprintf("%s   boolean   %t", node.key, value)
+	default:
+		return fmt.Sprintf("%s   null", node.key)
+	}
+}
+
+// link returns the URL a string leaf holds.
+func (v *JSONTreeView) link(id string) (string, bool) {
+	node := v.nodes[id]
+	if node == nil {
+		return "", false
+	}
+	text, ok := node.value.(string)
+	return text, ok && isLink(text)
+}
+
+// selectNode shows the path of the node and copies the value of a leaf.
+func (v *JSONTreeView) selectNode(id widget.TreeNodeID) {
+	v.pathLabel.SetText(id)
+	if v.isBranch(id) {
+		v.statusLabel.SetText("")
+		return
+	}
+
+	var value string
+	switch leaf := v.nodes[id].value.(type) {
+	case string:
+		value = leaf
+	case json.Number:
+		value = leaf.String()
+	case bool:
+		value = strconv.FormatBool(leaf)
+	default:
+		value = "null"
+	}
+	fyne.CurrentApp().Clipboard().SetContent(value)
+	v.statusLabel.SetText("Value copied")
+	// So clicking the leaf again copies it again
+	v.tree.Unselect(id)
+}
+
+// expandAll opens every branch, or the first expandAllLimit of them. The
+// tree opens them in one pass, which is much faster than a refresh per
+// branch; the branches past the limit are passed off as leaves meanwhile.
+func (v *JSONTreeView) expandAll() {
+	if !v.loaded {
+		return
+	}
+	budget := expandAllLimit
+	v.tree.IsBranch = func(id widget.TreeNodeID) bool {
+		if !v.isBranch(id) || budget == 0 {
+			return false
+		}
+		budget--
+		return true
+	}
+	v.tree.OpenAllBranches()
+	v.tree.IsBranch = v.isBranch
+
+	if budget == 0 {
+		v.statusLabel.SetText(fmt.Sprintf("Opened the first %d branches", expandAllLimit))
+	} else {
+		v.statusLabel.SetText("")
+	}
+}
+
+func (v *JSONTreeView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+// jsonPathKey returns the JSON path step for an object key: .key, or
+// ['key'] for keys that cannot be written that way.
+func jsonPathKey(key string) string {
+	if jsonPathKeyPattern.MatchString(key) {
+		return "." + key
+	}
+	if !strings.ContainsAny(key, "']") {
+		return "['" + key + "']"
+	}
+	return `["` + key + `"]`
+}
+
+func truncatePreview(text string) string {
+	runes := []rune(text)
+	if len(runes) > jsonPreviewLength {
+		return string(runes[:jsonPreviewLength-1]) + "…"
+	}
+	return text
+}
+
+// parseOrderedJSON parses body keeping the order of object keys and the
+// digits of numbers.
+func parseOrderedJSON(body string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		members := []jsonMember{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, jsonMember{key: keyToken.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return members, err
+	case json.Delim('['):
+		elements := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, value)
+		}
+		_, err := decoder.Token()
+		return elements, err
+	default:
+		return token, nil
+	}
+}
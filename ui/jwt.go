@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// JWTInfo is a decoded JWT and where it was found.
+type JWTInfo struct {
+	Source string
+	Token  string
+	// Header and Payload are pretty-printed JSON
+	Header  string
+	Payload string
+	// Times are the iat, nbf and exp claims the payload has
+	Times []JWTTime
+}
+
+// JWTTime is a claim holding a time, e.g. exp.
+type JWTTime struct {
+	Claim string
+	Time  time.Time
+}
+
+// describe returns the time of the claim with how long ago or ahead of now
+// it is, and how much that matters: an expired token or one not valid yet
+// is flagged.
+func (t JWTTime) describe(now time.Time) (string, widget.Importance) {
+	when := t.Time.Local().Format(time.DateTime)
+	switch t.Claim {
+	case "exp":
+		if now.After(t.Time) {
+			return fmt.Sprintf("%s (expired %s ago)", when, relativeDuration(now.Sub(t.Time))), widget.DangerImportance
+		}
+		return fmt.Sprintf("%s (expires in %s)", when, relativeDuration(t.Time.Sub(now))), widget.SuccessImportance
+	case "nbf":
+		if now.Before(t.Time) {
+			return fmt.Sprintf("%s (not valid for another %s)", when, relativeDuration(t.Time.Sub(now))), widget.DangerImportance
+		}
+	}
+	if now.Before(t.Time) {
+		return fmt.Sprintf("%s (in %s)", when, relativeDuration(t.Time.Sub(now))), widget.MediumImportance
+	}
+	return fmt.Sprintf("%s (%s ago)", when, relativeDuration(now.Sub(t.Time))), widget.MediumImportance
+}
+
+// jwtClaimNames are the names the time claims are shown with.
+var jwtClaimNames = map[string]string{
+	"iat": "Issued at (iat)",
+	"nbf": "Not before (nbf)",
+	"exp": "Expires (exp)",
+}
+
+// relativeDuration formats d in its largest unit, e.g. "5m" or "3d".
+func relativeDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+// ShowJWTDialog shows the header and payload of tokens, one at a time when
+// there are several, with the times of their iat, nbf and exp claims. The
+// signature can be verified by onVerify, which gets the token and the
+// secret, public key or JWKS URL typed in and is called off the main
+// thread, since fetching a JWKS takes a while. Nothing is fetched unless a
+// URL is typed in.
+func ShowJWTDialog(tokens []JWTInfo, onVerify func(token, key string) (string, error), parentWindow fyne.Window) {
+	headerEntry := widget.NewMultiLineEntry()
+	headerEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	headerEntry.Disable()
+	payloadEntry := widget.NewMultiLineEntry()
+	payloadEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	payloadEntry.Disable()
+	timesBox := container.New(layout.NewFormLayout())
+
+	keyEntry := widget.NewMultiLineEntry()
+	keyEntry.SetMinRowsVisible(2)
+	keyEntry.SetPlaceHolder("HMAC secret, PEM public key, JWK(S) or JWKS URL")
+	resultLabel := widget.NewLabel("")
+	resultLabel.Wrapping = fyne.TextWrapWord
+
+	var current JWTInfo
+	generation := 0
+	show := func(token JWTInfo) {
+		current = token
+		generation++
+		headerEntry.SetText(token.Header)
+		payloadEntry.SetText(token.Payload)
+		resultLabel.SetText("")
+
+		timesBox.RemoveAll()
+		now := time.Now()
+		for _, t := range token.Times {
+			text, importance := t.describe(now)
+			value := widget.NewLabel(text)
+			value.Importance = importance
+			timesBox.Add(widget.NewLabelWithStyle(jwtClaimNames[t.Claim], fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}))
+			timesBox.Add(value)
+		}
+		timesBox.Refresh()
+	}
+
+	verifyButton := widget.NewButton("Verify Signature", nil)
+	verifyButton.OnTapped = func() {
+		token, key := current.Token, keyEntry.Text
+		verifyGeneration := generation
+		verifyButton.Disable()
+		resultLabel.Importance = widget.MediumImportance
+		resultLabel.SetText("Verifying...")
+		go func() {
+			result, err := onVerify(token, key)
+			fyne.Do(func() {
+				verifyButton.Enable()
+				if verifyGeneration != generation {
+					return
+				}
+				if err != nil {
+					resultLabel.Importance = widget.DangerImportance
+					resultLabel.SetText("Not verified: " + err.Error())
+					return
+				}
+				resultLabel.Importance = widget.SuccessImportance
+				resultLabel.SetText(result)
+			})
+		}()
+	}
+
+	copyPayloadButton := widget.NewButtonWithIcon("Copy Payload", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(current.Payload)
+	})
+
+	var top fyne.CanvasObject = widget.NewLabel(tokens[0].Source)
+	if len(tokens) > 1 {
+		sources := make([]string, len(tokens))
+		for i, token := range tokens {
+			sources[i] = token.Source
+		}
+		sourceSelect := widget.NewSelect(sources, func(string) {})
+		sourceSelect.OnChanged = func(string) {
+			show(tokens[sourceSelect.SelectedIndex()])
+		}
+		top = sourceSelect
+		defer sourceSelect.SetSelectedIndex(0)
+	} else {
+		show(tokens[0])
+	}
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Payload", payloadEntry),
+		container.NewTabItem("Header", headerEntry),
+	)
+	verifyRow := container.NewVBox(
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, verifyButton, keyEntry),
+		resultLabel,
+	)
+
+	d := dialog.NewCustom("Decode JWT", "Close",
+		container.NewBorder(
+			container.NewVBox(container.NewBorder(nil, nil, nil, copyPayloadButton, top), timesBox),
+			verifyRow, nil, nil,
+			tabs,
+		),
+		parentWindow)
+	d.Resize(fyne.NewSize(700, 600))
+	d.Show()
+}
@@ -0,0 +1,347 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type KeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Disabled    bool   `json:"disabled,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// EnabledPairs filters out the pairs whose row checkbox is unticked.
+func EnabledPairs(pairs []KeyValue) []KeyValue {
+	enabled := make([]KeyValue, 0, len(pairs))
+	for _, pair := range pairs {
+		if !pair.Disabled {
+			enabled = append(enabled, pair)
+		}
+	}
+	return enabled
+}
+
+type KeyValueEditor struct {
+	container    *fyne.Container
+	rowsBox      *fyne.Container
+	rows         []*keyValueRow
+	keyHint      string
+	valueHint    string
+	toggles      bool
+	descriptions bool
+	OnChanged    func()
+
+	// KeySuggestions and ValueSuggestions, if set before rows are added,
+	// offer completions while a key or value is typed. The separator splits
+	// a value into items that are completed one at a time.
+	KeySuggestions   func() []string
+	ValueSuggestions func(key string) (values []string, separator string)
+
+	content    *fyne.Container
+	tableView  fyne.CanvasObject
+	addButton  *widget.Button
+	toolbar    *fyne.Container
+	bulkView   fyne.CanvasObject
+	bulkEntry  *widget.Entry
+	bulkErrors *widget.Label
+	bulkButton *widget.Button
+	bulk       bool
+}
+
+type keyValueRow struct {
+	enabledCheck     *widget.Check
+	keyEntry         *widget.Entry
+	valueEntry       *widget.Entry
+	descriptionEntry *widget.Entry
+	container        *fyne.Container
+}
+
+func NewKeyValueEditor(keyHint, valueHint, addLabel string) *KeyValueEditor {
+	return newKeyValueEditor(keyHint, valueHint, addLabel, false, false)
+}
+
+// NewKeyValueEditorWithToggles adds an enabled checkbox to every row so a
+// pair can be excluded without deleting it.
+func NewKeyValueEditorWithToggles(keyHint, valueHint, addLabel string) *KeyValueEditor {
+	return newKeyValueEditor(keyHint, valueHint, addLabel, true, false)
+}
+
+// NewKeyValueEditorWithDescriptions adds an optional description cell to
+// every row, next to the enabled checkbox of NewKeyValueEditorWithToggles.
+func NewKeyValueEditorWithDescriptions(keyHint, valueHint, addLabel string) *KeyValueEditor {
+	return newKeyValueEditor(keyHint, valueHint, addLabel, true, true)
+}
+
+func newKeyValueEditor(keyHint, valueHint, addLabel string, toggles, descriptions bool) *KeyValueEditor {
+	e := &KeyValueEditor{
+		keyHint:      keyHint,
+		valueHint:    valueHint,
+		toggles:      toggles,
+		descriptions: descriptions,
+	}
+
+	e.rowsBox = container.NewVBox()
+	e.addButton = widget.NewButtonWithIcon(addLabel, theme.ContentAddIcon(), func() {
+		e.addRow(KeyValue{})
+	})
+	e.tableView = container.NewVScroll(e.rowsBox)
+	e.content = container.NewStack(e.tableView)
+	e.toolbar = container.NewHBox(e.addButton)
+
+	e.container = container.NewBorder(
+		nil,
+		e.toolbar,
+		nil,
+		nil,
+		e.content,
+	)
+
+	return e
+}
+
+// AllowBulkEdit adds a button that switches between the table and a text
+// area with one "Name: value" line per row, e.g. to paste headers copied
+// from browser developer tools. Disabled rows are lines starting with "//".
+func (e *KeyValueEditor) AllowBulkEdit() {
+	e.bulkEntry = widget.NewMultiLineEntry()
+	e.bulkEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	e.bulkEntry.SetPlaceHolder("Name: value\n// Disabled-Name: value")
+	e.bulkEntry.OnChanged = func(string) {
+		e.showBulkErrors()
+		e.changed()
+	}
+
+	e.bulkErrors = widget.NewLabel("")
+	e.bulkErrors.Wrapping = fyne.TextWrapWord
+	e.bulkErrors.Importance = widget.DangerImportance
+	e.bulkErrors.Hide()
+
+	e.bulkView = container.NewBorder(nil, e.bulkErrors, nil, nil, e.bulkEntry)
+	e.bulkView.Hide()
+	e.content.Add(e.bulkView)
+
+	e.bulkButton = widget.NewButtonWithIcon("Bulk Edit", theme.DocumentCreateIcon(), e.toggleBulkEdit)
+	e.toolbar.Add(e.bulkButton)
+}
+
+// toggleBulkEdit converts the rows to text or back. The text is only turned
+// back into rows once every line can be read.
+func (e *KeyValueEditor) toggleBulkEdit() {
+	if !e.bulk {
+		e.bulkEntry.SetText(formatRawPairs(e.GetPairs()))
+		e.bulk = true
+		e.tableView.Hide()
+		e.addButton.Hide()
+		e.bulkView.Show()
+		e.bulkButton.SetText("Table")
+		return
+	}
+
+	pairs, problems := e.parseBulkText()
+	if len(problems) > 0 {
+		e.showBulkErrors()
+		return
+	}
+	e.bulk = false
+	e.SetPairs(pairs)
+	e.bulkView.Hide()
+	e.tableView.Show()
+	e.addButton.Show()
+	e.bulkButton.SetText("Bulk Edit")
+}
+
+func (e *KeyValueEditor) showBulkErrors() {
+	_, problems := e.parseBulkText()
+	e.bulkErrors.SetText(strings.Join(problems, "\n"))
+	if len(problems) == 0 {
+		e.bulkErrors.Hide()
+	} else {
+		e.bulkErrors.Show()
+	}
+}
+
+// parseBulkText reads the text area, keeping the descriptions of the rows it
+// was made from, which the text does not show.
+func (e *KeyValueEditor) parseBulkText() ([]KeyValue, []string) {
+	pairs, problems := parseRawPairs(e.bulkEntry.Text)
+	used := make([]bool, len(e.rows))
+	for i := range pairs {
+		for j, row := range e.rows {
+			if !used[j] && row.keyEntry.Text == pairs[i].Key {
+				if row.descriptionEntry != nil {
+					pairs[i].Description = row.descriptionEntry.Text
+				}
+				used[j] = true
+				break
+			}
+		}
+	}
+	return pairs, problems
+}
+
+// formatRawPairs writes one "Name: value" line per pair, with "//" in front
+// of disabled ones.
+func formatRawPairs(pairs []KeyValue) string {
+	var b strings.Builder
+	for _, pair := range pairs {
+		if pair.Disabled {
+			b.WriteString("// ")
+		}
+		b.WriteString(pair.Key + ": " + pair.Value + "\n")
+	}
+	return b.String()
+}
+
+// parseRawPairs reads the lines written by formatRawPairs. Blank lines are
+// skipped; lines that are not "Name: value" are reported by line number.
+func parseRawPairs(text string) (pairs []KeyValue, problems []string) {
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		disabled := false
+		if rest, ok := strings.CutPrefix(line, "//"); ok {
+			disabled = true
+			line = strings.TrimSpace(rest)
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("Line %d: expected Name: value", i+1))
+		case name == "":
+			problems = append(problems, fmt.Sprintf("Line %d: no name before the colon", i+1))
+		case strings.ContainsAny(name, " \t"):
+			problems = append(problems, fmt.Sprintf("Line %d: %q contains spaces", i+1, name))
+		default:
+			pairs = append(pairs, KeyValue{Key: name, Value: strings.TrimSpace(value), Disabled: disabled})
+		}
+	}
+	return pairs, problems
+}
+
+func (e *KeyValueEditor) addRow(pair KeyValue) {
+	row := &keyValueRow{}
+	var keyCell, valueCell fyne.CanvasObject
+	if e.KeySuggestions != nil {
+		keySuggest := NewSuggestEntry(e.KeySuggestions)
+		row.keyEntry, keyCell = &keySuggest.Entry, keySuggest
+	} else {
+		row.keyEntry = widget.NewEntry()
+		keyCell = row.keyEntry
+	}
+	if e.ValueSuggestions != nil {
+		valueSuggest := NewSuggestEntry(nil)
+		valueSuggest.Candidates = func() []string {
+			values, separator := e.ValueSuggestions(row.keyEntry.Text)
+			valueSuggest.Separator = separator
+			return values
+		}
+		row.valueEntry, valueCell = &valueSuggest.Entry, valueSuggest
+	} else {
+		row.valueEntry = widget.NewEntry()
+		valueCell = row.valueEntry
+	}
+	row.keyEntry.SetPlaceHolder(e.keyHint)
+	row.keyEntry.SetText(pair.Key)
+	row.valueEntry.SetPlaceHolder(e.valueHint)
+	row.valueEntry.SetText(pair.Value)
+
+	row.keyEntry.OnChanged = func(string) { e.changed() }
+	row.valueEntry.OnChanged = func(string) { e.changed() }
+
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.removeRow(row)
+	})
+
+	var left fyne.CanvasObject
+	if e.toggles {
+		row.enabledCheck = widget.NewCheck("", nil)
+		row.enabledCheck.SetChecked(!pair.Disabled)
+		row.enabledCheck.OnChanged = func(bool) { e.changed() }
+		left = row.enabledCheck
+	}
+
+	cells := container.NewGridWithColumns(2, keyCell, valueCell)
+	if e.descriptions {
+		row.descriptionEntry = widget.NewEntry()
+		row.descriptionEntry.SetPlaceHolder("Description")
+		row.descriptionEntry.SetText(pair.Description)
+		row.descriptionEntry.OnChanged = func(string) { e.changed() }
+		cells = container.NewGridWithColumns(3, keyCell, valueCell, row.descriptionEntry)
+	}
+
+	row.container = container.NewBorder(nil, nil, left, removeButton, cells)
+
+	e.rows = append(e.rows, row)
+	e.rowsBox.Add(row.container)
+}
+
+func (e *KeyValueEditor) removeRow(row *keyValueRow) {
+	for i, r := range e.rows {
+		if r == row {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+	e.rowsBox.Remove(row.container)
+	e.changed()
+}
+
+func (e *KeyValueEditor) changed() {
+	if e.OnChanged != nil {
+		e.OnChanged()
+	}
+}
+
+// GetPairs returns the rows in display order, skipping rows with an empty key.
+// Disabled rows are included; use EnabledPairs to drop them. In bulk edit
+// the lines that can be read are returned.
+func (e *KeyValueEditor) GetPairs() []KeyValue {
+	if e.bulk {
+		pairs, _ := e.parseBulkText()
+		return pairs
+	}
+
+	pairs := make([]KeyValue, 0, len(e.rows))
+	for _, row := range e.rows {
+		if row.keyEntry.Text == "" {
+			continue
+		}
+		pair := KeyValue{
+			Key:      row.keyEntry.Text,
+			Value:    row.valueEntry.Text,
+			Disabled: row.enabledCheck != nil && !row.enabledCheck.Checked,
+		}
+		if row.descriptionEntry != nil {
+			pair.Description = row.descriptionEntry.Text
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+func (e *KeyValueEditor) SetPairs(pairs []KeyValue) {
+	e.rows = nil
+	e.rowsBox.RemoveAll()
+	for _, pair := range pairs {
+		e.addRow(pair)
+	}
+	e.rowsBox.Refresh()
+	if e.bulk {
+		e.bulkEntry.SetText(formatRawPairs(pairs))
+	}
+}
+
+func (e *KeyValueEditor) GetContainer() *fyne.Container {
+	return e.container
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"net/url"
+	"regexp"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// maxBodyLinks bounds the URLs found in a body, so a body made of links
+// stays quick.
+const maxBodyLinks = 10000
+
+// linkPattern matches absolute http and https URLs in text. Punctuation
+// that ends a sentence or closes a bracket is not taken as part of one.
+var linkPattern = regexp.MustCompile(`https?://[^\s"'<>\\` + "`" + `]*[^\s"'<>\\` + "`" + `.,;:!?)\]}]`)
+
+// findLinks returns the URLs in text as byte offsets, in order.
+func findLinks(text string) [][]int {
+	return linkPattern.FindAllStringIndex(text, maxBodyLinks)
+}
+
+// isLink reports whether text is an absolute http or https URL and nothing
+// else.
+func isLink(text string) bool {
+	match := linkPattern.FindStringIndex(text)
+	return match != nil && match[0] == 0 && match[1] == len(text)
+}
+
+// openLink loads target into the URL bar with onOpenURL, or opens it in the
+// browser when Ctrl or Cmd is held.
+func openLink(target string, onOpenURL func(url string)) {
+	if linkModifierPressed() || onOpenURL == nil {
+		if u, err := url.Parse(target); err == nil {
+			fyne.CurrentApp().OpenURL(u)
+		}
+		return
+	}
+	onOpenURL(target)
+}
+
+// linkModifierPressed reports whether Ctrl or Cmd is held, which opens a
+// link in the browser rather than the URL bar.
+func linkModifierPressed() bool {
+	driver, ok := fyne.CurrentApp().Driver().(desktop.Driver)
+	if !ok {
+		return false
+	}
+	modifiers := driver.CurrentKeyModifiers()
+	return modifiers&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0
+}
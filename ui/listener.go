@@ -0,0 +1,287 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MaxListenerRequests is how many received requests the listener log keeps.
+const MaxListenerRequests = 500
+
+const DefaultListenerPort = 8090
+
+// DefaultListenerAddress keeps the listener to this machine, as what it
+// records may hold tokens and OAuth codes.
+const DefaultListenerAddress = "127.0.0.1"
+
+// ListenerConfig is the address and port of the webhook listener and the
+// response it answers every request with.
+type ListenerConfig struct {
+	Address string `json:"address,omitempty"`
+	Port    int    `json:"port"`
+	Status  int    `json:"status"`
+	Body    string `json:"body,omitempty"`
+}
+
+func DefaultListenerConfig() ListenerConfig {
+	return ListenerConfig{Address: DefaultListenerAddress, Port: DefaultListenerPort, Status: http.StatusOK}
+}
+
+// ListenerPanel is the Listener tab: the server settings, its URL and the
+// log of received requests. Starting and stopping the server is left to the
+// callbacks; the Set and Add methods must be called on the main thread.
+type ListenerPanel struct {
+	container    *fyne.Container
+	addressEntry *widget.Entry
+	portEntry    *widget.Entry
+	statusEntry  *widget.Entry
+	bodyEntry    *widget.Entry
+	startButton  *widget.Button
+	stopButton   *widget.Button
+	copyButton   *widget.Button
+	urlLabel     *widget.Label
+	logList      *widget.List
+	detailsEntry *widget.Entry
+	requests     []*storage.ListenerRequest
+	url          string
+	parentWindow fyne.Window
+
+	OnStart func(config ListenerConfig)
+	OnStop  func()
+	OnClear func()
+}
+
+func NewListenerPanel(parentWindow fyne.Window) *ListenerPanel {
+	p := &ListenerPanel{parentWindow: parentWindow}
+
+	p.addressEntry = widget.NewEntry()
+	p.addressEntry.SetPlaceHolder(DefaultListenerAddress)
+	p.addressEntry.Validator = func(text string) error {
+		if text = strings.TrimSpace(text); text != "" && net.ParseIP(text) == nil {
+			return errors.New("enter an IP address such as 127.0.0.1, or 0.0.0.0 for every interface")
+		}
+		return nil
+	}
+	p.portEntry = widget.NewEntry()
+	p.portEntry.Validator = func(text string) error {
+		if port, err := strconv.Atoi(strings.TrimSpace(text)); err != nil || port < 0 || port > 65535 {
+			return errors.New("enter a port from 0 to 65535")
+		}
+		return nil
+	}
+	p.statusEntry = widget.NewEntry()
+	p.statusEntry.Validator = func(text string) error {
+		if status, err := strconv.Atoi(strings.TrimSpace(text)); err != nil || status < 100 || status > 999 {
+			return errors.New("enter a status code such as 200")
+		}
+		return nil
+	}
+	p.bodyEntry = widget.NewMultiLineEntry()
+	p.bodyEntry.SetPlaceHolder("Response body (optional)")
+	p.bodyEntry.SetMinRowsVisible(3)
+
+	p.startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), p.start)
+	p.startButton.Importance = widget.HighImportance
+	p.stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if p.OnStop != nil {
+			p.OnStop()
+		}
+	})
+	p.urlLabel = widget.NewLabel("")
+	p.urlLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	p.copyButton = widget.NewButtonWithIcon("Copy URL", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(p.url)
+	})
+	clearButton := widget.NewButtonWithIcon("Clear Log", theme.ContentClearIcon(), func() {
+		if p.OnClear != nil {
+			p.OnClear()
+		}
+		p.SetRequests(nil)
+	})
+
+	p.logList = widget.NewList(
+		func() int {
+			return len(p.requests)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(p.requests) {
+				return
+			}
+			req := p.requests[i]
+			o.(*widget.Label).SetText(fmt.Sprintf("%s  %s %s", req.Timestamp.Format("15:04:05.000"), req.Method, req.Path))
+		},
+	)
+
+	p.detailsEntry = widget.NewMultiLineEntry()
+	p.detailsEntry.Wrapping = fyne.TextWrapBreak
+	p.detailsEntry.SetPlaceHolder("Select a request to see it in full")
+	p.detailsEntry.Disable()
+	p.logList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(p.requests) {
+			p.detailsEntry.SetText(listenerRequestDetails(p.requests[id]))
+		}
+	}
+
+	settings := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Address", p.addressEntry),
+			widget.NewFormItem("Port", p.portEntry),
+			widget.NewFormItem("Response status", p.statusEntry),
+			widget.NewFormItem("Response body", p.bodyEntry),
+		),
+		widget.NewLabel("Every request is answered with this response. Port 0 picks a free port.\nOnly this machine can reach 127.0.0.1; 0.0.0.0 lets others on the network send requests too."),
+	)
+
+	topBar := container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(p.startButton, p.stopButton), settings),
+		container.NewBorder(nil, nil, nil, container.NewHBox(p.copyButton, clearButton), p.urlLabel),
+	)
+
+	logSplit := container.NewVSplit(p.logList, p.detailsEntry)
+	logSplit.SetOffset(0.5)
+
+	p.container = container.NewBorder(topBar, nil, nil, nil, logSplit)
+	p.SetConfig(DefaultListenerConfig())
+	p.SetStopped()
+	return p
+}
+
+func (p *ListenerPanel) start() {
+	if err := p.addressEntry.Validate(); err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	if err := p.portEntry.Validate(); err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	if err := p.statusEntry.Validate(); err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	if p.OnStart != nil {
+		p.OnStart(p.GetConfig())
+	}
+}
+
+// listenerRequestDetails describes a received request in full, with its
+// headers sorted by name.
+func listenerRequestDetails(req *storage.ListenerRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  from %s\n\n%s %s\n", req.Timestamp.Format("2006-01-02 15:04:05.000"), req.RemoteAddr, req.Method, req.Path)
+
+	var headers map[string][]string
+	if req.Headers != "" {
+		if err := json.Unmarshal([]byte(req.Headers), &headers); err != nil {
+			fmt.Printf("Error parsing listener headers: %v\n", err)
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	if req.Body != "" {
+		b.WriteString("\n" + req.Body)
+	}
+	return b.String()
+}
+
+// GetConfig returns the address, port and canned response; invalid entries
+// fall back to the defaults.
+func (p *ListenerPanel) GetConfig() ListenerConfig {
+	config := DefaultListenerConfig()
+	if address := strings.TrimSpace(p.addressEntry.Text); net.ParseIP(address) != nil {
+		config.Address = address
+	}
+	if port, err := strconv.Atoi(strings.TrimSpace(p.portEntry.Text)); err == nil && port >= 0 && port <= 65535 {
+		config.Port = port
+	}
+	if status, err := strconv.Atoi(strings.TrimSpace(p.statusEntry.Text)); err == nil && status >= 100 && status <= 999 {
+		config.Status = status
+	}
+	config.Body = p.bodyEntry.Text
+	return config
+}
+
+func (p *ListenerPanel) SetConfig(config ListenerConfig) {
+	p.addressEntry.SetText(config.Address)
+	p.portEntry.SetText(strconv.Itoa(config.Port))
+	p.statusEntry.SetText(strconv.Itoa(config.Status))
+	p.bodyEntry.SetText(config.Body)
+}
+
+// SetRunning shows the URL the server is reachable at; the settings are
+// locked until it stops.
+func (p *ListenerPanel) SetRunning(url string) {
+	p.url = url
+	p.urlLabel.SetText("Listening on " + url)
+	p.startButton.Disable()
+	p.stopButton.Enable()
+	p.copyButton.Enable()
+	p.addressEntry.Disable()
+	p.portEntry.Disable()
+	p.statusEntry.Disable()
+	p.bodyEntry.Disable()
+}
+
+func (p *ListenerPanel) SetStopped() {
+	p.url = ""
+	p.urlLabel.SetText("Stopped")
+	p.startButton.Enable()
+	p.stopButton.Disable()
+	p.copyButton.Disable()
+	p.addressEntry.Enable()
+	p.portEntry.Enable()
+	p.statusEntry.Enable()
+	p.bodyEntry.Enable()
+}
+
+// AddRequest appends req to the log and scrolls to it.
+func (p *ListenerPanel) AddRequest(req *storage.ListenerRequest) {
+	p.requests = append(p.requests, req)
+	if len(p.requests) > MaxListenerRequests {
+		p.requests = p.requests[len(p.requests)-MaxListenerRequests:]
+	}
+	p.logList.Refresh()
+	p.logList.ScrollToBottom()
+}
+
+// SetRequests replaces the log, e.g. with the requests stored by an earlier
+// session.
+func (p *ListenerPanel) SetRequests(requests []*storage.ListenerRequest) {
+	p.requests = requests
+	p.logList.UnselectAll()
+	p.detailsEntry.SetText("")
+	p.logList.Refresh()
+}
+
+func (p *ListenerPanel) GetContainer() *fyne.Container {
+	return p.container
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// LoadTestConfig describes a load test run. Exactly one of Requests and
+// Duration is set.
+type LoadTestConfig struct {
+	Concurrency int
+	Requests    int
+	Duration    time.Duration
+}
+
+// LoadTestStats are the live counters shown while a load test runs.
+type LoadTestStats struct {
+	Completed int
+	Errors    int
+	Elapsed   time.Duration
+	RPS       float64
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
+
+// MaxLoadTestConcurrency caps the number of workers in a load test.
+const MaxLoadTestConcurrency = 1000
+
+const (
+	loadModeRequests = "Total requests"
+	loadModeDuration = "Duration"
+)
+
+// LoadTestDialog collects the load test settings and shows the live results.
+// The run itself is left to OnStart and OnStop; Update and Finished must be
+// called on the main thread.
+type LoadTestDialog struct {
+	concurrencyEntry *widget.Entry
+	modeRadio        *widget.RadioGroup
+	requestsEntry    *widget.Entry
+	durationEntry    *widget.Entry
+	startButton      *widget.Button
+	stopButton       *widget.Button
+	exportJSONButton *widget.Button
+	exportCSVButton  *widget.Button
+	completedLabel   *widget.Label
+	rpsLabel         *widget.Label
+	latencyLabel     *widget.Label
+	timeThresholds   TimeThresholds
+	parentWindow     fyne.Window
+
+	OnStart func(config LoadTestConfig)
+	OnStop  func()
+	// OnExport writes the results of the last run as "json" or "csv"
+	OnExport func(format string, w io.Writer) error
+}
+
+// ShowLoadTestDialog opens the dialog; the latency is coloured by the p95
+// against thresholds.
+func ShowLoadTestDialog(thresholds TimeThresholds, parentWindow fyne.Window) *LoadTestDialog {
+	d := &LoadTestDialog{timeThresholds: thresholds, parentWindow: parentWindow}
+
+	d.concurrencyEntry = widget.NewEntry()
+	d.concurrencyEntry.SetText("10")
+	d.concurrencyEntry.Validator = func(text string) error {
+		if n, ok := parseNonNegative(text); !ok || n < 1 || n > MaxLoadTestConcurrency {
+			return fmt.Errorf("enter from 1 to %d workers", MaxLoadTestConcurrency)
+		}
+		return nil
+	}
+
+	d.requestsEntry = widget.NewEntry()
+	d.requestsEntry.SetText("100")
+	d.requestsEntry.Validator = positiveValidator("enter at least 1 request")
+
+	d.durationEntry = widget.NewEntry()
+	d.durationEntry.SetText("10")
+	d.durationEntry.Validator = positiveValidator("enter at least 1 second")
+
+	d.modeRadio = widget.NewRadioGroup([]string{loadModeRequests, loadModeDuration}, func(mode string) {
+		if mode == loadModeDuration {
+			d.requestsEntry.Disable()
+			d.durationEntry.Enable()
+		} else {
+			d.requestsEntry.Enable()
+			d.durationEntry.Disable()
+		}
+	})
+	d.modeRadio.Horizontal = true
+	d.modeRadio.Required = true
+	d.modeRadio.SetSelected(loadModeRequests)
+
+	d.startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), d.start)
+	d.startButton.Importance = widget.HighImportance
+	d.stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if d.OnStop != nil {
+			d.OnStop()
+		}
+	})
+	d.stopButton.Disable()
+
+	d.exportJSONButton = widget.NewButton("Export JSON", func() { d.export("json") })
+	d.exportCSVButton = widget.NewButton("Export CSV", func() { d.export("csv") })
+	d.exportJSONButton.Disable()
+	d.exportCSVButton.Disable()
+
+	d.completedLabel = widget.NewLabel("Completed: -")
+	d.rpsLabel = widget.NewLabel("Requests/s: -")
+	d.latencyLabel = widget.NewLabel("Latency: -")
+
+	form := widget.NewForm(
+		widget.NewFormItem("Concurrency", d.concurrencyEntry),
+		widget.NewFormItem("Stop after", d.modeRadio),
+		widget.NewFormItem("Requests", d.requestsEntry),
+		widget.NewFormItem("Duration (s)", d.durationEntry),
+	)
+
+	content := container.NewVBox(
+		form,
+		container.NewHBox(d.startButton, d.stopButton),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Results", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		d.completedLabel,
+		d.rpsLabel,
+		d.latencyLabel,
+		container.NewHBox(d.exportJSONButton, d.exportCSVButton),
+	)
+
+	dlg := dialog.NewCustom("Load Test", "Close", content, parentWindow)
+	dlg.Resize(fyne.NewSize(450, 420))
+	dlg.Show()
+	return d
+}
+
+func positiveValidator(message string) fyne.StringValidator {
+	return func(text string) error {
+		if n, ok := parseNonNegative(text); !ok || n < 1 {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+func (d *LoadTestDialog) start() {
+	concurrency, ok := parseNonNegative(d.concurrencyEntry.Text)
+	if !ok || concurrency < 1 || concurrency > MaxLoadTestConcurrency {
+		dialog.ShowError(fmt.Errorf("concurrency must be from 1 to %d", MaxLoadTestConcurrency), d.parentWindow)
+		return
+	}
+
+	config := LoadTestConfig{Concurrency: concurrency}
+	if d.modeRadio.Selected == loadModeDuration {
+		seconds, ok := parseNonNegative(d.durationEntry.Text)
+		if !ok || seconds < 1 {
+			dialog.ShowError(errors.New("duration must be at least 1 second"), d.parentWindow)
+			return
+		}
+		config.Duration = time.Duration(seconds) * time.Second
+	} else {
+		requests, ok := parseNonNegative(d.requestsEntry.Text)
+		if !ok || requests < 1 {
+			dialog.ShowError(errors.New("enter at least 1 request"), d.parentWindow)
+			return
+		}
+		config.Requests = requests
+	}
+
+	d.startButton.Disable()
+	d.stopButton.Enable()
+	d.exportJSONButton.Disable()
+	d.exportCSVButton.Disable()
+	d.Update(LoadTestStats{})
+
+	if d.OnStart != nil {
+		d.OnStart(config)
+	}
+}
+
+// Update shows the latest counters.
+func (d *LoadTestDialog) Update(stats LoadTestStats) {
+	d.completedLabel.SetText(fmt.Sprintf("Completed: %d (%d errors) in %.1f s",
+		stats.Completed, stats.Errors, stats.Elapsed.Seconds()))
+	d.rpsLabel.SetText(fmt.Sprintf("Requests/s: %.1f", stats.RPS))
+	d.latencyLabel.Importance = widget.MediumImportance
+	if stats.Completed > stats.Errors {
+		d.latencyLabel.Importance = d.timeThresholds.Importance(stats.P95)
+	}
+	d.latencyLabel.SetText(fmt.Sprintf("Latency: p50 %s, p95 %s, p99 %s",
+		formatLatency(stats.P50), formatLatency(stats.P95), formatLatency(stats.P99)))
+}
+
+// Finished re-enables Start and, if the run produced results, the export
+// buttons.
+func (d *LoadTestDialog) Finished(stats LoadTestStats) {
+	d.Update(stats)
+	d.startButton.Enable()
+	d.stopButton.Disable()
+	if stats.Completed > 0 {
+		d.exportJSONButton.Enable()
+		d.exportCSVButton.Enable()
+	}
+}
+
+func formatLatency(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 2, 64) + " ms"
+}
+
+func (d *LoadTestDialog) export(format string) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, d.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if d.OnExport != nil {
+			if err := d.OnExport(format, writer); err != nil {
+				dialog.ShowError(err, d.parentWindow)
+			} else {
+				dialog.ShowInformation("Success", "Load test results exported successfully", d.parentWindow)
+			}
+		}
+	}, d.parentWindow)
+	save.SetFileName("load-test." + format)
+	save.SetFilter(storage.NewExtensionFileFilter([]string{"." + format}))
+	save.Show()
+}
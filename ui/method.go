@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const customMethodOption = "Custom…"
+
+var standardMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+var errInvalidMethod = errors.New("method must be a single word without spaces or separators")
+
+// MethodSelector is the HTTP method dropdown. Besides the standard verbs it
+// accepts arbitrary methods such as PROPFIND, which are added to the list
+// once used.
+type MethodSelector struct {
+	container    *fyne.Container
+	selectWidget *widget.Select
+	selected     string
+	parentWindow fyne.Window
+	OnChanged    func(method string)
+}
+
+func NewMethodSelector(parentWindow fyne.Window) *MethodSelector {
+	m := &MethodSelector{parentWindow: parentWindow}
+
+	options := append(append([]string{}, standardMethods...), customMethodOption)
+	m.selectWidget = widget.NewSelect(options, m.optionSelected)
+	m.container = container.NewStack(m.selectWidget)
+
+	return m
+}
+
+func (m *MethodSelector) optionSelected(value string) {
+	if value == customMethodOption {
+		m.showCustomDialog()
+		return
+	}
+	if value == m.selected {
+		return
+	}
+	m.selected = value
+	if m.OnChanged != nil {
+		m.OnChanged(value)
+	}
+}
+
+func (m *MethodSelector) showCustomDialog() {
+	methodEntry := widget.NewEntry()
+	methodEntry.SetPlaceHolder("e.g. PROPFIND")
+	methodEntry.Validator = func(text string) error {
+		if !validMethod(strings.TrimSpace(text)) {
+			return errInvalidMethod
+		}
+		return nil
+	}
+
+	dialog.ShowForm("Custom Method", "Use", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Method", methodEntry),
+		},
+		func(confirmed bool) {
+			method := strings.TrimSpace(methodEntry.Text)
+			if !confirmed || !validMethod(method) {
+				// Put the dropdown back on the method that is still in use
+				m.selectWidget.SetSelected(m.selected)
+				return
+			}
+			m.SetSelected(method)
+			if m.OnChanged != nil {
+				m.OnChanged(method)
+			}
+		}, m.parentWindow)
+}
+
+// Selected returns the method currently in use.
+func (m *MethodSelector) Selected() string {
+	return m.selected
+}
+
+// SetSelected selects method, adding it to the list if it is not one of the
+// known methods. It does not call OnChanged.
+func (m *MethodSelector) SetSelected(method string) {
+	if method == "" {
+		method = "GET"
+	}
+
+	known := false
+	for _, option := range m.selectWidget.Options {
+		if option == method {
+			known = true
+			break
+		}
+	}
+	if !known {
+		// Keep "Custom…" as the last entry
+		options := m.selectWidget.Options
+		options = append(options[:len(options)-1:len(options)-1], method, customMethodOption)
+		m.selectWidget.SetOptions(options)
+	}
+
+	m.selected = method
+	m.selectWidget.SetSelected(method)
+}
+
+func (m *MethodSelector) GetContainer() *fyne.Container {
+	return m.container
+}
+
+// validMethod reports whether method is a valid HTTP token, so that it will be
+// accepted by net/http.
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, r := range method {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const DefaultMockPort = 8091
+
+// MockLogEntry is a request answered by the mock server. Route is the name
+// of the saved request that answered it, or empty if none matched.
+type MockLogEntry struct {
+	Time   time.Time
+	Method string
+	Path   string
+	Status int
+	Route  string
+}
+
+func (e MockLogEntry) Summary() string {
+	route := "no route"
+	if e.Route != "" {
+		route = e.Route
+	}
+	return fmt.Sprintf("%s  %s %s → %d (%s)", e.Time.Format("15:04:05.000"), e.Method, e.Path, e.Status, route)
+}
+
+// MockPanel is the Mock tab: the collection to serve, the port and the log
+// of requests answered. Starting and stopping the server is left to the
+// callbacks; the Set and Add methods must be called on the main thread.
+type MockPanel struct {
+	container        *fyne.Container
+	collectionSelect *widget.Select
+	portEntry        *widget.Entry
+	lanCheck         *widget.Check
+	startButton      *widget.Button
+	stopButton       *widget.Button
+	copyButton       *widget.Button
+	urlLabel         *widget.Label
+	logList          *widget.List
+	collections      []*storage.Collection
+	entries          []MockLogEntry
+	url              string
+	parentWindow     fyne.Window
+
+	// OnStart is called with the collection to serve, nil for the unsorted
+	// requests, and whether other machines may reach the server
+	OnStart func(collectionID *int, port int, lan bool)
+	OnStop  func()
+}
+
+func NewMockPanel(parentWindow fyne.Window) *MockPanel {
+	p := &MockPanel{parentWindow: parentWindow}
+
+	p.collectionSelect = widget.NewSelect(nil, nil)
+	p.portEntry = widget.NewEntry()
+	p.portEntry.SetText(strconv.Itoa(DefaultMockPort))
+	p.portEntry.Validator = func(text string) error {
+		if port, err := strconv.Atoi(strings.TrimSpace(text)); err != nil || port < 0 || port > 65535 {
+			return errors.New("enter a port from 0 to 65535")
+		}
+		return nil
+	}
+	// Off by default, as the responses served may hold tokens
+	p.lanCheck = widget.NewCheck("Accept requests from other machines on the network", nil)
+
+	p.startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), p.start)
+	p.startButton.Importance = widget.HighImportance
+	p.stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		if p.OnStop != nil {
+			p.OnStop()
+		}
+	})
+	p.urlLabel = widget.NewLabel("")
+	p.urlLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	p.copyButton = widget.NewButtonWithIcon("Copy URL", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(p.url)
+	})
+	clearButton := widget.NewButtonWithIcon("Clear Log", theme.ContentClearIcon(), func() {
+		p.entries = nil
+		p.logList.Refresh()
+	})
+
+	p.logList = widget.NewList(
+		func() int {
+			return len(p.entries)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(p.entries) {
+				return
+			}
+			label := o.(*widget.Label)
+			entry := p.entries[i]
+			if entry.Route == "" {
+				label.Importance = widget.DangerImportance
+			} else {
+				label.Importance = widget.MediumImportance
+			}
+			label.SetText(entry.Summary())
+		},
+	)
+
+	settings := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Collection", p.collectionSelect),
+			widget.NewFormItem("Port", p.portEntry),
+			widget.NewFormItem("", p.lanCheck),
+		),
+		widget.NewLabel("Each saved request's method and path is answered with its latest response from the history. Path segments like {{id}} or :id match any value."),
+	)
+
+	topBar := container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(p.startButton, p.stopButton), settings),
+		container.NewBorder(nil, nil, nil, container.NewHBox(p.copyButton, clearButton), p.urlLabel),
+	)
+
+	p.container = container.NewBorder(topBar, nil, nil, nil, p.logList)
+	p.SetCollections(nil)
+	p.SetStopped()
+	return p
+}
+
+func (p *MockPanel) start() {
+	if err := p.portEntry.Validate(); err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	port, _ := strconv.Atoi(strings.TrimSpace(p.portEntry.Text))
+
+	// Option 0 is "(none)"; the rest follow p.collections
+	var collectionID *int
+	if i := p.collectionSelect.SelectedIndex(); i > 0 {
+		id := p.collections[i-1].ID
+		collectionID = &id
+	}
+	if p.OnStart != nil {
+		p.OnStart(collectionID, port, p.lanCheck.Checked)
+	}
+}
+
+// SetCollections updates the collections to choose from, keeping the
+// selected one if it still exists.
+func (p *MockPanel) SetCollections(collections []*storage.Collection) {
+	selected := p.collectionSelect.Selected
+	p.collections = collections
+
+	options := []string{noCollectionLabel}
+	for _, col := range collections {
+		options = append(options, col.Name)
+	}
+	p.collectionSelect.SetOptions(options)
+	p.collectionSelect.SetSelectedIndex(0)
+	for i, option := range options {
+		if option == selected {
+			p.collectionSelect.SetSelectedIndex(i)
+		}
+	}
+}
+
+func (p *MockPanel) GetPort() int {
+	port, err := strconv.Atoi(strings.TrimSpace(p.portEntry.Text))
+	if err != nil {
+		return DefaultMockPort
+	}
+	return port
+}
+
+func (p *MockPanel) SetPort(port int) {
+	p.portEntry.SetText(strconv.Itoa(port))
+}
+
+// SetLAN sets whether the server accepts requests from other machines.
+func (p *MockPanel) SetLAN(lan bool) {
+	p.lanCheck.SetChecked(lan)
+}
+
+// SetRunning shows the URL the mock is reachable at and how many routes it
+// serves; the settings are locked until it stops.
+func (p *MockPanel) SetRunning(url string, routes int) {
+	p.url = url
+	p.urlLabel.SetText(fmt.Sprintf("Serving %d routes on %s", routes, url))
+	p.startButton.Disable()
+	p.stopButton.Enable()
+	p.copyButton.Enable()
+	p.collectionSelect.Disable()
+	p.portEntry.Disable()
+	p.lanCheck.Disable()
+}
+
+func (p *MockPanel) SetStopped() {
+	p.url = ""
+	p.urlLabel.SetText("Stopped")
+	p.startButton.Enable()
+	p.stopButton.Disable()
+	p.copyButton.Disable()
+	p.collectionSelect.Enable()
+	p.portEntry.Enable()
+	p.lanCheck.Enable()
+}
+
+// AddEntry appends entry to the log and scrolls to it.
+func (p *MockPanel) AddEntry(entry MockLogEntry) {
+	p.entries = append(p.entries, entry)
+	if len(p.entries) > MaxListenerRequests {
+		p.entries = p.entries[len(p.entries)-MaxListenerRequests:]
+	}
+	p.logList.Refresh()
+	p.logList.ScrollToBottom()
+}
+
+func (p *MockPanel) GetContainer() *fyne.Container {
+	return p.container
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"errors"
+	"image/color"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	DefaultMonitorInterval = 5 // minutes
+	MaxMonitorInterval     = 24 * 60
+)
+
+// MonitorConfig is the schedule of a monitored saved request: it is sent
+// every IntervalMinutes while the app is open and fails unless the response
+// has ExpectedStatus.
+type MonitorConfig struct {
+	IntervalMinutes int `json:"interval_minutes"`
+	ExpectedStatus  int `json:"expected_status"`
+}
+
+// ShowMonitorDialog edits the monitor of the saved request called name;
+// config is nil when it is not monitored. onSave gets nil when monitoring
+// is turned off.
+func ShowMonitorDialog(name string, config *MonitorConfig, onSave func(config *MonitorConfig), parentWindow fyne.Window) {
+	current := MonitorConfig{IntervalMinutes: DefaultMonitorInterval, ExpectedStatus: 200}
+	if config != nil {
+		current = *config
+	}
+
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.Itoa(current.IntervalMinutes))
+	intervalEntry.Validator = func(text string) error {
+		n, ok := parseNonNegative(text)
+		if !ok || n < 1 || n > MaxMonitorInterval {
+			return errors.New("enter a number of minutes from 1 to " + strconv.Itoa(MaxMonitorInterval))
+		}
+		return nil
+	}
+
+	statusEntry := widget.NewEntry()
+	statusEntry.SetText(strconv.Itoa(current.ExpectedStatus))
+	statusEntry.Validator = func(text string) error {
+		n, ok := parseNonNegative(text)
+		if !ok || n < 100 || n > 999 {
+			return errors.New("enter a status code such as 200")
+		}
+		return nil
+	}
+
+	enabledCheck := widget.NewCheck("Monitor this request", func(checked bool) {
+		if checked {
+			intervalEntry.Enable()
+			statusEntry.Enable()
+		} else {
+			intervalEntry.Disable()
+			statusEntry.Disable()
+		}
+	})
+	enabledCheck.SetChecked(true)
+
+	d := dialog.NewForm("Monitor "+name, "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("", enabledCheck),
+			widget.NewFormItem("Every (minutes)", intervalEntry),
+			widget.NewFormItem("Expected status", statusEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if !enabledCheck.Checked {
+				onSave(nil)
+				return
+			}
+			interval, _ := parseNonNegative(intervalEntry.Text)
+			status, _ := parseNonNegative(statusEntry.Text)
+			onSave(&MonitorConfig{IntervalMinutes: interval, ExpectedStatus: status})
+		}, parentWindow)
+	d.Resize(fyne.NewSize(400, 250))
+	d.Show()
+}
+
+// MonitorStatus is a monitored request and the outcome of its last check.
+type MonitorStatus struct {
+	ID     int
+	Name   string
+	Config MonitorConfig
+
+	Checked time.Time // zero until the first check finishes
+	OK      bool
+	Result  string
+}
+
+var (
+	monitorUnknownColor = color.NRGBA{R: 150, G: 150, B: 150, A: 255}
+	monitorOKColor      = color.NRGBA{R: 0, G: 180, B: 0, A: 255}
+	monitorFailedColor  = color.NRGBA{R: 220, G: 0, B: 0, A: 255}
+)
+
+// MonitorsPanel lists the monitored requests with a status dot and the time
+// of their last check. The Set methods must be called on the main thread.
+type MonitorsPanel struct {
+	container *fyne.Container
+	list      *widget.List
+	monitors  []*MonitorStatus
+	selected  int
+
+	OnRunNow func(id int)
+	OnEdit   func(id int)
+}
+
+func NewMonitorsPanel() *MonitorsPanel {
+	p := &MonitorsPanel{selected: -1}
+
+	p.list = widget.NewList(
+		func() int {
+			return len(p.monitors)
+		},
+		func() fyne.CanvasObject {
+			dot := canvas.NewCircle(monitorUnknownColor)
+			dot.Resize(fyne.NewSize(12, 12))
+			nameLabel := widget.NewLabel("Monitor name")
+			nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			resultLabel := widget.NewLabel("")
+			resultLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil,
+				container.NewGridWrap(fyne.NewSize(12, 12), dot), nil,
+				container.NewVBox(nameLabel, resultLabel))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(p.monitors) {
+				return
+			}
+			m := p.monitors[i]
+			row := o.(*fyne.Container)
+			var dot *canvas.Circle
+			var labels *fyne.Container
+			for _, object := range row.Objects {
+				if wrap, ok := object.(*fyne.Container); ok {
+					if circle, ok := wrap.Objects[0].(*canvas.Circle); ok {
+						dot = circle
+					} else {
+						labels = wrap
+					}
+				}
+			}
+
+			switch {
+			case m.Checked.IsZero():
+				dot.FillColor = monitorUnknownColor
+			case m.OK:
+				dot.FillColor = monitorOKColor
+			default:
+				dot.FillColor = monitorFailedColor
+			}
+			dot.Refresh()
+
+			labels.Objects[0].(*widget.Label).SetText(m.Name)
+			result := "Every " + strconv.Itoa(m.Config.IntervalMinutes) + " min, not checked yet"
+			if !m.Checked.IsZero() {
+				result = m.Checked.Format("15:04:05") + "  " + m.Result
+			}
+			labels.Objects[1].(*widget.Label).SetText(result)
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = id
+	}
+	p.list.OnUnselected = func(widget.ListItemID) {
+		p.selected = -1
+	}
+
+	runButton := widget.NewButtonWithIcon("Run Now", theme.MediaPlayIcon(), func() {
+		if p.selected >= 0 && p.selected < len(p.monitors) && p.OnRunNow != nil {
+			p.OnRunNow(p.monitors[p.selected].ID)
+		}
+	})
+	editButton := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		if p.selected >= 0 && p.selected < len(p.monitors) && p.OnEdit != nil {
+			p.OnEdit(p.monitors[p.selected].ID)
+		}
+	})
+
+	p.container = container.NewBorder(
+		widget.NewLabelWithStyle("Monitors", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		container.NewVBox(
+			widget.NewLabel("Mark a saved request as monitored with Monitor in Collections."),
+			container.NewHBox(runButton, editButton),
+		),
+		nil,
+		nil,
+		p.list,
+	)
+	return p
+}
+
+// SetMonitors replaces the list, keeping the last result of monitors that
+// are still in it.
+func (p *MonitorsPanel) SetMonitors(monitors []*MonitorStatus) {
+	previous := map[int]*MonitorStatus{}
+	for _, m := range p.monitors {
+		previous[m.ID] = m
+	}
+	for _, m := range monitors {
+		if old, ok := previous[m.ID]; ok {
+			m.Checked, m.OK, m.Result = old.Checked, old.OK, old.Result
+		}
+	}
+	p.monitors = monitors
+	p.list.UnselectAll()
+	p.list.Refresh()
+}
+
+// SetResult records the outcome of a check and returns whether the monitor
+// was passing before, so a new failure can be reported once.
+func (p *MonitorsPanel) SetResult(id int, ok bool, result string, checked time.Time) (wasOK bool) {
+	for _, m := range p.monitors {
+		if m.ID != id {
+			continue
+		}
+		wasOK = m.Checked.IsZero() || m.OK
+		m.Checked, m.OK, m.Result = checked, ok, result
+		p.list.Refresh()
+		return wasOK
+	}
+	return true
+}
+
+func (p *MonitorsPanel) GetContainer() *fyne.Container {
+	return p.container
+}
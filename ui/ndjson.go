@@ -0,0 +1,356 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// ndjsonSniffLines is how many lines of a body are checked to tell
+	// NDJSON sent without an NDJSON Content-Type
+	ndjsonSniffLines = 5
+	// maxRecordPreview bounds the record text shown on a collapsed record
+	maxRecordPreview = 300
+)
+
+// IsNDJSONContentType reports whether contentType is newline-delimited
+// JSON, also called JSON Lines.
+func IsNDJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson",
+		"application/jsonl", "application/x-jsonl",
+		"application/jsonlines", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// looksLikeNDJSON reports whether body is a JSON object or array per line,
+// by its first lines, sent without an NDJSON Content-Type. A body of one
+// line is left to be shown as JSON.
+func looksLikeNDJSON(body string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(nil, backgroundFormatSize)
+	lines := 0
+	for lines < ndjsonSniffLines && scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") && !strings.HasPrefix(line, "[") || !json.Valid([]byte(line)) {
+			return false
+		}
+		lines++
+	}
+	return lines > 1
+}
+
+// ndjsonRecord is one line of an NDJSON body.
+type ndjsonRecord struct {
+	line int
+	text string
+	// err is why the line is not JSON
+	err error
+}
+
+// parseNDJSON splits body into its records, leaving out blank lines. A line
+// that is not JSON is kept with the reason.
+func parseNDJSON(body string) []ndjsonRecord {
+	var records []ndjsonRecord
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		record := ndjsonRecord{line: i + 1, text: line}
+		var value any
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			record.err = err
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ndjsonView shows the records of an NDJSON body one per row, collapsed to
+// their index and text and expanded to their JSON pretty-printed when
+// clicked. The filter narrows them to the records holding some text, in any
+// case, or to those a JSON path starting with $ selects something in; it is
+// applied off the main thread and kept for later bodies.
+type ndjsonView struct {
+	container   *fyne.Container
+	list        *widget.List
+	countLabel  *widget.Label
+	filterEntry *widget.Entry
+	filterError *widget.Label
+	filterTimer *time.Timer
+	// measure is a row used to work out the height of an expanded record
+	measure   fyne.CanvasObject
+	rowHeight float32
+	// match reports whether a JSON path selects something in a record; it
+	// is called off the main thread
+	match func(record, path string) (bool, error)
+
+	records []ndjsonRecord
+	// shown holds the indexes of the records the filter lets through
+	shown    []int
+	expanded map[int]bool
+	// sized holds the rows whose height was set for an expanded record
+	sized      map[widget.ListItemID]bool
+	generation int
+}
+
+func newNDJSONView(match func(record, path string) (bool, error)) *ndjsonView {
+	n := &ndjsonView{match: match, expanded: map[int]bool{}, sized: map[widget.ListItemID]bool{}}
+
+	n.list = widget.NewList(
+		func() int {
+			return len(n.shown)
+		},
+		n.createRow,
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			if id < len(n.shown) {
+				n.updateRow(n.shown[id], o)
+			}
+		},
+	)
+	n.list.OnSelected = func(id widget.ListItemID) {
+		n.list.Unselect(id)
+		if id < len(n.shown) {
+			n.toggle(id)
+		}
+	}
+	n.measure = n.createRow()
+	n.rowHeight = n.measure.MinSize().Height
+
+	n.countLabel = widget.NewLabel("")
+	n.filterEntry = widget.NewEntry()
+	n.filterEntry.SetPlaceHolder("Filter records by text, or by a JSON path such as $.level")
+	n.filterEntry.OnChanged = func(string) {
+		if n.filterTimer != nil {
+			n.filterTimer.Stop()
+		}
+		n.filterTimer = time.AfterFunc(filterDelay, func() {
+			fyne.Do(n.filter)
+		})
+	}
+	n.filterError = widget.NewLabel("")
+	n.filterError.Importance = widget.DangerImportance
+	n.filterError.Wrapping = fyne.TextWrapWord
+	n.filterError.Hide()
+
+	collapseButton := widget.NewButton("Collapse All", func() {
+		n.expanded = map[int]bool{}
+		n.resize()
+	})
+
+	n.container = container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, container.NewHBox(n.countLabel, collapseButton), n.filterEntry),
+			n.filterError,
+		),
+		nil, nil, nil,
+		n.list,
+	)
+	return n
+}
+
+func (n *ndjsonView) createRow() fyne.CanvasObject {
+	icon := widget.NewIcon(theme.MenuExpandIcon())
+	preview := widget.NewLabel("")
+	preview.Truncation = fyne.TextTruncateEllipsis
+	preview.TextStyle = fyne.TextStyle{Monospace: true}
+	copyButton := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), nil)
+	copyButton.Importance = widget.LowImportance
+	pretty := widget.NewLabel("")
+	pretty.TextStyle = fyne.TextStyle{Monospace: true}
+	pretty.Hide()
+	return container.NewBorder(
+		container.NewBorder(nil, nil, icon, copyButton, preview),
+		nil, nil, nil,
+		pretty,
+	)
+}
+
+// updateRow shows record i in a row made by createRow.
+func (n *ndjsonView) updateRow(i int, o fyne.CanvasObject) {
+	row := o.(*fyne.Container)
+	pretty := row.Objects[0].(*widget.Label)
+	header := row.Objects[1].(*fyne.Container)
+	preview := header.Objects[0].(*widget.Label)
+	icon := header.Objects[1].(*widget.Icon)
+	copyButton := header.Objects[2].(*widget.Button)
+
+	record := n.records[i]
+	text := record.text
+	if len(text) > maxRecordPreview {
+		text = strings.ToValidUTF8(text[:maxRecordPreview], "") + "…"
+	}
+	if record.err != nil {
+		preview.SetText(fmt.Sprintf("#%d  line %d is not JSON (%v): %s", i+1, record.line, record.err, text))
+	} else {
+		preview.SetText(fmt.Sprintf("#%d  %s", i+1, text))
+	}
+	copyButton.OnTapped = func() {
+		fyne.CurrentApp().Clipboard().SetContent(record.text)
+	}
+
+	if n.expanded[i] && record.err == nil {
+		icon.SetResource(theme.MenuDropDownIcon())
+		pretty.SetText(FormatJSON(record.text, BodyViewPretty))
+		pretty.Show()
+	} else {
+		icon.SetResource(theme.MenuExpandIcon())
+		pretty.Hide()
+	}
+}
+
+// toggle expands or collapses the record in row id.
+func (n *ndjsonView) toggle(id widget.ListItemID) {
+	i := n.shown[id]
+	n.expanded[i] = !n.expanded[i]
+	n.setHeight(id)
+}
+
+// setHeight sizes row id for its record, expanded or not.
+func (n *ndjsonView) setHeight(id widget.ListItemID) {
+	i := n.shown[id]
+	if !n.expanded[i] {
+		delete(n.sized, id)
+		n.list.SetItemHeight(id, n.rowHeight)
+		return
+	}
+	n.updateRow(i, n.measure)
+	n.sized[id] = true
+	n.list.SetItemHeight(id, n.measure.MinSize().Height)
+}
+
+// resize sizes the rows again after the records shown or expanded change.
+func (n *ndjsonView) resize() {
+	for id := range n.sized {
+		if id < len(n.shown) {
+			n.setHeight(id)
+		} else {
+			delete(n.sized, id)
+		}
+	}
+	for id, i := range n.shown {
+		if n.expanded[i] && !n.sized[id] {
+			n.setHeight(id)
+		}
+	}
+	n.list.Refresh()
+}
+
+// set shows the records of body, through the filter.
+func (n *ndjsonView) set(records []ndjsonRecord) {
+	n.records = records
+	n.expanded = map[int]bool{}
+	n.list.ScrollToTop()
+	n.filter()
+}
+
+// loading empties the list while a body is parsed.
+func (n *ndjsonView) loading() {
+	n.generation++
+	n.records, n.shown = nil, nil
+	n.resize()
+	n.countLabel.SetText("Parsing...")
+}
+
+// filter shows the records the filter lets through, working them out in
+// the background. A newer filter or body supersedes one still running.
+func (n *ndjsonView) filter() {
+	n.generation++
+	n.filterError.Hide()
+	expression := strings.TrimSpace(n.filterEntry.Text)
+	if expression == "" {
+		shown := make([]int, len(n.records))
+		for i := range shown {
+			shown[i] = i
+		}
+		n.show(shown)
+		return
+	}
+
+	generation, records, match := n.generation, n.records, n.match
+	go func() {
+		shown, err := filterRecords(records, expression, match)
+		fyne.Do(func() {
+			if generation != n.generation {
+				return
+			}
+			if err != nil {
+				n.filterError.SetText(fmt.Sprintf("Filter: %v", err))
+				n.filterError.Show()
+			}
+			n.show(shown)
+		})
+	}()
+}
+
+func (n *ndjsonView) show(shown []int) {
+	n.shown = shown
+	n.resize()
+
+	count := fmt.Sprintf("%d records", len(n.records))
+	if len(shown) != len(n.records) {
+		count = fmt.Sprintf("%d of %d records", len(shown), len(n.records))
+	}
+	invalid := 0
+	for _, record := range n.records {
+		if record.err != nil {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		count += fmt.Sprintf(", %d not JSON", invalid)
+	}
+	n.countLabel.SetText(count)
+}
+
+// filterRecords returns the indexes of the records holding expression, in
+// any case, or, for a JSON path, those it selects something in. A JSON path
+// that cannot be parsed lets none through.
+func filterRecords(records []ndjsonRecord, expression string, match func(record, path string) (bool, error)) ([]int, error) {
+	shown := []int{}
+	if strings.HasPrefix(expression, "$") {
+		if match == nil {
+			return shown, fmt.Errorf("JSON paths are not supported")
+		}
+		for i, record := range records {
+			if record.err != nil {
+				continue
+			}
+			ok, err := match(record.text, expression)
+			if err != nil {
+				return []int{}, err
+			}
+			if ok {
+				shown = append(shown, i)
+			}
+		}
+		return shown, nil
+	}
+
+	lower := strings.ToLower(expression)
+	for i, record := range records {
+		if strings.Contains(strings.ToLower(record.text), lower) {
+			shown = append(shown, i)
+		}
+	}
+	return shown, nil
+}
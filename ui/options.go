@@ -0,0 +1,439 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	DefaultTimeoutSeconds = 30
+	DefaultMaxRedirects   = 10
+)
+
+const (
+	TLSVerifyDefault = ""
+	TLSVerifyOn      = "verify"
+	TLSVerifySkip    = "skip"
+)
+
+const (
+	HTTPVersionAuto  = ""
+	HTTPVersionHTTP1 = "http1"
+	HTTPVersionHTTP2 = "http2"
+)
+
+var httpVersionLabels = []struct {
+	version string
+	label   string
+}{
+	{HTTPVersionAuto, "Automatic"},
+	{HTTPVersionHTTP1, "Force HTTP/1.1"},
+	{HTTPVersionHTTP2, "Require HTTP/2"},
+}
+
+const (
+	IPVersionAny = ""
+	IPVersion4   = "tcp4"
+	IPVersion6   = "tcp6"
+)
+
+var ipVersionLabels = []struct {
+	network string
+	label   string
+}{
+	{IPVersionAny, "Any (IPv4 or IPv6)"},
+	{IPVersion4, "IPv4 only"},
+	{IPVersion6, "IPv6 only"},
+}
+
+const acceptEncodingAuto = "Automatic (gzip, decoded by Go)"
+
+var acceptEncodings = []string{acceptEncodingAuto, "gzip", "br", "deflate", "identity"}
+
+var tlsVerifyLabels = []struct {
+	mode  string
+	label string
+}{
+	{TLSVerifyDefault, "Use setting"},
+	{TLSVerifyOn, "Verify certificates"},
+	{TLSVerifySkip, "Ignore certificate errors"},
+}
+
+// RequestOptionsEditor holds the per-request client settings shown in the
+// Options tab.
+type RequestOptionsEditor struct {
+	container         *fyne.Container
+	timeoutEntry      *widget.Entry
+	followCheck       *widget.Check
+	maxRedirectsEntry *widget.Entry
+	proxyEditor       *ProxyEditor
+	tlsVerifySelect   *widget.Select
+	cookiesCheck      *widget.Check
+	httpVersionSelect *widget.Select
+	encodingSelect    *widget.Select
+	ipVersionSelect   *widget.Select
+	unixSocketEntry   *widget.Entry
+	sniCheck          *widget.Check
+	downloadCheck     *widget.Check
+	chunkedCheck      *widget.Check
+	wrongLengthCheck  *widget.Check
+	wrongLengthEntry  *widget.Entry
+	OnChanged         func()
+}
+
+func NewRequestOptionsEditor() *RequestOptionsEditor {
+	o := &RequestOptionsEditor{}
+	o.createUI()
+	return o
+}
+
+func (o *RequestOptionsEditor) createUI() {
+	o.timeoutEntry = widget.NewEntry()
+	o.timeoutEntry.SetText(strconv.Itoa(DefaultTimeoutSeconds))
+	o.timeoutEntry.Validator = func(text string) error {
+		if _, ok := parseNonNegative(text); !ok {
+			return errors.New("enter a whole number of seconds")
+		}
+		return nil
+	}
+	o.timeoutEntry.OnChanged = func(string) {
+		o.changed()
+	}
+
+	o.maxRedirectsEntry = widget.NewEntry()
+	o.maxRedirectsEntry.SetText(strconv.Itoa(DefaultMaxRedirects))
+	o.maxRedirectsEntry.Validator = func(text string) error {
+		if _, ok := parseNonNegative(text); !ok {
+			return errors.New("enter a whole number")
+		}
+		return nil
+	}
+	o.maxRedirectsEntry.OnChanged = func(string) {
+		o.changed()
+	}
+
+	o.followCheck = widget.NewCheck("Follow redirects", func(checked bool) {
+		if checked {
+			o.maxRedirectsEntry.Enable()
+		} else {
+			o.maxRedirectsEntry.Disable()
+		}
+		o.changed()
+	})
+	o.followCheck.SetChecked(true)
+
+	versionLabels := make([]string, len(httpVersionLabels))
+	for i, v := range httpVersionLabels {
+		versionLabels[i] = v.label
+	}
+	o.httpVersionSelect = widget.NewSelect(versionLabels, func(string) {
+		o.changed()
+	})
+	o.httpVersionSelect.SetSelected(versionLabels[0])
+
+	o.encodingSelect = widget.NewSelect(acceptEncodings, func(string) {
+		o.changed()
+	})
+	o.encodingSelect.SetSelected(acceptEncodingAuto)
+
+	// Not remembered between sessions, like the TLS override
+	ipLabels := make([]string, len(ipVersionLabels))
+	for i, v := range ipVersionLabels {
+		ipLabels[i] = v.label
+	}
+	o.ipVersionSelect = widget.NewSelect(ipLabels, func(string) {
+		o.changed()
+	})
+	o.ipVersionSelect.SetSelected(ipLabels[0])
+
+	o.unixSocketEntry = widget.NewEntry()
+	o.unixSocketEntry.SetPlaceHolder("/var/run/docker.sock")
+	o.unixSocketEntry.OnChanged = func(string) {
+		o.changed()
+	}
+
+	o.cookiesCheck = widget.NewCheck("Use cookie jar (send and store cookies)", func(bool) {
+		o.changed()
+	})
+	o.cookiesCheck.SetChecked(true)
+
+	// The proxy override is not remembered, so every session starts out
+	// using the proxy from the settings
+	o.proxyEditor = NewProxyEditor(true)
+	o.proxyEditor.OnChanged = o.changed
+
+	labels := make([]string, len(tlsVerifyLabels))
+	for i, t := range tlsVerifyLabels {
+		labels[i] = t.label
+	}
+	o.tlsVerifySelect = widget.NewSelect(labels, func(string) {
+		o.changed()
+	})
+	o.tlsVerifySelect.SetSelected(labels[0])
+
+	// Not remembered either, since it only makes sense with a Host header
+	o.sniCheck = widget.NewCheck("Send the Host header as the TLS server name (SNI)", func(bool) {
+		o.changed()
+	})
+
+	sniLabel := widget.NewLabel("A Host header replaces the host from the URL in the request, while the connection goes to the URL's host. By default the TLS server name and the certificate check follow the URL too.")
+	sniLabel.Wrapping = fyne.TextWrapWord
+
+	// Not remembered either, so a forgotten check does not send every later
+	// response to a file
+	o.downloadCheck = widget.NewCheck("Save the response body to a file", func(bool) {
+		o.changed()
+	})
+
+	// Neither is remembered, so a forgotten check does not break every
+	// later request. A body has either a Content-Length or chunks.
+	o.wrongLengthEntry = widget.NewEntry()
+	o.wrongLengthEntry.SetPlaceHolder("Bytes to announce")
+	o.wrongLengthEntry.Validator = func(text string) error {
+		if _, ok := parseNonNegative(text); !ok {
+			return errors.New("enter a whole number of bytes")
+		}
+		return nil
+	}
+	o.wrongLengthEntry.OnChanged = func(string) {
+		o.changed()
+	}
+	o.wrongLengthEntry.Disable()
+
+	o.chunkedCheck = widget.NewCheck("Use chunked transfer encoding (no Content-Length)", func(checked bool) {
+		if checked {
+			o.wrongLengthCheck.SetChecked(false)
+		}
+		o.changed()
+	})
+	o.wrongLengthCheck = widget.NewCheck("Send a wrong Content-Length", func(checked bool) {
+		if checked {
+			o.chunkedCheck.SetChecked(false)
+			o.wrongLengthEntry.Enable()
+		} else {
+			o.wrongLengthEntry.Disable()
+		}
+		o.changed()
+	})
+
+	faultLabel := widget.NewLabel("Fault injection, for testing how a server copes with a broken request: a larger length than the body leaves the server waiting for the rest until the timeout, a smaller one sends only that many bytes of the body.")
+	faultLabel.Wrapping = fyne.TextWrapWord
+	faultLabel.Importance = widget.WarningImportance
+
+	o.container = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Timeout (seconds)", o.timeoutEntry),
+			widget.NewFormItem("HTTP version", o.httpVersionSelect),
+			widget.NewFormItem("Accept-Encoding", o.encodingSelect),
+			widget.NewFormItem("IP version", o.ipVersionSelect),
+			widget.NewFormItem("Unix socket", o.unixSocketEntry),
+		),
+		widget.NewLabel("With a Unix socket, or a URL like unix:///var/run/docker.sock:/v1.41/info, requests go to the socket; set a Host header for the host name."),
+		widget.NewLabel("Use 0 to wait for the response indefinitely."),
+		o.followCheck,
+		widget.NewForm(
+			widget.NewFormItem("Max redirects", o.maxRedirectsEntry),
+		),
+		o.cookiesCheck,
+		o.proxyEditor.GetContainer(),
+		container.NewBorder(nil, nil, widget.NewLabel("TLS:"), nil, o.tlsVerifySelect),
+		o.sniCheck,
+		sniLabel,
+		o.downloadCheck,
+		widget.NewLabel("Bodies larger than 50 MB are offered for saving either way."),
+		o.chunkedCheck,
+		widget.NewSeparator(),
+		o.wrongLengthCheck,
+		widget.NewForm(
+			widget.NewFormItem("Content-Length", o.wrongLengthEntry),
+		),
+		faultLabel,
+	)
+}
+
+func (o *RequestOptionsEditor) changed() {
+	if o.OnChanged != nil {
+		o.OnChanged()
+	}
+}
+
+// GetTimeout returns the timeout in seconds, where 0 means no timeout. An
+// invalid entry falls back to the default.
+func (o *RequestOptionsEditor) GetTimeout() int {
+	seconds, ok := parseNonNegative(o.timeoutEntry.Text)
+	if !ok {
+		return DefaultTimeoutSeconds
+	}
+	return seconds
+}
+
+func (o *RequestOptionsEditor) SetTimeout(seconds int) {
+	o.timeoutEntry.SetText(strconv.Itoa(seconds))
+}
+
+func (o *RequestOptionsEditor) GetFollowRedirects() bool {
+	return o.followCheck.Checked
+}
+
+func (o *RequestOptionsEditor) SetFollowRedirects(follow bool) {
+	o.followCheck.SetChecked(follow)
+}
+
+// GetMaxRedirects returns how many redirects may be followed before the
+// request fails. An invalid entry falls back to the default.
+func (o *RequestOptionsEditor) GetMaxRedirects() int {
+	max, ok := parseNonNegative(o.maxRedirectsEntry.Text)
+	if !ok {
+		return DefaultMaxRedirects
+	}
+	return max
+}
+
+func (o *RequestOptionsEditor) SetMaxRedirects(max int) {
+	o.maxRedirectsEntry.SetText(strconv.Itoa(max))
+}
+
+// GetHTTPVersion returns one of HTTPVersionAuto, HTTPVersionHTTP1 or
+// HTTPVersionHTTP2.
+func (o *RequestOptionsEditor) GetHTTPVersion() string {
+	for _, v := range httpVersionLabels {
+		if v.label == o.httpVersionSelect.Selected {
+			return v.version
+		}
+	}
+	return HTTPVersionAuto
+}
+
+func (o *RequestOptionsEditor) SetHTTPVersion(version string) {
+	for _, v := range httpVersionLabels {
+		if v.version == version {
+			o.httpVersionSelect.SetSelected(v.label)
+			return
+		}
+	}
+	o.httpVersionSelect.SetSelected(httpVersionLabels[0].label)
+}
+
+// GetAcceptEncoding returns the Accept-Encoding to send, or "" to let the
+// transport request gzip and decompress it transparently.
+func (o *RequestOptionsEditor) GetAcceptEncoding() string {
+	if o.encodingSelect.Selected == acceptEncodingAuto {
+		return ""
+	}
+	return o.encodingSelect.Selected
+}
+
+func (o *RequestOptionsEditor) SetAcceptEncoding(encoding string) {
+	for _, known := range acceptEncodings[1:] {
+		if known == encoding {
+			o.encodingSelect.SetSelected(known)
+			return
+		}
+	}
+	o.encodingSelect.SetSelected(acceptEncodingAuto)
+}
+
+// GetIPVersion returns the network to connect over, one of IPVersionAny,
+// IPVersion4 or IPVersion6.
+func (o *RequestOptionsEditor) GetIPVersion() string {
+	for _, v := range ipVersionLabels {
+		if v.label == o.ipVersionSelect.Selected {
+			return v.network
+		}
+	}
+	return IPVersionAny
+}
+
+// GetUnixSocket returns the path of the Unix socket to send the request
+// over, or "" to connect to the URL's host.
+func (o *RequestOptionsEditor) GetUnixSocket() string {
+	return strings.TrimSpace(o.unixSocketEntry.Text)
+}
+
+func (o *RequestOptionsEditor) SetUnixSocket(path string) {
+	o.unixSocketEntry.SetText(path)
+}
+
+func (o *RequestOptionsEditor) GetUseCookies() bool {
+	return o.cookiesCheck.Checked
+}
+
+func (o *RequestOptionsEditor) SetUseCookies(use bool) {
+	o.cookiesCheck.SetChecked(use)
+}
+
+// GetProxy returns the proxy override for this request. Its mode is
+// ProxyModeDefault when the proxy from the settings should be used.
+func (o *RequestOptionsEditor) GetProxy() ProxyConfig {
+	return o.proxyEditor.GetConfig()
+}
+
+// GetTLSVerify returns the TLS verification override for this request, one
+// of TLSVerifyDefault, TLSVerifyOn or TLSVerifySkip. Like the proxy override
+// it is not remembered between sessions.
+func (o *RequestOptionsEditor) GetTLSVerify() string {
+	for _, t := range tlsVerifyLabels {
+		if t.label == o.tlsVerifySelect.Selected {
+			return t.mode
+		}
+	}
+	return TLSVerifyDefault
+}
+
+func (o *RequestOptionsEditor) SetTLSVerify(mode string) {
+	for _, t := range tlsVerifyLabels {
+		if t.mode == mode {
+			o.tlsVerifySelect.SetSelected(t.label)
+			return
+		}
+	}
+}
+
+// GetSNIFromHost reports whether the TLS server name should be taken from a
+// Host header rather than the URL.
+func (o *RequestOptionsEditor) GetSNIFromHost() bool {
+	return o.sniCheck.Checked
+}
+
+// GetDownloadToFile reports whether the response body should be saved to a
+// file chosen when it arrives rather than shown.
+func (o *RequestOptionsEditor) GetDownloadToFile() bool {
+	return o.downloadCheck.Checked
+}
+
+// GetChunked reports whether the body should be sent with chunked transfer
+// encoding instead of a Content-Length.
+func (o *RequestOptionsEditor) GetChunked() bool {
+	return o.chunkedCheck.Checked
+}
+
+// GetWrongContentLength returns the Content-Length to announce in place of
+// the body's own for fault injection, or nil to send the real one.
+func (o *RequestOptionsEditor) GetWrongContentLength() *int64 {
+	if !o.wrongLengthCheck.Checked {
+		return nil
+	}
+	length, ok := parseNonNegative(o.wrongLengthEntry.Text)
+	if !ok {
+		return nil
+	}
+	announced := int64(length)
+	return &announced
+}
+
+func (o *RequestOptionsEditor) GetContainer() *fyne.Container {
+	return o.container
+}
+
+func parseNonNegative(text string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
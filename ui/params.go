@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+)
+
+// ParamsEditor keeps a table of query parameters in sync with the query
+// string of a URL. Disabled rows are kept in the table but left out of the URL,
+// and descriptions stay with their rows as the URL is edited. Path variables
+// such as :id are listed in a table below the params.
+type ParamsEditor struct {
+	editor        *KeyValueEditor
+	pathVariables *pathVariablesEditor
+	container     *fyne.Container
+	currentURL    string
+	updating      bool
+	OnURLChanged  func(newURL string)
+
+	// OnPathVariablesChanged is called when a path variable value is edited
+	OnPathVariablesChanged func()
+}
+
+func NewParamsEditor() *ParamsEditor {
+	p := &ParamsEditor{
+		editor:        NewKeyValueEditorWithDescriptions("Parameter", "Value", "Add Param"),
+		pathVariables: newPathVariablesEditor(),
+	}
+	p.editor.OnChanged = p.paramsChanged
+	p.pathVariables.OnChanged = func() {
+		if p.OnPathVariablesChanged != nil {
+			p.OnPathVariablesChanged()
+		}
+	}
+	p.container = container.NewBorder(nil, p.pathVariables.container, nil, nil, p.editor.GetContainer())
+	return p
+}
+
+// SetURL repopulates the rows from the query string of rawURL. Rows that are
+// currently disabled are preserved since they are not part of the URL.
+func (p *ParamsEditor) SetURL(rawURL string) {
+	if p.updating {
+		return
+	}
+	p.SetParams(rawURL, p.editor.GetPairs())
+}
+
+// SetParams repopulates the rows from the query string of rawURL, taking the
+// descriptions and disabled rows from rows, such as those of a stored request.
+func (p *ParamsEditor) SetParams(rawURL string, rows []KeyValue) {
+	p.currentURL = rawURL
+	p.pathVariables.setURL(rawURL)
+
+	params := ParseQueryParams(rawURL)
+	used := make([]bool, len(rows))
+	for i := range params {
+		for j, row := range rows {
+			if !used[j] && !row.Disabled && row.Key == params[i].Key {
+				params[i].Description = row.Description
+				used[j] = true
+				break
+			}
+		}
+	}
+	for _, row := range rows {
+		if row.Disabled {
+			params = append(params, row)
+		}
+	}
+
+	p.updating = true
+	p.editor.SetPairs(params)
+	p.updating = false
+}
+
+// GetParams returns every row, disabled ones included.
+func (p *ParamsEditor) GetParams() []KeyValue {
+	return p.editor.GetPairs()
+}
+
+func (p *ParamsEditor) paramsChanged() {
+	if p.updating {
+		return
+	}
+
+	newURL := BuildURLWithParams(p.currentURL, EnabledPairs(p.editor.GetPairs()))
+	if newURL == p.currentURL {
+		return
+	}
+	p.currentURL = newURL
+	p.pathVariables.setURL(newURL)
+
+	if p.OnURLChanged != nil {
+		p.updating = true
+		p.OnURLChanged(newURL)
+		p.updating = false
+	}
+}
+
+// GetPathVariables returns the path variables of the URL with their values.
+func (p *ParamsEditor) GetPathVariables() []KeyValue {
+	return p.pathVariables.getValues()
+}
+
+// SetPathVariables sets the values of the path variables, e.g. those of a
+// stored request, replacing the values typed before.
+func (p *ParamsEditor) SetPathVariables(values []KeyValue) {
+	p.pathVariables.setValues(values)
+}
+
+// CheckPathVariables points out the path variables without a value and
+// reports whether the request can be sent.
+func (p *ParamsEditor) CheckPathVariables() bool {
+	return p.pathVariables.check()
+}
+
+func (p *ParamsEditor) GetContainer() *fyne.Container {
+	return p.container
+}
+
+func splitURL(rawURL string) (base, query, fragment string) {
+	if i := strings.Index(rawURL, "#"); i >= 0 {
+		fragment = rawURL[i:]
+		rawURL = rawURL[:i]
+	}
+	if i := strings.Index(rawURL, "?"); i >= 0 {
+		query = rawURL[i+1:]
+		rawURL = rawURL[:i]
+	}
+	return rawURL, query, fragment
+}
+
+// ParseQueryParams splits the query string of rawURL into ordered pairs,
+// decoding percent-escapes where possible.
+func ParseQueryParams(rawURL string) []KeyValue {
+	_, query, _ := splitURL(rawURL)
+	if query == "" {
+		return nil
+	}
+
+	var params []KeyValue
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		if decoded, err := url.QueryUnescape(key); err == nil {
+			key = decoded
+		}
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+		if key == "" {
+			continue
+		}
+		params = append(params, KeyValue{Key: key, Value: value})
+	}
+	return params
+}
+
+// BuildURLWithParams replaces the query string of rawURL with the given pairs,
+// keeping their order and any fragment.
+func BuildURLWithParams(rawURL string, params []KeyValue) string {
+	base, _, fragment := splitURL(rawURL)
+
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		if param.Key == "" {
+			continue
+		}
+		part := url.QueryEscape(param.Key)
+		if param.Value != "" {
+			part += "=" + url.QueryEscape(param.Value)
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return base + fragment
+	}
+	return base + "?" + strings.Join(parts, "&") + fragment
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// pathVariablePattern matches a path segment written as :name or {name};
+// {{name}} is an environment variable instead.
+var pathVariablePattern = regexp.MustCompile(`^(?::([A-Za-z_][A-Za-z0-9_-]*)|\{([A-Za-z_][A-Za-z0-9_-]*)\})$`)
+
+// pathBounds returns where the path of rawURL starts and ends, leaving out
+// the scheme and host, or a leading {{variable}} standing for them, and the
+// query and fragment.
+func pathBounds(rawURL string) (start, end int) {
+	end = len(rawURL)
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		end = i
+	}
+	if i := strings.Index(rawURL[:end], "://"); i >= 0 {
+		start = i + 3
+	} else if strings.HasPrefix(rawURL, "{{") {
+		if i := strings.Index(rawURL[:end], "}}"); i >= 0 {
+			start = i + 2
+		}
+	}
+	// Whatever precedes the first slash is the host
+	if i := strings.Index(rawURL[start:end], "/"); i >= 0 {
+		return start + i, end
+	}
+	return end, end
+}
+
+// ReplacePathVariables calls replace for each :name or {name} segment of the
+// path of rawURL and puts the result in place of the segment; when ok is
+// false the segment is left as written.
+func ReplacePathVariables(rawURL string, replace func(name string) (value string, ok bool)) string {
+	start, end := pathBounds(rawURL)
+	segments := strings.Split(rawURL[start:end], "/")
+	for i, segment := range segments {
+		match := pathVariablePattern.FindStringSubmatch(segment)
+		if match == nil {
+			continue
+		}
+		if value, ok := replace(match[1] + match[2]); ok {
+			segments[i] = value
+		}
+	}
+	return rawURL[:start] + strings.Join(segments, "/") + rawURL[end:]
+}
+
+// PathVariableNames returns the path variables of rawURL in order, each once.
+func PathVariableNames(rawURL string) []string {
+	var names []string
+	seen := map[string]bool{}
+	ReplacePathVariables(rawURL, func(name string) (string, bool) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return "", false
+	})
+	return names
+}
+
+// pathVariablesEditor is the "Path variables" table below the params: one
+// row per path variable of the URL. Values are remembered by name, so a
+// variable removed from the URL and typed again gets its value back.
+type pathVariablesEditor struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+	rows      []*pathVariableRow
+	values    map[string]string
+	OnChanged func()
+}
+
+type pathVariableRow struct {
+	name       string
+	valueEntry *widget.Entry
+	errorLabel *widget.Label
+}
+
+func newPathVariablesEditor() *pathVariablesEditor {
+	p := &pathVariablesEditor{values: map[string]string{}}
+	p.rowsBox = container.NewVBox()
+	p.container = container.NewVBox(
+		widget.NewLabelWithStyle("Path variables", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		p.rowsBox,
+	)
+	p.container.Hide()
+	return p
+}
+
+// setURL lists the path variables of rawURL.
+func (p *pathVariablesEditor) setURL(rawURL string) {
+	names := PathVariableNames(rawURL)
+	if len(names) == len(p.rows) {
+		same := true
+		for i, row := range p.rows {
+			same = same && row.name == names[i]
+		}
+		if same {
+			return
+		}
+	}
+
+	p.rows = nil
+	p.rowsBox.RemoveAll()
+	for _, name := range names {
+		p.addRow(name)
+	}
+	if len(names) == 0 {
+		p.container.Hide()
+	} else {
+		p.container.Show()
+	}
+}
+
+func (p *pathVariablesEditor) addRow(name string) {
+	row := &pathVariableRow{name: name}
+	row.valueEntry = widget.NewEntry()
+	row.valueEntry.SetPlaceHolder("Value")
+	row.valueEntry.SetText(p.values[name])
+	row.valueEntry.OnChanged = func(text string) {
+		p.values[name] = text
+		if text != "" {
+			row.errorLabel.Hide()
+		}
+		if p.OnChanged != nil {
+			p.OnChanged()
+		}
+	}
+
+	row.errorLabel = widget.NewLabel("Enter a value to send the request")
+	row.errorLabel.Importance = widget.DangerImportance
+	row.errorLabel.Hide()
+
+	nameLabel := widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	p.rows = append(p.rows, row)
+	p.rowsBox.Add(container.NewBorder(nil, nil, nameLabel, row.errorLabel, row.valueEntry))
+}
+
+// getValues returns the values of the path variables in the URL.
+func (p *pathVariablesEditor) getValues() []KeyValue {
+	values := make([]KeyValue, 0, len(p.rows))
+	for _, row := range p.rows {
+		values = append(values, KeyValue{Key: row.name, Value: row.valueEntry.Text})
+	}
+	return values
+}
+
+// setValues replaces the remembered values, e.g. with those of a stored
+// request.
+func (p *pathVariablesEditor) setValues(values []KeyValue) {
+	p.values = map[string]string{}
+	for _, value := range values {
+		p.values[value.Key] = value.Value
+	}
+	for _, row := range p.rows {
+		row.valueEntry.SetText(p.values[row.name])
+		row.errorLabel.Hide()
+	}
+}
+
+// check shows an error next to every path variable without a value and
+// reports whether there are none.
+func (p *pathVariablesEditor) check() bool {
+	ok := true
+	for _, row := range p.rows {
+		if row.valueEntry.Text == "" {
+			row.errorLabel.Show()
+			ok = false
+		} else {
+			row.errorLabel.Hide()
+		}
+	}
+	return ok
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// RequestPreview is the Preview tab: the request as it will be sent, which
+// the caller renders again as the editors change.
+type RequestPreview struct {
+	container *fyne.Container
+	maskCheck *widget.Check
+	noteLabel *widget.Label
+	textEntry *widget.Entry
+
+	// OnMaskChanged is called when secrets are masked or shown
+	OnMaskChanged func()
+}
+
+func NewRequestPreview() *RequestPreview {
+	p := &RequestPreview{}
+
+	p.maskCheck = widget.NewCheck("Mask secrets", func(bool) {
+		if p.OnMaskChanged != nil {
+			p.OnMaskChanged()
+		}
+	})
+	p.maskCheck.SetChecked(true)
+
+	p.noteLabel = widget.NewLabel("")
+	p.noteLabel.Wrapping = fyne.TextWrapWord
+	p.noteLabel.Importance = widget.WarningImportance
+	p.noteLabel.Hide()
+
+	p.textEntry = widget.NewMultiLineEntry()
+	p.textEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	p.textEntry.Wrapping = fyne.TextWrapBreak
+	p.textEntry.Disable()
+
+	p.container = container.NewBorder(
+		container.NewVBox(p.maskCheck, p.noteLabel),
+		nil, nil, nil,
+		p.textEntry,
+	)
+	return p
+}
+
+// GetMaskSecrets reports whether secret variable values and credentials
+// should be masked.
+func (p *RequestPreview) GetMaskSecrets() bool {
+	return p.maskCheck.Checked
+}
+
+// SetPreview shows the request text, with a note on what differs when it
+// is actually sent; an empty note is hidden.
+func (p *RequestPreview) SetPreview(text, note string) {
+	p.textEntry.SetText(text)
+	p.noteLabel.SetText(note)
+	if note == "" {
+		p.noteLabel.Hide()
+	} else {
+		p.noteLabel.Show()
+	}
+}
+
+func (p *RequestPreview) GetContainer() *fyne.Container {
+	return p.container
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golem/protobuf"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ProtoFilesEditor lists the descriptor sets and .proto files protobuf
+// responses are decoded with. Files are checked when they are added.
+type ProtoFilesEditor struct {
+	container    *fyne.Container
+	rowsBox      *fyne.Container
+	files        []string
+	parentWindow fyne.Window
+}
+
+func NewProtoFilesEditor(parentWindow fyne.Window) *ProtoFilesEditor {
+	p := &ProtoFilesEditor{parentWindow: parentWindow}
+
+	p.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Descriptor File", theme.ContentAddIcon(), p.chooseFile)
+
+	p.container = container.NewBorder(nil, container.NewHBox(addButton), nil, nil, p.rowsBox)
+	return p
+}
+
+func (p *ProtoFilesEditor) chooseFile() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		path := reader.URI().Path()
+		if _, err := protobuf.LoadFile(path); err != nil {
+			dialog.ShowError(err, p.parentWindow)
+			return
+		}
+		p.SetFiles(append(p.files, path))
+	}, p.parentWindow)
+}
+
+func (p *ProtoFilesEditor) removeFile(index int) {
+	files := append([]string{}, p.files[:index]...)
+	p.SetFiles(append(files, p.files[index+1:]...))
+}
+
+func (p *ProtoFilesEditor) GetFiles() []string {
+	return p.files
+}
+
+func (p *ProtoFilesEditor) SetFiles(files []string) {
+	p.files = files
+	p.rowsBox.RemoveAll()
+	for i, file := range files {
+		index := i
+		status := widget.NewLabel(describeProtoFile(file))
+		status.Wrapping = fyne.TextWrapWord
+		removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+			p.removeFile(index)
+		})
+		p.rowsBox.Add(container.NewBorder(nil, nil, nil, removeButton, status))
+	}
+	p.rowsBox.Refresh()
+}
+
+func describeProtoFile(path string) string {
+	registry, err := protobuf.NewRegistry([]string{path})
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%d message types)", filepath.Base(path), len(registry.MessageTypes()))
+}
+
+func (p *ProtoFilesEditor) GetContainer() *fyne.Container {
+	return p.container
+}
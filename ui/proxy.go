@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// ProxyModeDefault is only used for per-request overrides and means the
+	// proxy from the settings applies.
+	ProxyModeDefault = ""
+	ProxyModeSystem  = "system"
+	ProxyModeNone    = "none"
+	ProxyModeManual  = "manual"
+)
+
+var proxyModeLabels = []struct {
+	mode  string
+	label string
+}{
+	{ProxyModeDefault, "Use proxy from settings"},
+	{ProxyModeSystem, "System proxy (environment)"},
+	{ProxyModeNone, "No proxy"},
+	{ProxyModeManual, "Manual"},
+}
+
+type ProxyConfig struct {
+	Mode     string `json:"mode,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// RemoteDNS has a SOCKS proxy resolve host names, as socks5h:// does
+	RemoteDNS bool `json:"remote_dns,omitempty"`
+}
+
+// Resolve returns the proxy to use for a request with override c, falling
+// back to the configured default.
+func (c ProxyConfig) Resolve(defaultConfig ProxyConfig) ProxyConfig {
+	if c.Mode == ProxyModeDefault {
+		return defaultConfig
+	}
+	return c
+}
+
+type ProxyEditor struct {
+	container      *fyne.Container
+	modeSelect     *widget.Select
+	hostEntry      *widget.Entry
+	usernameEntry  *widget.Entry
+	passwordEntry  *widget.Entry
+	remoteDNSCheck *widget.Check
+	manualForm     *fyne.Container
+	allowDefault   bool
+	OnChanged      func()
+}
+
+// NewProxyEditor creates the proxy form. With allowDefault the editor offers
+// "Use proxy from settings", for per-request overrides.
+func NewProxyEditor(allowDefault bool) *ProxyEditor {
+	p := &ProxyEditor{allowDefault: allowDefault}
+	p.createUI()
+	return p
+}
+
+func (p *ProxyEditor) createUI() {
+	p.hostEntry = widget.NewEntry()
+	p.hostEntry.SetPlaceHolder("proxy.example.com:8080 or socks5://localhost:1080")
+	p.usernameEntry = widget.NewEntry()
+	p.usernameEntry.SetPlaceHolder("Optional")
+	p.passwordEntry = widget.NewPasswordEntry()
+	p.passwordEntry.SetPlaceHolder("Optional")
+
+	for _, entry := range []*widget.Entry{p.hostEntry, p.usernameEntry, p.passwordEntry} {
+		entry.OnChanged = func(string) {
+			p.changed()
+		}
+	}
+
+	p.remoteDNSCheck = widget.NewCheck("Resolve host names through a SOCKS proxy", func(bool) {
+		p.changed()
+	})
+
+	p.manualForm = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Host:Port", p.hostEntry),
+			widget.NewFormItem("Username", p.usernameEntry),
+			widget.NewFormItem("Password", p.passwordEntry),
+		),
+		p.remoteDNSCheck,
+	)
+
+	var labels []string
+	for _, m := range proxyModeLabels {
+		if m.mode == ProxyModeDefault && !p.allowDefault {
+			continue
+		}
+		labels = append(labels, m.label)
+	}
+	p.modeSelect = widget.NewSelect(labels, func(string) {
+		if p.selectedMode() == ProxyModeManual {
+			p.manualForm.Show()
+		} else {
+			p.manualForm.Hide()
+		}
+		p.changed()
+	})
+
+	p.container = container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Proxy:"), nil, p.modeSelect),
+		p.manualForm,
+	)
+
+	p.modeSelect.SetSelected(labels[0])
+}
+
+func (p *ProxyEditor) changed() {
+	if p.OnChanged != nil {
+		p.OnChanged()
+	}
+}
+
+func (p *ProxyEditor) selectedMode() string {
+	for _, m := range proxyModeLabels {
+		if m.label == p.modeSelect.Selected {
+			return m.mode
+		}
+	}
+	return ProxyModeDefault
+}
+
+func (p *ProxyEditor) GetConfig() ProxyConfig {
+	config := ProxyConfig{Mode: p.selectedMode()}
+	if config.Mode == ProxyModeManual {
+		config.Host = p.hostEntry.Text
+		config.Username = p.usernameEntry.Text
+		config.Password = p.passwordEntry.Text
+		config.RemoteDNS = p.remoteDNSCheck.Checked
+	}
+	return config
+}
+
+func (p *ProxyEditor) SetConfig(config ProxyConfig) {
+	p.hostEntry.SetText(config.Host)
+	p.usernameEntry.SetText(config.Username)
+	p.passwordEntry.SetText(config.Password)
+	p.remoteDNSCheck.SetChecked(config.RemoteDNS)
+
+	label := p.modeSelect.Options[0]
+	for _, m := range proxyModeLabels {
+		if m.mode == config.Mode && (m.mode != ProxyModeDefault || p.allowDefault) {
+			label = m.label
+		}
+	}
+	p.modeSelect.SetSelected(label)
+}
+
+func (p *ProxyEditor) GetContainer() *fyne.Container {
+	return p.container
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MaxRepeatCount caps a repeated send; heavier runs belong in a load test.
+const MaxRepeatCount = 1000
+
+// ShowRepeatDialog asks how many times to send the request and how long to
+// wait between sends, starting from the previous choice.
+func ShowRepeatDialog(count int, delay time.Duration, onSend func(count int, delay time.Duration), parentWindow fyne.Window) {
+	countEntry := widget.NewEntry()
+	countEntry.SetText(strconv.Itoa(count))
+	countEntry.Validator = func(text string) error {
+		n, ok := parseNonNegative(text)
+		if !ok || n < 1 || n > MaxRepeatCount {
+			return errors.New("enter a number from 1 to " + strconv.Itoa(MaxRepeatCount))
+		}
+		return nil
+	}
+
+	delayEntry := widget.NewEntry()
+	delayEntry.SetText(strconv.FormatInt(delay.Milliseconds(), 10))
+	delayEntry.Validator = func(text string) error {
+		if _, ok := parseNonNegative(text); !ok {
+			return errors.New("enter a whole number of milliseconds")
+		}
+		return nil
+	}
+
+	dialog.ShowForm("Send ×N", "Send", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Times", countEntry),
+			widget.NewFormItem("Delay (ms)", delayEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			n, _ := parseNonNegative(countEntry.Text)
+			ms, _ := parseNonNegative(delayEntry.Text)
+			onSend(n, time.Duration(ms)*time.Millisecond)
+		}, parentWindow)
+}
@@ -0,0 +1,756 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golem/protobuf"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// How a structured response body is shown.
+const (
+	BodyViewPretty   = "Pretty"
+	BodyViewRaw      = "Raw"
+	BodyViewMinified = "Minified"
+	BodyViewTable    = "Table"
+	BodyViewRecords  = "Records"
+)
+
+// backgroundFormatSize is the body size from which formatting runs in the
+// background.
+const backgroundFormatSize = 256 << 10
+
+// protobufWireFormat is picked to show a protobuf body as its wire format
+// rather than decoded as a message type.
+const protobufWireFormat = "Wire format"
+
+// filterDelay is how long typing in the filter pauses before it is applied.
+const filterDelay = 300 * time.Millisecond
+
+// The kinds of body the view can format.
+const (
+	bodyKindPlain  = ""
+	bodyKindJSON   = "JSON"
+	bodyKindXML    = "XML"
+	bodyKindCSV    = "CSV"
+	bodyKindNDJSON = "NDJSON"
+)
+
+// ResponseBodyView shows the response body. A JSON or XML body, by its
+// Content-Type or its first characters, can be shown pretty-printed, as
+// received or minified; formatting runs off the main thread so a body of
+// several megabytes does not freeze the window. The view chosen is kept for
+// later responses. A JSON body can be narrowed down by a JSON path typed in
+// the filter, which is kept for later responses too. Word wrap, a monospace
+// font and the text size are set with the controls on the right, as are
+// line numbers and links, which show the body in rows drawn as they scroll
+// into view; a URL clicked there is loaded into the URL bar, or opened in
+// the browser with Ctrl or Cmd held.
+// A CSV or TSV body is shown as a table that sorts by the column clicked,
+// as can any other text body; the raw view stays a click away. An NDJSON
+// body is shown as its records, which can be filtered and expanded one by
+// one. A protobuf body is decoded as the message type picked, or shown as
+// its wire format.
+// Text selected in the body can be decoded from base64, URL encoding or a
+// Unix timestamp with the Decode button.
+// Of a body too large to load at once only the start is shown, unformatted,
+// with a bar to load more of it or save the whole of it.
+type ResponseBodyView struct {
+	// OnFilter returns the values of body selected by a JSON path or jq-style
+	// expression, as JSON; it is called off the main thread.
+	OnFilter func(body, expression string) (string, error)
+	// OnMatchRecord reports whether a JSON path selects something in a
+	// record of an NDJSON body; it is called off the main thread.
+	OnMatchRecord func(record, path string) (bool, error)
+	// OnTextStyleChanged is called when the text controls are used
+	OnTextStyleChanged func(style BodyTextStyle)
+	// OnLineNumbersChanged is called when line numbers are turned on or off
+	OnLineNumbersChanged func(on bool)
+	// OnLinksChanged is called when links are turned on or off
+	OnLinksChanged func(on bool)
+	// OnOpenURL loads a URL of the body into the URL bar
+	OnOpenURL func(url string)
+	// OnLoadMore returns the next part of a body shown in part, and how
+	// many bytes of the body are loaded with it
+	OnLoadMore func() (more string, loaded int, err error)
+	// OnSaveAll saves the whole of a body shown in part
+	OnSaveAll func()
+	// OnProtobufDecoded is called with a protobuf body decoded as JSON, or
+	// with "" when it is shown as its wire format
+	OnProtobufDecoded func(json string)
+
+	container      *fyne.Container
+	viewRadio      *widget.RadioGroup
+	kindLabel      *widget.Label
+	errorLabel     *widget.Label
+	formatControls *fyne.Container
+	entry          *widget.Entry
+	text           *bodyText
+	textControls   *bodyTextControls
+	lineNumbers    *widget.Check
+	links          *widget.Check
+	scroll         *container.Scroll
+	finder         *responseFinder
+	table          *csvTable
+	records        *ndjsonView
+	body           string
+	kind           string
+	generation     int
+	// views holds the view last chosen for each kind of body
+	views map[string]string
+
+	partialBar   *fyne.Container
+	partialLabel *widget.Label
+	contentType  string
+	loaded       int
+	total        int
+
+	protobufBar      *fyne.Container
+	protobufSelect   *widget.Select
+	protobufHint     *widget.Label
+	protobufRegistry *protobuf.Registry
+	protobufBody     string
+	// protobufType is the message type picked last
+	protobufType string
+
+	filterRow    *fyne.Container
+	filterEntry  *widget.Entry
+	filterError  *widget.Label
+	filterTimer  *time.Timer
+	parentWindow fyne.Window
+}
+
+func NewResponseBodyView(parentWindow fyne.Window) *ResponseBodyView {
+	v := &ResponseBodyView{parentWindow: parentWindow, views: map[string]string{}}
+
+	v.entry = widget.NewMultiLineEntry()
+	v.entry.Disable()
+	v.finder = newResponseFinder()
+
+	v.viewRadio = widget.NewRadioGroup([]string{BodyViewPretty, BodyViewRaw, BodyViewMinified}, func(view string) {
+		v.views[viewKind(v.kind)] = view
+		v.render()
+	})
+	v.viewRadio.Horizontal = true
+	v.viewRadio.Required = true
+	v.viewRadio.Selected = BodyViewPretty
+
+	v.kindLabel = widget.NewLabel("")
+	v.errorLabel = widget.NewLabel("")
+	v.errorLabel.Importance = widget.WarningImportance
+	v.errorLabel.Wrapping = fyne.TextWrapWord
+	v.errorLabel.Hide()
+
+	v.filterEntry = widget.NewEntry()
+	v.filterEntry.SetPlaceHolder("Filter, e.g. $.data.items[*].id or .data.items[].id")
+	v.filterEntry.OnChanged = func(string) {
+		if v.filterTimer != nil {
+			v.filterTimer.Stop()
+		}
+		v.filterTimer = time.AfterFunc(filterDelay, func() {
+			fyne.Do(v.render)
+		})
+	}
+	v.filterError = widget.NewLabel("")
+	v.filterError.Importance = widget.DangerImportance
+	v.filterError.Wrapping = fyne.TextWrapWord
+	v.filterError.Hide()
+
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(v.entry.Text)
+	})
+	saveButton := widget.NewButtonWithIcon("Save…", theme.DocumentSaveIcon(), v.saveShown)
+	v.filterRow = container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(copyButton, saveButton), v.filterEntry),
+		v.filterError,
+	)
+	v.filterRow.Hide()
+
+	v.partialLabel = widget.NewLabel("")
+	v.partialLabel.Importance = widget.WarningImportance
+	loadMoreButton := widget.NewButtonWithIcon("Load More", theme.MoreHorizontalIcon(), v.loadMore)
+	saveAllButton := widget.NewButtonWithIcon("Save to File", theme.DocumentSaveIcon(), func() {
+		if v.OnSaveAll != nil {
+			v.OnSaveAll()
+		}
+	})
+	v.partialBar = container.NewBorder(nil, nil, nil, container.NewHBox(loadMoreButton, saveAllButton), v.partialLabel)
+	v.partialBar.Hide()
+
+	v.protobufSelect = widget.NewSelect(nil, func(messageType string) {
+		v.protobufType = messageType
+		v.decodeProtobuf(messageType)
+	})
+	v.protobufHint = widget.NewLabel("Add descriptor sets or .proto files in Settings to decode messages")
+	v.protobufHint.Importance = widget.LowImportance
+	v.protobufBar = container.NewBorder(nil, nil, widget.NewLabel("Protobuf message:"), v.protobufHint, v.protobufSelect)
+	v.protobufBar.Hide()
+
+	v.formatControls = container.NewHBox(v.kindLabel, v.viewRadio)
+	v.formatControls.Hide()
+	v.textControls = newBodyTextControls(func(style BodyTextStyle) {
+		if v.OnTextStyleChanged != nil {
+			v.OnTextStyleChanged(style)
+		}
+	})
+
+	v.lineNumbers = widget.NewCheck("Line numbers", func(on bool) {
+		v.setLineNumbers(on)
+		if v.OnLineNumbersChanged != nil {
+			v.OnLineNumbersChanged(on)
+		}
+	})
+
+	v.links = widget.NewCheck("Links", func(on bool) {
+		v.finder.setLinks(on, v.entry.Text)
+		v.updateView()
+		if v.OnLinksChanged != nil {
+			v.OnLinksChanged(on)
+		}
+	})
+	v.finder.onOpenURL = func(url string) {
+		if v.OnOpenURL != nil {
+			v.OnOpenURL(url)
+		}
+	}
+
+	v.scroll = container.NewScroll(v.entry)
+	v.scroll.SetMinSize(fyne.NewSize(600, 400))
+
+	v.finder.onClose = v.updateView
+	v.text = newBodyText(v.entry, container.NewStack(v.scroll, v.finder.view))
+	v.table = newCSVTable()
+	v.table.container.Hide()
+	v.records = newNDJSONView(func(record, path string) (bool, error) {
+		if v.OnMatchRecord == nil {
+			return false, fmt.Errorf("JSON paths are not supported")
+		}
+		return v.OnMatchRecord(record, path)
+	})
+	v.records.container.Hide()
+
+	v.container = container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, v.formatControls, container.NewHBox(newDecodeButton(v.selectedText, parentWindow), v.lineNumbers, v.links, v.textControls.container)),
+			v.partialBar,
+			v.protobufBar,
+			v.filterRow,
+			v.errorLabel,
+			v.finder.bar,
+		),
+		nil, nil, nil,
+		container.NewStack(v.text.override, v.table.container, v.records.container),
+	)
+	return v
+}
+
+// selectedText is the text selected in the body, which only the entry
+// allows; it is "" while the body is shown as rows, a table or records.
+func (v *ResponseBodyView) selectedText() string {
+	if !v.text.override.Visible() || !v.scroll.Visible() {
+		return ""
+	}
+	return v.entry.SelectedText()
+}
+
+// GetTextStyle returns how the body is shown.
+func (v *ResponseBodyView) GetTextStyle() BodyTextStyle {
+	return v.textControls.style
+}
+
+// SetTextStyle sets how the body is shown. The body is laid out again once
+// changes pause, without formatting it again.
+func (v *ResponseBodyView) SetTextStyle(style BodyTextStyle) {
+	v.textControls.set(style)
+	v.text.setStyle(style)
+}
+
+// SetLineNumbers shows or hides the line numbers.
+func (v *ResponseBodyView) SetLineNumbers(on bool) {
+	v.lineNumbers.Checked = on
+	v.lineNumbers.Refresh()
+	v.setLineNumbers(on)
+}
+
+// SetLinks makes the URLs in the body links, or plain text again.
+func (v *ResponseBodyView) SetLinks(on bool) {
+	v.links.Checked = on
+	v.links.Refresh()
+	v.finder.setLinks(on, v.entry.Text)
+	v.updateView()
+}
+
+func (v *ResponseBodyView) setLineNumbers(on bool) {
+	v.finder.setLineNumbers(on, v.entry.Text)
+	v.updateView()
+}
+
+// updateView shows the body in the row view while it is needed for the find
+// bar, line numbers or links, and in the entry otherwise.
+func (v *ResponseBodyView) updateView() {
+	if v.finder.isShown() {
+		v.scroll.Hide()
+	} else {
+		v.scroll.Show()
+	}
+}
+
+// ShowFind opens the find bar over the body shown, or moves the cursor to
+// it if it is open. A body shown as a table or records is shown as
+// received first.
+func (v *ResponseBodyView) ShowFind() {
+	v.showRaw()
+	v.scroll.Hide()
+	v.finder.open(v.entry.Text)
+}
+
+// ShowGoToLine asks for a line number and scrolls to that line, turning
+// the line numbers on. A body shown as a table or records is shown as
+// received first.
+func (v *ResponseBodyView) ShowGoToLine() {
+	v.showRaw()
+	lines := strings.Count(v.entry.Text, "\n") + 1
+	lineEntry := widget.NewEntry()
+	lineEntry.SetPlaceHolder(fmt.Sprintf("1–%d", lines))
+	lineEntry.Validator = func(text string) error {
+		line, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || line < 1 || line > lines {
+			return fmt.Errorf("enter a line from 1 to %d", lines)
+		}
+		return nil
+	}
+
+	form := dialog.NewForm("Go to Line", "Go", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Line", lineEntry),
+	}, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		line, _ := strconv.Atoi(strings.TrimSpace(lineEntry.Text))
+		if !v.lineNumbers.Checked {
+			v.lineNumbers.SetChecked(true)
+		}
+		v.finder.showLine(line)
+	}, v.parentWindow)
+	form.Show()
+	v.parentWindow.Canvas().Focus(lineEntry)
+}
+
+// showRaw switches a body shown as a table or records to the raw view.
+func (v *ResponseBodyView) showRaw() {
+	if !v.text.override.Visible() {
+		v.viewRadio.SetSelected(BodyViewRaw)
+	}
+}
+
+// showInstead shows view, the table or the records, instead of the text,
+// or the text again when view is nil.
+func (v *ResponseBodyView) showInstead(view fyne.CanvasObject) {
+	if view == nil {
+		view = v.text.override
+	}
+	for _, other := range []fyne.CanvasObject{v.text.override, v.table.container, v.records.container} {
+		if other == view {
+			other.Show()
+		} else {
+			other.Hide()
+		}
+	}
+}
+
+// setEntryText shows text, also in the row view if it is shown.
+func (v *ResponseBodyView) setEntryText(text string) {
+	v.entry.SetText(text)
+	if v.finder.isShown() {
+		v.finder.setText(text)
+	}
+}
+
+// SetText shows a message or a body that is not to be formatted, such as
+// the start of an event stream.
+func (v *ResponseBodyView) SetText(text string) {
+	v.generation++
+	v.body = text
+	v.kind = bodyKindPlain
+	v.partialBar.Hide()
+	v.protobufBar.Hide()
+	v.formatControls.Hide()
+	v.filterRow.Hide()
+	v.errorLabel.Hide()
+	v.filterError.Hide()
+	v.showInstead(nil)
+	v.setEntryText(text)
+}
+
+// Append adds text to what is shown, e.g. the next event of a stream.
+func (v *ResponseBodyView) Append(text string) {
+	v.body += text
+	v.entry.Append(text)
+	if v.finder.isShown() {
+		v.finder.setText(v.entry.Text)
+	}
+}
+
+// SetBody shows a response body with the Content-Type it came with.
+func (v *ResponseBodyView) SetBody(body, contentType string) {
+	v.body = body
+	v.contentType = contentType
+	v.partialBar.Hide()
+	v.protobufBar.Hide()
+	switch {
+	case IsCSVContentType(contentType):
+		v.setKind(bodyKindCSV)
+	case IsNDJSONContentType(contentType) || looksLikeNDJSON(body):
+		v.setKind(bodyKindNDJSON)
+	case IsJSONContentType(contentType) || looksLikeJSON(body):
+		v.setKind(bodyKindJSON)
+	case IsXMLContentType(contentType) || looksLikeXML(body):
+		v.setKind(bodyKindXML)
+	default:
+		v.setKind(bodyKindPlain)
+	}
+	v.formatControls.Show()
+	if v.kind == bodyKindJSON {
+		v.filterRow.Show()
+	} else {
+		v.filterRow.Hide()
+	}
+	v.render()
+}
+
+// setKind offers the views of a kind of body, with the one last chosen for
+// it selected.
+func (v *ResponseBodyView) setKind(kind string) {
+	v.kind = kind
+	options := []string{BodyViewPretty, BodyViewRaw, BodyViewMinified}
+	label := kind
+	switch kind {
+	case bodyKindCSV:
+		options = []string{BodyViewTable, BodyViewRaw}
+	case bodyKindNDJSON:
+		options = []string{BodyViewRecords, BodyViewRaw}
+	case bodyKindPlain:
+		options = []string{BodyViewRaw, BodyViewTable}
+		label = "Text"
+	}
+	view, ok := v.views[viewKind(kind)]
+	if !ok {
+		view = options[0]
+	}
+	v.kindLabel.SetText(label + ":")
+	v.viewRadio.Options = options
+	v.viewRadio.Selected = view
+	v.viewRadio.Refresh()
+}
+
+// viewKind returns the kind of body whose chosen view applies to kind: XML
+// is shown as JSON is.
+func viewKind(kind string) string {
+	if kind == bodyKindXML {
+		return bodyKindJSON
+	}
+	return kind
+}
+
+// SetProtobuf shows a protobuf body decoded as JSON as the message type
+// picked from those of registry, or as its wire format. The type its
+// Content-Type names is picked first, then the one picked last.
+func (v *ResponseBodyView) SetProtobuf(body, contentType string, registry *protobuf.Registry) {
+	v.protobufBody, v.protobufRegistry = body, registry
+	messageTypes := registry.MessageTypes()
+	selected := protobufWireFormat
+	for _, messageType := range []string{v.protobufType, protobuf.MessageTypeHint(contentType)} {
+		if slices.Contains(messageTypes, messageType) {
+			selected = messageType
+		}
+	}
+	v.protobufSelect.Options = append([]string{protobufWireFormat}, messageTypes...)
+	v.protobufSelect.Selected = selected
+	v.protobufSelect.Refresh()
+	if len(messageTypes) == 0 {
+		v.protobufHint.Show()
+	} else {
+		v.protobufHint.Hide()
+	}
+	v.decodeProtobuf(selected)
+}
+
+// decodeProtobuf shows the protobuf body decoded as messageType, or as its
+// wire format when it is not one or none is picked.
+func (v *ResponseBodyView) decodeProtobuf(messageType string) {
+	var problem string
+	if messageType != protobufWireFormat {
+		decoded, err := v.protobufRegistry.Decode([]byte(v.protobufBody), messageType)
+		if err == nil {
+			v.SetBody(decoded, "application/json")
+			v.protobufBar.Show()
+			if v.OnProtobufDecoded != nil {
+				v.OnProtobufDecoded(decoded)
+			}
+			return
+		}
+		problem = fmt.Sprintf("Not a %s, shown as wire format: %v", messageType, err)
+	}
+
+	dump, err := protobuf.Dump([]byte(v.protobufBody))
+	v.SetText(dump)
+	v.protobufBar.Show()
+	if err != nil && problem == "" {
+		problem = fmt.Sprintf("Not well-formed protobuf, shown up to the error: %v", err)
+	}
+	if problem != "" {
+		v.errorLabel.SetText(problem)
+		v.errorLabel.Show()
+	}
+	if v.OnProtobufDecoded != nil {
+		v.OnProtobufDecoded("")
+	}
+}
+
+// SetPartialBody shows body, decoded from the first loaded bytes of a body
+// of total bytes with the Content-Type it came with. It is shown as
+// received, since the start of a JSON or XML document cannot be formatted,
+// until Load More has loaded the rest.
+func (v *ResponseBodyView) SetPartialBody(body, contentType string, loaded, total int) {
+	v.SetText(body)
+	v.contentType = contentType
+	v.loaded = loaded
+	v.total = total
+	v.showPartial()
+}
+
+func (v *ResponseBodyView) showPartial() {
+	v.partialLabel.SetText(fmt.Sprintf("Showing the first %s of %s", FormatBytes(int64(v.loaded)), FormatBytes(int64(v.total))))
+	v.partialBar.Show()
+}
+
+// loadMore adds the next part of a body shown in part, and formats the
+// body once all of it is loaded.
+func (v *ResponseBodyView) loadMore() {
+	if v.OnLoadMore == nil {
+		return
+	}
+	more, loaded, err := v.OnLoadMore()
+	if err != nil {
+		dialog.ShowError(err, v.parentWindow)
+		return
+	}
+	v.loaded = loaded
+	if loaded >= v.total {
+		v.SetBody(v.body+more, v.contentType)
+		return
+	}
+	v.Append(more)
+	v.showPartial()
+}
+
+// GetFilter returns the expression the body is filtered by.
+func (v *ResponseBodyView) GetFilter() string {
+	return strings.TrimSpace(v.filterEntry.Text)
+}
+
+// SetFilter sets the expression the body is filtered by, e.g. the one saved
+// with a request.
+func (v *ResponseBodyView) SetFilter(expression string) {
+	v.filterEntry.SetText(expression)
+}
+
+// saveShown saves what the body view shows, e.g. the filtered values.
+func (v *ResponseBodyView) saveShown() {
+	text := v.entry.Text
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, v.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write([]byte(text)); err != nil {
+			dialog.ShowError(err, v.parentWindow)
+		}
+	}, v.parentWindow)
+	save.SetFileName("response.json")
+	save.Show()
+}
+
+// render shows the body in the selected view, formatting a large one in
+// the background. A newer body or view supersedes a formatting still
+// running. A body that cannot be formatted is shown as received, with the
+// reason above it. A JSON body with a filter shows the values it selects
+// instead, or the body with the reason the filter failed.
+func (v *ResponseBodyView) render() {
+	v.generation++
+	v.errorLabel.Hide()
+	v.filterError.Hide()
+	switch v.viewRadio.Selected {
+	case BodyViewTable:
+		v.renderTable()
+		return
+	case BodyViewRecords:
+		v.renderRecords()
+		return
+	}
+	v.showInstead(nil)
+	filter := ""
+	if v.kind == bodyKindJSON && v.OnFilter != nil {
+		filter = v.GetFilter()
+	}
+	if filter == "" && (v.kind == bodyKindPlain || v.viewRadio.Selected == BodyViewRaw) {
+		v.setEntryText(v.body)
+		return
+	}
+
+	show := func(formatted string, err, filterErr error) {
+		if err != nil {
+			v.errorLabel.SetText(fmt.Sprintf("Not well-formed %s, shown as received: %v", v.kind, err))
+			v.errorLabel.Show()
+		}
+		if filterErr != nil {
+			v.filterError.SetText(fmt.Sprintf("Filter: %v", filterErr))
+			v.filterError.Show()
+		}
+		v.setEntryText(formatted)
+	}
+	if filter == "" && len(v.body) < backgroundFormatSize {
+		formatted, err := formatBody(v.body, v.kind, v.viewRadio.Selected)
+		show(formatted, err, nil)
+		return
+	}
+
+	generation, body, kind, view := v.generation, v.body, v.kind, v.viewRadio.Selected
+	apply := v.OnFilter
+	v.setEntryText("Formatting...")
+	go func() {
+		var formatted string
+		var err, filterErr error
+		if filter != "" {
+			var filtered string
+			if filtered, filterErr = apply(body, filter); filterErr == nil {
+				formatted = FormatJSON(filtered, view)
+			}
+		}
+		if filter == "" || filterErr != nil {
+			formatted, err = formatBody(body, kind, view)
+		}
+		fyne.Do(func() {
+			if generation == v.generation {
+				show(formatted, err, filterErr)
+			}
+		})
+	}()
+}
+
+// renderTable shows the body as a table, parsing a large one in the
+// background. The text is kept as received, for Copy and Find.
+func (v *ResponseBodyView) renderTable() {
+	v.setEntryText(v.body)
+	v.showInstead(v.table.container)
+	if len(v.body) < backgroundFormatSize {
+		v.table.set(parseCSV(v.body, v.contentType))
+		return
+	}
+
+	generation, body, contentType := v.generation, v.body, v.contentType
+	v.table.loading()
+	go func() {
+		data := parseCSV(body, contentType)
+		fyne.Do(func() {
+			if generation == v.generation {
+				v.table.set(data)
+			}
+		})
+	}()
+}
+
+// renderRecords shows the records of an NDJSON body, parsing a large one in
+// the background. The text is kept as received, for Copy and Find.
+func (v *ResponseBodyView) renderRecords() {
+	v.setEntryText(v.body)
+	v.showInstead(v.records.container)
+	if len(v.body) < backgroundFormatSize {
+		v.records.set(parseNDJSON(v.body))
+		return
+	}
+
+	generation, body := v.generation, v.body
+	v.records.loading()
+	go func() {
+		records := parseNDJSON(body)
+		fyne.Do(func() {
+			if generation == v.generation {
+				v.records.set(records)
+			}
+		})
+	}()
+}
+
+// IsJSON reports whether the body shown is JSON.
+func (v *ResponseBodyView) IsJSON() bool {
+	return v.kind == bodyKindJSON
+}
+
+func (v *ResponseBodyView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+func formatBody(body, kind, view string) (string, error) {
+	if kind == bodyKindXML {
+		return FormatXML(body, view)
+	}
+	return FormatJSON(body, view), nil
+}
+
+// IsJSONContentType reports whether contentType is JSON, including types
+// such as application/problem+json.
+func IsJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// looksLikeJSON reports whether body is a JSON object or array sent without
+// a JSON Content-Type.
+func looksLikeJSON(body string) bool {
+	trimmed := strings.TrimSpace(body)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return false
+	}
+	return json.Valid([]byte(trimmed))
+}
+
+// FormatJSON returns body pretty-printed or minified according to view.
+// Numbers are copied as written, so no precision is lost. A body that is
+// not valid JSON is returned unchanged.
+func FormatJSON(body, view string) string {
+	var out bytes.Buffer
+	var err error
+	switch view {
+	case BodyViewPretty:
+		err = json.Indent(&out, []byte(body), "", "  ")
+	case BodyViewMinified:
+		err = json.Compact(&out, []byte(body))
+	default:
+		return body
+	}
+	if err != nil {
+		return body
+	}
+	return out.String()
+}
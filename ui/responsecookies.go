@@ -0,0 +1,356 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// CookieHeader serializes cookie rows into the value of a single Cookie
+// header. Rows with an empty name or that are disabled are skipped.
+func CookieHeader(pairs []KeyValue) string {
+	var parts []string
+	for _, pair := range EnabledPairs(pairs) {
+		if pair.Key == "" {
+			continue
+		}
+		parts = append(parts, pair.Key+"="+pair.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseCookieHeader splits a Cookie header value back into rows.
+func ParseCookieHeader(value string) []KeyValue {
+	var pairs []KeyValue
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, _ := strings.Cut(part, "=")
+		pairs = append(pairs, KeyValue{Key: name, Value: val})
+	}
+	return pairs
+}
+
+// responseCookie is a Set-Cookie header of a response, parsed, with what a
+// browser would make of it.
+type responseCookie struct {
+	raw    string
+	cookie *http.Cookie
+	// issues are why a browser would reject the cookie, or notes on how it
+	// would treat it
+	issues   []string
+	rejected bool
+}
+
+// The columns of the cookies table.
+var responseCookieColumns = []struct {
+	title string
+	width float32
+}{
+	{"Name", 140},
+	{"Value", 180},
+	{"Domain", 140},
+	{"Path", 80},
+	{"Expires", 150},
+	{"Max-Age", 80},
+	{"Secure", 70},
+	{"HttpOnly", 80},
+	{"SameSite", 90},
+	{"Issues", 320},
+}
+
+// ResponseCookiesView lists the cookies set by a response in a table of
+// their attributes, flagging those a browser would reject. The selected
+// cookie can be added to the cookie jar or to the Cookies tab of the
+// request.
+type ResponseCookiesView struct {
+	// OnAddToJar stores a cookie as if requestURL had set it
+	OnAddToJar func(cookie *http.Cookie, requestURL string) error
+	// OnAddToRequest adds a cookie to the Cookies tab of the request
+	OnAddToRequest func(name, value string)
+
+	container   *fyne.Container
+	table       *widget.Table
+	statusLabel *widget.Label
+	jarButton   *widget.Button
+	reqButton   *widget.Button
+	cookies     []*responseCookie
+	requestURL  string
+	selected    int
+}
+
+func NewResponseCookiesView() *ResponseCookiesView {
+	v := &ResponseCookiesView{selected: -1}
+
+	v.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(v.cookies), len(responseCookieColumns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Cookie")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		v.updateCell,
+	)
+	v.table.ShowHeaderColumn = false
+	v.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("Column", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	v.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(responseCookieColumns) {
+			o.(*widget.Label).SetText(responseCookieColumns[id.Col].title)
+		}
+	}
+	for i, column := range responseCookieColumns {
+		v.table.SetColumnWidth(i, column.width)
+	}
+	v.table.OnSelected = func(id widget.TableCellID) {
+		v.selected = id.Row
+		v.updateButtons()
+	}
+	v.table.OnUnselected = func(widget.TableCellID) {
+		v.selected = -1
+		v.updateButtons()
+	}
+
+	v.statusLabel = widget.NewLabel("")
+	v.statusLabel.Truncation = fyne.TextTruncateEllipsis
+	v.jarButton = widget.NewButtonWithIcon("Add to Cookie Jar", theme.ContentAddIcon(), v.addToJar)
+	v.reqButton = widget.NewButtonWithIcon("Add to Request Cookies", theme.ContentAddIcon(), func() {
+		if cookie := v.selectedCookie(); cookie != nil && v.OnAddToRequest != nil {
+			v.OnAddToRequest(cookie.Name, cookie.Value)
+			v.statusLabel.SetText(fmt.Sprintf("Added %s to the Cookies tab", cookie.Name))
+		}
+	})
+
+	v.container = container.NewBorder(
+		nil,
+		container.NewBorder(nil, nil, nil, container.NewHBox(v.jarButton, v.reqButton), v.statusLabel),
+		nil, nil,
+		v.table,
+	)
+	v.SetCookies(nil, "")
+	return v
+}
+
+// SetCookies shows the values of the Set-Cookie headers of a response to
+// requestURL.
+func (v *ResponseCookiesView) SetCookies(setCookies []string, requestURL string) {
+	v.cookies = make([]*responseCookie, len(setCookies))
+	for i, raw := range setCookies {
+		v.cookies[i] = parseResponseCookie(raw, requestURL)
+	}
+	v.requestURL = requestURL
+	v.selected = -1
+	v.table.UnselectAll()
+	v.table.Refresh()
+
+	rejected := 0
+	for _, cookie := range v.cookies {
+		if cookie.rejected {
+			rejected++
+		}
+	}
+	switch {
+	case len(v.cookies) == 0:
+		v.statusLabel.SetText("No cookies in the response")
+	case rejected > 0:
+		v.statusLabel.SetText(fmt.Sprintf("%d of %d cookie(s) would be rejected by a browser", rejected, len(v.cookies)))
+	default:
+		v.statusLabel.SetText("Select a cookie to add it to the cookie jar or to the request")
+	}
+	v.updateButtons()
+}
+
+// Count returns the number of Set-Cookie headers shown.
+func (v *ResponseCookiesView) Count() int {
+	return len(v.cookies)
+}
+
+func (v *ResponseCookiesView) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	label := o.(*widget.Label)
+	if id.Row >= len(v.cookies) {
+		return
+	}
+	entry := v.cookies[id.Row]
+	label.Importance = widget.MediumImportance
+	if entry.rejected && (id.Col == 0 || id.Col == len(responseCookieColumns)-1) {
+		label.Importance = widget.DangerImportance
+	}
+
+	cookie := entry.cookie
+	if cookie == nil {
+		text := ""
+		switch id.Col {
+		case 0:
+			text = entry.raw
+		case len(responseCookieColumns) - 1:
+			text = strings.Join(entry.issues, "; ")
+		}
+		label.SetText(text)
+		return
+	}
+
+	var text string
+	switch id.Col {
+	case 0:
+		text = cookie.Name
+	case 1:
+		text = cookie.Value
+	case 2:
+		text = cookie.Domain
+	case 3:
+		text = cookie.Path
+	case 4:
+		if !cookie.Expires.IsZero() {
+			text = cookie.Expires.Local().Format("Jan 2 2006 15:04")
+		} else if cookie.RawExpires != "" {
+			text = cookie.RawExpires
+		} else {
+			text = "Session"
+		}
+	case 5:
+		if cookie.MaxAge > 0 {
+			text = fmt.Sprintf("%d", cookie.MaxAge)
+		} else if cookie.MaxAge < 0 {
+			text = "0"
+		}
+	case 6:
+		text = yesNo(cookie.Secure)
+	case 7:
+		text = yesNo(cookie.HttpOnly)
+	case 8:
+		text = describeSameSite(cookie.SameSite)
+	case 9:
+		text = strings.Join(entry.issues, "; ")
+	}
+	label.SetText(text)
+}
+
+func (v *ResponseCookiesView) selectedCookie() *http.Cookie {
+	if v.selected < 0 || v.selected >= len(v.cookies) {
+		return nil
+	}
+	return v.cookies[v.selected].cookie
+}
+
+func (v *ResponseCookiesView) updateButtons() {
+	if cookie := v.selectedCookie(); cookie != nil {
+		v.jarButton.Enable()
+		v.reqButton.Enable()
+	} else {
+		v.jarButton.Disable()
+		v.reqButton.Disable()
+	}
+}
+
+func (v *ResponseCookiesView) addToJar() {
+	cookie := v.selectedCookie()
+	if cookie == nil || v.OnAddToJar == nil {
+		return
+	}
+	if err := v.OnAddToJar(cookie, v.requestURL); err != nil {
+		v.statusLabel.SetText(fmt.Sprintf("%s not added: %v", cookie.Name, err))
+		return
+	}
+	v.statusLabel.SetText(fmt.Sprintf("Added %s to the cookie jar", cookie.Name))
+}
+
+func (v *ResponseCookiesView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+// parseResponseCookie parses a Set-Cookie value received from requestURL
+// and checks it the way a browser would.
+func parseResponseCookie(raw, requestURL string) *responseCookie {
+	entry := &responseCookie{raw: raw}
+	cookie, err := http.ParseSetCookie(raw)
+	if err != nil {
+		entry.issues = []string{fmt.Sprintf("Rejected: %v", err)}
+		entry.rejected = true
+		return entry
+	}
+	entry.cookie = cookie
+
+	reject := func(issue string) {
+		entry.issues = append(entry.issues, "Rejected: "+issue)
+		entry.rejected = true
+	}
+	target, _ := url.Parse(requestURL)
+	secureOrigin := target != nil && (target.Scheme == "https" || isLoopbackHost(target.Hostname()))
+
+	if cookie.Secure && target != nil && !secureOrigin {
+		reject("Secure cookie set over http")
+	}
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		reject("SameSite=None without Secure")
+	}
+	if cookie.Domain != "" && target != nil {
+		host := strings.ToLower(target.Hostname())
+		domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), ".")
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			reject(fmt.Sprintf("Domain %s does not match %s", cookie.Domain, host))
+		}
+	}
+	switch {
+	case strings.HasPrefix(cookie.Name, "__Host-"):
+		if !cookie.Secure || cookie.Domain != "" || cookie.Path != "/" {
+			reject("__Host- cookies need Secure, Path=/ and no Domain")
+		}
+	case strings.HasPrefix(cookie.Name, "__Secure-"):
+		if !cookie.Secure {
+			reject("__Secure- cookies need Secure")
+		}
+	}
+
+	if cookie.Expires.IsZero() && cookie.RawExpires != "" {
+		entry.issues = append(entry.issues, "Expires is not a date, so it is a session cookie")
+	}
+	if cookie.MaxAge < 0 || !cookie.Expires.IsZero() && cookie.MaxAge == 0 && cookie.Expires.Before(time.Now()) {
+		entry.issues = append(entry.issues, "Already expired: deletes the cookie")
+	}
+	if cookie.SameSite == http.SameSiteDefaultMode {
+		entry.issues = append(entry.issues, "Unknown SameSite value, treated as Lax")
+	}
+	return entry
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func describeSameSite(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	case http.SameSiteDefaultMode:
+		return "Invalid"
+	}
+	return ""
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+	return ""
+}
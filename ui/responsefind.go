@@ -0,0 +1,479 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// findDelay is how long typing pauses before the search runs
+	findDelay = 200 * time.Millisecond
+	// maxFindMatches bounds the matches found, so a one-letter search of a
+	// large body stays quick
+	maxFindMatches = 10000
+	// findRowWidth is the most characters a row of the search view shows; a
+	// longer line, such as minified JSON, is continued on the next rows
+	findRowWidth = 160
+)
+
+// findRowReplacer makes tabs and carriage returns visible as a row.
+var findRowReplacer = strings.NewReplacer("\t", "    ", "\r", "")
+
+// findRow is a row of the search view, as byte offsets into the text, with
+// the number of the line it is part of. A line longer than findRowWidth
+// continues on the next rows.
+type findRow struct {
+	start, end int
+	line       int
+	continued  bool
+}
+
+// findResult is a search of text: its rows and the matches, as byte
+// offsets, in order, and the URLs in it when links are shown.
+type findResult struct {
+	text    string
+	rows    []findRow
+	matches [][]int
+	links   [][]int
+	limited bool
+	err     error
+}
+
+// lineDigits returns how many digits the largest line number has.
+func (r findResult) lineDigits() int {
+	if len(r.rows) == 0 {
+		return 1
+	}
+	return len(strconv.Itoa(r.rows[len(r.rows)-1].line))
+}
+
+// findEntry is the search field: Return goes to the next match and Escape
+// closes the bar.
+type findEntry struct {
+	widget.Entry
+	onReturn func()
+	onEscape func()
+}
+
+func newFindEntry() *findEntry {
+	e := &findEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *findEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		e.onReturn()
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// responseFinder is the find bar of the response body with the view that
+// shows the matches. The body is shown in rows that are only drawn when
+// scrolled into view, all matches highlighted, so even a body of several
+// megabytes scrolls smoothly. Searches run in the background once typing
+// pauses. The same view shows the body with line numbers, which are drawn
+// with each row, so they scroll with it, and with its URLs as links. The
+// URLs are found with the rows, in the background.
+type responseFinder struct {
+	// onClose is called when the bar is closed
+	onClose func()
+	// onOpenURL loads a link that was clicked into the URL bar
+	onOpenURL func(url string)
+
+	bar        *fyne.Container
+	view       *widget.List
+	entry      *findEntry
+	caseCheck  *widget.Check
+	regexCheck *widget.Check
+	countLabel *widget.Label
+	text       string
+	result     findResult
+	current    int
+	generation int
+	timer      *time.Timer
+
+	lineNumbers bool
+	links       bool
+	// goToLine is the line to go to once the rows of the text are ready
+	goToLine int
+}
+
+func newResponseFinder() *responseFinder {
+	f := &responseFinder{}
+
+	f.entry = newFindEntry()
+	f.entry.SetPlaceHolder("Find in response")
+	f.entry.OnChanged = func(string) {
+		f.schedule()
+	}
+	f.entry.onReturn = func() {
+		f.move(1)
+	}
+	f.entry.onEscape = func() {
+		f.close()
+	}
+
+	f.caseCheck = widget.NewCheck("Match case", func(bool) {
+		f.schedule()
+	})
+	f.regexCheck = widget.NewCheck("Regex", func(bool) {
+		f.schedule()
+	})
+	f.countLabel = widget.NewLabel("")
+
+	previousButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		f.move(-1)
+	})
+	nextButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		f.move(1)
+	})
+	closeButton := widget.NewButtonWithIcon("", theme.CancelIcon(), f.close)
+
+	f.bar = container.NewBorder(nil, nil, nil,
+		container.NewHBox(f.countLabel, previousButton, nextButton, f.caseCheck, f.regexCheck, closeButton),
+		f.entry,
+	)
+	f.bar.Hide()
+
+	f.view = widget.NewList(
+		func() int {
+			return len(f.result.rows)
+		},
+		func() fyne.CanvasObject {
+			row := widget.NewRichText()
+			row.Truncation = fyne.TextTruncateClip
+			return row
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*widget.RichText)
+			row.Segments = f.rowSegments(id)
+			row.Refresh()
+		},
+	)
+	f.view.Hide()
+	return f
+}
+
+// isShown reports whether the view is shown, for the find bar, the line
+// numbers or the links.
+func (f *responseFinder) isShown() bool {
+	return f.isOpen() || f.lineNumbers || f.links
+}
+
+// setLineNumbers shows or hides line numbers, showing text in the view
+// while they are on.
+func (f *responseFinder) setLineNumbers(on bool, text string) {
+	f.lineNumbers = on
+	f.update(text)
+}
+
+// setLinks makes the URLs in text links, or plain text again.
+func (f *responseFinder) setLinks(on bool, text string) {
+	f.links = on
+	f.update(text)
+}
+
+// update shows text in the view while it is needed, and hides the view
+// otherwise.
+func (f *responseFinder) update(text string) {
+	if f.isShown() {
+		f.view.Show()
+		f.setText(text)
+		return
+	}
+	f.hideView()
+}
+
+// showLine scrolls to line and selects it, once the rows of the text are
+// ready.
+func (f *responseFinder) showLine(line int) {
+	f.goToLine = line
+	if f.result.text == f.text && f.result.rows != nil {
+		f.scrollToLine()
+	}
+}
+
+func (f *responseFinder) scrollToLine() {
+	line := f.goToLine
+	f.goToLine = 0
+	row := sort.Search(len(f.result.rows), func(i int) bool {
+		return f.result.rows[i].line >= line
+	})
+	if row < len(f.result.rows) {
+		f.view.ScrollTo(row)
+		f.view.Select(row)
+	}
+}
+
+func (f *responseFinder) hideView() {
+	f.view.Hide()
+	f.result = findResult{}
+	f.text = ""
+}
+
+// open shows the bar for text and puts the cursor in the search field.
+func (f *responseFinder) open(text string) {
+	f.bar.Show()
+	f.view.Show()
+	f.setText(text)
+	if canvas := fyne.CurrentApp().Driver().CanvasForObject(f.entry); canvas != nil {
+		canvas.Focus(f.entry)
+	}
+}
+
+func (f *responseFinder) isOpen() bool {
+	return f.bar.Visible()
+}
+
+// setText searches text, e.g. when a new response arrives with the bar open.
+func (f *responseFinder) setText(text string) {
+	f.text = text
+	f.schedule()
+}
+
+func (f *responseFinder) close() {
+	f.generation++
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	f.bar.Hide()
+	if f.isShown() {
+		// The matches are no longer highlighted
+		f.schedule()
+	} else {
+		f.hideView()
+	}
+	if canvas := fyne.CurrentApp().Driver().CanvasForObject(f.entry); canvas != nil {
+		canvas.Unfocus()
+	}
+	if f.onClose != nil {
+		f.onClose()
+	}
+}
+
+// schedule runs the search once typing pauses for findDelay, off the main
+// thread. A later search supersedes one still running.
+func (f *responseFinder) schedule() {
+	f.generation++
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if !f.isShown() {
+		return
+	}
+
+	generation, text := f.generation, f.text
+	query, matchCase, regex, links := f.entry.Text, f.caseCheck.Checked, f.regexCheck.Checked, f.links
+	if !f.isOpen() {
+		query = ""
+	}
+	f.timer = time.AfterFunc(findDelay, func() {
+		result := findMatches(text, query, matchCase, regex)
+		if links {
+			result.links = findLinks(text)
+		}
+		fyne.Do(func() {
+			if generation != f.generation {
+				return
+			}
+			f.result = result
+			f.current = 0
+			f.view.UnselectAll()
+			f.view.Refresh()
+			if f.goToLine > 0 {
+				f.scrollToLine()
+			} else if f.isOpen() {
+				f.showCurrent()
+			}
+		})
+	})
+}
+
+// move goes to the next match, or the previous one for -1, wrapping around.
+func (f *responseFinder) move(step int) {
+	if len(f.result.matches) == 0 {
+		return
+	}
+	f.current = (f.current + step + len(f.result.matches)) % len(f.result.matches)
+	f.view.Refresh()
+	f.showCurrent()
+}
+
+// showCurrent scrolls to the current match and updates the count.
+func (f *responseFinder) showCurrent() {
+	f.countLabel.Importance = widget.MediumImportance
+	switch {
+	case f.result.err != nil:
+		f.countLabel.Importance = widget.DangerImportance
+		f.countLabel.SetText("Invalid pattern")
+		return
+	case f.entry.Text == "":
+		f.countLabel.SetText("")
+		return
+	case len(f.result.matches) == 0:
+		f.countLabel.SetText("No matches")
+		return
+	}
+
+	total := fmt.Sprintf("%d", len(f.result.matches))
+	if f.result.limited {
+		total += "+"
+	}
+	f.countLabel.SetText(fmt.Sprintf("%d of %s", f.current+1, total))
+
+	start := f.result.matches[f.current][0]
+	row := sort.Search(len(f.result.rows), func(i int) bool {
+		return f.result.rows[i].end > start
+	})
+	if row < len(f.result.rows) {
+		f.view.ScrollTo(row)
+	}
+}
+
+// rowSegments returns the text of a row with the matches in it highlighted by
+// colour, the current one in its own, and the URLs outside them as links.
+func (f *responseFinder) rowSegments(id widget.ListItemID) []widget.RichTextSegment {
+	if id >= len(f.result.rows) {
+		return nil
+	}
+	row := f.result.rows[id]
+	monospace := fyne.TextStyle{Monospace: true}
+	plain := widget.RichTextStyle{Inline: true, TextStyle: monospace}
+	match := widget.RichTextStyle{Inline: true, ColorName: theme.ColorNamePrimary, TextStyle: monospace}
+	current := widget.RichTextStyle{Inline: true, ColorName: theme.ColorNameWarning, TextStyle: monospace}
+
+	var segments []widget.RichTextSegment
+	if f.lineNumbers {
+		number := ""
+		if !row.continued {
+			number = strconv.Itoa(row.line)
+		}
+		gutter := widget.RichTextStyle{Inline: true, ColorName: theme.ColorNamePlaceHolder, TextStyle: monospace}
+		segments = append(segments, &widget.TextSegment{
+			Style: gutter,
+			Text:  fmt.Sprintf("%*s │ ", f.result.lineDigits(), number),
+		})
+	}
+	add := func(start, end int, style widget.RichTextStyle) {
+		if start < end {
+			text := findRowReplacer.Replace(f.result.text[start:end])
+			segments = append(segments, &widget.TextSegment{Style: style, Text: text})
+		}
+	}
+
+	// A URL cut across rows is a link in each of them
+	links := f.result.links
+	addText := func(start, end int) {
+		j := sort.Search(len(links), func(j int) bool {
+			return links[j][1] > start
+		})
+		for ; j < len(links) && links[j][0] < end; j++ {
+			linkStart, linkEnd := max(links[j][0], start), min(links[j][1], end)
+			add(start, linkStart, plain)
+			target := f.result.text[links[j][0]:links[j][1]]
+			u, _ := url.Parse(target)
+			segments = append(segments, &widget.HyperlinkSegment{
+				Text: f.result.text[linkStart:linkEnd],
+				URL:  u,
+				OnTapped: func() {
+					openLink(target, f.onOpenURL)
+				},
+			})
+			start = linkEnd
+		}
+		add(start, end, plain)
+	}
+
+	matches := f.result.matches
+	i := sort.Search(len(matches), func(i int) bool {
+		return matches[i][1] > row.start
+	})
+	offset := row.start
+	for ; i < len(matches) && matches[i][0] < row.end; i++ {
+		start, end := max(matches[i][0], row.start), min(matches[i][1], row.end)
+		addText(offset, start)
+		if i == f.current {
+			add(start, end, current)
+		} else {
+			add(start, end, match)
+		}
+		offset = end
+	}
+	addText(offset, row.end)
+	if len(segments) == 0 {
+		segments = append(segments, &widget.TextSegment{Style: plain, Text: " "})
+	}
+	return segments
+}
+
+// findMatches splits text into rows and finds query in it, literally or as
+// a regular expression. Empty matches are skipped.
+func findMatches(text, query string, matchCase, regex bool) findResult {
+	result := findResult{text: text, rows: findRows(text)}
+	if query == "" {
+		return result
+	}
+
+	pattern := query
+	if !regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if !matchCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	for _, match := range re.FindAllStringIndex(text, maxFindMatches+1) {
+		if match[0] != match[1] {
+			result.matches = append(result.matches, match)
+		}
+	}
+	if len(result.matches) > maxFindMatches {
+		result.matches = result.matches[:maxFindMatches]
+		result.limited = true
+	}
+	return result
+}
+
+// findRows splits text at line ends and every findRowWidth characters.
+func findRows(text string) []findRow {
+	var rows []findRow
+	start, width, line, continued := 0, 0, 1, false
+	for i := 0; i < len(text); {
+		if text[i] == '\n' {
+			rows = append(rows, findRow{start: start, end: i, line: line, continued: continued})
+			i++
+			start, width, continued = i, 0, false
+			line++
+			continue
+		}
+		if width == findRowWidth {
+			rows = append(rows, findRow{start: start, end: i, line: line, continued: continued})
+			start, width, continued = i, 0, true
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		width++
+	}
+	return append(rows, findRow{start: start, end: len(text), line: line, continued: continued})
+}
@@ -0,0 +1,287 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The columns of the headers table.
+const (
+	headerColumnName = iota
+	headerColumnValue
+	headerColumnCopy
+)
+
+// ResponseHeadersView lists the headers of a response sorted by name, one
+// row per value, with a filter and a copy button per row. Clicking the value
+// of a Location or Link header offers its URL to OnOpenURL, or opens it in
+// the browser with Ctrl or Cmd held. It takes the headers as key/value
+// pairs, so the headers stored in the history, which unmarshal into
+// []KeyValue, can be shown with it too.
+type ResponseHeadersView struct {
+	// OnOpenURL loads a URL into the URL bar
+	OnOpenURL func(url string)
+
+	container    *fyne.Container
+	table        *widget.Table
+	filterEntry  *widget.Entry
+	countLabel   *widget.Label
+	headers      []KeyValue
+	rows         []KeyValue
+	baseURL      string
+	parentWindow fyne.Window
+}
+
+func NewResponseHeadersView(parentWindow fyne.Window) *ResponseHeadersView {
+	v := &ResponseHeadersView{parentWindow: parentWindow}
+
+	v.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(v.rows), 3
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Header")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewStack(label, widget.NewButtonWithIcon("", theme.ContentCopyIcon(), nil))
+		},
+		v.updateCell,
+	)
+	v.table.ShowHeaderColumn = false
+	v.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("Header", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	v.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		titles := []string{"Name", "Value", ""}
+		if id.Col >= 0 && id.Col < len(titles) {
+			o.(*widget.Label).SetText(titles[id.Col])
+		}
+	}
+	v.table.SetColumnWidth(headerColumnName, 220)
+	v.table.SetColumnWidth(headerColumnValue, 480)
+	v.table.SetColumnWidth(headerColumnCopy, 48)
+	v.table.OnSelected = v.selectCell
+
+	v.filterEntry = widget.NewEntry()
+	v.filterEntry.SetPlaceHolder("Filter by name or value")
+	v.filterEntry.OnChanged = func(string) {
+		v.applyFilter()
+	}
+	v.countLabel = widget.NewLabel("")
+
+	v.container = container.NewBorder(
+		container.NewBorder(nil, nil, nil, v.countLabel, v.filterEntry),
+		nil, nil, nil,
+		v.table,
+	)
+	v.SetHeaders(nil, "")
+	return v
+}
+
+// SetHeaders shows headers, a pair per value; requestURL is what relative
+// Location and Link URLs are resolved against.
+func (v *ResponseHeadersView) SetHeaders(headers []KeyValue, requestURL string) {
+	v.headers = make([]KeyValue, len(headers))
+	copy(v.headers, headers)
+	sort.SliceStable(v.headers, func(i, j int) bool {
+		return strings.ToLower(v.headers[i].Key) < strings.ToLower(v.headers[j].Key)
+	})
+	v.baseURL = requestURL
+	v.applyFilter()
+}
+
+// Count returns the number of header values shown when nothing is
+// filtered out.
+func (v *ResponseHeadersView) Count() int {
+	return len(v.headers)
+}
+
+func (v *ResponseHeadersView) applyFilter() {
+	filter := strings.ToLower(strings.TrimSpace(v.filterEntry.Text))
+	v.rows = nil
+	for _, header := range v.headers {
+		if filter == "" || strings.Contains(strings.ToLower(header.Key), filter) ||
+			strings.Contains(strings.ToLower(header.Value), filter) {
+			v.rows = append(v.rows, header)
+		}
+	}
+
+	switch {
+	case len(v.headers) == 0:
+		v.countLabel.SetText("No headers")
+	case filter != "":
+		v.countLabel.SetText(fmt.Sprintf("%d of %d", len(v.rows), len(v.headers)))
+	default:
+		v.countLabel.SetText(fmt.Sprintf("%d", len(v.headers)))
+	}
+	v.table.UnselectAll()
+	v.table.Refresh()
+}
+
+func (v *ResponseHeadersView) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	cell := o.(*fyne.Container)
+	label := cell.Objects[0].(*widget.Label)
+	button := cell.Objects[1].(*widget.Button)
+	if id.Row >= len(v.rows) {
+		return
+	}
+	header := v.rows[id.Row]
+
+	if id.Col == headerColumnCopy {
+		label.Hide()
+		button.OnTapped = func() {
+			fyne.CurrentApp().Clipboard().SetContent(header.Value)
+		}
+		button.Show()
+		return
+	}
+	button.Hide()
+	label.Show()
+	label.Importance = widget.MediumImportance
+	if id.Col == headerColumnName {
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		label.SetText(header.Key)
+		return
+	}
+	label.TextStyle = fyne.TextStyle{}
+	if len(headerURLs(header)) > 0 {
+		label.Importance = widget.HighImportance
+	}
+	label.SetText(header.Value)
+}
+
+// selectCell offers to load the URL of a Location or Link header whose value
+// was clicked.
+func (v *ResponseHeadersView) selectCell(id widget.TableCellID) {
+	v.table.Unselect(id)
+	if id.Col != headerColumnValue || id.Row >= len(v.rows) || v.OnOpenURL == nil {
+		return
+	}
+	links := headerURLs(v.rows[id.Row])
+	if len(links) == 0 {
+		return
+	}
+
+	targets := make([]string, len(links))
+	options := make([]string, len(links))
+	for i, link := range links {
+		targets[i] = v.resolve(link.target)
+		options[i] = targets[i]
+		if link.rel != "" {
+			options[i] = link.rel + ": " + targets[i]
+		}
+	}
+
+	// With Ctrl or Cmd held the link opens in the browser instead
+	load := v.OnOpenURL
+	if linkModifierPressed() {
+		if len(targets) == 1 {
+			openLink(targets[0], nil)
+			return
+		}
+		load = func(target string) {
+			openLink(target, nil)
+		}
+	}
+
+	if len(targets) == 1 {
+		dialog.ShowConfirm("Open URL", fmt.Sprintf("Load %s into the URL bar?", targets[0]), func(confirmed bool) {
+			if confirmed {
+				load(targets[0])
+			}
+		}, v.parentWindow)
+		return
+	}
+
+	choice := widget.NewRadioGroup(options, nil)
+	choice.Required = true
+	choice.SetSelected(options[0])
+	dialog.ShowCustomConfirm("Open URL", "Load", "Cancel", container.NewVScroll(choice), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		for i, option := range options {
+			if option == choice.Selected {
+				load(targets[i])
+			}
+		}
+	}, v.parentWindow)
+}
+
+// resolve makes a URL of a header absolute against the request URL.
+func (v *ResponseHeadersView) resolve(target string) string {
+	base, err := url.Parse(v.baseURL)
+	if err != nil {
+		return target
+	}
+	ref, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func (v *ResponseHeadersView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+// headerLink is a URL in a Location or Link header, with its rel for a Link.
+type headerLink struct {
+	target string
+	rel    string
+}
+
+// headerURLs returns the URLs of a Location or Link header, or nil for any
+// other header.
+func headerURLs(header KeyValue) []headerLink {
+	switch http.CanonicalHeaderKey(header.Key) {
+	case "Location", "Content-Location":
+		if target := strings.TrimSpace(header.Value); target != "" {
+			return []headerLink{{target: target}}
+		}
+	case "Link":
+		return parseLinkHeader(header.Value)
+	}
+	return nil
+}
+
+// parseLinkHeader reads the <url>; rel="name" entries of a Link header
+// (RFC 8288).
+func parseLinkHeader(value string) []headerLink {
+	var links []headerLink
+	for rest := value; ; {
+		start := strings.Index(rest, "<")
+		if start < 0 {
+			return links
+		}
+		end := strings.Index(rest[start:], ">")
+		if end < 0 {
+			return links
+		}
+		link := headerLink{target: strings.TrimSpace(rest[start+1 : start+end])}
+		rest = rest[start+end+1:]
+
+		// The parameters run up to the next entry
+		params := rest
+		if next := strings.Index(rest, "<"); next >= 0 {
+			params = rest[:next]
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "rel") {
+				link.rel = strings.Trim(strings.TrimSpace(val), `",`)
+			}
+		}
+		if link.target != "" {
+			links = append(links, link)
+		}
+	}
+}
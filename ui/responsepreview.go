@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/fyne-io/oksvg"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// previewImageWidth is the widest an image is shown; larger ones are
+	// scaled down
+	previewImageWidth = 600
+	// hexDumpLimit is how much of an image that cannot be decoded is shown
+	// as hex
+	hexDumpLimit = 64 << 10
+)
+
+// imageExtensions are the image types a response can be previewed as, with
+// the extension they are saved with.
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+}
+
+// ResponsePreview shows an HTML response as readable text, or an image
+// response as the image. A page is never rendered by a browser engine and
+// nothing it refers to is fetched on its own: the absolute image URLs of the
+// page are listed, each loaded only when its Load button is clicked.
+type ResponsePreview struct {
+	// OnLoadImage fetches an image of a page; it is called off the main
+	// thread. Without it images cannot be loaded.
+	OnLoadImage func(url string) ([]byte, error)
+
+	container    *fyne.Container
+	content      *fyne.Container
+	page         fyne.CanvasObject
+	text         *widget.RichText
+	images       *fyne.Container
+	messageView  *widget.Label
+	parentWindow fyne.Window
+	generation   int
+}
+
+func NewResponsePreview(parentWindow fyne.Window) *ResponsePreview {
+	p := &ResponsePreview{parentWindow: parentWindow}
+
+	p.text = widget.NewRichText()
+	p.text.Wrapping = fyne.TextWrapWord
+	p.images = container.NewVBox()
+	p.page = container.NewVScroll(container.NewVBox(p.text, p.images))
+
+	p.messageView = widget.NewLabel("")
+	p.messageView.Alignment = fyne.TextAlignCenter
+	p.messageView.Wrapping = fyne.TextWrapWord
+
+	p.content = container.NewStack()
+	p.container = container.NewStack(p.content, p.messageView)
+	p.Clear()
+	return p
+}
+
+// Clear shows that there is nothing to preview.
+func (p *ResponsePreview) Clear() {
+	p.generation++
+	p.showMessage("A preview is shown for HTML and image responses")
+}
+
+// SetHTML shows body as text, converting it in the background.
+func (p *ResponsePreview) SetHTML(body string) {
+	p.generation++
+	p.showMessage("Converting...")
+	generation := p.generation
+	go func() {
+		doc := convertHTML(body)
+		fyne.Do(func() {
+			if generation != p.generation {
+				return
+			}
+			if len(doc.segments) == 0 {
+				p.showMessage("The page has no text")
+			} else {
+				p.text.Segments = doc.segments
+				p.text.Refresh()
+				p.show(p.page)
+			}
+			p.setImages(doc.images)
+		})
+	}()
+}
+
+// SetImage shows body, an image of type contentType, decoding it in the
+// background, with its dimensions, its size and a button to save it. An
+// image that cannot be decoded is shown as hex with the reason.
+func (p *ResponsePreview) SetImage(body, contentType string) {
+	p.generation++
+	p.showMessage("Decoding...")
+	generation := p.generation
+	data := []byte(body)
+	go func() {
+		picture, width, height, err := decodePreviewImage(data, isSVGContentType(contentType))
+		fyne.Do(func() {
+			if generation != p.generation {
+				return
+			}
+
+			info := widget.NewLabel(FormatBytes(int64(len(data))))
+			var view fyne.CanvasObject
+			if err != nil {
+				info.SetText(fmt.Sprintf("Could not decode the image, shown as hex: %v", err))
+				info.Importance = widget.WarningImportance
+				info.Wrapping = fyne.TextWrapWord
+				view = newHexView(data)
+			} else {
+				info.SetText(fmt.Sprintf("%d × %d px, %s", width, height, FormatBytes(int64(len(data)))))
+				view = container.NewScroll(container.NewCenter(picture))
+			}
+
+			saveButton := widget.NewButtonWithIcon("Save as…", theme.DocumentSaveIcon(), func() {
+				p.saveImage(data, "image"+imageExtensions[imageMediaType(contentType)])
+			})
+			p.show(container.NewBorder(container.NewBorder(nil, nil, nil, saveButton, info), nil, nil, nil, view))
+		})
+	}()
+}
+
+// saveImage writes the bytes of the response as they were received.
+func (p *ResponsePreview) saveImage(data []byte, fileName string) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, p.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(data); err != nil {
+			dialog.ShowError(err, p.parentWindow)
+		}
+	}, p.parentWindow)
+	save.SetFileName(fileName)
+	save.Show()
+}
+
+func (p *ResponsePreview) show(view fyne.CanvasObject) {
+	p.content.Objects = []fyne.CanvasObject{view}
+	p.content.Refresh()
+	p.messageView.Hide()
+	p.content.Show()
+}
+
+func (p *ResponsePreview) showMessage(message string) {
+	p.messageView.SetText(message)
+	p.messageView.Show()
+	p.content.Hide()
+	p.content.Objects = nil
+	p.text.Segments = nil
+	p.text.Refresh()
+	p.images.RemoveAll()
+}
+
+// setImages lists the images of a page, with a button to load each.
+func (p *ResponsePreview) setImages(images []htmlImage) {
+	p.images.RemoveAll()
+	if len(images) == 0 || p.OnLoadImage == nil {
+		return
+	}
+
+	heading := widget.NewLabelWithStyle(fmt.Sprintf("Images (%d), not loaded until asked for", len(images)),
+		fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	p.images.Add(widget.NewSeparator())
+	p.images.Add(heading)
+	for _, img := range images {
+		p.images.Add(p.newImageRow(img))
+	}
+	p.images.Refresh()
+}
+
+// newImageRow shows the URL of an image with a Load button, which fetches
+// it and puts it in place of the button.
+func (p *ResponsePreview) newImageRow(img htmlImage) fyne.CanvasObject {
+	srcLabel := widget.NewLabel(img.alt + ": " + img.src)
+	srcLabel.Truncation = fyne.TextTruncateEllipsis
+	row := container.NewVBox()
+
+	var loadButton *widget.Button
+	loadButton = widget.NewButton("Load", func() {
+		loadButton.Disable()
+		loadButton.SetText("Loading...")
+		generation := p.generation
+		go func() {
+			data, err := p.OnLoadImage(img.src)
+			var picture *canvas.Image
+			if err == nil {
+				picture, _, _, err = decodePreviewImage(data, looksLikeSVG(data))
+			}
+			fyne.Do(func() {
+				if generation != p.generation {
+					return
+				}
+				row.RemoveAll()
+				row.Add(srcLabel)
+				if err != nil {
+					errorLabel := widget.NewLabel(fmt.Sprintf("Could not load the image: %v", err))
+					errorLabel.Importance = widget.DangerImportance
+					errorLabel.Wrapping = fyne.TextWrapWord
+					row.Add(errorLabel)
+					return
+				}
+				row.Add(container.NewHBox(picture))
+			})
+		}()
+	})
+
+	row.Add(container.NewBorder(nil, nil, nil, loadButton, srcLabel))
+	return row
+}
+
+func (p *ResponsePreview) GetContainer() *fyne.Container {
+	return p.container
+}
+
+// decodePreviewImage decodes a PNG, JPEG, GIF, WebP or, when svg is set,
+// SVG image, sized to show it at its own size scaled down to fit
+// previewImageWidth.
+func decodePreviewImage(data []byte, svg bool) (*canvas.Image, int, int, error) {
+	var (
+		picture       *canvas.Image
+		width, height int
+	)
+	if svg {
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		width, height = int(icon.ViewBox.W), int(icon.ViewBox.H)
+		if width <= 0 || height <= 0 {
+			return nil, 0, 0, errors.New("the SVG has no size")
+		}
+		picture = canvas.NewImageFromResource(fyne.NewStaticResource("image.svg", data))
+	} else {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		width, height = decoded.Bounds().Dx(), decoded.Bounds().Dy()
+		picture = canvas.NewImageFromImage(decoded)
+	}
+
+	picture.FillMode = canvas.ImageFillContain
+	size := fyne.NewSize(float32(width), float32(height))
+	if size.Width > previewImageWidth {
+		size = fyne.NewSize(previewImageWidth, size.Height*previewImageWidth/size.Width)
+	}
+	picture.SetMinSize(size)
+	return picture, width, height, nil
+}
+
+// newHexView shows the first hexDumpLimit bytes of data as a hex dump.
+func newHexView(data []byte) fyne.CanvasObject {
+	dump := hex.Dump(data[:min(len(data), hexDumpLimit)])
+	if len(data) > hexDumpLimit {
+		dump += fmt.Sprintf("... %d more bytes\n", len(data)-hexDumpLimit)
+	}
+	entry := widget.NewMultiLineEntry()
+	entry.TextStyle = fyne.TextStyle{Monospace: true}
+	entry.SetText(dump)
+	entry.Disable()
+	return entry
+}
+
+// IsImageContentType reports whether contentType is an image the preview
+// can show.
+func IsImageContentType(contentType string) bool {
+	_, ok := imageExtensions[imageMediaType(contentType)]
+	return ok
+}
+
+// looksLikeSVG reports whether data is markup rather than a raster image,
+// for images whose type is not known.
+func looksLikeSVG(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<"))
+}
+
+func isSVGContentType(contentType string) bool {
+	return imageMediaType(contentType) == "image/svg+xml"
+}
+
+func imageMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if mediaType == "image/jpg" {
+		return "image/jpeg"
+	}
+	return mediaType
+}
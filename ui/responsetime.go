@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// TimeThresholds are the response times, in milliseconds, that mark a
+// response as fast or slow. Times below FastMs show green, times from
+// SlowMs show red and those in between yellow.
+type TimeThresholds struct {
+	FastMs int `json:"fast_ms"`
+	SlowMs int `json:"slow_ms"`
+}
+
+func DefaultTimeThresholds() TimeThresholds {
+	return TimeThresholds{FastMs: 300, SlowMs: 1000}
+}
+
+// Importance colours a response time by the thresholds.
+func (t TimeThresholds) Importance(d time.Duration) widget.Importance {
+	switch ms := d.Milliseconds(); {
+	case ms < int64(t.FastMs):
+		return widget.SuccessImportance
+	case ms < int64(t.SlowMs):
+		return widget.WarningImportance
+	default:
+		return widget.DangerImportance
+	}
+}
+
+// Validate checks that both thresholds are positive and the fast one is
+// below the slow one.
+func (t TimeThresholds) Validate() error {
+	if t.FastMs < 1 || t.SlowMs <= t.FastMs {
+		return fmt.Errorf("the fast response time must be at least 1 ms and below the slow one")
+	}
+	return nil
+}
+
+// StatusImportance colours a status line such as "404 Not Found" by its
+// class: 2xx green, 4xx orange and 5xx red, other statuses and errors as
+// normal text.
+func StatusImportance(status string) widget.Importance {
+	code, err := strconv.Atoi(strings.SplitN(status, " ", 2)[0])
+	switch {
+	case err != nil:
+		return widget.MediumImportance
+	case code >= 200 && code < 300:
+		return widget.SuccessImportance
+	case code >= 400 && code < 500:
+		return widget.WarningImportance
+	case code >= 500 && code < 600:
+		return widget.DangerImportance
+	}
+	return widget.MediumImportance
+}
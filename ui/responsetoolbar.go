@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// What the copy bar copies.
+const (
+	CopyBody        = "Body"
+	CopyEscapedBody = "Escaped Body"
+	CopyHeaders     = "Headers"
+	CopyStatusLine  = "Status Line"
+	CopyExchange    = "Exchange"
+)
+
+// copiedNoticeDelay is how long the toolbar says what was copied.
+const copiedNoticeDelay = 2 * time.Second
+
+// ResponseToolbar is a row of buttons for the response: buttons that copy
+// parts of it to the clipboard, namely the body, the body as an escaped
+// string, the headers, the status line, or the request and response
+// together, a button that saves the body to a file, one that decodes the
+// JWTs of the exchange when it has any, and one that sends the request again
+// conditionally when the response has an ETag or Last-Modified header. The
+// buttons are disabled until there is a response.
+type ResponseToolbar struct {
+	// OnCopy returns the text to copy for one of the Copy constants, or the
+	// error to show instead
+	OnCopy func(what string) (string, error)
+	// OnSave saves the body to a file
+	OnSave func()
+	// OnDecodeJWT shows the JWTs of the exchange
+	OnDecodeJWT func()
+	// OnRevalidate sends the request again with the validators of the
+	// response
+	OnRevalidate func()
+
+	container    *fyne.Container
+	buttons      []*widget.Button
+	jwtButton    *widget.Button
+	revalidate   *widget.Button
+	noticeLabel  *widget.Label
+	generation   int
+	parentWindow fyne.Window
+}
+
+func NewResponseToolbar(parentWindow fyne.Window) *ResponseToolbar {
+	b := &ResponseToolbar{parentWindow: parentWindow}
+
+	b.noticeLabel = widget.NewLabel("")
+	b.noticeLabel.Importance = widget.SuccessImportance
+
+	row := container.NewHBox(widget.NewLabel("Copy:"))
+	for _, what := range []string{CopyBody, CopyEscapedBody, CopyHeaders, CopyStatusLine, CopyExchange} {
+		button := widget.NewButtonWithIcon(what, theme.ContentCopyIcon(), func() {
+			b.copy(what)
+		})
+		b.buttons = append(b.buttons, button)
+		row.Add(button)
+	}
+	row.Add(b.noticeLabel)
+
+	saveButton := widget.NewButtonWithIcon("Save Response", theme.DocumentSaveIcon(), func() {
+		if b.OnSave != nil {
+			b.OnSave()
+		}
+	})
+	b.buttons = append(b.buttons, saveButton)
+
+	b.jwtButton = widget.NewButton("Decode JWT", func() {
+		if b.OnDecodeJWT != nil {
+			b.OnDecodeJWT()
+		}
+	})
+	b.jwtButton.Hide()
+
+	b.revalidate = widget.NewButtonWithIcon("Revalidate", theme.ViewRefreshIcon(), func() {
+		if b.OnRevalidate != nil {
+			b.OnRevalidate()
+		}
+	})
+	b.revalidate.Hide()
+
+	b.container = container.NewBorder(nil, nil, nil, container.NewHBox(b.revalidate, b.jwtButton, saveButton), row)
+	b.SetEnabled(false)
+	return b
+}
+
+// SetEnabled enables the buttons when there is a response to copy.
+func (b *ResponseToolbar) SetEnabled(enabled bool) {
+	for _, button := range b.buttons {
+		if enabled {
+			button.Enable()
+		} else {
+			button.Disable()
+		}
+	}
+	b.generation++
+	b.noticeLabel.SetText("")
+	if !enabled {
+		b.jwtButton.Hide()
+		b.revalidate.Hide()
+	}
+}
+
+// ShowDecodeJWT shows the Decode JWT button when the exchange has JWTs.
+func (b *ResponseToolbar) ShowDecodeJWT(show bool) {
+	if show {
+		b.jwtButton.Show()
+	} else {
+		b.jwtButton.Hide()
+	}
+}
+
+// ShowRevalidate shows the Revalidate button when the response has an ETag
+// or Last-Modified header.
+func (b *ResponseToolbar) ShowRevalidate(show bool) {
+	if show {
+		b.revalidate.Show()
+	} else {
+		b.revalidate.Hide()
+	}
+}
+
+func (b *ResponseToolbar) copy(what string) {
+	if b.OnCopy == nil {
+		return
+	}
+	text, err := b.OnCopy(what)
+	if err != nil {
+		dialog.ShowError(err, b.parentWindow)
+		return
+	}
+	fyne.CurrentApp().Clipboard().SetContent(text)
+
+	b.generation++
+	generation := b.generation
+	b.noticeLabel.SetText("Copied " + what)
+	time.AfterFunc(copiedNoticeDelay, func() {
+		fyne.Do(func() {
+			if generation == b.generation {
+				b.noticeLabel.SetText("")
+			}
+		})
+	})
+}
+
+func (b *ResponseToolbar) GetContainer() *fyne.Container {
+	return b.container
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const scriptHelp = `Runs before each send, after {{variables}} are substituted. Throwing an error aborts the send.
+
+request.method, request.path (read only); request.url, request.body
+request.headers.get(name), .set(name, value), .remove(name)
+env.get(name), env.set(name, value)
+crypto.sha256(data), crypto.hmacSHA256(key, data), with an optional "hex" or "base64" encoding
+console.log(...), shown under the response`
+
+// ScriptEditor edits the JavaScript pre-request script of a request.
+type ScriptEditor struct {
+	container   *fyne.Container
+	scriptEntry *widget.Entry
+}
+
+func NewScriptEditor() *ScriptEditor {
+	e := &ScriptEditor{}
+
+	e.scriptEntry = widget.NewMultiLineEntry()
+	e.scriptEntry.SetPlaceHolder(`var ts = String(Math.floor(Date.now() / 1000));
+request.headers.set("X-Timestamp", ts);
+request.headers.set("X-Signature", crypto.hmacSHA256(env.get("apiSecret"), ts + request.path));`)
+	e.scriptEntry.TextStyle = fyne.TextStyle{Monospace: true}
+
+	help := widget.NewLabel(scriptHelp)
+	help.Wrapping = fyne.TextWrapWord
+
+	e.container = container.NewBorder(help, nil, nil, nil, e.scriptEntry)
+	return e
+}
+
+func (e *ScriptEditor) GetScript() string {
+	return e.scriptEntry.Text
+}
+
+func (e *ScriptEditor) SetScript(script string) {
+	e.scriptEntry.SetText(script)
+}
+
+func (e *ScriptEditor) GetContainer() *fyne.Container {
+	return e.container
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"golem/secrets"
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const unchangedSecretHint = "•••• (unchanged)"
+
+// UnlockSecrets unlocks vault, asking for the passphrase when the OS keyring
+// cannot be used, then calls onUnlocked. The first time a passphrase is
+// needed the user chooses it.
+func UnlockSecrets(db *storage.DB, vault *secrets.Vault, parentWindow fyne.Window, onUnlocked func()) {
+	done := func() {
+		if onUnlocked != nil {
+			onUnlocked()
+		}
+	}
+
+	err := vault.Unlock(db)
+	if err == nil {
+		done()
+		return
+	}
+	if !errors.Is(err, secrets.ErrPassphraseRequired) {
+		dialog.ShowError(err, parentWindow)
+		return
+	}
+
+	title := "Unlock Secrets"
+	passphraseEntry := widget.NewPasswordEntry()
+	passphrase := &widget.FormItem{
+		Text:     "Passphrase",
+		Widget:   passphraseEntry,
+		HintText: "Protects the secret variables",
+	}
+	items := []*widget.FormItem{passphrase}
+
+	if !secrets.HasPassphrase(db) {
+		title = "Set Secrets Passphrase"
+		passphrase.HintText = "No OS keyring is available; this is asked once per session"
+		confirmEntry := widget.NewPasswordEntry()
+		confirmEntry.Validator = func(text string) error {
+			if text != passphraseEntry.Text {
+				return errors.New("the passphrases do not match")
+			}
+			return nil
+		}
+		items = append(items, widget.NewFormItem("Confirm", confirmEntry))
+	}
+
+	form := dialog.NewForm(title, "Unlock", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := vault.UnlockWithPassphrase(db, passphraseEntry.Text); err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		done()
+	}, parentWindow)
+	form.Resize(fyne.NewSize(450, 0))
+	form.Show()
+	parentWindow.Canvas().Focus(passphraseEntry)
+}
+
+// VariableEditor edits variables as name/value rows with a secret flag.
+// Secret values are typed into concealed fields and are never shown: an
+// existing secret keeps its encrypted value unless a new one is entered.
+type VariableEditor struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+	rows      []*variableRow
+}
+
+type variableRow struct {
+	nameEntry   *widget.Entry
+	valueEntry  *widget.Entry
+	secretCheck *widget.Check
+	container   *fyne.Container
+
+	// stored is the encrypted value of a secret loaded from the database
+	stored string
+}
+
+func NewVariableEditor() *VariableEditor {
+	e := &VariableEditor{}
+
+	e.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Variable", theme.ContentAddIcon(), func() {
+		e.addRow(&storage.Variable{})
+	})
+
+	e.container = container.NewBorder(
+		nil,
+		container.NewHBox(addButton),
+		nil,
+		nil,
+		container.NewVScroll(e.rowsBox),
+	)
+
+	return e
+}
+
+func (e *VariableEditor) addRow(variable *storage.Variable) {
+	row := &variableRow{
+		nameEntry:  widget.NewEntry(),
+		valueEntry: widget.NewEntry(),
+	}
+	row.nameEntry.SetPlaceHolder("Name")
+	row.nameEntry.SetText(variable.Name)
+	row.valueEntry.SetPlaceHolder("Value")
+
+	if variable.Secret {
+		row.stored = variable.Value
+		row.valueEntry.Password = true
+		row.valueEntry.SetPlaceHolder(unchangedSecretHint)
+	} else {
+		row.valueEntry.SetText(variable.Value)
+	}
+
+	row.secretCheck = widget.NewCheck("Secret", func(secret bool) {
+		row.valueEntry.Password = secret
+		row.valueEntry.Refresh()
+	})
+	row.secretCheck.SetChecked(variable.Secret)
+
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.removeRow(row)
+	})
+
+	row.container = container.NewBorder(nil, nil, nil,
+		container.NewHBox(row.secretCheck, removeButton),
+		container.NewGridWithColumns(2, row.nameEntry, row.valueEntry),
+	)
+
+	e.rows = append(e.rows, row)
+	e.rowsBox.Add(row.container)
+}
+
+func (e *VariableEditor) removeRow(row *variableRow) {
+	for i, r := range e.rows {
+		if r == row {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+	e.rowsBox.Remove(row.container)
+}
+
+func (e *VariableEditor) SetVariables(variables []*storage.Variable) {
+	e.rows = nil
+	e.rowsBox.RemoveAll()
+	for _, variable := range variables {
+		e.addRow(variable)
+	}
+	e.rowsBox.Refresh()
+}
+
+// GetVariables returns the rows with a name, checking the names are usable
+// in a placeholder and unique. New secret values are encrypted, and a secret
+// made plain without typing a new value is decrypted; both fail with
+// secrets.ErrLocked until the vault is unlocked.
+func (e *VariableEditor) GetVariables(vault *secrets.Vault) ([]*storage.Variable, error) {
+	seen := make(map[string]bool, len(e.rows))
+	variables := make([]*storage.Variable, 0, len(e.rows))
+	for _, row := range e.rows {
+		name := row.nameEntry.Text
+		if name == "" {
+			continue
+		}
+		if !variableNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q: use letters, digits, _, . and -, not starting with a digit", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("variable %q is defined twice", name)
+		}
+		seen[name] = true
+
+		variable := &storage.Variable{Name: name, Value: row.valueEntry.Text, Secret: row.secretCheck.Checked}
+		unchanged := row.stored != "" && row.valueEntry.Text == ""
+		var err error
+		switch {
+		case variable.Secret && unchanged:
+			variable.Value = row.stored
+		case variable.Secret:
+			variable.Value, err = vault.Encrypt(variable.Value)
+		case unchanged:
+			variable.Value, err = vault.Decrypt(row.stored)
+		}
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+func (e *VariableEditor) GetContainer() *fyne.Container {
+	return e.container
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// expiryWarningDays is how close to its expiry a certificate is flagged.
+const expiryWarningDays = 30
+
+// TLSInfo is the TLS connection a response came over. It is kept in history
+// as JSON, so it holds descriptions rather than the certificates themselves.
+type TLSInfo struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name,omitempty"`
+	ALPN        string `json:"alpn,omitempty"`
+	// Verified is false when certificate verification was skipped, in
+	// which case HostnameError tells whether the server certificate does
+	// not match the host
+	Verified      bool              `json:"verified"`
+	HostnameError string            `json:"hostname_error,omitempty"`
+	Certificates  []CertificateInfo `json:"certificates"`
+}
+
+// CertificateInfo describes a certificate of the chain the server sent.
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SANs               []string  `json:"sans,omitempty"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	SerialNumber       string    `json:"serial_number"`
+	SHA256             string    `json:"sha256"`
+	SHA1               string    `json:"sha1"`
+}
+
+// validity describes when cert is valid relative to now, and how much that
+// matters.
+func (c CertificateInfo) validity(now time.Time) (string, widget.Importance) {
+	days := int(c.NotAfter.Sub(now).Hours() / 24)
+	switch {
+	case now.Before(c.NotBefore):
+		return fmt.Sprintf("not valid until %s", c.NotBefore.Local().Format(time.DateTime)), widget.DangerImportance
+	case now.After(c.NotAfter):
+		return fmt.Sprintf("expired %d days ago", int(now.Sub(c.NotAfter).Hours()/24)), widget.DangerImportance
+	case days <= expiryWarningDays:
+		return fmt.Sprintf("expires in %d days", days), widget.WarningImportance
+	}
+	return fmt.Sprintf("expires in %d days", days), widget.SuccessImportance
+}
+
+// SecurityView shows the TLS connection of a response: the protocol version
+// and cipher suite and the certificate chain the server sent. Expired
+// certificates and, when verification was skipped, a server certificate
+// that does not match the host are flagged in red.
+type SecurityView struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+}
+
+func NewSecurityView() *SecurityView {
+	v := &SecurityView{rowsBox: container.NewVBox()}
+	v.container = container.NewStack(container.NewVScroll(v.rowsBox))
+	v.SetTLS(nil)
+	return v
+}
+
+// SetTLS shows info; nil is a response that did not come over TLS, or none.
+func (v *SecurityView) SetTLS(info *TLSInfo) {
+	v.rowsBox.RemoveAll()
+	defer v.rowsBox.Refresh()
+	if info == nil {
+		v.rowsBox.Add(widget.NewLabel("The response did not come over TLS"))
+		return
+	}
+
+	connection := container.New(layout.NewFormLayout())
+	addSecurityRow(connection, "Protocol", info.Version, widget.MediumImportance)
+	addSecurityRow(connection, "Cipher suite", info.CipherSuite, widget.MediumImportance)
+	if info.ALPN != "" {
+		addSecurityRow(connection, "ALPN", info.ALPN, widget.MediumImportance)
+	}
+	if info.ServerName != "" {
+		addSecurityRow(connection, "Server name", info.ServerName, widget.MediumImportance)
+	}
+	if info.Verified {
+		addSecurityRow(connection, "Verification", "The certificate chain was verified", widget.SuccessImportance)
+	} else {
+		addSecurityRow(connection, "Verification", "Skipped (Options)", widget.WarningImportance)
+	}
+	v.rowsBox.Add(connection)
+
+	if info.HostnameError != "" {
+		v.rowsBox.Add(securityWarning("Hostname mismatch: " + info.HostnameError))
+	}
+	now := time.Now()
+	for _, cert := range info.Certificates {
+		if now.After(cert.NotAfter) {
+			v.rowsBox.Add(securityWarning("Expired certificate: " + cert.Subject))
+		}
+	}
+
+	for i, cert := range info.Certificates {
+		title := "Server certificate"
+		if i > 0 {
+			title = fmt.Sprintf("Issuer certificate %d", i)
+		}
+		v.rowsBox.Add(widget.NewSeparator())
+		v.rowsBox.Add(widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+
+		rows := container.New(layout.NewFormLayout())
+		addSecurityRow(rows, "Subject", cert.Subject, widget.MediumImportance)
+		addSecurityRow(rows, "Issuer", cert.Issuer, widget.MediumImportance)
+		if len(cert.SANs) > 0 {
+			addSecurityRow(rows, "Alternative names", strings.Join(cert.SANs, ", "), widget.MediumImportance)
+		}
+		addSecurityRow(rows, "Valid from", cert.NotBefore.Local().Format(time.DateTime), widget.MediumImportance)
+		validity, importance := cert.validity(now)
+		addSecurityRow(rows, "Valid until", fmt.Sprintf("%s (%s)", cert.NotAfter.Local().Format(time.DateTime), validity), importance)
+		addSecurityRow(rows, "Key", cert.KeyType, widget.MediumImportance)
+		addSecurityRow(rows, "Signature", cert.SignatureAlgorithm, widget.MediumImportance)
+		addSecurityRow(rows, "Serial number", cert.SerialNumber, widget.MediumImportance)
+		addSecurityRow(rows, "SHA-256", cert.SHA256, widget.MediumImportance)
+		addSecurityRow(rows, "SHA-1", cert.SHA1, widget.MediumImportance)
+		v.rowsBox.Add(rows)
+	}
+}
+
+func (v *SecurityView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+// addSecurityRow adds a name and a value that can be selected for copying.
+func addSecurityRow(rows *fyne.Container, name, value string, importance widget.Importance) {
+	valueLabel := widget.NewLabel(value)
+	valueLabel.Wrapping = fyne.TextWrapBreak
+	valueLabel.Selectable = true
+	valueLabel.Importance = importance
+	rows.Add(widget.NewLabelWithStyle(name, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}))
+	rows.Add(valueLabel)
+}
+
+func securityWarning(text string) *widget.Label {
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapWord
+	label.Importance = widget.DangerImportance
+	return label
+}
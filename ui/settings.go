@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Settings are the application-wide defaults edited in the Settings dialog.
+// Individual requests can override some of them in the Options tab.
+type Settings struct {
+	Proxy              ProxyConfig
+	ClientCertificates []ClientCertificate
+	SkipTLSVerify      bool
+	CAFiles            []string
+	UseSystemCAs       bool
+	DefaultHeaders     []KeyValue
+	// HostOverrides map a hostname to the IP address, optionally with a
+	// port, that connections for it go to; unticked rows are kept but unused
+	HostOverrides []KeyValue
+	// CopyCredentials leaves Authorization values unmasked when a request
+	// and its response are copied as text
+	CopyCredentials bool
+	// MaxDisplaySize is how many bytes of a response body are loaded for
+	// display; the rest is kept in a temporary file
+	MaxDisplaySize int64
+	// ProtoFiles are the descriptor sets and .proto files protobuf
+	// responses are decoded with
+	ProtoFiles []string
+	// TimeThresholds colour response times as fast or slow
+	TimeThresholds TimeThresholds
+	// AutoRevalidate remembers the ETag and Last-Modified of each URL and
+	// makes later GET and HEAD requests to it conditional
+	AutoRevalidate bool
+	// HistoryRetention limits how many history entries are kept and how
+	// much of each response body
+	HistoryRetention HistoryRetention
+}
+
+// ShowSettingsDialog edits a copy of settings and passes it to onSave when
+// the user confirms. The dialog stays open while a certificate fails to load.
+func ShowSettingsDialog(settings Settings, onSave func(Settings), parentWindow fyne.Window) {
+	proxyEditor := NewProxyEditor(false)
+	proxyEditor.SetConfig(settings.Proxy)
+
+	certificatesEditor := NewCertificatesEditor(parentWindow)
+	certificatesEditor.SetCertificates(settings.ClientCertificates)
+
+	caFilesEditor := NewCAFilesEditor(parentWindow)
+	caFilesEditor.SetFiles(settings.CAFiles)
+
+	systemCAsCheck := widget.NewCheck("Also trust the system root certificates", nil)
+	systemCAsCheck.SetChecked(settings.UseSystemCAs)
+
+	// Default headers are sent with every request unless it sets the same header
+	defaultHeadersEditor := NewKeyValueEditor("Header", "Value", "Add Header")
+	defaultHeadersEditor.SetPairs(settings.DefaultHeaders)
+
+	hostOverridesEditor := NewKeyValueEditorWithToggles("Hostname", "IP or IP:port", "Add Override")
+	hostOverridesEditor.SetPairs(settings.HostOverrides)
+
+	skipVerifyCheck := widget.NewCheck("Ignore TLS certificate errors (insecure)", nil)
+	skipVerifyCheck.SetChecked(settings.SkipTLSVerify)
+
+	maxDisplayEntry := widget.NewEntry()
+	maxDisplayEntry.SetText(strconv.FormatFloat(float64(settings.MaxDisplaySize)/(1<<20), 'f', -1, 64))
+	maxDisplayEntry.Validator = func(text string) error {
+		_, err := parseMegabytes(text)
+		return err
+	}
+
+	protoFilesEditor := NewProtoFilesEditor(parentWindow)
+	protoFilesEditor.SetFiles(settings.ProtoFiles)
+
+	fastEntry := widget.NewEntry()
+	fastEntry.SetText(strconv.Itoa(settings.TimeThresholds.FastMs))
+	fastEntry.Validator = positiveValidator("enter a whole number of milliseconds")
+	slowEntry := widget.NewEntry()
+	slowEntry.SetText(strconv.Itoa(settings.TimeThresholds.SlowMs))
+	slowEntry.Validator = positiveValidator("enter a whole number of milliseconds")
+
+	maxEntriesEntry := newLimitEntry(settings.HistoryRetention.MaxEntries, "enter a whole number of entries")
+	maxDaysEntry := newLimitEntry(settings.HistoryRetention.MaxDays, "enter a whole number of days")
+	maxBodyEntry := newLimitEntry(settings.HistoryRetention.MaxBodyKB, "enter a whole number of KB")
+
+	autoRevalidateCheck := widget.NewCheck("Remember ETag and Last-Modified per URL and send them back on every GET and HEAD", nil)
+	autoRevalidateCheck.SetChecked(settings.AutoRevalidate)
+
+	copyCredentialsCheck := widget.NewCheck("Include Authorization values when copying an exchange", nil)
+	copyCredentialsCheck.SetChecked(settings.CopyCredentials)
+
+	content := container.NewVScroll(container.NewVBox(
+		widget.NewLabelWithStyle("Default headers", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		defaultHeadersEditor.GetContainer(),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Host overrides", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Connections for a hostname go to another address; the Host header and TLS server name stay the same."),
+		hostOverridesEditor.GetContainer(),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Proxy", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		proxyEditor.GetContainer(),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("TLS", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		skipVerifyCheck,
+		widget.NewLabel("Trusted CA files (PEM)"),
+		caFilesEditor.GetContainer(),
+		systemCAsCheck,
+		widget.NewLabel("Client certificates (mTLS)"),
+		certificatesEditor.GetContainer(),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Large responses", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("Show at most"), widget.NewLabel("MB of a response body"), maxDisplayEntry),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Protobuf", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Descriptor sets (protoc --include_imports --descriptor_set_out) or .proto files, compiled with protoc"),
+		protoFilesEditor.GetContainer(),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Response times", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Times below the first show green, times from the second red and those between yellow."),
+		container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, widget.NewLabel("Fast below"), widget.NewLabel("ms"), fastEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("Slow from"), widget.NewLabel("ms"), slowEntry),
+		),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("History", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Leave a limit empty to keep everything. Starred requests are never deleted."),
+		container.NewBorder(nil, nil, widget.NewLabel("Keep at most"), widget.NewLabel("entries"), maxEntriesEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("Delete entries older than"), widget.NewLabel("days"), maxDaysEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("Store at most"), widget.NewLabel("KB of each response body"), maxBodyEntry),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Conditional requests", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		autoRevalidateCheck,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Copying", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		copyCredentialsCheck,
+	))
+
+	d := dialog.NewCustomWithoutButtons("Settings", content, parentWindow)
+
+	cancelButton := widget.NewButton("Cancel", d.Hide)
+	saveButton := widget.NewButton("Save", func() {
+		if err := certificatesEditor.Validate(); err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if err := validateHostOverrides(hostOverridesEditor.GetPairs()); err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		maxDisplaySize, err := parseMegabytes(maxDisplayEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		fast, _ := parseNonNegative(fastEntry.Text)
+		slow, _ := parseNonNegative(slowEntry.Text)
+		thresholds := TimeThresholds{FastMs: fast, SlowMs: slow}
+		if err := thresholds.Validate(); err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		var retention HistoryRetention
+		for _, limit := range []struct {
+			entry *widget.Entry
+			value *int
+			name  string
+		}{
+			{maxEntriesEntry, &retention.MaxEntries, "number of history entries"},
+			{maxDaysEntry, &retention.MaxDays, "age of history entries"},
+			{maxBodyEntry, &retention.MaxBodyKB, "stored size of response bodies"},
+		} {
+			n, ok := parseLimit(limit.entry.Text)
+			if !ok {
+				dialog.ShowError(fmt.Errorf("the limit on the %s must be a whole number or empty", limit.name), parentWindow)
+				return
+			}
+			*limit.value = n
+		}
+		settings.Proxy = proxyEditor.GetConfig()
+		settings.ClientCertificates = certificatesEditor.GetCertificates()
+		settings.SkipTLSVerify = skipVerifyCheck.Checked
+		settings.CAFiles = caFilesEditor.GetFiles()
+		settings.UseSystemCAs = systemCAsCheck.Checked
+		settings.DefaultHeaders = defaultHeadersEditor.GetPairs()
+		settings.HostOverrides = hostOverridesEditor.GetPairs()
+		settings.CopyCredentials = copyCredentialsCheck.Checked
+		settings.MaxDisplaySize = maxDisplaySize
+		settings.ProtoFiles = protoFilesEditor.GetFiles()
+		settings.TimeThresholds = thresholds
+		settings.AutoRevalidate = autoRevalidateCheck.Checked
+		settings.HistoryRetention = retention
+		d.Hide()
+		onSave(settings)
+	})
+	saveButton.Importance = widget.HighImportance
+
+	d.SetButtons([]fyne.CanvasObject{cancelButton, saveButton})
+	d.Resize(fyne.NewSize(550, 500))
+	d.Show()
+}
+
+// parseMegabytes reads the display limit for large responses, in MB.
+func parseMegabytes(text string) (int64, error) {
+	megabytes, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || megabytes < 0.1 || megabytes > 1024 {
+		return 0, fmt.Errorf("the display limit for response bodies must be between 0.1 and 1024 MB")
+	}
+	return int64(megabytes * (1 << 20)), nil
+}
+
+// newLimitEntry edits an optional limit, empty when limit is 0 for none.
+func newLimitEntry(limit int, message string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("No limit")
+	if limit > 0 {
+		entry.SetText(strconv.Itoa(limit))
+	}
+	entry.Validator = func(text string) error {
+		if _, ok := parseLimit(text); !ok {
+			return errors.New(message)
+		}
+		return nil
+	}
+	return entry
+}
+
+// parseLimit reads an optional limit, 0 for none when text is empty.
+func parseLimit(text string) (int, bool) {
+	if strings.TrimSpace(text) == "" {
+		return 0, true
+	}
+	return parseNonNegative(text)
+}
+
+// validateHostOverrides checks that every override address is an IP address
+// with an optional port.
+func validateHostOverrides(overrides []KeyValue) error {
+	for _, override := range overrides {
+		address := strings.TrimSpace(override.Value)
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = strings.Trim(address, "[]"), ""
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("the host override for %s needs an IP address, optionally with a port, not %q", override.Key, override.Value)
+		}
+		if n, err := strconv.Atoi(port); port != "" && (err != nil || n < 1 || n > 65535) {
+			return fmt.Errorf("the host override for %s has an invalid port %q", override.Key, port)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SizeBreakdown splits the size of a response into its parts. Headers and
+// StatusLine are counted as they are written in HTTP/1.1; Wire is the body
+// as it was transferred, which differs from Body when Encoding compressed
+// it and is -1 when the transport decompressed it before it could be
+// counted.
+type SizeBreakdown struct {
+	Body       int64  `json:"body"`
+	Wire       int64  `json:"wire"`
+	Encoding   string `json:"encoding,omitempty"`
+	Headers    int64  `json:"headers"`
+	StatusLine int64  `json:"status_line"`
+	Total      int64  `json:"total"`
+	// Transfer is the time the body took to arrive and Throughput the body
+	// bytes on the wire per second over it, 0 when too short to measure
+	Transfer   time.Duration `json:"transfer"`
+	Throughput float64       `json:"throughput"`
+}
+
+// Summary formats the breakdown for the Size label, e.g. "Size: 2.5 KB
+// total, body 8.2 KB (gzip, 2.1 KB on the wire), 1.3 MB/s".
+func (s *SizeBreakdown) Summary() string {
+	text := fmt.Sprintf("Size: %s total, body %s", FormatBytes(s.Total), FormatBytes(s.Body))
+	switch {
+	case s.Encoding == "":
+	case s.Wire < 0:
+		text += fmt.Sprintf(" (%s, decoded by transport)", s.Encoding)
+	default:
+		text += fmt.Sprintf(" (%s, %s on the wire)", s.Encoding, FormatBytes(s.Wire))
+	}
+	if s.Throughput > 0 {
+		text += ", " + FormatBytes(int64(s.Throughput)) + "/s"
+	}
+	return text
+}
+
+// Details lists every part in exact bytes.
+func (s *SizeBreakdown) Details() string {
+	lines := []string{
+		"Status line: " + exactBytes(s.StatusLine),
+		"Headers: " + exactBytes(s.Headers),
+		"Body: " + exactBytes(s.Body),
+	}
+	switch {
+	case s.Encoding == "":
+	case s.Wire < 0:
+		lines = append(lines, fmt.Sprintf("On the wire: unknown, %s was decoded by the transport", s.Encoding))
+	default:
+		lines = append(lines, fmt.Sprintf("On the wire: %s (%s)", exactBytes(s.Wire), s.Encoding))
+	}
+	lines = append(lines, "Total: "+exactBytes(s.Total))
+	if s.Throughput > 0 {
+		lines = append(lines, fmt.Sprintf("Throughput: %s/s (%s in %s)",
+			FormatBytes(int64(s.Throughput)), exactBytes(s.wireBody()), s.Transfer.Round(time.Microsecond)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wireBody is the body size the throughput was measured on.
+func (s *SizeBreakdown) wireBody() int64 {
+	if s.Wire >= 0 {
+		return s.Wire
+	}
+	return s.Body
+}
+
+// exactBytes formats n with thousands separators, e.g. "8,431 bytes".
+func exactBytes(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String() + " bytes"
+}
+
+// SizeHint is an info button for the Size label that expands the size
+// breakdown in exact bytes.
+type SizeHint struct {
+	button       *widget.Button
+	panel        *fyne.Container
+	detailsLabel *widget.Label
+	expanded     bool
+}
+
+func NewSizeHint() *SizeHint {
+	h := &SizeHint{}
+
+	h.detailsLabel = widget.NewLabel("")
+	h.panel = container.NewVBox(
+		widget.NewLabelWithStyle("Response size", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		h.detailsLabel,
+	)
+	h.panel.Hide()
+
+	h.button = widget.NewButtonWithIcon("", theme.InfoIcon(), h.toggle)
+	h.button.Importance = widget.LowImportance
+	h.button.Hide()
+	return h
+}
+
+func (h *SizeHint) toggle() {
+	h.expanded = !h.expanded
+	if h.expanded {
+		h.panel.Show()
+	} else {
+		h.panel.Hide()
+	}
+}
+
+// SetSizes shows sizes, keeping the hint expanded if it was; nil hides the
+// button and the hint, for no response.
+func (h *SizeHint) SetSizes(sizes *SizeBreakdown) {
+	if sizes == nil {
+		h.button.Hide()
+		h.panel.Hide()
+		return
+	}
+
+	h.detailsLabel.SetText(sizes.Details())
+	h.button.Show()
+	if h.expanded {
+		h.panel.Show()
+	}
+}
+
+// GetButton returns the info button, which goes next to the Size label.
+func (h *SizeHint) GetButton() *widget.Button {
+	return h.button
+}
+
+// GetContainer returns the expandable breakdown, which goes below the
+// stats.
+func (h *SizeHint) GetContainer() *fyne.Container {
+	return h.panel
+}
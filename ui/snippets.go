@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	storagefilter "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// snippetPlaceholderPattern matches ${name} and ${name:default} in a
+// snippet; {{name}} stays a variable resolved on send.
+var snippetPlaceholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_-]*)(?::([^}]*))?\}`)
+
+type snippetPlaceholder struct {
+	name         string
+	defaultValue string
+}
+
+// snippetPlaceholders returns the placeholders of body in order, each once;
+// the first default given for a name wins.
+func snippetPlaceholders(body string) []snippetPlaceholder {
+	var placeholders []snippetPlaceholder
+	seen := map[string]bool{}
+	for _, match := range snippetPlaceholderPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			placeholders = append(placeholders, snippetPlaceholder{name: match[1], defaultValue: match[2]})
+		}
+	}
+	return placeholders
+}
+
+// fillSnippet puts values in place of the placeholders of body.
+func fillSnippet(body string, values map[string]string) string {
+	return snippetPlaceholderPattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		return values[snippetPlaceholderPattern.FindStringSubmatch(placeholder)[1]]
+	})
+}
+
+// newSnippetsButton shows the saved snippets in a popup menu; choosing one
+// asks for its placeholders and inserts it into entry at the cursor. The
+// menu also saves the text of entry as a snippet and opens the manager.
+func newSnippetsButton(db *storage.DB, entry *widget.Entry, parentWindow fyne.Window) *widget.Button {
+	var button *widget.Button
+	button = widget.NewButtonWithIcon("Snippets", theme.ContentPasteIcon(), func() {
+		snippets, err := db.GetSnippets()
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+
+		var items []*fyne.MenuItem
+		for _, snippet := range snippets {
+			items = append(items, fyne.NewMenuItem(snippet.Name, func() {
+				insertSnippet(snippet, entry, parentWindow)
+			}))
+		}
+		if len(items) > 0 {
+			items = append(items, fyne.NewMenuItemSeparator())
+		}
+		items = append(items,
+			fyne.NewMenuItem("Save Body as Snippet...", func() {
+				showSaveSnippetDialog(db, entry.Text, parentWindow)
+			}),
+			fyne.NewMenuItem("Manage Snippets...", func() {
+				ShowSnippetsDialog(db, parentWindow)
+			}),
+		)
+
+		canvas := fyne.CurrentApp().Driver().CanvasForObject(button)
+		widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), canvas,
+			fyne.NewPos(0, button.Size().Height), button)
+	})
+	return button
+}
+
+// insertSnippet inserts snippet into entry, first asking for the values of
+// its placeholders if it has any.
+func insertSnippet(snippet *storage.Snippet, entry *widget.Entry, parentWindow fyne.Window) {
+	placeholders := snippetPlaceholders(snippet.Body)
+	if len(placeholders) == 0 {
+		insertAtCursor(entry, snippet.Body)
+		return
+	}
+
+	entries := make([]*widget.Entry, len(placeholders))
+	items := make([]*widget.FormItem, len(placeholders))
+	for i, placeholder := range placeholders {
+		entries[i] = widget.NewEntry()
+		entries[i].SetText(placeholder.defaultValue)
+		items[i] = widget.NewFormItem(placeholder.name, entries[i])
+	}
+
+	d := dialog.NewForm("Insert "+snippet.Name, "Insert", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		values := map[string]string{}
+		for i, placeholder := range placeholders {
+			values[placeholder.name] = entries[i].Text
+		}
+		insertAtCursor(entry, fillSnippet(snippet.Body, values))
+	}, parentWindow)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+}
+
+func showSaveSnippetDialog(db *storage.DB, body string, parentWindow fyne.Window) {
+	if strings.TrimSpace(body) == "" {
+		dialog.ShowInformation("Save Snippet", "The body is empty", parentWindow)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. User object")
+	nameEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return errors.New("enter a name")
+		}
+		return nil
+	}
+	hint := widget.NewLabel("Write ${name} or ${name:default} in the body to be asked for a value on insert. " +
+		"A snippet with the same name is replaced.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewForm("Save Snippet", "Save", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("", hint),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := db.SaveSnippet(strings.TrimSpace(nameEntry.Text), body); err != nil {
+				dialog.ShowError(err, parentWindow)
+			}
+		}, parentWindow)
+	d.Resize(fyne.NewSize(450, 250))
+	d.Show()
+}
+
+// ShowSnippetsDialog lists the saved snippets with a preview of the selected
+// one, to delete them or share them as a JSON file.
+func ShowSnippetsDialog(db *storage.DB, parentWindow fyne.Window) {
+	var snippets []*storage.Snippet
+	selected := -1
+
+	preview := widget.NewMultiLineEntry()
+	preview.TextStyle = fyne.TextStyle{Monospace: true}
+	preview.Disable()
+
+	list := widget.NewList(
+		func() int {
+			return len(snippets)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Snippet name")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(snippets[i].Name)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		preview.SetText(snippets[id].Body)
+	}
+
+	reload := func() {
+		var err error
+		if snippets, err = db.GetSnippets(); err != nil {
+			dialog.ShowError(err, parentWindow)
+		}
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+		preview.SetText("")
+	}
+	reload()
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		if selected < 0 || selected >= len(snippets) {
+			return
+		}
+		snippet := snippets[selected]
+		dialog.ShowConfirm("Delete Snippet", fmt.Sprintf("Delete %q?", snippet.Name), func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := db.DeleteSnippet(snippet.ID); err != nil {
+				dialog.ShowError(err, parentWindow)
+			}
+			reload()
+		}, parentWindow)
+	})
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if err := db.ExportSnippets(writer.URI().Path()); err != nil {
+				dialog.ShowError(err, parentWindow)
+			} else {
+				dialog.ShowInformation("Success", "Snippets exported successfully", parentWindow)
+			}
+		}, parentWindow)
+		save.SetFileName("snippets.json")
+		save.Show()
+	})
+
+	importButton := widget.NewButtonWithIcon("Import", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+
+			count, err := db.ImportSnippets(path)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("import failed: %w", err), parentWindow)
+				return
+			}
+			reload()
+			dialog.ShowInformation("Success", fmt.Sprintf("Imported %d snippet(s)", count), parentWindow)
+		}, parentWindow)
+		open.SetFilter(storagefilter.NewExtensionFileFilter([]string{".json"}))
+		open.Show()
+	})
+
+	content := container.NewBorder(
+		nil,
+		container.NewHBox(deleteButton, exportButton, importButton),
+		nil,
+		nil,
+		container.NewHSplit(list, preview),
+	)
+
+	d := dialog.NewCustom("Snippets", "Close", content, parentWindow)
+	d.Resize(fyne.NewSize(800, 500))
+	d.Show()
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// StatusCodeInfo explains a registered HTTP status code and names the RFC
+// section that defines it.
+type StatusCodeInfo struct {
+	Code        int
+	Name        string
+	Explanation string
+	RFC         int
+	Section     string
+}
+
+// URL links to the section of the RFC that defines the code.
+func (s StatusCodeInfo) URL() string {
+	return fmt.Sprintf("https://www.rfc-editor.org/rfc/rfc%d#section-%s", s.RFC, s.Section)
+}
+
+// statusCodes are the codes in the IANA HTTP Status Code Registry.
+var statusCodes = []StatusCodeInfo{
+	{100, "Continue", "The server has received the request headers and the client should go on sending the body. It answers a request sent with Expect: 100-continue, so a large body is not sent to a server that would refuse it.", 9110, "15.2.1"},
+	{101, "Switching Protocols", "The server agrees to the Upgrade header of the request and switches the connection to another protocol, such as WebSocket, right after this response.", 9110, "15.2.2"},
+	{102, "Processing", "A WebDAV server has accepted the request and is still working on it, so the client should not time out. It is deprecated and rarely sent.", 2518, "10.1"},
+	{103, "Early Hints", "Sent before the final response with Link headers the client can start preloading, such as stylesheets and scripts, while the server prepares the response.", 8297, "2"},
+	{200, "OK", "The request succeeded. What the body holds depends on the method: the resource for GET, the result of the action for POST.", 9110, "15.3.1"},
+	{201, "Created", "The request succeeded and created a new resource, usually found at the URL in the Location header.", 9110, "15.3.2"},
+	{202, "Accepted", "The request was accepted for processing, which has not finished and may still fail. Often used for jobs that run in the background, with a URL to check on them.", 9110, "15.3.3"},
+	{203, "Non-Authoritative Information", "The request succeeded, but a transforming proxy changed the response it got from the origin server.", 9110, "15.3.4"},
+	{204, "No Content", "The request succeeded and there is deliberately no body, as for a DELETE or a PUT that needs no answer.", 9110, "15.3.5"},
+	{205, "Reset Content", "The request succeeded and the client should reset the document it was sent from, such as clearing a form.", 9110, "15.3.6"},
+	{206, "Partial Content", "The server sends only the part of the resource asked for with a Range header; Content-Range says which part.", 9110, "15.3.7"},
+	{207, "Multi-Status", "A WebDAV response whose XML body holds a separate status for each of several resources the request acted on.", 4918, "11.1"},
+	{208, "Already Reported", "Used inside a WebDAV Multi-Status body for members of a collection that were already listed, so they are not repeated.", 5842, "7.1"},
+	{226, "IM Used", "The server applied the instance manipulations the request asked for with A-IM, such as sending only a delta from a cached version.", 3229, "10.4.1"},
+	{300, "Multiple Choices", "The resource has several representations, such as languages or formats, and the client should pick one. Rarely used, as servers usually choose themselves.", 9110, "15.4.1"},
+	{301, "Moved Permanently", "The resource has a new permanent URL, given in Location. Clients should use it from now on; browsers may turn a POST into a GET when following it.", 9110, "15.4.2"},
+	{302, "Found", "The resource is temporarily at the URL in Location. Browsers usually follow it with a GET whatever the method was; use 307 to keep the method.", 9110, "15.4.3"},
+	{303, "See Other", "The result is at another URL, given in Location, to be fetched with GET. Typically sent after a POST so that reloading does not submit it again.", 9110, "15.4.4"},
+	{304, "Not Modified", "The client's cached copy is still current, so there is no body. It answers a conditional GET or HEAD with If-None-Match or If-Modified-Since.", 9110, "15.4.5"},
+	{305, "Use Proxy", "Said the resource must be accessed through the proxy in Location. Deprecated for security reasons and not followed by clients.", 9110, "15.4.6"},
+	{306, "(Unused)", "Was used in an earlier draft of HTTP and is now reserved. A server should not send it.", 9110, "15.4.7"},
+	{307, "Temporary Redirect", "The resource is temporarily at the URL in Location, and the request must be repeated there with the same method and body.", 9110, "15.4.8"},
+	{308, "Permanent Redirect", "The resource has a new permanent URL, given in Location, and the request must be repeated there with the same method and body.", 9110, "15.4.9"},
+	{400, "Bad Request", "The server cannot process the request because of a client error, such as malformed syntax, an invalid body or a missing parameter. The body often says what is wrong.", 9110, "15.5.1"},
+	{401, "Unauthorized", "The request lacks valid credentials. The WWW-Authenticate header says how to authenticate; sending the request again with credentials may succeed.", 9110, "15.5.2"},
+	{402, "Payment Required", "Reserved for future use. Some APIs send it when a payment or subscription is needed, without a standard meaning.", 9110, "15.5.3"},
+	{403, "Forbidden", "The server understood the request and knows who is asking, but refuses it. Unlike 401, authenticating again will not help.", 9110, "15.5.4"},
+	{404, "Not Found", "There is nothing at this URL, or the server will not say there is. It may exist later; 410 says it is gone for good.", 9110, "15.5.5"},
+	{405, "Method Not Allowed", "The resource exists but does not support this method. The Allow header lists the methods it does support.", 9110, "15.5.6"},
+	{406, "Not Acceptable", "The server has no representation matching the Accept, Accept-Language or Accept-Encoding headers of the request.", 9110, "15.5.7"},
+	{407, "Proxy Authentication Required", "Like 401, but the proxy between the client and the server wants credentials, as Proxy-Authenticate says.", 9110, "15.5.8"},
+	{408, "Request Timeout", "The server gave up waiting for the client to finish sending the request, and usually closes the connection. The request can be sent again.", 9110, "15.5.9"},
+	{409, "Conflict", "The request conflicts with the current state of the resource, such as an edit based on an outdated version or a duplicate unique value.", 9110, "15.5.10"},
+	{410, "Gone", "The resource was here but has been removed for good, with no forwarding address. Clients should remove links to it.", 9110, "15.5.11"},
+	{411, "Length Required", "The server refuses a request body without a Content-Length header.", 9110, "15.5.12"},
+	{412, "Precondition Failed", "A condition in the request headers, such as If-Match or If-Unmodified-Since, is false, so the request was not carried out. Used to prevent lost updates.", 9110, "15.5.13"},
+	{413, "Content Too Large", "The request body is larger than the server is willing to process. Retry-After may say when to try again if the limit is temporary.", 9110, "15.5.14"},
+	{414, "URI Too Long", "The URL is longer than the server is willing to interpret, often because a large query string should have been a POST body.", 9110, "15.5.15"},
+	{415, "Unsupported Media Type", "The server does not accept the format of the request body, as given by Content-Type or Content-Encoding.", 9110, "15.5.16"},
+	{416, "Range Not Satisfiable", "None of the ranges asked for with the Range header overlap the resource, for example a range past its end.", 9110, "15.5.17"},
+	{417, "Expectation Failed", "The server cannot meet the Expect header of the request, such as Expect: 100-continue.", 9110, "15.5.18"},
+	{418, "(Unused)", "Reserved since \"I'm a teapot\" from the HTCPCP April Fools' RFC, which some servers still send as a joke. It has no meaning in HTTP.", 9110, "15.5.19"},
+	{421, "Misdirected Request", "The request reached a server that cannot answer for this host and scheme, typically when a reused HTTP/2 connection was for another host. Retrying on a new connection usually works.", 9110, "15.5.20"},
+	{422, "Unprocessable Content", "The request is well formed and its Content-Type is supported, but its content is semantically invalid, such as JSON that fails validation. The body usually lists the errors.", 9110, "15.5.21"},
+	{423, "Locked", "The WebDAV resource is locked, so it cannot be changed.", 4918, "11.3"},
+	{424, "Failed Dependency", "The WebDAV request failed because another action it depended on failed.", 4918, "11.4"},
+	{425, "Too Early", "The server will not risk processing a request sent in TLS early data, as it could be replayed. The client should send it again after the handshake.", 8470, "5.2"},
+	{426, "Upgrade Required", "The server refuses the request over the current protocol, and the Upgrade header says which protocol to switch to, such as a newer TLS or HTTP version.", 9110, "15.5.22"},
+	{428, "Precondition Required", "The server requires the request to be conditional, with If-Match for example, so that clients cannot overwrite changes they have not seen.", 6585, "3"},
+	{429, "Too Many Requests", "The client sent too many requests in a given time and is being rate limited. Retry-After says how long to wait.", 6585, "4"},
+	{431, "Request Header Fields Too Large", "A header, or all of them together, is too large for the server; often a cookie that has grown too big.", 6585, "5"},
+	{451, "Unavailable For Legal Reasons", "The server is denying access to the resource because of a legal demand, such as a court order or government censorship. The body should say who made the demand.", 7725, "3"},
+	{500, "Internal Server Error", "The server hit an unexpected condition that kept it from answering, often an unhandled error in the application. Its logs say more than the response.", 9110, "15.6.1"},
+	{501, "Not Implemented", "The server does not support the functionality needed, typically a method it does not recognize.", 9110, "15.6.2"},
+	{502, "Bad Gateway", "A gateway or proxy got an invalid response from the server behind it, which may be down or crashing.", 9110, "15.6.3"},
+	{503, "Service Unavailable", "The server cannot handle the request for now, because it is overloaded or down for maintenance. Retry-After may say when to try again.", 9110, "15.6.4"},
+	{504, "Gateway Timeout", "A gateway or proxy did not get a response in time from the server behind it.", 9110, "15.6.5"},
+	{505, "HTTP Version Not Supported", "The server does not support the HTTP version of the request.", 9110, "15.6.6"},
+	{506, "Variant Also Negotiates", "The server's content negotiation is misconfigured: the variant it chose is itself set up to negotiate.", 2295, "8.1"},
+	{507, "Insufficient Storage", "The WebDAV server cannot store what it needs to complete the request.", 4918, "11.5"},
+	{508, "Loop Detected", "The WebDAV server found an infinite loop while processing a request with Depth: infinity.", 5842, "7.2"},
+	{510, "Not Extended", "The request does not meet the policy for accessing the resource, from the HTTP Extension Framework. The RFC is historic and the code is obsolete.", 2774, "7"},
+	{511, "Network Authentication Required", "The network, typically a captive portal in a hotel or airport, wants the client to sign in before it gets access.", 6585, "6"},
+}
+
+// statusClasses explain each class of status code, for codes that are not
+// registered.
+var statusClasses = map[int]string{
+	1: "informational: the request was received and is being processed",
+	2: "success: the request was received, understood and accepted",
+	3: "redirection: the client must take further action to complete the request",
+	4: "client error: the request is wrong or cannot be fulfilled",
+	5: "server error: the server failed to fulfil a valid request",
+}
+
+// LookupStatusCode returns the explanation of a registered status code.
+func LookupStatusCode(code int) (StatusCodeInfo, bool) {
+	for _, info := range statusCodes {
+		if info.Code == code {
+			return info, true
+		}
+	}
+	return StatusCodeInfo{}, false
+}
+
+// describeUnknownStatus says that a code is not registered, and what its
+// class means when it has one.
+func describeUnknownStatus(code int) string {
+	text := fmt.Sprintf("%d is not a registered HTTP status code, so its meaning is specific to this server or the software in front of it.", code)
+	if class, ok := statusClasses[code/100]; ok {
+		text += fmt.Sprintf(" Its class, %dxx, is %s.", code/100, class)
+	}
+	return text
+}
+
+// StatusHint is an info button for the Status label that expands a hint
+// with the name of the response status code, what it means and a link to
+// the RFC that defines it.
+type StatusHint struct {
+	button     *widget.Button
+	panel      *fyne.Container
+	titleLabel *widget.Label
+	textLabel  *widget.Label
+	rfcLink    *widget.Hyperlink
+	expanded   bool
+}
+
+func NewStatusHint() *StatusHint {
+	h := &StatusHint{}
+
+	h.titleLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	h.textLabel = widget.NewLabel("")
+	h.textLabel.Wrapping = fyne.TextWrapWord
+	h.rfcLink = widget.NewHyperlink("", nil)
+	h.panel = container.NewVBox(h.titleLabel, h.textLabel, h.rfcLink)
+	h.panel.Hide()
+
+	h.button = widget.NewButtonWithIcon("", theme.InfoIcon(), h.toggle)
+	h.button.Importance = widget.LowImportance
+	h.button.Hide()
+	return h
+}
+
+func (h *StatusHint) toggle() {
+	h.expanded = !h.expanded
+	if h.expanded {
+		h.panel.Show()
+	} else {
+		h.panel.Hide()
+	}
+}
+
+// SetStatus explains code, keeping the hint expanded if it was; 0 hides
+// the button and the hint, for no response.
+func (h *StatusHint) SetStatus(code int) {
+	if code == 0 {
+		h.button.Hide()
+		h.panel.Hide()
+		return
+	}
+
+	if info, ok := LookupStatusCode(code); ok {
+		h.titleLabel.SetText(fmt.Sprintf("%d %s", info.Code, info.Name))
+		h.textLabel.SetText(info.Explanation)
+		h.rfcLink.SetText(fmt.Sprintf("RFC %d, section %s", info.RFC, info.Section))
+		link, _ := url.Parse(info.URL())
+		h.rfcLink.SetURL(link)
+		h.rfcLink.Show()
+	} else {
+		h.titleLabel.SetText(fmt.Sprintf("%d (not registered)", code))
+		h.textLabel.SetText(describeUnknownStatus(code))
+		h.rfcLink.Hide()
+	}
+	h.button.Show()
+	if h.expanded {
+		h.panel.Show()
+	}
+}
+
+// GetButton returns the info button, which goes next to the Status label.
+func (h *StatusHint) GetButton() *widget.Button {
+	return h.button
+}
+
+// GetContainer returns the expandable hint, which goes below the status.
+func (h *StatusHint) GetContainer() *fyne.Container {
+	return h.panel
+}
@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const suggestLimit = 8
+
+// SuggestEntry is an entry that suggests completions from a fixed list while
+// the user types; Up and Down move through them, Enter or a click picks one
+// and anything else is typed as usual. With a Separator, only the text after
+// its last occurrence is completed, e.g. one directive of a Cache-Control
+// list.
+type SuggestEntry struct {
+	widget.Entry
+
+	// Candidates returns the completions to offer; it is called when the
+	// entry gains focus
+	Candidates func() []string
+	Separator  string
+
+	candidates  []string
+	popup       *widget.PopUp
+	list        *suggestEntryList
+	suggestions []string
+	selected    int
+	navigating  bool
+}
+
+// suggestEntryList takes keyboard focus while the popup is open, since the
+// popup is an overlay, and passes the keys on to the entry.
+type suggestEntryList struct {
+	widget.List
+	entry *SuggestEntry
+}
+
+func NewSuggestEntry(candidates func() []string) *SuggestEntry {
+	e := &SuggestEntry{Candidates: candidates, selected: -1}
+	e.ExtendBaseWidget(e)
+
+	e.list = &suggestEntryList{entry: e}
+	e.list.Length = func() int {
+		return len(e.suggestions)
+	}
+	e.list.CreateItem = func() fyne.CanvasObject {
+		label := widget.NewLabel("")
+		label.Truncation = fyne.TextTruncateEllipsis
+		return label
+	}
+	e.list.UpdateItem = func(id widget.ListItemID, item fyne.CanvasObject) {
+		item.(*widget.Label).SetText(e.suggestions[id])
+	}
+	e.list.OnSelected = func(id widget.ListItemID) {
+		if !e.navigating {
+			e.choose(id)
+		}
+	}
+	e.list.ExtendBaseWidget(e.list)
+	return e
+}
+
+func (e *SuggestEntry) FocusGained() {
+	e.Entry.FocusGained()
+	// Focus comes back from the list while it is open
+	if e.Candidates != nil && (e.popup == nil || !e.popup.Visible()) {
+		e.candidates = e.Candidates()
+	}
+}
+
+func (e *SuggestEntry) TypedRune(r rune) {
+	e.Entry.TypedRune(r)
+	e.updateSuggestions()
+}
+
+func (e *SuggestEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.popup != nil && e.popup.Visible() {
+		switch key.Name {
+		case fyne.KeyDown:
+			e.move(1)
+			return
+		case fyne.KeyUp:
+			e.move(-1)
+			return
+		case fyne.KeyReturn, fyne.KeyEnter:
+			if e.selected >= 0 {
+				e.choose(e.selected)
+				return
+			}
+			e.hideSuggestions()
+		case fyne.KeyEscape:
+			e.hideSuggestions()
+			return
+		}
+	}
+
+	e.Entry.TypedKey(key)
+	if key.Name == fyne.KeyBackspace || key.Name == fyne.KeyDelete {
+		e.updateSuggestions()
+	}
+}
+
+// completing returns the text before the part being completed and that part.
+func (e *SuggestEntry) completing() (prefix, word string) {
+	if e.Separator != "" {
+		if i := strings.LastIndex(e.Text, e.Separator); i >= 0 {
+			return e.Text[:i+len(e.Separator)] + " ", strings.TrimSpace(e.Text[i+len(e.Separator):])
+		}
+	}
+	return "", strings.TrimSpace(e.Text)
+}
+
+// updateSuggestions offers the candidates starting with the word being
+// typed, then those containing it, ignoring case.
+func (e *SuggestEntry) updateSuggestions() {
+	_, word := e.completing()
+	if word == "" {
+		e.hideSuggestions()
+		return
+	}
+
+	lower := strings.ToLower(word)
+	var starting, containing []string
+	for _, candidate := range e.candidates {
+		candidateLower := strings.ToLower(candidate)
+		switch {
+		case candidateLower == lower:
+			// Nothing left to complete
+		case strings.HasPrefix(candidateLower, lower):
+			starting = append(starting, candidate)
+		case strings.Contains(candidateLower, lower):
+			containing = append(containing, candidate)
+		}
+	}
+	suggestions := append(starting, containing...)
+	if len(suggestions) > suggestLimit {
+		suggestions = suggestions[:suggestLimit]
+	}
+	e.showSuggestions(suggestions)
+}
+
+func (e *SuggestEntry) showSuggestions(suggestions []string) {
+	canvas := fyne.CurrentApp().Driver().CanvasForObject(e)
+	if len(suggestions) == 0 || canvas == nil {
+		e.hideSuggestions()
+		return
+	}
+	if e.popup == nil {
+		e.popup = widget.NewPopUp(e.list, canvas)
+	}
+
+	e.suggestions = suggestions
+	e.selected = -1
+	e.list.UnselectAll()
+	e.list.Refresh()
+	e.list.ScrollToTop()
+
+	rowHeight := widget.NewLabel("").MinSize().Height + theme.Padding()
+	e.popup.Resize(fyne.NewSize(max(e.Size().Width, 250), rowHeight*float32(len(suggestions))))
+
+	position := fyne.CurrentApp().Driver().AbsolutePositionForObject(e)
+	e.popup.ShowAtPosition(position.AddXY(0, e.Size().Height))
+	canvas.Focus(e.list)
+}
+
+func (e *SuggestEntry) hideSuggestions() {
+	if e.popup != nil && e.popup.Visible() {
+		e.popup.Hide()
+		if canvas := fyne.CurrentApp().Driver().CanvasForObject(e); canvas != nil {
+			canvas.Focus(e)
+		}
+	}
+}
+
+func (e *SuggestEntry) move(delta int) {
+	next := e.selected + delta
+	if next < 0 || next >= len(e.suggestions) {
+		return
+	}
+	e.selected = next
+
+	e.navigating = true
+	e.list.Select(next)
+	e.navigating = false
+}
+
+func (e *SuggestEntry) choose(id widget.ListItemID) {
+	if id < 0 || id >= len(e.suggestions) {
+		return
+	}
+	prefix, _ := e.completing()
+	text := prefix + e.suggestions[id]
+
+	e.hideSuggestions()
+	e.SetText(text)
+	e.CursorColumn = len([]rune(text))
+	e.Refresh()
+}
+
+func (l *suggestEntryList) FocusGained() {}
+
+func (l *suggestEntryList) FocusLost() {}
+
+func (l *suggestEntryList) TypedRune(r rune) {
+	l.entry.TypedRune(r)
+}
+
+func (l *suggestEntryList) TypedKey(key *fyne.KeyEvent) {
+	l.entry.TypedKey(key)
+}
@@ -0,0 +1,281 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	AssertStatus   = "status"
+	AssertHeader   = "header"
+	AssertJSONPath = "jsonpath"
+	AssertBody     = "body"
+	AssertTime     = "time"
+)
+
+var assertionTypeLabels = []struct {
+	assertionType string
+	label         string
+	targetHint    string
+}{
+	{AssertStatus, "Status code", ""},
+	{AssertHeader, "Header", "Header name"},
+	{AssertJSONPath, "JSON path", "e.g. $.items.length"},
+	{AssertBody, "Body", ""},
+	{AssertTime, "Response time (ms)", ""},
+}
+
+const (
+	OperatorEquals         = "equals"
+	OperatorNotEquals      = "not equals"
+	OperatorContains       = "contains"
+	OperatorNotContains    = "not contains"
+	OperatorLess           = "<"
+	OperatorLessOrEqual    = "<="
+	OperatorGreater        = ">"
+	OperatorGreaterOrEqual = ">="
+	OperatorExists         = "exists"
+	OperatorMatches        = "matches"
+)
+
+var assertionOperators = []string{
+	OperatorEquals,
+	OperatorNotEquals,
+	OperatorContains,
+	OperatorNotContains,
+	OperatorLess,
+	OperatorLessOrEqual,
+	OperatorGreater,
+	OperatorGreaterOrEqual,
+	OperatorExists,
+	OperatorMatches,
+}
+
+// Assertion is a check run against the response after each send, e.g.
+// status equals 200.
+type Assertion struct {
+	Type     string `json:"type"`
+	Target   string `json:"target,omitempty"`
+	Operator string `json:"operator"`
+	Expected string `json:"expected,omitempty"`
+}
+
+func (a Assertion) String() string {
+	subject := a.Type
+	for _, t := range assertionTypeLabels {
+		if t.assertionType == a.Type {
+			subject = t.label
+		}
+	}
+	if a.Target != "" {
+		subject += " " + a.Target
+	}
+	if a.Operator == OperatorExists {
+		return subject + " exists"
+	}
+	return fmt.Sprintf("%s %s %s", subject, a.Operator, a.Expected)
+}
+
+// AssertionResult is the outcome of an assertion; Actual is the value
+// checked and Message explains a failure.
+type AssertionResult struct {
+	Assertion Assertion `json:"assertion"`
+	Passed    bool      `json:"passed"`
+	Actual    string    `json:"actual,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// TestsEditor edits the assertions of a request and shows a pass or fail
+// badge next to each after a send.
+type TestsEditor struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+	rows      []*assertionRow
+}
+
+type assertionRow struct {
+	typeSelect     *widget.Select
+	targetEntry    *widget.Entry
+	operatorSelect *widget.Select
+	expectedEntry  *widget.Entry
+	resultLabel    *widget.Label
+	container      *fyne.Container
+}
+
+func NewTestsEditor() *TestsEditor {
+	e := &TestsEditor{}
+
+	e.rowsBox = container.NewVBox()
+	addButton := widget.NewButtonWithIcon("Add Assertion", theme.ContentAddIcon(), func() {
+		e.addRow(Assertion{Type: AssertStatus, Operator: OperatorEquals, Expected: "200"})
+		e.changed()
+	})
+
+	e.container = container.NewBorder(
+		widget.NewLabel("Assertions are checked against the response after each send."),
+		container.NewHBox(addButton),
+		nil,
+		nil,
+		container.NewVScroll(e.rowsBox),
+	)
+
+	return e
+}
+
+func (e *TestsEditor) addRow(assertion Assertion) {
+	row := &assertionRow{
+		targetEntry:   widget.NewEntry(),
+		expectedEntry: widget.NewEntry(),
+		resultLabel:   widget.NewLabel(""),
+	}
+
+	labels := make([]string, len(assertionTypeLabels))
+	for i, t := range assertionTypeLabels {
+		labels[i] = t.label
+	}
+	row.typeSelect = widget.NewSelect(labels, func(label string) {
+		for _, t := range assertionTypeLabels {
+			if t.label == label {
+				row.targetEntry.SetPlaceHolder(t.targetHint)
+				if t.targetHint == "" {
+					row.targetEntry.Disable()
+				} else {
+					row.targetEntry.Enable()
+				}
+			}
+		}
+		e.changed()
+	})
+	row.operatorSelect = widget.NewSelect(assertionOperators, func(operator string) {
+		if operator == OperatorExists {
+			row.expectedEntry.Disable()
+		} else {
+			row.expectedEntry.Enable()
+		}
+		e.changed()
+	})
+	row.expectedEntry.SetPlaceHolder("Expected")
+
+	for _, t := range assertionTypeLabels {
+		if t.assertionType == assertion.Type {
+			row.typeSelect.SetSelected(t.label)
+		}
+	}
+	row.targetEntry.SetText(assertion.Target)
+	row.operatorSelect.SetSelected(assertion.Operator)
+	row.expectedEntry.SetText(assertion.Expected)
+
+	row.targetEntry.OnChanged = func(string) { e.changed() }
+	row.expectedEntry.OnChanged = func(string) { e.changed() }
+
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.removeRow(row)
+	})
+
+	row.container = container.NewBorder(nil, nil, nil,
+		container.NewHBox(row.resultLabel, removeButton),
+		container.NewGridWithColumns(4, row.typeSelect, row.targetEntry, row.operatorSelect, row.expectedEntry),
+	)
+
+	e.rows = append(e.rows, row)
+	e.rowsBox.Add(row.container)
+}
+
+func (e *TestsEditor) removeRow(row *assertionRow) {
+	for i, r := range e.rows {
+		if r == row {
+			e.rows = append(e.rows[:i], e.rows[i+1:]...)
+			break
+		}
+	}
+	e.rowsBox.Remove(row.container)
+	e.changed()
+}
+
+// changed clears the badges, which no longer match the assertions.
+func (e *TestsEditor) changed() {
+	for _, row := range e.rows {
+		row.resultLabel.SetText("")
+	}
+}
+
+func (e *TestsEditor) GetAssertions() []Assertion {
+	assertions := make([]Assertion, 0, len(e.rows))
+	for _, row := range e.rows {
+		assertion := Assertion{
+			Operator: row.operatorSelect.Selected,
+			Expected: row.expectedEntry.Text,
+		}
+		for _, t := range assertionTypeLabels {
+			if t.label == row.typeSelect.Selected {
+				assertion.Type = t.assertionType
+				if t.targetHint != "" {
+					assertion.Target = row.targetEntry.Text
+				}
+			}
+		}
+		if assertion.Operator == OperatorExists {
+			assertion.Expected = ""
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions
+}
+
+func (e *TestsEditor) SetAssertions(assertions []Assertion) {
+	e.rows = nil
+	e.rowsBox.RemoveAll()
+	for _, assertion := range assertions {
+		e.addRow(assertion)
+	}
+	e.rowsBox.Refresh()
+}
+
+// SetResults shows the badge of each assertion; results are in the order
+// of GetAssertions. nil clears the badges.
+func (e *TestsEditor) SetResults(results []AssertionResult) {
+	for i, row := range e.rows {
+		if i >= len(results) {
+			row.resultLabel.SetText("")
+			continue
+		}
+		result := results[i]
+		if result.Passed {
+			row.resultLabel.SetText("✓ Pass")
+			row.resultLabel.Importance = widget.SuccessImportance
+		} else {
+			row.resultLabel.SetText("✗ " + result.Message)
+			row.resultLabel.Importance = widget.DangerImportance
+		}
+		row.resultLabel.Refresh()
+	}
+}
+
+func (e *TestsEditor) GetContainer() *fyne.Container {
+	return e.container
+}
+
+// AllPassed reports whether every assertion passed.
+func AllPassed(results []AssertionResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// SummarizeResults describes results in one line, e.g. "Tests: 3/4 passed".
+func SummarizeResults(results []AssertionResult) string {
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	return fmt.Sprintf("Tests: %d/%d passed", passed, len(results))
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// TimingPhase is one phase of a request, Start being its offset from the
+// beginning of the request.
+type TimingPhase struct {
+	Name     string
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// TimingView shows where the time of a request went, one row per phase with
+// a bar placed on a common time axis.
+type TimingView struct {
+	container *fyne.Container
+	rowsBox   *fyne.Container
+}
+
+func NewTimingView() *TimingView {
+	v := &TimingView{rowsBox: container.NewVBox()}
+	v.container = container.NewStack(container.NewVScroll(v.rowsBox))
+	v.SetTiming(nil, 0, false)
+	return v
+}
+
+// SetTiming shows phases, which end by total at the latest. reused tells
+// that the connection was reused, skipping the phases before sending. No
+// phases clears the view.
+func (v *TimingView) SetTiming(phases []TimingPhase, total time.Duration, reused bool) {
+	v.rowsBox.RemoveAll()
+	if len(phases) == 0 {
+		v.rowsBox.Add(widget.NewLabel("No timing for this response"))
+		v.rowsBox.Refresh()
+		return
+	}
+
+	for _, phase := range phases {
+		total = max(total, phase.Start+phase.Duration)
+	}
+
+	grid := container.NewGridWithColumns(3)
+	for _, phase := range phases {
+		bar := canvas.NewRectangle(theme.Color(theme.ColorNamePrimary))
+		var from, to float32
+		if total > 0 {
+			from = float32(phase.Start) / float32(total)
+			to = float32(phase.Start+phase.Duration) / float32(total)
+		}
+		durationLabel := widget.NewLabel(formatDuration(phase.Duration))
+		durationLabel.Alignment = fyne.TextAlignTrailing
+		grid.Add(widget.NewLabel(phase.Name))
+		grid.Add(container.New(&timingBarLayout{from: from, to: to}, bar))
+		grid.Add(durationLabel)
+	}
+	v.rowsBox.Add(grid)
+
+	totalLabel := widget.NewLabel("Total: " + formatDuration(total))
+	totalLabel.TextStyle = fyne.TextStyle{Bold: true}
+	v.rowsBox.Add(totalLabel)
+	if reused {
+		reusedLabel := widget.NewLabel("The connection was reused, so there was no DNS lookup, connect or TLS handshake")
+		reusedLabel.Wrapping = fyne.TextWrapWord
+		reusedLabel.Importance = widget.HighImportance
+		v.rowsBox.Add(reusedLabel)
+	}
+	v.rowsBox.Refresh()
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2f ms", float64(d.Microseconds())/1000)
+}
+
+func (v *TimingView) GetContainer() *fyne.Container {
+	return v.container
+}
+
+// timingBarLayout places its object between the fractions from and to of
+// the available width, vertically centred. A phase that took no time is
+// still shown as a sliver.
+type timingBarLayout struct {
+	from, to float32
+}
+
+func (l *timingBarLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	height := size.Height / 2
+	width := max((l.to-l.from)*size.Width, 1)
+	for _, object := range objects {
+		object.Resize(fyne.NewSize(width, height))
+		object.Move(fyne.NewPos(l.from*size.Width, (size.Height-height)/2))
+	}
+}
+
+func (l *timingBarLayout) MinSize([]fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(100, theme.TextSize())
+}
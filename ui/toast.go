@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// toastDelay is how long a toast stays up.
+const toastDelay = 4 * time.Second
+
+// undoDelay is how long an undo toast stays up.
+const undoDelay = 8 * time.Second
+
+// ShowToast shows message at the bottom of the window for a few seconds,
+// without taking the focus or waiting to be dismissed.
+func ShowToast(message string, parentWindow fyne.Window) {
+	label := widget.NewLabel(message)
+	label.Truncation = fyne.TextTruncateEllipsis
+	content := container.NewBorder(nil, nil, widget.NewIcon(theme.ConfirmIcon()), nil, label)
+	toast := showToast(content, parentWindow)
+
+	time.AfterFunc(toastDelay, func() {
+		fyne.Do(toast.Hide)
+	})
+}
+
+// ShowUndoToast shows message with an Undo button at the bottom of the
+// window for a few seconds; onUndo is called if it is pressed. The toast is
+// returned so that a newer one can replace it.
+func ShowUndoToast(message string, onUndo func(), parentWindow fyne.Window) *widget.PopUp {
+	label := widget.NewLabel(message)
+	label.Truncation = fyne.TextTruncateEllipsis
+	var toast *widget.PopUp
+	undoButton := widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), func() {
+		toast.Hide()
+		onUndo()
+	})
+	content := container.NewBorder(nil, nil, widget.NewIcon(theme.DeleteIcon()), undoButton, label)
+	toast = showToast(content, parentWindow)
+
+	time.AfterFunc(undoDelay, func() {
+		fyne.Do(toast.Hide)
+	})
+	return toast
+}
+
+// showToast shows content centred at the bottom of the window.
+func showToast(content fyne.CanvasObject, parentWindow fyne.Window) *widget.PopUp {
+	toast := widget.NewPopUp(content, parentWindow.Canvas())
+
+	canvasSize := parentWindow.Canvas().Size()
+	size := toast.MinSize()
+	size.Width = min(size.Width, canvasSize.Width-2*theme.Padding())
+	toast.Resize(size)
+	toast.ShowAtPosition(fyne.NewPos((canvasSize.Width-size.Width)/2, canvasSize.Height-size.Height-4*theme.Padding()))
+	return toast
+}
@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"fmt"
+	"golem/storage"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	urlSuggestionLimit = 8
+	// urlLookupDelay debounces the history lookup while the user types
+	urlLookupDelay = 250 * time.Millisecond
+)
+
+// URLEntry is the URL field. While the user types it suggests URLs from the
+// request history; Up and Down move through them and Enter picks one.
+// Text set programmatically, e.g. from the params table, does not trigger
+// suggestions.
+type URLEntry struct {
+	widget.Entry
+
+	db          *storage.DB
+	window      fyne.Window
+	popup       *widget.PopUp
+	list        *suggestionList
+	suggestions []*storage.URLSuggestion
+	selected    int
+	navigating  bool
+	lookupTimer *time.Timer
+	lookupID    int
+
+	// OnSuggestionChosen is called after a suggestion replaced the text
+	OnSuggestionChosen func(suggestion *storage.URLSuggestion)
+
+	// OnCurlPasted is called instead of pasting text that is a curl command
+	OnCurlPasted func(command string)
+}
+
+// suggestionList takes keyboard focus while the popup is open, since the
+// popup is an overlay, and passes the keys on to the entry.
+type suggestionList struct {
+	widget.List
+	entry *URLEntry
+}
+
+func NewURLEntry(db *storage.DB, window fyne.Window) *URLEntry {
+	e := &URLEntry{db: db, window: window, selected: -1}
+	e.ExtendBaseWidget(e)
+
+	e.list = &suggestionList{entry: e}
+	e.list.Length = func() int {
+		return len(e.suggestions)
+	}
+	e.list.CreateItem = newSuggestionRow
+	e.list.UpdateItem = func(id widget.ListItemID, item fyne.CanvasObject) {
+		row := item.(*fyne.Container)
+		row.Objects[0].(*widget.Label).SetText(e.suggestions[id].URL)
+		row.Objects[1].(*widget.Label).SetText(e.suggestions[id].Method)
+	}
+	e.list.OnSelected = func(id widget.ListItemID) {
+		if !e.navigating {
+			e.choose(id)
+		}
+	}
+	e.list.ExtendBaseWidget(e.list)
+
+	e.popup = widget.NewPopUp(e.list, window.Canvas())
+	return e
+}
+
+func newSuggestionRow() fyne.CanvasObject {
+	methodLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	urlLabel := widget.NewLabel("")
+	urlLabel.Truncation = fyne.TextTruncateEllipsis
+	return container.NewBorder(nil, nil, methodLabel, nil, urlLabel)
+}
+
+func (e *URLEntry) TypedRune(r rune) {
+	e.Entry.TypedRune(r)
+	e.scheduleLookup()
+}
+
+func (e *URLEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.popup.Visible() {
+		switch key.Name {
+		case fyne.KeyDown:
+			e.move(1)
+			return
+		case fyne.KeyUp:
+			e.move(-1)
+			return
+		case fyne.KeyReturn, fyne.KeyEnter:
+			if e.selected >= 0 {
+				e.choose(e.selected)
+				return
+			}
+			e.hideSuggestions()
+		case fyne.KeyEscape:
+			e.hideSuggestions()
+			return
+		}
+	}
+
+	e.Entry.TypedKey(key)
+	if key.Name == fyne.KeyBackspace || key.Name == fyne.KeyDelete {
+		e.scheduleLookup()
+	}
+}
+
+func (e *URLEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if paste, ok := shortcut.(*fyne.ShortcutPaste); ok && e.OnCurlPasted != nil && paste.Clipboard != nil {
+		if text := strings.TrimSpace(paste.Clipboard.Content()); strings.HasPrefix(text, "curl ") {
+			e.OnCurlPasted(text)
+			return
+		}
+	}
+
+	e.Entry.TypedShortcut(shortcut)
+	if _, ok := shortcut.(*fyne.ShortcutPaste); ok {
+		e.scheduleLookup()
+	}
+}
+
+func (e *URLEntry) scheduleLookup() {
+	if e.lookupTimer != nil {
+		e.lookupTimer.Stop()
+	}
+	e.lookupID++
+	id := e.lookupID
+
+	text := strings.TrimSpace(e.Text)
+	if text == "" {
+		e.hideSuggestions()
+		return
+	}
+
+	e.lookupTimer = time.AfterFunc(urlLookupDelay, func() {
+		suggestions, err := e.db.GetDistinctURLs(text, urlSuggestionLimit)
+		if err != nil {
+			fmt.Printf("Error loading URL suggestions: %v\n", err)
+			return
+		}
+		fyne.Do(func() {
+			// A newer lookup or a choice made meanwhile wins
+			if id == e.lookupID {
+				e.showSuggestions(suggestions)
+			}
+		})
+	})
+}
+
+func (e *URLEntry) showSuggestions(suggestions []*storage.URLSuggestion) {
+	// Nothing left to complete when the only match is what was typed
+	if len(suggestions) == 0 || (len(suggestions) == 1 && suggestions[0].URL == e.Text) {
+		e.hideSuggestions()
+		return
+	}
+
+	e.suggestions = suggestions
+	e.selected = -1
+	e.list.UnselectAll()
+	e.list.Refresh()
+	e.list.ScrollToTop()
+
+	rowHeight := newSuggestionRow().MinSize().Height + theme.Padding()
+	e.popup.Resize(fyne.NewSize(e.Size().Width, rowHeight*float32(len(suggestions))))
+
+	position := fyne.CurrentApp().Driver().AbsolutePositionForObject(e)
+	e.popup.ShowAtPosition(position.AddXY(0, e.Size().Height))
+	e.window.Canvas().Focus(e.list)
+}
+
+func (e *URLEntry) hideSuggestions() {
+	if e.lookupTimer != nil {
+		e.lookupTimer.Stop()
+	}
+	e.lookupID++
+
+	if e.popup.Visible() {
+		e.popup.Hide()
+		e.window.Canvas().Focus(e)
+	}
+}
+
+func (e *URLEntry) move(delta int) {
+	next := e.selected + delta
+	if next < 0 || next >= len(e.suggestions) {
+		return
+	}
+	e.selected = next
+
+	e.navigating = true
+	e.list.Select(next)
+	e.navigating = false
+}
+
+func (e *URLEntry) choose(id widget.ListItemID) {
+	if id < 0 || id >= len(e.suggestions) {
+		return
+	}
+	suggestion := e.suggestions[id]
+
+	e.hideSuggestions()
+	e.SetText(suggestion.URL)
+	e.CursorColumn = len([]rune(suggestion.URL))
+	e.Refresh()
+
+	if e.OnSuggestionChosen != nil {
+		e.OnSuggestionChosen(suggestion)
+	}
+}
+
+func (l *suggestionList) FocusGained() {}
+
+func (l *suggestionList) FocusLost() {}
+
+func (l *suggestionList) TypedRune(r rune) {
+	l.entry.TypedRune(r)
+}
+
+func (l *suggestionList) TypedKey(key *fyne.KeyEvent) {
+	l.entry.TypedKey(key)
+}
@@ -0,0 +1,355 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"golem/secrets"
+	"golem/storage"
+	"regexp"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	storagefilter "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// variableNamePattern is what may appear between {{ and }}.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ValidVariableName reports whether name can be used in a {{name}}
+// placeholder.
+func ValidVariableName(name string) bool {
+	return variableNamePattern.MatchString(name)
+}
+
+const globalScopeLabel = "Globals"
+
+// VariablesDialog edits the global variables and those of each environment.
+// Switching scope saves the variables shown so far.
+type VariablesDialog struct {
+	db           *storage.DB
+	vault        *secrets.Vault
+	editor       *VariableEditor
+	scopeSelect  *widget.Select
+	deleteButton *widget.Button
+	exportButton *widget.Button
+	environments []*storage.Environment
+	scope        int // environment ID, 0 for the globals
+	parentWindow fyne.Window
+	onChanged    func()
+}
+
+// ShowVariablesDialog edits the variables substituted for {{name}} when a
+// request is sent. onChanged is called when environments are added or
+// removed.
+func ShowVariablesDialog(db *storage.DB, vault *secrets.Vault, onChanged func(), parentWindow fyne.Window) {
+	v := &VariablesDialog{
+		db:           db,
+		vault:        vault,
+		editor:       NewVariableEditor(),
+		parentWindow: parentWindow,
+		onChanged:    onChanged,
+	}
+
+	v.scopeSelect = widget.NewSelect(nil, func(label string) {
+		id := v.idForLabel(label)
+		if id == v.scope {
+			return
+		}
+		// Stay on the current scope until its variables are saved, so
+		// invalid ones can be fixed
+		v.scopeSelect.SetSelected(v.scopeLabel())
+		v.run(v.save, func() { v.selectScope(id) })
+	})
+
+	newButton := widget.NewButtonWithIcon("New", theme.ContentAddIcon(), v.newEnvironment)
+	v.deleteButton = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), v.deleteEnvironment)
+	importButton := widget.NewButtonWithIcon("Import", theme.UploadIcon(), v.importEnvironment)
+	v.exportButton = widget.NewButtonWithIcon("Export", theme.DownloadIcon(), v.exportEnvironment)
+
+	v.loadScopes(0)
+
+	hint := widget.NewLabel("Use {{name}} in the URL, header values or body. Environment variables override globals. Secret values are stored encrypted and never shown.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	top := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Scope"),
+			container.NewHBox(newButton, v.deleteButton, importButton, v.exportButton),
+			v.scopeSelect),
+		hint,
+	)
+
+	d := dialog.NewCustomWithoutButtons("Variables",
+		container.NewBorder(top, nil, nil, nil, container.NewVScroll(v.editor.GetContainer())),
+		parentWindow)
+
+	cancelButton := widget.NewButton("Cancel", d.Hide)
+	saveButton := widget.NewButton("Save", func() {
+		v.run(v.save, d.Hide)
+	})
+	saveButton.Importance = widget.HighImportance
+
+	d.SetButtons([]fyne.CanvasObject{cancelButton, saveButton})
+	d.Resize(fyne.NewSize(650, 450))
+	d.Show()
+}
+
+// loadScopes refreshes the scope list and shows the scope with id.
+func (v *VariablesDialog) loadScopes(id int) {
+	environments, err := v.db.GetEnvironments()
+	if err != nil {
+		dialog.ShowError(err, v.parentWindow)
+	}
+	v.environments = environments
+
+	options := []string{globalScopeLabel}
+	for _, environment := range environments {
+		options = append(options, environment.Name)
+	}
+	v.scopeSelect.SetOptions(options)
+	v.selectScope(id)
+}
+
+func (v *VariablesDialog) idForLabel(label string) int {
+	for _, environment := range v.environments {
+		if environment.Name == label {
+			return environment.ID
+		}
+	}
+	return 0
+}
+
+func (v *VariablesDialog) environment() *storage.Environment {
+	for _, environment := range v.environments {
+		if environment.ID == v.scope {
+			return environment
+		}
+	}
+	return nil
+}
+
+func (v *VariablesDialog) scopeLabel() string {
+	if environment := v.environment(); environment != nil {
+		return environment.Name
+	}
+	return globalScopeLabel
+}
+
+func (v *VariablesDialog) selectScope(id int) {
+	v.scope = id
+	if v.environment() == nil {
+		v.scope = 0
+	}
+	v.scopeSelect.SetSelected(v.scopeLabel())
+
+	if v.scope == 0 {
+		v.deleteButton.Disable()
+		v.exportButton.Disable()
+	} else {
+		v.deleteButton.Enable()
+		v.exportButton.Enable()
+	}
+
+	var variables []*storage.Variable
+	var err error
+	if v.scope == 0 {
+		variables, err = v.db.GetVariables()
+	} else {
+		variables, err = v.db.GetEnvironmentVariables(v.scope)
+	}
+	if err != nil {
+		dialog.ShowError(err, v.parentWindow)
+	}
+
+	v.editor.SetVariables(variables)
+}
+
+// run calls action, then onDone if it succeeded. When the action needs the
+// secrets, they are unlocked and it is tried again.
+func (v *VariablesDialog) run(action func() error, onDone func()) {
+	err := action()
+	if errors.Is(err, secrets.ErrLocked) {
+		UnlockSecrets(v.db, v.vault, v.parentWindow, func() { v.run(action, onDone) })
+		return
+	}
+	if err != nil {
+		dialog.ShowError(err, v.parentWindow)
+		return
+	}
+	if onDone != nil {
+		onDone()
+	}
+}
+
+// save stores the variables of the scope being shown.
+func (v *VariablesDialog) save() error {
+	variables, err := v.editor.GetVariables(v.vault)
+	if err != nil {
+		return err
+	}
+	if v.scope == 0 {
+		return v.db.ReplaceVariables(variables)
+	}
+	return v.db.ReplaceEnvironmentVariables(v.scope, variables)
+}
+
+func (v *VariablesDialog) changed() {
+	if v.onChanged != nil {
+		v.onChanged()
+	}
+}
+
+func (v *VariablesDialog) newEnvironment() {
+	v.run(v.save, v.showNewEnvironmentForm)
+}
+
+func (v *VariablesDialog) showNewEnvironmentForm() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. staging")
+	nameEntry.Validator = func(text string) error {
+		if text == "" {
+			return errors.New("enter a name")
+		}
+		if v.idForLabel(text) != 0 || text == globalScopeLabel || text == noEnvironmentLabel {
+			return fmt.Errorf("%q is already in use", text)
+		}
+		return nil
+	}
+
+	dialog.ShowForm("New Environment", "Create", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Name", nameEntry)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			environment, err := v.db.CreateEnvironment(nameEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, v.parentWindow)
+				return
+			}
+			v.loadScopes(environment.ID)
+			v.changed()
+		}, v.parentWindow)
+}
+
+func (v *VariablesDialog) deleteEnvironment() {
+	environment := v.environment()
+	if environment == nil {
+		return
+	}
+
+	dialog.ShowConfirm("Delete Environment",
+		fmt.Sprintf("Delete the environment %q and its variables?", environment.Name),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := v.db.DeleteEnvironment(environment.ID); err != nil {
+				dialog.ShowError(err, v.parentWindow)
+				return
+			}
+			v.loadScopes(0)
+			v.changed()
+		}, v.parentWindow)
+}
+
+// exportEnvironment leaves secret variables out of the file unless the user
+// chooses to include them, in plaintext.
+func (v *VariablesDialog) exportEnvironment() {
+	environment := v.environment()
+	if environment == nil {
+		return
+	}
+
+	v.run(v.save, func() {
+		variables, err := v.db.GetEnvironmentVariables(environment.ID)
+		if err != nil {
+			dialog.ShowError(err, v.parentWindow)
+			return
+		}
+		secretCount := 0
+		for _, variable := range variables {
+			if variable.Secret {
+				secretCount++
+			}
+		}
+		if secretCount == 0 {
+			v.saveExport(environment, false)
+			return
+		}
+
+		confirm := dialog.NewConfirm("Secret Variables",
+			fmt.Sprintf("%q has %d secret variable(s). Include their values in the file? They will be written in plaintext.", environment.Name, secretCount),
+			func(include bool) {
+				if !include {
+					v.saveExport(environment, false)
+					return
+				}
+				UnlockSecrets(v.db, v.vault, v.parentWindow, func() { v.saveExport(environment, true) })
+			}, v.parentWindow)
+		confirm.SetConfirmText("Include")
+		confirm.SetDismissText("Leave Out")
+		confirm.Show()
+	})
+}
+
+func (v *VariablesDialog) saveExport(environment *storage.Environment, includeSecrets bool) {
+	var decrypt func(string) (string, error)
+	if includeSecrets {
+		decrypt = v.vault.Decrypt
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, v.parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := v.db.ExportEnvironment(environment, writer.URI().Path(), decrypt); err != nil {
+			dialog.ShowError(err, v.parentWindow)
+		} else {
+			dialog.ShowInformation("Success", "Environment exported successfully", v.parentWindow)
+		}
+	}, v.parentWindow)
+	save.SetFileName(environment.Name + ".json")
+	save.Show()
+}
+
+func (v *VariablesDialog) importEnvironment() {
+	v.run(v.save, v.openImport)
+}
+
+func (v *VariablesDialog) openImport() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, v.parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		var environment *storage.Environment
+		v.run(func() error {
+			var err error
+			// Secrets in the file need the vault; run unlocks it and retries
+			if environment, err = v.db.ImportEnvironment(path, v.vault.Encrypt); err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+			return nil
+		}, func() {
+			v.loadScopes(environment.ID)
+			v.changed()
+		})
+	}, v.parentWindow)
+	open.SetFilter(storagefilter.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
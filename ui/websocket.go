@@ -0,0 +1,331 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	WebSocketSent     = "sent"
+	WebSocketReceived = "received"
+)
+
+const (
+	WebSocketText   = "text"
+	WebSocketBinary = "binary"
+	WebSocketPing   = "ping"
+	WebSocketPong   = "pong"
+	WebSocketClose  = "close"
+	WebSocketEvent  = "event"
+	WebSocketError  = "error"
+)
+
+// MaxWebSocketMessages caps the session log; the oldest entries are dropped.
+const MaxWebSocketMessages = 1000
+
+const (
+	payloadText   = "Text"
+	payloadHex    = "Binary (hex)"
+	payloadBase64 = "Binary (base64)"
+)
+
+// WebSocketMessage is one entry of a WebSocket session log: a frame sent or
+// received, or a connection event or error. Binary frames keep their payload
+// in Binary, everything else in Text.
+type WebSocketMessage struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction,omitempty"`
+	Type      string    `json:"type"`
+	Text      string    `json:"text,omitempty"`
+	Binary    []byte    `json:"binary,omitempty"`
+}
+
+// Size is the payload size in bytes.
+func (m WebSocketMessage) Size() int {
+	if m.Type == WebSocketBinary {
+		return len(m.Binary)
+	}
+	return len(m.Text)
+}
+
+// Summary describes the message in one line for the log.
+func (m WebSocketMessage) Summary() string {
+	arrow := "•"
+	switch {
+	case m.Type == WebSocketError:
+		arrow = "✗"
+	case m.Direction == WebSocketSent:
+		arrow = "→"
+	case m.Direction == WebSocketReceived:
+		arrow = "←"
+	}
+
+	text := m.Text
+	if m.Type == WebSocketBinary {
+		text = fmt.Sprintf("%d bytes: %s", len(m.Binary), hex.EncodeToString(m.Binary))
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	return fmt.Sprintf("%s %s %-6s %s", m.Time.Format("15:04:05.000"), arrow, m.Type, text)
+}
+
+// Details describes the message in full; binary payloads are shown both as
+// hex and as base64.
+func (m WebSocketMessage) Details() string {
+	header := fmt.Sprintf("%s  %s %s", m.Time.Format("2006-01-02 15:04:05.000"), m.Direction, m.Type)
+	if m.Type == WebSocketBinary {
+		return fmt.Sprintf("%s (%d bytes)\n\nHex:\n%s\n\nBase64:\n%s", header, len(m.Binary),
+			hex.EncodeToString(m.Binary), base64.StdEncoding.EncodeToString(m.Binary))
+	}
+	return header + "\n\n" + m.Text
+}
+
+// WebSocketPanel is the WebSocket tab: the URL and handshake headers, the
+// session log and a composer. The connection itself is left to the
+// callbacks; the Set and Add methods must be called on the main thread.
+type WebSocketPanel struct {
+	container     *fyne.Container
+	urlEntry      *widget.Entry
+	headersEditor *KeyValueEditor
+	statusLabel   *widget.Label
+	connectButton *widget.Button
+	closeButton   *widget.Button
+	pingButton    *widget.Button
+	sendButton    *widget.Button
+	saveButton    *widget.Button
+	logList       *widget.List
+	detailsEntry  *widget.Entry
+	composerEntry *widget.Entry
+	formatSelect  *widget.Select
+	messages      []WebSocketMessage
+	parentWindow  fyne.Window
+
+	OnConnect func(url string, headers []KeyValue)
+	OnSend    func(message WebSocketMessage)
+	OnPing    func()
+	OnClose   func()
+	// OnSave stores the session transcript in the history
+	OnSave func(messages []WebSocketMessage)
+}
+
+func NewWebSocketPanel(parentWindow fyne.Window) *WebSocketPanel {
+	p := &WebSocketPanel{parentWindow: parentWindow}
+
+	p.urlEntry = widget.NewEntry()
+	p.urlEntry.SetPlaceHolder("ws:// or wss:// URL...")
+	p.headersEditor = NewKeyValueEditor("Header", "Value", "Add Header")
+	p.statusLabel = widget.NewLabel("Disconnected")
+
+	p.connectButton = widget.NewButtonWithIcon("Connect", theme.MediaPlayIcon(), p.connect)
+	p.connectButton.Importance = widget.HighImportance
+	p.closeButton = widget.NewButtonWithIcon("Close", theme.MediaStopIcon(), func() {
+		if p.OnClose != nil {
+			p.OnClose()
+		}
+	})
+	p.pingButton = widget.NewButton("Ping", func() {
+		if p.OnPing != nil {
+			p.OnPing()
+		}
+	})
+	p.sendButton = widget.NewButtonWithIcon("Send", theme.MailSendIcon(), p.send)
+	p.saveButton = widget.NewButtonWithIcon("Save to History", theme.DocumentSaveIcon(), func() {
+		if p.OnSave != nil {
+			p.OnSave(p.messages)
+		}
+		p.saveButton.Disable()
+	})
+	clearButton := widget.NewButtonWithIcon("Clear Log", theme.ContentClearIcon(), func() {
+		p.SetMessages(nil)
+	})
+
+	p.logList = widget.NewList(
+		func() int {
+			return len(p.messages)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= len(p.messages) {
+				return
+			}
+			label := o.(*widget.Label)
+			message := p.messages[i]
+			switch message.Type {
+			case WebSocketError:
+				label.Importance = widget.DangerImportance
+			case WebSocketEvent, WebSocketClose:
+				label.Importance = widget.LowImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.SetText(message.Summary())
+		},
+	)
+
+	p.detailsEntry = widget.NewMultiLineEntry()
+	p.detailsEntry.Wrapping = fyne.TextWrapBreak
+	p.detailsEntry.SetPlaceHolder("Select a message to see it in full")
+	p.detailsEntry.Disable()
+	p.logList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(p.messages) {
+			p.detailsEntry.SetText(p.messages[id].Details())
+		}
+	}
+
+	p.composerEntry = widget.NewMultiLineEntry()
+	p.composerEntry.SetPlaceHolder("Message to send")
+	p.composerEntry.SetMinRowsVisible(3)
+	p.formatSelect = widget.NewSelect([]string{payloadText, payloadHex, payloadBase64}, nil)
+	p.formatSelect.SetSelected(payloadText)
+
+	topBar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(p.connectButton, p.closeButton, p.pingButton),
+		p.urlEntry,
+	)
+
+	logSplit := container.NewVSplit(p.logList, p.detailsEntry)
+	logSplit.SetOffset(0.7)
+
+	composer := container.NewBorder(nil, nil, nil,
+		container.NewVBox(p.formatSelect, p.sendButton),
+		p.composerEntry,
+	)
+
+	logSection := container.NewBorder(
+		container.NewBorder(nil, nil, nil, container.NewHBox(clearButton, p.saveButton), p.statusLabel),
+		composer,
+		nil,
+		nil,
+		logSplit,
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Log", logSection),
+		container.NewTabItem("Headers", p.headersEditor.GetContainer()),
+	)
+
+	p.container = container.NewBorder(topBar, nil, nil, nil, tabs)
+	p.SetDisconnected()
+	p.saveButton.Disable()
+	return p
+}
+
+func (p *WebSocketPanel) connect() {
+	text := strings.TrimSpace(p.urlEntry.Text)
+	// A URL built from {{variables}} is checked once they are substituted
+	if !strings.Contains(text, "{{") {
+		target, err := url.Parse(text)
+		if err != nil || (target.Scheme != "ws" && target.Scheme != "wss") || target.Host == "" {
+			dialog.ShowError(errors.New("enter a ws:// or wss:// URL"), p.parentWindow)
+			return
+		}
+	}
+	if p.OnConnect != nil {
+		p.OnConnect(text, p.headersEditor.GetPairs())
+	}
+}
+
+func (p *WebSocketPanel) send() {
+	message, err := composeMessage(p.formatSelect.Selected, p.composerEntry.Text)
+	if err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	if p.OnSend != nil {
+		p.OnSend(message)
+	}
+}
+
+// composeMessage builds the frame to send from the composer text, decoding
+// it for binary formats.
+func composeMessage(format, text string) (WebSocketMessage, error) {
+	message := WebSocketMessage{Direction: WebSocketSent, Type: WebSocketBinary}
+	var err error
+	switch format {
+	case payloadHex:
+		message.Binary, err = hex.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return message, fmt.Errorf("invalid hex: %w", err)
+		}
+	case payloadBase64:
+		message.Binary, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return message, fmt.Errorf("invalid base64: %w", err)
+		}
+	default:
+		message.Type = WebSocketText
+		message.Text = text
+	}
+	return message, nil
+}
+
+// SetConnecting disables Connect while the handshake is in progress.
+func (p *WebSocketPanel) SetConnecting() {
+	p.statusLabel.SetText("Connecting...")
+	p.connectButton.Disable()
+}
+
+// SetConnected enables the controls of an open connection; status is the
+// handshake response status.
+func (p *WebSocketPanel) SetConnected(status string) {
+	p.statusLabel.SetText("Connected (" + status + ")")
+	p.connectButton.Disable()
+	p.closeButton.Enable()
+	p.pingButton.Enable()
+	p.sendButton.Enable()
+}
+
+func (p *WebSocketPanel) SetDisconnected() {
+	p.statusLabel.SetText("Disconnected")
+	p.connectButton.Enable()
+	p.closeButton.Disable()
+	p.pingButton.Disable()
+	p.sendButton.Disable()
+}
+
+// AddMessage appends message to the log and scrolls to it.
+func (p *WebSocketPanel) AddMessage(message WebSocketMessage) {
+	p.messages = append(p.messages, message)
+	if len(p.messages) > MaxWebSocketMessages {
+		p.messages = p.messages[len(p.messages)-MaxWebSocketMessages:]
+	}
+	p.saveButton.Enable()
+	p.logList.Refresh()
+	p.logList.ScrollToBottom()
+}
+
+// SetMessages replaces the log, e.g. with a transcript from the history.
+func (p *WebSocketPanel) SetMessages(messages []WebSocketMessage) {
+	p.messages = messages
+	p.saveButton.Disable()
+	p.logList.UnselectAll()
+	p.detailsEntry.SetText("")
+	p.logList.Refresh()
+}
+
+func (p *WebSocketPanel) SetURL(url string) {
+	p.urlEntry.SetText(url)
+}
+
+func (p *WebSocketPanel) SetHeaders(headers []KeyValue) {
+	p.headersEditor.SetPairs(headers)
+}
+
+func (p *WebSocketPanel) GetContainer() *fyne.Container {
+	return p.container
+}
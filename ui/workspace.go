@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"golem/secrets"
+	"golem/storage"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	storagefilter "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// How a workspace file is imported
+const (
+	workspaceMerge   = "Merge into these"
+	workspaceReplace = "Replace these"
+)
+
+// ShowWorkspaceExport writes the collections, saved requests, variables,
+// environments and settings to a file for a backup or another machine,
+// asking first whether the values of secret variables, the proxy
+// credentials and the Authorization default headers go in too.
+func ShowWorkspaceExport(db *storage.DB, vault *secrets.Vault, parentWindow fyne.Window) {
+	secretCount, err := countSecretVariables(db)
+	if err != nil {
+		dialog.ShowError(err, parentWindow)
+		return
+	}
+	credentialCount, err := db.CountWorkspaceCredentials()
+	if err != nil {
+		dialog.ShowError(err, parentWindow)
+		return
+	}
+	if secretCount+credentialCount == 0 {
+		saveWorkspace(db, nil, parentWindow)
+		return
+	}
+
+	confirm := dialog.NewConfirm("Secret Values",
+		fmt.Sprintf("There are %d secret variable(s) and %d credential(s) in the proxy settings, default headers and saved requests. Include their values in the file? They will be written in plaintext.", secretCount, credentialCount),
+		func(include bool) {
+			switch {
+			case !include:
+				saveWorkspace(db, nil, parentWindow)
+			case secretCount == 0:
+				// Nothing to decrypt, so the secrets need not be unlocked
+				saveWorkspace(db, vault.Decrypt, parentWindow)
+			default:
+				UnlockSecrets(db, vault, parentWindow, func() { saveWorkspace(db, vault.Decrypt, parentWindow) })
+			}
+		}, parentWindow)
+	confirm.SetConfirmText("Include")
+	confirm.SetDismissText("Leave Out")
+	confirm.Show()
+}
+
+// countSecretVariables counts the secret variables, global and of every
+// environment.
+func countSecretVariables(db *storage.DB) (int, error) {
+	variables, err := db.GetVariables()
+	if err != nil {
+		return 0, err
+	}
+	environments, err := db.GetEnvironments()
+	if err != nil {
+		return 0, err
+	}
+	for _, environment := range environments {
+		environmentVariables, err := db.GetEnvironmentVariables(environment.ID)
+		if err != nil {
+			return 0, err
+		}
+		variables = append(variables, environmentVariables...)
+	}
+
+	count := 0
+	for _, variable := range variables {
+		if variable.Secret {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func saveWorkspace(db *storage.DB, decrypt func(string) (string, error), parentWindow fyne.Window) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := db.ExportWorkspace(writer.URI().Path(), decrypt); err != nil {
+			dialog.ShowError(err, parentWindow)
+		} else {
+			dialog.ShowInformation("Success", "Workspace exported successfully", parentWindow)
+		}
+	}, parentWindow)
+	save.SetFileName("golem-workspace.json")
+	save.Show()
+}
+
+// ShowWorkspaceImport reads a workspace file, asking whether it is merged
+// into the collections, saved requests, variables and environments here or
+// replaces them. onImported is called once it has been, to show the
+// changes; the settings in the file are stored either way.
+func ShowWorkspaceImport(db *storage.DB, vault *secrets.Vault, onImported func(), parentWindow fyne.Window) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		modeRadio := widget.NewRadioGroup([]string{workspaceMerge, workspaceReplace}, nil)
+		modeRadio.Required = true
+		modeRadio.SetSelected(workspaceMerge)
+		hint := widget.NewLabel("Merging updates those with the same names and adds the rest.\nReplacing deletes the collections, requests, variables and environments here first.")
+		hint.Importance = widget.LowImportance
+
+		dialog.ShowForm("Import Workspace", "Import", "Cancel",
+			[]*widget.FormItem{
+				widget.NewFormItem("Collections and variables", modeRadio),
+				widget.NewFormItem("", hint),
+			},
+			func(confirmed bool) {
+				if confirmed {
+					importWorkspace(db, vault, path, modeRadio.Selected == workspaceReplace, onImported, parentWindow)
+				}
+			}, parentWindow)
+	}, parentWindow)
+	open.SetFilter(storagefilter.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
+
+// importWorkspace imports the file at path, unlocking the secrets and trying
+// again when the file has secret values to store.
+func importWorkspace(db *storage.DB, vault *secrets.Vault, path string, replace bool, onImported func(), parentWindow fyne.Window) {
+	workspace, err := db.ImportWorkspace(path, replace, vault.Encrypt)
+	if errors.Is(err, secrets.ErrLocked) {
+		UnlockSecrets(db, vault, parentWindow, func() {
+			importWorkspace(db, vault, path, replace, onImported, parentWindow)
+		})
+		return
+	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("import failed: %w", err), parentWindow)
+		return
+	}
+
+	if onImported != nil {
+		onImported()
+	}
+	dialog.ShowInformation("Workspace Imported",
+		fmt.Sprintf("Imported %d collection(s), %d saved request(s), %d global variable(s), %d environment(s) and %d setting(s).",
+			len(workspace.Collections), len(workspace.Requests), len(workspace.Variables), len(workspace.Environments), len(workspace.Preferences)),
+		parentWindow)
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// xmlNode is a node of a parsed XML document, keeping the text of the
+// document as written: attribute quoting, entities, namespace prefixes and
+// CDATA sections come out unchanged.
+type xmlNode struct {
+	// raw is the markup of anything but an element, or its start tag
+	raw      string
+	element  bool
+	name     xml.Name
+	children []*xmlNode
+	// endTag is empty for an element written as <name/>
+	endTag string
+	// start and end are the offsets of the whole element in the document
+	start, end int64
+}
+
+// XMLSyntaxError is a document that is not well-formed, with the position
+// of the problem.
+type XMLSyntaxError struct {
+	Line, Column int
+	Err          error
+}
+
+func (e *XMLSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// IsXMLContentType reports whether contentType is XML, including types such
+// as application/atom+xml.
+func IsXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// looksLikeXML reports whether body starts with an XML declaration.
+func looksLikeXML(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), "<?xml")
+}
+
+// FormatXML returns body with one element per line, indented, or minified
+// with the whitespace between elements removed. An element holding text is
+// kept on one line as written, so mixed content and the text of CDATA
+// sections do not change. A document that is not well-formed is returned
+// unchanged with an *XMLSyntaxError.
+func FormatXML(body, view string) (string, error) {
+	if view != BodyViewPretty && view != BodyViewMinified {
+		return body, nil
+	}
+	nodes, err := parseXML(body)
+	if err != nil {
+		return body, err
+	}
+
+	indent, newline := "  ", "\n"
+	if view == BodyViewMinified {
+		indent, newline = "", ""
+	}
+	var out strings.Builder
+	for _, node := range nodes {
+		if !node.element && strings.TrimSpace(node.raw) == "" {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString(newline)
+		}
+		writeXMLNode(&out, body, node, 0, indent, newline)
+	}
+	return out.String(), nil
+}
+
+func writeXMLNode(out *strings.Builder, body string, node *xmlNode, depth int, indent, newline string) {
+	out.WriteString(strings.Repeat(indent, depth))
+	if !node.element {
+		out.WriteString(node.raw)
+		return
+	}
+
+	var children []*xmlNode
+	hasText := false
+	for _, child := range node.children {
+		if child.element || strings.TrimSpace(child.raw) != "" {
+			children = append(children, child)
+		}
+		if !child.element && isXMLText(child.raw) && strings.TrimSpace(child.raw) != "" {
+			hasText = true
+		}
+	}
+
+	switch {
+	case hasText:
+		out.WriteString(body[node.start:node.end])
+	case len(children) == 0:
+		out.WriteString(node.raw)
+		out.WriteString(node.endTag)
+	default:
+		out.WriteString(node.raw)
+		for _, child := range children {
+			out.WriteString(newline)
+			writeXMLNode(out, body, child, depth+1, indent, newline)
+		}
+		out.WriteString(newline)
+		out.WriteString(strings.Repeat(indent, depth))
+		out.WriteString(node.endTag)
+	}
+}
+
+// isXMLText reports whether raw is text or a CDATA section rather than a
+// comment, processing instruction or directive.
+func isXMLText(raw string) bool {
+	return !strings.HasPrefix(raw, "<") || strings.HasPrefix(raw, "<![CDATA[")
+}
+
+// parseXML reads the nodes at the top level of body. Tokens are read raw, so
+// namespace prefixes are neither resolved nor rewritten.
+func parseXML(body string) ([]*xmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	var (
+		top    []*xmlNode
+		stack  []*xmlNode
+		offset int64
+	)
+	add := func(node *xmlNode) {
+		if len(stack) == 0 {
+			top = append(top, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+		}
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, column := decoder.InputPos()
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				err = errors.New(syntaxErr.Msg)
+			}
+			return nil, &XMLSyntaxError{Line: line, Column: column, Err: err}
+		}
+		next := decoder.InputOffset()
+		raw := body[offset:next]
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{raw: raw, element: true, name: token.Name, start: offset}
+			add(node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			// RawToken leaves matching the tags to the caller
+			if len(stack) == 0 || stack[len(stack)-1].name != token.Name {
+				line, column := decoder.InputPos()
+				return nil, &XMLSyntaxError{Line: line, Column: column, Err: fmt.Errorf("unexpected end element %s", raw)}
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			node.endTag = raw
+			node.end = next
+		default:
+			add(&xmlNode{raw: raw})
+		}
+		offset = next
+	}
+
+	if len(stack) > 0 {
+		line, column := decoder.InputPos()
+		return nil, &XMLSyntaxError{Line: line, Column: column, Err: fmt.Errorf("unclosed element %s", stack[len(stack)-1].raw)}
+	}
+	return top, nil
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		pretty   string
+		minified string
+	}{
+		{
+			name:     "nested elements",
+			body:     `<?xml version="1.0"?><a><b><c/></b><d x='1'></d></a>`,
+			pretty:   "<?xml version=\"1.0\"?>\n<a>\n  <b>\n    <c/>\n  </b>\n  <d x='1'></d>\n</a>",
+			minified: `<?xml version="1.0"?><a><b><c/></b><d x='1'></d></a>`,
+		},
+		{
+			name:     "namespace prefixes",
+			body:     `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:Price xmlns:m="urn:x">1.5</m:Price></soap:Body></soap:Envelope>`,
+			pretty:   "<soap:Envelope xmlns:soap=\"http://www.w3.org/2003/05/soap-envelope\">\n  <soap:Body>\n    <m:Price xmlns:m=\"urn:x\">1.5</m:Price>\n  </soap:Body>\n</soap:Envelope>",
+			minified: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:Price xmlns:m="urn:x">1.5</m:Price></soap:Body></soap:Envelope>`,
+		},
+		{
+			name:     "mixed content",
+			body:     "<doc>\n<p>Some <b>bold</b>  text &amp; more</p>\n</doc>",
+			pretty:   "<doc>\n  <p>Some <b>bold</b>  text &amp; more</p>\n</doc>",
+			minified: "<doc><p>Some <b>bold</b>  text &amp; more</p></doc>",
+		},
+		{
+			name:     "cdata",
+			body:     "<a>\n  <script><![CDATA[if (a < b) {\n  go();\n}]]></script>\n</a>",
+			pretty:   "<a>\n  <script><![CDATA[if (a < b) {\n  go();\n}]]></script>\n</a>",
+			minified: "<a><script><![CDATA[if (a < b) {\n  go();\n}]]></script></a>",
+		},
+		{
+			name:     "comments",
+			body:     "<!-- top --><a><!-- inside --><b/></a>",
+			pretty:   "<!-- top -->\n<a>\n  <!-- inside -->\n  <b/>\n</a>",
+			minified: "<!-- top --><a><!-- inside --><b/></a>",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for view, want := range map[string]string{BodyViewPretty: tc.pretty, BodyViewMinified: tc.minified} {
+				got, err := FormatXML(tc.body, view)
+				if err != nil {
+					t.Fatalf("%s: %v", view, err)
+				}
+				if got != want {
+					t.Errorf("%s:\n--- got\n%s\n--- want\n%s", view, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatXMLSyntaxError(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		line, column int
+	}{
+		{"mismatched end tag", "<a>\n  <b></c>\n</a>", 2, 10},
+		{"unclosed element", "<a>\n  <b></b>\n", 3, 1},
+		{"bad attribute", "<a>\n<b x=1/></a>", 2, 7},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FormatXML(tc.body, BodyViewPretty)
+			var syntaxErr *XMLSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got error %v, want an *XMLSyntaxError", err)
+			}
+			if syntaxErr.Line != tc.line || syntaxErr.Column != tc.column {
+				t.Errorf("got line %d, column %d, want line %d, column %d (%v)", syntaxErr.Line, syntaxErr.Column, tc.line, tc.column, err)
+			}
+			if got != tc.body {
+				t.Errorf("got %q, want the body unchanged", got)
+			}
+		})
+	}
+}
+
+func TestFormatXMLOtherViews(t *testing.T) {
+	body := "<a><b/></a>"
+	if got, err := FormatXML(body, BodyViewRaw); err != nil || got != body {
+		t.Errorf("got %q, %v, want the body unchanged", got, err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golem/storage"
+	"golem/ui"
+)
+
+// placeholderPattern matches {{name}}, allowing spaces inside the braces
+// and arguments after the name as in {{randomInt 1 100}}.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)((?:\s+[^\s{}]+)*)\s*\}\}`)
+
+// parsePlaceholder returns the variable name and arguments of a placeholder.
+func parsePlaceholder(placeholder string) (string, []string) {
+	match := placeholderPattern.FindStringSubmatch(placeholder)
+	return match[1], strings.Fields(match[2])
+}
+
+// secretMask is shown in place of secret values, e.g. in history.
+const secretMask = "••••"
+
+// variableResolver substitutes variable values and remembers the names it
+// could not resolve. Secret values are decrypted only as they are
+// substituted; with no decrypt function they are masked instead. Built-in
+// dynamic variables are left for applyDynamicVariables.
+type variableResolver struct {
+	variables map[string]*storage.Variable
+	decrypt   func(string) (string, error)
+	missing   map[string]bool
+	err       error
+}
+
+func newVariableResolver(variables map[string]*storage.Variable, decrypt func(string) (string, error)) *variableResolver {
+	return &variableResolver{variables: variables, decrypt: decrypt, missing: make(map[string]bool)}
+}
+
+func (r *variableResolver) resolve(text string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name, args := parsePlaceholder(placeholder)
+		variable, ok := r.variables[name]
+		if !ok || len(args) > 0 {
+			if _, dynamic := dynamicVariables[name]; !dynamic {
+				r.missing[name] = true
+			}
+			return placeholder
+		}
+		if !variable.Secret {
+			return variable.Value
+		}
+		if r.decrypt == nil {
+			return secretMask
+		}
+		value, err := r.decrypt(variable.Value)
+		if err != nil {
+			if r.err == nil {
+				r.err = err
+			}
+			return placeholder
+		}
+		return value
+	})
+}
+
+// Missing returns the unresolved variable names in alphabetical order.
+func (r *variableResolver) Missing() []string {
+	names := make([]string, 0, len(r.missing))
+	for name := range r.missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maskVariables substitutes the placeholders in text, masking secret values.
+func maskVariables(text string, variables map[string]*storage.Variable) string {
+	return newVariableResolver(variables, nil).resolve(text)
+}
+
+// resolveVariables returns a copy of request with the placeholders in the
+// URL, header values, body, form values and credentials substituted, and
+// the names of any variables without a value. The error is from decrypting
+// a secret value.
+func resolveVariables(request RequestInfo, variables map[string]*storage.Variable, decrypt func(string) (string, error)) (RequestInfo, []string, error) {
+	r := newVariableResolver(variables, decrypt)
+	request = substituteRequest(request, r.resolve)
+	request.URL = applyPathVariables(request.URL, request.PathVariables)
+	request.PathVariables = nil
+	return request, r.Missing(), r.err
+}
+
+// substituteRequest returns a copy of request with substitute applied to
+// every field that may contain placeholders.
+func substituteRequest(request RequestInfo, substitute func(string) string) RequestInfo {
+	request.URL = substitute(request.URL)
+	request.Body = substitute(request.Body)
+
+	pathVariables := make([]ui.KeyValue, len(request.PathVariables))
+	for i, pathVariable := range request.PathVariables {
+		pathVariable.Value = substitute(pathVariable.Value)
+		pathVariables[i] = pathVariable
+	}
+	request.PathVariables = pathVariables
+
+	headers := make([]ui.KeyValue, len(request.Headers))
+	for i, header := range request.Headers {
+		// A disabled header is not sent, so its placeholders need no value
+		if !header.Disabled {
+			header.Value = substitute(header.Value)
+		}
+		headers[i] = header
+	}
+	request.Headers = headers
+
+	formFields := make([]ui.FormField, len(request.FormFields))
+	for i, field := range request.FormFields {
+		if !field.IsFile {
+			field.Value = substitute(field.Value)
+		}
+		formFields[i] = field
+	}
+	request.FormFields = formFields
+
+	// OAuth2 settings are left alone since they identify the stored token
+	request.Auth.Username = substitute(request.Auth.Username)
+	request.Auth.Password = substitute(request.Auth.Password)
+	switch request.Auth.Type {
+	case ui.AuthTypeBearer:
+		request.Auth.Token = substitute(request.Auth.Token)
+	case ui.AuthTypeHMAC:
+		request.Auth.StringToSign = substitute(request.Auth.StringToSign)
+		request.Auth.Secret = substitute(request.Auth.Secret)
+	}
+
+	return request
+}
+
+// applyPathVariables puts the values of the path variables in place of the
+// :name and {name} segments of rawURL, escaped for a path apart from the
+// {{placeholders}} left in them. Segments without a value stay as written.
+func applyPathVariables(rawURL string, pathVariables []ui.KeyValue) string {
+	return ui.ReplacePathVariables(rawURL, func(name string) (string, bool) {
+		for _, pathVariable := range pathVariables {
+			if pathVariable.Key == name && pathVariable.Value != "" {
+				return escapePathValue(pathVariable.Value), true
+			}
+		}
+		return "", false
+	})
+}
+
+func escapePathValue(value string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringIndex(value, -1) {
+		b.WriteString(url.PathEscape(value[last:match[0]]))
+		b.WriteString(value[match[0]:match[1]])
+		last = match[1]
+	}
+	b.WriteString(url.PathEscape(value[last:]))
+	return b.String()
+}
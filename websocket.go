@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golem/ui"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketCloseTimeout is how long Close waits for the server to answer the
+// close frame before dropping the connection.
+const webSocketCloseTimeout = 5 * time.Second
+
+var webSocketCloseCodes = map[int]string{
+	websocket.CloseNormalClosure:           "normal closure",
+	websocket.CloseGoingAway:               "going away",
+	websocket.CloseProtocolError:           "protocol error",
+	websocket.CloseUnsupportedData:         "unsupported data",
+	websocket.CloseNoStatusReceived:        "no status",
+	websocket.CloseAbnormalClosure:         "abnormal closure",
+	websocket.CloseInvalidFramePayloadData: "invalid payload",
+	websocket.ClosePolicyViolation:         "policy violation",
+	websocket.CloseMessageTooBig:           "message too big",
+	websocket.CloseInternalServerErr:       "internal server error",
+}
+
+// webSocketSession is an open WebSocket connection. Received frames and
+// connection events are passed to onMessage from the reading goroutine, and
+// onClosed is called once the connection is gone.
+type webSocketSession struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	closing   atomic.Bool
+	onMessage func(ui.WebSocketMessage)
+	onClosed  func()
+}
+
+// dialWebSocket opens a connection to request.URL with the request's
+// headers, proxy, TLS settings and timeout for the handshake.
+func dialWebSocket(request *RequestInfo, onMessage func(ui.WebSocketMessage), onClosed func()) (*webSocketSession, *http.Response, error) {
+	transport, err := newTransport(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer := websocket.Dialer{
+		Proxy:            transport.Proxy,
+		NetDialContext:   transport.DialContext,
+		TLSClientConfig:  transport.TLSClientConfig,
+		HandshakeTimeout: request.Timeout,
+		Jar:              request.CookieJar,
+	}
+
+	header := http.Header{}
+	for _, h := range request.Headers {
+		if h.Disabled {
+			continue
+		}
+		header.Add(h.Key, h.Value)
+	}
+
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	conn, response, err := dialer.DialContext(ctx, request.URL, header)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && response != nil {
+			return nil, response, fmt.Errorf("the server refused the upgrade: %s", response.Status)
+		}
+		return nil, response, proxyError(err)
+	}
+
+	s := &webSocketSession{conn: conn, onMessage: onMessage, onClosed: onClosed}
+	conn.SetPingHandler(func(data string) error {
+		s.received(ui.WebSocketPing, data)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		s.received(ui.WebSocketPong, data)
+		return nil
+	})
+	go s.read()
+	return s, response, nil
+}
+
+func (s *webSocketSession) received(messageType, text string) {
+	s.onMessage(ui.WebSocketMessage{Time: time.Now(), Direction: ui.WebSocketReceived, Type: messageType, Text: text})
+}
+
+func (s *webSocketSession) event(messageType, text string) {
+	s.onMessage(ui.WebSocketMessage{Time: time.Now(), Type: messageType, Text: text})
+}
+
+func (s *webSocketSession) read() {
+	defer s.onClosed()
+	defer s.conn.Close()
+
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			switch {
+			case errors.As(err, &closeErr):
+				s.received(ui.WebSocketClose, describeCloseCode(closeErr.Code, closeErr.Text))
+			case !s.closing.Load():
+				s.event(ui.WebSocketError, err.Error())
+			}
+			s.event(ui.WebSocketEvent, "Disconnected")
+			return
+		}
+
+		message := ui.WebSocketMessage{Time: time.Now(), Direction: ui.WebSocketReceived, Type: ui.WebSocketText, Text: string(data)}
+		if messageType == websocket.BinaryMessage {
+			message.Type = ui.WebSocketBinary
+			message.Text = ""
+			message.Binary = data
+		}
+		s.onMessage(message)
+	}
+}
+
+// send writes a text or binary frame and logs it once it is written.
+func (s *webSocketSession) send(message ui.WebSocketMessage) {
+	s.writeMu.Lock()
+	var err error
+	if message.Type == ui.WebSocketBinary {
+		err = s.conn.WriteMessage(websocket.BinaryMessage, message.Binary)
+	} else {
+		err = s.conn.WriteMessage(websocket.TextMessage, []byte(message.Text))
+	}
+	s.writeMu.Unlock()
+
+	if err != nil {
+		s.event(ui.WebSocketError, fmt.Sprintf("Send failed: %v", err))
+		return
+	}
+	message.Time = time.Now()
+	s.onMessage(message)
+}
+
+func (s *webSocketSession) ping() {
+	if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+		s.event(ui.WebSocketError, fmt.Sprintf("Ping failed: %v", err))
+		return
+	}
+	s.onMessage(ui.WebSocketMessage{Time: time.Now(), Direction: ui.WebSocketSent, Type: ui.WebSocketPing})
+}
+
+// close starts the closing handshake. The connection is dropped if the
+// server does not answer in time.
+func (s *webSocketSession) close() {
+	if s.closing.Swap(true) {
+		return
+	}
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+		s.conn.Close()
+		return
+	}
+	s.onMessage(ui.WebSocketMessage{Time: time.Now(), Direction: ui.WebSocketSent, Type: ui.WebSocketClose,
+		Text: describeCloseCode(websocket.CloseNormalClosure, "")})
+	time.AfterFunc(webSocketCloseTimeout, func() {
+		s.conn.Close()
+	})
+}
+
+func describeCloseCode(code int, reason string) string {
+	text := fmt.Sprint(code)
+	if name, ok := webSocketCloseCodes[code]; ok {
+		text += " (" + name + ")"
+	}
+	if reason != "" {
+		text += ": " + reason
+	}
+	return text
+}